@@ -0,0 +1,113 @@
+package cachesim
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/traefik/paerser/cli"
+	"github.com/traefik/traefik/v3/pkg/advancedcache/config"
+	"github.com/traefik/traefik/v3/pkg/advancedcache/simulator"
+)
+
+// NewCmd builds a new CacheSim command.
+func NewCmd() *cli.Command {
+	return &cli.Command{
+		Name: "cachesim",
+		Description: `Replays an access log against one or more advancedcache configurations offline and reports
+hit ratio, byte hit ratio, upstream request rate and memory usage over time.
+
+  traefik cachesim --log access.log --config lru=lru.yaml --config wtinylfu=wtinylfu.yaml`,
+		Configuration: nil,
+		Run:           runCmd,
+	}
+}
+
+// candidates collects repeated --config flags, each one is either "path" or "name=path".
+type candidates []string
+
+func (c *candidates) String() string { return strings.Join(*c, ",") }
+
+func (c *candidates) Set(value string) error {
+	*c = append(*c, value)
+	return nil
+}
+
+func runCmd(args []string) error {
+	var configs candidates
+
+	fs := flag.NewFlagSet("cachesim", flag.ContinueOnError)
+	logFlag := fs.String("log", "", "Path to the access log (JSON or common format), - for stdin.")
+	formatFlag := fs.String("format", simulator.FormatAuto, "Access log format: json, common or empty to detect it per line.")
+	intervalFlag := fs.Duration("interval", time.Minute, "Reporting interval of the virtual clock.")
+	scaleFlag := fs.Float64("scale", 1, "Multiplier applied to the storage size and to every response size.")
+	timelineFlag := fs.Bool("timeline", true, "Print the per interval timeline in addition to the summary.")
+	fs.Var(&configs, "config", "Cache configuration to evaluate as path or name=path, may be repeated.")
+	fs.SetOutput(os.Stderr)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if *logFlag == "" {
+		return errors.New("--log is required")
+	}
+	if len(configs) == 0 {
+		return errors.New("at least one --config is required")
+	}
+
+	candidates := make([]simulator.Candidate, 0, len(configs))
+	for _, value := range configs {
+		name, path, found := strings.Cut(value, "=")
+		if !found {
+			path = value
+			name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+		}
+
+		cfg, err := config.LoadConfig(path)
+		if err != nil {
+			return fmt.Errorf("load config %s: %w", path, err)
+		}
+		candidates = append(candidates, simulator.Candidate{Name: name, Cfg: cfg})
+	}
+
+	in := os.Stdin
+	if *logFlag != "-" {
+		f, err := os.Open(*logFlag)
+		if err != nil {
+			return fmt.Errorf("open access log: %w", err)
+		}
+		defer func() { _ = f.Close() }()
+		in = f
+	}
+
+	requests, skipped, err := simulator.ReadLog(in, *formatFlag)
+	if err != nil {
+		return err
+	}
+	if len(requests) == 0 {
+		return fmt.Errorf("no requests were read from %s (skipped lines: %d)", *logFlag, skipped)
+	}
+
+	fmt.Printf("Replaying %d requests (skipped lines: %d) from %s to %s.\n\n",
+		len(requests), skipped,
+		requests[0].Time.UTC().Format(time.RFC3339),
+		requests[len(requests)-1].Time.UTC().Format(time.RFC3339),
+	)
+
+	sim := simulator.New(requests, simulator.Options{Interval: *intervalFlag, Scale: *scaleFlag})
+	reports := sim.Run(context.Background(), candidates)
+
+	if err = simulator.WriteSummary(os.Stdout, reports); err != nil {
+		return err
+	}
+	if *timelineFlag {
+		fmt.Println()
+		return simulator.WriteTimeline(os.Stdout, reports)
+	}
+	return nil
+}
@@ -24,6 +24,7 @@ import (
 	"github.com/spiffe/go-spiffe/v2/workloadapi"
 	"github.com/traefik/paerser/cli"
 	"github.com/traefik/traefik/v3/cmd"
+	"github.com/traefik/traefik/v3/cmd/cachesim"
 	"github.com/traefik/traefik/v3/cmd/healthcheck"
 	cmdVersion "github.com/traefik/traefik/v3/cmd/version"
 	tcli "github.com/traefik/traefik/v3/pkg/cli"
@@ -34,6 +35,7 @@ import (
 	"github.com/traefik/traefik/v3/pkg/logs"
 	"github.com/traefik/traefik/v3/pkg/metrics"
 	"github.com/traefik/traefik/v3/pkg/middlewares/accesslog"
+	advancedcache "github.com/traefik/traefik/v3/pkg/middlewares/advancedcache"
 	"github.com/traefik/traefik/v3/pkg/provider/acme"
 	"github.com/traefik/traefik/v3/pkg/provider/aggregator"
 	"github.com/traefik/traefik/v3/pkg/provider/tailscale"
@@ -80,6 +82,12 @@ Complete documentation is available at https://traefik.io`,
 		os.Exit(1)
 	}
 
+	err = cmdTraefik.AddCommand(cachesim.NewCmd())
+	if err != nil {
+		stdlog.Println(err)
+		os.Exit(1)
+	}
+
 	err = cli.Execute(cmdTraefik)
 	if err != nil {
 		log.Error().Err(err).Msg("Command error")
@@ -129,6 +137,17 @@ func runCmd(staticConfiguration *static.Configuration) error {
 		staticConfiguration.Ping.WithContext(ctx)
 	}
 
+	// The standalone cache listener is started before the routers are built, so advancedCache middlewares
+	// referring to the same config file share its cache.
+	var cacheListener *advancedcache.Standalone
+	if cacheConfig := staticConfiguration.AdvancedCache; cacheConfig != nil {
+		cacheListener, err = advancedcache.NewStandalone(ctx, cacheConfig.Address, cacheConfig.ConfigPath, time.Duration(cacheConfig.ShutdownTimeout))
+		if err != nil {
+			return fmt.Errorf("setting up advanced cache listener: %w", err)
+		}
+		cacheListener.Start()
+	}
+
 	svr.Start(ctx)
 	defer svr.Close()
 
@@ -164,6 +183,9 @@ func runCmd(staticConfiguration *static.Configuration) error {
 	}
 
 	svr.Wait()
+	if cacheListener != nil {
+		cacheListener.Wait()
+	}
 	log.Info().Msg("Shutting down")
 	return nil
 }
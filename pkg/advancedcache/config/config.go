@@ -5,6 +5,7 @@ import (
 	"gopkg.in/yaml.v3"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 )
 
@@ -14,6 +15,33 @@ const (
 	Test = "test"
 )
 
+const (
+	EvictionPolicyLRU      = "lru"      // Plain per-shard LRU with TinyLFU admission against a single victim.
+	EvictionPolicyWTinyLFU = "wtinylfu" // Admission window LRU in front of a segmented main LRU (W-TinyLFU).
+)
+
+// Priority classes of rules, entries of a lower class are evicted first.
+const (
+	PriorityLow      = "low"
+	PriorityNormal   = "normal" // Default class.
+	PriorityHigh     = "high"
+	PriorityCritical = "critical"
+)
+
+const (
+	PriorityClassLow = iota
+	PriorityClassNormal
+	PriorityClassHigh
+	PriorityClassCritical
+)
+
+var priorityClasses = map[string]int{
+	PriorityLow:      PriorityClassLow,
+	PriorityNormal:   PriorityClassNormal,
+	PriorityHigh:     PriorityClassHigh,
+	PriorityCritical: PriorityClassCritical,
+}
+
 type TraefikIntermediateConfig struct {
 	ConfigPath string `yaml:"configPath" mapstructure:"configPath"`
 }
@@ -45,7 +73,10 @@ type CacheBox struct {
 	Persistence *Persistence     `yaml:"persistence"`
 	Refresh     *Refresh         `yaml:"refresh"`
 	Eviction    *Eviction        `yaml:"eviction"`
+	Expiration  *Expiration      `yaml:"expiration"`
 	Storage     *Storage         `yaml:"storage"`
+	LargeObject *LargeObject     `yaml:"large_object"`
+	Shadow      *Shadow          `yaml:"shadow"`
 	Logs        Logs             `yaml:"logs"`
 	K8S         K8S              `yaml:"k8s"`
 	Metrics     Metrics          `yaml:"metrics"`
@@ -95,6 +126,18 @@ type Proxy struct {
 	To      string        `yaml:"to"`
 	Rate    int           `yaml:"rate"`    // Rate limiting reqs to backend per second.
 	Timeout time.Duration `yaml:"timeout"` // Timeout for requests to backend.
+	// Concurrency replaces the fixed rate limit by an adaptive limit of in-flight backend requests.
+	Concurrency *Concurrency `yaml:"concurrency"`
+}
+
+type Concurrency struct {
+	Enabled      bool    `yaml:"enabled"`
+	Initial      int     `yaml:"initial"`       // Initial limit of in-flight backend requests (default 20).
+	Min          int     `yaml:"min"`           // The limit never goes below (default 1).
+	Max          int     `yaml:"max"`           // The limit never goes above (default 1000).
+	Tolerance    float64 `yaml:"tolerance"`     // Latency growth over the baseline tolerated before the limit shrinks, 2 means twice (default 2).
+	Backoff      float64 `yaml:"backoff"`       // Multiplicative decrease on a backend error or timeout, 0.9 means -10% (default 0.9).
+	RefreshShare float64 `yaml:"refresh_share"` // Share of the limit available to background refreshes, they are shed first (default 0.5).
 }
 
 type Dump struct {
@@ -117,8 +160,49 @@ type Preallocation struct {
 }
 
 type Eviction struct {
-	Enabled   bool    `yaml:"enabled"`
-	Threshold float64 `yaml:"threshold"` // 0.9 means 90%
+	Enabled    bool           `yaml:"enabled"`
+	Threshold  float64        `yaml:"threshold"`   // 0.9 means 90%
+	Policy     string         `yaml:"policy"`      // "lru" (default) or "wtinylfu"
+	SampleSize int64          `yaml:"sample_size"` // Num of TinyLFU increments after which frequencies are halved.
+	Window     EvictionWindow `yaml:"window"`      // Used only by the "wtinylfu" policy.
+}
+
+func (e *Eviction) IsWTinyLFU() bool {
+	return e.Policy == EvictionPolicyWTinyLFU
+}
+
+type EvictionWindow struct {
+	Size       float64 `yaml:"size"`        // Initial share of the admission window, 0.01 means 1%.
+	Protected  float64 `yaml:"protected"`   // Share of the main segment reserved for the protected LRU, 0.8 means 80%.
+	Adaptive   bool    `yaml:"adaptive"`    // Hill-climb the window size by the observed hit ratio.
+	SampleSize int64   `yaml:"sample_size"` // Num of lookups per hill-climbing step.
+}
+
+type Expiration struct {
+	MaxAge   time.Duration `yaml:"max_age"`  // Hard max age of an entry since its last update, it is never served after (0 = unlimited).
+	Interval time.Duration `yaml:"interval"` // How often expired entries are removed (default 1s).
+	// StaleIfError keeps entries past their max age this long more, they are served only when
+	// the backend fetch fails or is shed by the concurrency limit (0 = expired entries are removed at once).
+	StaleIfError time.Duration `yaml:"stale_if_error"`
+}
+
+// MaxAge returns the hard max age of entries of the rule: the rule value overrides the default one, 0 means unlimited.
+func (c *Cache) MaxAge(rule *Rule) time.Duration {
+	if rule != nil && rule.MaxAge > 0 {
+		return rule.MaxAge
+	}
+	if c.Cache.Expiration != nil {
+		return c.Cache.Expiration.MaxAge
+	}
+	return 0
+}
+
+// StaleIfError returns how long entries past their max age are kept as a fallback for failed backend fetches.
+func (c *Cache) StaleIfError() time.Duration {
+	if c.Cache.Expiration != nil {
+		return c.Cache.Expiration.StaleIfError
+	}
+	return 0
 }
 
 type Storage struct {
@@ -126,12 +210,40 @@ type Storage struct {
 	Size uint   `yaml:"size"` // 21474836480=2gb(bytes)
 }
 
+type Shadow struct {
+	Workers  int `yaml:"workers"`  // Num of concurrent verification fetches (default 2).
+	Queue    int `yaml:"queue"`    // Num of pending verifications, samples over it are dropped (default 1024).
+	Examples int `yaml:"examples"` // Num of the latest divergences kept per rule (default 10).
+}
+
+type LargeObject struct {
+	Threshold int64 `yaml:"threshold"`  // Responses larger than this (bytes) are streamed to the client instead of being buffered (0 = disabled).
+	Store     bool  `yaml:"store"`      // Store streamed responses in chunks, otherwise they are passed through and not cached.
+	ChunkSize int   `yaml:"chunk_size"` // Size of chunks a streamed response is stored in (default 256KiB).
+	MaxSize   int64 `yaml:"max_size"`   // Streamed responses larger than this are not stored (0 = unlimited).
+}
+
+const defaultChunkSize = 256 << 10
+
+// ChunkBytes returns the size of chunks a streamed response is read and stored in.
+func (l *LargeObject) ChunkBytes() int {
+	if l.ChunkSize > 0 {
+		return l.ChunkSize
+	}
+	return defaultChunkSize
+}
+
+// IsLargeObjectEnabled reports whether responses above the threshold are streamed.
+func (c *Cache) IsLargeObjectEnabled() bool {
+	return c.Cache.LargeObject != nil && c.Cache.LargeObject.Threshold > 0
+}
+
 type Refresh struct {
 	Enabled bool `yaml:"enabled"`
 	// TTL - refresh TTL (max time life of response item in cache without refreshing).
 	TTL      time.Duration `yaml:"ttl"`       // e.g. "1d" (responses with 200 status code)
 	Rate     int           `yaml:"rate"`      // Rate limiting to external backend.
-	ScanRate int           `yaml:"scan_rate"` // Rate limiting of num scans items per second.
+	ScanRate int           `yaml:"scan_rate"` // Max num of due items taken from the refresh queue per second.
 	// beta определяет коэффициент, используемый для вычисления случайного момента обновления кэша.
 	// Чем выше beta, тем чаще кэш будет обновляться до истечения TTL.
 	// Формула взята из подхода "stochastic cache expiration" (см. Google Staleness paper):
@@ -162,11 +274,42 @@ type Gzip struct {
 }
 
 type Rule struct {
-	Gzip       Gzip         `yaml:"gzip"`
-	CacheKey   RuleKey      `yaml:"cache_key"`
-	CacheValue RuleValue    `yaml:"cache_value"`
-	Refresh    *RuleRefresh `yaml:"refresh"`
-	PathBytes  []byte       // Virtual field
+	Gzip       Gzip          `yaml:"gzip"`
+	CacheKey   RuleKey       `yaml:"cache_key"`
+	CacheValue RuleValue     `yaml:"cache_value"`
+	Refresh    *RuleRefresh  `yaml:"refresh"`
+	Eviction   RuleEviction  `yaml:"eviction"`
+	MaxAge     time.Duration `yaml:"max_age"` // Overrides expiration.max_age for the rule (0 = use the default).
+	Shadow     *RuleShadow   `yaml:"shadow"`
+	ESI        *RuleESI      `yaml:"esi"`
+	PathBytes  []byte        // Virtual field
+}
+
+type RuleESI struct {
+	Enabled     bool          `yaml:"enabled"`
+	Concurrency int           `yaml:"concurrency"`  // Num of fragments resolved at once per response (default 8).
+	Timeout     time.Duration `yaml:"timeout"`      // Deadline of the whole assembly including nested fragments (default 2s).
+	MaxIncludes int           `yaml:"max_includes"` // Includes of a single document over it fail (default 64).
+	MaxDepth    int           `yaml:"max_depth"`    // Nesting depth of fragments which are ESI documents themselves (default 2).
+}
+
+const (
+	ShadowComparePayload = "payload" // The whole cached payload (status, stored headers and body) must match.
+	ShadowCompareJSON    = "json"    // JSON bodies must match, except for the ignored fields.
+)
+
+type RuleShadow struct {
+	SampleRate   float64    `yaml:"sample_rate"`   // Share of hits verified against the upstream, 0.01 means 1% (0 = disabled).
+	Compare      string     `yaml:"compare"`       // "payload" (default) or "json".
+	IgnoreFields []string   `yaml:"ignore_fields"` // Dotted paths of JSON fields ignored by the "json" comparison, "*" matches any key or index.
+	IgnorePaths  [][]string // Virtual field
+}
+
+type RuleEviction struct {
+	MaxShare      float64 `yaml:"max_share"` // Max share of storage.size the rule may occupy, 0.2 means 20% (0 = no quota).
+	Priority      string  `yaml:"priority"`  // "low", "normal" (default), "high" or "critical".
+	MaxBytes      int64   // Virtual field
+	PriorityClass int     // Virtual field
 }
 
 type RuleKey struct {
@@ -181,13 +324,21 @@ type RuleValue struct {
 	HeadersMap map[string]struct{} // Virtual field
 }
 
+func isRangeHeader(name string) bool {
+	return strings.EqualFold(name, "Range") || strings.EqualFold(name, "If-Range")
+}
+
 func LoadConfig(path string) (*Cache, error) {
 	dir, err := os.Getwd()
 	if err != nil {
 		return nil, err
 	}
 
-	path, err = filepath.Abs(filepath.Clean(filepath.Join(dir, path)))
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(dir, path)
+	}
+
+	path, err = filepath.Abs(filepath.Clean(path))
 	if err != nil {
 		return nil, fmt.Errorf("failed to resolve absolute config filepath: %w", err)
 	}
@@ -217,6 +368,9 @@ func LoadConfig(path string) (*Cache, error) {
 		// Request headers
 		keyHeadersMap := make(map[string]struct{}, len(rule.CacheKey.Headers))
 		for _, header := range rule.CacheKey.Headers {
+			if isRangeHeader(header) {
+				continue // ranges are served from the full cached response
+			}
 			keyHeadersMap[header] = struct{}{}
 		}
 		rule.CacheKey.HeadersMap = keyHeadersMap
@@ -227,10 +381,61 @@ func LoadConfig(path string) (*Cache, error) {
 			valueHeadersMap[header] = struct{}{}
 		}
 		rule.CacheValue.HeadersMap = valueHeadersMap
+
+		// Eviction quota and priority
+		if rule.Eviction.Priority == "" {
+			rule.Eviction.Priority = PriorityNormal
+		}
+		class, ok := priorityClasses[rule.Eviction.Priority]
+		if !ok {
+			return nil, fmt.Errorf("rule %s: unknown eviction priority %q", rulePath, rule.Eviction.Priority)
+		}
+		rule.Eviction.PriorityClass = class
+
+		if rule.Eviction.MaxShare < 0 || rule.Eviction.MaxShare > 1 {
+			return nil, fmt.Errorf("rule %s: eviction max_share must be within [0, 1], got %v", rulePath, rule.Eviction.MaxShare)
+		}
+		rule.Eviction.MaxBytes = int64(float64(cfg.Cache.Storage.Size) * rule.Eviction.MaxShare)
+
+		// Shadow verification
+		if shadow := rule.Shadow; shadow != nil {
+			if shadow.SampleRate < 0 || shadow.SampleRate > 1 {
+				return nil, fmt.Errorf("rule %s: shadow sample_rate must be within [0, 1], got %v", rulePath, shadow.SampleRate)
+			}
+			switch shadow.Compare {
+			case "":
+				shadow.Compare = ShadowComparePayload
+			case ShadowComparePayload, ShadowCompareJSON:
+			default:
+				return nil, fmt.Errorf("rule %s: unknown shadow compare mode %q", rulePath, shadow.Compare)
+			}
+			for _, field := range shadow.IgnoreFields {
+				shadow.IgnorePaths = append(shadow.IgnorePaths, strings.Split(field, "."))
+			}
+		}
+
+		// Edge Side Includes
+		if esi := rule.ESI; esi != nil {
+			if esi.Concurrency < 0 || esi.Timeout < 0 || esi.MaxIncludes < 0 || esi.MaxDepth < 0 {
+				return nil, fmt.Errorf("rule %s: esi concurrency, timeout, max_includes and max_depth must not be negative", rulePath)
+			}
+		}
 	}
 
 	cfg.Cache.Proxy.FromUrl = []byte(cfg.Cache.Proxy.From)
 
+	if c := cfg.Cache.Proxy.Concurrency; c != nil {
+		if c.RefreshShare < 0 || c.RefreshShare > 1 {
+			return nil, fmt.Errorf("proxy concurrency refresh_share must be within [0, 1], got %v", c.RefreshShare)
+		}
+		if c.Backoff < 0 || c.Backoff >= 1 {
+			return nil, fmt.Errorf("proxy concurrency backoff must be within [0, 1), got %v", c.Backoff)
+		}
+		if c.Min > 0 && c.Max > 0 && c.Min > c.Max {
+			return nil, fmt.Errorf("proxy concurrency min %d is greater than max %d", c.Min, c.Max)
+		}
+	}
+
 	cfg.Cache.LifeTime.EscapeMaxReqDurationHeaderBytes = []byte(cfg.Cache.LifeTime.EscapeMaxReqDurationHeader)
 
 	return cfg, nil
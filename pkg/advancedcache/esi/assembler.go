@@ -0,0 +1,236 @@
+package esi
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/traefik/traefik/v3/pkg/advancedcache/config"
+	"github.com/traefik/traefik/v3/pkg/advancedcache/model"
+	"github.com/traefik/traefik/v3/pkg/advancedcache/repository"
+	"github.com/traefik/traefik/v3/pkg/advancedcache/storage"
+)
+
+const (
+	defaultConcurrency = 8
+	defaultTimeout     = 2 * time.Second
+	defaultMaxIncludes = 64
+	defaultMaxDepth    = 2
+)
+
+var (
+	errTooManyIncludes = errors.New("esi: too many includes")
+	errInvalidSrc      = errors.New("esi: include src must be a path of the same upstream")
+)
+
+// Assembler builds documents of rules with enabled ESI: every <esi:include> is resolved as its own cache entry
+// (with the rule and TTL of the fragment path) through the same storage and backend. A fragment path without
+// a rule is fetched from the backend on every assembly and is not cached.
+//
+// Fragments are requested without client headers, so they are never compressed and are shared by all clients.
+type Assembler struct {
+	ctx     context.Context
+	cfg     *config.Cache
+	storage storage.Storage
+	backend repository.Backender
+}
+
+func NewAssembler(ctx context.Context, cfg *config.Cache, storage storage.Storage, backend repository.Backender) *Assembler {
+	return &Assembler{
+		ctx:     ctx,
+		cfg:     cfg,
+		storage: storage,
+		backend: backend,
+	}
+}
+
+// IsEnabled reports whether documents of the rule are assembled.
+func IsEnabled(rule *config.Rule) bool {
+	return rule != nil && rule.ESI != nil && rule.ESI.Enabled
+}
+
+// Assemble resolves includes of the document within the rule deadline. A failed include fails the whole
+// document unless it has onerror="continue", then it is omitted.
+func (a *Assembler) Assemble(rule *config.Rule, body []byte) ([]byte, error) {
+	opts := options(rule.ESI)
+
+	ctx, cancel := context.WithTimeout(a.ctx, opts.Timeout)
+	defer cancel()
+
+	return a.assemble(ctx, opts, body, 1)
+}
+
+func options(cfg *config.RuleESI) config.RuleESI {
+	opts := *cfg
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = defaultConcurrency
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = defaultTimeout
+	}
+	if opts.MaxIncludes <= 0 {
+		opts.MaxIncludes = defaultMaxIncludes
+	}
+	if opts.MaxDepth <= 0 {
+		opts.MaxDepth = defaultMaxDepth
+	}
+	return opts
+}
+
+func (a *Assembler) assemble(ctx context.Context, opts config.RuleESI, body []byte, depth int) ([]byte, error) {
+	if !HasTags(body) {
+		return body, nil
+	}
+	segments, err := Parse(body)
+	if err != nil {
+		return nil, err
+	}
+
+	type result struct {
+		body []byte
+		err  error
+	}
+	var (
+		results  = make([]chan result, len(segments))
+		sem      = make(chan struct{}, opts.Concurrency)
+		includes int
+	)
+	for i, segment := range segments {
+		if !segment.IsInclude() {
+			continue
+		}
+		if includes++; includes > opts.MaxIncludes {
+			return nil, errTooManyIncludes
+		}
+
+		results[i] = make(chan result, 1)
+		go func(src string, out chan<- result) {
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			case <-ctx.Done():
+				out <- result{err: ctx.Err()}
+				return
+			}
+
+			fragment, rule, err := a.fragment(ctx, src)
+			if err == nil && depth < opts.MaxDepth && IsEnabled(rule) {
+				fragment, err = a.assemble(ctx, opts, fragment, depth+1)
+			}
+			out <- result{body: fragment, err: err}
+		}(segment.Src, results[i])
+	}
+
+	// all results are awaited, so no goroutine outlives the assembly except the backend fetches
+	var (
+		buf      = bytes.NewBuffer(make([]byte, 0, len(body)))
+		firstErr error
+	)
+	for i, segment := range segments {
+		if !segment.IsInclude() {
+			buf.Write(segment.Literal)
+			continue
+		}
+		r := <-results[i]
+		if r.err != nil {
+			if !segment.ContinueOnError && firstErr == nil {
+				firstErr = fmt.Errorf("esi: include %q: %w", segment.Src, r.err)
+			}
+			continue
+		}
+		buf.Write(r.body)
+	}
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	return buf.Bytes(), nil
+}
+
+// fragment resolves an include src within the deadline. A backend fetch which exceeded the deadline is
+// not canceled: it completes in background and the fragment is still cached for the next assembly.
+func (a *Assembler) fragment(ctx context.Context, src string) ([]byte, *config.Rule, error) {
+	u, err := url.Parse(src)
+	if err != nil || u.Scheme != "" || u.Host != "" || !strings.HasPrefix(u.Path, "/") {
+		return nil, nil, errInvalidSrc
+	}
+	path, query := []byte(u.Path), []byte(u.RawQuery)
+	rule := model.MatchRule(a.cfg, path)
+
+	type result struct {
+		body []byte
+		err  error
+	}
+	out := make(chan result, 1)
+	go func() {
+		body, err := a.resolve(rule, path, query)
+		out <- result{body: body, err: err}
+	}()
+
+	select {
+	case <-ctx.Done():
+		return nil, nil, ctx.Err()
+	case r := <-out:
+		return r.body, rule, r.err
+	}
+}
+
+func (a *Assembler) resolve(rule *config.Rule, path, query []byte) ([]byte, error) {
+	headers := make([][2][]byte, 0)
+
+	if rule == nil {
+		status, _, body, releaser, err := a.backend.Fetch(nil, path, query, &headers)
+		defer releaser()
+		if err != nil {
+			return nil, err
+		} else if status != http.StatusOK {
+			return nil, fmt.Errorf("upstream status code %d", status)
+		}
+		return bytes.Clone(body), nil // the body is released with the response
+	}
+
+	entry, err := model.NewEntryManual(a.cfg, path, query, &headers, a.backend.RevalidatorMaker())
+	if err != nil {
+		return nil, err
+	}
+	if cached, hit := a.storage.Get(entry); hit {
+		return payloadBody(cached)
+	}
+
+	status, responseHeaders, body, releaser, err := a.backend.Fetch(rule, path, query, &headers)
+	defer releaser()
+	if err != nil {
+		return nil, err
+	} else if status != http.StatusOK {
+		return nil, fmt.Errorf("upstream status code %d", status)
+	}
+
+	entry.SetPayload(path, query, &headers, responseHeaders, body, status)
+	entry.TouchUpdatedAt()
+
+	// the entry must not be used after Set, but its payload memory stays valid
+	fragment, err := payloadBody(entry)
+	if err != nil {
+		return nil, err
+	}
+	a.storage.Set(entry)
+
+	return fragment, nil
+}
+
+// payloadBody returns the body of the entry, it refers to the payload memory which is never modified.
+func payloadBody(entry *model.Entry) ([]byte, error) {
+	queryHeaders, responseHeaders, chunks, _, _, releaser, err := entry.PayloadChunks()
+	defer releaser(queryHeaders, responseHeaders)
+	if err != nil {
+		return nil, err
+	}
+	if len(chunks) == 1 {
+		return chunks[0], nil
+	}
+	return bytes.Join(chunks, nil), nil
+}
@@ -0,0 +1,140 @@
+package esi
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/traefik/traefik/v3/pkg/advancedcache/config"
+	"github.com/traefik/traefik/v3/pkg/advancedcache/model"
+	"github.com/traefik/traefik/v3/pkg/advancedcache/repository"
+	"github.com/traefik/traefik/v3/pkg/advancedcache/storage"
+)
+
+// mapStorage keeps entries by key, only Get and Set are used by the assembler.
+type mapStorage struct {
+	storage.Storage
+	mu      sync.Mutex
+	entries map[uint64]*model.Entry
+}
+
+func (s *mapStorage) Get(entry *model.Entry) (*model.Entry, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	cached, ok := s.entries[entry.MapKey()]
+	return cached, ok
+}
+
+func (s *mapStorage) Set(entry *model.Entry) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries[entry.MapKey()] = entry
+	return true
+}
+
+// pathBackend answers with the body of the requested path, a missing path fails.
+type pathBackend struct {
+	mu      sync.Mutex
+	bodies  map[string]string
+	delay   time.Duration
+	fetched map[string]int
+}
+
+func (b *pathBackend) fetch(_ *config.Rule, path []byte, _ []byte, _ *[][2][]byte) (int, *[][2][]byte, []byte, func(), error) {
+	time.Sleep(b.delay)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.fetched[string(path)]++
+	body, ok := b.bodies[string(path)]
+	if !ok {
+		return 0, nil, nil, func() {}, errors.New("connection refused")
+	}
+	return http.StatusOK, &[][2][]byte{}, []byte(body), func() {}, nil
+}
+
+func (b *pathBackend) Fetch(rule *config.Rule, path []byte, query []byte, queryHeaders *[][2][]byte) (int, *[][2][]byte, []byte, func(), error) {
+	return b.fetch(rule, path, query, queryHeaders)
+}
+
+func (b *pathBackend) FetchStream(rule *config.Rule, path []byte, query []byte, queryHeaders *[][2][]byte) (int, *[][2][]byte, []byte, io.Reader, int, func(), error) {
+	status, headers, body, release, err := b.fetch(rule, path, query, queryHeaders)
+	return status, headers, body, nil, len(body), release, err
+}
+
+func (b *pathBackend) RevalidatorMaker() func(*config.Rule, []byte, []byte, *[][2][]byte) (int, *[][2][]byte, []byte, func(), error) {
+	return b.fetch
+}
+
+func (b *pathBackend) Limiter() *repository.Limiter {
+	return nil
+}
+
+func newTestAssembler(bodies map[string]string) (*Assembler, *pathBackend, *config.Rule) {
+	page := &config.Rule{PathBytes: []byte("/page"), ESI: &config.RuleESI{Enabled: true, Timeout: time.Second}}
+	nested := &config.Rule{PathBytes: []byte("/fragments/nav"), ESI: &config.RuleESI{Enabled: true}}
+	cfg := &config.Cache{Cache: &config.CacheBox{Rules: map[string]*config.Rule{
+		"/page":             page,
+		"/fragments/header": {PathBytes: []byte("/fragments/header")},
+		"/fragments/nav":    nested,
+		"/fragments/item":   {PathBytes: []byte("/fragments/item")},
+	}}}
+	backend := &pathBackend{bodies: bodies, fetched: make(map[string]int)}
+	db := &mapStorage{entries: make(map[uint64]*model.Entry)}
+	return NewAssembler(context.Background(), cfg, db, backend), backend, page
+}
+
+func TestAssembleCachesFragments(t *testing.T) {
+	a, backend, page := newTestAssembler(map[string]string{
+		"/fragments/header": "<h1>title</h1>",
+		"/fragments/nav":    `<nav><esi:include src="/fragments/item"/></nav>`,
+		"/fragments/item":   "<a>item</a>",
+		"/fragments/plain":  "<p>uncached</p>",
+	})
+	doc := `<esi:include src="/fragments/header"/><esi:include src="/fragments/nav"/>` +
+		`<esi:include src="/fragments/plain"/><esi:include src="/fragments/missing" onerror="continue"/>!`
+
+	for i := 0; i < 2; i++ {
+		body, err := a.Assemble(page, []byte(doc))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := "<h1>title</h1><nav><a>item</a></nav><p>uncached</p>!"; string(body) != want {
+			t.Fatalf("unexpected document:\n got %s\nwant %s", body, want)
+		}
+	}
+
+	// fragments with a rule are fetched once, the one without a rule on every assembly
+	for path, want := range map[string]int{"/fragments/header": 1, "/fragments/nav": 1, "/fragments/item": 1, "/fragments/plain": 2} {
+		if got := backend.fetched[path]; got != want {
+			t.Fatalf("%s: expected %d fetches, got %d", path, want, got)
+		}
+	}
+}
+
+func TestAssembleFailures(t *testing.T) {
+	a, backend, page := newTestAssembler(map[string]string{"/fragments/header": "<h1>title</h1>"})
+
+	if _, err := a.Assemble(page, []byte(`<esi:include src="/fragments/missing"/>`)); err == nil {
+		t.Fatal("expected a failed include without onerror=continue to fail the document")
+	}
+	if _, err := a.Assemble(page, []byte(`<esi:include src="https://example.com/fragments/header"/>`)); !errors.Is(err, errInvalidSrc) {
+		t.Fatalf("expected an external src to be rejected, got %v", err)
+	}
+
+	page.ESI.MaxIncludes = 1
+	if _, err := a.Assemble(page, []byte(strings.Repeat(`<esi:include src="/fragments/header"/>`, 2))); !errors.Is(err, errTooManyIncludes) {
+		t.Fatalf("expected too many includes, got %v", err)
+	}
+	page.ESI.MaxIncludes = 0
+
+	backend.delay, page.ESI.Timeout = 100*time.Millisecond, 10*time.Millisecond
+	body, err := a.Assemble(page, []byte(`[<esi:include src="/fragments/plain" onerror="continue"/>]`))
+	if err != nil || string(body) != "[]" {
+		t.Fatalf("expected a late include to be omitted, got %q, %v", body, err)
+	}
+}
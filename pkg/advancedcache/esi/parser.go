@@ -0,0 +1,120 @@
+package esi
+
+import (
+	"bytes"
+	"errors"
+	"html"
+)
+
+var (
+	includeOpen = []byte("<esi:include")
+	removeOpen  = []byte("<esi:remove>")
+	removeClose = []byte("</esi:remove>")
+	includeEnd  = []byte("</esi:include>")
+	tagPrefix   = []byte("<esi:")
+)
+
+var (
+	errUnclosedTag     = errors.New("esi: unclosed tag")
+	errIncludeNoSource = errors.New("esi: include without src")
+)
+
+// Segment is either a literal part of the document or an include.
+type Segment struct {
+	Literal         []byte
+	Src             string // Not empty for an include
+	ContinueOnError bool   // onerror="continue": a failed include is omitted instead of failing the document
+}
+
+// IsInclude reports whether the segment is an include.
+func (s Segment) IsInclude() bool {
+	return s.Src != ""
+}
+
+// HasTags is a cheap check whether the document may contain ESI markup at all.
+func HasTags(body []byte) bool {
+	return bytes.Contains(body, tagPrefix)
+}
+
+// Parse splits the document into literals and includes. <esi:remove> blocks (fallback content for
+// clients without ESI) are dropped. Literals refer to the document memory.
+func Parse(body []byte) (segments []Segment, err error) {
+	for len(body) > 0 {
+		at := bytes.Index(body, tagPrefix)
+		if at < 0 {
+			segments = append(segments, Segment{Literal: body})
+			break
+		}
+		if at > 0 {
+			segments = append(segments, Segment{Literal: body[:at]})
+		}
+		body = body[at:]
+
+		switch {
+		case bytes.HasPrefix(body, removeOpen):
+			end := bytes.Index(body, removeClose)
+			if end < 0 {
+				return nil, errUnclosedTag
+			}
+			body = body[end+len(removeClose):]
+
+		case bytes.HasPrefix(body, includeOpen):
+			end := bytes.IndexByte(body, '>')
+			if end < 0 {
+				return nil, errUnclosedTag
+			}
+			tag := body[len(includeOpen):end]
+			body = body[end+1:]
+
+			selfClosed := bytes.HasSuffix(tag, []byte("/"))
+			if selfClosed {
+				tag = tag[:len(tag)-1]
+			} else if bytes.HasPrefix(bytes.TrimLeft(body, " \t\r\n"), includeEnd) {
+				body = bytes.TrimLeft(body, " \t\r\n")[len(includeEnd):]
+			}
+
+			attrs := parseAttributes(tag)
+			if attrs["src"] == "" {
+				return nil, errIncludeNoSource
+			}
+			segments = append(segments, Segment{
+				Src:             attrs["src"],
+				ContinueOnError: attrs["onerror"] == "continue",
+			})
+
+		default:
+			// unsupported ESI tags are passed through as is
+			segments = append(segments, Segment{Literal: body[:len(tagPrefix)]})
+			body = body[len(tagPrefix):]
+		}
+	}
+	return segments, nil
+}
+
+// parseAttributes parses name="value" (or name='value') pairs, values are HTML-unescaped.
+func parseAttributes(tag []byte) map[string]string {
+	attrs := make(map[string]string, 2)
+	for {
+		tag = bytes.TrimLeft(tag, " \t\r\n")
+		eq := bytes.IndexByte(tag, '=')
+		if eq <= 0 || eq+1 >= len(tag) {
+			return attrs
+		}
+		name := string(bytes.TrimSpace(tag[:eq]))
+		tag = bytes.TrimLeft(tag[eq+1:], " \t\r\n")
+		if len(tag) == 0 {
+			return attrs
+		}
+
+		quote := tag[0]
+		if quote != '"' && quote != '\'' {
+			return attrs
+		}
+		end := bytes.IndexByte(tag[1:], quote)
+		if end < 0 {
+			return attrs
+		}
+		attrs[name] = html.UnescapeString(string(tag[1 : end+1]))
+		tag = tag[end+2:]
+	}
+}
@@ -0,0 +1,56 @@
+package esi
+
+import (
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	doc := `<html><esi:include src="/fragments/header?lang=en&amp;v=2"/>` +
+		`<esi:remove><a href="/header">header</a></esi:remove>` +
+		`<main>body</main>` +
+		`<esi:include src='/fragments/footer' onerror="continue"></esi:include>` +
+		`<esi:vars>$(HTTP_HOST)</esi:vars></html>`
+
+	segments, err := Parse([]byte(doc))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var (
+		literals string
+		includes []Segment
+	)
+	for _, s := range segments {
+		if s.IsInclude() {
+			includes = append(includes, s)
+			literals += "|"
+		} else {
+			literals += string(s.Literal)
+		}
+	}
+
+	if want := `<html>|<main>body</main>|<esi:vars>$(HTTP_HOST)</esi:vars></html>`; literals != want {
+		t.Fatalf("unexpected literals:\n got %s\nwant %s", literals, want)
+	}
+	if len(includes) != 2 {
+		t.Fatalf("expected 2 includes, got %d", len(includes))
+	}
+	if includes[0].Src != "/fragments/header?lang=en&v=2" || includes[0].ContinueOnError {
+		t.Fatalf("unexpected first include: %+v", includes[0])
+	}
+	if includes[1].Src != "/fragments/footer" || !includes[1].ContinueOnError {
+		t.Fatalf("unexpected second include: %+v", includes[1])
+	}
+}
+
+func TestParseMalformed(t *testing.T) {
+	for _, doc := range []string{
+		`<esi:include src="/a"`,
+		`<esi:remove>fallback`,
+		`<esi:include onerror="continue"/>`,
+	} {
+		if _, err := Parse([]byte(doc)); err == nil {
+			t.Fatalf("expected an error for %q", doc)
+		}
+	}
+}
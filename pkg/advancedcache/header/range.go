@@ -0,0 +1,107 @@
+package header
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const bytesUnit = "bytes="
+
+// ByteRange is an inclusive range of body bytes.
+type ByteRange struct {
+	Start int64
+	End   int64
+}
+
+// Len returns the number of bytes in the range.
+func (r ByteRange) Len() int64 {
+	return r.End - r.Start + 1
+}
+
+// ContentRange formats the Content-Range header value of the range.
+func (r ByteRange) ContentRange(size int64) string {
+	buf := make([]byte, 0, 48)
+	buf = append(buf, "bytes "...)
+	buf = strconv.AppendInt(buf, r.Start, 10)
+	buf = append(buf, '-')
+	buf = strconv.AppendInt(buf, r.End, 10)
+	buf = append(buf, '/')
+	buf = strconv.AppendInt(buf, size, 10)
+	return string(buf)
+}
+
+// UnsatisfiedContentRange formats the Content-Range header value of a 416 response.
+func UnsatisfiedContentRange(size int64) string {
+	return "bytes */" + strconv.FormatInt(size, 10)
+}
+
+// ParseRange parses the Range header against a body of the given size.
+// Only a single byte range is served: ok=false means the header must be ignored and the full body is served
+// (no header, another unit, multiple ranges or a malformed value). satisfiable=false means 416.
+func ParseRange(value string, size int64) (r ByteRange, ok bool, satisfiable bool) {
+	if value == "" || !strings.HasPrefix(value, bytesUnit) {
+		return r, false, false
+	}
+	spec := strings.TrimSpace(value[len(bytesUnit):])
+	if spec == "" || strings.Contains(spec, ",") {
+		return r, false, false
+	}
+
+	dash := strings.IndexByte(spec, '-')
+	if dash < 0 {
+		return r, false, false
+	}
+	first, last := strings.TrimSpace(spec[:dash]), strings.TrimSpace(spec[dash+1:])
+
+	if first == "" {
+		// suffix range: the last N bytes
+		n, err := strconv.ParseInt(last, 10, 64)
+		if err != nil || n < 0 {
+			return r, false, false
+		}
+		if n == 0 || size == 0 {
+			return r, true, false
+		}
+		return ByteRange{Start: max(size-n, 0), End: size - 1}, true, true
+	}
+
+	start, err := strconv.ParseInt(first, 10, 64)
+	if err != nil || start < 0 {
+		return r, false, false
+	}
+	end := size - 1
+	if last != "" {
+		if end, err = strconv.ParseInt(last, 10, 64); err != nil || end < start {
+			return r, false, false
+		}
+	}
+	if start >= size {
+		return r, true, false
+	}
+	return ByteRange{Start: start, End: min(end, size-1)}, true, true
+}
+
+// IfRangeMatches reports whether the If-Range precondition holds for a response with the given ETag
+// and Last-Modified (unix nano), so the range may be served. An entity tag must match strongly,
+// a date must match the last modification exactly (at the second precision of the header).
+func IfRangeMatches(ifRange string, etag string, lastModified int64) bool {
+	if ifRange == "" {
+		return true
+	}
+	if strings.HasPrefix(ifRange, "W/") {
+		return false
+	}
+	if strings.HasPrefix(ifRange, `"`) {
+		return etag != "" && !strings.HasPrefix(etag, "W/") && ifRange == etag
+	}
+
+	t, err := http.ParseTime(ifRange)
+	if err != nil {
+		if t, err = time.Parse(time.RFC1123, ifRange); err != nil {
+			return false
+		}
+	}
+	return t.Unix() == time.Unix(0, lastModified).Unix()
+}
@@ -0,0 +1,70 @@
+package header
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestParseRange(t *testing.T) {
+	tests := []struct {
+		value       string
+		wantRange   ByteRange
+		ok          bool
+		satisfiable bool
+	}{
+		{value: "", ok: false},
+		{value: "items=0-1", ok: false},
+		{value: "bytes=0-1,4-5", ok: false},
+		{value: "bytes=5-1", ok: false},
+		{value: "bytes=abc", ok: false},
+		{value: "bytes=0-3", wantRange: ByteRange{Start: 0, End: 3}, ok: true, satisfiable: true},
+		{value: "bytes=4-", wantRange: ByteRange{Start: 4, End: 9}, ok: true, satisfiable: true},
+		{value: "bytes=8-100", wantRange: ByteRange{Start: 8, End: 9}, ok: true, satisfiable: true},
+		{value: "bytes=-3", wantRange: ByteRange{Start: 7, End: 9}, ok: true, satisfiable: true},
+		{value: "bytes=-30", wantRange: ByteRange{Start: 0, End: 9}, ok: true, satisfiable: true},
+		{value: "bytes=10-", ok: true, satisfiable: false},
+		{value: "bytes=-0", ok: true, satisfiable: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.value, func(t *testing.T) {
+			r, ok, satisfiable := ParseRange(test.value, 10)
+			if ok != test.ok || satisfiable != test.satisfiable {
+				t.Fatalf("expected ok=%v satisfiable=%v, got ok=%v satisfiable=%v", test.ok, test.satisfiable, ok, satisfiable)
+			}
+			if satisfiable && r != test.wantRange {
+				t.Fatalf("expected %+v, got %+v", test.wantRange, r)
+			}
+		})
+	}
+}
+
+func TestIfRangeMatches(t *testing.T) {
+	modified := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		desc    string
+		ifRange string
+		etag    string
+		want    bool
+	}{
+		{desc: "no precondition", want: true},
+		{desc: "same strong etag", ifRange: `"v1"`, etag: `"v1"`, want: true},
+		{desc: "another etag", ifRange: `"v1"`, etag: `"v2"`, want: false},
+		{desc: "weak etag", ifRange: `W/"v1"`, etag: `W/"v1"`, want: false},
+		{desc: "no etag", ifRange: `"v1"`, want: false},
+		{desc: "same date", ifRange: modified.Format(http.TimeFormat), want: true},
+		{desc: "same date as Last-Modified is written", ifRange: modified.Format(time.RFC1123), want: true},
+		{desc: "older date", ifRange: modified.Add(-time.Hour).Format(http.TimeFormat), want: false},
+		{desc: "malformed", ifRange: "yesterday", want: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			if got := IfRangeMatches(test.ifRange, test.etag, modified.UnixNano()); got != test.want {
+				t.Fatalf("expected %v, got %v", test.want, got)
+			}
+		})
+	}
+}
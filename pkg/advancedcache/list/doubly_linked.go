@@ -117,6 +117,7 @@ func (l *List[T]) Remove(e *Element[T]) {
 
 	e.prev.next = e.next
 	e.next.prev = e.prev
+	e.list = nil
 
 	atomic.AddInt64(&l.len, -1)
 }
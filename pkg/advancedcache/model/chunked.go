@@ -0,0 +1,82 @@
+package model
+
+import (
+	"sync/atomic"
+)
+
+// packedPayload is the packed request+response of an entry. The body of a large object is not packed,
+// it is kept in chunks as it was streamed from the backend, so it never needs a single huge allocation.
+type packedPayload struct {
+	bytes  []byte
+	chunks [][]byte // nil for regular entries
+}
+
+func (p *packedPayload) weight() int64 {
+	weight := int64(cap(p.bytes))
+	for _, chunk := range p.chunks {
+		weight += int64(cap(chunk))
+	}
+	return weight
+}
+
+func bodySize(chunks [][]byte) (size int64) {
+	for _, chunk := range chunks {
+		size += int64(len(chunk))
+	}
+	return size
+}
+
+// SetChunkedPayload packs the payload of a large object, its body is kept in the given chunks as is.
+func (e *Entry) SetChunkedPayload(
+	path, query []byte,
+	queryHeaders *[][2][]byte,
+	headers *[][2][]byte,
+	chunks [][]byte,
+	status int,
+) {
+	if chunks == nil {
+		chunks = [][]byte{}
+	}
+	e.payload.Store(&packedPayload{
+		bytes:  packPayload(path, query, queryHeaders, headers, nil, status),
+		chunks: chunks,
+	})
+	atomic.StoreInt64(&e.isCompressed, 0)
+}
+
+// IsChunked reports whether the entry is a large object with the body kept in chunks.
+func (e *Entry) IsChunked() bool {
+	ptr := e.payload.Load()
+	return ptr != nil && ptr.chunks != nil
+}
+
+// PayloadChunks unpacks the payload like Payload does, but returns the body as a list of chunks
+// (a single one for regular entries), both are taken from the same payload version.
+func (e *Entry) PayloadChunks() (
+	queryHeaders *[][2][]byte,
+	responseHeaders *[][2][]byte,
+	chunks [][]byte,
+	size int64,
+	status int,
+	releaseFn func(q, h *[][2][]byte),
+	err error,
+) {
+	var packed []byte
+	ptr := e.payload.Load()
+	if ptr != nil {
+		packed = ptr.bytes
+	}
+
+	_, _, queryHeaders, responseHeaders, body, status, releaseFn, err := unpackPayload(packed)
+	if err != nil {
+		return queryHeaders, responseHeaders, nil, 0, status, releaseFn, err
+	}
+
+	if ptr.chunks != nil {
+		return queryHeaders, responseHeaders, ptr.chunks, bodySize(ptr.chunks), status, releaseFn, nil
+	}
+	if len(body) > 0 {
+		chunks = [][]byte{body}
+	}
+	return queryHeaders, responseHeaders, chunks, int64(len(body)), status, releaseFn, nil
+}
@@ -37,15 +37,16 @@ type Entry struct {
 	shard        uint64   // 64  bit xxh % NumOfShards
 	fingerprint  [16]byte // 128 bit xxh
 	rule         *config.Rule
-	payload      *atomic.Pointer[[]byte]
+	payload      *atomic.Pointer[packedPayload]
 	lruListElem  *atomic.Pointer[list.Element[*Entry]]
 	revalidator  Revalidator
 	updatedAt    int64 // atomic: unix nano (last update was at)
 	isCompressed int64 // atomic: bool as int64
+	isRead       int64 // atomic: bool as int64 (was read since the last refresh)
 }
 
 func (e *Entry) Init() *Entry {
-	e.payload = &atomic.Pointer[[]byte]{}
+	e.payload = &atomic.Pointer[packedPayload]{}
 	e.lruListElem = &atomic.Pointer[list.Element[*Entry]]{}
 	atomic.StoreInt64(&e.updatedAt, time.Now().UnixNano())
 	return e
@@ -68,6 +69,8 @@ func NewEntryNetHttp(rule *config.Rule, r *http.Request) *Entry {
 }
 
 // NewEntryFastHttp accepts path, query and request headers as bytes slices.
+// The key is built of the raw query like NewEntryNetHttp does, so both request paths share entries
+// (header names must be normalized by the server for the same reason).
 func NewEntryFastHttp(cfg *config.Cache, r *fasthttp.RequestCtx) (*Entry, error) {
 	rule := MatchRule(cfg, r.Path())
 	if rule == nil {
@@ -77,7 +80,7 @@ func NewEntryFastHttp(cfg *config.Cache, r *fasthttp.RequestCtx) (*Entry, error)
 	entry := new(Entry).Init()
 	entry.rule = rule
 
-	filteredQueries, filteredQueriesReleaser := entry.getFilteredAndSortedKeyQueriesFastHttp(r)
+	filteredQueries, filteredQueriesReleaser := entry.parseFilterAndSortQuery(r.URI().QueryString())
 	defer filteredQueriesReleaser(filteredQueries)
 
 	filteredHeaders, filteredHeadersReleaser := entry.getFilteredAndSortedKeyHeadersFastHttp(r)
@@ -127,7 +130,7 @@ func NewEntryFromField(
 	entry.shard = shard
 	entry.fingerprint = fingerprint
 	entry.rule = rule
-	entry.payload.Store(&payload)
+	entry.payload.Store(&packedPayload{bytes: payload})
 	entry.revalidator = revalidator
 	entry.isCompressed = isCompressed
 	entry.updatedAt = updatedAt
@@ -144,7 +147,7 @@ var keyBufPool = sync.Pool{
 }
 
 func (e *Entry) calculateAndSetUpKeys(filteredQueries, filteredHeaders *[][2][]byte) *Entry {
-	l := 0
+	l := len(e.rule.PathBytes)
 	for _, pair := range *filteredQueries {
 		l += len(pair[0]) + len(pair[1])
 	}
@@ -159,6 +162,8 @@ func (e *Entry) calculateAndSetUpKeys(filteredQueries, filteredHeaders *[][2][]b
 		keyBufPool.Put(buf)
 	}()
 
+	// the rule path separates entries of different rules with equal keys (e.g. ESI fragments without a query)
+	buf.Write(e.rule.PathBytes)
 	for _, pair := range *filteredQueries {
 		buf.Write(pair[0])
 		buf.Write(pair[1])
@@ -215,6 +220,8 @@ func (e *Entry) DumpBuffer(r *fasthttp.RequestCtx) {
 		keyBufPool.Put(buf)
 	}()
 
+	// the rule path separates entries of different rules with equal keys (e.g. ESI fragments without a query)
+	buf.Write(e.rule.PathBytes)
 	for _, pair := range *filteredQueries {
 		buf.Write(pair[0])
 		buf.Write(pair[1])
@@ -232,6 +239,9 @@ func (e *Entry) Fingerprint() [16]byte {
 }
 
 func (e *Entry) IsSamePayload(another *Entry) bool {
+	if e.IsChunked() || another.IsChunked() {
+		return false // large objects are not compared, a fetched one always replaces the stored one
+	}
 	return e.isPayloadsAreEquals(e.PayloadBytes(), another.PayloadBytes())
 }
 
@@ -249,7 +259,19 @@ func (e *Entry) SwapPayloads(another *Entry) {
 }
 
 func (e *Entry) TouchUpdatedAt() {
-	atomic.StoreInt64(&e.updatedAt, time.Now().Unix())
+	e.SetUpdatedAt(time.Now().UnixNano())
+}
+
+// SetUpdatedAt sets the last update time (unix nano) which the max age and the refresh schedule count from.
+// The storage passes its own clock, so an offline run may replay entries at the access log time.
+func (e *Entry) SetUpdatedAt(at int64) {
+	atomic.StoreInt64(&e.updatedAt, at)
+}
+
+// MarkRefreshed records a successful refresh at the given unix nano time.
+func (e *Entry) MarkRefreshed(at int64) {
+	atomic.StoreInt64(&e.updatedAt, at)
+	atomic.StoreInt64(&e.isRead, 0)
 }
 
 func (e *Entry) SetRevalidator(revalidator Revalidator) *Entry {
@@ -278,6 +300,18 @@ func (e *Entry) SetPayload(
 	body []byte,
 	status int,
 ) {
+	e.payload.Store(&packedPayload{bytes: packPayload(path, query, queryHeaders, headers, body, status)})
+	atomic.StoreInt64(&e.isCompressed, 0)
+}
+
+// packPayload packs Path, Query, QueryHeaders, StatusCode, ResponseHeaders and Body into a single slice.
+func packPayload(
+	path, query []byte,
+	queryHeaders *[][2][]byte,
+	headers *[][2][]byte,
+	body []byte,
+	status int,
+) []byte {
 	queryHeadersDeref := *queryHeaders
 	responseHeadersDeref := *headers
 
@@ -361,10 +395,7 @@ func (e *Entry) SetPayload(
 	payloadBuf = append(payloadBuf, body...)
 	offset += len(body)
 
-	// === 5) Store raw ===
-	payloadBuf = payloadBuf[:]
-	e.payload.Store(&payloadBuf)
-	atomic.StoreInt64(&e.isCompressed, 0)
+	return payloadBuf
 }
 
 var payloadReleaser = func(queryHeaders *[][2][]byte, responseHeaders *[][2][]byte) {
@@ -375,6 +406,7 @@ var payloadReleaser = func(queryHeaders *[][2][]byte, responseHeaders *[][2][]by
 }
 
 // Payload decompresses the entire payload and unpacks it into fields.
+// The body of a large object is kept in chunks and is empty here, see PayloadChunks.
 func (e *Entry) Payload() (
 	path []byte,
 	query []byte,
@@ -385,7 +417,20 @@ func (e *Entry) Payload() (
 	releaseFn func(q, h *[][2][]byte),
 	err error,
 ) {
-	payload := e.PayloadBytes()
+	return unpackPayload(e.PayloadBytes())
+}
+
+// unpackPayload unpacks fields of a packed payload, they refer to the payload memory.
+func unpackPayload(payload []byte) (
+	path []byte,
+	query []byte,
+	queryHeaders *[][2][]byte,
+	responseHeaders *[][2][]byte,
+	body []byte,
+	status int,
+	releaseFn func(q, h *[][2][]byte),
+	err error,
+) {
 	if len(payload) == 0 {
 		return nil, nil, nil, nil, nil, 0, emptyReleaser, fmt.Errorf("payload is empty")
 	}
@@ -464,13 +509,17 @@ func (e *Entry) PayloadBytes() []byte {
 	var payload []byte
 	ptr := e.payload.Load()
 	if ptr != nil {
-		return *ptr
+		return ptr.bytes
 	}
 	return payload
 }
 
 func (e *Entry) Weight() int64 {
-	return int64(unsafe.Sizeof(*e)) + int64(cap(e.PayloadBytes()))
+	ptr := e.payload.Load()
+	if ptr == nil {
+		return int64(unsafe.Sizeof(*e))
+	}
+	return int64(unsafe.Sizeof(*e)) + ptr.weight()
 }
 
 func (e *Entry) IsCompressed() bool {
@@ -481,6 +530,21 @@ func (e *Entry) UpdateAt() int64 {
 	return atomic.LoadInt64(&e.updatedAt)
 }
 
+// ExpiresAt returns the unix nano time after which the entry must not be served, 0 means never.
+func (e *Entry) ExpiresAt(cfg *config.Cache) int64 {
+	maxAge := cfg.MaxAge(e.rule)
+	if maxAge <= 0 {
+		return 0
+	}
+	return atomic.LoadInt64(&e.updatedAt) + maxAge.Nanoseconds()
+}
+
+// IsExpired reports whether the entry has outlived its hard max age at the given unix nano time.
+func (e *Entry) IsExpired(cfg *config.Cache, now int64) bool {
+	expiresAt := e.ExpiresAt(cfg)
+	return expiresAt > 0 && now >= expiresAt
+}
+
 func (e *Entry) parseFilterAndSortQuery(b []byte) (queries *[][2][]byte, releaseFn func(*[][2][]byte)) {
 	b = bytes.TrimLeft(b, "?")
 
@@ -777,22 +841,15 @@ func (e *Entry) LruListElement() *list.Element[*Entry] {
 	return e.lruListElem.Load()
 }
 
-// ShouldBeRefreshed implements probabilistic refresh logic ("beta" algorithm).
-// Returns true if the entry is stale and, with a probability proportional to its staleness, should be refreshed now.
-func (e *Entry) ShouldBeRefreshed(cfg *config.Cache) bool {
-	if e == nil {
-		return false
-	}
-
-	var (
-		ttl         = cfg.Cache.Refresh.TTL.Nanoseconds()
-		beta        = cfg.Cache.Refresh.Beta
-		coefficient = cfg.Cache.Refresh.Coefficient
-	)
+// refreshParams resolves refresh TTL (nanoseconds), beta and coefficient of the entry rule over the global ones.
+func (e *Entry) refreshParams(cfg *config.Cache) (ttl int64, beta, coefficient float64, enabled bool) {
+	ttl = cfg.Cache.Refresh.TTL.Nanoseconds()
+	beta = cfg.Cache.Refresh.Beta
+	coefficient = cfg.Cache.Refresh.Coefficient
 
 	if e.rule.Refresh != nil {
 		if !e.rule.Refresh.Enabled {
-			return false
+			return 0, 0, 0, false
 		}
 
 		if e.rule.Refresh.TTL.Nanoseconds() > 0 {
@@ -806,6 +863,21 @@ func (e *Entry) ShouldBeRefreshed(cfg *config.Cache) bool {
 		}
 	}
 
+	return ttl, beta, coefficient, ttl > 0
+}
+
+// ShouldBeRefreshed implements probabilistic refresh logic ("beta" algorithm).
+// Returns true if the entry is stale and, with a probability proportional to its staleness, should be refreshed now.
+func (e *Entry) ShouldBeRefreshed(cfg *config.Cache) bool {
+	if e == nil {
+		return false
+	}
+
+	ttl, beta, coefficient, enabled := e.refreshParams(cfg)
+	if !enabled {
+		return false
+	}
+
 	// время, прошедшее с последнего обновления
 	elapsed := time.Now().UnixNano() - atomic.LoadInt64(&e.updatedAt)
 	minStale := int64(float64(ttl) * coefficient)
@@ -827,6 +899,42 @@ func (e *Entry) ShouldBeRefreshed(cfg *config.Cache) bool {
 	return rand.Float64() < prob
 }
 
+// RefreshAt is the deterministic counterpart of ShouldBeRefreshed: it returns the unix nano time when the entry
+// should be refreshed, 0 means never. The point within [ttl*coefficient, ttl] is drawn from the same exponential
+// distribution, but the random value is derived from the entry key, so refreshes of different entries are spread
+// while a single entry is always scheduled at the same offset from its last update.
+func (e *Entry) RefreshAt(cfg *config.Cache) int64 {
+	if cfg.Cache.Refresh == nil || !cfg.Cache.Refresh.Enabled {
+		return 0
+	}
+
+	ttl, beta, coefficient, enabled := e.refreshParams(cfg)
+	if !enabled {
+		return 0
+	}
+
+	x := 1.0
+	if beta > 0 {
+		u := float64(e.key>>11) / (1 << 53) // uniform [0, 1) from the key hash
+		x = math.Min(1, -math.Log1p(-u)/beta)
+	}
+	offset := max(int64(float64(ttl)*x), int64(float64(ttl)*coefficient))
+
+	return atomic.LoadInt64(&e.updatedAt) + offset
+}
+
+// MarkRead remembers that the entry was served since its last refresh.
+func (e *Entry) MarkRead() {
+	if atomic.LoadInt64(&e.isRead) == 0 {
+		atomic.StoreInt64(&e.isRead, 1)
+	}
+}
+
+// IsRead reports whether the entry was served since its last refresh.
+func (e *Entry) IsRead() bool {
+	return atomic.LoadInt64(&e.isRead) == 1
+}
+
 var invalidUpstreamStatusCodeReceivedError = errors.New("invalid upstream status code")
 
 // Revalidate calls the revalidator closure to fetch fresh data and updates the timestamp.
@@ -849,7 +957,7 @@ func (e *Entry) Revalidate() error {
 	e.SetPayload(path, query, headers, respHeaders, body, statusCode)
 
 	// successful refresh, set up current timestamp as last update point
-	atomic.StoreInt64(&e.updatedAt, time.Now().UnixNano())
+	e.MarkRefreshed(time.Now().UnixNano())
 
 	return nil
 }
@@ -896,9 +1004,22 @@ func (e *Entry) ToBytes() (data []byte, releaseFn func()) {
 	buf.Write(scratch8[:])
 
 	// === Payload ===
-	binary.LittleEndian.PutUint32(scratch4[:], uint32(len(payload)))
-	buf.Write(scratch4[:])
-	buf.Write(payload)
+	if chunks := e.payload.Load().chunks; chunks != nil {
+		// a large object is dumped as a regular one: the empty packed body is replaced by its chunks
+		bodyLen := bodySize(chunks)
+		binary.LittleEndian.PutUint32(scratch4[:], uint32(len(payload)+int(bodyLen)))
+		buf.Write(scratch4[:])
+		buf.Write(payload[:len(payload)-4])
+		binary.LittleEndian.PutUint32(scratch4[:], uint32(bodyLen))
+		buf.Write(scratch4[:])
+		for _, chunk := range chunks {
+			buf.Write(chunk)
+		}
+	} else {
+		binary.LittleEndian.PutUint32(scratch4[:], uint32(len(payload)))
+		buf.Write(scratch4[:])
+		buf.Write(payload)
+	}
 
 	// Возвращаем готовый []byte и release
 	return buf.Bytes(), releaseFn
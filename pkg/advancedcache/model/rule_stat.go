@@ -0,0 +1,14 @@
+package model
+
+// RuleStat is a snapshot of the storage occupancy of a single rule.
+type RuleStat struct {
+	Path      string  `json:"path"`
+	Priority  string  `json:"priority"`
+	Mem       int64   `json:"mem"`       // Bytes occupied by the rule entries.
+	Len       int64   `json:"len"`       // Num of the rule entries.
+	MaxBytes  int64   `json:"maxBytes"`  // Quota in bytes (0 = no quota).
+	Share     float64 `json:"share"`     // Occupied share of storage.size.
+	Evictions int64   `json:"evictions"` // Num of the rule entries evicted since start.
+	Expired   int64   `json:"expired"`   // Num of the rule entries removed after their max age since start.
+	Rejected  int64   `json:"rejected"`  // Num of the rule entries which were not admitted.
+}
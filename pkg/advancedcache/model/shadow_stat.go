@@ -0,0 +1,25 @@
+package model
+
+import "time"
+
+// ShadowStat is a snapshot of the shadow verification of a single rule.
+type ShadowStat struct {
+	Path           string       `json:"path"`
+	Compare        string       `json:"compare"`
+	SampleRate     float64      `json:"sampleRate"`
+	Sampled        int64        `json:"sampled"`        // Hits picked for verification.
+	Dropped        int64        `json:"dropped"`        // Samples dropped because the verification queue was full.
+	Compared       int64        `json:"compared"`       // Cached responses compared with the upstream ones.
+	Diverged       int64        `json:"diverged"`       // Cached responses which differ from the upstream ones.
+	Errors         int64        `json:"errors"`         // Failed upstream fetches.
+	DivergenceRate float64      `json:"divergenceRate"` // Diverged / Compared.
+	Examples       []ShadowDiff `json:"examples"`       // The latest divergences, the newest first.
+}
+
+// ShadowDiff is an example of a cached response which differs from the upstream one.
+type ShadowDiff struct {
+	At    time.Time `json:"at"`
+	Query string    `json:"query"`
+	Age   string    `json:"age"`  // Time since the cached response was fetched or refreshed.
+	Diff  []string  `json:"diff"` // Human-readable differences, e.g. `items.0.price: 10 != 12`.
+}
@@ -12,4 +12,27 @@ var (
 	Misses                   = "cache_misses"
 	MapMemoryUsageMetricName = "cache_memory_usage"
 	MapLength                = "cache_length"
+	RefreshQueueDepth        = "cache_refresh_queue_depth"
+	RefreshLag               = "cache_refresh_lag_seconds"
+	StaleServed              = "cache_stale_served"
+	ESIErrors                = "cache_esi_errors"
+	/* Upstream concurrency */
+	UpstreamLimit       = "upstream_concurrency_limit"
+	UpstreamInFlight    = "upstream_in_flight"
+	UpstreamLatency     = "upstream_latency_seconds"
+	UpstreamBaseline    = "upstream_latency_baseline_seconds"
+	UpstreamShedUser    = "upstream_shed_user"
+	UpstreamShedRefresh = "upstream_shed_refresh"
+	/* Cache per rule */
+	RuleMemoryUsage = "cache_rule_memory_usage"
+	RuleLength      = "cache_rule_length"
+	RuleQuota       = "cache_rule_quota_bytes"
+	RuleEvictions   = "cache_rule_evictions"
+	RuleExpired     = "cache_rule_expired"
+	RuleRejected    = "cache_rule_rejected"
+	/* Shadow verification per rule */
+	ShadowCompared = "cache_shadow_compared"
+	ShadowDiverged = "cache_shadow_diverged"
+	ShadowErrors   = "cache_shadow_errors"
+	ShadowDropped  = "cache_shadow_dropped"
 )
@@ -3,8 +3,11 @@ package metrics
 import (
 	"bytes"
 	"github.com/VictoriaMetrics/metrics"
+	"github.com/traefik/traefik/v3/pkg/advancedcache/model"
 	"github.com/traefik/traefik/v3/pkg/advancedcache/prometheus/metrics/keyword"
+	"github.com/traefik/traefik/v3/pkg/advancedcache/repository"
 	"sync"
+	"time"
 )
 
 var (
@@ -17,6 +20,7 @@ var (
 	methodBytes = []byte(`",method="`)
 	statusBytes = []byte(`",status="`)
 	closerBytes = []byte(`"}`)
+	ruleBytes   = []byte(`{rule="`)
 )
 
 // Meter defines methods for recording application metrics.
@@ -30,6 +34,12 @@ type Meter interface {
 	SetCacheLength(count uint64)
 	SetCacheMemory(bytes uint64)
 	SetAvgResponseTime(avg float64)
+	SetRuleStat(stat model.RuleStat)
+	SetRefreshQueue(depth uint64, lag time.Duration)
+	SetStaleServed(value uint64)
+	SetESIErrors(value uint64)
+	SetLimiterStat(stat repository.LimiterStat)
+	SetShadowStat(stat model.ShadowStat)
 }
 
 // Metrics implements Meter using VictoriaMetrics metrics.
@@ -75,3 +85,61 @@ func (m *Metrics) SetCacheLength(count uint64) {
 func (m *Metrics) SetAvgResponseTime(avgDuration float64) {
 	metrics.GetOrCreateGauge(keyword.AvgDuration, nil).Set(avgDuration)
 }
+
+// SetRefreshQueue exports the background refresh queue depth and lag.
+func (m *Metrics) SetRefreshQueue(depth uint64, lag time.Duration) {
+	metrics.GetOrCreateGauge(keyword.RefreshQueueDepth, nil).Set(float64(depth))
+	metrics.GetOrCreateGauge(keyword.RefreshLag, nil).Set(lag.Seconds())
+}
+
+func (m *Metrics) SetStaleServed(value uint64) {
+	metrics.GetOrCreateCounter(keyword.StaleServed).Set(value)
+}
+
+func (m *Metrics) SetESIErrors(value uint64) {
+	metrics.GetOrCreateCounter(keyword.ESIErrors).Set(value)
+}
+
+// SetLimiterStat exports the adaptive backend concurrency limit, its load, observed latencies and shed fetches.
+func (m *Metrics) SetLimiterStat(stat repository.LimiterStat) {
+	metrics.GetOrCreateGauge(keyword.UpstreamLimit, nil).Set(float64(stat.Limit))
+	metrics.GetOrCreateGauge(keyword.UpstreamInFlight, nil).Set(float64(stat.InFlight))
+	metrics.GetOrCreateGauge(keyword.UpstreamLatency, nil).Set(stat.Latency.Seconds())
+	metrics.GetOrCreateGauge(keyword.UpstreamBaseline, nil).Set(stat.Baseline.Seconds())
+	metrics.GetOrCreateCounter(keyword.UpstreamShedUser).Set(uint64(stat.ShedUser))
+	metrics.GetOrCreateCounter(keyword.UpstreamShedRefresh).Set(uint64(stat.ShedRefresh))
+}
+
+// SetRuleStat exports occupancy, quota, eviction and expiration counters of a single rule.
+func (m *Metrics) SetRuleStat(stat model.RuleStat) {
+	metrics.GetOrCreateGauge(ruleMetricName(keyword.RuleMemoryUsage, stat.Path), nil).Set(float64(stat.Mem))
+	metrics.GetOrCreateGauge(ruleMetricName(keyword.RuleLength, stat.Path), nil).Set(float64(stat.Len))
+	metrics.GetOrCreateGauge(ruleMetricName(keyword.RuleQuota, stat.Path), nil).Set(float64(stat.MaxBytes))
+	metrics.GetOrCreateCounter(ruleMetricName(keyword.RuleEvictions, stat.Path)).Set(uint64(stat.Evictions))
+	metrics.GetOrCreateCounter(ruleMetricName(keyword.RuleExpired, stat.Path)).Set(uint64(stat.Expired))
+	metrics.GetOrCreateCounter(ruleMetricName(keyword.RuleRejected, stat.Path)).Set(uint64(stat.Rejected))
+}
+
+// SetShadowStat exports shadow verification counters of a single rule.
+func (m *Metrics) SetShadowStat(stat model.ShadowStat) {
+	metrics.GetOrCreateCounter(ruleMetricName(keyword.ShadowCompared, stat.Path)).Set(uint64(stat.Compared))
+	metrics.GetOrCreateCounter(ruleMetricName(keyword.ShadowDiverged, stat.Path)).Set(uint64(stat.Diverged))
+	metrics.GetOrCreateCounter(ruleMetricName(keyword.ShadowErrors, stat.Path)).Set(uint64(stat.Errors))
+	metrics.GetOrCreateCounter(ruleMetricName(keyword.ShadowDropped, stat.Path)).Set(uint64(stat.Dropped))
+}
+
+// ruleMetricName builds a metric name labeled by the rule path, e.g. cache_rule_length{rule="/api/v1/data"}.
+func ruleMetricName(name, rule string) string {
+	buf := bufPool.Get().(*bytes.Buffer)
+	defer func() {
+		buf.Reset()
+		bufPool.Put(buf)
+	}()
+
+	buf.WriteString(name)
+	buf.Write(ruleBytes)
+	buf.WriteString(rule)
+	buf.Write(closerBytes)
+
+	return buf.String()
+}
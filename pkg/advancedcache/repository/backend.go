@@ -8,6 +8,7 @@ import (
 	"github.com/traefik/traefik/v3/pkg/advancedcache/pools"
 	"github.com/valyala/fasthttp"
 	"golang.org/x/time/rate"
+	"io"
 	"net"
 	"net/http"
 	"sync"
@@ -59,20 +60,49 @@ type Backender interface {
 	) (
 		status int, headers *[][2][]byte, body []byte, releaseFn func(), err error,
 	)
+
+	// FetchStream fetches like Fetch does, but a body over the large object threshold is not buffered:
+	// it is returned as a stream (body is nil then) which is valid until releaseFn is called.
+	FetchStream(
+		rule *config.Rule, path []byte, query []byte, queryHeaders *[][2][]byte,
+	) (
+		status int, headers *[][2][]byte, body []byte, stream io.Reader, contentLength int, releaseFn func(), err error,
+	)
+
+	// Limiter returns the adaptive concurrency limiter, nil if backend fetches are rate limited.
+	Limiter() *Limiter
 }
 
 // Backend implements the Backender interface.
 // It fetches and constructs SEO page data responses from an external backend.
 type Backend struct {
-	ctx         context.Context
-	cfg         *config.Cache // Global configuration (backend URL, etc)
-	transport   *http.Transport
-	clientsPool *sync.Pool
-	rateLimiter *rate.Limiter
+	ctx          context.Context
+	cfg          *config.Cache // Global configuration (backend URL, etc)
+	transport    *http.Transport
+	clientsPool  *sync.Pool
+	rateLimiter  *rate.Limiter
+	limiter      *Limiter         // Adaptive concurrency limiter, replaces rateLimiter when enabled
+	streamClient *fasthttp.Client // Streams large objects, nil when large_object is disabled
 }
 
 // NewBackend creates a new instance of Backend.
 func NewBackend(ctx context.Context, cfg *config.Cache) *Backend {
+	var limiter *Limiter
+	if c := cfg.Cache.Proxy.Concurrency; c != nil && c.Enabled {
+		limiter = NewLimiter(c)
+	}
+
+	var streamClient *fasthttp.Client
+	if cfg.IsLargeObjectEnabled() {
+		streamClient = &fasthttp.Client{
+			MaxConnsPerHost:     512,
+			ReadTimeout:         cfg.Cache.Proxy.Timeout, // covers the whole body transfer
+			WriteTimeout:        5 * time.Second,
+			StreamResponseBody:  true,
+			MaxResponseBodySize: int(cfg.Cache.LargeObject.Threshold), // bodies over it are streamed
+		}
+	}
+
 	return &Backend{
 		ctx: ctx,
 		cfg: cfg,
@@ -88,14 +118,25 @@ func NewBackend(ctx context.Context, cfg *config.Cache) *Backend {
 			rate.Limit(cfg.Cache.Proxy.Rate),
 			cfg.Cache.Proxy.Rate/10,
 		),
+		limiter:      limiter,
+		streamClient: streamClient,
 	}
 }
 
+// Limiter returns the adaptive concurrency limiter, nil if backend fetches are rate limited.
+func (s *Backend) Limiter() *Limiter {
+	return s.limiter
+}
+
 func (s *Backend) Fetch(
 	rule *config.Rule, path []byte, query []byte, queryHeaders *[][2][]byte,
 ) (
 	status int, headers *[][2][]byte, body []byte, releaseFn func(), err error,
 ) {
+	if s.limiter != nil {
+		return s.fetchLimited(LaneUser, rule, path, query, queryHeaders)
+	}
+
 	if err = s.rateLimiter.Wait(s.ctx); err != nil {
 		return 0, nil, nil, emptyReleaseFn, err
 	}
@@ -103,6 +144,33 @@ func (s *Backend) Fetch(
 	return s.requestExternalBackend(rule, path, query, queryHeaders)
 }
 
+func (s *Backend) FetchStream(
+	rule *config.Rule, path []byte, query []byte, queryHeaders *[][2][]byte,
+) (
+	status int, headers *[][2][]byte, body []byte, stream io.Reader, contentLength int, releaseFn func(), err error,
+) {
+	if s.streamClient == nil {
+		status, headers, body, releaseFn, err = s.Fetch(rule, path, query, queryHeaders)
+		return status, headers, body, nil, len(body), releaseFn, err
+	}
+
+	if s.limiter != nil {
+		done, err := s.limiter.Acquire(LaneUser)
+		if err != nil {
+			return 0, nil, nil, nil, 0, emptyReleaseFn, err
+		}
+		status, headers, body, stream, contentLength, releaseFn, err = s.requestExternalBackendStream(rule, path, query, queryHeaders)
+		done(err == nil && status < http.StatusInternalServerError) // the latency sample is the time to the response headers
+		return status, headers, body, stream, contentLength, releaseFn, err
+	}
+
+	if err = s.rateLimiter.Wait(s.ctx); err != nil {
+		return 0, nil, nil, nil, 0, emptyReleaseFn, err
+	}
+
+	return s.requestExternalBackendStream(rule, path, query, queryHeaders)
+}
+
 // RevalidatorMaker builds a new revalidator for model.Response by catching a request into closure for be able to call backend later.
 func (s *Backend) RevalidatorMaker() func(
 	rule *config.Rule, path []byte, query []byte, queryHeaders *[][2][]byte,
@@ -114,10 +182,31 @@ func (s *Backend) RevalidatorMaker() func(
 	) (
 		status int, headers *[][2][]byte, body []byte, releaseFn func(), err error,
 	) {
+		if s.limiter != nil {
+			return s.fetchLimited(LaneRefresh, rule, path, query, queryHeaders)
+		}
 		return s.requestExternalBackend(rule, path, query, queryHeaders)
 	}
 }
 
+// fetchLimited performs the request within the concurrency limit of the lane, it fails fast with ErrOverloaded
+// instead of waiting for a slot. The outcome feeds the limiter: errors and 5xx statuses shrink the limit.
+func (s *Backend) fetchLimited(
+	lane Lane, rule *config.Rule, path []byte, query []byte, queryHeaders *[][2][]byte,
+) (
+	status int, headers *[][2][]byte, body []byte, releaseFn func(), err error,
+) {
+	done, err := s.limiter.Acquire(lane)
+	if err != nil {
+		return 0, nil, nil, emptyReleaseFn, err
+	}
+
+	status, headers, body, releaseFn, err = s.requestExternalBackend(rule, path, query, queryHeaders)
+	done(err == nil && status < http.StatusInternalServerError)
+
+	return status, headers, body, releaseFn, err
+}
+
 var (
 	emptyReleaseFn = func() {}
 	urlBufPool     = sync.Pool{
@@ -135,6 +224,98 @@ func (s *Backend) requestExternalBackend(
 	req := fasthttp.AcquireRequest()
 	defer fasthttp.ReleaseRequest(req)
 
+	timeout, err := s.prepareRequest(req, rule, path, query, queryHeaders)
+	if err != nil {
+		return 0, nil, nil, emptyReleaseFn, err
+	}
+
+	resp := fasthttp.AcquireResponse()
+	if err = pools.BackendHttpClientPool.DoTimeout(req, resp, timeout); err != nil {
+		fasthttp.ReleaseResponse(resp)
+		return 0, nil, nil, emptyReleaseFn, err
+	}
+
+	headers = s.responseHeaders(rule, resp)
+
+	buf := pools.BackendBodyBufferPool.Get().(*bytes.Buffer)
+
+	// make a final releaser func
+	releaseFn = func() {
+		*headers = (*headers)[:0]
+		pools.KeyValueSlicePool.Put(headers)
+
+		buf.Reset()
+		pools.BackendBodyBufferPool.Put(buf)
+
+		fasthttp.ReleaseResponse(resp)
+	}
+
+	if err = resp.BodyWriteTo(buf); err != nil {
+		releaseFn() // release on error
+		return 0, nil, nil, emptyReleaseFn, err
+	}
+
+	return resp.StatusCode(), headers, buf.Bytes(), releaseFn, nil
+}
+
+// requestExternalBackendStream performs the HTTP request to backend, a body within the large object
+// threshold is buffered, a larger one (or one of unknown length) is returned as a stream.
+func (s *Backend) requestExternalBackendStream(
+	rule *config.Rule, path []byte, query []byte, queryHeaders *[][2][]byte,
+) (status int, headers *[][2][]byte, body []byte, stream io.Reader, contentLength int, releaseFn func(), err error) {
+	req := fasthttp.AcquireRequest()
+	defer fasthttp.ReleaseRequest(req)
+
+	if _, err = s.prepareRequest(req, rule, path, query, queryHeaders); err != nil {
+		return 0, nil, nil, nil, 0, emptyReleaseFn, err
+	}
+
+	resp := fasthttp.AcquireResponse()
+	if err = s.streamClient.Do(req, resp); err != nil {
+		fasthttp.ReleaseResponse(resp)
+		return 0, nil, nil, nil, 0, emptyReleaseFn, err
+	}
+
+	headers = s.responseHeaders(rule, resp)
+	contentLength = resp.Header.ContentLength()
+
+	if contentLength >= 0 && int64(contentLength) <= s.cfg.Cache.LargeObject.Threshold {
+		buf := pools.BackendBodyBufferPool.Get().(*bytes.Buffer)
+
+		releaseFn = func() {
+			*headers = (*headers)[:0]
+			pools.KeyValueSlicePool.Put(headers)
+
+			buf.Reset()
+			pools.BackendBodyBufferPool.Put(buf)
+
+			fasthttp.ReleaseResponse(resp)
+		}
+
+		if _, err = io.Copy(buf, resp.BodyStream()); err != nil {
+			releaseFn() // release on error
+			return 0, nil, nil, nil, 0, emptyReleaseFn, err
+		}
+
+		return resp.StatusCode(), headers, buf.Bytes(), nil, contentLength, releaseFn, nil
+	}
+
+	releaseFn = func() {
+		*headers = (*headers)[:0]
+		pools.KeyValueSlicePool.Put(headers)
+
+		_ = resp.CloseBodyStream()
+		fasthttp.ReleaseResponse(resp)
+	}
+
+	return resp.StatusCode(), headers, nil, resp.BodyStream(), contentLength, releaseFn, nil
+}
+
+// prepareRequest builds the backend GET request and returns its timeout. A request of a cached rule
+// always fetches the full response: ranges are served from the cached one.
+func (s *Backend) prepareRequest(
+	req *fasthttp.Request, rule *config.Rule, path []byte, query []byte, queryHeaders *[][2][]byte,
+) (timeout time.Duration, err error) {
 	urlBuf := urlBufPool.Get().(*bytes.Buffer)
 	defer func() { urlBuf.Reset(); urlBufPool.Put(urlBuf) }()
 
@@ -142,41 +323,48 @@ func (s *Backend) requestExternalBackend(
 	urlBuf.Grow(len(s.cfg.Cache.Proxy.FromUrl) + len(path) + len(query) + 1)
 
 	if _, err = urlBuf.Write(s.cfg.Cache.Proxy.FromUrl); err != nil {
-		return 0, nil, nil, emptyReleaseFn, err
+		return 0, err
 	}
 	if _, err = urlBuf.Write(path); err != nil {
-		return 0, nil, nil, emptyReleaseFn, err
+		return 0, err
 	}
 	if _, err = urlBuf.Write(queryPrefix); err != nil {
-		return 0, nil, nil, emptyReleaseFn, err
+		return 0, err
 	}
 	if _, err = urlBuf.Write(query); err != nil {
-		return 0, nil, nil, emptyReleaseFn, err
+		return 0, err
 	}
 	req.SetRequestURIBytes(urlBuf.Bytes())
 
 	var isBot bool
 	for _, kv := range *queryHeaders {
+		if rule != nil && isRangeHeader(kv[0]) {
+			continue
+		}
 		req.Header.SetBytesKV(kv[0], kv[1])
 		if bytes.Equal(kv[0], s.cfg.Cache.LifeTime.EscapeMaxReqDurationHeaderBytes) {
 			isBot = true
 		}
 	}
 
-	var timeout time.Duration
 	if isBot {
-		timeout = s.cfg.Cache.Proxy.Timeout
-	} else {
-		timeout = s.cfg.Cache.LifeTime.MaxReqDuration
+		return s.cfg.Cache.Proxy.Timeout, nil
 	}
+	return s.cfg.Cache.LifeTime.MaxReqDuration, nil
+}
 
-	resp := fasthttp.AcquireResponse()
-	if err = pools.BackendHttpClientPool.DoTimeout(req, resp, timeout); err != nil {
-		fasthttp.ReleaseResponse(resp)
-		return 0, nil, nil, emptyReleaseFn, err
-	}
+var (
+	rangeHeader   = []byte("Range")
+	ifRangeHeader = []byte("If-Range")
+)
+
+func isRangeHeader(key []byte) bool {
+	return bytes.EqualFold(key, rangeHeader) || bytes.EqualFold(key, ifRangeHeader)
+}
 
-	headers = pools.KeyValueSlicePool.Get().(*[][2][]byte)
+// responseHeaders collects the response headers allowed by the rule (all of them without a rule).
+func (s *Backend) responseHeaders(rule *config.Rule, resp *fasthttp.Response) *[][2][]byte {
+	headers := pools.KeyValueSlicePool.Get().(*[][2][]byte)
 
 	if rule != nil {
 		allowedHeadersMap := rule.CacheValue.HeadersMap
@@ -191,23 +379,5 @@ func (s *Backend) requestExternalBackend(
 		})
 	}
 
-	buf := pools.BackendBodyBufferPool.Get().(*bytes.Buffer)
-
-	// make a final releaser func
-	releaseFn = func() {
-		*headers = (*headers)[:0]
-		pools.KeyValueSlicePool.Put(headers)
-
-		buf.Reset()
-		pools.BackendBodyBufferPool.Put(buf)
-
-		fasthttp.ReleaseResponse(resp)
-	}
-
-	if err = resp.BodyWriteTo(buf); err != nil {
-		releaseFn() // release on error
-		return 0, nil, nil, emptyReleaseFn, err
-	}
-
-	return resp.StatusCode(), headers, buf.Bytes(), releaseFn, nil
+	return headers
 }
@@ -0,0 +1,179 @@
+package repository
+
+import (
+	"errors"
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/traefik/traefik/v3/pkg/advancedcache/config"
+)
+
+const (
+	defaultInitialLimit = 20
+	defaultMinLimit     = 1
+	defaultMaxLimit     = 1000
+	defaultTolerance    = 2.0
+	defaultBackoff      = 0.9
+	defaultRefreshShare = 0.5
+
+	shortRTTAlpha = 0.1  // EWMA weight of the recent latency
+	longRTTAlpha  = 0.01 // EWMA weight of the baseline latency
+	smoothing     = 0.2  // How fast the limit moves to its new estimate
+	minGradient   = 0.5  // The limit shrinks at most by half per sample
+)
+
+// ErrOverloaded is returned when a fetch is shed because the backend concurrency limit of its lane is exceeded.
+var ErrOverloaded = errors.New("backend concurrency limit exceeded")
+
+// Lane is a priority class of backend fetches.
+type Lane int
+
+const (
+	LaneUser    Lane = iota // Cache misses and proxied requests, a client is waiting for them.
+	LaneRefresh             // Background refreshes, they are shed first.
+)
+
+// LimiterStat is a snapshot of the adaptive concurrency limiter.
+type LimiterStat struct {
+	Limit       int
+	InFlight    int
+	Latency     time.Duration // Recent backend latency.
+	Baseline    time.Duration // Long-term backend latency.
+	ShedUser    int64
+	ShedRefresh int64
+}
+
+// Limiter adaptively limits the number of in-flight backend requests (gradient + AIMD).
+//
+// Every completed request is a latency sample: while the recent latency stays within tolerance of the
+// long-term baseline the limit grows (by about sqrt(limit) per sample when it is saturated), when the backend
+// slows down the limit shrinks proportionally, an error or timeout cuts it multiplicatively (at most once per
+// latency window). Requests over the limit are not queued, they fail fast with ErrOverloaded.
+// Refreshes may occupy only refresh_share of the limit, so they are shed before user-facing fetches.
+type Limiter struct {
+	mu           sync.Mutex
+	limit        float64
+	minLimit     float64
+	maxLimit     float64
+	tolerance    float64
+	backoff      float64
+	refreshShare float64
+	inFlight     int
+	shortRTT     float64 // EWMA, ns
+	longRTT      float64 // EWMA, ns
+	lastBackoff  int64   // UnixNano
+	shedUser     atomic.Int64
+	shedRefresh  atomic.Int64
+}
+
+// NewLimiter creates an adaptive concurrency limiter, zero config values are replaced by defaults.
+func NewLimiter(cfg *config.Concurrency) *Limiter {
+	l := &Limiter{
+		limit:        defaultInitialLimit,
+		minLimit:     defaultMinLimit,
+		maxLimit:     defaultMaxLimit,
+		tolerance:    defaultTolerance,
+		backoff:      defaultBackoff,
+		refreshShare: defaultRefreshShare,
+	}
+	if cfg.Initial > 0 {
+		l.limit = float64(cfg.Initial)
+	}
+	if cfg.Min > 0 {
+		l.minLimit = float64(cfg.Min)
+	}
+	if cfg.Max > 0 {
+		l.maxLimit = float64(cfg.Max)
+	}
+	if cfg.Tolerance > 0 {
+		l.tolerance = cfg.Tolerance
+	}
+	if cfg.Backoff > 0 {
+		l.backoff = cfg.Backoff
+	}
+	if cfg.RefreshShare > 0 {
+		l.refreshShare = cfg.RefreshShare
+	}
+	l.limit = min(max(l.limit, l.minLimit), l.maxLimit)
+	return l
+}
+
+// Acquire takes a slot of the lane or fails fast with ErrOverloaded. On success, done must be called
+// once the backend responded: ok=false means an error, a timeout or a 5xx status.
+func (l *Limiter) Acquire(lane Lane) (done func(ok bool), err error) {
+	l.mu.Lock()
+	capacity := l.limit
+	if lane == LaneRefresh {
+		capacity *= l.refreshShare
+	}
+	if float64(l.inFlight) >= math.Floor(capacity) {
+		l.mu.Unlock()
+		if lane == LaneRefresh {
+			l.shedRefresh.Add(1)
+		} else {
+			l.shedUser.Add(1)
+		}
+		return nil, ErrOverloaded
+	}
+	l.inFlight++
+	l.mu.Unlock()
+
+	from := time.Now()
+	return func(ok bool) {
+		l.release(time.Since(from), ok)
+	}, nil
+}
+
+func (l *Limiter) release(rtt time.Duration, ok bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	inFlight := l.inFlight
+	l.inFlight--
+
+	if !ok {
+		now := time.Now().UnixNano()
+		if now-l.lastBackoff >= int64(l.shortRTT) {
+			l.limit = max(l.limit*l.backoff, l.minLimit)
+			l.lastBackoff = now
+		}
+		return
+	}
+
+	sample := float64(rtt)
+	if l.shortRTT == 0 {
+		l.shortRTT, l.longRTT = sample, sample
+	} else {
+		l.shortRTT += (sample - l.shortRTT) * shortRTTAlpha
+		l.longRTT += (sample - l.longRTT) * longRTTAlpha
+	}
+
+	// a sustained slowdown drags the baseline up, so it is pulled back to recover the gradient
+	if l.longRTT > l.shortRTT*2 {
+		l.longRTT *= 0.95
+	}
+
+	gradient := min(max(l.tolerance*l.longRTT/l.shortRTT, minGradient), 1)
+	if gradient == 1 && float64(inFlight) < l.limit/2 {
+		return // the limit is not a bottleneck, there is no evidence it can grow
+	}
+
+	target := l.limit*gradient + math.Sqrt(l.limit)
+	l.limit = min(max(l.limit*(1-smoothing)+target*smoothing, l.minLimit), l.maxLimit)
+}
+
+// Stat returns the current limit, load and shed counters.
+func (l *Limiter) Stat() LimiterStat {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return LimiterStat{
+		Limit:       int(l.limit),
+		InFlight:    l.inFlight,
+		Latency:     time.Duration(l.shortRTT),
+		Baseline:    time.Duration(l.longRTT),
+		ShedUser:    l.shedUser.Load(),
+		ShedRefresh: l.shedRefresh.Load(),
+	}
+}
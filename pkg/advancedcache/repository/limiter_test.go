@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/traefik/traefik/v3/pkg/advancedcache/config"
+)
+
+func TestLimiterShedsRefreshesFirst(t *testing.T) {
+	l := NewLimiter(&config.Concurrency{Initial: 10, RefreshShare: 0.5})
+
+	var done []func(ok bool)
+	for i := 0; i < 5; i++ {
+		release, err := l.Acquire(LaneRefresh)
+		if err != nil {
+			t.Fatalf("refresh %d: unexpected error: %v", i, err)
+		}
+		done = append(done, release)
+	}
+	if _, err := l.Acquire(LaneRefresh); !errors.Is(err, ErrOverloaded) {
+		t.Fatalf("expected a refresh over its share to be shed, got %v", err)
+	}
+
+	for i := 0; i < 5; i++ {
+		release, err := l.Acquire(LaneUser)
+		if err != nil {
+			t.Fatalf("user fetch %d: unexpected error: %v", i, err)
+		}
+		done = append(done, release)
+	}
+	if _, err := l.Acquire(LaneUser); !errors.Is(err, ErrOverloaded) {
+		t.Fatalf("expected a user fetch over the limit to fail fast, got %v", err)
+	}
+
+	for _, release := range done {
+		release(true)
+	}
+	if stat := l.Stat(); stat.InFlight != 0 || stat.ShedUser != 1 || stat.ShedRefresh != 1 {
+		t.Fatalf("unexpected limiter stat: %+v", stat)
+	}
+}
+
+func TestLimiterAdaptsToLatencyAndErrors(t *testing.T) {
+	l := NewLimiter(&config.Concurrency{Initial: 20, Min: 2, Max: 100})
+
+	// saturated and fast: the limit grows
+	for i := 0; i < 200; i++ {
+		l.limit = max(l.limit, 20)
+		l.inFlight = int(l.limit)
+		l.release(10*time.Millisecond, true)
+	}
+	grown := l.Stat().Limit
+	if grown <= 20 {
+		t.Fatalf("expected the limit to grow while latency is stable, got %d", grown)
+	}
+
+	// the backend slows down 10 times: the limit shrinks before the baseline adapts
+	for i := 0; i < 30; i++ {
+		l.inFlight = int(l.limit)
+		l.release(100*time.Millisecond, true)
+	}
+	slowed := l.Stat().Limit
+	if slowed >= grown {
+		t.Fatalf("expected the limit to shrink on latency growth, got %d (was %d)", slowed, grown)
+	}
+
+	// errors cut the limit down to its minimum
+	for i := 0; i < 100; i++ {
+		l.inFlight = 1
+		l.lastBackoff = 0
+		l.release(time.Millisecond, false)
+	}
+	if limit := l.Stat().Limit; limit != 2 {
+		t.Fatalf("expected errors to drive the limit to its minimum, got %d", limit)
+	}
+}
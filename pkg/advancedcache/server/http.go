@@ -13,10 +13,14 @@ import (
 	"time"
 )
 
+const defaultShutdownTimeout = time.Second * 10
+
 type HTTP struct {
-	ctx    context.Context
-	config *config.Cache
-	server *fasthttp.Server
+	ctx             context.Context
+	config          *config.Cache
+	server          *fasthttp.Server
+	addr            string        // proxy.to by default
+	shutdownTimeout time.Duration // Time to drain in-flight requests once ctx is done
 }
 
 func New(
@@ -25,11 +29,37 @@ func New(
 	controllers []controller.HttpController,
 	middlewares []middleware.HttpMiddleware,
 ) (*HTTP, error) {
-	s := &HTTP{ctx: ctx, config: config}
+	s := &HTTP{ctx: ctx, config: config, addr: config.Cache.Proxy.To, shutdownTimeout: defaultShutdownTimeout}
 	s.initServer(s.buildRouter(controllers), middlewares)
 	return s, nil
 }
 
+// WithAddress overrides the listened address.
+func (s *HTTP) WithAddress(addr string) *HTTP {
+	s.addr = addr
+	return s
+}
+
+// WithShutdownTimeout overrides the time to drain in-flight requests on shutdown.
+func (s *HTTP) WithShutdownTimeout(timeout time.Duration) *HTTP {
+	if timeout > 0 {
+		s.shutdownTimeout = timeout
+	}
+	return s
+}
+
+// WithHeaderNamesNormalizing makes the server canonicalize request header names (accept-encoding -> Accept-Encoding)
+// as net/http does, so that the keys of requests it serves match the keys of the ones served by the middleware.
+func (s *HTTP) WithHeaderNamesNormalizing() *HTTP {
+	s.server.DisableHeaderNamesNormalizing = false
+	return s
+}
+
+// Handler returns the request handler with all middlewares applied.
+func (s *HTTP) Handler() fasthttp.RequestHandler {
+	return s.server.Handler
+}
+
 func (s *HTTP) ListenAndServe() {
 	wg := &sync.WaitGroup{}
 	defer wg.Wait()
@@ -45,7 +75,7 @@ func (s *HTTP) serve(wg *sync.WaitGroup) {
 	defer wg.Done()
 
 	name := s.config.Cache.Proxy.Name
-	port := s.config.Cache.Proxy.To
+	port := s.addr
 
 	log.Info().Msgf("[server] %v was started (port: %v)", name, port)
 	defer log.Info().Msgf("[server] %v was stopped (port: %v)", name, port)
@@ -60,7 +90,7 @@ func (s *HTTP) shutdown(wg *sync.WaitGroup) {
 
 	<-s.ctx.Done()
 
-	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	ctx, cancel := context.WithTimeout(context.Background(), s.shutdownTimeout)
 	defer cancel()
 
 	if err := s.server.ShutdownWithContext(ctx); err != nil {
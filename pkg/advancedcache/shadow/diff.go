@@ -0,0 +1,199 @@
+package shadow
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	maxDiffLines  = 10 // Differences kept per example
+	maxValueChars = 64 // Values in a difference are truncated to
+)
+
+// diffJSON compares two JSON documents and returns the differing fields, fields matching
+// the ignored paths (and everything below them) are skipped.
+func diffJSON(cached, upstream []byte, ignore [][]string) ([]string, error) {
+	a, err := decodeJSON(cached)
+	if err != nil {
+		return nil, fmt.Errorf("cached body: %w", err)
+	}
+	b, err := decodeJSON(upstream)
+	if err != nil {
+		return nil, fmt.Errorf("upstream body: %w", err)
+	}
+
+	d := &differ{ignore: ignore}
+	d.walk(nil, a, b)
+	return d.lines, nil
+}
+
+func decodeJSON(data []byte) (v any, err error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber() // numbers are compared as written
+	if err = dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+type differ struct {
+	ignore [][]string
+	lines  []string
+}
+
+func (d *differ) add(path []string, format string, args ...any) {
+	if len(d.lines) < maxDiffLines {
+		d.lines = append(d.lines, fieldName(path)+": "+fmt.Sprintf(format, args...))
+	}
+}
+
+func (d *differ) walk(path []string, a, b any) {
+	if len(d.lines) >= maxDiffLines || d.isIgnored(path) {
+		return
+	}
+
+	switch av := a.(type) {
+	case map[string]any:
+		bv, ok := b.(map[string]any)
+		if !ok {
+			d.add(path, "%s != %s", short(a), short(b))
+			return
+		}
+		keys := make([]string, 0, len(av)+len(bv))
+		for k := range av {
+			keys = append(keys, k)
+		}
+		for k := range bv {
+			if _, found := av[k]; !found {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			field := append(path[:len(path):len(path)], k)
+			aField, inA := av[k]
+			bField, inB := bv[k]
+			switch {
+			case !inA:
+				if !d.isIgnored(field) {
+					d.add(field, "missing in cache, upstream %s", short(bField))
+				}
+			case !inB:
+				if !d.isIgnored(field) {
+					d.add(field, "missing upstream, cached %s", short(aField))
+				}
+			default:
+				d.walk(field, aField, bField)
+			}
+		}
+
+	case []any:
+		bv, ok := b.([]any)
+		if !ok {
+			d.add(path, "%s != %s", short(a), short(b))
+			return
+		}
+		if len(av) != len(bv) {
+			d.add(path, "%d items != %d items", len(av), len(bv))
+		}
+		for i := 0; i < min(len(av), len(bv)); i++ {
+			d.walk(append(path[:len(path):len(path)], strconv.Itoa(i)), av[i], bv[i])
+		}
+
+	default:
+		if a != b {
+			d.add(path, "%s != %s", short(a), short(b))
+		}
+	}
+}
+
+// isIgnored reports whether the path matches one of the ignored ones, "*" matches any key or index.
+func (d *differ) isIgnored(path []string) bool {
+	for _, pattern := range d.ignore {
+		if len(pattern) != len(path) {
+			continue
+		}
+		matched := true
+		for i := range pattern {
+			if pattern[i] != "*" && pattern[i] != path[i] {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return true
+		}
+	}
+	return false
+}
+
+func fieldName(path []string) string {
+	if len(path) == 0 {
+		return "(root)"
+	}
+	return strings.Join(path, ".")
+}
+
+func short(v any) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	if len(data) > maxValueChars {
+		return string(data[:maxValueChars]) + "..."
+	}
+	return string(data)
+}
+
+// diffPayload describes how the upstream payload differs from the cached one (they are known to differ).
+func diffPayload(
+	cachedStatus, upstreamStatus int,
+	cachedHeaders, upstreamHeaders *[][2][]byte,
+	cachedBody, upstreamBody []byte,
+) (lines []string) {
+	if cachedStatus != upstreamStatus {
+		lines = append(lines, fmt.Sprintf("status: %d != %d", cachedStatus, upstreamStatus))
+	}
+	if !sameHeaders(*cachedHeaders, *upstreamHeaders) {
+		lines = append(lines, "headers: "+headerNames(*cachedHeaders)+" != "+headerNames(*upstreamHeaders))
+	}
+	if !bytes.Equal(cachedBody, upstreamBody) {
+		at := 0
+		for at < len(cachedBody) && at < len(upstreamBody) && cachedBody[at] == upstreamBody[at] {
+			at++
+		}
+		lines = append(lines, fmt.Sprintf("body: %d bytes != %d bytes, first difference at %d", len(cachedBody), len(upstreamBody), at))
+	}
+	if len(lines) == 0 {
+		lines = append(lines, "payload layout differs")
+	}
+	return lines
+}
+
+func sameHeaders(a, b [][2][]byte) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !bytes.Equal(a[i][0], b[i][0]) || !bytes.Equal(a[i][1], b[i][1]) {
+			return false
+		}
+	}
+	return true
+}
+
+func headerNames(headers [][2][]byte) string {
+	names := make([]string, 0, len(headers))
+	for _, kv := range headers {
+		names = append(names, string(kv[0])+"="+string(kv[1]))
+	}
+	s := "[" + strings.Join(names, ", ") + "]"
+	if len(s) > maxValueChars {
+		return s[:maxValueChars] + "...]"
+	}
+	return s
+}
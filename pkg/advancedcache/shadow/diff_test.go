@@ -0,0 +1,76 @@
+package shadow
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDiffJSON(t *testing.T) {
+	tests := []struct {
+		desc     string
+		cached   string
+		upstream string
+		ignore   []string
+		want     []string
+	}{
+		{
+			desc:     "same documents with another key order",
+			cached:   `{"a":1,"b":[1,2]}`,
+			upstream: `{"b":[1,2],"a":1}`,
+		},
+		{
+			desc:     "changed, added and removed fields",
+			cached:   `{"price":10,"old":true,"items":[{"id":1}]}`,
+			upstream: `{"price":12,"new":"x","items":[{"id":2},{"id":3}]}`,
+			want: []string{
+				`items: 1 items != 2 items`,
+				`items.0.id: 1 != 2`,
+				`new: missing in cache, upstream "x"`,
+				`old: missing upstream, cached true`,
+				`price: 10 != 12`,
+			},
+		},
+		{
+			desc:     "ignored fields",
+			cached:   `{"meta":{"generated_at":"1"},"items":[{"id":1,"ts":1}],"price":10}`,
+			upstream: `{"meta":{"generated_at":"2"},"items":[{"id":1,"ts":2}],"price":10}`,
+			ignore:   []string{"meta.generated_at", "items.*.ts"},
+		},
+		{
+			desc:     "ignored subtree",
+			cached:   `{"meta":{"a":1,"b":2}}`,
+			upstream: `{"meta":{"a":2}}`,
+			ignore:   []string{"meta"},
+		},
+		{
+			desc:     "numbers are compared as written",
+			cached:   `{"n":1.0}`,
+			upstream: `{"n":1}`,
+			want:     []string{`n: 1.0 != 1`},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			var ignore [][]string
+			for _, field := range test.ignore {
+				ignore = append(ignore, strings.Split(field, "."))
+			}
+
+			got, err := diffJSON([]byte(test.cached), []byte(test.upstream), ignore)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Fatalf("expected %q, got %q", test.want, got)
+			}
+		})
+	}
+}
+
+func TestDiffJSONInvalidBody(t *testing.T) {
+	if _, err := diffJSON([]byte(`{"a":1}`), []byte(`<html>`), nil); err == nil {
+		t.Fatal("expected an error for a non-JSON upstream body")
+	}
+}
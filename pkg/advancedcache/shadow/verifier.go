@@ -0,0 +1,252 @@
+package shadow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/traefik/traefik/v3/pkg/advancedcache/config"
+	"github.com/traefik/traefik/v3/pkg/advancedcache/model"
+	"github.com/traefik/traefik/v3/pkg/advancedcache/repository"
+	"github.com/traefik/traefik/v3/pkg/advancedcache/utils"
+)
+
+const (
+	defaultWorkers  = 2
+	defaultQueue    = 1024
+	defaultExamples = 10
+)
+
+// ruleStat accumulates the verification outcome of a single rule.
+type ruleStat struct {
+	rule     *config.Rule
+	sampled  atomic.Int64
+	dropped  atomic.Int64
+	compared atomic.Int64
+	diverged atomic.Int64
+	errors   atomic.Int64
+	mu       sync.Mutex
+	examples []model.ShadowDiff // The newest last
+}
+
+// Verifier measures staleness of cached responses: a sampled share of hits (see config.RuleShadow) is fetched
+// from the upstream in the background and compared with the cached copy. Sampling never blocks the client:
+// a sample is dropped when the queue is full, fetches go through the refresh lane of the backend.
+type Verifier struct {
+	ctx      context.Context
+	cfg      *config.Cache
+	fetch    model.Revalidator
+	queue    chan *model.Entry
+	workers  int
+	examples int
+	stats    map[*config.Rule]*ruleStat // Rules with enabled verification only, never modified after start
+	ordered  []*ruleStat                // Sorted by path for stable output
+}
+
+func NewVerifier(ctx context.Context, cfg *config.Cache, backend repository.Backender) *Verifier {
+	workers, queue, examples := defaultWorkers, defaultQueue, defaultExamples
+	if c := cfg.Cache.Shadow; c != nil {
+		if c.Workers > 0 {
+			workers = c.Workers
+		}
+		if c.Queue > 0 {
+			queue = c.Queue
+		}
+		if c.Examples > 0 {
+			examples = c.Examples
+		}
+	}
+
+	v := &Verifier{
+		ctx:      ctx,
+		cfg:      cfg,
+		fetch:    backend.RevalidatorMaker(),
+		queue:    make(chan *model.Entry, queue),
+		workers:  workers,
+		examples: examples,
+		stats:    make(map[*config.Rule]*ruleStat),
+	}
+	for _, rule := range cfg.Cache.Rules {
+		if rule.Shadow != nil && rule.Shadow.SampleRate > 0 {
+			stat := &ruleStat{rule: rule}
+			v.stats[rule] = stat
+			v.ordered = append(v.ordered, stat)
+		}
+	}
+	sort.Slice(v.ordered, func(i, j int) bool {
+		return string(v.ordered[i].rule.PathBytes) < string(v.ordered[j].rule.PathBytes)
+	})
+	return v
+}
+
+// Run launches verification workers, it does nothing if no rule has enabled verification.
+func (v *Verifier) Run() *Verifier {
+	if !v.cfg.Cache.Enabled || len(v.ordered) == 0 {
+		return v
+	}
+
+	for i := 0; i < v.workers; i++ {
+		go func() {
+			for {
+				select {
+				case <-v.ctx.Done():
+					return
+				case entry := <-v.queue:
+					v.verify(entry)
+				}
+			}
+		}()
+	}
+
+	go v.runLogger()
+
+	return v
+}
+
+// Sample picks a served hit for verification according to its rule sample rate.
+func (v *Verifier) Sample(entry *model.Entry) {
+	if v == nil {
+		return
+	}
+	stat, ok := v.stats[entry.Rule()]
+	if !ok || entry.IsChunked() || rand.Float64() >= stat.rule.Shadow.SampleRate {
+		return
+	}
+
+	stat.sampled.Add(1)
+	select {
+	case v.queue <- entry:
+	default:
+		stat.dropped.Add(1)
+	}
+}
+
+func (v *Verifier) verify(entry *model.Entry) {
+	stat := v.stats[entry.Rule()]
+
+	path, query, queryHeaders, cachedHeaders, cachedBody, cachedStatus, payloadReleaser, err := entry.Payload()
+	defer payloadReleaser(queryHeaders, cachedHeaders)
+	if err != nil {
+		stat.errors.Add(1)
+		return
+	}
+	age := time.Since(time.Unix(0, entry.UpdateAt()))
+
+	status, headers, body, releaser, err := v.fetch(stat.rule, path, query, queryHeaders)
+	defer releaser()
+	if errors.Is(err, repository.ErrOverloaded) {
+		stat.dropped.Add(1) // verification is shed before anything else
+		return
+	} else if err != nil {
+		stat.errors.Add(1)
+		return
+	}
+
+	stat.compared.Add(1)
+
+	var diff []string
+	if stat.rule.Shadow.Compare == config.ShadowCompareJSON {
+		if status != cachedStatus {
+			diff = []string{fmt.Sprintf("status: %d != %d", cachedStatus, status)}
+		} else if diff, err = diffJSON(cachedBody, body, stat.rule.Shadow.IgnorePaths); err != nil {
+			diff = []string{err.Error()}
+		}
+	} else {
+		cached := new(model.Entry).Init()
+		cached.SetPayload(path, query, queryHeaders, cachedHeaders, cachedBody, cachedStatus)
+		upstream := new(model.Entry).Init()
+		upstream.SetPayload(path, query, queryHeaders, headers, body, status)
+		if !cached.IsSamePayload(upstream) {
+			diff = diffPayload(cachedStatus, status, cachedHeaders, headers, cachedBody, body)
+		}
+	}
+
+	if len(diff) > 0 {
+		stat.diverged.Add(1)
+		v.addExample(stat, model.ShadowDiff{
+			At:    time.Now(),
+			Query: string(query),
+			Age:   age.Truncate(time.Second).String(),
+			Diff:  diff,
+		})
+	}
+}
+
+func (v *Verifier) addExample(stat *ruleStat, example model.ShadowDiff) {
+	stat.mu.Lock()
+	defer stat.mu.Unlock()
+	if len(stat.examples) >= v.examples {
+		copy(stat.examples, stat.examples[1:])
+		stat.examples = stat.examples[:len(stat.examples)-1]
+	}
+	stat.examples = append(stat.examples, example)
+}
+
+// Stats returns verification snapshots of rules with enabled verification sorted by path.
+func (v *Verifier) Stats() []model.ShadowStat {
+	if v == nil {
+		return nil
+	}
+	stats := make([]model.ShadowStat, 0, len(v.ordered))
+	for _, s := range v.ordered {
+		stat := model.ShadowStat{
+			Path:       string(s.rule.PathBytes),
+			Compare:    s.rule.Shadow.Compare,
+			SampleRate: s.rule.Shadow.SampleRate,
+			Sampled:    s.sampled.Load(),
+			Dropped:    s.dropped.Load(),
+			Compared:   s.compared.Load(),
+			Diverged:   s.diverged.Load(),
+			Errors:     s.errors.Load(),
+		}
+		if stat.Compared > 0 {
+			stat.DivergenceRate = float64(stat.Diverged) / float64(stat.Compared)
+		}
+
+		s.mu.Lock()
+		stat.Examples = make([]model.ShadowDiff, 0, len(s.examples))
+		for i := len(s.examples) - 1; i >= 0; i-- {
+			stat.Examples = append(stat.Examples, s.examples[i])
+		}
+		s.mu.Unlock()
+
+		stats = append(stats, stat)
+	}
+	return stats
+}
+
+func (v *Verifier) runLogger() {
+	var (
+		ticker                     = utils.NewTicker(v.ctx, 5*time.Second)
+		prevCompared, prevDiverged int64
+	)
+	for {
+		select {
+		case <-v.ctx.Done():
+			return
+		case <-ticker:
+			var compared, diverged int64
+			for _, s := range v.ordered {
+				compared += s.compared.Load()
+				diverged += s.diverged.Load()
+			}
+			if compared == prevCompared {
+				continue
+			}
+
+			log.Info().
+				Str("target", "shadow").
+				Int64("compared", compared-prevCompared).
+				Int64("diverged", diverged-prevDiverged).
+				Msg("[shadow][5s]")
+
+			prevCompared, prevDiverged = compared, diverged
+		}
+	}
+}
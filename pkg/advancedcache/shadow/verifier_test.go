@@ -0,0 +1,78 @@
+package shadow
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/traefik/traefik/v3/pkg/advancedcache/config"
+	"github.com/traefik/traefik/v3/pkg/advancedcache/model"
+	"github.com/traefik/traefik/v3/pkg/advancedcache/repository"
+)
+
+// stubBackend answers every fetch with the same body.
+type stubBackend struct {
+	body []byte
+}
+
+func (b *stubBackend) fetch(*config.Rule, []byte, []byte, *[][2][]byte) (int, *[][2][]byte, []byte, func(), error) {
+	return http.StatusOK, &[][2][]byte{}, b.body, func() {}, nil
+}
+
+func (b *stubBackend) Fetch(rule *config.Rule, path []byte, query []byte, queryHeaders *[][2][]byte) (int, *[][2][]byte, []byte, func(), error) {
+	return b.fetch(rule, path, query, queryHeaders)
+}
+
+func (b *stubBackend) FetchStream(rule *config.Rule, path []byte, query []byte, queryHeaders *[][2][]byte) (int, *[][2][]byte, []byte, io.Reader, int, func(), error) {
+	status, headers, body, release, err := b.fetch(rule, path, query, queryHeaders)
+	return status, headers, body, nil, len(body), release, err
+}
+
+func (b *stubBackend) RevalidatorMaker() func(*config.Rule, []byte, []byte, *[][2][]byte) (int, *[][2][]byte, []byte, func(), error) {
+	return b.fetch
+}
+
+func (b *stubBackend) Limiter() *repository.Limiter {
+	return nil
+}
+
+func newVerifierTestEntry(t *testing.T, cfg *config.Cache, body string) *model.Entry {
+	t.Helper()
+	entry, err := model.NewEntryManual(cfg, []byte("/api"), []byte("id=1"), &[][2][]byte{}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry.SetPayload([]byte("/api"), []byte("id=1"), &[][2][]byte{}, &[][2][]byte{}, []byte(body), http.StatusOK)
+	return entry
+}
+
+func TestVerifierReportsDivergence(t *testing.T) {
+	for _, compare := range []string{config.ShadowComparePayload, config.ShadowCompareJSON} {
+		t.Run(compare, func(t *testing.T) {
+			rule := &config.Rule{
+				PathBytes: []byte("/api"),
+				Shadow:    &config.RuleShadow{SampleRate: 1, Compare: compare, IgnorePaths: [][]string{{"ts"}}},
+			}
+			cfg := &config.Cache{Cache: &config.CacheBox{Rules: map[string]*config.Rule{"/api": rule}}}
+			backend := &stubBackend{body: []byte(`{"price":12,"ts":2}`)}
+			v := NewVerifier(context.Background(), cfg, backend)
+
+			v.verify(newVerifierTestEntry(t, cfg, `{"price":10,"ts":1}`))
+			backend.body = []byte(`{"price":10,"ts":1}`)
+			v.verify(newVerifierTestEntry(t, cfg, `{"price":10,"ts":1}`))
+
+			stats := v.Stats()
+			if len(stats) != 1 {
+				t.Fatalf("expected stats of a single rule, got %d", len(stats))
+			}
+			stat := stats[0]
+			if stat.Compared != 2 || stat.Diverged != 1 || stat.DivergenceRate != 0.5 {
+				t.Fatalf("expected 1 of 2 responses to diverge: %+v", stat)
+			}
+			if len(stat.Examples) != 1 || stat.Examples[0].Query != "id=1" || len(stat.Examples[0].Diff) == 0 {
+				t.Fatalf("expected an example of the divergence: %+v", stat.Examples)
+			}
+		})
+	}
+}
@@ -0,0 +1,152 @@
+package simulator
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+)
+
+// Sample holds the counters of a single virtual clock interval.
+type Sample struct {
+	At          time.Time // End of the interval.
+	Requests    int64
+	Hits        int64
+	Misses      int64 // Cacheable requests which went to the upstream.
+	Uncacheable int64 // Non-GET requests and requests without a matching rule.
+	Bytes       int64 // Response bytes served to clients.
+	HitBytes    int64 // Response bytes served from the cache.
+	Refreshes   int64 // Background refreshes made by the refresh schedule within the refresh rate.
+	Mem         int64 // Storage memory usage at the end of the interval.
+	Len         int64 // Number of stored entries at the end of the interval.
+}
+
+// Upstream returns the number of requests which reached the upstream during the interval.
+func (s Sample) Upstream() float64 {
+	return float64(s.Misses + s.Uncacheable + s.Refreshes)
+}
+
+// Report is the outcome of replaying an access log against a single candidate configuration.
+type Report struct {
+	Name     string
+	Interval time.Duration
+	Samples  []Sample
+	Total    Sample // Counters summed over all samples, Mem and Len hold the peak values.
+}
+
+func (r *Report) add(s Sample) {
+	r.Samples = append(r.Samples, s)
+
+	r.Total.At = s.At
+	r.Total.Requests += s.Requests
+	r.Total.Hits += s.Hits
+	r.Total.Misses += s.Misses
+	r.Total.Uncacheable += s.Uncacheable
+	r.Total.Bytes += s.Bytes
+	r.Total.HitBytes += s.HitBytes
+	r.Total.Refreshes += s.Refreshes
+	r.Total.Mem = max(r.Total.Mem, s.Mem)
+	r.Total.Len = max(r.Total.Len, s.Len)
+}
+
+// HitRatio returns the share of all requests served from the cache.
+func (r *Report) HitRatio() float64 {
+	return ratio(r.Total.Hits, r.Total.Requests)
+}
+
+// ByteHitRatio returns the share of all response bytes served from the cache.
+func (r *Report) ByteHitRatio() float64 {
+	return ratio(r.Total.HitBytes, r.Total.Bytes)
+}
+
+// UpstreamRPS returns the average upstream request rate over the replayed period.
+func (r *Report) UpstreamRPS() float64 {
+	if len(r.Samples) == 0 {
+		return 0
+	}
+	return r.Total.Upstream() / (time.Duration(len(r.Samples)) * r.Interval).Seconds()
+}
+
+// Final returns the last sample (the zero Sample if nothing was replayed).
+func (r *Report) Final() Sample {
+	if len(r.Samples) == 0 {
+		return Sample{}
+	}
+	return r.Samples[len(r.Samples)-1]
+}
+
+// WriteSummary writes one row per candidate with its overall figures.
+func WriteSummary(w io.Writer, reports []*Report) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	_, _ = fmt.Fprintln(tw, "candidate\trequests\thit%\tbyte hit%\tupstream rps\trefreshes\tuncacheable\tpeak mem\tfinal mem\tentries\t")
+	for _, r := range reports {
+		_, _ = fmt.Fprintf(tw, "%s\t%d\t%.2f\t%.2f\t%.2f\t%d\t%d\t%s\t%s\t%d\t\n",
+			r.Name,
+			r.Total.Requests,
+			r.HitRatio()*100,
+			r.ByteHitRatio()*100,
+			r.UpstreamRPS(),
+			r.Total.Refreshes,
+			r.Total.Uncacheable,
+			formatBytes(r.Total.Mem),
+			formatBytes(r.Final().Mem),
+			r.Final().Len,
+		)
+	}
+	return tw.Flush()
+}
+
+// WriteTimeline writes per interval hit ratio, upstream rate and memory usage of every candidate side by side.
+func WriteTimeline(w io.Writer, reports []*Report) error {
+	if len(reports) == 0 {
+		return nil
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+
+	_, _ = fmt.Fprint(tw, "time\t")
+	for _, r := range reports {
+		_, _ = fmt.Fprintf(tw, "%s hit%%\t%s upstream rps\t%s mem\t", r.Name, r.Name, r.Name)
+	}
+	_, _ = fmt.Fprintln(tw)
+
+	// every candidate replays the same log with the same interval, so the samples are aligned
+	for i := range reports[0].Samples {
+		_, _ = fmt.Fprintf(tw, "%s\t", reports[0].Samples[i].At.UTC().Format(time.RFC3339))
+		for _, r := range reports {
+			if i >= len(r.Samples) {
+				_, _ = fmt.Fprint(tw, "-\t-\t-\t")
+				continue
+			}
+			s := r.Samples[i]
+			_, _ = fmt.Fprintf(tw, "%.2f\t%.2f\t%s\t",
+				ratio(s.Hits, s.Requests)*100,
+				s.Upstream()/r.Interval.Seconds(),
+				formatBytes(s.Mem),
+			)
+		}
+		_, _ = fmt.Fprintln(tw)
+	}
+
+	return tw.Flush()
+}
+
+func ratio(part, total int64) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(part) / float64(total)
+}
+
+func formatBytes(b int64) string {
+	const unit = 1024
+	if b < unit {
+		return fmt.Sprintf("%dB", b)
+	}
+	div, exp := int64(unit), 0
+	for n := b / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f%ciB", float64(b)/float64(div), "KMGTPE"[exp])
+}
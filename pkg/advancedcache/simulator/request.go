@@ -0,0 +1,151 @@
+package simulator
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/traefik/traefik/v3/pkg/middlewares/accesslog"
+)
+
+const (
+	FormatAuto   = ""
+	FormatJSON   = "json"
+	FormatCommon = "common"
+
+	commonLogTimeFormat = "02/Jan/2006:15:04:05 -0700"
+	requestHeaderPrefix = "request_"
+)
+
+var errUnparsableLine = errors.New("unparsable access log line")
+
+// Request is a single replayed request taken from an access log line.
+type Request struct {
+	Time    time.Time
+	Method  string
+	Path    []byte
+	Query   []byte
+	Headers [][2][]byte // Request headers which were kept in the access log (JSON format only).
+	Status  int         // Status code returned to the client.
+	Size    int64       // Response body size returned to the client.
+}
+
+// ReadLog parses an access log written by pkg/middlewares/accesslog in the JSON or the common (CLF) format.
+// With FormatAuto the format is detected per line. Unparsable lines are skipped and counted.
+func ReadLog(r io.Reader, format string) (requests []Request, skipped int, err error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		var req Request
+		switch {
+		case format == FormatJSON, format == FormatAuto && line[0] == '{':
+			req, err = parseJSON(line)
+		case format == FormatCommon, format == FormatAuto:
+			req, err = parseCommon(string(line))
+		default:
+			return nil, 0, fmt.Errorf("unknown access log format %q", format)
+		}
+		if err != nil {
+			skipped++
+			continue
+		}
+
+		requests = append(requests, req)
+	}
+
+	if err = scanner.Err(); err != nil {
+		return nil, skipped, fmt.Errorf("read access log: %w", err)
+	}
+
+	return requests, skipped, nil
+}
+
+func parseJSON(line []byte) (Request, error) {
+	var fields map[string]any
+	if err := json.Unmarshal(line, &fields); err != nil {
+		return Request{}, err
+	}
+
+	uri, _ := fields[accesslog.RequestPath].(string)
+	startUTC, _ := fields[accesslog.StartUTC].(string)
+	if uri == "" || startUTC == "" {
+		return Request{}, errUnparsableLine
+	}
+
+	at, err := time.Parse(time.RFC3339Nano, startUTC)
+	if err != nil {
+		return Request{}, err
+	}
+
+	req := Request{Time: at, Method: http.MethodGet}
+	req.Path, req.Query = splitURI(uri)
+
+	if method, ok := fields[accesslog.RequestMethod].(string); ok {
+		req.Method = method
+	}
+	if status, ok := fields[accesslog.DownstreamStatus].(float64); ok {
+		req.Status = int(status)
+	}
+	if size, ok := fields[accesslog.DownstreamContentSize].(float64); ok {
+		req.Size = int64(size)
+	}
+
+	for key, value := range fields {
+		name, found := strings.CutPrefix(key, requestHeaderPrefix)
+		if !found {
+			continue
+		}
+		if v, ok := value.(string); ok {
+			req.Headers = append(req.Headers, [2][]byte{[]byte(http.CanonicalHeaderKey(name)), []byte(v)})
+		}
+	}
+
+	return req, nil
+}
+
+func parseCommon(line string) (Request, error) {
+	fields, err := accesslog.ParseAccessLog(line)
+	if err != nil {
+		return Request{}, err
+	}
+	if len(fields) == 0 {
+		return Request{}, errUnparsableLine
+	}
+
+	at, err := time.Parse(commonLogTimeFormat, fields[accesslog.StartUTC])
+	if err != nil {
+		return Request{}, err
+	}
+
+	// the CLF parser stores the downstream status and size under the origin keys
+	req := Request{Time: at, Method: fields[accesslog.RequestMethod]}
+	req.Path, req.Query = splitURI(fields[accesslog.RequestPath])
+	req.Status, _ = strconv.Atoi(fields[accesslog.OriginStatus])
+	req.Size, _ = strconv.ParseInt(fields[accesslog.OriginContentSize], 10, 64)
+
+	for _, key := range []string{accesslog.RequestRefererHeader, accesslog.RequestUserAgentHeader} {
+		if value := strings.Trim(fields[key], `"`); value != "" && value != "-" {
+			req.Headers = append(req.Headers, [2][]byte{[]byte(strings.TrimPrefix(key, requestHeaderPrefix)), []byte(value)})
+		}
+	}
+
+	return req, nil
+}
+
+func splitURI(uri string) (path, query []byte) {
+	p, q, _ := strings.Cut(uri, "?")
+	return []byte(p), []byte(q)
+}
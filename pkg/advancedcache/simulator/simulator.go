@@ -0,0 +1,157 @@
+package simulator
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/traefik/traefik/v3/pkg/advancedcache/config"
+	"github.com/traefik/traefik/v3/pkg/advancedcache/model"
+	"github.com/traefik/traefik/v3/pkg/advancedcache/storage/lru"
+)
+
+const defaultInterval = time.Minute
+
+// Candidate is a named cache configuration to replay the access log against.
+type Candidate struct {
+	Name string
+	Cfg  *config.Cache
+}
+
+// Options control a simulation run.
+type Options struct {
+	// Interval is the reporting step of the virtual clock (driven by the access log timestamps).
+	Interval time.Duration
+	// Scale multiplies the storage size and every response size, so that a sample of traffic
+	// can be replayed against a proportionally smaller cache. 1 means no scaling.
+	Scale float64
+}
+
+// Simulator replays requests against the real keying (model.Entry) and the real InMemoryStorage
+// eviction/admission policy, using the access log timestamps as a virtual clock: the storage expires
+// and refreshes entries by the log time, so a day of traffic replayed in seconds still ages them.
+type Simulator struct {
+	requests []Request
+	opts     Options
+}
+
+// New creates a Simulator over the given requests.
+func New(requests []Request, opts Options) *Simulator {
+	if opts.Interval <= 0 {
+		opts.Interval = defaultInterval
+	}
+	if opts.Scale <= 0 {
+		opts.Scale = 1
+	}
+	return &Simulator{requests: requests, opts: opts}
+}
+
+// Run replays all requests against every candidate, one after another, and returns their reports in the same order.
+func (s *Simulator) Run(ctx context.Context, candidates []Candidate) []*Report {
+	reports := make([]*Report, 0, len(candidates))
+	for _, candidate := range candidates {
+		reports = append(reports, s.run(ctx, candidate))
+	}
+	return reports
+}
+
+func (s *Simulator) run(ctx context.Context, candidate Candidate) *Report {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	cfg := s.scaled(candidate.Cfg)
+
+	// the storage reads the access log time of the replayed request as the current time
+	var now time.Time
+	db := lru.NewOfflineStorage(ctx, cfg, func() time.Time { return now })
+	report := &Report{Name: candidate.Name, Interval: s.opts.Interval}
+
+	var (
+		body        []byte
+		respHeaders = make([][2][]byte, 0)
+		sample      = Sample{}
+		bucketEnd   time.Time
+	)
+
+	flush := func() {
+		sample.Mem = db.RealMem()
+		sample.Len = db.RealLen()
+		report.add(sample)
+		sample = Sample{At: bucketEnd}
+	}
+
+	for i := range s.requests {
+		req := &s.requests[i]
+
+		if bucketEnd.IsZero() {
+			bucketEnd = req.Time.Truncate(s.opts.Interval).Add(s.opts.Interval)
+			sample.At = bucketEnd
+		}
+		for !req.Time.Before(bucketEnd) {
+			now = bucketEnd
+			sample.Refreshes += int64(db.Advance())
+			flush()
+			bucketEnd = bucketEnd.Add(s.opts.Interval)
+			sample.At = bucketEnd
+		}
+
+		now = req.Time
+		sample.Refreshes += int64(db.Advance())
+
+		size := int64(float64(req.Size) * s.opts.Scale)
+		sample.Requests++
+		sample.Bytes += size
+
+		if req.Method != http.MethodGet {
+			sample.Uncacheable++
+			continue
+		}
+
+		// NewEntryManual filters headers in place, so it works on a copy
+		headers := append(make([][2][]byte, 0, len(req.Headers)), req.Headers...)
+		entry, err := model.NewEntryManual(cfg, req.Path, req.Query, &headers, nil)
+		if err != nil {
+			sample.Uncacheable++
+			continue
+		}
+
+		if _, hit := db.Get(entry); hit {
+			sample.Hits++
+			sample.HitBytes += size
+			continue
+		}
+
+		sample.Misses++
+		if req.Status != http.StatusOK {
+			continue // the cache stores only successful responses
+		}
+
+		if int64(cap(body)) < size {
+			body = make([]byte, size)
+		}
+		entry.SetPayload(req.Path, req.Query, &headers, &respHeaders, body[:size], req.Status)
+		entry.SetUpdatedAt(now.UnixNano())
+		db.Set(entry)
+
+		if cfg.Cache.Eviction.Enabled {
+			db.Evict()
+		}
+	}
+
+	if sample.Requests > 0 {
+		flush()
+	}
+
+	return report
+}
+
+// scaled returns a copy of the candidate configuration with the storage size scaled,
+// the candidate itself is left untouched so that it may be replayed again.
+func (s *Simulator) scaled(cfg *config.Cache) *config.Cache {
+	box := *cfg.Cache
+	storage := *box.Storage
+	storage.Size = uint(float64(storage.Size) * s.opts.Scale)
+	box.Storage = &storage
+
+	return &config.Cache{Cache: &box}
+}
@@ -0,0 +1,101 @@
+package simulator
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/traefik/traefik/v3/pkg/advancedcache/config"
+)
+
+const testLog = `{"StartUTC":"2025-01-01T00:00:00Z","RequestMethod":"GET","RequestPath":"/api/v2/pagedata?project[id]=1&domain=a","DownstreamStatus":200,"DownstreamContentSize":1000,"request_Accept-Encoding":"gzip"}
+{"StartUTC":"2025-01-01T00:00:10Z","RequestMethod":"GET","RequestPath":"/api/v2/pagedata?project[id]=1&domain=a","DownstreamStatus":200,"DownstreamContentSize":1000,"request_Accept-Encoding":"gzip"}
+{"StartUTC":"2025-01-01T00:00:20Z","RequestMethod":"POST","RequestPath":"/api/v2/pagedata","DownstreamStatus":200,"DownstreamContentSize":10}
+127.0.0.1 - - [01/Jan/2025:00:01:05 +0000] "GET /api/v2/pagedata?project[id]=1&domain=a HTTP/1.1" 200 1000 "-" "-" 1 "router" "http://backend" 1ms
+127.0.0.1 - - [01/Jan/2025:00:01:30 +0000] "GET /unknown HTTP/1.1" 200 10 "-" "-" 2 "router" "http://backend" 1ms
+not an access log line
+`
+
+func TestReadLog(t *testing.T) {
+	requests, skipped, err := ReadLog(strings.NewReader(testLog), FormatAuto)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(requests) != 5 || skipped != 1 {
+		t.Fatalf("expected 5 requests and 1 skipped line, got %d and %d", len(requests), skipped)
+	}
+
+	req := requests[0]
+	if string(req.Path) != "/api/v2/pagedata" || string(req.Query) != "project[id]=1&domain=a" {
+		t.Fatalf("unexpected uri: %s?%s", req.Path, req.Query)
+	}
+	if req.Status != 200 || req.Size != 1000 || len(req.Headers) != 1 {
+		t.Fatalf("unexpected request: %+v", req)
+	}
+	if clf := requests[3]; clf.Method != "GET" || clf.Status != 200 || clf.Size != 1000 || !clf.Time.Equal(req.Time.Add(65*time.Second)) {
+		t.Fatalf("unexpected common log request: %+v", clf)
+	}
+}
+
+func TestSimulatorRun(t *testing.T) {
+	requests, _, err := ReadLog(strings.NewReader(testLog), FormatAuto)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := config.LoadConfig("../../../advancedCache.cfg.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reports := New(requests, Options{Interval: time.Minute}).Run(context.Background(), []Candidate{{Name: "default", Cfg: cfg}})
+	if len(reports) != 1 {
+		t.Fatalf("expected a single report, got %d", len(reports))
+	}
+
+	report := reports[0]
+	if len(report.Samples) != 2 {
+		t.Fatalf("expected 2 samples, got %d", len(report.Samples))
+	}
+	if report.Total.Requests != 5 || report.Total.Hits != 1 || report.Total.Uncacheable != 2 {
+		t.Fatalf("unexpected totals: %+v", report.Total)
+	}
+	if report.Total.Len == 0 {
+		t.Fatal("expected the cache to hold the stored entry")
+	}
+}
+
+func TestSimulatorRunOnLogClock(t *testing.T) {
+	// the rule has a 6h max age and a 1h refresh ttl, the entry is read once and then left alone for 8 hours
+	const log = `{"StartUTC":"2025-01-01T00:00:00Z","RequestMethod":"GET","RequestPath":"/api/v2/pagedata?project[id]=1&domain=a","DownstreamStatus":200,"DownstreamContentSize":1000}
+{"StartUTC":"2025-01-01T00:00:10Z","RequestMethod":"GET","RequestPath":"/api/v2/pagedata?project[id]=1&domain=a","DownstreamStatus":200,"DownstreamContentSize":1000}
+{"StartUTC":"2025-01-01T08:00:00Z","RequestMethod":"GET","RequestPath":"/api/v2/pagedata?project[id]=1&domain=a","DownstreamStatus":200,"DownstreamContentSize":1000}
+`
+	requests, _, err := ReadLog(strings.NewReader(log), FormatAuto)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := config.LoadConfig("../../../advancedCache.cfg.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	size := cfg.Cache.Storage.Size
+
+	sim := New(requests, Options{Interval: time.Minute, Scale: 0.5})
+	for range 2 {
+		report := sim.Run(context.Background(), []Candidate{{Name: "default", Cfg: cfg}})[0]
+
+		if report.Total.Hits != 1 || report.Total.Misses != 2 {
+			t.Fatalf("expected the entry to expire by the log time: %+v", report.Total)
+		}
+		if report.Total.Refreshes != 1 {
+			t.Fatalf("expected a single refresh of the read entry, got %d", report.Total.Refreshes)
+		}
+	}
+
+	if cfg.Cache.Storage.Size != size {
+		t.Fatalf("expected the candidate storage size to be left untouched, got %d instead of %d", cfg.Cache.Storage.Size, size)
+	}
+}
@@ -0,0 +1,114 @@
+package lfu
+
+import (
+	"math"
+	"sync"
+	"sync/atomic"
+)
+
+const (
+	// DefaultWindow is the initial share of the admission window (1% as in the W-TinyLFU paper).
+	DefaultWindow = 0.01
+	// DefaultClimberSampleSize is the number of lookups per hill-climbing step.
+	DefaultClimberSampleSize = 100_000
+
+	minWindow        = 0.001
+	maxWindow        = 0.8
+	initialStep      = 0.0625 // 6.25% of the cache per step
+	stepDecay        = 0.98
+	restartThreshold = 0.05 // restart climbing when the hit ratio jumps by 5%
+	minStep          = 0.0001
+)
+
+// Climber adapts the admission window share by hill climbing on the observed hit ratio.
+// Every sampleSize lookups it compares the hit ratio with the previous sample: if it improved
+// the window keeps moving in the same direction, otherwise the direction is reversed.
+// The step decays over time so the window converges, and is restarted on large workload shifts.
+type Climber struct {
+	mu           sync.Mutex
+	enabled      bool
+	sampleSize   int64
+	hits         atomic.Int64
+	misses       atomic.Int64
+	window       atomic.Uint64 // float64 bits
+	step         float64
+	prevHitRatio float64
+}
+
+// NewClimber creates a Climber starting at the given window share.
+// When enabled is false the window stays fixed and Record is a no-op.
+func NewClimber(window float64, enabled bool, sampleSize int64) *Climber {
+	if window <= 0 {
+		window = DefaultWindow
+	}
+	if sampleSize <= 0 {
+		sampleSize = DefaultClimberSampleSize
+	}
+	c := &Climber{
+		enabled:    enabled,
+		sampleSize: sampleSize,
+		step:       initialStep,
+	}
+	c.window.Store(math.Float64bits(clamp(window)))
+	return c
+}
+
+// Window returns the current share of the admission window in [0, 1].
+func (c *Climber) Window() float64 {
+	return math.Float64frombits(c.window.Load())
+}
+
+// Record registers a lookup result and climbs once a full sample has been collected.
+func (c *Climber) Record(hit bool) {
+	if !c.enabled {
+		return
+	}
+
+	var n int64
+	if hit {
+		n = c.hits.Add(1) + c.misses.Load()
+	} else {
+		n = c.misses.Add(1) + c.hits.Load()
+	}
+
+	if n >= c.sampleSize && c.mu.TryLock() {
+		c.climb()
+		c.mu.Unlock()
+	}
+}
+
+// climb must be called under c.mu.
+func (c *Climber) climb() {
+	hits, misses := c.hits.Swap(0), c.misses.Swap(0)
+	total := hits + misses
+	if total == 0 {
+		return
+	}
+
+	hitRatio := float64(hits) / float64(total)
+	delta := hitRatio - c.prevHitRatio
+	c.prevHitRatio = hitRatio
+
+	switch {
+	case math.Abs(delta) >= restartThreshold:
+		// the workload has changed, explore again with the initial step in the direction of the change
+		c.step = math.Copysign(initialStep, c.step)
+		if delta < 0 {
+			c.step = -c.step
+		}
+	case delta < 0:
+		c.step = -c.step * stepDecay
+	default:
+		c.step *= stepDecay
+	}
+
+	if math.Abs(c.step) < minStep {
+		c.step = math.Copysign(minStep, c.step)
+	}
+
+	c.window.Store(math.Float64bits(clamp(c.Window() + c.step)))
+}
+
+func clamp(window float64) float64 {
+	return math.Max(minWindow, math.Min(maxWindow, window))
+}
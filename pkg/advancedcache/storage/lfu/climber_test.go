@@ -0,0 +1,48 @@
+package lfu
+
+import "testing"
+
+func TestClimberDisabledKeepsWindow(t *testing.T) {
+	c := NewClimber(0.05, false, 10)
+	for i := 0; i < 1000; i++ {
+		c.Record(i%2 == 0)
+	}
+	if w := c.Window(); w != 0.05 {
+		t.Fatalf("expected window to stay 0.05, got %f", w)
+	}
+}
+
+func TestClimberReversesOnWorseHitRatio(t *testing.T) {
+	const sampleSize = 100
+	c := NewClimber(0.2, true, sampleSize)
+
+	record := func(hits int) {
+		for i := 0; i < sampleSize; i++ {
+			c.Record(i < hits)
+		}
+	}
+
+	record(50) // first sample: the hit ratio has improved from zero, the window grows
+	grown := c.Window()
+	if grown <= 0.2 {
+		t.Fatalf("expected window to grow, got %f", grown)
+	}
+
+	record(49) // a slightly worse hit ratio must reverse the direction
+	if w := c.Window(); w >= grown {
+		t.Fatalf("expected window to shrink after a worse sample, before=%f after=%f", grown, w)
+	}
+}
+
+func TestClimberIsBounded(t *testing.T) {
+	const sampleSize = 10
+	c := NewClimber(0.5, true, sampleSize)
+	for step := 0; step < 1000; step++ {
+		for i := 0; i < sampleSize; i++ {
+			c.Record(i <= step%sampleSize)
+		}
+		if w := c.Window(); w < minWindow || w > maxWindow {
+			t.Fatalf("window out of bounds: %f", w)
+		}
+	}
+}
@@ -44,6 +44,22 @@ func (c *countMinSketch) estimate(key uint64) uint32 {
 	return mins
 }
 
+// halve divides every counter by two (the aging step of TinyLFU).
+// Concurrent increments are not lost: each counter is updated by CAS.
+func (c *countMinSketch) halve() {
+	for i := 0; i < sketchDepth; i++ {
+		row := &c.table[i]
+		for j := range row {
+			for {
+				old := atomic.LoadUint32(&row[j])
+				if old == 0 || atomic.CompareAndSwapUint32(&row[j], old, old>>1) {
+					break
+				}
+			}
+		}
+	}
+}
+
 func hash64(seed, key uint64) uint64 {
 	x := key ^ seed
 	x ^= x >> 33
@@ -20,18 +20,34 @@ func newDoorkeeper(capacity int) *doorkeeper {
 	}
 }
 
+// Allow reports whether the key has already been seen and marks it as seen otherwise.
 func (d *doorkeeper) Allow(key uint64) bool {
+	if d.Contains(key) {
+		return true
+	}
+
+	h1 := hash64(d.seeds[0], key) & d.mask
+	h2 := hash64(d.seeds[1], key) & d.mask
+
+	atomic.OrUint64(&d.bits[h1/64], 1<<(h1%64))
+	atomic.OrUint64(&d.bits[h2/64], 1<<(h2%64))
+	return false
+}
+
+// Contains reports whether the key has been seen since the last reset without marking it.
+func (d *doorkeeper) Contains(key uint64) bool {
 	h1 := hash64(d.seeds[0], key) & d.mask
 	h2 := hash64(d.seeds[1], key) & d.mask
 
 	b1 := (atomic.LoadUint64(&d.bits[h1/64]) & (1 << (h1 % 64))) != 0
 	b2 := (atomic.LoadUint64(&d.bits[h2/64]) & (1 << (h2 % 64))) != 0
 
-	if b1 && b2 {
-		return true
-	}
+	return b1 && b2
+}
 
-	atomic.OrUint64(&d.bits[h1/64], 1<<(h1%64))
-	atomic.OrUint64(&d.bits[h2/64], 1<<(h2%64))
-	return false
+// reset clears the bloom filter in place, so readers never observe a half-replaced doorkeeper.
+func (d *doorkeeper) reset() {
+	for i := range d.bits {
+		atomic.StoreUint64(&d.bits[i], 0)
+	}
 }
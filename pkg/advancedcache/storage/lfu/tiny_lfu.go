@@ -1,74 +1,84 @@
 package lfu
 
 import (
-	"context"
-	"github.com/traefik/traefik/v3/pkg/advancedcache/model"
 	"sync/atomic"
-	"time"
+
+	"github.com/traefik/traefik/v3/pkg/advancedcache/model"
 )
 
-const doorkeeperCapacity = 1 << 19
+const (
+	doorkeeperCapacity = 1 << 19
 
-type TinyLFU struct {
-	curr atomic.Pointer[countMinSketch]
-	prev atomic.Pointer[countMinSketch]
-	door *doorkeeper
-}
+	// DefaultSampleSize is the number of recorded accesses after which all frequencies are aged (halved).
+	DefaultSampleSize = 10 * sketchWidth
+)
 
-func NewTinyLFU(ctx context.Context) *TinyLFU {
-	lfu := &TinyLFU{door: newDoorkeeper(doorkeeperCapacity)}
-	lfu.curr.Store(newCountMinSketch())
-	lfu.prev.Store(newCountMinSketch())
-	go lfu.run(ctx)
-	return lfu
+// TinyLFU is a frequency based admission filter: a count-min sketch guarded by a doorkeeper bloom filter.
+// Frequencies are aged by sample count (every sampleSize increments the sketch is halved and the doorkeeper
+// is cleared) instead of by wall clock, so the history window scales with traffic.
+type TinyLFU struct {
+	sketch     *countMinSketch
+	door       *doorkeeper
+	sampleSize int64
+	samples    atomic.Int64
+	resetting  atomic.Bool
+	resets     atomic.Int64
 }
 
-func (t *TinyLFU) run(ctx context.Context) {
-	ticker := time.NewTicker(time.Minute)
-	defer ticker.Stop()
-	for {
-		select {
-		case <-ctx.Done():
-			return
-		case <-ticker.C:
-			t.Rotate()
-		}
+// NewTinyLFU creates a TinyLFU which ages its frequencies every sampleSize increments.
+// A non-positive sampleSize falls back to DefaultSampleSize.
+func NewTinyLFU(sampleSize int64) *TinyLFU {
+	if sampleSize <= 0 {
+		sampleSize = DefaultSampleSize
+	}
+	return &TinyLFU{
+		sketch:     newCountMinSketch(),
+		door:       newDoorkeeper(doorkeeperCapacity),
+		sampleSize: sampleSize,
 	}
 }
 
+// Increment records one access of the key.
+// The first access only passes the doorkeeper, so one-hit wonders never pollute the sketch.
 func (t *TinyLFU) Increment(key uint64) {
-	t.curr.Load().Increment(key)
-	t.door.Allow(key)
+	if t.door.Allow(key) {
+		t.sketch.Increment(key)
+	}
+	if t.samples.Add(1) >= t.sampleSize {
+		t.reset()
+	}
 }
 
-func (t *TinyLFU) Admit(new, old *model.Entry) bool {
-	newKey := new.MapKey()
-	oldKey := old.MapKey()
+// Admit reports whether the candidate is worth more than the victim it would replace.
+// The candidate wins only with a strictly higher estimated frequency.
+func (t *TinyLFU) Admit(candidate, victim *model.Entry) bool {
+	return t.Estimate(candidate.MapKey()) > t.Estimate(victim.MapKey())
+}
 
-	if !t.door.Allow(newKey) {
-		return true
+// Estimate returns the approximate access frequency of the key since the last aging.
+func (t *TinyLFU) Estimate(key uint64) uint32 {
+	freq := t.sketch.estimate(key)
+	if t.door.Contains(key) {
+		freq++
 	}
-
-	newFreq := t.estimate(newKey)
-	oldFreq := t.estimate(oldKey)
-
-	return newFreq >= oldFreq
+	return freq
 }
 
-func (t *TinyLFU) Rotate() {
-	// current -> previous
-	curr := t.curr.Load()
-	t.prev.Store(curr)
-
-	// current -> new current (new seeds)
-	t.curr.Store(newCountMinSketch())
-
-	// doorkeeper -> new doorkeeper
-	t.door = newDoorkeeper(doorkeeperCapacity)
+// Resets returns how many times the frequencies were aged.
+func (t *TinyLFU) Resets() int64 {
+	return t.resets.Load()
 }
 
-func (t *TinyLFU) estimate(key uint64) uint32 {
-	c := t.curr.Load().estimate(key)
-	p := t.prev.Load().estimate(key)
-	return (c + p) / 2
+// reset halves the sketch and clears the doorkeeper in place. Only one goroutine performs it at a time,
+// others keep counting into the same structures.
+func (t *TinyLFU) reset() {
+	if !t.resetting.CompareAndSwap(false, true) {
+		return
+	}
+	defer t.resetting.Store(false)
+
+	t.sketch.halve()
+	t.door.reset()
+	t.samples.Store(t.sampleSize / 2)
+	t.resets.Add(1)
 }
@@ -1,15 +1,13 @@
 package lfu
 
 import (
-	"context"
 	"github.com/traefik/traefik/v3/pkg/advancedcache/model"
 	"math/rand"
 	"testing"
-	"time"
 )
 
 func BenchmarkTinyLFUIncrement(b *testing.B) {
-	tlfu := NewTinyLFU(context.Background())
+	tlfu := NewTinyLFU(DefaultSampleSize)
 
 	keys := make([]uint64, b.N)
 	for i := 0; i < b.N; i++ {
@@ -23,13 +21,12 @@ func BenchmarkTinyLFUIncrement(b *testing.B) {
 }
 
 func BenchmarkTinyLFUAdmit(b *testing.B) {
-	tlfu := NewTinyLFU(context.Background())
+	tlfu := NewTinyLFU(DefaultSampleSize)
 
 	// simulate some initial frequencies
 	for i := 0; i < 100000; i++ {
 		tlfu.Increment(uint64(i))
 	}
-	time.Sleep(time.Second) // wait for run()
 
 	newEntry := (&model.Entry{}).SetMapKey(rand.Uint64())
 	oldEntry := (&model.Entry{}).SetMapKey(rand.Uint64())
@@ -1,7 +1,6 @@
 package lfu
 
 import (
-	"context"
 	"math/rand"
 	"sync"
 	"testing"
@@ -11,7 +10,7 @@ import (
 )
 
 func TestTinyLFUConcurrentUsage(t *testing.T) {
-	tlfu := NewTinyLFU(context.Background())
+	tlfu := NewTinyLFU(1 << 20)
 
 	var wg sync.WaitGroup
 
@@ -44,3 +43,48 @@ func TestTinyLFUConcurrentUsage(t *testing.T) {
 
 	wg.Wait()
 }
+
+func TestTinyLFUAgingBySampleCount(t *testing.T) {
+	const sampleSize = 1000
+	tlfu := NewTinyLFU(sampleSize)
+
+	hot := uint64(42)
+	for i := 0; i < sampleSize/2; i++ {
+		tlfu.Increment(hot)
+	}
+	before := tlfu.Estimate(hot)
+	if before < sampleSize/4 {
+		t.Fatalf("expected hot key estimate to be at least %d, got %d", sampleSize/4, before)
+	}
+
+	// push the sample counter over its size with other keys to trigger aging
+	for i := 0; i < sampleSize; i++ {
+		tlfu.Increment(uint64(1_000_000 + i))
+	}
+
+	if tlfu.Resets() == 0 {
+		t.Fatal("expected frequencies to be aged at least once")
+	}
+	if after := tlfu.Estimate(hot); after > before/2+1 {
+		t.Fatalf("expected hot key estimate to be halved, before=%d after=%d", before, after)
+	}
+}
+
+func TestTinyLFUAdmitPrefersFrequent(t *testing.T) {
+	tlfu := NewTinyLFU(1 << 20)
+
+	frequent := (&model.Entry{}).SetMapKey(1)
+	rare := (&model.Entry{}).SetMapKey(2)
+
+	for i := 0; i < 10; i++ {
+		tlfu.Increment(frequent.MapKey())
+	}
+	tlfu.Increment(rare.MapKey())
+
+	if tlfu.Admit(rare, frequent) {
+		t.Fatal("rare candidate must not replace a frequent victim")
+	}
+	if !tlfu.Admit(frequent, rare) {
+		t.Fatal("frequent candidate must replace a rare victim")
+	}
+}
@@ -2,16 +2,31 @@ package lru
 
 import (
 	"context"
+	"github.com/traefik/traefik/v3/pkg/advancedcache/config"
 	"github.com/traefik/traefik/v3/pkg/advancedcache/list"
 	"github.com/traefik/traefik/v3/pkg/advancedcache/model"
+	"github.com/traefik/traefik/v3/pkg/advancedcache/storage/lfu"
 	sharded "github.com/traefik/traefik/v3/pkg/advancedcache/storage/map"
+	"sync"
 	"unsafe"
 )
 
+const (
+	defaultProtectedShare = 0.8
+	victimSampleSize      = 8 // Num of tail entries compared by rank when a victim is picked
+)
+
 // ShardNode represents a single Shard's LRUStorage and accounting info.
 // Each Shard has its own LRUStorage list and a pointer to its element in the balancer's memList.
+// Under the W-TinyLFU policy the Shard is split into three segments:
+// - window: admission window, every new entry lands here first;
+// - lruList: main probation segment, entries which survived the window or were demoted;
+// - protected: main protected segment, entries which were hit at least once in probation.
 type ShardNode struct {
+	mu          sync.Mutex                   // Guards moves of entries between segments
+	window      *list.List[*model.Entry]     // Admission window (W-TinyLFU only)
 	lruList     *list.List[*model.Entry]     // Per-Shard LRUStorage list; less used responses at the back
+	protected   *list.List[*model.Entry]     // Protected main segment (W-TinyLFU only)
 	memListElem *list.Element[*ShardNode]    // Pointer to this node's position in Balance.memList
 	Shard       *sharded.Shard[*model.Entry] // Reference to the actual Shard (map + sync)
 }
@@ -25,6 +40,21 @@ func (s *ShardNode) LruList() *list.List[*model.Entry] {
 	return s.lruList
 }
 
+// Len returns the number of entries tracked by all segments of the Shard.
+func (s *ShardNode) Len() int {
+	return s.window.Len() + s.lruList.Len() + s.protected.Len()
+}
+
+// Admitter decides whether a candidate entry is worth more than the victim it would replace.
+type Admitter interface {
+	Admit(candidate, victim *model.Entry) bool
+}
+
+// Ranker orders entries for eviction, an entry with a lower rank is evicted first.
+type Ranker interface {
+	Rank(entry *model.Entry) int
+}
+
 type Balancer interface {
 	Rebalance()
 	Mem() int64
@@ -34,6 +64,8 @@ type Balancer interface {
 	Remove(shardKey uint64, el *list.Element[*model.Entry])
 	MostLoaded(offset int) (*ShardNode, bool)
 	FindVictim(shardKey uint64) (*model.Entry, bool)
+	Victim(shardKey uint64) (*model.Entry, bool)
+	RuleVictim(shardKey uint64, rule *config.Rule) (*model.Entry, bool)
 }
 
 // Balance maintains per-Shard LRUStorage lists and provides efficient selection of loaded shards for eviction.
@@ -41,10 +73,15 @@ type Balancer interface {
 // - shards is a flat array for O(1) access by Shard index.
 // - shardedMap is the underlying data storage (map of all entries).
 type Balance struct {
-	ctx        context.Context
-	shards     [sharded.NumOfShards]*ShardNode // Shard index → *ShardNode
-	memList    *list.List[*ShardNode]          // Doubly-linked list of shards, ordered by Memory usage (most loaded at front)
-	shardedMap *sharded.Map[*model.Entry]      // Actual underlying storage of entries
+	ctx            context.Context
+	shards         [sharded.NumOfShards]*ShardNode // Shard index → *ShardNode
+	memList        *list.List[*ShardNode]          // Doubly-linked list of shards, ordered by Memory usage (most loaded at front)
+	shardedMap     *sharded.Map[*model.Entry]      // Actual underlying storage of entries
+	segmented      bool                            // W-TinyLFU policy is enabled
+	protectedShare float64                         // Share of the main segment reserved for protected entries
+	admitter       Admitter                        // Decides window candidate vs. probation victim duels
+	climber        *lfu.Climber                    // Provides the current admission window share
+	ranker         Ranker                          // Orders tail entries by rule quota and priority (optional)
 }
 
 var ptrBytesSize uint64 = 8
@@ -58,11 +95,28 @@ func (b *Balance) Mem() int64 {
 }
 
 // NewBalancer creates a new Balance instance and initializes memList.
-func NewBalancer(ctx context.Context, shardedMap *sharded.Map[*model.Entry]) *Balance {
+func NewBalancer(
+	ctx context.Context,
+	cfg *config.Cache,
+	shardedMap *sharded.Map[*model.Entry],
+	admitter Admitter,
+	climber *lfu.Climber,
+	ranker Ranker,
+) *Balance {
+	protectedShare := cfg.Cache.Eviction.Window.Protected
+	if protectedShare <= 0 || protectedShare >= 1 {
+		protectedShare = defaultProtectedShare
+	}
+
 	return &Balance{
-		ctx:        ctx,
-		memList:    list.New[*ShardNode](), // Sorted mode for easier rebalancing
-		shardedMap: shardedMap,
+		ctx:            ctx,
+		memList:        list.New[*ShardNode](), // Sorted mode for easier rebalancing
+		shardedMap:     shardedMap,
+		segmented:      cfg.Cache.Eviction.IsWTinyLFU(),
+		protectedShare: protectedShare,
+		admitter:       admitter,
+		climber:        climber,
+		ranker:         ranker,
 	}
 }
 
@@ -74,25 +128,65 @@ func (b *Balance) Rebalance() {
 // Register inserts a new ShardNode for a given Shard, creates its LRUStorage, and adds it to memList and shards array.
 func (b *Balance) Register(shard *sharded.Shard[*model.Entry]) {
 	n := &ShardNode{
-		Shard:   shard,
-		lruList: list.New[*model.Entry](),
+		Shard:     shard,
+		window:    list.New[*model.Entry](),
+		lruList:   list.New[*model.Entry](),
+		protected: list.New[*model.Entry](),
 	}
 	n.memListElem = b.memList.PushBack(n)
 	b.shards[shard.ID()] = n
 }
 
 // Push inserts a response into the appropriate Shard's LRUStorage list and updates counters.
-// Returns the affected ShardNode for further operations.
+// Under W-TinyLFU a new entry always enters the admission window, admission is decided on eviction.
 func (b *Balance) Push(entry *model.Entry) {
-	entry.SetLruListElement(b.shards[entry.ShardKey()].lruList.PushFront(entry))
+	n := b.shards[entry.ShardKey()]
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if b.segmented {
+		entry.SetLruListElement(n.window.PushFront(entry))
+	} else {
+		entry.SetLruListElement(n.lruList.PushFront(entry))
+	}
 }
 
+// Update bumps the entry position. Under W-TinyLFU a hit in probation promotes the entry into
+// the protected segment, which in its turn demotes its own overflow back to probation.
 func (b *Balance) Update(existing *model.Entry) {
-	b.shards[existing.ShardKey()].lruList.MoveToFront(existing.LruListElement())
+	el := existing.LruListElement()
+	if el == nil {
+		return
+	}
+
+	n := b.shards[existing.ShardKey()]
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	switch l := el.List(); {
+	case l == nil:
+		return // already removed
+	case b.segmented && l == n.lruList:
+		n.lruList.Remove(el)
+		existing.SetLruListElement(n.protected.PushFront(existing))
+		b.demote(n)
+	default:
+		l.MoveToFront(el)
+	}
 }
 
 func (b *Balance) Remove(shardKey uint64, el *list.Element[*model.Entry]) {
-	b.shards[shardKey].lruList.Remove(el)
+	if el == nil {
+		return
+	}
+
+	n := b.shards[shardKey]
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if l := el.List(); l != nil {
+		l.Remove(el)
+	}
 }
 
 // MostLoaded returns the first non-empty Shard node from the front of memList,
@@ -105,6 +199,18 @@ func (b *Balance) MostLoaded(offset int) (*ShardNode, bool) {
 	return el.Value(), ok
 }
 
+// walkMostLoaded calls fn for each Shard node in memList order until fn returns false.
+// It must be called by the evictor only, since memList is reordered by Rebalance of the same goroutine.
+func (b *Balance) walkMostLoaded(fn func(n *ShardNode) bool) {
+	el, ok := b.memList.Next(0)
+	for i, length := 0, b.memList.Len(); ok && i < length; i++ {
+		if !fn(el.Value()) {
+			return
+		}
+		el = el.Next()
+	}
+}
+
 func (b *Balance) FindVictim(shardKey uint64) (*model.Entry, bool) {
 	shardKeyInt64 := int64(shardKey)
 	if el := b.shards[shardKeyInt64].lruList.Back(); el != nil {
@@ -122,3 +228,127 @@ func (b *Balance) FindVictim(shardKey uint64) (*model.Entry, bool) {
 	}
 	return nil, false
 }
+
+// Victim returns the next entry of the Shard which should be evicted.
+// Under plain LRU it is the tail of the Shard list. Under W-TinyLFU, when the window exceeds its share,
+// the window tail (candidate) duels with the main segment tail (victim): the candidate is returned if
+// it loses, otherwise it moves to probation and the victim is returned.
+func (b *Balance) Victim(shardKey uint64) (*model.Entry, bool) {
+	n := b.shards[shardKey]
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if !b.segmented {
+		if el := b.coldest(n.lruList); el != nil {
+			return el.Value(), true
+		}
+		return nil, false
+	}
+
+	windowLimit := int(float64(n.Len()) * b.climber.Window())
+	if windowLimit < 1 {
+		windowLimit = 1
+	}
+
+	// the window may overflow by more than one entry while the shard is filling up or when the climber
+	// shrinks it, the surplus is transferred to probation without a duel (it will compete there)
+	for n.window.Len() > windowLimit+1 {
+		n.transfer(n.window.Back())
+	}
+
+	if n.window.Len() > windowLimit {
+		candidateEl := n.window.Back()
+		victimEl := b.mainVictim(n)
+		if victimEl == nil {
+			n.transfer(candidateEl) // there is no one to compete with
+		} else {
+			candidate, victim := candidateEl.Value(), victimEl.Value()
+			if !b.admitter.Admit(candidate, victim) {
+				return candidate, true
+			}
+			// the candidate has won, move it to probation and evict the victim
+			n.transfer(candidateEl)
+			return victim, true
+		}
+	}
+
+	if el := b.mainVictim(n); el != nil {
+		return el.Value(), true
+	}
+	if el := n.window.Back(); el != nil {
+		return el.Value(), true
+	}
+	return nil, false
+}
+
+// RuleVictim returns the least recently used entry of the given rule in the Shard.
+// Cold segments are scanned first: probation, then the window, then the protected segment.
+func (b *Balance) RuleVictim(shardKey uint64, rule *config.Rule) (*model.Entry, bool) {
+	n := b.shards[shardKey]
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	for _, l := range [...]*list.List[*model.Entry]{n.lruList, n.window, n.protected} {
+		el := l.Back()
+		for i, length := 0, l.Len(); el != nil && i < length; el, i = el.Prev(), i+1 {
+			if entry := el.Value(); entry.Rule() == rule {
+				return entry, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// mainVictim returns the coldest entry of probation or, when it is empty, of the protected segment.
+// Must be called under ShardNode.mu.
+func (b *Balance) mainVictim(n *ShardNode) *list.Element[*model.Entry] {
+	if el := b.coldest(n.lruList); el != nil {
+		return el
+	}
+	return b.coldest(n.protected)
+}
+
+// coldest returns the entry with the lowest rank among a few entries at the tail of the list,
+// the tail-most one wins a tie. Without a ranker it is just the tail.
+// Must be called under ShardNode.mu.
+func (b *Balance) coldest(l *list.List[*model.Entry]) *list.Element[*model.Entry] {
+	el := l.Back()
+	if el == nil || b.ranker == nil {
+		return el
+	}
+
+	coldest, coldestRank := el, b.ranker.Rank(el.Value())
+	limit := min(victimSampleSize, l.Len())
+	for i := 1; i < limit && coldestRank > rankOverQuota; i++ {
+		if el = el.Prev(); el == nil {
+			break
+		}
+		if rank := b.ranker.Rank(el.Value()); rank < coldestRank {
+			coldest, coldestRank = el, rank
+		}
+	}
+	return coldest
+}
+
+// transfer moves an entry from the window to the front of probation.
+// Must be called under ShardNode.mu.
+func (n *ShardNode) transfer(el *list.Element[*model.Entry]) {
+	n.window.Remove(el)
+	entry := el.Value()
+	entry.SetLruListElement(n.lruList.PushFront(entry))
+}
+
+// demote keeps the protected segment within its share by moving its tail to the front of probation.
+// Must be called under ShardNode.mu.
+func (b *Balance) demote(n *ShardNode) {
+	limit := int(float64(n.lruList.Len()+n.protected.Len()) * b.protectedShare)
+	for n.protected.Len() > limit {
+		el := n.protected.Back()
+		if el == nil {
+			return
+		}
+		n.protected.Remove(el)
+		entry := el.Value()
+		entry.SetLruListElement(n.lruList.PushFront(entry))
+	}
+}
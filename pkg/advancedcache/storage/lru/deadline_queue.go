@@ -0,0 +1,147 @@
+package lru
+
+import (
+	"container/heap"
+	"sync"
+	"sync/atomic"
+
+	"github.com/traefik/traefik/v3/pkg/advancedcache/model"
+	sharded "github.com/traefik/traefik/v3/pkg/advancedcache/storage/map"
+)
+
+const (
+	// deadlineCompactionFactor triggers a rebuild of a shard heap when it holds that many times
+	// more items than the shard holds entries (removed entries are dropped from heaps lazily).
+	deadlineCompactionFactor = 2
+	deadlineCompactionMinLen = 64
+)
+
+type deadlineItem struct {
+	deadline int64 // unix nano
+	entry    *model.Entry
+}
+
+// deadlineHeap is a min-heap of entries ordered by their deadline.
+type deadlineHeap []deadlineItem
+
+func (h deadlineHeap) Len() int           { return len(h) }
+func (h deadlineHeap) Less(i, j int) bool { return h[i].deadline < h[j].deadline }
+func (h deadlineHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *deadlineHeap) Push(x any)        { *h = append(*h, x.(deadlineItem)) }
+func (h *deadlineHeap) Pop() any {
+	old := *h
+	n := len(old)
+	item := old[n-1]
+	old[n-1] = deadlineItem{}
+	*h = old[:n-1]
+	return item
+}
+
+type deadlineShard struct {
+	mu   sync.Mutex
+	heap deadlineHeap
+}
+
+// deadlineQueue orders stored entries by a deadline (expiration, refresh and so on).
+// Each Shard has its own heap, so pushes of different shards do not contend and popping costs O(log n).
+// Items of removed entries are not deleted eagerly: they are dropped when popped or when the heap is compacted.
+type deadlineQueue struct {
+	db     *InMemoryStorage
+	len    atomic.Int64
+	shards [sharded.NumOfShards]*deadlineShard
+}
+
+func newDeadlineQueue(db *InMemoryStorage) *deadlineQueue {
+	q := &deadlineQueue{db: db}
+	for i := range q.shards {
+		q.shards[i] = &deadlineShard{}
+	}
+	return q
+}
+
+// Len returns the number of scheduled items, including not yet dropped items of removed entries.
+func (q *deadlineQueue) Len() int64 {
+	return q.len.Load()
+}
+
+func (q *deadlineQueue) push(entry *model.Entry, deadline int64) {
+	s := q.shards[entry.ShardKey()]
+	s.mu.Lock()
+	heap.Push(&s.heap, deadlineItem{deadline: deadline, entry: entry})
+	s.mu.Unlock()
+	q.len.Add(1)
+}
+
+// popDue pops items which are due at now (deadline <= now) of stored entries, at most limit of them (0 = no limit).
+// Shards are visited starting from offset, the returned next offset points to the shard where popping has stopped,
+// so that a limited caller may spread its attention over shards.
+func (q *deadlineQueue) popDue(now int64, limit int, offset uint64, due []deadlineItem) ([]deadlineItem, uint64) {
+	for i := uint64(0); i < sharded.NumOfShards; i++ {
+		id := (offset + i) % sharded.NumOfShards
+		if limit > 0 && len(due) >= limit {
+			return due, id
+		}
+
+		s := q.shards[id]
+		s.mu.Lock()
+		for len(s.heap) > 0 && s.heap[0].deadline <= now && (limit <= 0 || len(due) < limit) {
+			item := heap.Pop(&s.heap).(deadlineItem)
+			q.len.Add(-1)
+			if q.isStored(item.entry) {
+				due = append(due, item)
+			}
+		}
+		q.compact(s)
+		s.mu.Unlock()
+	}
+	return due, offset
+}
+
+// earliest returns the smallest deadline over all shards.
+func (q *deadlineQueue) earliest() (deadline int64, found bool) {
+	for _, s := range q.shards {
+		s.mu.Lock()
+		if len(s.heap) > 0 && (!found || s.heap[0].deadline < deadline) {
+			deadline, found = s.heap[0].deadline, true
+		}
+		s.mu.Unlock()
+	}
+	return deadline, found
+}
+
+func (q *deadlineQueue) reset() {
+	for _, s := range q.shards {
+		s.mu.Lock()
+		q.len.Add(-int64(len(s.heap)))
+		s.heap = nil
+		s.mu.Unlock()
+	}
+}
+
+// compact drops items of entries which are no longer stored when the heap has grown too much over the Shard.
+// Must be called under deadlineShard.mu.
+func (q *deadlineQueue) compact(s *deadlineShard) {
+	n := len(s.heap)
+	// all items of the heap belong to the same map shard, so any of them points to it
+	if n <= deadlineCompactionMinLen || int64(n) <= deadlineCompactionFactor*q.db.shardedMap.Shard(s.heap[0].entry.MapKey()).Len() {
+		return
+	}
+
+	alive := s.heap[:0]
+	for _, item := range s.heap {
+		if q.isStored(item.entry) {
+			alive = append(alive, item)
+		}
+	}
+	for i := len(alive); i < n; i++ {
+		s.heap[i] = deadlineItem{}
+	}
+	s.heap = alive
+	heap.Init(&s.heap)
+	q.len.Add(int64(len(alive) - n))
+}
+
+func (q *deadlineQueue) isStored(entry *model.Entry) bool {
+	stored, found := q.db.shardedMap.Get(entry.MapKey())
+	return found && stored == entry
+}
@@ -4,14 +4,13 @@ import (
 	"context"
 	"github.com/rs/zerolog/log"
 	"github.com/traefik/traefik/v3/pkg/advancedcache/config"
+	sharded "github.com/traefik/traefik/v3/pkg/advancedcache/storage/map"
 	"runtime"
 	"time"
 
 	"github.com/traefik/traefik/v3/pkg/advancedcache/utils"
 )
 
-const fatShardsPercent = 0.17
-
 var evictionStatCh = make(chan EvictionStat, runtime.GOMAXPROCS(0)*4)
 
 // EvictionStat carries statistics for each eviction batch.
@@ -25,22 +24,21 @@ type Evictor interface {
 }
 
 type Evict struct {
-	ctx                 context.Context
-	cfg                 *config.Cache
-	db                  *InMemoryStorage
-	balancer            *Balance
-	memoryThreshold     int64
-	fatShardsPercentage int
+	ctx              context.Context
+	cfg              *config.Cache
+	db               *InMemoryStorage
+	balancer         *Balance
+	memoryThreshold  int64
+	quotaShardOffset uint64 // Shard to start the next quota pass from, spreads quota evictions over shards
 }
 
 func NewEvictor(ctx context.Context, cfg *config.Cache, db *InMemoryStorage, balancer *Balance) *Evict {
 	return &Evict{
-		ctx:                 ctx,
-		cfg:                 cfg,
-		db:                  db,
-		balancer:            balancer,
-		fatShardsPercentage: int(float64(cfg.Cache.Preallocate.Shards) * fatShardsPercent),
-		memoryThreshold:     int64(float64(cfg.Cache.Storage.Size) * cfg.Cache.Eviction.Threshold),
+		ctx:             ctx,
+		cfg:             cfg,
+		db:              db,
+		balancer:        balancer,
+		memoryThreshold: int64(float64(cfg.Cache.Storage.Size) * cfg.Cache.Eviction.Threshold),
 	}
 }
 
@@ -80,48 +78,74 @@ func (e *Evict) ShouldEvict() bool {
 	return e.db.Mem() >= e.memoryThreshold
 }
 
-// shouldEvictRightNow (returns a honest memory usage) checks if current Weight usage has reached or exceeded the threshold.
-func (e *Evict) shouldEvictRightNow() bool {
-	return e.db.RealMem() >= e.memoryThreshold
-}
-
-// evictUntilWithinLimit repeatedly removes entries from the most loaded Shard (tail of InMemoryStorage)
-// until Weight drops below threshold or no more can be evicted.
+// evictUntilWithinLimit brings rules back within their quotas and then removes entries from the most loaded
+// shards until Weight drops below threshold or no more can be evicted. Victims are taken by rank: entries of
+// rules over their quota first, then priority classes from low to critical, so a higher class is only touched
+// when nothing of the lower ones is left at the tails.
 func (e *Evict) evictUntilWithinLimit() (items int, mem int64) {
-	shardOffset := 0
-	for e.shouldEvictRightNow() {
-		shardOffset++
-		if shardOffset >= e.fatShardsPercentage {
-			e.balancer.Rebalance()
-			shardOffset = 0
-		}
+	items, mem = e.evictOverQuota()
 
-		shard, found := e.balancer.MostLoaded(shardOffset)
-		if !found {
+	// the honest memory usage is calculated once, then it is decreased by freed bytes
+	excess := e.db.RealMem() - e.memoryThreshold
+	for maxRank := rankOverQuota; maxRank <= config.PriorityClassCritical && excess >= 0; maxRank++ {
+		if !e.db.quotas.HasRank(maxRank) {
 			continue
 		}
-
-		if shard.LruList().Len() == 0 {
-			continue
+		for excess >= 0 {
+			sweptItems, sweptMem := e.sweep(maxRank, excess)
+			if sweptItems == 0 {
+				break // nothing of this rank is left at the tails, go to the next one
+			}
+			items += sweptItems
+			mem += sweptMem
+			excess -= sweptMem
 		}
+	}
+	return
+}
 
-		offset := 0
-		evictions := 0
-		for e.shouldEvictRightNow() {
-			el, ok := shard.LruList().Next(offset)
-			if !ok {
-				break // end of the LRU list, move to next
+// sweep walks shards starting from the most loaded one and evicts their victims while their rank
+// is not above maxRank, until the excess of memory is freed.
+func (e *Evict) sweep(maxRank int, excess int64) (items int, mem int64) {
+	e.balancer.Rebalance()
+	e.balancer.walkMostLoaded(func(shard *ShardNode) bool {
+		// the number of attempts is bounded by the shard length to not spin on entries which are already gone
+		for attempts := shard.Len(); attempts > 0 && mem <= excess; attempts-- {
+			victim, ok := e.balancer.Victim(shard.Shard.ID())
+			if !ok || e.db.quotas.Rank(victim) > maxRank {
+				break // the shard is empty or keeps more valuable entries only, move to next
 			}
 
-			freedMem, hit := e.db.Remove(el.Value())
-			if hit {
+			if freedMem, hit := e.db.evict(victim); hit {
 				items++
-				evictions++
 				mem += freedMem
 			}
+		}
+		return mem <= excess
+	})
+	return
+}
 
-			offset++
+// evictOverQuota removes the least recently used entries of each rule which exceeds its quota
+// until the rule fits into it, regardless of the overall memory usage.
+func (e *Evict) evictOverQuota() (items int, mem int64) {
+	for _, rule := range e.db.quotas.OverQuota() {
+		for i := uint64(0); i < sharded.NumOfShards && e.db.quotas.IsOverQuota(rule); i++ {
+			shardKey := (e.quotaShardOffset + i) % sharded.NumOfShards
+			for e.db.quotas.IsOverQuota(rule) {
+				victim, found := e.balancer.RuleVictim(shardKey, rule)
+				if !found {
+					break // no more entries of the rule in the shard, move to next
+				}
+				if freedMem, hit := e.db.evict(victim); hit {
+					items++
+					mem += freedMem
+				} else {
+					break
+				}
+			}
 		}
+		e.quotaShardOffset = (e.quotaShardOffset + 1) % sharded.NumOfShards
 	}
 	return
 }
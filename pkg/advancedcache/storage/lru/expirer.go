@@ -0,0 +1,123 @@
+package lru
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/traefik/traefik/v3/pkg/advancedcache/config"
+	"github.com/traefik/traefik/v3/pkg/advancedcache/model"
+	"github.com/traefik/traefik/v3/pkg/advancedcache/utils"
+)
+
+const defaultExpirationInterval = time.Second
+
+// Expirer removes entries which have outlived their hard max age (see config.Cache.MaxAge).
+// Entries are kept in a deadline queue, so collection costs O(log n) per expired entry and does not depend
+// on the refresher sampling. An entry deadline moves forward when it is refreshed, the queue is not updated
+// in that case: a popped entry is re-checked and pushed back with its actual deadline.
+type Expirer struct {
+	ctx      context.Context
+	cfg      *config.Cache
+	db       *InMemoryStorage
+	interval time.Duration
+	queue    *deadlineQueue
+	due      []deadlineItem // Reused by Collect, which is never called concurrently
+}
+
+func NewExpirer(ctx context.Context, cfg *config.Cache, db *InMemoryStorage) *Expirer {
+	interval := defaultExpirationInterval
+	if cfg.Cache.Expiration != nil && cfg.Cache.Expiration.Interval > 0 {
+		interval = cfg.Cache.Expiration.Interval
+	}
+
+	return &Expirer{
+		ctx:      ctx,
+		cfg:      cfg,
+		db:       db,
+		interval: interval,
+		queue:    newDeadlineQueue(db),
+	}
+}
+
+// Run launches the background collection of expired entries.
+func (e *Expirer) Run() *Expirer {
+	if e.cfg.Cache.Enabled {
+		go func() {
+			var (
+				expiredPer5Sec int
+				freedPer5Sec   int64
+				collectTicker  = utils.NewTicker(e.ctx, e.interval)
+				logTicker      = utils.NewTicker(e.ctx, 5*time.Second)
+			)
+			for {
+				select {
+				case <-e.ctx.Done():
+					return
+				case now := <-collectTicker:
+					items, freed := e.Collect(now.UnixNano())
+					expiredPer5Sec += items
+					freedPer5Sec += freed
+				case <-logTicker:
+					if expiredPer5Sec <= 0 {
+						continue
+					}
+
+					log.Info().
+						Str("target", "expiration").
+						Int64("freedMemBytes", freedPer5Sec).
+						Int("expiredItems", expiredPer5Sec).
+						Msg("[expiration][5s]")
+
+					expiredPer5Sec = 0
+					freedPer5Sec = 0
+				}
+			}
+		}()
+	}
+	return e
+}
+
+// Push schedules the expiration of a stored entry, entries of rules without max age are ignored.
+func (e *Expirer) Push(entry *model.Entry) {
+	if deadline := e.removeAt(entry); deadline > 0 {
+		e.queue.push(entry, deadline)
+	}
+}
+
+// removeAt returns the unix nano time when the entry is removed: an expired entry is kept
+// for stale_if_error more as a fallback for failed backend fetches.
+func (e *Expirer) removeAt(entry *model.Entry) int64 {
+	expiresAt := entry.ExpiresAt(e.cfg)
+	if expiresAt <= 0 {
+		return 0
+	}
+	return expiresAt + e.cfg.StaleIfError().Nanoseconds()
+}
+
+// Collect removes all entries which are expired (and out of the stale grace period) at the given unix nano time.
+func (e *Expirer) Collect(now int64) (items int, freedMem int64) {
+	e.due, _ = e.queue.popDue(now, 0, 0, e.due[:0])
+	for i := range e.due {
+		entry := e.due[i].entry
+		e.due[i] = deadlineItem{}
+
+		if deadline := e.removeAt(entry); deadline > now {
+			e.queue.push(entry, deadline) // was refreshed meanwhile
+			continue
+		}
+		if !e.queue.isStored(entry) {
+			continue // was replaced after it had been popped
+		}
+		if freed, hit := e.db.expire(entry); hit {
+			items++
+			freedMem += freed
+		}
+	}
+	return items, freedMem
+}
+
+// Reset drops all scheduled expirations (the storage was cleared).
+func (e *Expirer) Reset() {
+	e.queue.reset()
+}
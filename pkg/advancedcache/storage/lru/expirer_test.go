@@ -0,0 +1,110 @@
+package lru
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/traefik/traefik/v3/pkg/advancedcache/config"
+)
+
+func TestExpiredEntryIsNeverServed(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	cfg := newQuotaTestConfig(8<<20, map[string]config.RuleEviction{"/news": {PriorityClass: config.PriorityClassNormal}})
+	cfg.Cache.Rules["/news"].MaxAge = 10 * time.Millisecond
+	db := NewOfflineStorage(ctx, cfg, time.Now)
+
+	entry := newQuotaTestEntry(t, cfg, "/news", 1)
+	db.Set(entry)
+	if _, found := db.Get(entry); !found {
+		t.Fatal("expected a fresh entry to be served")
+	}
+
+	time.Sleep(20 * time.Millisecond)
+
+	if _, found := db.Get(entry); found {
+		t.Fatal("expected an expired entry to not be served")
+	}
+	if stat := findRuleStat(t, db, "/news"); stat.Len != 0 || stat.Expired != 1 {
+		t.Fatalf("expected the expired entry to be removed: %+v", stat)
+	}
+}
+
+func TestExpirerCollectsUnreadEntries(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	cfg := newQuotaTestConfig(8<<20, map[string]config.RuleEviction{
+		"/news":   {PriorityClass: config.PriorityClassNormal},
+		"/static": {PriorityClass: config.PriorityClassNormal},
+	})
+	cfg.Cache.Expiration = &config.Expiration{MaxAge: time.Hour}
+	cfg.Cache.Rules["/news"].MaxAge = 50 * time.Millisecond
+	db := NewOfflineStorage(ctx, cfg, time.Now)
+
+	for i := 0; i < 100; i++ {
+		db.Set(newQuotaTestEntry(t, cfg, "/news", i))
+		db.Set(newQuotaTestEntry(t, cfg, "/static", i))
+	}
+
+	if items, _ := db.expirer.Collect(time.Now().UnixNano()); items != 0 {
+		t.Fatalf("expected nothing to expire yet, got %d", items)
+	}
+
+	time.Sleep(30 * time.Millisecond)
+
+	// the refresh moves the deadline forward, the heap must re-check the entry instead of removing it
+	refreshed := newQuotaTestEntry(t, cfg, "/news", 0)
+	stored, _ := db.Get(refreshed)
+	stored.TouchUpdatedAt()
+
+	time.Sleep(30 * time.Millisecond)
+
+	if items, _ := db.expirer.Collect(time.Now().UnixNano()); items != 99 {
+		t.Fatalf("expected 99 expired entries of the rule with its own max age, got %d", items)
+	}
+	if _, found := db.Get(refreshed); !found {
+		t.Fatal("expected the refreshed entry to survive")
+	}
+	if stat := findRuleStat(t, db, "/static"); stat.Len != 100 {
+		t.Fatalf("expected the default max age to keep entries of another rule: %+v", stat)
+	}
+
+	if items, _ := db.expirer.Collect(time.Now().Add(2 * time.Hour).UnixNano()); items != 101 {
+		t.Fatalf("expected the rest of entries to expire, got %d", items)
+	}
+}
+
+func TestExpiredEntryIsKeptAsStaleFallback(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	cfg := newQuotaTestConfig(8<<20, map[string]config.RuleEviction{"/news": {PriorityClass: config.PriorityClassNormal}})
+	cfg.Cache.Expiration = &config.Expiration{StaleIfError: time.Hour}
+	cfg.Cache.Rules["/news"].MaxAge = 10 * time.Millisecond
+	db := NewOfflineStorage(ctx, cfg, time.Now)
+
+	entry := newQuotaTestEntry(t, cfg, "/news", 1)
+	db.Set(entry)
+
+	time.Sleep(20 * time.Millisecond)
+
+	if _, found := db.Get(entry); found {
+		t.Fatal("expected an expired entry to not be served as a hit")
+	}
+	if _, found := db.GetStale(entry); !found {
+		t.Fatal("expected an expired entry to be available as a stale fallback")
+	}
+	if items, _ := db.expirer.Collect(time.Now().UnixNano()); items != 0 {
+		t.Fatalf("expected the stale entry to be kept, got %d expired", items)
+	}
+
+	if items, _ := db.expirer.Collect(time.Now().Add(2 * time.Hour).UnixNano()); items != 1 {
+		t.Fatalf("expected the stale entry to be removed after the grace period, got %d", items)
+	}
+	if _, found := db.GetStale(entry); found {
+		t.Fatal("expected no stale fallback after the grace period")
+	}
+}
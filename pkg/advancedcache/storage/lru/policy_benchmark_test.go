@@ -0,0 +1,316 @@
+package lru
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"math/rand"
+	"os"
+	"strconv"
+	"testing"
+
+	"github.com/traefik/traefik/v3/pkg/advancedcache/config"
+	"github.com/traefik/traefik/v3/pkg/advancedcache/model"
+	"github.com/traefik/traefik/v3/pkg/advancedcache/storage/lfu"
+	sharded "github.com/traefik/traefik/v3/pkg/advancedcache/storage/map"
+	"github.com/zeebo/xxh3"
+)
+
+// traceEnv points to a file with one key per line which will be replayed in addition to the synthetic traces.
+const traceEnv = "ADVANCEDCACHE_TRACE"
+
+const (
+	simCapacity  = 1_000
+	simTraceLen  = 300_000
+	simKeysSpace = 50_000
+)
+
+type trace struct {
+	name string
+	keys []uint64
+}
+
+// rotatingTinyLFU is a copy of the admission filter the LRU policy used before W-TinyLFU, kept as the baseline:
+// two count-min sketches rotated on a fixed period, a doorkeeper admitting any first-seen key,
+// and a newcomer admitted as soon as it is as frequent as the victim.
+// The original rotated every minute, the replay rotates every rotateEvery accesses instead.
+type rotatingTinyLFU struct {
+	curr        *rotatingSketch
+	prev        *rotatingSketch
+	door        map[uint64]struct{}
+	accesses    int
+	rotateEvery int
+}
+
+func newRotatingTinyLFU(rotateEvery int) *rotatingTinyLFU {
+	return &rotatingTinyLFU{
+		curr:        newRotatingSketch(),
+		prev:        newRotatingSketch(),
+		door:        make(map[uint64]struct{}),
+		rotateEvery: rotateEvery,
+	}
+}
+
+func (t *rotatingTinyLFU) Increment(key uint64) {
+	t.curr.increment(key)
+	t.door[key] = struct{}{}
+
+	t.accesses++
+	if t.accesses%t.rotateEvery == 0 {
+		t.prev, t.curr = t.curr, newRotatingSketch()
+		t.door = make(map[uint64]struct{})
+	}
+}
+
+func (t *rotatingTinyLFU) Admit(candidate, victim *model.Entry) bool {
+	if _, seen := t.door[candidate.MapKey()]; !seen {
+		return true
+	}
+	return t.estimate(candidate.MapKey()) >= t.estimate(victim.MapKey())
+}
+
+func (t *rotatingTinyLFU) estimate(key uint64) uint32 {
+	return (t.curr.estimate(key) + t.prev.estimate(key)) / 2
+}
+
+const (
+	rotatingSketchDepth = 4
+	rotatingSketchWidth = 1 << 17
+)
+
+type rotatingSketch struct {
+	table [rotatingSketchDepth][rotatingSketchWidth]uint32
+	seeds [rotatingSketchDepth]uint64
+}
+
+func newRotatingSketch() *rotatingSketch {
+	s := &rotatingSketch{}
+	for i := range s.seeds {
+		s.seeds[i] = rand.Uint64()
+	}
+	return s
+}
+
+func (s *rotatingSketch) increment(key uint64) {
+	for i := range s.seeds {
+		s.table[i][xxh3.HashSeed(keyBytes(key), s.seeds[i])%rotatingSketchWidth]++
+	}
+}
+
+func (s *rotatingSketch) estimate(key uint64) uint32 {
+	mins := ^uint32(0)
+	for i := range s.seeds {
+		mins = min(mins, s.table[i][xxh3.HashSeed(keyBytes(key), s.seeds[i])%rotatingSketchWidth])
+	}
+	return mins
+}
+
+func keyBytes(key uint64) []byte {
+	return binary.LittleEndian.AppendUint64(nil, key)
+}
+
+// simCache replays a trace against a single shard of the real Balance with a capacity in entries.
+// The eviction happens synchronously right after each insertion.
+type simCache struct {
+	balancer  *Balance
+	tinyLFU   *lfu.TinyLFU
+	baseline  *rotatingTinyLFU
+	climber   *lfu.Climber
+	entries   map[uint64]*model.Entry
+	capacity  int
+	segmented bool
+}
+
+func newSimCache(policy string, capacity int) *simCache {
+	cfg := &config.Cache{Cache: &config.CacheBox{Eviction: &config.Eviction{
+		Policy: policy,
+		Window: config.EvictionWindow{Size: lfu.DefaultWindow, Adaptive: true, SampleSize: int64(capacity * 10)},
+	}}}
+
+	tinyLFU := lfu.NewTinyLFU(int64(capacity * 10))
+	climber := lfu.NewClimber(
+		cfg.Cache.Eviction.Window.Size,
+		cfg.Cache.Eviction.IsWTinyLFU() && cfg.Cache.Eviction.Window.Adaptive,
+		cfg.Cache.Eviction.Window.SampleSize,
+	)
+	balancer := NewBalancer(context.Background(), cfg, nil, tinyLFU, climber, nil)
+	balancer.Register(sharded.NewShard[*model.Entry](0, capacity))
+
+	return &simCache{
+		balancer:  balancer,
+		tinyLFU:   tinyLFU,
+		baseline:  newRotatingTinyLFU(capacity * 10),
+		climber:   climber,
+		entries:   make(map[uint64]*model.Entry, capacity),
+		capacity:  capacity,
+		segmented: cfg.Cache.Eviction.IsWTinyLFU(),
+	}
+}
+
+func (c *simCache) access(key uint64) (hit bool) {
+	if c.segmented {
+		c.tinyLFU.Increment(key)
+	} else {
+		c.baseline.Increment(key)
+	}
+
+	if entry, found := c.entries[key]; found {
+		c.climber.Record(true)
+		c.balancer.Update(entry)
+		return true
+	}
+	c.climber.Record(false)
+
+	entry := new(model.Entry).Init().SetMapKey(key)
+
+	if !c.segmented && len(c.entries) >= c.capacity {
+		// the current policy: a newcomer must be as frequent as the LRU tail to be admitted
+		victim, found := c.balancer.Victim(0)
+		if !found || !c.baseline.Admit(entry, victim) {
+			return false
+		}
+		c.remove(victim)
+	}
+
+	c.entries[key] = entry
+	c.balancer.Push(entry)
+
+	for len(c.entries) > c.capacity {
+		victim, found := c.balancer.Victim(0)
+		if !found {
+			break
+		}
+		c.remove(victim)
+	}
+
+	return false
+}
+
+func (c *simCache) remove(entry *model.Entry) {
+	c.balancer.Remove(0, entry.LruListElement())
+	delete(c.entries, entry.MapKey())
+}
+
+func replay(policy string, capacity int, keys []uint64) (hitRatio float64) {
+	c := newSimCache(policy, capacity)
+	hits := 0
+	for _, key := range keys {
+		if c.access(key) {
+			hits++
+		}
+	}
+	return float64(hits) / float64(len(keys))
+}
+
+// zipfTrace is a stable skewed workload.
+func zipfTrace(r *rand.Rand) []uint64 {
+	zipf := rand.NewZipf(r, 1.01, 1, simKeysSpace)
+	keys := make([]uint64, simTraceLen)
+	for i := range keys {
+		keys[i] = zipf.Uint64()
+	}
+	return keys
+}
+
+// newsTrace mixes a skewed background with short bursts of fresh keys which are hot for a while (news pages).
+func newsTrace(r *rand.Rand) []uint64 {
+	zipf := rand.NewZipf(r, 1.01, 1, simKeysSpace)
+	keys := make([]uint64, simTraceLen)
+	burstBase := uint64(simKeysSpace)
+	for i := range keys {
+		if i%5_000 == 0 {
+			burstBase += 100
+		}
+		if r.Intn(2) == 0 {
+			keys[i] = burstBase + uint64(r.Intn(100))
+		} else {
+			keys[i] = zipf.Uint64()
+		}
+	}
+	return keys
+}
+
+// scanTrace interrupts a skewed workload by long sequential scans of keys which are never requested again.
+func scanTrace(r *rand.Rand) []uint64 {
+	zipf := rand.NewZipf(r, 1.01, 1, simKeysSpace)
+	keys := make([]uint64, simTraceLen)
+	scanKey := uint64(simKeysSpace)
+	for i := range keys {
+		if (i/10_000)%3 == 2 {
+			scanKey++
+			keys[i] = scanKey
+		} else {
+			keys[i] = zipf.Uint64()
+		}
+	}
+	return keys
+}
+
+// fileTrace reads one key per line, numeric keys are taken as is and any other line is hashed.
+func fileTrace(tb testing.TB, path string) []uint64 {
+	tb.Helper()
+
+	f, err := os.Open(path)
+	if err != nil {
+		tb.Fatalf("open trace %s: %v", path, err)
+	}
+	defer func() { _ = f.Close() }()
+
+	var keys []uint64
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if key, err := strconv.ParseUint(line, 10, 64); err == nil {
+			keys = append(keys, key)
+		} else {
+			keys = append(keys, xxh3.HashString(line))
+		}
+	}
+	if err = scanner.Err(); err != nil {
+		tb.Fatalf("read trace %s: %v", path, err)
+	}
+	return keys
+}
+
+func traces(tb testing.TB) []trace {
+	r := rand.New(rand.NewSource(1))
+	out := []trace{
+		{name: "zipf", keys: zipfTrace(r)},
+		{name: "news", keys: newsTrace(r)},
+		{name: "scan", keys: scanTrace(r)},
+	}
+	if path := os.Getenv(traceEnv); path != "" {
+		out = append(out, trace{name: "file", keys: fileTrace(tb, path)})
+	}
+	return out
+}
+
+// BenchmarkPolicyHitRatio replays the traces against both eviction policies and reports their hit ratios,
+// the LRU policy being guarded by the legacy rotating TinyLFU:
+//
+//	go test -run=^$ -bench=PolicyHitRatio ./pkg/advancedcache/storage/lru/
+//	ADVANCEDCACHE_TRACE=keys.txt go test -run=^$ -bench=PolicyHitRatio ./pkg/advancedcache/storage/lru/
+func BenchmarkPolicyHitRatio(b *testing.B) {
+	for _, tr := range traces(b) {
+		for _, policy := range []string{config.EvictionPolicyLRU, config.EvictionPolicyWTinyLFU} {
+			b.Run(tr.name+"/"+policy, func(b *testing.B) {
+				var hitRatio float64
+				for i := 0; i < b.N; i++ {
+					hitRatio = replay(policy, simCapacity, tr.keys)
+				}
+				b.ReportMetric(hitRatio*100, "hit%")
+			})
+		}
+	}
+}
+
+func TestWTinyLFUHandlesRecencyBursts(t *testing.T) {
+	keys := newsTrace(rand.New(rand.NewSource(1)))
+
+	lruHitRatio := replay(config.EvictionPolicyLRU, simCapacity, keys)
+	wtinylfuHitRatio := replay(config.EvictionPolicyWTinyLFU, simCapacity, keys)
+
+	if wtinylfuHitRatio <= lruHitRatio {
+		t.Fatalf("expected W-TinyLFU to beat the current policy on recency bursts: wtinylfu=%.4f lru=%.4f", wtinylfuHitRatio, lruHitRatio)
+	}
+}
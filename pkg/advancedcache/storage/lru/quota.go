@@ -0,0 +1,177 @@
+package lru
+
+import (
+	"sort"
+	"sync/atomic"
+
+	"github.com/traefik/traefik/v3/pkg/advancedcache/config"
+	"github.com/traefik/traefik/v3/pkg/advancedcache/model"
+	"github.com/traefik/traefik/v3/pkg/advancedcache/storage/lfu"
+)
+
+// rankOverQuota is the eviction rank of entries whose rule exceeds its quota, such entries go first.
+const rankOverQuota = -1
+
+// ruleUsage tracks how much of the storage a single rule occupies.
+type ruleUsage struct {
+	rule      *config.Rule
+	mem       atomic.Int64
+	len       atomic.Int64
+	evictions atomic.Int64
+	expired   atomic.Int64
+	rejected  atomic.Int64
+}
+
+func (u *ruleUsage) overQuota() bool {
+	return u.rule.Eviction.MaxBytes > 0 && u.mem.Load() > u.rule.Eviction.MaxBytes
+}
+
+// Quotas accounts storage occupancy per rule and ranks entries for eviction by their rule
+// quota and priority class. Rules are known at start, so the set of usages is never modified.
+type Quotas struct {
+	storageSize int64
+	usages      map[*config.Rule]*ruleUsage
+	ordered     []*ruleUsage // Sorted by path for stable output
+}
+
+// NewQuotas creates per-rule accounting for all configured rules.
+func NewQuotas(cfg *config.Cache) *Quotas {
+	q := &Quotas{
+		storageSize: int64(cfg.Cache.Storage.Size),
+		usages:      make(map[*config.Rule]*ruleUsage, len(cfg.Cache.Rules)),
+		ordered:     make([]*ruleUsage, 0, len(cfg.Cache.Rules)),
+	}
+	for _, rule := range cfg.Cache.Rules {
+		usage := &ruleUsage{rule: rule}
+		q.usages[rule] = usage
+		q.ordered = append(q.ordered, usage)
+	}
+	sort.Slice(q.ordered, func(i, j int) bool {
+		return string(q.ordered[i].rule.PathBytes) < string(q.ordered[j].rule.PathBytes)
+	})
+	return q
+}
+
+func (q *Quotas) usage(entry *model.Entry) *ruleUsage {
+	if q == nil {
+		return nil
+	}
+	return q.usages[entry.Rule()]
+}
+
+// Add accounts a stored entry (or a change of its weight when it is updated).
+func (q *Quotas) Add(entry *model.Entry, weight int64, length int64) {
+	if u := q.usage(entry); u != nil {
+		u.mem.Add(weight)
+		u.len.Add(length)
+	}
+}
+
+// Evicted accounts an entry removed by the evictor.
+func (q *Quotas) Evicted(entry *model.Entry) {
+	if u := q.usage(entry); u != nil {
+		u.evictions.Add(1)
+	}
+}
+
+// Expired accounts an entry removed after its max age.
+func (q *Quotas) Expired(entry *model.Entry) {
+	if u := q.usage(entry); u != nil {
+		u.expired.Add(1)
+	}
+}
+
+// Rejected accounts an entry which was not admitted into the storage.
+func (q *Quotas) Rejected(entry *model.Entry) {
+	if u := q.usage(entry); u != nil {
+		u.rejected.Add(1)
+	}
+}
+
+// Rank returns the eviction rank of the entry: entries over their rule quota have the lowest rank,
+// others are ranked by the rule priority class. An entry with a lower rank is evicted first.
+func (q *Quotas) Rank(entry *model.Entry) int {
+	u := q.usage(entry)
+	if u == nil {
+		return config.PriorityClassNormal
+	}
+	if u.overQuota() {
+		return rankOverQuota
+	}
+	return u.rule.Eviction.PriorityClass
+}
+
+// OverQuota returns rules which currently exceed their quota.
+func (q *Quotas) OverQuota() (rules []*config.Rule) {
+	for _, u := range q.ordered {
+		if u.overQuota() {
+			rules = append(rules, u.rule)
+		}
+	}
+	return rules
+}
+
+// HasRank reports whether the storage may keep entries of the given eviction rank.
+func (q *Quotas) HasRank(rank int) bool {
+	if rank == config.PriorityClassNormal {
+		return true // entries without a rule are ranked as normal ones
+	}
+	for _, u := range q.ordered {
+		if rank == rankOverQuota && u.overQuota() || u.rule.Eviction.PriorityClass == rank && u.len.Load() > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// IsOverQuota reports whether the rule currently exceeds its quota.
+func (q *Quotas) IsOverQuota(rule *config.Rule) bool {
+	if u, ok := q.usages[rule]; ok {
+		return u.overQuota()
+	}
+	return false
+}
+
+// Reset zeroes occupancy of all rules (the storage was cleared), eviction counters are kept.
+func (q *Quotas) Reset() {
+	for _, u := range q.ordered {
+		u.mem.Store(0)
+		u.len.Store(0)
+	}
+}
+
+// Stats returns occupancy snapshots of all rules sorted by path.
+func (q *Quotas) Stats() []model.RuleStat {
+	stats := make([]model.RuleStat, 0, len(q.ordered))
+	for _, u := range q.ordered {
+		stat := model.RuleStat{
+			Path:      string(u.rule.PathBytes),
+			Priority:  u.rule.Eviction.Priority,
+			Mem:       u.mem.Load(),
+			Len:       u.len.Load(),
+			MaxBytes:  u.rule.Eviction.MaxBytes,
+			Evictions: u.evictions.Load(),
+			Expired:   u.expired.Load(),
+			Rejected:  u.rejected.Load(),
+		}
+		if q.storageSize > 0 {
+			stat.Share = float64(stat.Mem) / float64(q.storageSize)
+		}
+		stats = append(stats, stat)
+	}
+	return stats
+}
+
+// ruleAdmitter decides W-TinyLFU duels and the legacy LRU admission with respect to rule quotas and
+// priority classes first, the TinyLFU frequency estimate breaks ties.
+type ruleAdmitter struct {
+	quotas  *Quotas
+	tinyLFU *lfu.TinyLFU
+}
+
+func (a *ruleAdmitter) Admit(candidate, victim *model.Entry) bool {
+	if candidateRank, victimRank := a.quotas.Rank(candidate), a.quotas.Rank(victim); candidateRank != victimRank {
+		return candidateRank > victimRank
+	}
+	return a.tinyLFU.Admit(candidate, victim)
+}
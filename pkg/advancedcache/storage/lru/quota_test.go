@@ -0,0 +1,141 @@
+package lru
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/traefik/traefik/v3/pkg/advancedcache/config"
+	"github.com/traefik/traefik/v3/pkg/advancedcache/model"
+)
+
+const quotaTestBodySize = 10 * 1024
+
+func newQuotaTestConfig(size uint, rules map[string]config.RuleEviction) *config.Cache {
+	cfg := &config.Cache{Cache: &config.CacheBox{
+		Enabled:     true,
+		Preallocate: config.Preallocation{PerShard: 8},
+		Eviction:    &config.Eviction{Enabled: true, Threshold: 0.9, Policy: config.EvictionPolicyWTinyLFU},
+		Refresh:     &config.Refresh{},
+		Storage:     &config.Storage{Size: size},
+		Rules:       make(map[string]*config.Rule, len(rules)),
+	}}
+	for path, eviction := range rules {
+		eviction.MaxBytes = int64(float64(size) * eviction.MaxShare)
+		cfg.Cache.Rules[path] = &config.Rule{
+			PathBytes: []byte(path),
+			Eviction:  eviction,
+			CacheKey:  config.RuleKey{Query: []string{"id"}, QueryBytes: [][]byte{[]byte("id")}},
+		}
+	}
+	return cfg
+}
+
+func newQuotaTestEntry(t *testing.T, cfg *config.Cache, path string, id int) *model.Entry {
+	t.Helper()
+
+	// the cache key is built from the query and headers only, so the path is a part of the id
+	headers := make([][2][]byte, 0)
+	query := []byte("id=" + path + strconv.Itoa(id))
+	entry, err := model.NewEntryManual(cfg, []byte(path), query, &headers, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry.SetPayload([]byte(path), query, &headers, &headers, make([]byte, quotaTestBodySize), 200)
+	return entry
+}
+
+func findRuleStat(t *testing.T, db *InMemoryStorage, path string) model.RuleStat {
+	t.Helper()
+
+	for _, stat := range db.RuleStats() {
+		if stat.Path == path {
+			return stat
+		}
+	}
+	t.Fatalf("no stats of rule %s", path)
+	return model.RuleStat{}
+}
+
+func TestRuleQuotaIsEnforced(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	cfg := newQuotaTestConfig(8<<20, map[string]config.RuleEviction{
+		"/capped": {MaxShare: 0.1, PriorityClass: config.PriorityClassNormal},
+		"/other":  {PriorityClass: config.PriorityClassNormal},
+	})
+	db := NewOfflineStorage(ctx, cfg, time.Now)
+
+	for i := 0; i < 300; i++ {
+		db.Set(newQuotaTestEntry(t, cfg, "/capped", i))
+		db.Evict()
+	}
+	for i := 0; i < 100; i++ {
+		db.Set(newQuotaTestEntry(t, cfg, "/other", i))
+		db.Evict()
+	}
+
+	capped := findRuleStat(t, db, "/capped")
+	if capped.Mem > capped.MaxBytes {
+		t.Fatalf("rule exceeds its quota: mem=%d max=%d", capped.Mem, capped.MaxBytes)
+	}
+	if capped.Len == 0 || capped.Evictions+capped.Rejected == 0 {
+		t.Fatalf("expected the rule to keep entries within its quota: %+v", capped)
+	}
+
+	if other := findRuleStat(t, db, "/other"); other.Len != 100 || other.Evictions != 0 {
+		t.Fatalf("rule without quota must not be affected: %+v", other)
+	}
+}
+
+func TestPriorityClassSurvivesEviction(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	cfg := newQuotaTestConfig(2<<20, map[string]config.RuleEviction{
+		"/landing": {PriorityClass: config.PriorityClassCritical},
+		"/search":  {PriorityClass: config.PriorityClassLow},
+	})
+	db := NewOfflineStorage(ctx, cfg, time.Now)
+
+	landing := make([]*model.Entry, 0, 5)
+	for i := 0; i < cap(landing); i++ {
+		entry := newQuotaTestEntry(t, cfg, "/landing", i)
+		landing = append(landing, entry)
+		db.Set(entry)
+	}
+	for i := 0; i < 1_000; i++ {
+		db.Set(newQuotaTestEntry(t, cfg, "/search", i))
+		db.Evict()
+	}
+
+	if search := findRuleStat(t, db, "/search"); search.Evictions == 0 {
+		t.Fatalf("expected the low priority rule to be evicted: %+v", search)
+	}
+	for _, entry := range landing {
+		if _, found := db.Get(entry); !found {
+			t.Fatalf("critical entry %d was evicted", entry.MapKey())
+		}
+	}
+}
+
+func TestMissesBuildFrequency(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	cfg := newQuotaTestConfig(8<<20, map[string]config.RuleEviction{"/news": {PriorityClass: config.PriorityClassNormal}})
+	db := NewOfflineStorage(ctx, cfg, time.Now)
+
+	evicted := newQuotaTestEntry(t, cfg, "/news", 1)
+	for i := 0; i < 3; i++ {
+		if _, found := db.Get(evicted); found {
+			t.Fatal("expected a miss of an entry which is not stored")
+		}
+	}
+
+	if freq := db.tinyLFU.Estimate(evicted.MapKey()); freq < 3 {
+		t.Fatalf("expected every missed lookup to be counted, got a frequency of %d", freq)
+	}
+}
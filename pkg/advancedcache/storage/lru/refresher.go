@@ -2,7 +2,11 @@ package lru
 
 import (
 	"context"
+	"errors"
+	"github.com/traefik/traefik/v3/pkg/advancedcache/model"
 	"github.com/traefik/traefik/v3/pkg/advancedcache/rate"
+	"github.com/traefik/traefik/v3/pkg/advancedcache/repository"
+	"sort"
 	"sync/atomic"
 	"time"
 
@@ -11,11 +15,17 @@ import (
 	"github.com/traefik/traefik/v3/pkg/advancedcache/utils"
 )
 
-const workersNum = 4
+const (
+	workersNum        = 4
+	refreshTick       = 100 * time.Millisecond
+	refreshRetryDelay = 30 * time.Second // Delay before the next attempt to refresh an entry after a failure
+	refreshShedDelay  = time.Second      // Delay before the next attempt to refresh an entry shed by the backend concurrency limit
+)
 
 var (
 	scansNumCounter            = atomic.Int64{}
 	scansFoundNumCounter       = atomic.Int64{}
+	skippedRefreshesNumCounter = atomic.Int64{}
 	successRefreshesNumCounter = atomic.Int64{}
 	failedRefreshesNumCounter  = atomic.Int64{}
 )
@@ -25,13 +35,20 @@ type Refresher interface {
 }
 
 // Refresh is responsible for background refreshing of cache entries.
-// It periodically samples random shards and randomly selects "cold" entries
-// (from the end of each shard's InMemoryStorage list) to refreshItem if necessary.
-// Communication: provider->consumer (MPSC).
+// Every stored entry is kept in a time-ordered queue by the moment it should be refreshed (see model.Entry.RefreshAt).
+// Each tick the scheduler pops due entries, skips the ones which have not been read since their last refresh
+// and hands the rest to workers, the most frequently used (by TinyLFU estimate) first, within the refresh rate.
+// Due entries which did not fit into the rate stay in the queue, their delay is reported as the refresh lag.
 type Refresh struct {
-	ctx     context.Context
-	cfg     *config.Cache
-	storage *InMemoryStorage
+	ctx        context.Context
+	cfg        *config.Cache
+	storage    *InMemoryStorage
+	queue      *deadlineQueue
+	workCh     chan *model.Entry
+	lag        atomic.Int64   // Nanoseconds the oldest due entry is waiting for
+	offset     uint64         // Shard to start the next scan from
+	due        []deadlineItem // Reused by the scheduler tick
+	candidates []deadlineItem // Reused by the scheduler tick
 }
 
 // NewRefresher constructs a Refresh.
@@ -40,22 +57,40 @@ func NewRefresher(ctx context.Context, cfg *config.Cache, storage *InMemoryStora
 		ctx:     ctx,
 		cfg:     cfg,
 		storage: storage,
+		queue:   newDeadlineQueue(storage),
+		workCh:  make(chan *model.Entry, max(1, cfg.Cache.Refresh.Rate)),
 	}
 }
 
 // Run starts the refresher background loop.
-// It runs a logger (if debugging is enabled), spawns a provider for sampling shards,
-// and continuously processes shard samples for candidate responses to refreshItem.
+// It runs a logger (if debugging is enabled), the scheduler which pops due entries each tick
+// and workers which refresh them within the upstream rate limit.
 func (r *Refresh) Run() *Refresh {
 	if r.cfg.Cache.Enabled && r.cfg.Cache.Refresh.Enabled {
 		r.runLogger() // handle consumer stats and print logs
-		r.run()       // run workers (N=workersNum) which scan the storage and run async refresh tasks
+		r.run()       // run the scheduler and workers (N=workersNum) which run async refresh tasks
 	}
 	return r
 }
 
+// Schedule puts a stored entry into the refresh queue, entries of rules with disabled refresh are ignored.
+func (r *Refresh) Schedule(entry *model.Entry) {
+	if deadline := entry.RefreshAt(r.cfg); deadline > 0 {
+		r.queue.push(entry, deadline)
+	}
+}
+
+// Reset drops all scheduled refreshes (the storage was cleared).
+func (r *Refresh) Reset() {
+	r.queue.reset()
+}
+
+// Queue returns the refresh queue depth and the refresh lag (how long the oldest due entry is waiting for).
+func (r *Refresh) Queue() (depth int64, lag time.Duration) {
+	return r.queue.Len(), time.Duration(r.lag.Load())
+}
+
 func (r *Refresh) run() {
-	scanRateCh := rate.NewLimiter(r.ctx, r.cfg.Cache.Refresh.ScanRate, r.cfg.Cache.Refresh.ScanRate/10).Chan()
 	upstreamRateCh := rate.NewLimiter(r.ctx, r.cfg.Cache.Refresh.Rate, r.cfg.Cache.Refresh.Rate/10).Chan()
 
 	for i := 0; i < workersNum; i++ {
@@ -64,27 +99,127 @@ func (r *Refresh) run() {
 				select {
 				case <-r.ctx.Done():
 					return
-				case <-scanRateCh:
-					scansNumCounter.Add(1)
-					if entry, found := r.storage.Rand(); found && entry.ShouldBeRefreshed(r.cfg) {
-						scansFoundNumCounter.Add(1)
-						select {
-						case <-r.ctx.Done():
-							return
-						case <-upstreamRateCh:
-							go func() {
-								if err := entry.Revalidate(); err != nil {
-									failedRefreshesNumCounter.Add(1)
-								} else {
-									successRefreshesNumCounter.Add(1)
-								}
-							}()
-						}
+				case entry := <-r.workCh:
+					select {
+					case <-r.ctx.Done():
+						return
+					case <-upstreamRateCh:
+						go r.refresh(entry)
 					}
 				}
 			}
 		}()
 	}
+
+	go func() {
+		t := utils.NewTicker(r.ctx, refreshTick)
+		for {
+			select {
+			case <-r.ctx.Done():
+				return
+			case now := <-t:
+				r.tick(now.UnixNano())
+			}
+		}
+	}()
+}
+
+func (r *Refresh) refresh(entry *model.Entry) {
+	if err := entry.Revalidate(); errors.Is(err, repository.ErrOverloaded) {
+		// shed refreshes are accounted by the limiter, the entry is still served meanwhile
+		r.queue.push(entry, r.storage.now()+refreshShedDelay.Nanoseconds())
+	} else if err != nil {
+		failedRefreshesNumCounter.Add(1)
+		r.queue.push(entry, r.storage.now()+refreshRetryDelay.Nanoseconds())
+	} else {
+		successRefreshesNumCounter.Add(1)
+		r.Schedule(entry)
+	}
+}
+
+// tick pops due entries (at most scan_rate per second) and dispatches the most frequently used
+// of them to workers (at most rate per second), the rest is pushed back.
+func (r *Refresh) tick(now int64) {
+	r.dispatchDue(now, refreshTick, func(entry *model.Entry) bool {
+		select {
+		case r.workCh <- entry:
+			return true
+		default: // workers are behind the rate
+			return false
+		}
+	})
+}
+
+// advance is the offline counterpart of tick for the period since the previous call (0 = none):
+// the dispatched entries are marked as refreshed at now right away, without fetching them.
+// Returns the number of refreshes.
+func (r *Refresh) advance(now, prev int64) (refreshes int) {
+	period := refreshTick
+	if prev > 0 && now > prev {
+		period = time.Duration(now - prev)
+	}
+
+	r.dispatchDue(now, period, func(entry *model.Entry) bool {
+		entry.MarkRefreshed(now)
+		r.Schedule(entry)
+		refreshes++
+		return true
+	})
+
+	return refreshes
+}
+
+// dispatchDue pops entries due at now (at most scan_rate per second of the period) and passes the most frequently
+// used of them to dispatch (at most rate per second of the period), the rest and the rejected ones are pushed back.
+func (r *Refresh) dispatchDue(now int64, period time.Duration, dispatch func(entry *model.Entry) bool) {
+	scanLimit := max(1, int(float64(r.cfg.Cache.Refresh.ScanRate)*period.Seconds()))
+	refreshLimit := max(1, int(float64(r.cfg.Cache.Refresh.Rate)*period.Seconds()))
+
+	r.due, r.offset = r.queue.popDue(now, scanLimit, r.offset, r.due[:0])
+	scansNumCounter.Add(int64(len(r.due)))
+
+	r.candidates = r.candidates[:0]
+	for _, item := range r.due {
+		entry := item.entry
+		deadline := entry.RefreshAt(r.cfg)
+		switch {
+		case deadline == 0:
+			continue // refresh was disabled
+		case deadline > now:
+			r.queue.push(entry, deadline) // was updated meanwhile
+		case !entry.IsRead():
+			// nobody needs it fresh, check again in a refresh period
+			skippedRefreshesNumCounter.Add(1)
+			r.queue.push(entry, now+deadline-entry.UpdateAt())
+		default:
+			r.candidates = append(r.candidates, item)
+		}
+	}
+	clear(r.due)
+
+	// hot entries first, the oldest deadline first among equally hot ones
+	sort.SliceStable(r.candidates, func(i, j int) bool {
+		ei, ej := r.storage.tinyLFU.Estimate(r.candidates[i].entry.MapKey()), r.storage.tinyLFU.Estimate(r.candidates[j].entry.MapKey())
+		if ei != ej {
+			return ei > ej
+		}
+		return r.candidates[i].deadline < r.candidates[j].deadline
+	})
+
+	for i, item := range r.candidates {
+		if i < refreshLimit && dispatch(item.entry) {
+			scansFoundNumCounter.Add(1)
+			continue
+		}
+		r.queue.push(item.entry, item.deadline)
+	}
+	clear(r.candidates)
+
+	if deadline, found := r.queue.earliest(); found && deadline < now {
+		r.lag.Store(now - deadline)
+	} else {
+		r.lag.Store(0)
+	}
 }
 
 // runLogger periodically logs the number of successful and failed refreshItem attempts.
@@ -101,6 +236,7 @@ func (r *Refresh) runLogger() {
 			errors  int64
 			scans   int64
 			found   int64
+			skipped int64
 		}
 
 		var (
@@ -110,7 +246,7 @@ func (r *Refresh) runLogger() {
 		)
 
 		reset := func(c *counters) {
-			c.success, c.errors, c.scans, c.found = 0, 0, 0, 0
+			c.success, c.errors, c.scans, c.found, c.skipped = 0, 0, 0, 0, 0
 		}
 
 		logCounters := func(label string, c *counters) {
@@ -120,6 +256,7 @@ func (r *Refresh) runLogger() {
 				Int64("errors", c.errors).
 				Int64("scans", c.scans).
 				Int64("scans_found", c.found).
+				Int64("skipped", c.skipped).
 				Msgf("[refresher][%s]", label)
 		}
 
@@ -133,21 +270,25 @@ func (r *Refresh) runLogger() {
 				errors := failedRefreshesNumCounter.Swap(0)
 				scans := scansNumCounter.Swap(0)
 				found := scansFoundNumCounter.Swap(0)
+				skipped := skippedRefreshesNumCounter.Swap(0)
 
 				accHourly.success += success
 				accHourly.errors += errors
 				accHourly.scans += scans
 				accHourly.found += found
+				accHourly.skipped += skipped
 
 				acc12Hourly.success += success
 				acc12Hourly.errors += errors
 				acc12Hourly.scans += scans
 				acc12Hourly.found += found
+				acc12Hourly.skipped += skipped
 
 				acc24Hourly.success += success
 				acc24Hourly.errors += errors
 				acc24Hourly.scans += scans
 				acc24Hourly.found += found
+				acc24Hourly.skipped += skipped
 
 				log.Info().
 					Str("target", "refresher").
@@ -155,6 +296,9 @@ func (r *Refresh) runLogger() {
 					Int64("errors", errors).
 					Int64("scans", scans).
 					Int64("scans_found", found).
+					Int64("skipped", skipped).
+					Int64("queue", r.queue.Len()).
+					Str("lag", time.Duration(r.lag.Load()).String()).
 					Msg("[refresher][5s]")
 
 			case <-eachHour:
@@ -0,0 +1,64 @@
+package lru
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/traefik/traefik/v3/pkg/advancedcache/config"
+	"github.com/traefik/traefik/v3/pkg/advancedcache/model"
+)
+
+func TestRefreshSchedulerPrioritizesHotEntries(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	cfg := newQuotaTestConfig(8<<20, map[string]config.RuleEviction{"/page": {PriorityClass: config.PriorityClassNormal}})
+	cfg.Cache.Refresh = &config.Refresh{Enabled: true, TTL: time.Hour, Beta: 0.4, Coefficient: 0.5, Rate: 10, ScanRate: 1000}
+	db := NewOfflineStorage(ctx, cfg, time.Now)
+
+	hot, cold, unread := newQuotaTestEntry(t, cfg, "/page", 1), newQuotaTestEntry(t, cfg, "/page", 2), newQuotaTestEntry(t, cfg, "/page", 3)
+	for _, entry := range []*model.Entry{hot, cold, unread} {
+		db.Set(entry)
+	}
+	for i := 0; i < 10; i++ {
+		db.Get(hot)
+	}
+	db.Get(cold)
+
+	if depth, _ := db.RefreshQueue(); depth != 3 {
+		t.Fatalf("expected 3 scheduled entries, got %d", depth)
+	}
+
+	// nothing is due before ttl*coefficient
+	db.refresher.tick(time.Now().Add(29 * time.Minute).UnixNano())
+	if len(db.refresher.workCh) != 0 {
+		t.Fatalf("expected nothing to be refreshed yet, got %d", len(db.refresher.workCh))
+	}
+
+	// the rate allows a single refresh per tick, the hottest entry goes first
+	db.refresher.tick(time.Now().Add(2 * time.Hour).UnixNano())
+	if len(db.refresher.workCh) != 1 {
+		t.Fatalf("expected a single refresh within the rate, got %d", len(db.refresher.workCh))
+	}
+	if entry := <-db.refresher.workCh; entry.MapKey() != hot.MapKey() {
+		t.Fatalf("expected the hot entry to be refreshed first")
+	}
+
+	depth, lag := db.RefreshQueue()
+	if depth != 2 {
+		t.Fatalf("expected the cold and the skipped unread entries to stay in the queue, got %d", depth)
+	}
+	if lag <= 0 {
+		t.Fatal("expected the cold entry which did not fit into the rate to be reported as lag")
+	}
+
+	// the unread entry was rescheduled for the next period, the cold one is still due
+	db.refresher.tick(time.Now().Add(2 * time.Hour).UnixNano())
+	if entry := <-db.refresher.workCh; entry.MapKey() != cold.MapKey() {
+		t.Fatalf("expected the cold entry to be refreshed next")
+	}
+	if len(db.refresher.workCh) != 0 {
+		t.Fatalf("expected the unread entry %d to be skipped", unread.MapKey())
+	}
+}
@@ -14,22 +14,59 @@ import (
 	"github.com/traefik/traefik/v3/pkg/advancedcache/utils"
 )
 
+// Clock returns the current time. The storage runs on time.Now, an offline storage may be driven by a virtual clock.
+type Clock func() time.Time
+
 // InMemoryStorage is a Weight-aware, sharded InMemoryStorage cache with background eviction and refreshItem support.
 type InMemoryStorage struct {
 	ctx             context.Context            // Main context for lifecycle control
 	cfg             *config.Cache              // CacheBox configuration
 	shardedMap      *sharded.Map[*model.Entry] // Sharded storage for cache entries
 	tinyLFU         *lfu.TinyLFU               // Helps hold more frequency used items in cache while eviction
+	climber         *lfu.Climber               // Adapts the W-TinyLFU admission window by the observed hit ratio
+	quotas          *Quotas                    // Per-rule occupancy, quotas and priority classes
+	admitter        Admitter                   // TinyLFU admission with respect to rule quotas and priorities
 	backend         repository.Backender       // Remote backend server.
 	balancer        Balancer                   // Helps pick shards to evict from
+	evictor         *Evict                     // Brings memory usage back under the threshold
+	expirer         *Expirer                   // Removes entries which have outlived their max age
+	refresher       *Refresh                   // Refreshes entries in background by their schedule
 	mem             int64                      // Current Weight usage (bytes)
 	memoryThreshold int64                      // Threshold for triggering eviction (bytes)
+	memoryLimit     int64                      // Hard limit after which new entries are rejected (bytes)
+	clock           Clock                      // Source of the current time for max age, stale_if_error and refresh
+	advancedAt      int64                      // Unix nano time of the last Advance of an offline storage
 }
 
 // NewStorage constructs a new InMemoryStorage cache instance and launches eviction and refreshItem routines.
 func NewStorage(ctx context.Context, cfg *config.Cache, backend repository.Backender) *InMemoryStorage {
+	db := newStorage(ctx, cfg, backend, time.Now).runLogger()
+
+	db.refresher.Run()
+	db.evictor.Run()
+	db.expirer.Run()
+
+	return db
+}
+
+// NewOfflineStorage constructs an InMemoryStorage without background refresher, evictor, expirer and loggers.
+// The caller drives eviction synchronously through Evict, and expiration and refresh through Advance,
+// with the given clock as the current time, which makes a run reproducible (e.g. the offline simulator).
+func NewOfflineStorage(ctx context.Context, cfg *config.Cache, clock Clock) *InMemoryStorage {
+	return newStorage(ctx, cfg, nil, clock)
+}
+
+func newStorage(ctx context.Context, cfg *config.Cache, backend repository.Backender, clock Clock) *InMemoryStorage {
 	shardedMap := sharded.NewMap[*model.Entry](ctx, cfg.Cache.Preallocate.PerShard)
-	balancer := NewBalancer(ctx, shardedMap)
+	tinyLFU := lfu.NewTinyLFU(cfg.Cache.Eviction.SampleSize)
+	climber := lfu.NewClimber(
+		cfg.Cache.Eviction.Window.Size,
+		cfg.Cache.Eviction.IsWTinyLFU() && cfg.Cache.Eviction.Window.Adaptive,
+		cfg.Cache.Eviction.Window.SampleSize,
+	)
+	quotas := NewQuotas(cfg)
+	admitter := &ruleAdmitter{quotas: quotas, tinyLFU: tinyLFU}
+	balancer := NewBalancer(ctx, cfg, shardedMap, admitter, climber, quotas)
 
 	db := (&InMemoryStorage{
 		ctx:             ctx,
@@ -37,12 +74,17 @@ func NewStorage(ctx context.Context, cfg *config.Cache, backend repository.Backe
 		shardedMap:      shardedMap,
 		balancer:        balancer,
 		backend:         backend,
-		tinyLFU:         lfu.NewTinyLFU(ctx),
+		tinyLFU:         tinyLFU,
+		climber:         climber,
+		quotas:          quotas,
+		admitter:        admitter,
 		memoryThreshold: int64(float64(cfg.Cache.Storage.Size) * cfg.Cache.Eviction.Threshold),
-	}).init().runLogger()
-
-	NewRefresher(ctx, cfg, db).Run()
-	NewEvictor(ctx, cfg, db, balancer).Run()
+		memoryLimit:     int64(cfg.Cache.Storage.Size),
+		clock:           clock,
+	}).init()
+	db.evictor = NewEvictor(ctx, cfg, db, balancer)
+	db.expirer = NewExpirer(ctx, cfg, db)
+	db.refresher = NewRefresher(ctx, cfg, db)
 
 	return db
 }
@@ -65,6 +107,9 @@ func (s *InMemoryStorage) Clear() {
 
 		shard.Clear()
 	})
+	s.quotas.Reset()
+	s.expirer.Reset()
+	s.refresher.Reset()
 }
 
 // Rand returns a random item from storage.
@@ -73,31 +118,57 @@ func (s *InMemoryStorage) Rand() (entry *model.Entry, ok bool) {
 }
 
 // Get retrieves a response by request and bumps its InMemoryStorage position.
+// An entry which has outlived its max age is never returned, it is removed right away.
+// Every lookup counts as an access, so a key which keeps being requested after eviction
+// builds the frequency it needs to win the admission duel.
 // Returns: (response, releaser, found).
 func (s *InMemoryStorage) Get(req *model.Entry) (ptr *model.Entry, found bool) {
+	s.tinyLFU.Increment(req.MapKey())
+
 	ptr, found = s.shardedMap.Get(req.MapKey())
 	if !found || !ptr.IsSameFingerprint(req.Fingerprint()) {
+		s.climber.Record(false)
+		return nil, false
+	} else if expiresAt, now := ptr.ExpiresAt(s.cfg), s.now(); expiresAt > 0 && now >= expiresAt {
+		if now >= expiresAt+s.cfg.StaleIfError().Nanoseconds() {
+			s.expire(ptr)
+		} // otherwise the entry is kept for GetStale until the expirer removes it
+		s.climber.Record(false)
 		return nil, false
 	} else {
+		s.climber.Record(true)
+		ptr.MarkRead()
 		s.touch(ptr)
 		return ptr, true
 	}
 }
 
+// GetStale returns an entry which has outlived its max age but is still within the stale_if_error grace period.
+// It is a fallback for failed or shed backend fetches, so the lookup does not count as a hit.
+func (s *InMemoryStorage) GetStale(req *model.Entry) (ptr *model.Entry, found bool) {
+	ptr, found = s.shardedMap.Get(req.MapKey())
+	if !found || !ptr.IsSameFingerprint(req.Fingerprint()) {
+		return nil, false
+	}
+	if expiresAt := ptr.ExpiresAt(s.cfg); expiresAt > 0 && s.now() >= expiresAt+s.cfg.StaleIfError().Nanoseconds() {
+		return nil, false
+	}
+	return ptr, true
+}
+
 // Set inserts or updates a response in the cache, updating Weight usage and InMemoryStorage position.
 // On 'wasPersisted=true' must be called Entry.Finalize, otherwise Entry.Finalize.
 func (s *InMemoryStorage) Set(new *model.Entry) (persisted bool) {
 	key := new.MapKey()
 
-	// increase access counter of tinyLFU
-	s.tinyLFU.Increment(key)
-
 	// try to find existing entry
 	if old, found := s.shardedMap.Get(key); found {
 		if old.IsSameFingerprint(new.Fingerprint()) {
 			// entry was found, no hash collisions, fingerprint check has passed, next check payload
 			if old.IsSamePayload(new) {
-				// nothing change, an existing entry has the same payload, just up the element in LRU list
+				// nothing change, an existing entry has the same payload which is confirmed to be fresh,
+				// just up the element in LRU list
+				old.SetUpdatedAt(s.now())
 				s.touch(old)
 			} else {
 				// payload has changes, updated it and up the element in LRU list of course
@@ -109,15 +180,37 @@ func (s *InMemoryStorage) Set(new *model.Entry) (persisted bool) {
 		s.Remove(old)
 	}
 
+	// a rule which has exhausted its quota may only replace its own entries
+	if s.quotas.IsOverQuota(new.Rule()) {
+		victim, found := s.balancer.RuleVictim(new.ShardKey(), new.Rule())
+		if !found || !s.tinyLFU.Admit(new, victim) {
+			s.quotas.Rejected(new)
+			return false
+		}
+		s.evict(victim)
+	}
+
 	// check whether we are still into memory limit
-	if s.ShouldEvict() { // if so then check admission by tinyLFU
-		if victim, admit := s.balancer.FindVictim(new.ShardKey()); !admit || !s.tinyLFU.Admit(new, victim) {
+	if s.cfg.Cache.Eviction.IsWTinyLFU() {
+		// W-TinyLFU admits everything into the window, the duel with a victim happens on eviction,
+		// so only the hard limit protects us while the evictor catches up
+		if s.Mem() >= s.memoryLimit {
+			s.quotas.Rejected(new)
+			return false
+		}
+	} else if s.ShouldEvict() { // if so then check admission by tinyLFU
+		if victim, admit := s.balancer.FindVictim(new.ShardKey()); !admit || !s.admitter.Admit(new, victim) {
+			s.quotas.Rejected(new)
 			return false
 		}
 	}
 
 	// insert a new one Entry into map
 	s.shardedMap.Set(key, new)
+	s.quotas.Add(new, new.Weight(), 1)
+	// schedule the hard expiration and the background refresh
+	s.expirer.Push(new)
+	s.refresher.Schedule(new)
 	// insert a new one Entry LRU element into LRU list
 	s.balancer.Push(new)
 
@@ -126,7 +219,26 @@ func (s *InMemoryStorage) Set(new *model.Entry) (persisted bool) {
 
 func (s *InMemoryStorage) Remove(entry *model.Entry) (freedBytes int64, hit bool) {
 	s.balancer.Remove(entry.ShardKey(), entry.LruListElement())
-	return s.shardedMap.Remove(entry.MapKey())
+	if freedBytes, hit = s.shardedMap.Remove(entry.MapKey()); hit {
+		s.quotas.Add(entry, -freedBytes, -1)
+	}
+	return freedBytes, hit
+}
+
+// expire removes the entry which has outlived its max age and accounts it in the rule stats.
+func (s *InMemoryStorage) expire(entry *model.Entry) (freedBytes int64, hit bool) {
+	if freedBytes, hit = s.Remove(entry); hit {
+		s.quotas.Expired(entry)
+	}
+	return freedBytes, hit
+}
+
+// evict removes the entry on behalf of the eviction policy and accounts it in the rule stats.
+func (s *InMemoryStorage) evict(entry *model.Entry) (freedBytes int64, hit bool) {
+	if freedBytes, hit = s.Remove(entry); hit {
+		s.quotas.Evicted(entry)
+	}
+	return freedBytes, hit
 }
 
 func (s *InMemoryStorage) Len() int64 {
@@ -149,6 +261,39 @@ func (s *InMemoryStorage) Stat() (bytes int64, length int64) {
 	return s.shardedMap.Mem(), s.shardedMap.Len()
 }
 
+// RefreshQueue returns the background refresh queue depth and lag.
+func (s *InMemoryStorage) RefreshQueue() (depth int64, lag time.Duration) {
+	return s.refresher.Queue()
+}
+
+// RuleStats returns the storage occupancy of each rule.
+func (s *InMemoryStorage) RuleStats() []model.RuleStat {
+	return s.quotas.Stats()
+}
+
+// Evict synchronously removes entries until memory usage drops below the threshold.
+func (s *InMemoryStorage) Evict() (items int, freedMem int64) {
+	return s.evictor.evictUntilWithinLimit()
+}
+
+// Advance synchronously removes expired entries and refreshes due entries (within the refresh rate) of an offline
+// storage at the current time of its clock. Refreshed entries keep their payload, as there is no backend to fetch from.
+// Returns the number of refreshes.
+func (s *InMemoryStorage) Advance() (refreshes int) {
+	now := s.now()
+	if s.advancedAt > 0 && now-s.advancedAt < refreshTick.Nanoseconds() {
+		return 0
+	}
+
+	s.expirer.Collect(now)
+	if s.cfg.Cache.Refresh != nil && s.cfg.Cache.Refresh.Enabled {
+		refreshes = s.refresher.advance(now, s.advancedAt)
+	}
+	s.advancedAt = now
+
+	return refreshes
+}
+
 // ShouldEvict [HOT PATH METHOD] (max stale value = 25ms) checks if current Weight usage has reached or exceeded the threshold.
 func (s *InMemoryStorage) ShouldEvict() bool {
 	return s.Mem() >= s.memoryThreshold
@@ -158,6 +303,11 @@ func (s *InMemoryStorage) WalkShards(ctx context.Context, fn func(key uint64, sh
 	s.shardedMap.WalkShards(ctx, fn)
 }
 
+// now returns the current unix nano time of the storage clock.
+func (s *InMemoryStorage) now() int64 {
+	return s.clock().UnixNano()
+}
+
 // touch bumps the InMemoryStorage position of an existing entry (MoveToFront) and increases its refCount.
 func (s *InMemoryStorage) touch(existing *model.Entry) {
 	s.balancer.Update(existing)
@@ -165,8 +315,10 @@ func (s *InMemoryStorage) touch(existing *model.Entry) {
 
 // update refreshes Weight accounting and InMemoryStorage position for an updated entry.
 func (s *InMemoryStorage) update(existing, new *model.Entry) {
+	weight := existing.Weight()
 	existing.SwapPayloads(new)
-	existing.TouchUpdatedAt()
+	existing.SetUpdatedAt(s.now())
+	s.quotas.Add(existing, existing.Weight()-weight, 0)
 	s.balancer.Update(existing)
 }
 
@@ -2,6 +2,8 @@ package storage
 
 import (
 	"context"
+	"time"
+
 	"github.com/traefik/traefik/v3/pkg/advancedcache/model"
 	sharded "github.com/traefik/traefik/v3/pkg/advancedcache/storage/map"
 )
@@ -13,6 +15,9 @@ type Storage interface {
 	// Returns the response, a releaser for safe concurrent access, and a hit/miss flag.
 	Get(*model.Entry) (entry *model.Entry, hit bool)
 
+	// GetStale returns an entry past its max age which is kept as a fallback for failed backend fetches.
+	GetStale(*model.Entry) (entry *model.Entry, found bool)
+
 	// Set stores a new response in the cache and returns a releaser for managing resource lifetime.
 	// 1. You definitely cannot use 'inEntry' after use in Set due to it can be removed, you will receive a cache entry on hit!
 	// 2. Use Release and Remove for manage Entry lifetime.
@@ -27,6 +32,12 @@ type Storage interface {
 	// Stat returns bytes usage and num of items in storage.
 	Stat() (bytes int64, length int64)
 
+	// RuleStats returns bytes usage, num of items and evictions per rule.
+	RuleStats() []model.RuleStat
+
+	// RefreshQueue returns the background refresh queue depth and lag (how long the oldest due entry is waiting for).
+	RefreshQueue() (depth int64, lag time.Duration)
+
 	// Len - return stored value (refreshes every 100ms).
 	Len() int64
 
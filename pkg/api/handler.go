@@ -30,7 +30,8 @@ func writeError(rw http.ResponseWriter, msg string, code int) {
 
 type serviceInfoRepresentation struct {
 	*runtime.ServiceInfo
-	ServerStatus map[string]string `json:"serverStatus,omitempty"`
+	ServerStatus        map[string]string `json:"serverStatus,omitempty"`
+	ServerStatusReasons map[string]string `json:"serverStatusReasons,omitempty"`
 }
 
 // RunTimeRepresentation is the configuration information exposed by the API handler.
@@ -122,8 +123,9 @@ func (h Handler) getRuntimeConfiguration(rw http.ResponseWriter, request *http.R
 	siRepr := make(map[string]*serviceInfoRepresentation, len(h.runtimeConfiguration.Services))
 	for k, v := range h.runtimeConfiguration.Services {
 		siRepr[k] = &serviceInfoRepresentation{
-			ServiceInfo:  v,
-			ServerStatus: v.GetAllStatus(),
+			ServiceInfo:         v,
+			ServerStatus:        v.GetAllStatus(),
+			ServerStatusReasons: v.GetAllStatusReasons(),
 		}
 	}
 
@@ -34,19 +34,21 @@ func newRouterRepresentation(name string, rt *runtime.RouterInfo) routerRepresen
 
 type serviceRepresentation struct {
 	*runtime.ServiceInfo
-	ServerStatus map[string]string `json:"serverStatus,omitempty"`
-	Name         string            `json:"name,omitempty"`
-	Provider     string            `json:"provider,omitempty"`
-	Type         string            `json:"type,omitempty"`
+	ServerStatus        map[string]string `json:"serverStatus,omitempty"`
+	ServerStatusReasons map[string]string `json:"serverStatusReasons,omitempty"`
+	Name                string            `json:"name,omitempty"`
+	Provider            string            `json:"provider,omitempty"`
+	Type                string            `json:"type,omitempty"`
 }
 
 func newServiceRepresentation(name string, si *runtime.ServiceInfo) serviceRepresentation {
 	return serviceRepresentation{
-		ServiceInfo:  si,
-		Name:         name,
-		Provider:     getProviderName(name),
-		ServerStatus: si.GetAllStatus(),
-		Type:         strings.ToLower(extractType(si.Service)),
+		ServiceInfo:         si,
+		Name:                name,
+		Provider:            getProviderName(name),
+		ServerStatus:        si.GetAllStatus(),
+		ServerStatusReasons: si.GetAllStatusReasons(),
+		Type:                strings.ToLower(extractType(si.Service)),
 	}
 }
 
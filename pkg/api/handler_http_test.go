@@ -682,6 +682,40 @@ func TestHandler_HTTP(t *testing.T) {
 				jsonFile:   "testdata/service-bar.json",
 			},
 		},
+		{
+			desc: "one service by id with a down server",
+			path: "/api/http/services/baz@myprovider",
+			conf: runtime.Configuration{
+				Services: map[string]*runtime.ServiceInfo{
+					"baz@myprovider": func() *runtime.ServiceInfo {
+						si := &runtime.ServiceInfo{
+							Service: &dynamic.Service{
+								LoadBalancer: &dynamic.ServersLoadBalancer{
+									PassHostHeader: pointer(true),
+									Servers: []dynamic.Server{
+										{
+											URL: "http://127.0.0.1",
+										},
+										{
+											URL: "http://127.0.0.2",
+										},
+									},
+								},
+							},
+							UsedBy: []string{"foo@myprovider"},
+						}
+						si.UpdateServerStatus("http://127.0.0.1", "UP")
+						si.UpdateServerStatus("http://127.0.0.2", "DOWN")
+						si.UpdateServerStatusReason("http://127.0.0.2", "received error status code: 503")
+						return si
+					}(),
+				},
+			},
+			expected: expected{
+				statusCode: http.StatusOK,
+				jsonFile:   "testdata/service-baz-down.json",
+			},
+		},
 		{
 			desc: "one service by id containing slash",
 			path: "/api/http/services/" + url.PathEscape("foo / bar@myprovider"),
@@ -102,6 +102,8 @@ type Mirroring struct {
 	MaxBodySize *int64          `json:"maxBodySize,omitempty" toml:"maxBodySize,omitempty" yaml:"maxBodySize,omitempty" export:"true"`
 	Mirrors     []MirrorService `json:"mirrors,omitempty" toml:"mirrors,omitempty" yaml:"mirrors,omitempty" export:"true"`
 	HealthCheck *HealthCheck    `json:"healthCheck,omitempty" toml:"healthCheck,omitempty" yaml:"healthCheck,omitempty" label:"allowEmpty" file:"allowEmpty" kv:"allowEmpty" export:"true"`
+	// Comparison enables the comparison of the mirrors responses with the main service ones.
+	Comparison *MirrorComparison `json:"comparison,omitempty" toml:"comparison,omitempty" yaml:"comparison,omitempty" label:"allowEmpty" file:"allowEmpty" kv:"allowEmpty" export:"true"`
 }
 
 // SetDefaults Default values for a WRRService.
@@ -114,6 +116,31 @@ func (m *Mirroring) SetDefaults() {
 
 // +k8s:deepcopy-gen=true
 
+// MirrorComparison holds the configuration of the comparison of the mirrors responses with the main service ones.
+type MirrorComparison struct {
+	// Headers defines the names of the response headers to compare.
+	Headers []string `json:"headers,omitempty" toml:"headers,omitempty" yaml:"headers,omitempty" export:"true"`
+	// Body enables the comparison of the response bodies.
+	Body bool `json:"body,omitempty" toml:"body,omitempty" yaml:"body,omitempty" export:"true"`
+	// JSON compares the response bodies as JSON documents, regardless of their formatting and keys order.
+	JSON bool `json:"json,omitempty" toml:"json,omitempty" yaml:"json,omitempty" export:"true"`
+	// IgnoredFields defines the dot-separated paths of the JSON fields ignored by the comparison (e.g. data.updatedAt).
+	IgnoredFields []string `json:"ignoredFields,omitempty" toml:"ignoredFields,omitempty" yaml:"ignoredFields,omitempty" export:"true"`
+	// MaxBodySize defines the maximum size, in bytes, of the captured response bodies.
+	// The bodies of larger responses are not compared.
+	MaxBodySize int64 `json:"maxBodySize,omitempty" toml:"maxBodySize,omitempty" yaml:"maxBodySize,omitempty" export:"true"`
+	// LogPercent defines the percentage of mismatches logged with their differences.
+	LogPercent int `json:"logPercent,omitempty" toml:"logPercent,omitempty" yaml:"logPercent,omitempty" export:"true"`
+}
+
+// SetDefaults Default values for a MirrorComparison.
+func (m *MirrorComparison) SetDefaults() {
+	m.MaxBodySize = 65536
+	m.LogPercent = 10
+}
+
+// +k8s:deepcopy-gen=true
+
 // Failover holds the Failover configuration.
 type Failover struct {
 	Service     string       `json:"service,omitempty" toml:"service,omitempty" yaml:"service,omitempty" export:"true"`
@@ -219,6 +246,15 @@ const (
 	BalancerStrategyWRR BalancerStrategy = "wrr"
 	// BalancerStrategyP2C is the power of two choices strategy.
 	BalancerStrategyP2C BalancerStrategy = "p2c"
+	// BalancerStrategyPeakEWMA is the least latency strategy, based on the peak EWMA of the server latencies.
+	BalancerStrategyPeakEWMA BalancerStrategy = "peakewma"
+	// BalancerStrategyRingHash is the consistent hashing strategy based on a ring of virtual nodes.
+	BalancerStrategyRingHash BalancerStrategy = "ringhash"
+	// BalancerStrategyMaglev is the consistent hashing strategy based on a Maglev lookup table.
+	BalancerStrategyMaglev BalancerStrategy = "maglev"
+	// BalancerStrategyLocality is the weighted round-robin strategy preferring the servers in the same zone as Traefik,
+	// then in the same region, and overflowing to the other ones when the local capacity is too low.
+	BalancerStrategyLocality BalancerStrategy = "locality"
 )
 
 // +k8s:deepcopy-gen=true
@@ -228,11 +264,24 @@ type ServersLoadBalancer struct {
 	Sticky   *Sticky          `json:"sticky,omitempty" toml:"sticky,omitempty" yaml:"sticky,omitempty" label:"allowEmpty" file:"allowEmpty" kv:"allowEmpty" export:"true"`
 	Servers  []Server         `json:"servers,omitempty" toml:"servers,omitempty" yaml:"servers,omitempty" label-slice-as-struct:"server" export:"true"`
 	Strategy BalancerStrategy `json:"strategy,omitempty" toml:"strategy,omitempty" yaml:"strategy,omitempty" export:"true"`
+	// ConsistentHash defines the request attribute hashed by the ringhash and maglev strategies.
+	ConsistentHash *ConsistentHash `json:"consistentHash,omitempty" toml:"consistentHash,omitempty" yaml:"consistentHash,omitempty" label:"allowEmpty" file:"allowEmpty" kv:"allowEmpty" export:"true"`
+	// Locality defines when the locality strategy overflows the traffic to the servers of the next locality.
+	Locality *Locality `json:"locality,omitempty" toml:"locality,omitempty" yaml:"locality,omitempty" label:"allowEmpty" file:"allowEmpty" kv:"allowEmpty" export:"true"`
 	// HealthCheck enables regular active checks of the responsiveness of the
 	// children servers of this load-balancer. To propagate status changes (e.g. all
 	// servers of this service are down) upwards, HealthCheck must also be enabled on
 	// the parent(s) of this service.
-	HealthCheck        *ServerHealthCheck  `json:"healthCheck,omitempty" toml:"healthCheck,omitempty" yaml:"healthCheck,omitempty" export:"true"`
+	HealthCheck *ServerHealthCheck `json:"healthCheck,omitempty" toml:"healthCheck,omitempty" yaml:"healthCheck,omitempty" export:"true"`
+	// OutlierDetection enables the passive health checking of the children servers of this load-balancer:
+	// a server failing the requests it is given is ejected for a while.
+	// Ejections are reported as status changes, like the active HealthCheck ones.
+	OutlierDetection *OutlierDetection `json:"outlierDetection,omitempty" toml:"outlierDetection,omitempty" yaml:"outlierDetection,omitempty" label:"allowEmpty" file:"allowEmpty" kv:"allowEmpty" export:"true"`
+	// SlowStart ramps up the weight of the servers newly added or newly healthy.
+	// It applies to the wrr, p2c, peakewma and locality strategies, the consistent hashing ones do not support it.
+	SlowStart *SlowStart `json:"slowStart,omitempty" toml:"slowStart,omitempty" yaml:"slowStart,omitempty" label:"allowEmpty" file:"allowEmpty" kv:"allowEmpty" export:"true"`
+	// Hedging sends a duplicate of the requests slow to answer to another server, the first response is used.
+	Hedging            *Hedging            `json:"hedging,omitempty" toml:"hedging,omitempty" yaml:"hedging,omitempty" label:"allowEmpty" file:"allowEmpty" kv:"allowEmpty" export:"true"`
 	PassHostHeader     *bool               `json:"passHostHeader" toml:"passHostHeader" yaml:"passHostHeader" export:"true"`
 	ResponseForwarding *ResponseForwarding `json:"responseForwarding,omitempty" toml:"responseForwarding,omitempty" yaml:"responseForwarding,omitempty" export:"true"`
 	ServersTransport   string              `json:"serversTransport,omitempty" toml:"serversTransport,omitempty" yaml:"serversTransport,omitempty" export:"true"`
@@ -267,6 +316,45 @@ func (l *ServersLoadBalancer) SetDefaults() {
 
 // +k8s:deepcopy-gen=true
 
+// ConsistentHash holds the request attribute hashed by the consistent hashing strategies.
+// At most one attribute can be set, the source IP is hashed by default.
+// A request without the attribute is hashed by its source IP.
+type ConsistentHash struct {
+	// Header is the name of the hashed request header.
+	Header string `json:"header,omitempty" toml:"header,omitempty" yaml:"header,omitempty" export:"true"`
+	// Cookie is the name of the hashed request cookie.
+	Cookie string `json:"cookie,omitempty" toml:"cookie,omitempty" yaml:"cookie,omitempty" export:"true"`
+	// Query is the name of the hashed query parameter.
+	Query string `json:"query,omitempty" toml:"query,omitempty" yaml:"query,omitempty" export:"true"`
+	// Path hashes the request path.
+	Path bool `json:"path,omitempty" toml:"path,omitempty" yaml:"path,omitempty" export:"true"`
+	// SourceIP hashes the client IP selected by the strategy.
+	SourceIP *IPStrategy `json:"sourceIP,omitempty" toml:"sourceIP,omitempty" yaml:"sourceIP,omitempty" label:"allowEmpty" file:"allowEmpty" kv:"allowEmpty" export:"true"`
+	// TableSize is the size of the Maglev lookup table (a prime number, 65537 by default)
+	// or the number of virtual nodes on the ring (4096 by default).
+	TableSize int `json:"tableSize,omitempty" toml:"tableSize,omitempty" yaml:"tableSize,omitempty" export:"true"`
+}
+
+// +k8s:deepcopy-gen=true
+
+// Locality holds the locality strategy configuration.
+// The servers are grouped by locality: the ones in the same zone as Traefik, the other ones in the same region, and the remaining ones.
+// The traffic is sent to the first locality with enough capacity, or to the first one with a healthy server.
+type Locality struct {
+	// MinHealthyPercent is the percentage of healthy servers of a locality under which the traffic overflows to the next locality.
+	MinHealthyPercent int `json:"minHealthyPercent,omitempty" toml:"minHealthyPercent,omitempty" yaml:"minHealthyPercent,omitempty" export:"true"`
+	// MaxInFlightPerServer is the number of in-flight requests per healthy server of a locality
+	// above which the traffic overflows to the next locality. Zero means no limit.
+	MaxInFlightPerServer int `json:"maxInFlightPerServer,omitempty" toml:"maxInFlightPerServer,omitempty" yaml:"maxInFlightPerServer,omitempty" export:"true"`
+}
+
+// SetDefaults Default values for a Locality.
+func (l *Locality) SetDefaults() {
+	l.MinHealthyPercent = 70
+}
+
+// +k8s:deepcopy-gen=true
+
 // ResponseForwarding holds the response forwarding configuration.
 type ResponseForwarding struct {
 	// FlushInterval defines the interval, in milliseconds, in between flushes to the client while copying the response body.
@@ -290,6 +378,10 @@ type Server struct {
 	Weight       *int   `json:"weight,omitempty" toml:"weight,omitempty" yaml:"weight,omitempty" export:"true"`
 	PreservePath bool   `json:"preservePath,omitempty" toml:"preservePath,omitempty" yaml:"preservePath,omitempty" export:"true"`
 	Fenced       bool   `json:"fenced,omitempty" toml:"-" yaml:"-" label:"-" file:"-" kv:"-"`
+	// Zone is the zone the server runs in, used by the locality strategy.
+	Zone string `json:"zone,omitempty" toml:"zone,omitempty" yaml:"zone,omitempty" export:"true"`
+	// Region is the region the server runs in, used by the locality strategy.
+	Region string `json:"region,omitempty" toml:"region,omitempty" yaml:"region,omitempty" export:"true"`
 	// Scheme can only be defined with label Providers.
 	Scheme string `json:"-" toml:"-" yaml:"-" file:"-" kv:"-"`
 	Port   string `json:"-" toml:"-" yaml:"-" file:"-" kv:"-"`
@@ -311,6 +403,16 @@ type ServerHealthCheck struct {
 	Hostname          string            `json:"hostname,omitempty" toml:"hostname,omitempty" yaml:"hostname,omitempty"`
 	FollowRedirects   *bool             `json:"followRedirects,omitempty" toml:"followRedirects,omitempty" yaml:"followRedirects,omitempty" export:"true"`
 	Headers           map[string]string `json:"headers,omitempty" toml:"headers,omitempty" yaml:"headers,omitempty" export:"true"`
+	// StatusRanges defines the accepted response status codes, as ranges (e.g. "200-299") or single codes, instead of Status.
+	StatusRanges []string `json:"statusRanges,omitempty" toml:"statusRanges,omitempty" yaml:"statusRanges,omitempty" export:"true"`
+	// Body defines the assertions on the response body, all of them must pass.
+	Body *HealthCheckBody `json:"body,omitempty" toml:"body,omitempty" yaml:"body,omitempty" export:"true"`
+	// GRPCService is the name of the service checked with the gRPC health protocol, the whole server is checked by default.
+	GRPCService string `json:"grpcService,omitempty" toml:"grpcService,omitempty" yaml:"grpcService,omitempty" export:"true"`
+	// Rise is the number of consecutive successful checks for an unhealthy server to become healthy (default 1).
+	Rise int `json:"rise,omitempty" toml:"rise,omitempty" yaml:"rise,omitempty" export:"true"`
+	// Fall is the number of consecutive failed checks for a healthy server to become unhealthy (default 1).
+	Fall int `json:"fall,omitempty" toml:"fall,omitempty" yaml:"fall,omitempty" export:"true"`
 }
 
 // SetDefaults Default values for a HealthCheck.
@@ -324,6 +426,110 @@ func (h *ServerHealthCheck) SetDefaults() {
 
 // +k8s:deepcopy-gen=true
 
+// HealthCheckBody holds the assertions on the health check response body.
+type HealthCheckBody struct {
+	// Contains is a substring the body must contain.
+	Contains string `json:"contains,omitempty" toml:"contains,omitempty" yaml:"contains,omitempty" export:"true"`
+	// Regex is a regular expression the body must match.
+	Regex string `json:"regex,omitempty" toml:"regex,omitempty" yaml:"regex,omitempty" export:"true"`
+	// JSONPath is the path, in the GJSON syntax, of a value the JSON body must contain.
+	JSONPath string `json:"jsonPath,omitempty" toml:"jsonPath,omitempty" yaml:"jsonPath,omitempty" export:"true"`
+	// JSONValue is the expected value, as a string, at JSONPath.
+	// When empty, any value but false and null is accepted.
+	JSONValue string `json:"jsonValue,omitempty" toml:"jsonValue,omitempty" yaml:"jsonValue,omitempty" export:"true"`
+}
+
+// +k8s:deepcopy-gen=true
+
+// OutlierDetection holds the passive health checking configuration.
+// A server is ejected after ConsecutiveErrors 5xx responses, after ConsecutiveGatewayErrors 502, 503 and 504 responses
+// (connection errors included), or when its success rate over the last Interval is too low compared to the others.
+// Each ejection of a server lasts twice as long as the previous one, from BaseEjectionTime up to MaxEjectionTime,
+// and no more than MaxEjectionPercent of the servers are ejected at once (yet at least one server can be ejected).
+type OutlierDetection struct {
+	ConsecutiveErrors        int                 `json:"consecutiveErrors,omitempty" toml:"consecutiveErrors,omitempty" yaml:"consecutiveErrors,omitempty" export:"true"`
+	ConsecutiveGatewayErrors int                 `json:"consecutiveGatewayErrors,omitempty" toml:"consecutiveGatewayErrors,omitempty" yaml:"consecutiveGatewayErrors,omitempty" export:"true"`
+	SuccessRate              *OutlierSuccessRate `json:"successRate,omitempty" toml:"successRate,omitempty" yaml:"successRate,omitempty" label:"allowEmpty" file:"allowEmpty" kv:"allowEmpty" export:"true"`
+	Interval                 ptypes.Duration     `json:"interval,omitempty" toml:"interval,omitempty" yaml:"interval,omitempty" export:"true"`
+	BaseEjectionTime         ptypes.Duration     `json:"baseEjectionTime,omitempty" toml:"baseEjectionTime,omitempty" yaml:"baseEjectionTime,omitempty" export:"true"`
+	MaxEjectionTime          ptypes.Duration     `json:"maxEjectionTime,omitempty" toml:"maxEjectionTime,omitempty" yaml:"maxEjectionTime,omitempty" export:"true"`
+	MaxEjectionPercent       int                 `json:"maxEjectionPercent,omitempty" toml:"maxEjectionPercent,omitempty" yaml:"maxEjectionPercent,omitempty" export:"true"`
+}
+
+// SetDefaults Default values for an OutlierDetection.
+func (o *OutlierDetection) SetDefaults() {
+	o.ConsecutiveErrors = 5
+	o.Interval = ptypes.Duration(10 * time.Second)
+	o.BaseEjectionTime = ptypes.Duration(30 * time.Second)
+	o.MaxEjectionTime = ptypes.Duration(300 * time.Second)
+	o.MaxEjectionPercent = 10
+}
+
+// +k8s:deepcopy-gen=true
+
+// OutlierSuccessRate holds the success rate outlier detection configuration.
+// Over each interval, the success rates of the servers having handled at least RequestVolume requests are computed,
+// provided that there are at least MinimumHosts of them. A server is ejected if its success rate
+// is below the mean minus StdevFactor times the standard deviation of the success rates.
+type OutlierSuccessRate struct {
+	MinimumHosts  int     `json:"minimumHosts,omitempty" toml:"minimumHosts,omitempty" yaml:"minimumHosts,omitempty" export:"true"`
+	RequestVolume int     `json:"requestVolume,omitempty" toml:"requestVolume,omitempty" yaml:"requestVolume,omitempty" export:"true"`
+	StdevFactor   float64 `json:"stdevFactor,omitempty" toml:"stdevFactor,omitempty" yaml:"stdevFactor,omitempty" export:"true"`
+}
+
+// SetDefaults Default values for an OutlierSuccessRate.
+func (o *OutlierSuccessRate) SetDefaults() {
+	o.MinimumHosts = 5
+	o.RequestVolume = 100
+	o.StdevFactor = 1.9
+}
+
+// +k8s:deepcopy-gen=true
+
+// SlowStart holds the slow start configuration.
+// During Window, the effective weight of a newly added or newly healthy server ramps up from MinWeightPercent
+// of its weight to its full weight, following (elapsed/Window)^(1/Aggression):
+// an Aggression of 1 ramps linearly, a higher one ramps faster at the beginning of the window.
+type SlowStart struct {
+	Window           ptypes.Duration `json:"window,omitempty" toml:"window,omitempty" yaml:"window,omitempty" export:"true"`
+	Aggression       float64         `json:"aggression,omitempty" toml:"aggression,omitempty" yaml:"aggression,omitempty" export:"true"`
+	MinWeightPercent int             `json:"minWeightPercent,omitempty" toml:"minWeightPercent,omitempty" yaml:"minWeightPercent,omitempty" export:"true"`
+}
+
+// SetDefaults Default values for a SlowStart.
+func (s *SlowStart) SetDefaults() {
+	s.Window = ptypes.Duration(30 * time.Second)
+	s.Aggression = 1
+	s.MinWeightPercent = 10
+}
+
+// +k8s:deepcopy-gen=true
+
+// Hedging holds the request hedging configuration.
+// When the response headers of a request are not received within the hedging delay,
+// a duplicate of the request is sent to another server, and the first response is used.
+type Hedging struct {
+	// Delay defines the time to wait for the response headers before hedging.
+	// It is used until enough latencies are observed when Percentile is set.
+	Delay ptypes.Duration `json:"delay,omitempty" toml:"delay,omitempty" yaml:"delay,omitempty" export:"true"`
+	// Percentile defines the percentile (between 0 and 100) of the observed latencies used as the hedging delay.
+	Percentile float64 `json:"percentile,omitempty" toml:"percentile,omitempty" yaml:"percentile,omitempty" export:"true"`
+	// Methods defines the methods of the requests which can be hedged.
+	// Requests with a body and connection upgrades are never hedged.
+	// Default: the safe methods, GET, HEAD and OPTIONS.
+	Methods []string `json:"methods,omitempty" toml:"methods,omitempty" yaml:"methods,omitempty" export:"true"`
+	// BudgetPercent defines the maximum percentage of the requests which are hedged.
+	BudgetPercent int `json:"budgetPercent,omitempty" toml:"budgetPercent,omitempty" yaml:"budgetPercent,omitempty" export:"true"`
+}
+
+// SetDefaults Default values for a Hedging.
+func (h *Hedging) SetDefaults() {
+	h.Delay = ptypes.Duration(100 * time.Millisecond)
+	h.BudgetPercent = 10
+}
+
+// +k8s:deepcopy-gen=true
+
 // HealthCheck controls healthcheck awareness and propagation at the services level.
 type HealthCheck struct{}
 
@@ -36,6 +36,10 @@ type Middleware struct {
 	AdvancedCache     *config.TraefikIntermediateConfig `json:"advancedCache,omitempty" toml:"advancedCache,omitempty" yaml:"advancedCache,omitempty" export:"true"`
 	DigestAuth        *DigestAuth                       `json:"digestAuth,omitempty" toml:"digestAuth,omitempty" yaml:"digestAuth,omitempty" export:"true"`
 	ForwardAuth       *ForwardAuth                      `json:"forwardAuth,omitempty" toml:"forwardAuth,omitempty" yaml:"forwardAuth,omitempty" export:"true"`
+	JWT               *JWT                              `json:"jwt,omitempty" toml:"jwt,omitempty" yaml:"jwt,omitempty" export:"true"`
+	OIDC              *OIDC                             `json:"oidc,omitempty" toml:"oidc,omitempty" yaml:"oidc,omitempty" export:"true"`
+	APIKey            *APIKey                           `json:"apiKey,omitempty" toml:"apiKey,omitempty" yaml:"apiKey,omitempty" export:"true"`
+	HMAC              *HMAC                             `json:"hmac,omitempty" toml:"hmac,omitempty" yaml:"hmac,omitempty" export:"true"`
 	InFlightReq       *InFlightReq                      `json:"inFlightReq,omitempty" toml:"inFlightReq,omitempty" yaml:"inFlightReq,omitempty" export:"true"`
 	Buffering         *Buffering                        `json:"buffering,omitempty" toml:"buffering,omitempty" yaml:"buffering,omitempty" export:"true"`
 	CircuitBreaker    *CircuitBreaker                   `json:"circuitBreaker,omitempty" toml:"circuitBreaker,omitempty" yaml:"circuitBreaker,omitempty" export:"true"`
@@ -89,6 +93,116 @@ type AddPrefix struct {
 
 // +k8s:deepcopy-gen=true
 
+// APIKey holds the API key middleware configuration.
+// This middleware restricts access to your services to the consumers of known API keys.
+type APIKey struct {
+	// HeaderName defines the name of the header holding the API key.
+	// Default: X-API-Key.
+	HeaderName string `json:"headerName,omitempty" toml:"headerName,omitempty" yaml:"headerName,omitempty" export:"true"`
+	// QueryParameter defines the name of the query parameter holding the API key, when not in the header.
+	QueryParameter string `json:"queryParameter,omitempty" toml:"queryParameter,omitempty" yaml:"queryParameter,omitempty" export:"true"`
+	// CookieName defines the name of the cookie holding the API key, when neither in the header nor in the query parameter.
+	CookieName string `json:"cookieName,omitempty" toml:"cookieName,omitempty" yaml:"cookieName,omitempty" export:"true"`
+	// Keys defines the API keys.
+	Keys []APIKeyEntry `json:"keys,omitempty" toml:"keys,omitempty" yaml:"keys,omitempty"`
+	// KeysFile defines the path to a YAML file holding a list of API keys, in addition to the Keys.
+	// The file is reloaded when it changes.
+	KeysFile string `json:"keysFile,omitempty" toml:"keysFile,omitempty" yaml:"keysFile,omitempty"`
+	// HeaderField defines a header field to store the consumer of the API key.
+	HeaderField string `json:"headerField,omitempty" toml:"headerField,omitempty" yaml:"headerField,omitempty" export:"true"`
+	// ForwardMetadata defines the headers to set on the forwarded request from the API key metadata, as a map of header names to metadata names.
+	// The headers are removed from the request when the API key does not hold the metadata.
+	ForwardMetadata map[string]string `json:"forwardMetadata,omitempty" toml:"forwardMetadata,omitempty" yaml:"forwardMetadata,omitempty" export:"true"`
+	// RemoveKey defines whether to remove the API key from the request before forwarding it to the service.
+	RemoveKey bool `json:"removeKey,omitempty" toml:"removeKey,omitempty" yaml:"removeKey,omitempty" export:"true"`
+}
+
+// +k8s:deepcopy-gen=true
+
+// APIKeyEntry holds an API key of the API key middleware.
+type APIKeyEntry struct {
+	// Hash defines the hash of the API key, either a bcrypt hash ($2y$...), an argon2id hash ($argon2id$...),
+	// or a SHA-256 hash in hexadecimal prefixed with {SHA256}.
+	// SHA-256 hashes are recommended for the randomly generated API keys, as they are much faster to verify.
+	Hash string `json:"hash,omitempty" toml:"hash,omitempty" yaml:"hash,omitempty" loggable:"false"`
+	// Prefix defines the beginning of the API key which is not secret, e.g. the key ID of the API keys like <ID>.<secret>.
+	// The API keys are only checked against the bcrypt and argon2id hashes of the entries they start with the prefix of,
+	// whereas every entry without a prefix is checked.
+	Prefix string `json:"prefix,omitempty" toml:"prefix,omitempty" yaml:"prefix,omitempty"`
+	// Consumer defines the name of the consumer of the API key.
+	Consumer string `json:"consumer,omitempty" toml:"consumer,omitempty" yaml:"consumer,omitempty"`
+	// Routers defines the routers on which the API key is allowed.
+	// Default: all the routers.
+	Routers []string `json:"routers,omitempty" toml:"routers,omitempty" yaml:"routers,omitempty"`
+	// ExpiresAt defines the time, in the RFC 3339 format, from which the API key is rejected.
+	ExpiresAt string `json:"expiresAt,omitempty" toml:"expiresAt,omitempty" yaml:"expiresAt,omitempty"`
+	// Metadata defines the metadata of the API key, which can be forwarded to the service.
+	Metadata map[string]string `json:"metadata,omitempty" toml:"metadata,omitempty" yaml:"metadata,omitempty"`
+}
+
+// +k8s:deepcopy-gen=true
+
+// HMAC holds the HMAC signature middleware configuration.
+// This middleware restricts access to your services to the requests signed with a shared secret.
+type HMAC struct {
+	// Secret defines the shared secret the requests are signed with.
+	Secret string `json:"secret,omitempty" toml:"secret,omitempty" yaml:"secret,omitempty" loggable:"false"`
+	// SecretFile defines the path to a file holding the shared secret, used when the Secret is not set.
+	SecretFile string `json:"secretFile,omitempty" toml:"secretFile,omitempty" yaml:"secretFile,omitempty"`
+	// Algorithm defines the hash algorithm of the signature, among sha1, sha256 and sha512.
+	// Default: sha256.
+	Algorithm string `json:"algorithm,omitempty" toml:"algorithm,omitempty" yaml:"algorithm,omitempty" export:"true"`
+	// SignatureHeader defines the name of the header holding the signature.
+	// Default: X-Signature.
+	SignatureHeader string `json:"signatureHeader,omitempty" toml:"signatureHeader,omitempty" yaml:"signatureHeader,omitempty" export:"true"`
+	// SignaturePrefix defines a prefix of the signature header value to remove before decoding the signature, such as sha256=.
+	SignaturePrefix string `json:"signaturePrefix,omitempty" toml:"signaturePrefix,omitempty" yaml:"signaturePrefix,omitempty" export:"true"`
+	// Encoding defines the encoding of the signature, among hex and base64.
+	// Default: hex.
+	Encoding string `json:"encoding,omitempty" toml:"encoding,omitempty" yaml:"encoding,omitempty" export:"true"`
+	// SignedParts defines the parts of the request forming the signed string, in order.
+	// The supported parts are method, path, query, host, timestamp, nonce, body, bodyDigest (hex-encoded SHA-256 of the body) and header:<name>.
+	// The body is read in memory, up to MaxBodySize.
+	// Default: body.
+	SignedParts []string `json:"signedParts,omitempty" toml:"signedParts,omitempty" yaml:"signedParts,omitempty" export:"true"`
+	// Separator defines the separator of the signed parts.
+	// Default: a line feed.
+	Separator string `json:"separator,omitempty" toml:"separator,omitempty" yaml:"separator,omitempty" export:"true"`
+	// MaxBodySize defines the maximum size in bytes of the body read to verify the signature.
+	// The requests with a larger body are rejected with a 413 Request Entity Too Large.
+	// Default: 10485760 (10MiB).
+	MaxBodySize int64 `json:"maxBodySize,omitempty" toml:"maxBodySize,omitempty" yaml:"maxBodySize,omitempty" export:"true"`
+	// TimestampHeader defines the name of the header holding the signing time, as a Unix time in seconds.
+	// When set, the requests signed outside of the clock skew window are rejected.
+	// The timestamp must be one of the signed parts.
+	TimestampHeader string `json:"timestampHeader,omitempty" toml:"timestampHeader,omitempty" yaml:"timestampHeader,omitempty" export:"true"`
+	// ClockSkew defines the maximum difference allowed between the signing time and the current time.
+	// Default: 5m.
+	ClockSkew ptypes.Duration `json:"clockSkew,omitempty" toml:"clockSkew,omitempty" yaml:"clockSkew,omitempty" export:"true"`
+	// NonceHeader defines the name of the header holding the nonce of the request.
+	// When set, the requests with a nonce already seen in the clock skew window are rejected.
+	// The nonce must be one of the signed parts, and the TimestampHeader must be set.
+	NonceHeader string `json:"nonceHeader,omitempty" toml:"nonceHeader,omitempty" yaml:"nonceHeader,omitempty" export:"true"`
+	// NonceCacheSize defines the maximum number of nonces remembered, for twice the clock skew.
+	// The requests with a new nonce are rejected while the cache is full.
+	// Default: 10000.
+	NonceCacheSize int `json:"nonceCacheSize,omitempty" toml:"nonceCacheSize,omitempty" yaml:"nonceCacheSize,omitempty" export:"true"`
+}
+
+// SetDefaults Default values for a HMAC.
+func (h *HMAC) SetDefaults() {
+	h.Algorithm = "sha256"
+	h.SignatureHeader = "X-Signature"
+	h.Encoding = "hex"
+	h.SignedParts = []string{"body"}
+	h.Separator = "\n"
+	h.MaxBodySize = 10 * 1024 * 1024
+	h.ClockSkew = ptypes.Duration(5 * time.Minute)
+	h.NonceCacheSize = 10000
+}
+
+// +k8s:deepcopy-gen=true
+
 // BasicAuth holds the basic auth middleware configuration.
 // This middleware restricts access to your services to known users.
 // More info: https://doc.traefik.io/traefik/v3.5/middlewares/http/basicauth/
@@ -295,6 +409,134 @@ type ClientTLS struct {
 
 // +k8s:deepcopy-gen=true
 
+// JWT holds the JWT middleware configuration.
+// This middleware authenticates the requests by validating their JSON Web Token.
+type JWT struct {
+	// HeaderName defines the name of the header holding the token.
+	// The token of the Authorization header is expected with the Bearer scheme.
+	// Default: Authorization.
+	HeaderName string `json:"headerName,omitempty" toml:"headerName,omitempty" yaml:"headerName,omitempty" export:"true"`
+	// Secret defines the secret verifying the HMAC (HS256, HS384 and HS512) token signatures.
+	Secret string `json:"secret,omitempty" toml:"secret,omitempty" yaml:"secret,omitempty" loggable:"false"`
+	// PublicKeys defines the PEM-encoded public keys or certificates, or the paths to the files holding them,
+	// verifying the RSA, ECDSA and EdDSA token signatures.
+	PublicKeys []types.FileOrContent `json:"publicKeys,omitempty" toml:"publicKeys,omitempty" yaml:"publicKeys,omitempty"`
+	// JWKSURL defines the URL of the JSON Web Key Set verifying the token signatures.
+	JWKSURL string `json:"jwksURL,omitempty" toml:"jwksURL,omitempty" yaml:"jwksURL,omitempty"`
+	// JWKSFile defines the path to the file holding the JSON Web Key Set verifying the token signatures.
+	JWKSFile string `json:"jwksFile,omitempty" toml:"jwksFile,omitempty" yaml:"jwksFile,omitempty"`
+	// JWKSRefreshInterval defines how often the JSON Web Key Set is fetched from its URL.
+	// It is also fetched when a token is signed by an unknown key, to follow the key rotations.
+	// Default: 1h.
+	JWKSRefreshInterval ptypes.Duration `json:"jwksRefreshInterval,omitempty" toml:"jwksRefreshInterval,omitempty" yaml:"jwksRefreshInterval,omitempty" export:"true"`
+	// TLS defines the configuration used to secure the connection to the JSON Web Key Set URL.
+	TLS *ClientTLS `json:"tls,omitempty" toml:"tls,omitempty" yaml:"tls,omitempty" export:"true"`
+	// Algorithms defines the allowed signature algorithms.
+	// Default: all the algorithms supported by the configured keys.
+	Algorithms []string `json:"algorithms,omitempty" toml:"algorithms,omitempty" yaml:"algorithms,omitempty" export:"true"`
+	// Issuers defines the allowed issuers (iss claim) of the tokens.
+	Issuers []string `json:"issuers,omitempty" toml:"issuers,omitempty" yaml:"issuers,omitempty" export:"true"`
+	// Audiences defines the allowed audiences (aud claim), one of which the tokens must be issued for.
+	Audiences []string `json:"audiences,omitempty" toml:"audiences,omitempty" yaml:"audiences,omitempty" export:"true"`
+	// ClockSkew defines the tolerated clock skew when validating the exp, nbf and iat claims.
+	ClockSkew ptypes.Duration `json:"clockSkew,omitempty" toml:"clockSkew,omitempty" yaml:"clockSkew,omitempty" export:"true"`
+	// Claims defines the expression the token claims must match, combining the Claim, ClaimContains and ClaimRegexp matchers,
+	// e.g. Claim(`role`, `admin`) && ClaimContains(`scope`, `read`).
+	// Nested claims are designated with dot-separated names.
+	// A request whose token does not match is rejected with HTTP 403 Forbidden.
+	Claims string `json:"claims,omitempty" toml:"claims,omitempty" yaml:"claims,omitempty" export:"true"`
+	// ForwardClaims defines the headers to set on the forwarded request from the token claims, as a map of header names to claim names.
+	// The headers are removed from the request when the token does not hold the claim.
+	ForwardClaims map[string]string `json:"forwardClaims,omitempty" toml:"forwardClaims,omitempty" yaml:"forwardClaims,omitempty" export:"true"`
+	// RemoveHeader defines whether to remove the header holding the token before forwarding the request to the service.
+	RemoveHeader bool `json:"removeHeader,omitempty" toml:"removeHeader,omitempty" yaml:"removeHeader,omitempty" export:"true"`
+}
+
+// +k8s:deepcopy-gen=true
+
+// OIDC holds the OpenID Connect middleware configuration.
+// This middleware authenticates the users with an OpenID Connect provider, using the authorization code flow with PKCE,
+// and keeps their session in an encrypted cookie.
+type OIDC struct {
+	// Issuer defines the URL of the OpenID Connect provider, from which its discovery document is fetched.
+	Issuer string `json:"issuer,omitempty" toml:"issuer,omitempty" yaml:"issuer,omitempty"`
+	// ClientID defines the client identifier registered with the provider.
+	ClientID string `json:"clientID,omitempty" toml:"clientID,omitempty" yaml:"clientID,omitempty"`
+	// ClientSecret defines the client secret registered with the provider.
+	// It can be omitted for public clients.
+	ClientSecret string `json:"clientSecret,omitempty" toml:"clientSecret,omitempty" yaml:"clientSecret,omitempty" loggable:"false"`
+	// Scopes defines the requested scopes.
+	// Default: openid, profile, email.
+	Scopes []string `json:"scopes,omitempty" toml:"scopes,omitempty" yaml:"scopes,omitempty" export:"true"`
+	// RedirectPath defines the path of the redirection endpoint, to which the provider sends back the users.
+	// Default: /oauth2/callback.
+	RedirectPath string `json:"redirectPath,omitempty" toml:"redirectPath,omitempty" yaml:"redirectPath,omitempty" export:"true"`
+	// LogoutPath defines the path which ends the session of the users.
+	// Default: /oauth2/logout.
+	LogoutPath string `json:"logoutPath,omitempty" toml:"logoutPath,omitempty" yaml:"logoutPath,omitempty" export:"true"`
+	// PostLogoutRedirectURL defines the URL to which the users are redirected once logged out.
+	// When the provider supports the RP-initiated logout, it is sent to the provider which redirects the users.
+	// Default: /.
+	PostLogoutRedirectURL string `json:"postLogoutRedirectURL,omitempty" toml:"postLogoutRedirectURL,omitempty" yaml:"postLogoutRedirectURL,omitempty"`
+	// DiscoveryRefreshInterval defines how often the discovery document of the provider is fetched.
+	// Default: 1h.
+	DiscoveryRefreshInterval ptypes.Duration `json:"discoveryRefreshInterval,omitempty" toml:"discoveryRefreshInterval,omitempty" yaml:"discoveryRefreshInterval,omitempty" export:"true"`
+	// TLS defines the configuration used to secure the connection to the provider.
+	TLS *ClientTLS `json:"tls,omitempty" toml:"tls,omitempty" yaml:"tls,omitempty" export:"true"`
+	// Session defines the session cookie configuration.
+	Session *OIDCSession `json:"session,omitempty" toml:"session,omitempty" yaml:"session,omitempty" label:"allowEmpty" file:"allowEmpty" kv:"allowEmpty" export:"true"`
+	// ForwardClaims defines the headers to set on the forwarded request from the ID token claims, as a map of header names to claim names.
+	// Nested claims are designated with dot-separated names.
+	// The headers are removed from the request when the ID token does not hold the claim.
+	ForwardClaims map[string]string `json:"forwardClaims,omitempty" toml:"forwardClaims,omitempty" yaml:"forwardClaims,omitempty" export:"true"`
+	// ForwardAccessToken defines whether to forward the access token to the service, in the Authorization header with the Bearer scheme.
+	ForwardAccessToken bool `json:"forwardAccessToken,omitempty" toml:"forwardAccessToken,omitempty" yaml:"forwardAccessToken,omitempty" export:"true"`
+}
+
+// SetDefaults Default values for a OIDC.
+func (o *OIDC) SetDefaults() {
+	o.Scopes = []string{"openid", "profile", "email"}
+	o.RedirectPath = "/oauth2/callback"
+	o.LogoutPath = "/oauth2/logout"
+	o.DiscoveryRefreshInterval = ptypes.Duration(time.Hour)
+}
+
+// +k8s:deepcopy-gen=true
+
+// OIDCSession holds the session cookie configuration of the OpenID Connect middleware.
+// The session is encrypted, and split into several cookies when it exceeds the cookie size limit.
+type OIDCSession struct {
+	// Secret defines the secret encrypting the session cookie.
+	// It must be at least 32 characters long, and shared by the middlewares of the different Traefik instances.
+	Secret string `json:"secret,omitempty" toml:"secret,omitempty" yaml:"secret,omitempty" loggable:"false"`
+	// Name defines the session cookie name.
+	// Default: a name generated from the middleware name.
+	Name string `json:"name,omitempty" toml:"name,omitempty" yaml:"name,omitempty" export:"true"`
+	// Path defines the path that must exist in the requested URL for the browser to send the session cookie.
+	// Default: /.
+	Path string `json:"path,omitempty" toml:"path,omitempty" yaml:"path,omitempty" export:"true"`
+	// Domain defines the host to which the session cookie is sent.
+	Domain string `json:"domain,omitempty" toml:"domain,omitempty" yaml:"domain,omitempty"`
+	// SameSite defines the same site policy of the session cookie.
+	// More info: https://developer.mozilla.org/en-US/docs/Web/HTTP/Headers/Set-Cookie/SameSite
+	// Default: lax.
+	// +kubebuilder:validation:Enum=none;lax;strict
+	SameSite string `json:"sameSite,omitempty" toml:"sameSite,omitempty" yaml:"sameSite,omitempty" export:"true"`
+	// MaxAge defines how long the session lasts before the users have to authenticate again,
+	// regardless of the token refreshes.
+	// Default: 24h.
+	MaxAge ptypes.Duration `json:"maxAge,omitempty" toml:"maxAge,omitempty" yaml:"maxAge,omitempty" export:"true"`
+}
+
+// SetDefaults Default values for a OIDCSession.
+func (s *OIDCSession) SetDefaults() {
+	s.Path = "/"
+	s.SameSite = "lax"
+	s.MaxAge = ptypes.Duration(24 * time.Hour)
+}
+
+// +k8s:deepcopy-gen=true
+
 // Headers holds the headers middleware configuration.
 // This middleware manages the requests and responses headers.
 // More info: https://doc.traefik.io/traefik/v3.5/middlewares/http/headers/#customrequestheaders
@@ -519,6 +761,55 @@ type InFlightReq struct {
 	// If none are set, the default is to use the requestHost.
 	// More info: https://doc.traefik.io/traefik/v3.5/middlewares/http/inflightreq/#sourcecriterion
 	SourceCriterion *SourceCriterion `json:"sourceCriterion,omitempty" toml:"sourceCriterion,omitempty" yaml:"sourceCriterion,omitempty" export:"true"`
+	// Queue enables the queueing of the requests exceeding the amount, instead of rejecting them right away.
+	// In queue mode, the routers using the middleware share the same in-flight requests limit and queue.
+	Queue *InFlightReqQueue `json:"queue,omitempty" toml:"queue,omitempty" yaml:"queue,omitempty" label:"allowEmpty" file:"allowEmpty" kv:"allowEmpty" export:"true"`
+}
+
+// +k8s:deepcopy-gen=true
+
+// InFlightReqQueue holds the in-flight request middleware queue configuration.
+type InFlightReqQueue struct {
+	// MaxLength defines the maximum number of requests waiting in the queue, per source.
+	// The requests arriving when the queue is full are rejected with HTTP 429 Too Many Requests.
+	// Default: 100.
+	// +kubebuilder:validation:Minimum=0
+	MaxLength int `json:"maxLength,omitempty" toml:"maxLength,omitempty" yaml:"maxLength,omitempty" export:"true"`
+	// MaxWait defines how long a request waits in the queue at most, before being rejected with HTTP 429 Too Many Requests.
+	// Default: 10s.
+	// +kubebuilder:validation:Pattern="^([0-9]+(ns|us|µs|ms|s|m|h)?)+$"
+	// +kubebuilder:validation:XIntOrString
+	MaxWait ptypes.Duration `json:"maxWait,omitempty" toml:"maxWait,omitempty" yaml:"maxWait,omitempty" export:"true"`
+	// PriorityClasses defines the priority classes of the queued requests, from the highest priority to the lowest.
+	// The requests are admitted by priority, and in FIFO order within a class.
+	// The requests matching no class have the lowest priority.
+	// Without classes, the requests are admitted in FIFO order.
+	PriorityClasses []InFlightReqPriorityClass `json:"priorityClasses,omitempty" toml:"priorityClasses,omitempty" yaml:"priorityClasses,omitempty" export:"true"`
+}
+
+// SetDefaults Default values for a InFlightReqQueue.
+func (q *InFlightReqQueue) SetDefaults() {
+	q.MaxLength = 100
+	q.MaxWait = ptypes.Duration(10 * time.Second)
+}
+
+// +k8s:deepcopy-gen=true
+
+// InFlightReqPriorityClass holds a priority class of the in-flight request middleware queue.
+// A request belongs to the class if it matches any of its criteria.
+type InFlightReqPriorityClass struct {
+	// HeaderName defines the name of the request header matching the class.
+	HeaderName string `json:"headerName,omitempty" toml:"headerName,omitempty" yaml:"headerName,omitempty" export:"true"`
+	// HeaderValues defines the values of the header matching the class.
+	// If empty, any request with the header matches the class.
+	HeaderValues []string `json:"headerValues,omitempty" toml:"headerValues,omitempty" yaml:"headerValues,omitempty" export:"true"`
+	// SourceCriterion defines the criterion extracting the source of the requests, matched against the sources.
+	SourceCriterion *SourceCriterion `json:"sourceCriterion,omitempty" toml:"sourceCriterion,omitempty" yaml:"sourceCriterion,omitempty" export:"true"`
+	// Sources defines the sources matching the class.
+	// With the ipStrategy criterion, the sources are IP addresses or CIDR ranges.
+	Sources []string `json:"sources,omitempty" toml:"sources,omitempty" yaml:"sources,omitempty" export:"true"`
+	// Routers defines the names of the routers whose requests match the class.
+	Routers []string `json:"routers,omitempty" toml:"routers,omitempty" yaml:"routers,omitempty" export:"true"`
 }
 
 // +k8s:deepcopy-gen=true
@@ -544,6 +835,8 @@ type SourceCriterion struct {
 	RequestHeaderName string `json:"requestHeaderName,omitempty" toml:"requestHeaderName,omitempty" yaml:"requestHeaderName,omitempty" export:"true"`
 	// RequestHost defines whether to consider the request Host as the source.
 	RequestHost bool `json:"requestHost,omitempty" toml:"requestHost,omitempty" yaml:"requestHost,omitempty" export:"true"`
+	// APIKeyConsumer defines whether to consider the consumer of the API key, authenticated by an APIKey middleware, as the source.
+	APIKeyConsumer bool `json:"apiKeyConsumer,omitempty" toml:"apiKeyConsumer,omitempty" yaml:"apiKeyConsumer,omitempty" export:"true"`
 }
 
 // +k8s:deepcopy-gen=true
@@ -573,6 +866,21 @@ type RateLimit struct {
 	// Redis stores the configuration for using Redis as a bucket in the rate-limiting algorithm.
 	// If not specified, Traefik will default to an in-memory bucket for the algorithm.
 	Redis *Redis `json:"redis,omitempty" toml:"redis,omitempty" yaml:"redis,omitempty" export:"true"`
+
+	// Quota defines a long-window quota for the given source, enforced in addition to the rate.
+	Quota *RateLimitQuota `json:"quota,omitempty" toml:"quota,omitempty" yaml:"quota,omitempty" export:"true"`
+
+	// QuotaFile defines the path of the file persisting the quota counters, when Redis is not used.
+	// If not specified, the quota counters are only kept in memory.
+	QuotaFile string `json:"quotaFile,omitempty" toml:"quotaFile,omitempty" yaml:"quotaFile,omitempty"`
+
+	// Plans defines named plans, each with its own rate and quota, replacing the Average, Period, Burst and Quota options
+	// for the requests of the plan.
+	Plans map[string]RateLimitPlan `json:"plans,omitempty" toml:"plans,omitempty" yaml:"plans,omitempty" export:"true"`
+
+	// PlanCriterion defines what criterion is used to select the plan of a request.
+	// The requests without plan are limited by the Average, Period, Burst and Quota options.
+	PlanCriterion *PlanCriterion `json:"planCriterion,omitempty" toml:"planCriterion,omitempty" yaml:"planCriterion,omitempty" export:"true"`
 }
 
 // SetDefaults sets the default values on a RateLimit.
@@ -583,6 +891,63 @@ func (r *RateLimit) SetDefaults() {
 
 // +k8s:deepcopy-gen=true
 
+// RateLimitPlan holds the limits of a rate limiting plan.
+type RateLimitPlan struct {
+	// Average is the maximum rate, by default in requests/s, allowed for the given source.
+	// It defaults to 0, which means no rate limiting.
+	Average int64 `json:"average,omitempty" toml:"average,omitempty" yaml:"average,omitempty" export:"true"`
+	// Period, in combination with Average, defines the actual maximum rate. It defaults to a second.
+	Period ptypes.Duration `json:"period,omitempty" toml:"period,omitempty" yaml:"period,omitempty" export:"true"`
+	// Burst is the maximum number of requests allowed to arrive in the same arbitrarily small period of time.
+	// It defaults to 1.
+	Burst int64 `json:"burst,omitempty" toml:"burst,omitempty" yaml:"burst,omitempty" export:"true"`
+	// Quota defines a long-window quota for the given source, enforced in addition to the rate.
+	Quota *RateLimitQuota `json:"quota,omitempty" toml:"quota,omitempty" yaml:"quota,omitempty" export:"true"`
+}
+
+// SetDefaults sets the default values on a RateLimitPlan.
+func (r *RateLimitPlan) SetDefaults() {
+	r.Burst = 1
+	r.Period = ptypes.Duration(time.Second)
+}
+
+// +k8s:deepcopy-gen=true
+
+// RateLimitQuota holds the quota configuration.
+// The quota counts the requests of a source in a calendar window, in UTC.
+type RateLimitQuota struct {
+	// Limit is the maximum number of requests allowed for the given source in the window.
+	Limit int64 `json:"limit,omitempty" toml:"limit,omitempty" yaml:"limit,omitempty" export:"true"`
+	// Window defines the calendar window of the quota, among hour, day, week and month.
+	// Default: day.
+	// +kubebuilder:validation:Enum=hour;day;week;month
+	Window string `json:"window,omitempty" toml:"window,omitempty" yaml:"window,omitempty" export:"true"`
+}
+
+// SetDefaults sets the default values on a RateLimitQuota.
+func (r *RateLimitQuota) SetDefaults() {
+	r.Window = "day"
+}
+
+// +k8s:deepcopy-gen=true
+
+// PlanCriterion defines the criterion used to select the plan of a request.
+// Only one of RequestHeaderName, JWTClaim and APIKeyConsumer can be set.
+type PlanCriterion struct {
+	// RequestHeaderName defines the name of the header holding the plan.
+	RequestHeaderName string `json:"requestHeaderName,omitempty" toml:"requestHeaderName,omitempty" yaml:"requestHeaderName,omitempty" export:"true"`
+	// JWTClaim defines the claim, of the token verified by a JWT middleware, holding the plan.
+	// Nested claims are separated by dots.
+	JWTClaim string `json:"jwtClaim,omitempty" toml:"jwtClaim,omitempty" yaml:"jwtClaim,omitempty" export:"true"`
+	// APIKeyConsumer defines whether to select the plan from the consumer of the API key, authenticated by an APIKey middleware.
+	APIKeyConsumer bool `json:"apiKeyConsumer,omitempty" toml:"apiKeyConsumer,omitempty" yaml:"apiKeyConsumer,omitempty" export:"true"`
+	// Mapping defines the plans of the criterion values, as a map of values to plan names.
+	// The values without mapping are used as the plan name.
+	Mapping map[string]string `json:"mapping,omitempty" toml:"mapping,omitempty" yaml:"mapping,omitempty" export:"true"`
+}
+
+// +k8s:deepcopy-gen=true
+
 // Redis holds the Redis configuration.
 type Redis struct {
 	// Endpoints contains either a single address or a seed list of host:port addresses.
@@ -687,7 +1052,8 @@ type ReplacePathRegex struct {
 
 // Retry holds the retry middleware configuration.
 // This middleware reissues requests a given number of times to a backend server if that server does not reply.
-// As soon as the server answers, the middleware stops retrying, regardless of the response status.
+// As soon as the server answers, the middleware stops retrying,
+// unless the response status matches the status, grpcStatus or retryOn conditions.
 // More info: https://doc.traefik.io/traefik/v3.5/middlewares/http/retry/
 type Retry struct {
 	// Attempts defines how many times the request should be retried.
@@ -698,6 +1064,49 @@ type Retry struct {
 	// The value of initialInterval should be provided in seconds or as a valid duration format,
 	// see https://pkg.go.dev/time#ParseDuration.
 	InitialInterval ptypes.Duration `json:"initialInterval,omitempty" toml:"initialInterval,omitempty" yaml:"initialInterval,omitempty" export:"true"`
+	// RetryOn defines the conditions, besides the response statuses, triggering a retry:
+	// connectFailure, when the request could not be sent to the backend,
+	// and timeout, when the backend did not answer in time (504 Gateway Timeout).
+	// Default: connectFailure.
+	RetryOn []string `json:"retryOn,omitempty" toml:"retryOn,omitempty" yaml:"retryOn,omitempty" export:"true"`
+	// Status defines which status or range of statuses of the backend response trigger a retry.
+	// It can be either a status code as a number (502),
+	// as multiple comma-separated numbers (502,503),
+	// as ranges by separating two codes with a dash (502-504),
+	// or a combination of the two (429,502-504).
+	Status []string `json:"status,omitempty" toml:"status,omitempty" yaml:"status,omitempty" export:"true"`
+	// GRPCStatus defines the gRPC status codes of the backend response triggering a retry (14 for UNAVAILABLE).
+	// The gRPC status is read from the headers of the response, which is how errors are returned by gRPC servers.
+	GRPCStatus []int `json:"grpcStatus,omitempty" toml:"grpcStatus,omitempty" yaml:"grpcStatus,omitempty" export:"true"`
+	// Methods defines the methods of the requests retried on a response condition (status, gRPC status or timeout).
+	// Requests which could not be sent to the backend are retried whatever their method.
+	// Default: the idempotent methods, GET, HEAD, OPTIONS, TRACE, PUT and DELETE.
+	Methods []string `json:"methods,omitempty" toml:"methods,omitempty" yaml:"methods,omitempty" export:"true"`
+	// Budget limits the number of concurrent retries, to prevent retry storms.
+	Budget *RetryBudget `json:"budget,omitempty" toml:"budget,omitempty" yaml:"budget,omitempty" label:"allowEmpty" file:"allowEmpty" kv:"allowEmpty" export:"true"`
+}
+
+// Retry conditions.
+const (
+	RetryOnConnectFailure = "connectFailure"
+	RetryOnTimeout        = "timeout"
+)
+
+// +k8s:deepcopy-gen=true
+
+// RetryBudget holds the retry budget configuration:
+// the retries in progress are limited to a percentage of the requests in progress.
+type RetryBudget struct {
+	// Percent defines the maximum percentage of the requests in progress which can be retries.
+	Percent int `json:"percent,omitempty" toml:"percent,omitempty" yaml:"percent,omitempty" export:"true"`
+	// MinRetries defines the number of concurrent retries which are always allowed, whatever the requests in progress.
+	MinRetries int `json:"minRetries,omitempty" toml:"minRetries,omitempty" yaml:"minRetries,omitempty" export:"true"`
+}
+
+// SetDefaults sets the default values on a RetryBudget.
+func (r *RetryBudget) SetDefaults() {
+	r.Percent = 20
+	r.MinRetries = 3
 }
 
 // +k8s:deepcopy-gen=true
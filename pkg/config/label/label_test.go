@@ -170,6 +170,9 @@ func TestDecodeConfiguration(t *testing.T) {
 		"traefik.http.services.Service0.loadbalancer.healthcheck.scheme":               "foobar",
 		"traefik.http.services.Service0.loadbalancer.healthcheck.mode":                 "foobar",
 		"traefik.http.services.Service0.loadbalancer.healthcheck.timeout":              "1s",
+		"traefik.http.services.Service0.loadbalancer.healthcheck.grpcservice":          "foobar",
+		"traefik.http.services.Service0.loadbalancer.healthcheck.rise":                 "42",
+		"traefik.http.services.Service0.loadbalancer.healthcheck.fall":                 "42",
 		"traefik.http.services.Service0.loadbalancer.healthcheck.followredirects":      "true",
 		"traefik.http.services.Service0.loadbalancer.passhostheader":                   "true",
 		"traefik.http.services.Service0.loadbalancer.responseforwarding.flushinterval": "1s",
@@ -178,6 +181,8 @@ func TestDecodeConfiguration(t *testing.T) {
 		"traefik.http.services.Service0.loadbalancer.server.preservepath":              "true",
 		"traefik.http.services.Service0.loadbalancer.server.scheme":                    "foobar",
 		"traefik.http.services.Service0.loadbalancer.server.port":                      "8080",
+		"traefik.http.services.Service0.loadbalancer.server.zone":                      "foobar",
+		"traefik.http.services.Service0.loadbalancer.server.region":                    "foobar",
 		"traefik.http.services.Service0.loadbalancer.sticky.cookie.name":               "foobar",
 		"traefik.http.services.Service0.loadbalancer.sticky.cookie.secure":             "true",
 		"traefik.http.services.Service0.loadbalancer.sticky.cookie.path":               "/foobar",
@@ -195,6 +200,9 @@ func TestDecodeConfiguration(t *testing.T) {
 		"traefik.http.services.Service1.loadbalancer.healthcheck.scheme":               "foobar",
 		"traefik.http.services.Service1.loadbalancer.healthcheck.mode":                 "foobar",
 		"traefik.http.services.Service1.loadbalancer.healthcheck.timeout":              "1s",
+		"traefik.http.services.Service1.loadbalancer.healthcheck.grpcservice":          "foobar",
+		"traefik.http.services.Service1.loadbalancer.healthcheck.rise":                 "42",
+		"traefik.http.services.Service1.loadbalancer.healthcheck.fall":                 "42",
 		"traefik.http.services.Service1.loadbalancer.healthcheck.followredirects":      "true",
 		"traefik.http.services.Service1.loadbalancer.passhostheader":                   "true",
 		"traefik.http.services.Service1.loadbalancer.responseforwarding.flushinterval": "1s",
@@ -203,6 +211,8 @@ func TestDecodeConfiguration(t *testing.T) {
 		"traefik.http.services.Service1.loadbalancer.server.preservepath":              "true",
 		"traefik.http.services.Service1.loadbalancer.server.scheme":                    "foobar",
 		"traefik.http.services.Service1.loadbalancer.server.port":                      "8080",
+		"traefik.http.services.Service1.loadbalancer.server.zone":                      "foobar",
+		"traefik.http.services.Service1.loadbalancer.server.region":                    "foobar",
 		"traefik.http.services.Service1.loadbalancer.sticky":                           "false",
 		"traefik.http.services.Service1.loadbalancer.sticky.cookie.name":               "fui",
 		"traefik.http.services.Service1.loadbalancer.serversTransport":                 "foobar",
@@ -698,6 +708,8 @@ func TestDecodeConfiguration(t *testing.T) {
 							{
 								URL:          "foobar",
 								PreservePath: true,
+								Zone:         "foobar",
+								Region:       "foobar",
 								Scheme:       "foobar",
 								Port:         "8080",
 							},
@@ -718,6 +730,9 @@ func TestDecodeConfiguration(t *testing.T) {
 								"name1": "foobar",
 							},
 							FollowRedirects: pointer(true),
+							GRPCService:     "foobar",
+							Rise:            42,
+							Fall:            42,
 						},
 						PassHostHeader: pointer(true),
 						ResponseForwarding: &dynamic.ResponseForwarding{
@@ -733,6 +748,8 @@ func TestDecodeConfiguration(t *testing.T) {
 							{
 								URL:          "foobar",
 								PreservePath: true,
+								Zone:         "foobar",
+								Region:       "foobar",
 								Scheme:       "foobar",
 								Port:         "8080",
 							},
@@ -753,6 +770,9 @@ func TestDecodeConfiguration(t *testing.T) {
 								"name1": "foobar",
 							},
 							FollowRedirects: pointer(true),
+							GRPCService:     "foobar",
+							Rise:            42,
+							Fall:            42,
 						},
 						PassHostHeader: pointer(true),
 						ResponseForwarding: &dynamic.ResponseForwarding{
@@ -1243,6 +1263,8 @@ func TestEncodeConfiguration(t *testing.T) {
 							{
 								URL:          "foobar",
 								PreservePath: true,
+								Zone:         "foobar",
+								Region:       "foobar",
 								Scheme:       "foobar",
 								Port:         "8080",
 							},
@@ -1261,6 +1283,9 @@ func TestEncodeConfiguration(t *testing.T) {
 								"name0": "foobar",
 								"name1": "foobar",
 							},
+							GRPCService: "foobar",
+							Rise:        42,
+							Fall:        42,
 						},
 						PassHostHeader: pointer(true),
 						ResponseForwarding: &dynamic.ResponseForwarding{
@@ -1276,6 +1301,8 @@ func TestEncodeConfiguration(t *testing.T) {
 							{
 								URL:          "foobar",
 								PreservePath: true,
+								Zone:         "foobar",
+								Region:       "foobar",
 								Scheme:       "foobar",
 								Port:         "8080",
 							},
@@ -1294,6 +1321,9 @@ func TestEncodeConfiguration(t *testing.T) {
 								"name0": "foobar",
 								"name1": "foobar",
 							},
+							GRPCService: "foobar",
+							Rise:        42,
+							Fall:        42,
 						},
 						PassHostHeader: pointer(true),
 						ResponseForwarding: &dynamic.ResponseForwarding{
@@ -1403,6 +1433,7 @@ func TestEncodeConfiguration(t *testing.T) {
 		"traefik.HTTP.Middlewares.Middleware9.IPAllowList.RejectStatusCode":                        "0",
 		"traefik.HTTP.Middlewares.Middleware9.IPAllowList.SourceRange":                             "foobar, fiibar",
 		"traefik.HTTP.Middlewares.Middleware10.InFlightReq.Amount":                                 "42",
+		"traefik.HTTP.Middlewares.Middleware10.InFlightReq.SourceCriterion.APIKeyConsumer":         "false",
 		"traefik.HTTP.Middlewares.Middleware10.InFlightReq.SourceCriterion.IPStrategy.Depth":       "42",
 		"traefik.HTTP.Middlewares.Middleware10.InFlightReq.SourceCriterion.IPStrategy.ExcludedIPs": "foobar, fiibar",
 		"traefik.HTTP.Middlewares.Middleware10.InFlightReq.SourceCriterion.IPStrategy.IPv6Subnet":  "42",
@@ -1433,6 +1464,7 @@ func TestEncodeConfiguration(t *testing.T) {
 		"traefik.HTTP.Middlewares.Middleware12.RateLimit.Burst":                                    "42",
 		"traefik.HTTP.Middlewares.Middleware12.RateLimit.SourceCriterion.RequestHeaderName":        "foobar",
 		"traefik.HTTP.Middlewares.Middleware12.RateLimit.SourceCriterion.RequestHost":              "true",
+		"traefik.HTTP.Middlewares.Middleware12.RateLimit.SourceCriterion.APIKeyConsumer":           "false",
 		"traefik.HTTP.Middlewares.Middleware12.RateLimit.SourceCriterion.IPStrategy.Depth":         "42",
 		"traefik.HTTP.Middlewares.Middleware12.RateLimit.SourceCriterion.IPStrategy.ExcludedIPs":   "foobar, foobar",
 		"traefik.HTTP.Middlewares.Middleware12.RateLimit.SourceCriterion.IPStrategy.IPv6Subnet":    "42",
@@ -1484,12 +1516,17 @@ func TestEncodeConfiguration(t *testing.T) {
 		"traefik.HTTP.Services.Service0.LoadBalancer.HealthCheck.Port":                 "42",
 		"traefik.HTTP.Services.Service0.LoadBalancer.HealthCheck.Scheme":               "foobar",
 		"traefik.HTTP.Services.Service0.LoadBalancer.HealthCheck.Timeout":              "1000000000",
+		"traefik.HTTP.Services.Service0.LoadBalancer.HealthCheck.GRPCService":          "foobar",
+		"traefik.HTTP.Services.Service0.LoadBalancer.HealthCheck.Rise":                 "42",
+		"traefik.HTTP.Services.Service0.LoadBalancer.HealthCheck.Fall":                 "42",
 		"traefik.HTTP.Services.Service0.LoadBalancer.PassHostHeader":                   "true",
 		"traefik.HTTP.Services.Service0.LoadBalancer.ResponseForwarding.FlushInterval": "1000000000",
 		"traefik.HTTP.Services.Service0.LoadBalancer.Strategy":                         "foobar",
 		"traefik.HTTP.Services.Service0.LoadBalancer.server.URL":                       "foobar",
 		"traefik.HTTP.Services.Service0.LoadBalancer.server.PreservePath":              "true",
 		"traefik.HTTP.Services.Service0.LoadBalancer.server.Port":                      "8080",
+		"traefik.HTTP.Services.Service0.LoadBalancer.server.Zone":                      "foobar",
+		"traefik.HTTP.Services.Service0.LoadBalancer.server.Region":                    "foobar",
 		"traefik.HTTP.Services.Service0.LoadBalancer.server.Scheme":                    "foobar",
 		"traefik.HTTP.Services.Service0.LoadBalancer.Sticky.Cookie.Name":               "foobar",
 		"traefik.HTTP.Services.Service0.LoadBalancer.Sticky.Cookie.HTTPOnly":           "true",
@@ -1509,12 +1546,17 @@ func TestEncodeConfiguration(t *testing.T) {
 		"traefik.HTTP.Services.Service1.LoadBalancer.HealthCheck.Port":                 "42",
 		"traefik.HTTP.Services.Service1.LoadBalancer.HealthCheck.Scheme":               "foobar",
 		"traefik.HTTP.Services.Service1.LoadBalancer.HealthCheck.Timeout":              "1000000000",
+		"traefik.HTTP.Services.Service1.LoadBalancer.HealthCheck.GRPCService":          "foobar",
+		"traefik.HTTP.Services.Service1.LoadBalancer.HealthCheck.Rise":                 "42",
+		"traefik.HTTP.Services.Service1.LoadBalancer.HealthCheck.Fall":                 "42",
 		"traefik.HTTP.Services.Service1.LoadBalancer.PassHostHeader":                   "true",
 		"traefik.HTTP.Services.Service1.LoadBalancer.ResponseForwarding.FlushInterval": "1000000000",
 		"traefik.HTTP.Services.Service1.LoadBalancer.Strategy":                         "foobar",
 		"traefik.HTTP.Services.Service1.LoadBalancer.server.URL":                       "foobar",
 		"traefik.HTTP.Services.Service1.LoadBalancer.server.PreservePath":              "true",
 		"traefik.HTTP.Services.Service1.LoadBalancer.server.Port":                      "8080",
+		"traefik.HTTP.Services.Service1.LoadBalancer.server.Zone":                      "foobar",
+		"traefik.HTTP.Services.Service1.LoadBalancer.server.Region":                    "foobar",
 		"traefik.HTTP.Services.Service1.LoadBalancer.server.Scheme":                    "foobar",
 		"traefik.HTTP.Services.Service1.LoadBalancer.ServersTransport":                 "foobar",
 
@@ -20,6 +20,8 @@ const (
 const (
 	StatusUp   = "UP"
 	StatusDown = "DOWN"
+	// StatusEjected is the status of a server ejected by the outlier detection.
+	StatusEjected = "EJECTED"
 )
 
 // Configuration holds the information about the currently running traefik instance.
@@ -146,6 +146,8 @@ type ServiceInfo struct {
 
 	serverStatusMu sync.RWMutex
 	serverStatus   map[string]string // keyed by server URL
+	// serverStatusReasons are the reasons of the last failed health checks, keyed by server URL.
+	serverStatusReasons map[string]string
 }
 
 // AddError adds err to s.Err, if it does not already exist.
@@ -181,6 +183,41 @@ func (s *ServiceInfo) UpdateServerStatus(server, status string) {
 	s.serverStatus[server] = status
 }
 
+// UpdateServerStatusReason sets the reason of the last failed health check of the server in the ServiceInfo,
+// an empty reason removes it.
+// It is the responsibility of the caller to check that s is not nil.
+func (s *ServiceInfo) UpdateServerStatusReason(server, reason string) {
+	s.serverStatusMu.Lock()
+	defer s.serverStatusMu.Unlock()
+
+	if reason == "" {
+		delete(s.serverStatusReasons, server)
+		return
+	}
+
+	if s.serverStatusReasons == nil {
+		s.serverStatusReasons = make(map[string]string)
+	}
+	s.serverStatusReasons[server] = reason
+}
+
+// GetAllStatusReasons returns the reasons of the last failed health checks of the servers in ServiceInfo.
+// It is the responsibility of the caller to check that s is not nil.
+func (s *ServiceInfo) GetAllStatusReasons() map[string]string {
+	s.serverStatusMu.RLock()
+	defer s.serverStatusMu.RUnlock()
+
+	if len(s.serverStatusReasons) == 0 {
+		return nil
+	}
+
+	allReasons := make(map[string]string, len(s.serverStatusReasons))
+	for k, v := range s.serverStatusReasons {
+		allReasons[k] = v
+	}
+	return allReasons
+}
+
 // GetAllStatus returns all the statuses of all the servers in ServiceInfo.
 // It is the responsibility of the caller to check that s is not nil.
 func (s *ServiceInfo) GetAllStatus() map[string]string {
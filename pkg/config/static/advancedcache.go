@@ -0,0 +1,24 @@
+package static
+
+import (
+	"time"
+
+	ptypes "github.com/traefik/paerser/types"
+)
+
+// DefaultAdvancedCacheShutdownTimeout is the default duration to drain the advanced cache listener.
+const DefaultAdvancedCacheShutdownTimeout = 10 * time.Second
+
+// AdvancedCache serves the advanced cache on a dedicated fasthttp listener.
+// The advancedCache middlewares referring to the same configuration file share its storage.
+type AdvancedCache struct {
+	Address         string          `description:"Address of the dedicated advanced cache listener." json:"address,omitempty" toml:"address,omitempty" yaml:"address,omitempty" export:"true"`
+	ConfigPath      string          `description:"Path to the advanced cache configuration file." json:"configPath,omitempty" toml:"configPath,omitempty" yaml:"configPath,omitempty" export:"true"`
+	ShutdownTimeout ptypes.Duration `description:"Duration to drain in-flight requests on shutdown." json:"shutdownTimeout,omitempty" toml:"shutdownTimeout,omitempty" yaml:"shutdownTimeout,omitempty" export:"true"`
+}
+
+// SetDefaults sets the default values.
+func (a *AdvancedCache) SetDefaults() {
+	a.Address = ":8021"
+	a.ShutdownTimeout = ptypes.Duration(DefaultAdvancedCacheShutdownTimeout)
+}
@@ -84,6 +84,10 @@ type Configuration struct {
 	Spiffe *SpiffeClientConfig `description:"SPIFFE integration configuration." json:"spiffe,omitempty" toml:"spiffe,omitempty" yaml:"spiffe,omitempty" export:"true"`
 
 	OCSP *tls.OCSPConfig `description:"OCSP configuration." json:"ocsp,omitempty" toml:"ocsp,omitempty" yaml:"ocsp,omitempty" label:"allowEmpty" file:"allowEmpty" export:"true"`
+
+	AdvancedCache *AdvancedCache `description:"Serves the advanced cache on a dedicated fasthttp listener." json:"advancedCache,omitempty" toml:"advancedCache,omitempty" yaml:"advancedCache,omitempty" label:"allowEmpty" file:"allowEmpty" export:"true"`
+
+	Locality *Locality `description:"Zone and region Traefik runs in, used by the locality load-balancing strategy." json:"locality,omitempty" toml:"locality,omitempty" yaml:"locality,omitempty" export:"true"`
 }
 
 // Core configures Traefik core behavior.
@@ -97,6 +101,12 @@ func (c *Core) SetDefaults() {
 	c.DefaultRuleSyntax = "v3"
 }
 
+// Locality defines the zone and region Traefik runs in.
+type Locality struct {
+	Zone   string `description:"Zone Traefik runs in." json:"zone,omitempty" toml:"zone,omitempty" yaml:"zone,omitempty" export:"true"`
+	Region string `description:"Region Traefik runs in." json:"region,omitempty" toml:"region,omitempty" yaml:"region,omitempty" export:"true"`
+}
+
 // SpiffeClientConfig defines the SPIFFE client configuration.
 type SpiffeClientConfig struct {
 	WorkloadAPIAddr string `description:"Defines the workload API address." json:"workloadAPIAddr,omitempty" toml:"workloadAPIAddr,omitempty" yaml:"workloadAPIAddr,omitempty"`
@@ -393,6 +403,10 @@ func (c *Configuration) ValidateConfiguration() error {
 		}
 	}
 
+	if c.AdvancedCache != nil && c.AdvancedCache.ConfigPath == "" {
+		return errors.New("the advanced cache listener requires a configPath")
+	}
+
 	if c.Providers != nil && c.Providers.KubernetesIngressNGINX != nil {
 		if c.Experimental == nil || !c.Experimental.KubernetesIngressNGINX {
 			return errors.New("the experimental KubernetesIngressNGINX feature must be enabled to use the KubernetesIngressNGINX provider")
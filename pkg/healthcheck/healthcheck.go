@@ -1,19 +1,25 @@
 package healthcheck
 
 import (
+	"bytes"
 	"context"
+	"crypto/tls"
 	"errors"
 	"fmt"
+	"io"
 	"net"
 	"net/http"
 	"net/url"
+	"regexp"
 	"strconv"
 	"time"
 
 	gokitmetrics "github.com/go-kit/kit/metrics"
 	"github.com/rs/zerolog/log"
+	"github.com/tidwall/gjson"
 	"github.com/traefik/traefik/v3/pkg/config/dynamic"
 	"github.com/traefik/traefik/v3/pkg/config/runtime"
+	"github.com/traefik/traefik/v3/pkg/types"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials/insecure"
@@ -21,7 +27,18 @@ import (
 	"google.golang.org/grpc/status"
 )
 
-const modeGRPC = "grpc"
+// Health check modes, the http one being the default.
+const (
+	// ModeGRPC checks the servers with the gRPC health checking protocol.
+	ModeGRPC = "grpc"
+	// ModeTCP only checks that a TCP connection can be established to the servers.
+	ModeTCP = "tcp"
+	// ModeTLS only checks that a TLS handshake can be completed with the servers.
+	ModeTLS = "tls"
+)
+
+// maxBodySize is the maximum size of the health check response body read for the body assertions.
+const maxBodySize = 1 << 20
 
 // StatusSetter should be implemented by a service that, when the status of a
 // registered target change, needs to be notified of that change.
@@ -43,6 +60,11 @@ type metricsHealthCheck interface {
 type target struct {
 	targetURL *url.URL
 	name      string
+
+	up bool
+	// successes and failures are the numbers of consecutive successful and failed checks.
+	successes int
+	failures  int
 }
 
 type ServiceHealthChecker struct {
@@ -54,9 +76,17 @@ type ServiceHealthChecker struct {
 	unhealthyInterval time.Duration
 	timeout           time.Duration
 
+	// statusRanges are the accepted status codes, and bodyRegex the regular expression the body must match.
+	statusRanges types.HTTPCodeRanges
+	bodyRegex    *regexp.Regexp
+	// rise and fall are the numbers of consecutive successful and failed checks flipping the status of a server.
+	rise int
+	fall int
+
 	metrics metricsHealthCheck
 
-	client *http.Client
+	client    *http.Client
+	tlsConfig *tls.Config
 
 	healthyTargets   chan target
 	unhealthyTargets chan target
@@ -64,7 +94,9 @@ type ServiceHealthChecker struct {
 	serviceName string
 }
 
-func NewServiceHealthChecker(ctx context.Context, metrics metricsHealthCheck, config *dynamic.ServerHealthCheck, service StatusSetter, info *runtime.ServiceInfo, transport http.RoundTripper, targets map[string]*url.URL, serviceName string) *ServiceHealthChecker {
+// NewServiceHealthChecker creates a health checker of the given targets.
+// tlsConfig, which may be nil, is used by the tls mode.
+func NewServiceHealthChecker(ctx context.Context, metrics metricsHealthCheck, config *dynamic.ServerHealthCheck, service StatusSetter, info *runtime.ServiceInfo, transport http.RoundTripper, tlsConfig *tls.Config, targets map[string]*url.URL, serviceName string) (*ServiceHealthChecker, error) {
 	logger := log.Ctx(ctx)
 
 	interval := time.Duration(config.Interval)
@@ -92,6 +124,19 @@ func NewServiceHealthChecker(ctx context.Context, metrics metricsHealthCheck, co
 		timeout = time.Duration(dynamic.DefaultHealthCheckTimeout)
 	}
 
+	statusRanges, err := types.NewHTTPCodeRanges(config.StatusRanges)
+	if err != nil {
+		return nil, fmt.Errorf("parsing health check status ranges: %w", err)
+	}
+
+	var bodyRegex *regexp.Regexp
+	if config.Body != nil && config.Body.Regex != "" {
+		bodyRegex, err = regexp.Compile(config.Body.Regex)
+		if err != nil {
+			return nil, fmt.Errorf("compiling health check body regex: %w", err)
+		}
+	}
+
 	client := &http.Client{
 		Transport: transport,
 	}
@@ -107,6 +152,7 @@ func NewServiceHealthChecker(ctx context.Context, metrics metricsHealthCheck, co
 		healthyTargets <- target{
 			targetURL: targetURL,
 			name:      name,
+			up:        true,
 		}
 	}
 	unhealthyTargets := make(chan target, len(targets))
@@ -118,12 +164,17 @@ func NewServiceHealthChecker(ctx context.Context, metrics metricsHealthCheck, co
 		interval:          interval,
 		unhealthyInterval: unhealthyInterval,
 		timeout:           timeout,
+		statusRanges:      statusRanges,
+		bodyRegex:         bodyRegex,
+		rise:              max(config.Rise, 1),
+		fall:              max(config.Fall, 1),
 		healthyTargets:    healthyTargets,
 		unhealthyTargets:  unhealthyTargets,
 		serviceName:       serviceName,
 		client:            client,
+		tlsConfig:         tlsConfig,
 		metrics:           metrics,
-	}
+	}, nil
 }
 
 func (shc *ServiceHealthChecker) Launch(ctx context.Context) {
@@ -165,9 +216,7 @@ func (shc *ServiceHealthChecker) healthcheck(ctx context.Context, targets chan t
 				default:
 				}
 
-				up := true
-				serverUpMetricValue := float64(1)
-
+				var reason string
 				if err := shc.executeHealthCheck(ctx, shc.config, target.targetURL); err != nil {
 					// The context is canceled when the dynamic configuration is refreshed.
 					if errors.Is(err, context.Canceled) {
@@ -179,11 +228,27 @@ func (shc *ServiceHealthChecker) healthcheck(ctx context.Context, targets chan t
 						Err(err).
 						Msg("Health check failed.")
 
-					up = false
-					serverUpMetricValue = float64(0)
+					reason = err.Error()
+					target.successes = 0
+					target.failures++
+				} else {
+					target.successes++
+					target.failures = 0
 				}
 
-				shc.balancer.SetStatus(ctx, target.name, up)
+				// The status flips only after enough consecutive successful or failed checks.
+				switch {
+				case target.up && target.failures >= shc.fall:
+					target.up = false
+				case !target.up && target.successes >= shc.rise:
+					target.up = true
+				}
+
+				up := target.up
+				serverUpMetricValue := float64(0)
+				if up {
+					serverUpMetricValue = 1
+				}
 
 				var statusStr string
 				if up {
@@ -195,10 +260,15 @@ func (shc *ServiceHealthChecker) healthcheck(ctx context.Context, targets chan t
 				}
 
 				shc.info.UpdateServerStatus(target.targetURL.String(), statusStr)
+				shc.info.UpdateServerStatusReason(target.targetURL.String(), reason)
 
 				shc.metrics.ServiceServerUpGauge().
 					With("service", shc.serviceName, "url", target.targetURL.String()).
 					Set(serverUpMetricValue)
+
+				// The status is set last, so that an outlier detector standing as the balancer
+				// can override the server status it reports.
+				shc.balancer.SetStatus(ctx, target.name, up)
 			}
 		}
 	}
@@ -208,10 +278,16 @@ func (shc *ServiceHealthChecker) executeHealthCheck(ctx context.Context, config
 	ctx, cancel := context.WithDeadline(ctx, time.Now().Add(shc.timeout))
 	defer cancel()
 
-	if config.Mode == modeGRPC {
+	switch config.Mode {
+	case ModeGRPC:
 		return shc.checkHealthGRPC(ctx, target)
+	case ModeTCP:
+		return shc.checkHealthTCP(ctx, target)
+	case ModeTLS:
+		return shc.checkHealthTLS(ctx, target)
+	default:
+		return shc.checkHealthHTTP(ctx, target)
 	}
-	return shc.checkHealthHTTP(ctx, target)
 }
 
 // checkHealthHTTP returns an error with a meaningful description if the health check failed.
@@ -229,12 +305,59 @@ func (shc *ServiceHealthChecker) checkHealthHTTP(ctx context.Context, target *ur
 
 	defer resp.Body.Close()
 
-	if shc.config.Status == 0 && (resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusBadRequest) {
+	switch {
+	case len(shc.statusRanges) > 0:
+		if !shc.statusRanges.Contains(resp.StatusCode) {
+			return fmt.Errorf("received error status code: %v expected status codes: %v", resp.StatusCode, shc.config.StatusRanges)
+		}
+	case shc.config.Status != 0:
+		if shc.config.Status != resp.StatusCode {
+			return fmt.Errorf("received error status code: %v expected status code: %v", resp.StatusCode, shc.config.Status)
+		}
+	case resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusBadRequest:
 		return fmt.Errorf("received error status code: %v", resp.StatusCode)
 	}
 
-	if shc.config.Status != 0 && shc.config.Status != resp.StatusCode {
-		return fmt.Errorf("received error status code: %v expected status code: %v", resp.StatusCode, shc.config.Status)
+	if shc.config.Body != nil {
+		return shc.checkBody(resp.Body)
+	}
+
+	return nil
+}
+
+// checkBody returns an error with a meaningful description if a body assertion failed.
+func (shc *ServiceHealthChecker) checkBody(r io.Reader) error {
+	body, err := io.ReadAll(io.LimitReader(r, maxBodySize))
+	if err != nil {
+		return fmt.Errorf("reading body: %w", err)
+	}
+
+	assertions := shc.config.Body
+
+	if assertions.Contains != "" && !bytes.Contains(body, []byte(assertions.Contains)) {
+		return fmt.Errorf("body does not contain %q", assertions.Contains)
+	}
+
+	if shc.bodyRegex != nil && !shc.bodyRegex.Match(body) {
+		return fmt.Errorf("body does not match %q", assertions.Regex)
+	}
+
+	if assertions.JSONPath == "" {
+		return nil
+	}
+
+	if !gjson.ValidBytes(body) {
+		return errors.New("body is not valid JSON")
+	}
+
+	value := gjson.GetBytes(body, assertions.JSONPath)
+	switch {
+	case !value.Exists():
+		return fmt.Errorf("JSON path %q not found in body", assertions.JSONPath)
+	case assertions.JSONValue == "" && (value.Type == gjson.Null || value.Type == gjson.False):
+		return fmt.Errorf("JSON path %q value is %s", assertions.JSONPath, value.Raw)
+	case assertions.JSONValue != "" && value.String() != assertions.JSONValue:
+		return fmt.Errorf("JSON path %q value is %q expected value: %q", assertions.JSONPath, value.String(), assertions.JSONValue)
 	}
 
 	return nil
@@ -300,7 +423,7 @@ func (shc *ServiceHealthChecker) checkHealthGRPC(ctx context.Context, serverURL
 	}
 	defer func() { _ = conn.Close() }()
 
-	resp, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{})
+	resp, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{Service: shc.config.GRPCService})
 	if err != nil {
 		if stat, ok := status.FromError(err); ok {
 			switch stat.Code() {
@@ -322,3 +445,57 @@ func (shc *ServiceHealthChecker) checkHealthGRPC(ctx context.Context, serverURL
 
 	return nil
 }
+
+// checkHealthTCP returns an error with a meaningful description if a TCP connection cannot be established to the server.
+func (shc *ServiceHealthChecker) checkHealthTCP(ctx context.Context, serverURL *url.URL) error {
+	serverAddr := shc.serverAddress(serverURL)
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", serverAddr)
+	if err != nil {
+		return fmt.Errorf("TCP connection to %s failed: %w", serverAddr, err)
+	}
+	_ = conn.Close()
+
+	return nil
+}
+
+// checkHealthTLS returns an error with a meaningful description if the TLS handshake with the server failed.
+func (shc *ServiceHealthChecker) checkHealthTLS(ctx context.Context, serverURL *url.URL) error {
+	serverAddr := shc.serverAddress(serverURL)
+
+	tlsConfig := &tls.Config{}
+	if shc.tlsConfig != nil {
+		tlsConfig = shc.tlsConfig.Clone()
+	}
+	if tlsConfig.ServerName == "" {
+		tlsConfig.ServerName = serverURL.Hostname()
+		if shc.config.Hostname != "" {
+			tlsConfig.ServerName = shc.config.Hostname
+		}
+	}
+
+	dialer := tls.Dialer{Config: tlsConfig}
+	conn, err := dialer.DialContext(ctx, "tcp", serverAddr)
+	if err != nil {
+		return fmt.Errorf("TLS handshake with %s failed: %w", serverAddr, err)
+	}
+	_ = conn.Close()
+
+	return nil
+}
+
+// serverAddress returns the address of the server, with the health check port if defined.
+func (shc *ServiceHealthChecker) serverAddress(serverURL *url.URL) string {
+	port := serverURL.Port()
+	switch {
+	case shc.config.Port != 0:
+		port = strconv.Itoa(shc.config.Port)
+	case port == "" && serverURL.Scheme == "https":
+		port = "443"
+	case port == "":
+		port = "80"
+	}
+
+	return net.JoinHostPort(serverURL.Hostname(), port)
+}
@@ -66,7 +66,8 @@ func TestNewServiceHealthChecker_durations(t *testing.T) {
 
 	for _, test := range testCases {
 		t.Run(test.desc, func(t *testing.T) {
-			healthChecker := NewServiceHealthChecker(t.Context(), nil, test.config, nil, nil, http.DefaultTransport, nil, "")
+			healthChecker, err := NewServiceHealthChecker(t.Context(), nil, test.config, nil, nil, http.DefaultTransport, nil, nil, "")
+			require.NoError(t, err)
 			assert.Equal(t, test.expInterval, healthChecker.interval)
 			assert.Equal(t, test.expTimeout, healthChecker.timeout)
 		})
@@ -291,19 +292,178 @@ func TestServiceHealthChecker_checkHealthHTTP_NotFollowingRedirects(t *testing.T
 		Interval:        dynamic.DefaultHealthCheckInterval,
 		Timeout:         dynamic.DefaultHealthCheckTimeout,
 	}
-	healthChecker := NewServiceHealthChecker(ctx, nil, config, nil, nil, http.DefaultTransport, nil, "")
+	healthChecker, err := NewServiceHealthChecker(ctx, nil, config, nil, nil, http.DefaultTransport, nil, nil, "")
+	require.NoError(t, err)
 
-	err := healthChecker.checkHealthHTTP(ctx, testhelpers.MustParseURL(server.URL))
+	err = healthChecker.checkHealthHTTP(ctx, testhelpers.MustParseURL(server.URL))
 	require.NoError(t, err)
 
 	assert.False(t, redirectServerCalled, "HTTP redirect must not be followed")
 }
 
+func TestNewServiceHealthChecker_invalidConfig(t *testing.T) {
+	_, err := NewServiceHealthChecker(t.Context(), nil, &dynamic.ServerHealthCheck{StatusRanges: []string{"foo"}}, nil, nil, http.DefaultTransport, nil, nil, "")
+	require.Error(t, err)
+
+	_, err = NewServiceHealthChecker(t.Context(), nil, &dynamic.ServerHealthCheck{Body: &dynamic.HealthCheckBody{Regex: "("}}, nil, nil, http.DefaultTransport, nil, nil, "")
+	require.Error(t, err)
+}
+
+func TestServiceHealthChecker_checkHealthHTTP(t *testing.T) {
+	testCases := []struct {
+		desc        string
+		config      dynamic.ServerHealthCheck
+		status      int
+		body        string
+		expectedErr string
+	}{
+		{
+			desc:   "status in ranges",
+			config: dynamic.ServerHealthCheck{StatusRanges: []string{"200-299", "404"}},
+			status: http.StatusNotFound,
+		},
+		{
+			desc:        "status not in ranges",
+			config:      dynamic.ServerHealthCheck{StatusRanges: []string{"200-299"}},
+			status:      http.StatusMovedPermanently,
+			expectedErr: "received error status code: 301 expected status codes: [200-299]",
+		},
+		{
+			desc:   "body contains",
+			config: dynamic.ServerHealthCheck{Body: &dynamic.HealthCheckBody{Contains: "OK"}},
+			status: http.StatusOK,
+			body:   "status: OK",
+		},
+		{
+			desc:        "body does not contain",
+			config:      dynamic.ServerHealthCheck{Body: &dynamic.HealthCheckBody{Contains: "OK"}},
+			status:      http.StatusOK,
+			body:        "status: degraded",
+			expectedErr: `body does not contain "OK"`,
+		},
+		{
+			desc:   "body matches",
+			config: dynamic.ServerHealthCheck{Body: &dynamic.HealthCheckBody{Regex: `^status: (OK|ready)$`}},
+			status: http.StatusOK,
+			body:   "status: ready",
+		},
+		{
+			desc:        "body does not match",
+			config:      dynamic.ServerHealthCheck{Body: &dynamic.HealthCheckBody{Regex: `^status: (OK|ready)$`}},
+			status:      http.StatusOK,
+			body:        "status: degraded",
+			expectedErr: `body does not match "^status: (OK|ready)$"`,
+		},
+		{
+			desc:   "JSON path value",
+			config: dynamic.ServerHealthCheck{Body: &dynamic.HealthCheckBody{JSONPath: "checks.db.status", JSONValue: "up"}},
+			status: http.StatusOK,
+			body:   `{"checks":{"db":{"status":"up"}}}`,
+		},
+		{
+			desc:        "unexpected JSON path value",
+			config:      dynamic.ServerHealthCheck{Body: &dynamic.HealthCheckBody{JSONPath: "checks.db.status", JSONValue: "up"}},
+			status:      http.StatusOK,
+			body:        `{"checks":{"db":{"status":"degraded"}}}`,
+			expectedErr: `JSON path "checks.db.status" value is "degraded" expected value: "up"`,
+		},
+		{
+			desc:   "truthy JSON path value",
+			config: dynamic.ServerHealthCheck{Body: &dynamic.HealthCheckBody{JSONPath: "healthy"}},
+			status: http.StatusOK,
+			body:   `{"healthy":true}`,
+		},
+		{
+			desc:        "false JSON path value",
+			config:      dynamic.ServerHealthCheck{Body: &dynamic.HealthCheckBody{JSONPath: "healthy"}},
+			status:      http.StatusOK,
+			body:        `{"healthy":false}`,
+			expectedErr: `JSON path "healthy" value is false`,
+		},
+		{
+			desc:        "missing JSON path",
+			config:      dynamic.ServerHealthCheck{Body: &dynamic.HealthCheckBody{JSONPath: "healthy"}},
+			status:      http.StatusOK,
+			body:        `{}`,
+			expectedErr: `JSON path "healthy" not found in body`,
+		},
+		{
+			desc:        "invalid JSON body",
+			config:      dynamic.ServerHealthCheck{Body: &dynamic.HealthCheckBody{JSONPath: "healthy"}},
+			status:      http.StatusOK,
+			body:        `status: OK`,
+			expectedErr: "body is not valid JSON",
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+				rw.WriteHeader(test.status)
+				_, _ = rw.Write([]byte(test.body))
+			}))
+			t.Cleanup(server.Close)
+
+			healthChecker, err := NewServiceHealthChecker(t.Context(), nil, &test.config, nil, nil, http.DefaultTransport, nil, nil, "")
+			require.NoError(t, err)
+
+			err = healthChecker.checkHealthHTTP(t.Context(), testhelpers.MustParseURL(server.URL))
+			if test.expectedErr != "" {
+				require.EqualError(t, err, test.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestServiceHealthChecker_checkHealthTCP(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	serverURL := testhelpers.MustParseURL(server.URL)
+
+	healthChecker, err := NewServiceHealthChecker(t.Context(), nil, &dynamic.ServerHealthCheck{Mode: ModeTCP}, nil, nil, http.DefaultTransport, nil, nil, "")
+	require.NoError(t, err)
+
+	err = healthChecker.executeHealthCheck(t.Context(), healthChecker.config, serverURL)
+	require.NoError(t, err)
+
+	server.Close()
+
+	err = healthChecker.executeHealthCheck(t.Context(), healthChecker.config, serverURL)
+	require.Error(t, err)
+}
+
+func TestServiceHealthChecker_checkHealthTLS(t *testing.T) {
+	server := httptest.NewTLSServer(http.NotFoundHandler())
+	t.Cleanup(server.Close)
+	serverURL := testhelpers.MustParseURL(server.URL)
+
+	config := &dynamic.ServerHealthCheck{Mode: ModeTLS}
+
+	// The server certificate is not trusted.
+	healthChecker, err := NewServiceHealthChecker(t.Context(), nil, config, nil, nil, http.DefaultTransport, nil, nil, "")
+	require.NoError(t, err)
+
+	err = healthChecker.executeHealthCheck(t.Context(), config, serverURL)
+	require.Error(t, err)
+
+	tlsConfig := server.Client().Transport.(*http.Transport).TLSClientConfig
+	healthChecker, err = NewServiceHealthChecker(t.Context(), nil, config, nil, nil, http.DefaultTransport, tlsConfig, nil, "")
+	require.NoError(t, err)
+
+	err = healthChecker.executeHealthCheck(t.Context(), config, serverURL)
+	require.NoError(t, err)
+}
+
 func TestServiceHealthChecker_Launch(t *testing.T) {
 	testCases := []struct {
 		desc                  string
 		mode                  string
 		status                int
+		rise                  int
+		fall                  int
 		server                StartTestServer
 		expNumRemovedServers  int
 		expNumUpsertedServers int
@@ -376,6 +536,33 @@ func TestServiceHealthChecker_Launch(t *testing.T) {
 			expGaugeValue:         0,
 			targetStatus:          runtime.StatusDown,
 		},
+		{
+			desc:                  "healthy server staying healthy until the fall threshold",
+			server:                newHTTPServer(http.StatusServiceUnavailable, http.StatusOK),
+			fall:                  2,
+			expNumRemovedServers:  0,
+			expNumUpsertedServers: 2,
+			expGaugeValue:         1,
+			targetStatus:          runtime.StatusUp,
+		},
+		{
+			desc:                  "healthy server becoming sick at the fall threshold",
+			server:                newHTTPServer(http.StatusServiceUnavailable, http.StatusServiceUnavailable),
+			fall:                  2,
+			expNumRemovedServers:  1,
+			expNumUpsertedServers: 1,
+			expGaugeValue:         0,
+			targetStatus:          runtime.StatusDown,
+		},
+		{
+			desc:                  "sick server becoming healthy at the rise threshold",
+			server:                newHTTPServer(http.StatusServiceUnavailable, http.StatusOK, http.StatusOK),
+			rise:                  2,
+			expNumRemovedServers:  2,
+			expNumUpsertedServers: 1,
+			expGaugeValue:         1,
+			targetStatus:          runtime.StatusUp,
+		},
 		{
 			desc:                  "healthy grpc server staying healthy",
 			mode:                  "grpc",
@@ -421,6 +608,8 @@ func TestServiceHealthChecker_Launch(t *testing.T) {
 			config := &dynamic.ServerHealthCheck{
 				Mode:              test.mode,
 				Status:            test.status,
+				Rise:              test.rise,
+				Fall:              test.fall,
 				Path:              "/path",
 				Interval:          ptypes.Duration(500 * time.Millisecond),
 				UnhealthyInterval: pointer(ptypes.Duration(500 * time.Millisecond)),
@@ -429,7 +618,8 @@ func TestServiceHealthChecker_Launch(t *testing.T) {
 
 			gauge := &testhelpers.CollectingGauge{}
 			serviceInfo := &runtime.ServiceInfo{}
-			hc := NewServiceHealthChecker(ctx, &MetricsMock{gauge}, config, lb, serviceInfo, http.DefaultTransport, map[string]*url.URL{"test": targetURL}, "foobar")
+			hc, err := NewServiceHealthChecker(ctx, &MetricsMock{gauge}, config, lb, serviceInfo, http.DefaultTransport, nil, map[string]*url.URL{"test": targetURL}, "foobar")
+			require.NoError(t, err)
 
 			wg := sync.WaitGroup{}
 			wg.Add(1)
@@ -454,6 +644,11 @@ func TestServiceHealthChecker_Launch(t *testing.T) {
 			assert.InDelta(t, test.expGaugeValue, gauge.GaugeValue, delta, "ServerUp Gauge")
 			assert.Equal(t, []string{"service", "foobar", "url", targetURL.String()}, gauge.LastLabelValues)
 			assert.Equal(t, map[string]string{targetURL.String(): test.targetStatus}, serviceInfo.GetAllStatus())
+			if test.targetStatus == runtime.StatusUp {
+				assert.Empty(t, serviceInfo.GetAllStatusReasons())
+			} else {
+				assert.Contains(t, serviceInfo.GetAllStatusReasons(), targetURL.String())
+			}
 		})
 	}
 }
@@ -486,7 +681,8 @@ func TestDifferentIntervals(t *testing.T) {
 
 	gauge := &testhelpers.CollectingGauge{}
 	serviceInfo := &runtime.ServiceInfo{}
-	hc := NewServiceHealthChecker(ctx, &MetricsMock{gauge}, config, lb, serviceInfo, http.DefaultTransport, map[string]*url.URL{"healthy": healthyURL, "unhealthy": unhealthyURL}, "foobar")
+	hc, err := NewServiceHealthChecker(ctx, &MetricsMock{gauge}, config, lb, serviceInfo, http.DefaultTransport, nil, map[string]*url.URL{"healthy": healthyURL, "unhealthy": unhealthyURL}, "foobar")
+	require.NoError(t, err)
 
 	wg := sync.WaitGroup{}
 	wg.Add(1)
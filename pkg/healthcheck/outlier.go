@@ -0,0 +1,361 @@
+package healthcheck
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	gokitmetrics "github.com/go-kit/kit/metrics"
+	"github.com/rs/zerolog/log"
+	"github.com/traefik/traefik/v3/pkg/config/dynamic"
+	"github.com/traefik/traefik/v3/pkg/config/runtime"
+)
+
+type metricsOutlierDetection interface {
+	ServiceServerUpGauge() gokitmetrics.Gauge
+	ServiceServerEjectionsCounter() gokitmetrics.Counter
+}
+
+type outlierServer struct {
+	name      string
+	targetURL string
+
+	// Responses accounting, updated by the request path.
+	consecutiveErrors        atomic.Int64
+	consecutiveGatewayErrors atomic.Int64
+	successes                atomic.Int64
+	failures                 atomic.Int64
+
+	// Ejection state, protected by the detector mutex.
+	// up is the status given by the active health check.
+	up           bool
+	ejected      bool
+	ejectedUntil time.Time
+	// ejections is the multiplier of the ejection time, incremented on ejection,
+	// and decremented on each interval the server is not ejected.
+	ejections int
+}
+
+// OutlierDetector is a passive health checker: it watches the responses of the servers of a load-balancer,
+// and ejects the ones failing too many requests for an exponentially growing duration.
+// It stands between the active health checker, if any, and the load-balancer:
+// a server is up for the load-balancer if it is up for the active health checker and not ejected.
+type OutlierDetector struct {
+	balancer StatusSetter
+	info     *runtime.ServiceInfo
+
+	consecutiveErrors        int64
+	consecutiveGatewayErrors int64
+	successRate              *dynamic.OutlierSuccessRate
+	interval                 time.Duration
+	baseEjectionTime         time.Duration
+	maxEjectionTime          time.Duration
+	maxEjectionPercent       int
+
+	metrics metricsOutlierDetection
+
+	serviceName string
+
+	now func() time.Time
+
+	// mu protects the servers map and their ejection state.
+	mu      sync.Mutex
+	servers map[string]*outlierServer
+}
+
+// NewOutlierDetector creates an outlier detector updating the status of the servers of the given balancer.
+// Servers are registered with Wrap.
+func NewOutlierDetector(ctx context.Context, metrics metricsOutlierDetection, config *dynamic.OutlierDetection, balancer StatusSetter, info *runtime.ServiceInfo, serviceName string) *OutlierDetector {
+	logger := log.Ctx(ctx)
+
+	defaults := &dynamic.OutlierDetection{}
+	defaults.SetDefaults()
+
+	interval := time.Duration(config.Interval)
+	if interval <= 0 {
+		logger.Error().Msg("Outlier detection interval smaller than zero, default value will be used instead.")
+		interval = time.Duration(defaults.Interval)
+	}
+
+	baseEjectionTime := time.Duration(config.BaseEjectionTime)
+	if baseEjectionTime <= 0 {
+		logger.Error().Msg("Outlier detection base ejection time smaller than zero, default value will be used instead.")
+		baseEjectionTime = time.Duration(defaults.BaseEjectionTime)
+	}
+
+	maxEjectionTime := time.Duration(config.MaxEjectionTime)
+	if maxEjectionTime < baseEjectionTime {
+		logger.Error().Msg("Outlier detection max ejection time smaller than the base ejection time, the base ejection time will be used instead.")
+		maxEjectionTime = baseEjectionTime
+	}
+
+	return &OutlierDetector{
+		balancer:                 balancer,
+		info:                     info,
+		consecutiveErrors:        int64(config.ConsecutiveErrors),
+		consecutiveGatewayErrors: int64(config.ConsecutiveGatewayErrors),
+		successRate:              config.SuccessRate,
+		interval:                 interval,
+		baseEjectionTime:         baseEjectionTime,
+		maxEjectionTime:          maxEjectionTime,
+		maxEjectionPercent:       config.MaxEjectionPercent,
+		metrics:                  metrics,
+		serviceName:              serviceName,
+		now:                      time.Now,
+		servers:                  make(map[string]*outlierServer),
+	}
+}
+
+// Wrap registers the server of the given name and returns its handler, accounting the responses it gives.
+func (d *OutlierDetector) Wrap(name string, targetURL *url.URL, next http.Handler) http.Handler {
+	server := &outlierServer{name: name, targetURL: targetURL.String(), up: true}
+
+	d.mu.Lock()
+	d.servers[name] = server
+	d.mu.Unlock()
+
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		recorder := &outlierStatusRecorder{ResponseWriter: rw, status: http.StatusOK}
+		next.ServeHTTP(recorder, req)
+
+		d.observe(req.Context(), server, recorder.status)
+	})
+}
+
+// SetStatus records the status given by the active health checker,
+// the server is up for the balancer only if it is not ejected.
+func (d *OutlierDetector) SetStatus(ctx context.Context, childName string, up bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	server, ok := d.servers[childName]
+	if !ok {
+		d.balancer.SetStatus(ctx, childName, up)
+		return
+	}
+
+	server.up = up
+	d.updateStatus(ctx, server)
+}
+
+// Launch periodically ends ejections and evaluates the success rates, until ctx is done.
+func (d *OutlierDetector) Launch(ctx context.Context) {
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			d.evaluate(ctx)
+		}
+	}
+}
+
+func (d *OutlierDetector) observe(ctx context.Context, server *outlierServer, status int) {
+	if status < http.StatusInternalServerError {
+		server.successes.Add(1)
+		server.consecutiveErrors.Store(0)
+		server.consecutiveGatewayErrors.Store(0)
+		return
+	}
+
+	server.failures.Add(1)
+	errs := server.consecutiveErrors.Add(1)
+
+	var gatewayErrs int64
+	switch status {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		gatewayErrs = server.consecutiveGatewayErrors.Add(1)
+	default:
+		server.consecutiveGatewayErrors.Store(0)
+	}
+
+	switch {
+	case d.consecutiveErrors > 0 && errs >= d.consecutiveErrors:
+		d.eject(ctx, server, "consecutive errors")
+	case d.consecutiveGatewayErrors > 0 && gatewayErrs >= d.consecutiveGatewayErrors:
+		d.eject(ctx, server, "consecutive gateway errors")
+	}
+}
+
+func (d *OutlierDetector) eject(ctx context.Context, server *outlierServer, reason string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.ejectLocked(ctx, server, reason)
+}
+
+// ejectLocked ejects the server unless the maximum of ejected servers is reached.
+// The caller must hold the detector lock.
+func (d *OutlierDetector) ejectLocked(ctx context.Context, server *outlierServer, reason string) {
+	if server.ejected {
+		return
+	}
+
+	var ejected int
+	for _, s := range d.servers {
+		if s.ejected {
+			ejected++
+		}
+	}
+	if ejected >= max(1, len(d.servers)*d.maxEjectionPercent/100) {
+		log.Ctx(ctx).Debug().Str("targetURL", server.targetURL).
+			Msgf("Outlier detected (%s), but the maximum of ejected servers is reached.", reason)
+		return
+	}
+
+	duration := d.baseEjectionTime
+	for range server.ejections {
+		duration *= 2
+		if duration >= d.maxEjectionTime {
+			break
+		}
+	}
+	duration = min(duration, d.maxEjectionTime)
+
+	server.ejections++
+	server.ejected = true
+	server.ejectedUntil = d.now().Add(duration)
+	server.consecutiveErrors.Store(0)
+	server.consecutiveGatewayErrors.Store(0)
+
+	log.Ctx(ctx).Warn().Str("targetURL", server.targetURL).Dur("duration", duration).
+		Msgf("Outlier detected (%s), server ejected.", reason)
+
+	d.metrics.ServiceServerEjectionsCounter().
+		With("service", d.serviceName, "url", server.targetURL).
+		Add(1)
+
+	d.updateStatus(ctx, server)
+}
+
+func (d *OutlierDetector) evaluate(ctx context.Context) {
+	now := d.now()
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for _, server := range d.servers {
+		switch {
+		case server.ejected && !now.Before(server.ejectedUntil):
+			server.ejected = false
+			log.Ctx(ctx).Info().Str("targetURL", server.targetURL).Msg("Server ejection ended.")
+			d.updateStatus(ctx, server)
+		case !server.ejected && server.ejections > 0:
+			server.ejections--
+		}
+	}
+
+	if d.successRate != nil {
+		d.evaluateSuccessRate(ctx)
+	}
+
+	for _, server := range d.servers {
+		server.successes.Store(0)
+		server.failures.Store(0)
+	}
+}
+
+// evaluateSuccessRate ejects the servers whose success rate over the last interval
+// is below the mean minus stdevFactor times the standard deviation.
+// The caller must hold the detector lock.
+func (d *OutlierDetector) evaluateSuccessRate(ctx context.Context) {
+	rates := make(map[*outlierServer]float64)
+	for _, server := range d.servers {
+		if server.ejected || !server.up {
+			continue
+		}
+
+		successes, failures := server.successes.Load(), server.failures.Load()
+		if successes+failures < int64(d.successRate.RequestVolume) || successes+failures == 0 {
+			continue
+		}
+		rates[server] = float64(successes) / float64(successes+failures)
+	}
+
+	if len(rates) == 0 || len(rates) < d.successRate.MinimumHosts {
+		return
+	}
+
+	var mean float64
+	for _, rate := range rates {
+		mean += rate
+	}
+	mean /= float64(len(rates))
+
+	var variance float64
+	for _, rate := range rates {
+		variance += (rate - mean) * (rate - mean)
+	}
+	stdev := math.Sqrt(variance / float64(len(rates)))
+
+	threshold := mean - d.successRate.StdevFactor*stdev
+	for server, rate := range rates {
+		if rate < threshold {
+			d.ejectLocked(ctx, server, "low success rate")
+		}
+	}
+}
+
+// updateStatus propagates the status of the server to the balancer, the runtime information and the metrics.
+// The caller must hold the detector lock.
+func (d *OutlierDetector) updateStatus(ctx context.Context, server *outlierServer) {
+	up := server.up && !server.ejected
+	d.balancer.SetStatus(ctx, server.name, up)
+
+	status := runtime.StatusUp
+	switch {
+	case server.ejected:
+		status = runtime.StatusEjected
+	case !server.up:
+		status = runtime.StatusDown
+	}
+	d.info.UpdateServerStatus(server.targetURL, status)
+
+	serverUpMetricValue := float64(0)
+	if up {
+		serverUpMetricValue = 1
+	}
+	d.metrics.ServiceServerUpGauge().
+		With("service", d.serviceName, "url", server.targetURL).
+		Set(serverUpMetricValue)
+}
+
+// outlierStatusRecorder records the status code of the response.
+type outlierStatusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+// WriteHeader captures the final status code.
+func (r *outlierStatusRecorder) WriteHeader(status int) {
+	if status >= http.StatusOK {
+		r.status = status
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// Hijack hijacks the connection.
+func (r *outlierStatusRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := r.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, fmt.Errorf("%T is not a http.Hijacker", r.ResponseWriter)
+	}
+	return hijacker.Hijack()
+}
+
+// Flush sends any buffered data to the client.
+func (r *outlierStatusRecorder) Flush() {
+	if flusher, ok := r.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
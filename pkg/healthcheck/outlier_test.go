@@ -0,0 +1,309 @@
+package healthcheck
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	gokitmetrics "github.com/go-kit/kit/metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	ptypes "github.com/traefik/paerser/types"
+	"github.com/traefik/traefik/v3/pkg/config/dynamic"
+	"github.com/traefik/traefik/v3/pkg/config/runtime"
+	"github.com/traefik/traefik/v3/pkg/testhelpers"
+)
+
+type statusBalancer struct {
+	mu     sync.Mutex
+	status map[string]bool
+}
+
+func (b *statusBalancer) SetStatus(_ context.Context, childName string, up bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.status[childName] = up
+}
+
+func (b *statusBalancer) isUp(childName string) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	up, ok := b.status[childName]
+	return !ok || up
+}
+
+type outlierMetricsMock struct {
+	up        *testhelpers.CollectingGauge
+	ejections *testhelpers.CollectingCounter
+}
+
+func (m *outlierMetricsMock) ServiceServerUpGauge() gokitmetrics.Gauge {
+	return m.up
+}
+
+func (m *outlierMetricsMock) ServiceServerEjectionsCounter() gokitmetrics.Counter {
+	return m.ejections
+}
+
+type outlierTest struct {
+	detector *OutlierDetector
+	balancer *statusBalancer
+	info     *runtime.ServiceInfo
+	metrics  *outlierMetricsMock
+	handlers map[string]http.Handler
+	codes    map[string]int
+	now      time.Time
+}
+
+func newOutlierTest(t *testing.T, config *dynamic.OutlierDetection, servers int) *outlierTest {
+	t.Helper()
+
+	test := &outlierTest{
+		balancer: &statusBalancer{status: make(map[string]bool)},
+		info:     &runtime.ServiceInfo{},
+		metrics:  &outlierMetricsMock{up: &testhelpers.CollectingGauge{}, ejections: &testhelpers.CollectingCounter{}},
+		handlers: make(map[string]http.Handler),
+		codes:    make(map[string]int),
+		now:      time.Unix(0, 0),
+	}
+	test.detector = NewOutlierDetector(context.Background(), test.metrics, config, test.balancer, test.info, "service")
+	test.detector.now = func() time.Time { return test.now }
+
+	for i := range servers {
+		name := "http://10.0.0." + strconv.Itoa(i)
+		test.codes[name] = http.StatusOK
+		test.handlers[name] = test.detector.Wrap(name, testhelpers.MustParseURL(name), http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+			rw.WriteHeader(test.codes[name])
+		}))
+	}
+	return test
+}
+
+func (o *outlierTest) serve(name string, count int) {
+	for range count {
+		o.handlers[name].ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	}
+}
+
+func (o *outlierTest) advance(d time.Duration) {
+	o.now = o.now.Add(d)
+	o.detector.evaluate(context.Background())
+}
+
+func newOutlierDetection() *dynamic.OutlierDetection {
+	config := &dynamic.OutlierDetection{}
+	config.SetDefaults()
+	config.MaxEjectionPercent = 100
+	return config
+}
+
+func TestOutlierDetector_consecutiveErrors(t *testing.T) {
+	testCases := []struct {
+		desc          string
+		config        func(config *dynamic.OutlierDetection)
+		code          int
+		count         int
+		expectedEject bool
+	}{
+		{
+			desc:          "consecutive errors",
+			code:          http.StatusInternalServerError,
+			count:         5,
+			expectedEject: true,
+		},
+		{
+			desc:  "not enough consecutive errors",
+			code:  http.StatusInternalServerError,
+			count: 4,
+		},
+		{
+			desc:  "client errors",
+			code:  http.StatusNotFound,
+			count: 10,
+		},
+		{
+			desc: "consecutive gateway errors",
+			config: func(config *dynamic.OutlierDetection) {
+				config.ConsecutiveErrors = 0
+				config.ConsecutiveGatewayErrors = 3
+			},
+			code:          http.StatusBadGateway,
+			count:         3,
+			expectedEject: true,
+		},
+		{
+			desc: "internal errors are not gateway errors",
+			config: func(config *dynamic.OutlierDetection) {
+				config.ConsecutiveErrors = 0
+				config.ConsecutiveGatewayErrors = 3
+			},
+			code:  http.StatusInternalServerError,
+			count: 10,
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			config := newOutlierDetection()
+			if test.config != nil {
+				test.config(config)
+			}
+			o := newOutlierTest(t, config, 2)
+
+			o.codes["http://10.0.0.0"] = test.code
+			o.serve("http://10.0.0.0", test.count)
+
+			assert.Equal(t, !test.expectedEject, o.balancer.isUp("http://10.0.0.0"))
+			assert.True(t, o.balancer.isUp("http://10.0.0.1"))
+
+			if test.expectedEject {
+				assert.Equal(t, runtime.StatusEjected, o.info.GetAllStatus()["http://10.0.0.0"])
+				assert.InDelta(t, 1, o.metrics.ejections.CounterValue, 0)
+				assert.Equal(t, []string{"service", "service", "url", "http://10.0.0.0"}, o.metrics.ejections.LastLabelValues)
+				assert.InDelta(t, 0, o.metrics.up.GaugeValue, 0)
+			}
+		})
+	}
+}
+
+func TestOutlierDetector_successResetsConsecutiveErrors(t *testing.T) {
+	o := newOutlierTest(t, newOutlierDetection(), 2)
+
+	o.codes["http://10.0.0.0"] = http.StatusInternalServerError
+	o.serve("http://10.0.0.0", 4)
+	o.codes["http://10.0.0.0"] = http.StatusOK
+	o.serve("http://10.0.0.0", 1)
+	o.codes["http://10.0.0.0"] = http.StatusInternalServerError
+	o.serve("http://10.0.0.0", 4)
+
+	assert.True(t, o.balancer.isUp("http://10.0.0.0"))
+}
+
+func TestOutlierDetector_ejectionTime(t *testing.T) {
+	config := newOutlierDetection()
+	config.BaseEjectionTime = ptypes.Duration(10 * time.Second)
+	config.MaxEjectionTime = ptypes.Duration(30 * time.Second)
+	config.Interval = ptypes.Duration(time.Second)
+	o := newOutlierTest(t, config, 2)
+
+	const name = "http://10.0.0.0"
+	o.codes[name] = http.StatusInternalServerError
+
+	// Each ejection lasts twice as long as the previous one, up to the max ejection time.
+	for _, expected := range []time.Duration{10 * time.Second, 20 * time.Second, 30 * time.Second, 30 * time.Second} {
+		o.serve(name, 5)
+		require.False(t, o.balancer.isUp(name))
+
+		o.advance(expected - time.Second)
+		require.False(t, o.balancer.isUp(name), "ejection shorter than %s", expected)
+
+		o.advance(time.Second)
+		require.True(t, o.balancer.isUp(name), "ejection longer than %s", expected)
+		assert.Equal(t, runtime.StatusUp, o.info.GetAllStatus()[name])
+	}
+
+	// The multiplier decreases while the server is not ejected.
+	for range 4 {
+		o.advance(time.Second)
+	}
+	o.serve(name, 5)
+	o.advance(10 * time.Second)
+	assert.True(t, o.balancer.isUp(name))
+}
+
+func TestOutlierDetector_maxEjectionPercent(t *testing.T) {
+	config := newOutlierDetection()
+	config.MaxEjectionPercent = 50
+	o := newOutlierTest(t, config, 4)
+
+	for name := range o.codes {
+		o.codes[name] = http.StatusInternalServerError
+		o.serve(name, 5)
+	}
+
+	var ejected int
+	for name := range o.codes {
+		if !o.balancer.isUp(name) {
+			ejected++
+		}
+	}
+	assert.Equal(t, 2, ejected)
+}
+
+func TestOutlierDetector_atLeastOneEjection(t *testing.T) {
+	config := newOutlierDetection()
+	config.MaxEjectionPercent = 10
+	o := newOutlierTest(t, config, 2)
+
+	o.codes["http://10.0.0.0"] = http.StatusInternalServerError
+	o.serve("http://10.0.0.0", 5)
+
+	assert.False(t, o.balancer.isUp("http://10.0.0.0"))
+}
+
+func TestOutlierDetector_successRate(t *testing.T) {
+	config := newOutlierDetection()
+	config.ConsecutiveErrors = 0
+	config.SuccessRate = &dynamic.OutlierSuccessRate{}
+	config.SuccessRate.SetDefaults()
+	o := newOutlierTest(t, config, 6)
+
+	// 20% of errors on one server, none on the others.
+	for _, name := range []string{"http://10.0.0.0", "http://10.0.0.1", "http://10.0.0.2", "http://10.0.0.3", "http://10.0.0.4"} {
+		o.serve(name, 100)
+	}
+	o.codes["http://10.0.0.0"] = http.StatusServiceUnavailable
+	o.serve("http://10.0.0.0", 25)
+	// Not enough requests to be evaluated.
+	o.codes["http://10.0.0.5"] = http.StatusServiceUnavailable
+	o.serve("http://10.0.0.5", 50)
+
+	o.advance(time.Second)
+
+	assert.False(t, o.balancer.isUp("http://10.0.0.0"))
+	for _, name := range []string{"http://10.0.0.1", "http://10.0.0.2", "http://10.0.0.3", "http://10.0.0.4", "http://10.0.0.5"} {
+		assert.True(t, o.balancer.isUp(name), name)
+	}
+
+	// The window is reset on each interval.
+	o.codes["http://10.0.0.1"] = http.StatusServiceUnavailable
+	o.serve("http://10.0.0.1", 50)
+	o.advance(time.Second)
+	assert.True(t, o.balancer.isUp("http://10.0.0.1"), "not enough servers with the required volume")
+}
+
+func TestOutlierDetector_activeHealthCheck(t *testing.T) {
+	o := newOutlierTest(t, newOutlierDetection(), 2)
+
+	const name = "http://10.0.0.0"
+
+	// Down for the active health check.
+	o.detector.SetStatus(context.Background(), name, false)
+	assert.False(t, o.balancer.isUp(name))
+	assert.Equal(t, runtime.StatusDown, o.info.GetAllStatus()[name])
+
+	// Up for the active health check, yet ejected.
+	o.codes[name] = http.StatusInternalServerError
+	o.serve(name, 5)
+	o.detector.SetStatus(context.Background(), name, true)
+	assert.False(t, o.balancer.isUp(name))
+	assert.Equal(t, runtime.StatusEjected, o.info.GetAllStatus()[name])
+
+	// Ejection ended.
+	o.advance(time.Duration(dynamic.DefaultHealthCheckInterval))
+	assert.True(t, o.balancer.isUp(name))
+	assert.Equal(t, runtime.StatusUp, o.info.GetAllStatus()[name])
+}
+
+func TestOutlierStatusRecorder_hijackNotSupported(t *testing.T) {
+	recorder := &outlierStatusRecorder{ResponseWriter: httptest.NewRecorder()}
+
+	_, _, err := recorder.Hijack()
+	assert.Error(t, err)
+}
@@ -32,6 +32,9 @@ const (
 	ddLastConfigReloadSuccessName = "config.reload.lastSuccessTimestamp"
 	ddOpenConnsName               = "open.connections"
 
+	ddMiddlewareQueueDepthName = "middleware.queue.depth"
+	ddMiddlewareQueueWaitName  = "middleware.queue.wait"
+
 	ddTLSCertsNotAfterTimestampName = "tls.certs.notAfterTimestamp"
 
 	ddEntryPointReqsName        = "entrypoint.request.total"
@@ -46,13 +49,18 @@ const (
 	ddRouterReqsBytesName    = "router.requests.bytes.total"
 	ddRouterRespsBytesName   = "router.responses.bytes.total"
 
-	ddServiceReqsName         = "service.request.total"
-	ddServiceReqsTLSName      = "service.request.tls.total"
-	ddServiceReqsDurationName = "service.request.duration"
-	ddServiceRetriesName      = "service.retries.total"
-	ddServiceServerUpName     = "service.server.up"
-	ddServiceReqsBytesName    = "service.requests.bytes.total"
-	ddServiceRespsBytesName   = "service.responses.bytes.total"
+	ddServiceReqsName              = "service.request.total"
+	ddServiceReqsTLSName           = "service.request.tls.total"
+	ddServiceReqsDurationName      = "service.request.duration"
+	ddServiceRetriesName           = "service.retries.total"
+	ddServiceServerUpName          = "service.server.up"
+	ddServiceServerCostName        = "service.server.cost"
+	ddServiceEjectionsName         = "service.server.ejections.total"
+	ddServiceHedgesName            = "service.hedges.total"
+	ddServiceHedgeWinsName         = "service.hedges.wins.total"
+	ddServiceMirrorComparisonsName = "service.mirror.comparisons.total"
+	ddServiceReqsBytesName         = "service.requests.bytes.total"
+	ddServiceRespsBytesName        = "service.responses.bytes.total"
 )
 
 // RegisterDatadog registers the metrics pusher if this didn't happen yet and creates a datadog Registry instance.
@@ -75,7 +83,9 @@ func RegisterDatadog(ctx context.Context, config *types.Datadog) Registry {
 		lastConfigReloadSuccessGauge:   datadogClient.NewGauge(ddLastConfigReloadSuccessName),
 		openConnectionsGauge:           datadogClient.NewGauge(ddOpenConnsName),
 		tlsCertsNotAfterTimestampGauge: datadogClient.NewGauge(ddTLSCertsNotAfterTimestampName),
+		middlewareQueueDepthGauge:      datadogClient.NewGauge(ddMiddlewareQueueDepthName),
 	}
+	registry.middlewareQueueWaitHistogram, _ = NewHistogramWithScale(datadogClient.NewHistogram(ddMiddlewareQueueWaitName, 1.0), time.Second)
 
 	if config.AddEntryPointsLabels {
 		registry.epEnabled = config.AddEntryPointsLabels
@@ -102,6 +112,11 @@ func RegisterDatadog(ctx context.Context, config *types.Datadog) Registry {
 		registry.serviceReqDurationHistogram, _ = NewHistogramWithScale(datadogClient.NewHistogram(ddServiceReqsDurationName, 1.0), time.Second)
 		registry.serviceRetriesCounter = datadogClient.NewCounter(ddServiceRetriesName, 1.0)
 		registry.serviceServerUpGauge = datadogClient.NewGauge(ddServiceServerUpName)
+		registry.serviceServerCostGauge = datadogClient.NewGauge(ddServiceServerCostName)
+		registry.serviceServerEjectionsCounter = datadogClient.NewCounter(ddServiceEjectionsName, 1.0)
+		registry.serviceHedgesCounter = datadogClient.NewCounter(ddServiceHedgesName, 1.0)
+		registry.serviceHedgeWinsCounter = datadogClient.NewCounter(ddServiceHedgeWinsName, 1.0)
+		registry.serviceMirrorComparisonsCounter = datadogClient.NewCounter(ddServiceMirrorComparisonsName, 1.0)
 		registry.serviceReqsBytesCounter = datadogClient.NewCounter(ddServiceReqsBytesName, 1.0)
 		registry.serviceRespsBytesCounter = datadogClient.NewCounter(ddServiceRespsBytesName, 1.0)
 	}
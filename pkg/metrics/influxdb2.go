@@ -28,6 +28,9 @@ const (
 	influxDBLastConfigReloadSuccessName = "traefik.config.reload.lastSuccessTimestamp"
 	influxDBOpenConnsName               = "traefik.open.connections"
 
+	influxDBMiddlewareQueueDepthName = "traefik.middleware.queue.depth"
+	influxDBMiddlewareQueueWaitName  = "traefik.middleware.queue.wait"
+
 	influxDBTLSCertsNotAfterTimestampName = "traefik.tls.certs.notAfterTimestamp"
 
 	influxDBEntryPointReqsName        = "traefik.entrypoint.requests.total"
@@ -42,13 +45,18 @@ const (
 	influxDBRouterReqsBytesName    = "traefik.router.requests.bytes.total"
 	influxDBRouterRespsBytesName   = "traefik.router.responses.bytes.total"
 
-	influxDBServiceReqsName         = "traefik.service.requests.total"
-	influxDBServiceReqsTLSName      = "traefik.service.requests.tls.total"
-	influxDBServiceReqsDurationName = "traefik.service.request.duration"
-	influxDBServiceRetriesTotalName = "traefik.service.retries.total"
-	influxDBServiceServerUpName     = "traefik.service.server.up"
-	influxDBServiceReqsBytesName    = "traefik.service.requests.bytes.total"
-	influxDBServiceRespsBytesName   = "traefik.service.responses.bytes.total"
+	influxDBServiceReqsName              = "traefik.service.requests.total"
+	influxDBServiceReqsTLSName           = "traefik.service.requests.tls.total"
+	influxDBServiceReqsDurationName      = "traefik.service.request.duration"
+	influxDBServiceRetriesTotalName      = "traefik.service.retries.total"
+	influxDBServiceServerUpName          = "traefik.service.server.up"
+	influxDBServiceServerCostName        = "traefik.service.server.cost"
+	influxDBServiceEjectionsName         = "traefik.service.server.ejections.total"
+	influxDBServiceHedgesName            = "traefik.service.hedges.total"
+	influxDBServiceHedgeWinsName         = "traefik.service.hedges.wins.total"
+	influxDBServiceMirrorComparisonsName = "traefik.service.mirror.comparisons.total"
+	influxDBServiceReqsBytesName         = "traefik.service.requests.bytes.total"
+	influxDBServiceRespsBytesName        = "traefik.service.responses.bytes.total"
 )
 
 // RegisterInfluxDB2 creates metrics exporter for InfluxDB2.
@@ -83,7 +91,9 @@ func RegisterInfluxDB2(ctx context.Context, config *types.InfluxDB2) Registry {
 		lastConfigReloadSuccessGauge:   influxDB2Store.NewGauge(influxDBLastConfigReloadSuccessName),
 		openConnectionsGauge:           influxDB2Store.NewGauge(influxDBOpenConnsName),
 		tlsCertsNotAfterTimestampGauge: influxDB2Store.NewGauge(influxDBTLSCertsNotAfterTimestampName),
+		middlewareQueueDepthGauge:      influxDB2Store.NewGauge(influxDBMiddlewareQueueDepthName),
 	}
+	registry.middlewareQueueWaitHistogram, _ = NewHistogramWithScale(influxDB2Store.NewHistogram(influxDBMiddlewareQueueWaitName), time.Second)
 
 	if config.AddEntryPointsLabels {
 		registry.epEnabled = config.AddEntryPointsLabels
@@ -110,6 +120,11 @@ func RegisterInfluxDB2(ctx context.Context, config *types.InfluxDB2) Registry {
 		registry.serviceReqDurationHistogram, _ = NewHistogramWithScale(influxDB2Store.NewHistogram(influxDBServiceReqsDurationName), time.Second)
 		registry.serviceRetriesCounter = influxDB2Store.NewCounter(influxDBServiceRetriesTotalName)
 		registry.serviceServerUpGauge = influxDB2Store.NewGauge(influxDBServiceServerUpName)
+		registry.serviceServerCostGauge = influxDB2Store.NewGauge(influxDBServiceServerCostName)
+		registry.serviceServerEjectionsCounter = influxDB2Store.NewCounter(influxDBServiceEjectionsName)
+		registry.serviceHedgesCounter = influxDB2Store.NewCounter(influxDBServiceHedgesName)
+		registry.serviceHedgeWinsCounter = influxDB2Store.NewCounter(influxDBServiceHedgeWinsName)
+		registry.serviceMirrorComparisonsCounter = influxDB2Store.NewCounter(influxDBServiceMirrorComparisonsName)
 		registry.serviceReqsBytesCounter = influxDB2Store.NewCounter(influxDBServiceReqsBytesName)
 		registry.serviceRespsBytesCounter = influxDB2Store.NewCounter(influxDBServiceRespsBytesName)
 	}
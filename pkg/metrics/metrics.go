@@ -52,8 +52,18 @@ type Registry interface {
 	ServiceReqDurationHistogram() ScalableHistogram
 	ServiceRetriesCounter() metrics.Counter
 	ServiceServerUpGauge() metrics.Gauge
+	ServiceServerCostGauge() metrics.Gauge
+	ServiceServerEjectionsCounter() metrics.Counter
+	ServiceHedgesCounter() metrics.Counter
+	ServiceHedgeWinsCounter() metrics.Counter
+	ServiceMirrorComparisonsCounter() metrics.Counter
 	ServiceReqsBytesCounter() metrics.Counter
 	ServiceRespsBytesCounter() metrics.Counter
+
+	// middleware metrics
+
+	MiddlewareQueueDepthGauge() metrics.Gauge
+	MiddlewareQueueWaitHistogram() ScalableHistogram
 }
 
 // NewVoidRegistry is a noop implementation of metrics.Registry.
@@ -85,8 +95,15 @@ func NewMultiRegistry(registries []Registry) Registry {
 	var serviceReqDurationHistogram []ScalableHistogram
 	var serviceRetriesCounter []metrics.Counter
 	var serviceServerUpGauge []metrics.Gauge
+	var serviceServerCostGauge []metrics.Gauge
+	var serviceServerEjectionsCounter []metrics.Counter
+	var serviceHedgesCounter []metrics.Counter
+	var serviceHedgeWinsCounter []metrics.Counter
+	var serviceMirrorComparisonsCounter []metrics.Counter
 	var serviceReqsBytesCounter []metrics.Counter
 	var serviceRespsBytesCounter []metrics.Counter
+	var middlewareQueueDepthGauge []metrics.Gauge
+	var middlewareQueueWaitHistogram []ScalableHistogram
 
 	for _, r := range registries {
 		if r.ConfigReloadsCounter() != nil {
@@ -146,67 +163,102 @@ func NewMultiRegistry(registries []Registry) Registry {
 		if r.ServiceServerUpGauge() != nil {
 			serviceServerUpGauge = append(serviceServerUpGauge, r.ServiceServerUpGauge())
 		}
+		if r.ServiceServerCostGauge() != nil {
+			serviceServerCostGauge = append(serviceServerCostGauge, r.ServiceServerCostGauge())
+		}
+		if r.ServiceServerEjectionsCounter() != nil {
+			serviceServerEjectionsCounter = append(serviceServerEjectionsCounter, r.ServiceServerEjectionsCounter())
+		}
+		if r.ServiceHedgesCounter() != nil {
+			serviceHedgesCounter = append(serviceHedgesCounter, r.ServiceHedgesCounter())
+		}
+		if r.ServiceHedgeWinsCounter() != nil {
+			serviceHedgeWinsCounter = append(serviceHedgeWinsCounter, r.ServiceHedgeWinsCounter())
+		}
+		if r.ServiceMirrorComparisonsCounter() != nil {
+			serviceMirrorComparisonsCounter = append(serviceMirrorComparisonsCounter, r.ServiceMirrorComparisonsCounter())
+		}
 		if r.ServiceReqsBytesCounter() != nil {
 			serviceReqsBytesCounter = append(serviceReqsBytesCounter, r.ServiceReqsBytesCounter())
 		}
 		if r.ServiceRespsBytesCounter() != nil {
 			serviceRespsBytesCounter = append(serviceRespsBytesCounter, r.ServiceRespsBytesCounter())
 		}
+		if r.MiddlewareQueueDepthGauge() != nil {
+			middlewareQueueDepthGauge = append(middlewareQueueDepthGauge, r.MiddlewareQueueDepthGauge())
+		}
+		if r.MiddlewareQueueWaitHistogram() != nil {
+			middlewareQueueWaitHistogram = append(middlewareQueueWaitHistogram, r.MiddlewareQueueWaitHistogram())
+		}
 	}
 
 	return &standardRegistry{
-		epEnabled:                      len(entryPointReqsCounter) > 0 || len(entryPointReqDurationHistogram) > 0,
-		svcEnabled:                     len(serviceReqsCounter) > 0 || len(serviceReqDurationHistogram) > 0 || len(serviceRetriesCounter) > 0 || len(serviceServerUpGauge) > 0,
-		routerEnabled:                  len(routerReqsCounter) > 0 || len(routerReqDurationHistogram) > 0,
-		configReloadsCounter:           multi.NewCounter(configReloadsCounter...),
-		lastConfigReloadSuccessGauge:   multi.NewGauge(lastConfigReloadSuccessGauge...),
-		openConnectionsGauge:           multi.NewGauge(openConnectionsGauge...),
-		tlsCertsNotAfterTimestampGauge: multi.NewGauge(tlsCertsNotAfterTimestampGauge...),
-		entryPointReqsCounter:          NewMultiCounterWithHeaders(entryPointReqsCounter...),
-		entryPointReqsTLSCounter:       multi.NewCounter(entryPointReqsTLSCounter...),
-		entryPointReqDurationHistogram: MultiHistogram(entryPointReqDurationHistogram),
-		entryPointReqsBytesCounter:     multi.NewCounter(entryPointReqsBytesCounter...),
-		entryPointRespsBytesCounter:    multi.NewCounter(entryPointRespsBytesCounter...),
-		routerReqsCounter:              NewMultiCounterWithHeaders(routerReqsCounter...),
-		routerReqsTLSCounter:           multi.NewCounter(routerReqsTLSCounter...),
-		routerReqDurationHistogram:     MultiHistogram(routerReqDurationHistogram),
-		routerReqsBytesCounter:         multi.NewCounter(routerReqsBytesCounter...),
-		routerRespsBytesCounter:        multi.NewCounter(routerRespsBytesCounter...),
-		serviceReqsCounter:             NewMultiCounterWithHeaders(serviceReqsCounter...),
-		serviceReqsTLSCounter:          multi.NewCounter(serviceReqsTLSCounter...),
-		serviceReqDurationHistogram:    MultiHistogram(serviceReqDurationHistogram),
-		serviceRetriesCounter:          multi.NewCounter(serviceRetriesCounter...),
-		serviceServerUpGauge:           multi.NewGauge(serviceServerUpGauge...),
-		serviceReqsBytesCounter:        multi.NewCounter(serviceReqsBytesCounter...),
-		serviceRespsBytesCounter:       multi.NewCounter(serviceRespsBytesCounter...),
+		epEnabled:                       len(entryPointReqsCounter) > 0 || len(entryPointReqDurationHistogram) > 0,
+		svcEnabled:                      len(serviceReqsCounter) > 0 || len(serviceReqDurationHistogram) > 0 || len(serviceRetriesCounter) > 0 || len(serviceServerUpGauge) > 0,
+		routerEnabled:                   len(routerReqsCounter) > 0 || len(routerReqDurationHistogram) > 0,
+		configReloadsCounter:            multi.NewCounter(configReloadsCounter...),
+		lastConfigReloadSuccessGauge:    multi.NewGauge(lastConfigReloadSuccessGauge...),
+		openConnectionsGauge:            multi.NewGauge(openConnectionsGauge...),
+		tlsCertsNotAfterTimestampGauge:  multi.NewGauge(tlsCertsNotAfterTimestampGauge...),
+		entryPointReqsCounter:           NewMultiCounterWithHeaders(entryPointReqsCounter...),
+		entryPointReqsTLSCounter:        multi.NewCounter(entryPointReqsTLSCounter...),
+		entryPointReqDurationHistogram:  MultiHistogram(entryPointReqDurationHistogram),
+		entryPointReqsBytesCounter:      multi.NewCounter(entryPointReqsBytesCounter...),
+		entryPointRespsBytesCounter:     multi.NewCounter(entryPointRespsBytesCounter...),
+		routerReqsCounter:               NewMultiCounterWithHeaders(routerReqsCounter...),
+		routerReqsTLSCounter:            multi.NewCounter(routerReqsTLSCounter...),
+		routerReqDurationHistogram:      MultiHistogram(routerReqDurationHistogram),
+		routerReqsBytesCounter:          multi.NewCounter(routerReqsBytesCounter...),
+		routerRespsBytesCounter:         multi.NewCounter(routerRespsBytesCounter...),
+		serviceReqsCounter:              NewMultiCounterWithHeaders(serviceReqsCounter...),
+		serviceReqsTLSCounter:           multi.NewCounter(serviceReqsTLSCounter...),
+		serviceReqDurationHistogram:     MultiHistogram(serviceReqDurationHistogram),
+		serviceRetriesCounter:           multi.NewCounter(serviceRetriesCounter...),
+		serviceServerUpGauge:            multi.NewGauge(serviceServerUpGauge...),
+		serviceServerCostGauge:          multi.NewGauge(serviceServerCostGauge...),
+		serviceServerEjectionsCounter:   multi.NewCounter(serviceServerEjectionsCounter...),
+		serviceHedgesCounter:            multi.NewCounter(serviceHedgesCounter...),
+		serviceHedgeWinsCounter:         multi.NewCounter(serviceHedgeWinsCounter...),
+		serviceMirrorComparisonsCounter: multi.NewCounter(serviceMirrorComparisonsCounter...),
+		serviceReqsBytesCounter:         multi.NewCounter(serviceReqsBytesCounter...),
+		serviceRespsBytesCounter:        multi.NewCounter(serviceRespsBytesCounter...),
+		middlewareQueueDepthGauge:       multi.NewGauge(middlewareQueueDepthGauge...),
+		middlewareQueueWaitHistogram:    MultiHistogram(middlewareQueueWaitHistogram),
 	}
 }
 
 type standardRegistry struct {
-	epEnabled                      bool
-	routerEnabled                  bool
-	svcEnabled                     bool
-	configReloadsCounter           metrics.Counter
-	lastConfigReloadSuccessGauge   metrics.Gauge
-	openConnectionsGauge           metrics.Gauge
-	tlsCertsNotAfterTimestampGauge metrics.Gauge
-	entryPointReqsCounter          CounterWithHeaders
-	entryPointReqsTLSCounter       metrics.Counter
-	entryPointReqDurationHistogram ScalableHistogram
-	entryPointReqsBytesCounter     metrics.Counter
-	entryPointRespsBytesCounter    metrics.Counter
-	routerReqsCounter              CounterWithHeaders
-	routerReqsTLSCounter           metrics.Counter
-	routerReqDurationHistogram     ScalableHistogram
-	routerReqsBytesCounter         metrics.Counter
-	routerRespsBytesCounter        metrics.Counter
-	serviceReqsCounter             CounterWithHeaders
-	serviceReqsTLSCounter          metrics.Counter
-	serviceReqDurationHistogram    ScalableHistogram
-	serviceRetriesCounter          metrics.Counter
-	serviceServerUpGauge           metrics.Gauge
-	serviceReqsBytesCounter        metrics.Counter
-	serviceRespsBytesCounter       metrics.Counter
+	epEnabled                       bool
+	routerEnabled                   bool
+	svcEnabled                      bool
+	configReloadsCounter            metrics.Counter
+	lastConfigReloadSuccessGauge    metrics.Gauge
+	openConnectionsGauge            metrics.Gauge
+	tlsCertsNotAfterTimestampGauge  metrics.Gauge
+	entryPointReqsCounter           CounterWithHeaders
+	entryPointReqsTLSCounter        metrics.Counter
+	entryPointReqDurationHistogram  ScalableHistogram
+	entryPointReqsBytesCounter      metrics.Counter
+	entryPointRespsBytesCounter     metrics.Counter
+	routerReqsCounter               CounterWithHeaders
+	routerReqsTLSCounter            metrics.Counter
+	routerReqDurationHistogram      ScalableHistogram
+	routerReqsBytesCounter          metrics.Counter
+	routerRespsBytesCounter         metrics.Counter
+	serviceReqsCounter              CounterWithHeaders
+	serviceReqsTLSCounter           metrics.Counter
+	serviceReqDurationHistogram     ScalableHistogram
+	serviceRetriesCounter           metrics.Counter
+	serviceServerUpGauge            metrics.Gauge
+	serviceServerCostGauge          metrics.Gauge
+	serviceServerEjectionsCounter   metrics.Counter
+	serviceHedgesCounter            metrics.Counter
+	serviceHedgeWinsCounter         metrics.Counter
+	serviceMirrorComparisonsCounter metrics.Counter
+	serviceReqsBytesCounter         metrics.Counter
+	serviceRespsBytesCounter        metrics.Counter
+	middlewareQueueDepthGauge       metrics.Gauge
+	middlewareQueueWaitHistogram    ScalableHistogram
 }
 
 func (r *standardRegistry) IsEpEnabled() bool {
@@ -297,6 +349,26 @@ func (r *standardRegistry) ServiceServerUpGauge() metrics.Gauge {
 	return r.serviceServerUpGauge
 }
 
+func (r *standardRegistry) ServiceServerCostGauge() metrics.Gauge {
+	return r.serviceServerCostGauge
+}
+
+func (r *standardRegistry) ServiceServerEjectionsCounter() metrics.Counter {
+	return r.serviceServerEjectionsCounter
+}
+
+func (r *standardRegistry) ServiceHedgesCounter() metrics.Counter {
+	return r.serviceHedgesCounter
+}
+
+func (r *standardRegistry) ServiceHedgeWinsCounter() metrics.Counter {
+	return r.serviceHedgeWinsCounter
+}
+
+func (r *standardRegistry) ServiceMirrorComparisonsCounter() metrics.Counter {
+	return r.serviceMirrorComparisonsCounter
+}
+
 func (r *standardRegistry) ServiceReqsBytesCounter() metrics.Counter {
 	return r.serviceReqsBytesCounter
 }
@@ -305,6 +377,14 @@ func (r *standardRegistry) ServiceRespsBytesCounter() metrics.Counter {
 	return r.serviceRespsBytesCounter
 }
 
+func (r *standardRegistry) MiddlewareQueueDepthGauge() metrics.Gauge {
+	return r.middlewareQueueDepthGauge
+}
+
+func (r *standardRegistry) MiddlewareQueueWaitHistogram() ScalableHistogram {
+	return r.middlewareQueueWaitHistogram
+}
+
 // ScalableHistogram is a Histogram with a predefined time unit,
 // used when producing observations without explicitly setting the observed value.
 type ScalableHistogram interface {
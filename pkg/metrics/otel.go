@@ -123,7 +123,12 @@ func RegisterOpenTelemetry(ctx context.Context, config *types.OTLP) Registry {
 		lastConfigReloadSuccessGauge:   newOTLPGaugeFrom(meter, configLastReloadSuccessName, "Last config reload success", "ms"),
 		openConnectionsGauge:           newOTLPGaugeFrom(meter, openConnectionsName, "How many open connections exist, by entryPoint and protocol", "1"),
 		tlsCertsNotAfterTimestampGauge: newOTLPGaugeFrom(meter, tlsCertsNotAfterTimestampName, "Certificate expiration timestamp", "ms"),
+		middlewareQueueDepthGauge: newOTLPGaugeFrom(meter, middlewareQueueDepthName,
+			"How many requests are waiting in the queue of a middleware.", "1"),
 	}
+	reg.middlewareQueueWaitHistogram, _ = NewHistogramWithScale(newOTLPHistogramFrom(meter, middlewareQueueWaitName,
+		"How long the requests waited in the queue of a middleware before being admitted.",
+		"s"), time.Second)
 
 	if config.AddEntryPointsLabels {
 		reg.entryPointReqsCounter = NewCounterWithNoopHeaders(newOTLPCounterFrom(meter, entryPointReqsTotalName,
@@ -166,6 +171,17 @@ func RegisterOpenTelemetry(ctx context.Context, config *types.OTLP) Registry {
 		reg.serviceServerUpGauge = newOTLPGaugeFrom(meter, serviceServerUpName,
 			"service server is up, described by gauge value of 0 or 1.",
 			"1")
+		reg.serviceServerCostGauge = newOTLPGaugeFrom(meter, serviceServerCostName,
+			"Cost of a service server for the peakewma load-balancer, its peak EWMA latency weighted by its in-flight requests.",
+			"s")
+		reg.serviceServerEjectionsCounter = newOTLPCounterFrom(meter, serviceServerEjectionsName,
+			"How many times a service server was ejected by the outlier detection.")
+		reg.serviceHedgesCounter = newOTLPCounterFrom(meter, serviceHedgesTotalName,
+			"How many hedged requests were sent to a service.")
+		reg.serviceHedgeWinsCounter = newOTLPCounterFrom(meter, serviceHedgeWinsTotalName,
+			"How many hedged requests answered before the original request of a service.")
+		reg.serviceMirrorComparisonsCounter = newOTLPCounterFrom(meter, serviceMirrorComparisonsName,
+			"How many mirror responses were compared with the main service responses, partitioned by mirror and result.")
 		reg.serviceReqsBytesCounter = newOTLPCounterFrom(meter, serviceReqsBytesTotalName,
 			"The total size of requests in bytes received by a service, partitioned by status code, protocol, and method.")
 		reg.serviceRespsBytesCounter = newOTLPCounterFrom(meter, serviceRespsBytesTotalName,
@@ -47,14 +47,24 @@ const (
 	routerRespsBytesTotalName = metricRouterPrefix + "responses_bytes_total"
 
 	// service level.
-	metricServicePrefix        = MetricNamePrefix + "service_"
-	serviceReqsTotalName       = metricServicePrefix + "requests_total"
-	serviceReqsTLSTotalName    = metricServicePrefix + "requests_tls_total"
-	serviceReqDurationName     = metricServicePrefix + "request_duration_seconds"
-	serviceRetriesTotalName    = metricServicePrefix + "retries_total"
-	serviceServerUpName        = metricServicePrefix + "server_up"
-	serviceReqsBytesTotalName  = metricServicePrefix + "requests_bytes_total"
-	serviceRespsBytesTotalName = metricServicePrefix + "responses_bytes_total"
+	metricServicePrefix          = MetricNamePrefix + "service_"
+	serviceReqsTotalName         = metricServicePrefix + "requests_total"
+	serviceReqsTLSTotalName      = metricServicePrefix + "requests_tls_total"
+	serviceReqDurationName       = metricServicePrefix + "request_duration_seconds"
+	serviceRetriesTotalName      = metricServicePrefix + "retries_total"
+	serviceServerUpName          = metricServicePrefix + "server_up"
+	serviceServerCostName        = metricServicePrefix + "server_cost_seconds"
+	serviceServerEjectionsName   = metricServicePrefix + "server_ejections_total"
+	serviceHedgesTotalName       = metricServicePrefix + "hedges_total"
+	serviceHedgeWinsTotalName    = metricServicePrefix + "hedge_wins_total"
+	serviceMirrorComparisonsName = metricServicePrefix + "mirror_comparisons_total"
+	serviceReqsBytesTotalName    = metricServicePrefix + "requests_bytes_total"
+	serviceRespsBytesTotalName   = metricServicePrefix + "responses_bytes_total"
+
+	// middleware level.
+	metricMiddlewarePrefix   = MetricNamePrefix + "middleware_"
+	middlewareQueueDepthName = metricMiddlewarePrefix + "queue_depth"
+	middlewareQueueWaitName  = metricMiddlewarePrefix + "queue_wait_seconds"
 )
 
 // promState holds all metric state internally and acts as the only Collector we register for Prometheus.
@@ -126,12 +136,23 @@ func initStandardRegistry(config *types.Prometheus) Registry {
 		Name: openConnectionsName,
 		Help: "How many open connections exist, by entryPoint and protocol",
 	}, []string{"entrypoint", "protocol"})
+	middlewareQueueDepth := newGaugeFrom(stdprometheus.GaugeOpts{
+		Name: middlewareQueueDepthName,
+		Help: "How many requests are waiting in the queue of a middleware.",
+	}, []string{"middleware"})
+	middlewareQueueWait := newHistogramFrom(stdprometheus.HistogramOpts{
+		Name:    middlewareQueueWaitName,
+		Help:    "How long the requests waited in the queue of a middleware before being admitted.",
+		Buckets: buckets,
+	}, []string{"middleware"})
 
 	promState.vectors = []vector{
 		configReloads.cv,
 		lastConfigReloadSuccess.gv,
 		tlsCertsNotAfterTimestamp.gv,
 		openConnections.gv,
+		middlewareQueueDepth.gv,
+		middlewareQueueWait.hv,
 	}
 
 	reg := &standardRegistry{
@@ -142,7 +163,9 @@ func initStandardRegistry(config *types.Prometheus) Registry {
 		lastConfigReloadSuccessGauge:   lastConfigReloadSuccess,
 		tlsCertsNotAfterTimestampGauge: tlsCertsNotAfterTimestamp,
 		openConnectionsGauge:           openConnections,
+		middlewareQueueDepthGauge:      middlewareQueueDepth,
 	}
+	reg.middlewareQueueWaitHistogram, _ = NewHistogramWithScale(middlewareQueueWait, time.Second)
 
 	if config.AddEntryPointsLabels {
 		entryPointReqs := newCounterWithHeadersFrom(stdprometheus.CounterOpts{
@@ -241,6 +264,26 @@ func initStandardRegistry(config *types.Prometheus) Registry {
 			Name: serviceServerUpName,
 			Help: "service server is up, described by gauge value of 0 or 1.",
 		}, []string{"service", "url"})
+		serviceServerCost := newGaugeFrom(stdprometheus.GaugeOpts{
+			Name: serviceServerCostName,
+			Help: "Cost of a service server for the peakewma load-balancer, its peak EWMA latency weighted by its in-flight requests.",
+		}, []string{"service", "url"})
+		serviceServerEjections := newCounterFrom(stdprometheus.CounterOpts{
+			Name: serviceServerEjectionsName,
+			Help: "How many times a service server was ejected by the outlier detection.",
+		}, []string{"service", "url"})
+		serviceHedges := newCounterFrom(stdprometheus.CounterOpts{
+			Name: serviceHedgesTotalName,
+			Help: "How many hedged requests were sent to a service.",
+		}, []string{"service"})
+		serviceHedgeWins := newCounterFrom(stdprometheus.CounterOpts{
+			Name: serviceHedgeWinsTotalName,
+			Help: "How many hedged requests answered before the original request of a service.",
+		}, []string{"service"})
+		serviceMirrorComparisons := newCounterFrom(stdprometheus.CounterOpts{
+			Name: serviceMirrorComparisonsName,
+			Help: "How many mirror responses were compared with the main service responses, partitioned by mirror and result.",
+		}, []string{"service", "mirror", "result"})
 		serviceReqsBytesTotal := newCounterFrom(stdprometheus.CounterOpts{
 			Name: serviceReqsBytesTotalName,
 			Help: "The total size of requests in bytes received by a service, partitioned by status code, protocol, and method.",
@@ -256,6 +299,11 @@ func initStandardRegistry(config *types.Prometheus) Registry {
 			serviceReqDurations.hv,
 			serviceRetries.cv,
 			serviceServerUp.gv,
+			serviceServerCost.gv,
+			serviceServerEjections.cv,
+			serviceHedges.cv,
+			serviceHedgeWins.cv,
+			serviceMirrorComparisons.cv,
 			serviceReqsBytesTotal.cv,
 			serviceRespsBytesTotal.cv,
 		)
@@ -265,6 +313,11 @@ func initStandardRegistry(config *types.Prometheus) Registry {
 		reg.serviceReqDurationHistogram, _ = NewHistogramWithScale(serviceReqDurations, time.Second)
 		reg.serviceRetriesCounter = serviceRetries
 		reg.serviceServerUpGauge = serviceServerUp
+		reg.serviceServerCostGauge = serviceServerCost
+		reg.serviceServerEjectionsCounter = serviceServerEjections
+		reg.serviceHedgesCounter = serviceHedges
+		reg.serviceHedgeWinsCounter = serviceHedgeWins
+		reg.serviceMirrorComparisonsCounter = serviceMirrorComparisons
 		reg.serviceReqsBytesCounter = serviceReqsBytesTotal
 		reg.serviceRespsBytesCounter = serviceRespsBytesTotal
 	}
@@ -108,6 +108,14 @@ func TestPrometheus(t *testing.T) {
 		OpenConnectionsGauge().
 		With("entrypoint", "test", "protocol", "TCP").
 		Set(1)
+	prometheusRegistry.
+		MiddlewareQueueDepthGauge().
+		With("middleware", "middleware1").
+		Set(1)
+	prometheusRegistry.
+		MiddlewareQueueWaitHistogram().
+		With("middleware", "middleware1").
+		Observe(1)
 
 	prometheusRegistry.
 		TLSCertsNotAfterTimestampGauge().
@@ -172,6 +180,26 @@ func TestPrometheus(t *testing.T) {
 		ServiceServerUpGauge().
 		With("service", "service1", "url", "http://127.0.0.10:80").
 		Set(1)
+	prometheusRegistry.
+		ServiceServerCostGauge().
+		With("service", "service1", "url", "http://127.0.0.10:80").
+		Set(2)
+	prometheusRegistry.
+		ServiceServerEjectionsCounter().
+		With("service", "service1", "url", "http://127.0.0.10:80").
+		Add(1)
+	prometheusRegistry.
+		ServiceHedgesCounter().
+		With("service", "service1").
+		Add(1)
+	prometheusRegistry.
+		ServiceHedgeWinsCounter().
+		With("service", "service1").
+		Add(1)
+	prometheusRegistry.
+		ServiceMirrorComparisonsCounter().
+		With("service", "service1", "mirror", "mirror1", "result", "match").
+		Add(1)
 	prometheusRegistry.
 		ServiceRespsBytesCounter().
 		With("service", "service1", "code", strconv.Itoa(http.StatusOK), "method", http.MethodGet, "protocol", "http").
@@ -206,6 +234,20 @@ func TestPrometheus(t *testing.T) {
 			},
 			assert: buildGaugeAssert(t, openConnectionsName, 1),
 		},
+		{
+			name: middlewareQueueDepthName,
+			labels: map[string]string{
+				"middleware": "middleware1",
+			},
+			assert: buildGaugeAssert(t, middlewareQueueDepthName, 1),
+		},
+		{
+			name: middlewareQueueWaitName,
+			labels: map[string]string{
+				"middleware": "middleware1",
+			},
+			assert: buildHistogramAssert(t, middlewareQueueWaitName, 1),
+		},
 		{
 			name: tlsCertsNotAfterTimestampName,
 			labels: map[string]string{
@@ -356,6 +398,45 @@ func TestPrometheus(t *testing.T) {
 			},
 			assert: buildGaugeAssert(t, serviceServerUpName, 1),
 		},
+		{
+			name: serviceServerCostName,
+			labels: map[string]string{
+				"service": "service1",
+				"url":     "http://127.0.0.10:80",
+			},
+			assert: buildGaugeAssert(t, serviceServerCostName, 2),
+		},
+		{
+			name: serviceServerEjectionsName,
+			labels: map[string]string{
+				"service": "service1",
+				"url":     "http://127.0.0.10:80",
+			},
+			assert: buildCounterAssert(t, serviceServerEjectionsName, 1),
+		},
+		{
+			name: serviceHedgesTotalName,
+			labels: map[string]string{
+				"service": "service1",
+			},
+			assert: buildCounterAssert(t, serviceHedgesTotalName, 1),
+		},
+		{
+			name: serviceHedgeWinsTotalName,
+			labels: map[string]string{
+				"service": "service1",
+			},
+			assert: buildCounterAssert(t, serviceHedgeWinsTotalName, 1),
+		},
+		{
+			name: serviceMirrorComparisonsName,
+			labels: map[string]string{
+				"service": "service1",
+				"mirror":  "mirror1",
+				"result":  "match",
+			},
+			assert: buildCounterAssert(t, serviceMirrorComparisonsName, 1),
+		},
 		{
 			name: serviceReqsBytesTotalName,
 			labels: map[string]string{
@@ -21,6 +21,9 @@ const (
 	statsdLastConfigReloadSuccessName = "config.reload.lastSuccessTimestamp"
 	statsdOpenConnectionsName         = "open.connections"
 
+	statsdMiddlewareQueueDepthName = "middleware.queue.depth"
+	statsdMiddlewareQueueWaitName  = "middleware.queue.wait"
+
 	statsdTLSCertsNotAfterTimestampName = "tls.certs.notAfterTimestamp"
 
 	statsdEntryPointReqsName        = "entrypoint.request.total"
@@ -35,13 +38,18 @@ const (
 	statsdRouterReqsBytesName    = "router.requests.bytes.total"
 	statsdRouterRespsBytesName   = "router.responses.bytes.total"
 
-	statsdServiceReqsName         = "service.request.total"
-	statsdServiceReqsTLSName      = "service.request.tls.total"
-	statsdServiceReqsDurationName = "service.request.duration"
-	statsdServiceRetriesTotalName = "service.retries.total"
-	statsdServiceServerUpName     = "service.server.up"
-	statsdServiceReqsBytesName    = "service.requests.bytes.total"
-	statsdServiceRespsBytesName   = "service.responses.bytes.total"
+	statsdServiceReqsName              = "service.request.total"
+	statsdServiceReqsTLSName           = "service.request.tls.total"
+	statsdServiceReqsDurationName      = "service.request.duration"
+	statsdServiceRetriesTotalName      = "service.retries.total"
+	statsdServiceServerUpName          = "service.server.up"
+	statsdServiceServerCostName        = "service.server.cost"
+	statsdServiceEjectionsName         = "service.server.ejections.total"
+	statsdServiceHedgesName            = "service.hedges.total"
+	statsdServiceHedgeWinsName         = "service.hedges.wins.total"
+	statsdServiceMirrorComparisonsName = "service.mirror.comparisons.total"
+	statsdServiceReqsBytesName         = "service.requests.bytes.total"
+	statsdServiceRespsBytesName        = "service.responses.bytes.total"
 )
 
 // RegisterStatsd registers the metrics pusher if this didn't happen yet and creates a statsd Registry instance.
@@ -62,7 +70,9 @@ func RegisterStatsd(ctx context.Context, config *types.Statsd) Registry {
 		lastConfigReloadSuccessGauge:   statsdClient.NewGauge(statsdLastConfigReloadSuccessName),
 		tlsCertsNotAfterTimestampGauge: statsdClient.NewGauge(statsdTLSCertsNotAfterTimestampName),
 		openConnectionsGauge:           statsdClient.NewGauge(statsdOpenConnectionsName),
+		middlewareQueueDepthGauge:      statsdClient.NewGauge(statsdMiddlewareQueueDepthName),
 	}
+	registry.middlewareQueueWaitHistogram, _ = NewHistogramWithScale(statsdClient.NewTiming(statsdMiddlewareQueueWaitName, 1.0), time.Millisecond)
 
 	if config.AddEntryPointsLabels {
 		registry.epEnabled = config.AddEntryPointsLabels
@@ -89,6 +99,11 @@ func RegisterStatsd(ctx context.Context, config *types.Statsd) Registry {
 		registry.serviceReqDurationHistogram, _ = NewHistogramWithScale(statsdClient.NewTiming(statsdServiceReqsDurationName, 1.0), time.Millisecond)
 		registry.serviceRetriesCounter = statsdClient.NewCounter(statsdServiceRetriesTotalName, 1.0)
 		registry.serviceServerUpGauge = statsdClient.NewGauge(statsdServiceServerUpName)
+		registry.serviceServerCostGauge = statsdClient.NewGauge(statsdServiceServerCostName)
+		registry.serviceServerEjectionsCounter = statsdClient.NewCounter(statsdServiceEjectionsName, 1.0)
+		registry.serviceHedgesCounter = statsdClient.NewCounter(statsdServiceHedgesName, 1.0)
+		registry.serviceHedgeWinsCounter = statsdClient.NewCounter(statsdServiceHedgeWinsName, 1.0)
+		registry.serviceMirrorComparisonsCounter = statsdClient.NewCounter(statsdServiceMirrorComparisonsName, 1.0)
 		registry.serviceReqsBytesCounter = statsdClient.NewCounter(statsdServiceReqsBytesName, 1.0)
 		registry.serviceRespsBytesCounter = statsdClient.NewCounter(statsdServiceRespsBytesName, 1.0)
 	}
@@ -3,11 +3,13 @@ package counter
 import "sync/atomic"
 
 var (
-	Total    = &atomic.Int64{}
-	Hits     = &atomic.Int64{}
-	Misses   = &atomic.Int64{}
-	Proxies  = &atomic.Int64{}
-	Errors   = &atomic.Int64{}
-	Panics   = &atomic.Int64{}
-	Duration = &atomic.Int64{} // UnixNano
+	Total     = &atomic.Int64{}
+	Hits      = &atomic.Int64{}
+	Misses    = &atomic.Int64{}
+	Proxies   = &atomic.Int64{}
+	Stale     = &atomic.Int64{} // Stale entries served instead of failed or shed backend fetches
+	ESIErrors = &atomic.Int64{} // ESI documents failed to assemble
+	Errors    = &atomic.Int64{}
+	Panics    = &atomic.Int64{}
+	Duration  = &atomic.Int64{} // UnixNano
 )
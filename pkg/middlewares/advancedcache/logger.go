@@ -5,6 +5,8 @@ import (
 	"github.com/rs/zerolog/log"
 	"github.com/traefik/traefik/v3/pkg/advancedcache/config"
 	"github.com/traefik/traefik/v3/pkg/advancedcache/prometheus/metrics"
+	"github.com/traefik/traefik/v3/pkg/advancedcache/repository"
+	"github.com/traefik/traefik/v3/pkg/advancedcache/shadow"
 	"github.com/traefik/traefik/v3/pkg/advancedcache/storage"
 	"github.com/traefik/traefik/v3/pkg/advancedcache/utils"
 	"github.com/traefik/traefik/v3/pkg/middlewares/advancedcache/counter"
@@ -14,18 +16,25 @@ import (
 )
 
 type MetricsLogger struct {
-	ctx     context.Context
-	cfg     *config.Cache
-	storage storage.Storage
-	metrics metrics.Meter
+	ctx      context.Context
+	cfg      *config.Cache
+	storage  storage.Storage
+	limiter  *repository.Limiter // nil if backend fetches are rate limited
+	verifier *shadow.Verifier
+	metrics  metrics.Meter
 }
 
-func NewMetricsLogger(ctx context.Context, cfg *config.Cache, storage storage.Storage, metrics metrics.Meter) *MetricsLogger {
+func NewMetricsLogger(
+	ctx context.Context, cfg *config.Cache, storage storage.Storage,
+	limiter *repository.Limiter, verifier *shadow.Verifier, metrics metrics.Meter,
+) *MetricsLogger {
 	return &MetricsLogger{
-		ctx:     ctx,
-		cfg:     cfg,
-		storage: storage,
-		metrics: metrics,
+		ctx:      ctx,
+		cfg:      cfg,
+		storage:  storage,
+		limiter:  limiter,
+		verifier: verifier,
+		metrics:  metrics,
 	}
 }
 
@@ -76,6 +85,12 @@ func (l *MetricsLogger) run() {
 				proxiedNumLoc := counter.Proxies.Load()
 				counter.Proxies.Store(0)
 
+				staleNumLoc := counter.Stale.Load()
+				counter.Stale.Store(0)
+
+				esiErrorsNumLoc := counter.ESIErrors.Load()
+				counter.ESIErrors.Store(0)
+
 				errorsNumLoc := counter.Errors.Load()
 				counter.Errors.Store(0)
 
@@ -98,8 +113,21 @@ func (l *MetricsLogger) run() {
 				l.metrics.SetErrors(uint64(errorsNumLoc))
 				l.metrics.SetPanics(uint64(panicsNumLoc))
 				l.metrics.SetProxiedNum(uint64(proxiedNumLoc))
+				l.metrics.SetStaleServed(uint64(staleNumLoc))
+				l.metrics.SetESIErrors(uint64(esiErrorsNumLoc))
 				l.metrics.SetRPS(float64(totalNumLoc))
 				l.metrics.SetAvgResponseTime(avgDuration)
+				depth, lag := l.storage.RefreshQueue()
+				l.metrics.SetRefreshQueue(uint64(depth), lag)
+				for _, stat := range l.storage.RuleStats() {
+					l.metrics.SetRuleStat(stat)
+				}
+				if l.limiter != nil {
+					l.metrics.SetLimiterStat(l.limiter.Stat())
+				}
+				for _, stat := range l.verifier.Stats() {
+					l.metrics.SetShadowStat(stat)
+				}
 
 				totalNum += totalNumLoc
 				hitsNum += hitsNumLoc
@@ -4,9 +4,11 @@ import (
 	"errors"
 	"fmt"
 	"github.com/traefik/traefik/v3/pkg/advancedcache/config"
+	"github.com/traefik/traefik/v3/pkg/advancedcache/esi"
 	"github.com/traefik/traefik/v3/pkg/advancedcache/header"
 	"github.com/traefik/traefik/v3/pkg/advancedcache/model"
 	"github.com/traefik/traefik/v3/pkg/advancedcache/repository"
+	"github.com/traefik/traefik/v3/pkg/advancedcache/shadow"
 	"github.com/traefik/traefik/v3/pkg/advancedcache/storage"
 	"github.com/traefik/traefik/v3/pkg/middlewares/advancedcache/counter"
 	"net/http"
@@ -17,6 +19,7 @@ import (
 var (
 	routeNotFoundError                  = errors.New("cache route not found")
 	upstreamBadStatusCodeError          = fmt.Errorf("upstream bad status code")
+	upstreamServerErrorStatusCodeError  = fmt.Errorf("upstream server error status code")
 	temporaryUnavailableStatusCodeError = fmt.Errorf("temporaty unavailable status code")
 )
 
@@ -37,18 +40,25 @@ func DisableCache() {
 }
 
 type CacheRoute struct {
-	cfg     *config.Cache
-	storage storage.Storage
-	backend repository.Backender
-	rules   map[string]*config.Rule
+	cfg       *config.Cache
+	storage   storage.Storage
+	backend   repository.Backender
+	verifier  *shadow.Verifier
+	assembler *esi.Assembler
+	rules     map[string]*config.Rule
 }
 
-func NewCacheRoutes(cfg *config.Cache, storage storage.Storage, backend repository.Backender) *CacheRoute {
+func NewCacheRoutes(
+	cfg *config.Cache, storage storage.Storage, backend repository.Backender,
+	verifier *shadow.Verifier, assembler *esi.Assembler,
+) *CacheRoute {
 	return &CacheRoute{
-		cfg:     cfg,
-		storage: storage,
-		backend: backend,
-		rules:   cfg.Cache.Rules,
+		cfg:       cfg,
+		storage:   storage,
+		backend:   backend,
+		verifier:  verifier,
+		assembler: assembler,
+		rules:     cfg.Cache.Rules,
 	}
 }
 
@@ -62,18 +72,35 @@ func (c *CacheRoute) ServeHTTP(w http.ResponseWriter, r *http.Request) error {
 
 	if cacheEntry, hit := c.storage.Get(reqEntry); hit {
 		counter.Hits.Add(1)
-		return c.writeResponse(w, cacheEntry)
+		c.verifier.Sample(cacheEntry)
+		return c.writeResponse(w, r, cacheEntry)
 	}
 
 	counter.Misses.Add(1)
+	if c.cfg.IsLargeObjectEnabled() {
+		return c.serveStream(w, r, reqEntry)
+	}
+
 	if fetchedEntry, err := c.fetchUpstream(r, reqEntry); err == nil {
 		c.storage.Set(fetchedEntry)
-		return c.writeResponse(w, fetchedEntry)
+		return c.writeResponse(w, r, fetchedEntry)
+	} else if staleEntry, found := c.staleFallback(reqEntry, err); found {
+		counter.Stale.Add(1)
+		return c.writeResponse(w, r, staleEntry)
 	} else {
 		return err
 	}
 }
 
+// staleFallback returns an expired entry kept within stale_if_error when the backend fetch was shed
+// or failed on the backend side (client errors like 404 are not masked by stale data).
+func (c *CacheRoute) staleFallback(entry *model.Entry, err error) (*model.Entry, bool) {
+	if errors.Is(err, upstreamBadStatusCodeError) {
+		return nil, false
+	}
+	return c.storage.GetStale(entry)
+}
+
 func (c *CacheRoute) fetchUpstream(r *http.Request, entry *model.Entry) (*model.Entry, error) {
 	path := unsafe.Slice(unsafe.StringData(r.URL.Path), len(r.URL.Path))
 	query := unsafe.Slice(unsafe.StringData(r.URL.RawQuery), len(r.URL.RawQuery))
@@ -87,11 +114,8 @@ func (c *CacheRoute) fetchUpstream(r *http.Request, entry *model.Entry) (*model.
 	if err != nil {
 		return nil, err
 	}
-
-	if statusCode == http.StatusServiceUnavailable {
-		return nil, temporaryUnavailableStatusCodeError
-	} else if statusCode != http.StatusOK {
-		return nil, upstreamBadStatusCodeError
+	if err = upstreamStatusError(statusCode); err != nil {
+		return nil, err
 	}
 
 	entry.SetPayload(path, query, queryHeaders, responseHeaders, body, statusCode)
@@ -101,36 +125,53 @@ func (c *CacheRoute) fetchUpstream(r *http.Request, entry *model.Entry) (*model.
 	return entry, nil
 }
 
-func (c *CacheRoute) writeResponse(w http.ResponseWriter, entry *model.Entry) error {
-	_, _, queryHeaders, responseHeaders, responseBody, status, payloadReleaser, err := entry.Payload()
+// upstreamStatusError returns nil for a cacheable status.
+func upstreamStatusError(statusCode int) error {
+	if statusCode == http.StatusServiceUnavailable {
+		return temporaryUnavailableStatusCodeError
+	} else if statusCode >= http.StatusInternalServerError {
+		return upstreamServerErrorStatusCodeError
+	} else if statusCode != http.StatusOK {
+		return upstreamBadStatusCodeError
+	}
+	return nil
+}
+
+func (c *CacheRoute) writeResponse(w http.ResponseWriter, r *http.Request, entry *model.Entry) error {
+	queryHeaders, responseHeaders, chunks, size, status, payloadReleaser, err := entry.PayloadChunks()
 	defer payloadReleaser(queryHeaders, responseHeaders)
 	if err != nil {
 		return err
 	}
 
-	// Write cached headers
-	for _, kv := range *responseHeaders {
-		w.Header().Add(
-			unsafe.String(unsafe.SliceData(kv[0]), len(kv[0])),
-			unsafe.String(unsafe.SliceData(kv[1]), len(kv[1])),
-		)
+	// ESI: includes are resolved before anything is written, so a failed document still falls back to the upstream
+	if status == http.StatusOK && c.isAssembled(entry, responseHeaders) {
+		return c.writeAssembled(w, entry, responseHeaders, chunks)
 	}
 
+	// Write cached headers
+	writeHeaders(w, responseHeaders)
+
 	// Last-Modified
 	header.SetLastModifiedNetHttp(w, entry)
 
 	// Content-Type
 	w.Header().Set("Content-Type", "application/json")
 
+	// Range: a part of the full response is served
+	body := header.ByteRange{Start: 0, End: size - 1}
+	if status == http.StatusOK {
+		var ok bool
+		if status, body, ok = selectRange(w, r, size, entry.UpdateAt()); !ok {
+			return nil
+		}
+	}
+
 	// StatusCode-code
 	w.WriteHeader(status)
 
 	// Write a response body
-	if _, err = w.Write(responseBody); err != nil {
-		return err
-	}
-
-	return nil
+	return writeChunks(w, chunks, 0, body)
 }
 
 func (c *CacheRoute) Paths() []string {
@@ -0,0 +1,57 @@
+package route
+
+import (
+	"bytes"
+	"net/http"
+	"strconv"
+
+	"github.com/traefik/traefik/v3/pkg/advancedcache/esi"
+	"github.com/traefik/traefik/v3/pkg/advancedcache/header"
+	"github.com/traefik/traefik/v3/pkg/advancedcache/model"
+	"github.com/traefik/traefik/v3/pkg/middlewares/advancedcache/counter"
+)
+
+var contentEncodingHeader = []byte("Content-Encoding")
+
+// isAssembled reports whether the cached document must go through ESI assembly. An encoded (compressed)
+// document cannot be parsed, so it is served as is.
+func (c *CacheRoute) isAssembled(entry *model.Entry, responseHeaders *[][2][]byte) bool {
+	if c.assembler == nil || !esi.IsEnabled(entry.Rule()) {
+		return false
+	}
+	for _, kv := range *responseHeaders {
+		if bytes.EqualFold(kv[0], contentEncodingHeader) && !bytes.EqualFold(kv[1], []byte("identity")) {
+			return false
+		}
+	}
+	return true
+}
+
+// writeAssembled serves the assembled document in full: ranges and validators of the cached template
+// do not apply to it.
+func (c *CacheRoute) writeAssembled(w http.ResponseWriter, entry *model.Entry, responseHeaders *[][2][]byte, chunks [][]byte) error {
+	body, err := c.assembler.Assemble(entry.Rule(), bytes.Join(chunks, nil))
+	if err != nil {
+		counter.ESIErrors.Add(1)
+		return err
+	}
+
+	// Write cached headers
+	writeHeaders(w, responseHeaders)
+
+	// Last-Modified
+	header.SetLastModifiedNetHttp(w, entry)
+
+	// Content-Type: the document is not JSON, the cached type is kept
+	if w.Header().Get("Content-Type") == "" {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	}
+
+	w.Header().Del("ETag")
+	w.Header().Del("Accept-Ranges")
+	w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+	w.WriteHeader(http.StatusOK)
+
+	_, err = w.Write(body)
+	return err
+}
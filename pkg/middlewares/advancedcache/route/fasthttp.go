@@ -0,0 +1,221 @@
+package route
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/fasthttp/router"
+	"github.com/rs/zerolog/log"
+	"github.com/traefik/traefik/v3/pkg/advancedcache/header"
+	"github.com/traefik/traefik/v3/pkg/advancedcache/model"
+	"github.com/traefik/traefik/v3/pkg/advancedcache/pools"
+	"github.com/traefik/traefik/v3/pkg/advancedcache/repository"
+	"github.com/traefik/traefik/v3/pkg/middlewares/advancedcache/counter"
+	"github.com/valyala/fasthttp"
+)
+
+var (
+	contentTypeJson    = []byte("application/json")
+	contentTypeHtml    = []byte("text/html; charset=utf-8")
+	etagHeader         = []byte("ETag")
+	unavailableMessage = []byte(`{
+	  "status": 503,
+	  "error": "Service unavailable",
+	  "message": "Please try again later and contact support though Reddy: Star team."
+	}`)
+)
+
+// FastHttpController serves the cache on the standalone fasthttp listener. It shares storage, backend, shadow
+// verifier and ESI assembler with the CacheRoute of the middleware, a path without a rule and a failed fetch
+// fall back to the upstream like the middleware router does.
+//
+// Ranges and streaming of large objects are handled by the middleware only: here the full body is always sent.
+type FastHttpController struct {
+	cache  *CacheRoute
+	report func(error)
+}
+
+// NewFastHttpController builds the controller over the cache route, report accounts errors of served requests.
+func NewFastHttpController(cache *CacheRoute, report func(error)) *FastHttpController {
+	return &FastHttpController{cache: cache, report: report}
+}
+
+func (c *FastHttpController) AddRoute(r *router.Router) {
+	r.GET("/{path:*}", c.ServeFastHTTP)
+}
+
+func (c *FastHttpController) ServeFastHTTP(ctx *fasthttp.RequestCtx) {
+	var from = time.Now()
+	defer func() { counter.Duration.Add(time.Since(from).Nanoseconds()) }()
+	counter.Total.Add(1)
+
+	defer func() {
+		if err := recover(); err != nil {
+			counter.Panics.Add(1)
+			log.Error().Msgf("Recovered from panic: %v", err)
+			writeUnavailableFastHttp(ctx)
+		}
+	}()
+
+	if IsCacheEnabled() {
+		err := c.serveCache(ctx)
+		if err == nil {
+			return // success: respond with cached response
+		}
+		if !errors.Is(err, routeNotFoundError) {
+			c.report(err)
+			if errors.Is(err, repository.ErrOverloaded) {
+				// the backend is saturated: the upstream fallback would be shed as well
+				writeUnavailableFastHttp(ctx)
+				return
+			}
+		}
+		// error: fallback to upstream
+	}
+
+	if IsUpstreamEnabled() {
+		if err := c.serveUpstream(ctx); err != nil {
+			c.report(err)
+			// error: respond that server is unavailable
+		} else {
+			return // success: respond with upstream response
+		}
+	}
+
+	writeUnavailableFastHttp(ctx)
+}
+
+func (c *FastHttpController) serveCache(ctx *fasthttp.RequestCtx) error {
+	reqEntry, err := model.NewEntryFastHttp(c.cache.cfg, ctx)
+	if err != nil {
+		return routeNotFoundError
+	}
+
+	if cacheEntry, hit := c.cache.storage.Get(reqEntry); hit {
+		counter.Hits.Add(1)
+		c.cache.verifier.Sample(cacheEntry)
+		return c.writeResponse(ctx, cacheEntry)
+	}
+
+	counter.Misses.Add(1)
+	if fetchedEntry, err := c.fetchUpstream(ctx, reqEntry); err == nil {
+		c.cache.storage.Set(fetchedEntry)
+		return c.writeResponse(ctx, fetchedEntry)
+	} else if staleEntry, found := c.cache.staleFallback(reqEntry, err); found {
+		counter.Stale.Add(1)
+		return c.writeResponse(ctx, staleEntry)
+	} else {
+		return err
+	}
+}
+
+func (c *FastHttpController) fetchUpstream(ctx *fasthttp.RequestCtx, entry *model.Entry) (*model.Entry, error) {
+	path, query := ctx.Path(), ctx.URI().QueryString()
+
+	queryHeaders, queryReleaser := getQueryHeadersFastHttp(ctx)
+	defer queryReleaser(queryHeaders)
+
+	counter.Proxies.Add(1)
+	statusCode, responseHeaders, body, releaser, err := c.cache.backend.Fetch(entry.Rule(), path, query, queryHeaders)
+	defer releaser()
+	if err != nil {
+		return nil, err
+	}
+	if err = upstreamStatusError(statusCode); err != nil {
+		return nil, err
+	}
+
+	entry.SetPayload(path, query, queryHeaders, responseHeaders, body, statusCode)
+	entry.SetRevalidator(c.cache.backend.RevalidatorMaker())
+	entry.TouchUpdatedAt()
+
+	return entry, nil
+}
+
+func (c *FastHttpController) writeResponse(ctx *fasthttp.RequestCtx, entry *model.Entry) error {
+	queryHeaders, responseHeaders, chunks, _, status, payloadReleaser, err := entry.PayloadChunks()
+	defer payloadReleaser(queryHeaders, responseHeaders)
+	if err != nil {
+		return err
+	}
+
+	// ESI: includes are resolved before anything is written, so a failed document still falls back to the upstream
+	assembled := status == http.StatusOK && c.cache.isAssembled(entry, responseHeaders)
+	if assembled {
+		body, err := c.cache.assembler.Assemble(entry.Rule(), bytes.Join(chunks, nil))
+		if err != nil {
+			counter.ESIErrors.Add(1)
+			return err
+		}
+		chunks = [][]byte{body}
+	}
+
+	// Write cached headers
+	for _, kv := range *responseHeaders {
+		ctx.Response.Header.AddBytesKV(kv[0], kv[1])
+	}
+
+	// Last-Modified
+	header.SetLastModifiedFastHttp(ctx, entry)
+
+	// Content-Type
+	if !assembled {
+		ctx.Response.Header.SetContentTypeBytes(contentTypeJson)
+	} else {
+		ctx.Response.Header.DelBytes(etagHeader) // validators of the cached template do not apply to the document
+		if len(ctx.Response.Header.ContentType()) == 0 {
+			ctx.Response.Header.SetContentTypeBytes(contentTypeHtml)
+		}
+	}
+
+	// StatusCode-code
+	ctx.SetStatusCode(status)
+
+	// Write a response body (Content-Length is set by the body size)
+	for _, chunk := range chunks {
+		if _, err = ctx.Write(chunk); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *FastHttpController) serveUpstream(ctx *fasthttp.RequestCtx) error {
+	queryHeaders, queryReleaser := getQueryHeadersFastHttp(ctx)
+	defer queryReleaser(queryHeaders)
+
+	counter.Proxies.Add(1)
+	status, headers, body, releaser, err := c.cache.backend.Fetch(nil, ctx.Path(), ctx.URI().QueryString(), queryHeaders)
+	defer releaser()
+	if err != nil {
+		return err
+	}
+
+	for _, kv := range *headers {
+		ctx.Response.Header.AddBytesKV(kv[0], kv[1])
+	}
+	header.SetLastModifiedValueFastHttp(ctx, time.Now().UnixNano())
+	ctx.Response.Header.SetContentTypeBytes(contentTypeJson)
+	ctx.SetStatusCode(status)
+
+	_, err = ctx.Write(body)
+	return err
+}
+
+// getQueryHeadersFastHttp collects request headers, they refer to the request memory.
+func getQueryHeadersFastHttp(ctx *fasthttp.RequestCtx) (headers *[][2][]byte, releaseFn func(*[][2][]byte)) {
+	headers = pools.KeyValueSlicePool.Get().(*[][2][]byte)
+	ctx.Request.Header.VisitAll(func(key, value []byte) {
+		*headers = append(*headers, [2][]byte{key, value})
+	})
+	return headers, queryHeadersReleaser
+}
+
+func writeUnavailableFastHttp(ctx *fasthttp.RequestCtx) {
+	ctx.Response.Reset()
+	ctx.Response.Header.SetContentTypeBytes(contentTypeJson)
+	ctx.SetStatusCode(http.StatusServiceUnavailable)
+	ctx.SetBody(unavailableMessage)
+}
@@ -0,0 +1,155 @@
+package route
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/traefik/traefik/v3/pkg/advancedcache/config"
+	"github.com/traefik/traefik/v3/pkg/advancedcache/model"
+	"github.com/traefik/traefik/v3/pkg/advancedcache/repository"
+	"github.com/traefik/traefik/v3/pkg/advancedcache/storage"
+	"github.com/valyala/fasthttp"
+)
+
+// mapStorage keeps entries by key, only Get and Set are used by the request paths.
+type mapStorage struct {
+	storage.Storage
+	mu      sync.RWMutex
+	entries map[uint64]*model.Entry
+}
+
+func (s *mapStorage) Get(entry *model.Entry) (*model.Entry, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	cached, ok := s.entries[entry.MapKey()]
+	if !ok || !cached.IsSameFingerprint(entry.Fingerprint()) {
+		return nil, false
+	}
+	return cached, true
+}
+
+func (s *mapStorage) Set(entry *model.Entry) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries[entry.MapKey()] = entry
+	return true
+}
+
+// countingBackend answers every fetch with the same body and counts fetches.
+type countingBackend struct {
+	mu      sync.Mutex
+	body    []byte
+	fetched int
+}
+
+func (b *countingBackend) fetch(*config.Rule, []byte, []byte, *[][2][]byte) (int, *[][2][]byte, []byte, func(), error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.fetched++
+	return http.StatusOK, &[][2][]byte{}, b.body, func() {}, nil
+}
+
+func (b *countingBackend) Fetch(rule *config.Rule, path []byte, query []byte, queryHeaders *[][2][]byte) (int, *[][2][]byte, []byte, func(), error) {
+	return b.fetch(rule, path, query, queryHeaders)
+}
+
+func (b *countingBackend) FetchStream(rule *config.Rule, path []byte, query []byte, queryHeaders *[][2][]byte) (int, *[][2][]byte, []byte, io.Reader, int, func(), error) {
+	status, headers, body, release, err := b.fetch(rule, path, query, queryHeaders)
+	return status, headers, body, nil, len(body), release, err
+}
+
+func (b *countingBackend) RevalidatorMaker() func(*config.Rule, []byte, []byte, *[][2][]byte) (int, *[][2][]byte, []byte, func(), error) {
+	return b.fetch
+}
+
+func (b *countingBackend) Limiter() *repository.Limiter {
+	return nil
+}
+
+func newFastHttpTestController(tb testing.TB) (*FastHttpController, *CacheRoute, *countingBackend) {
+	tb.Helper()
+	rule := &config.Rule{
+		PathBytes: []byte("/api"),
+		CacheKey: config.RuleKey{
+			QueryBytes: [][]byte{[]byte("id")},
+			HeadersMap: map[string]struct{}{"Accept-Encoding": {}},
+		},
+	}
+	cfg := &config.Cache{Cache: &config.CacheBox{Rules: map[string]*config.Rule{"/api": rule}}}
+	backend := &countingBackend{body: []byte(`{"id":1}`)}
+	cache := NewCacheRoutes(cfg, &mapStorage{entries: make(map[uint64]*model.Entry)}, backend, nil, nil)
+	EnableCache()
+	return NewFastHttpController(cache, func(err error) { tb.Error(err) }), cache, backend
+}
+
+func newFastHttpTestRequest(uri string) *fasthttp.RequestCtx {
+	ctx := new(fasthttp.RequestCtx)
+	ctx.Request.SetRequestURI(uri)
+	ctx.Request.Header.Set("accept-encoding", "gzip")
+	return ctx
+}
+
+func newNetHttpTestRequest(uri string) *http.Request {
+	req := httptest.NewRequest(http.MethodGet, uri, nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	return req
+}
+
+func TestFastHttpControllerSharesEntries(t *testing.T) {
+	controller, cache, backend := newFastHttpTestController(t)
+
+	// stored by the middleware path, then served by the fasthttp one (and back) with a single fetch
+	if err := cache.ServeHTTP(httptest.NewRecorder(), newNetHttpTestRequest("/api?id=1&skip=1")); err != nil {
+		t.Fatal(err)
+	}
+	ctx := newFastHttpTestRequest("/api?skip=2&id=1")
+	controller.ServeFastHTTP(ctx)
+	if ctx.Response.StatusCode() != http.StatusOK || string(ctx.Response.Body()) != `{"id":1}` {
+		t.Fatalf("unexpected response: %d %q", ctx.Response.StatusCode(), ctx.Response.Body())
+	}
+
+	controller.ServeFastHTTP(newFastHttpTestRequest("/api?id=2"))
+	rec := httptest.NewRecorder()
+	if err := cache.ServeHTTP(rec, newNetHttpTestRequest("/api?id=2")); err != nil {
+		t.Fatal(err)
+	}
+	if rec.Body.String() != `{"id":1}` {
+		t.Fatalf("unexpected response: %q", rec.Body.String())
+	}
+
+	if backend.fetched != 2 {
+		t.Fatalf("expected both request paths to share entries (2 fetches), got %d fetches", backend.fetched)
+	}
+}
+
+func BenchmarkCacheHitNetHttp(b *testing.B) {
+	_, cache, _ := newFastHttpTestController(b)
+	req := newNetHttpTestRequest("/api?id=1")
+	if err := cache.ServeHTTP(httptest.NewRecorder(), req); err != nil {
+		b.Fatal(err)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := cache.ServeHTTP(httptest.NewRecorder(), req); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkCacheHitFastHttp(b *testing.B) {
+	controller, _, _ := newFastHttpTestController(b)
+	ctx := newFastHttpTestRequest("/api?id=1")
+	controller.ServeFastHTTP(ctx)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		ctx.Response.Reset()
+		controller.ServeFastHTTP(ctx)
+	}
+}
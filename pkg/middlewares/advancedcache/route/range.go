@@ -0,0 +1,56 @@
+package route
+
+import (
+	"net/http"
+	"strconv"
+	"unsafe"
+
+	"github.com/traefik/traefik/v3/pkg/advancedcache/header"
+)
+
+// selectRange decides which part of a full response (status 200) of the given size is served according to
+// Range and If-Range, and sets Accept-Ranges, Content-Range and Content-Length. ok=false means that
+// the 416 response has been written already.
+func selectRange(w http.ResponseWriter, r *http.Request, size int64, lastModified int64) (status int, body header.ByteRange, ok bool) {
+	status, body = http.StatusOK, header.ByteRange{Start: 0, End: size - 1}
+
+	w.Header().Set("Accept-Ranges", "bytes")
+	if rng, isRange, satisfiable := header.ParseRange(r.Header.Get("Range"), size); isRange &&
+		header.IfRangeMatches(r.Header.Get("If-Range"), w.Header().Get("ETag"), lastModified) {
+		if !satisfiable {
+			w.Header().Del("Content-Length")
+			w.Header().Set("Content-Range", header.UnsatisfiedContentRange(size))
+			w.WriteHeader(http.StatusRequestedRangeNotSatisfiable)
+			return 0, body, false
+		}
+		w.Header().Set("Content-Range", rng.ContentRange(size))
+		status, body = http.StatusPartialContent, rng
+	}
+
+	w.Header().Set("Content-Length", strconv.FormatInt(body.Len(), 10))
+	return status, body, true
+}
+
+// writeChunks writes the part of the body within the range, offset is the body position of the first chunk.
+func writeChunks(w http.ResponseWriter, chunks [][]byte, offset int64, body header.ByteRange) error {
+	for _, chunk := range chunks {
+		from, to := max(body.Start-offset, 0), min(body.End-offset, int64(len(chunk))-1)+1
+		offset += int64(len(chunk))
+		if from >= to {
+			continue
+		}
+		if _, err := w.Write(chunk[from:to]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeHeaders(w http.ResponseWriter, headers *[][2][]byte) {
+	for _, kv := range *headers {
+		w.Header().Add(
+			unsafe.String(unsafe.SliceData(kv[0]), len(kv[0])),
+			unsafe.String(unsafe.SliceData(kv[1]), len(kv[1])),
+		)
+	}
+}
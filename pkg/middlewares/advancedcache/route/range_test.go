@@ -0,0 +1,63 @@
+package route
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/traefik/traefik/v3/pkg/advancedcache/model"
+)
+
+func newChunkedTestEntry() *model.Entry {
+	entry := new(model.Entry).Init()
+	responseHeaders := [][2][]byte{{[]byte("ETag"), []byte(`"v1"`)}}
+	entry.SetChunkedPayload(nil, nil, &[][2][]byte{}, &responseHeaders, [][]byte{
+		[]byte("0123"), []byte("4567"), []byte("89"),
+	}, http.StatusOK)
+	return entry
+}
+
+func TestWriteResponseServesRanges(t *testing.T) {
+	tests := []struct {
+		desc         string
+		headers      map[string]string
+		status       int
+		body         string
+		contentRange string
+	}{
+		{desc: "full response", status: http.StatusOK, body: "0123456789"},
+		{desc: "range across chunks", headers: map[string]string{"Range": "bytes=2-5"}, status: http.StatusPartialContent, body: "2345", contentRange: "bytes 2-5/10"},
+		{desc: "suffix range", headers: map[string]string{"Range": "bytes=-3"}, status: http.StatusPartialContent, body: "789", contentRange: "bytes 7-9/10"},
+		{desc: "unsatisfiable range", headers: map[string]string{"Range": "bytes=10-"}, status: http.StatusRequestedRangeNotSatisfiable, contentRange: "bytes */10"},
+		{desc: "matching if-range", headers: map[string]string{"Range": "bytes=0-0", "If-Range": `"v1"`}, status: http.StatusPartialContent, body: "0", contentRange: "bytes 0-0/10"},
+		{desc: "changed if-range", headers: map[string]string{"Range": "bytes=0-0", "If-Range": `"v0"`}, status: http.StatusOK, body: "0123456789"},
+	}
+
+	route := &CacheRoute{}
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/file", nil)
+			for k, v := range test.headers {
+				req.Header.Set(k, v)
+			}
+			rec := httptest.NewRecorder()
+
+			if err := route.writeResponse(rec, req, newChunkedTestEntry()); err != nil {
+				t.Fatal(err)
+			}
+
+			if rec.Code != test.status {
+				t.Fatalf("expected status %d, got %d", test.status, rec.Code)
+			}
+			if rec.Body.String() != test.body {
+				t.Fatalf("expected body %q, got %q", test.body, rec.Body.String())
+			}
+			if got := rec.Header().Get("Content-Range"); got != test.contentRange {
+				t.Fatalf("expected Content-Range %q, got %q", test.contentRange, got)
+			}
+			if got := rec.Header().Get("Accept-Ranges"); got != "bytes" {
+				t.Fatalf("expected Accept-Ranges bytes, got %q", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package route
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/traefik/traefik/v3/pkg/advancedcache/config"
+	"github.com/traefik/traefik/v3/pkg/advancedcache/model"
+	"github.com/traefik/traefik/v3/pkg/advancedcache/storage"
+)
+
+const cacheRulesPath = "/cache/rules"
+
+type rulesResponse struct {
+	Mem      int64            `json:"mem"`
+	Len      int64            `json:"len"`
+	MemLimit uint             `json:"memLimit"`
+	Rules    []model.RuleStat `json:"rules"`
+}
+
+type RulesRoute struct {
+	cfg     *config.Cache
+	storage storage.Storage
+}
+
+func NewRulesRoute(cfg *config.Cache, storage storage.Storage) *RulesRoute {
+	return &RulesRoute{
+		cfg:     cfg,
+		storage: storage,
+	}
+}
+
+// ServeHTTP is mounted at GET /cache/rules and returns storage occupancy, quota and evictions per rule.
+func (c *RulesRoute) ServeHTTP(w http.ResponseWriter, r *http.Request) error {
+	mem, length := c.storage.Stat()
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(rulesResponse{
+		Mem:      mem,
+		Len:      length,
+		MemLimit: c.cfg.Cache.Storage.Size,
+		Rules:    c.storage.RuleStats(),
+	})
+	return nil
+}
+
+func (c *RulesRoute) Paths() []string {
+	return []string{cacheRulesPath}
+}
+
+func (c *RulesRoute) IsEnabled() bool {
+	return IsCacheEnabled()
+}
+
+func (c *RulesRoute) IsInternal() bool {
+	return true
+}
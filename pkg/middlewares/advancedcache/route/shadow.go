@@ -0,0 +1,43 @@
+package route
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/traefik/traefik/v3/pkg/advancedcache/model"
+	"github.com/traefik/traefik/v3/pkg/advancedcache/shadow"
+)
+
+const cacheShadowPath = "/cache/shadow"
+
+type shadowResponse struct {
+	Rules []model.ShadowStat `json:"rules"`
+}
+
+type ShadowRoute struct {
+	verifier *shadow.Verifier
+}
+
+func NewShadowRoute(verifier *shadow.Verifier) *ShadowRoute {
+	return &ShadowRoute{verifier: verifier}
+}
+
+// ServeHTTP is mounted at GET /cache/shadow and returns divergence rates and the latest diffs per rule.
+func (c *ShadowRoute) ServeHTTP(w http.ResponseWriter, r *http.Request) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(shadowResponse{Rules: c.verifier.Stats()})
+	return nil
+}
+
+func (c *ShadowRoute) Paths() []string {
+	return []string{cacheShadowPath}
+}
+
+func (c *ShadowRoute) IsEnabled() bool {
+	return IsCacheEnabled()
+}
+
+func (c *ShadowRoute) IsInternal() bool {
+	return true
+}
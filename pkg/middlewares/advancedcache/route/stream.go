@@ -0,0 +1,154 @@
+package route
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"unsafe"
+
+	"github.com/traefik/traefik/v3/pkg/advancedcache/header"
+	"github.com/traefik/traefik/v3/pkg/advancedcache/model"
+	"github.com/traefik/traefik/v3/pkg/middlewares/advancedcache/counter"
+	"github.com/traefik/traefik/v3/pkg/middlewares/advancedcache/router"
+)
+
+// serveStream handles a miss when large objects are enabled: a response within the threshold is buffered
+// and cached as usual, a larger one is streamed to the client while it is being stored in chunks.
+func (c *CacheRoute) serveStream(w http.ResponseWriter, r *http.Request, entry *model.Entry) error {
+	path := unsafe.Slice(unsafe.StringData(r.URL.Path), len(r.URL.Path))
+	query := unsafe.Slice(unsafe.StringData(r.URL.RawQuery), len(r.URL.RawQuery))
+
+	queryHeaders, queryReleaser := getQueryHeaders(r)
+	defer queryReleaser(queryHeaders)
+
+	counter.Proxies.Add(1)
+	statusCode, responseHeaders, body, stream, contentLength, releaser, err := c.backend.FetchStream(entry.Rule(), path, query, queryHeaders)
+	defer releaser()
+	if err == nil {
+		err = upstreamStatusError(statusCode)
+	}
+	if err != nil {
+		if staleEntry, found := c.staleFallback(entry, err); found {
+			counter.Stale.Add(1)
+			return c.writeResponse(w, r, staleEntry)
+		}
+		return err
+	}
+
+	entry.SetRevalidator(c.backend.RevalidatorMaker())
+	entry.TouchUpdatedAt()
+
+	if stream == nil {
+		entry.SetPayload(path, query, queryHeaders, responseHeaders, body, statusCode)
+		c.storage.Set(entry)
+		return c.writeResponse(w, r, entry)
+	}
+
+	chunks, complete, err := c.streamResponse(w, r, entry, responseHeaders, stream, int64(contentLength))
+	if err != nil {
+		return fmt.Errorf("%w: %w", router.ErrResponseStarted, err)
+	} else if !complete {
+		return nil
+	}
+
+	entry.SetChunkedPayload(path, query, queryHeaders, responseHeaders, chunks, statusCode)
+	c.storage.Set(entry)
+
+	return nil
+}
+
+// streamResponse copies the backend stream to the client chunk by chunk. Chunks are kept for storing unless
+// the response is not stored (large_object.store=false) or exceeds large_object.max_size. A range is served
+// only if the size is known in advance, otherwise the full response is sent.
+func (c *CacheRoute) streamResponse(
+	w http.ResponseWriter, r *http.Request, entry *model.Entry,
+	responseHeaders *[][2][]byte, stream io.Reader, contentLength int64,
+) (chunks [][]byte, complete bool, err error) {
+	lo := c.cfg.Cache.LargeObject
+	store := lo.Store && (lo.MaxSize <= 0 || contentLength <= lo.MaxSize)
+
+	// Write backend headers
+	writeHeaders(w, responseHeaders)
+
+	// Last-Modified
+	header.SetLastModifiedNetHttp(w, entry)
+
+	// Content-Type
+	w.Header().Set("Content-Type", "application/json")
+
+	status, body := http.StatusOK, header.ByteRange{Start: 0, End: contentLength - 1}
+	if contentLength >= 0 {
+		var ok bool
+		if status, body, ok = selectRange(w, r, contentLength, entry.UpdateAt()); !ok {
+			if !store {
+				return nil, false, nil
+			}
+			// the client has got 416, but the response is still read to be stored
+			w = discardWriter{w}
+			body = header.ByteRange{Start: 0, End: -1}
+		}
+	} else {
+		w.Header().Del("Content-Length")
+		body.End = math.MaxInt64 // unknown size, up to the end
+	}
+
+	w.WriteHeader(status)
+	flusher, _ := w.(http.Flusher)
+
+	var (
+		chunkSize = c.cfg.Cache.LargeObject.ChunkBytes()
+		buf       []byte
+		offset    int64
+	)
+	for {
+		if store || buf == nil {
+			buf = make([]byte, chunkSize) // a stored chunk must not be reused
+		}
+
+		n, readErr := io.ReadFull(stream, buf)
+		if n > 0 {
+			chunk := buf[:n]
+			if err = writeChunks(w, [][]byte{chunk}, offset, body); err != nil {
+				return nil, false, err
+			}
+			if flusher != nil {
+				flusher.Flush()
+			}
+
+			offset += int64(n)
+			if store {
+				chunks = append(chunks, chunk)
+				if lo.MaxSize > 0 && offset > lo.MaxSize {
+					store, chunks = false, nil
+				}
+			}
+		}
+
+		if errors.Is(readErr, io.EOF) || errors.Is(readErr, io.ErrUnexpectedEOF) {
+			break
+		} else if readErr != nil {
+			return nil, false, readErr // the client gets a truncated response, nothing is stored
+		}
+	}
+
+	if contentLength >= 0 && offset != contentLength {
+		return nil, false, io.ErrUnexpectedEOF
+	}
+	if chunks == nil && store {
+		chunks = [][]byte{}
+	}
+	return chunks, store, nil
+}
+
+// discardWriter keeps headers and status of the wrapped writer, but drops the body.
+type discardWriter struct {
+	http.ResponseWriter
+}
+
+func (d discardWriter) WriteHeader(int) {}
+
+func (d discardWriter) Write(p []byte) (int, error) {
+	return len(p), nil
+}
@@ -0,0 +1,57 @@
+package route
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/traefik/traefik/v3/pkg/advancedcache/config"
+	"github.com/traefik/traefik/v3/pkg/advancedcache/model"
+)
+
+func newStreamTestRoute(lo *config.LargeObject) *CacheRoute {
+	return &CacheRoute{cfg: &config.Cache{Cache: &config.CacheBox{LargeObject: lo}}}
+}
+
+func TestStreamResponseStoresChunks(t *testing.T) {
+	body := strings.Repeat("x", 10_000) + "end"
+	route := newStreamTestRoute(&config.LargeObject{Threshold: 1024, Store: true, ChunkSize: 4096})
+
+	req := httptest.NewRequest(http.MethodGet, "/file", nil)
+	req.Header.Set("Range", "bytes=10000-")
+	rec := httptest.NewRecorder()
+
+	chunks, complete, err := route.streamResponse(rec, req, new(model.Entry).Init(), &[][2][]byte{},
+		strings.NewReader(body), int64(len(body)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !complete || len(chunks) != 3 || !bytes.Equal(bytes.Join(chunks, nil), []byte(body)) {
+		t.Fatalf("expected the full body to be stored in 3 chunks, got complete=%v chunks=%d", complete, len(chunks))
+	}
+	if rec.Code != http.StatusPartialContent || rec.Body.String() != "end" {
+		t.Fatalf("expected the requested range to be streamed, got %d %q", rec.Code, rec.Body.String())
+	}
+}
+
+func TestStreamResponseSkipsOversizedObjects(t *testing.T) {
+	body := strings.Repeat("x", 10_000)
+	route := newStreamTestRoute(&config.LargeObject{Threshold: 1024, Store: true, ChunkSize: 4096, MaxSize: 5000})
+
+	rec := httptest.NewRecorder()
+
+	// the size is unknown in advance, so the limit is detected while streaming
+	_, complete, err := route.streamResponse(rec, httptest.NewRequest(http.MethodGet, "/file", nil),
+		new(model.Entry).Init(), &[][2][]byte{}, strings.NewReader(body), -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if complete {
+		t.Fatal("expected an object over max_size to not be stored")
+	}
+	if rec.Code != http.StatusOK || rec.Body.Len() != len(body) {
+		t.Fatalf("expected the full body to be streamed, got %d with %d bytes", rec.Code, rec.Body.Len())
+	}
+}
@@ -1,9 +1,14 @@
 package router
 
 import (
+	"errors"
 	"net/http"
 )
 
+// ErrResponseStarted marks an error which occurred after the response had been partially written:
+// it is accounted, but the request can't fall back to another route.
+var ErrResponseStarted = errors.New("response has been started")
+
 type Upstream = Route
 type CacheProxy = Upstream
 
@@ -2,7 +2,9 @@ package router
 
 import (
 	"context"
+	"errors"
 	"github.com/rs/zerolog/log"
+	"github.com/traefik/traefik/v3/pkg/advancedcache/repository"
 	"github.com/traefik/traefik/v3/pkg/advancedcache/utils"
 	"github.com/traefik/traefik/v3/pkg/middlewares/advancedcache/counter"
 	"net/http"
@@ -75,6 +77,14 @@ func (router *Router) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		if err := router.cacheProxy.ServeHTTP(w, r); err != nil {
 			router.errorsCh <- err
 			counter.Errors.Add(1)
+			if errors.Is(err, ErrResponseStarted) {
+				return // the client has got a truncated response
+			}
+			if errors.Is(err, repository.ErrOverloaded) {
+				// the backend is saturated: the upstream fallback would be shed as well
+				router.unavailable.ServeHTTP(w, r)
+				return
+			}
 			// error: fallback to upstream
 		} else {
 			return // success: respond with cacheProxy response
@@ -95,6 +105,13 @@ func (router *Router) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	return
 }
 
+// Report accounts an error of a request served outside the router (e.g. by the standalone fasthttp listener),
+// so it is logged along with the router ones.
+func (router *Router) Report(err error) {
+	router.errorsCh <- err
+	counter.Errors.Add(1)
+}
+
 func (router *Router) runErrorLogger() {
 	go func() {
 		var prev map[string]int
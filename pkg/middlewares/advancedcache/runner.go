@@ -11,6 +11,12 @@ func (m *TraefikCacheMiddleware) run(ctx context.Context, config *config.Traefik
 	log.Info().Msg("[advanced-cache] starting")
 
 	m.ctx = ctx
+	if s := standalone.Load(); s != nil && s.serves(config.ConfigPath) {
+		m.cfg, m.router = s.cache.cfg, s.cache.router
+		log.Info().Msg("[advanced-cache] shares the cache of the standalone listener")
+		return nil
+	}
+
 	cfg, err := m.loadConfig(config)
 	if err != nil {
 		log.Error().Err(err).Msg("[advanced-cache] failed to load config")
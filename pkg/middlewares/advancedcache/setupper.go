@@ -4,8 +4,10 @@ import (
 	"context"
 	"github.com/rs/zerolog/log"
 	"github.com/traefik/traefik/v3/pkg/advancedcache/config"
+	"github.com/traefik/traefik/v3/pkg/advancedcache/esi"
 	"github.com/traefik/traefik/v3/pkg/advancedcache/prometheus/metrics"
 	"github.com/traefik/traefik/v3/pkg/advancedcache/repository"
+	"github.com/traefik/traefik/v3/pkg/advancedcache/shadow"
 	"github.com/traefik/traefik/v3/pkg/advancedcache/storage"
 	"github.com/traefik/traefik/v3/pkg/advancedcache/storage/lru"
 	"github.com/traefik/traefik/v3/pkg/middlewares/advancedcache/route"
@@ -17,20 +19,36 @@ var (
 	cacheDumper storage.Dumper
 )
 
+// cacheInstance is the set of dependencies built of a config file.
+type cacheInstance struct {
+	cfg    *config.Cache
+	route  *route.CacheRoute
+	router *router.Router
+}
+
 func (m *TraefikCacheMiddleware) setUpCache() {
-	ctx := m.ctx
-	cacheCfg = m.cfg
+	m.router = buildCache(m.ctx, m.cfg).router
+}
+
+// buildCache builds the cache and runs its workers until ctx is done.
+func buildCache(ctx context.Context, cfg *config.Cache) *cacheInstance {
+	cacheCfg = cfg
 
 	// build dependencies
 	meter := metrics.New()
 	backend := repository.NewBackend(ctx, cacheCfg)
 	db := lru.NewStorage(ctx, cacheCfg, backend)
+	verifier := shadow.NewVerifier(ctx, cacheCfg, backend).Run()
+	assembler := esi.NewAssembler(ctx, cacheCfg, db, backend)
 	cacheDumper = storage.NewDumper(cacheCfg, db, backend)
 
-	m.router = router.NewRouter(ctx,
+	cacheRoute := route.NewCacheRoutes(cacheCfg, db, backend, verifier, assembler)
+	cacheRouter := router.NewRouter(ctx,
 		route.NewUpstream(backend),
-		route.NewCacheRoutes(cacheCfg, db, backend),
+		cacheRoute,
 		route.NewClearRoute(cacheCfg, db),
+		route.NewRulesRoute(cacheCfg, db),
+		route.NewShadowRoute(verifier),
 		route.NewK8sProbeRoute(),
 		route.NewEnableRoute(),
 		route.NewDisableRoute(),
@@ -38,17 +56,19 @@ func (m *TraefikCacheMiddleware) setUpCache() {
 	)
 
 	// run additional workers
-	NewMetricsLogger(ctx, cacheCfg, db, meter).run()
+	NewMetricsLogger(ctx, cacheCfg, db, backend.Limiter(), verifier, meter).run()
 
 	// load data if necessary
 	LoadDumpIfNecessary(ctx)
-	m.loadMocksIfNecessary(ctx, backend, db)
+	loadMocksIfNecessary(ctx, backend, db)
 
 	// tell everyone that cache is enabled
 	route.EnableCache()
+
+	return &cacheInstance{cfg: cfg, route: cacheRoute, router: cacheRouter}
 }
 
-func (m *TraefikCacheMiddleware) loadMocksIfNecessary(ctx context.Context, backend repository.Backender, db storage.Storage) {
+func loadMocksIfNecessary(ctx context.Context, backend repository.Backender, db storage.Storage) {
 	if cacheCfg.Cache.Persistence.Mock.Enabled {
 		storage.LoadMocks(ctx, cacheCfg, backend, db, cacheCfg.Cache.Persistence.Mock.Length)
 	}
@@ -0,0 +1,87 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/traefik/traefik/v3/pkg/advancedcache/config"
+	"github.com/traefik/traefik/v3/pkg/advancedcache/gc"
+	httpserver "github.com/traefik/traefik/v3/pkg/advancedcache/server"
+	"github.com/traefik/traefik/v3/pkg/advancedcache/server/controller"
+	"github.com/traefik/traefik/v3/pkg/advancedcache/server/middleware"
+	"github.com/traefik/traefik/v3/pkg/middlewares/advancedcache/route"
+)
+
+// standalone is the running standalone listener, nil if it is not configured.
+var standalone atomic.Pointer[Standalone]
+
+// Standalone serves the cache on a dedicated fasthttp listener. Its cache lives as long as Traefik does:
+// middlewares referring to the same config file use it instead of building their own one (which lives
+// until the next dynamic configuration reload only).
+type Standalone struct {
+	configPath string // Absolute
+	cache      *cacheInstance
+	server     *httpserver.HTTP
+	done       chan struct{}
+}
+
+// NewStandalone builds the cache of the config file and the listener, in-flight requests are drained
+// within shutdownTimeout once ctx is done.
+func NewStandalone(ctx context.Context, address, configPath string, shutdownTimeout time.Duration) (*Standalone, error) {
+	log.Info().Msg("[advanced-cache] starting standalone listener")
+
+	path, err := filepath.Abs(configPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve absolute config filepath: %w", err)
+	}
+	cfg, err := config.LoadConfig(path)
+	if err != nil {
+		return nil, err
+	}
+
+	cache := buildCache(ctx, cfg)
+	gc.Run(ctx, cfg)
+
+	server, err := httpserver.New(ctx, cfg,
+		[]controller.HttpController{route.NewFastHttpController(cache.route, cache.router.Report)},
+		[]middleware.HttpMiddleware{},
+	)
+	if err != nil {
+		return nil, err
+	}
+	// the entries are shared with the middleware, which builds their keys of canonical header names
+	server.WithAddress(address).WithShutdownTimeout(shutdownTimeout).WithHeaderNamesNormalizing()
+
+	s := &Standalone{
+		configPath: path,
+		cache:      cache,
+		server:     server,
+		done:       make(chan struct{}),
+	}
+	standalone.Store(s)
+
+	return s, nil
+}
+
+// Start serves the listener in background until ctx is done.
+func (s *Standalone) Start() {
+	go func() {
+		defer close(s.done)
+		s.server.ListenAndServe()
+	}()
+}
+
+// Wait blocks until the listener is stopped and in-flight requests are drained.
+func (s *Standalone) Wait() {
+	<-s.done
+}
+
+// serves reports whether the cache is built of the config file.
+func (s *Standalone) serves(configPath string) bool {
+	path, err := filepath.Abs(configPath)
+	return err == nil && path == s.configPath
+}
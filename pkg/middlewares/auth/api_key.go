@@ -0,0 +1,383 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"os"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/traefik/traefik/v3/pkg/config/dynamic"
+	"github.com/traefik/traefik/v3/pkg/middlewares"
+	"github.com/traefik/traefik/v3/pkg/middlewares/accesslog"
+	"github.com/traefik/traefik/v3/pkg/middlewares/observability"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/sync/singleflight"
+)
+
+const (
+	typeNameAPIKey = "APIKey"
+
+	defaultAPIKeyHeader = "X-API-Key"
+	// keysFileCheckInterval is the minimum interval between two checks of the API keys file for changes.
+	keysFileCheckInterval = 5 * time.Second
+	// maxVerifiedKeys is the maximum number of API keys verified against a slow hash kept in cache.
+	maxVerifiedKeys = 1000
+)
+
+// keySource is the part of the request the API key has been read from.
+type keySource int
+
+const (
+	keySourceHeader keySource = iota
+	keySourceQuery
+	keySourceCookie
+)
+
+type apiKeyAuth struct {
+	next            http.Handler
+	name            string
+	routerName      string
+	headerName      string
+	queryParameter  string
+	cookieName      string
+	headerField     string
+	forwardMetadata map[string]string
+	removeKey       bool
+
+	inlineKeys    []dynamic.APIKeyEntry
+	keysFile      string
+	qualifyRouter func(string) string
+
+	// reloadMu ensures that the API keys file is reloaded once at a time.
+	reloadMu      sync.Mutex
+	mu            sync.RWMutex
+	store         *apiKeyStore
+	fileModTime   time.Time
+	fileSize      int64
+	fileCheckedAt time.Time
+	// verified caches the entries matched by the API keys verified against a slow hash, by the SHA-256 hash of the key.
+	verified map[[sha256.Size]byte]*apiKeyEntry
+	// slowVerifications limits the concurrent verifications against the slow hashes to the number of CPUs,
+	// so that unknown API keys cannot exhaust them.
+	slowVerifications chan struct{}
+
+	singleflightGroup *singleflight.Group
+}
+
+// NewAPIKey creates an API key authentication middleware.
+func NewAPIKey(ctx context.Context, next http.Handler, config dynamic.APIKey, name string) (http.Handler, error) {
+	middlewares.GetLogger(ctx, name, typeNameAPIKey).Debug().Msg("Creating middleware")
+
+	if len(config.Keys) == 0 && config.KeysFile == "" {
+		return nil, errors.New("keys or keysFile must be defined")
+	}
+
+	a := &apiKeyAuth{
+		next:           next,
+		name:           name,
+		routerName:     middlewares.GetRouterName(ctx),
+		headerName:     config.HeaderName,
+		queryParameter: config.QueryParameter,
+		cookieName:     config.CookieName,
+		headerField:    config.HeaderField,
+		removeKey:      config.RemoveKey,
+		inlineKeys:     config.Keys,
+		keysFile:       config.KeysFile,
+		qualifyRouter: func(router string) string {
+			return qualifyRouterName(name, router)
+		},
+		verified:          make(map[[sha256.Size]byte]*apiKeyEntry),
+		slowVerifications: make(chan struct{}, runtime.NumCPU()),
+		singleflightGroup: new(singleflight.Group),
+	}
+
+	if a.headerName == "" {
+		a.headerName = defaultAPIKeyHeader
+	}
+
+	if len(config.ForwardMetadata) > 0 {
+		a.forwardMetadata = make(map[string]string, len(config.ForwardMetadata))
+		for header, metadata := range config.ForwardMetadata {
+			a.forwardMetadata[http.CanonicalHeaderKey(header)] = metadata
+		}
+	}
+
+	fileKeys, err := a.statKeysFile()
+	if err != nil {
+		return nil, err
+	}
+
+	a.store, err = newAPIKeyStore(slices.Concat(a.inlineKeys, fileKeys), a.qualifyRouter)
+	if err != nil {
+		return nil, err
+	}
+
+	return a, nil
+}
+
+func (a *apiKeyAuth) GetTracingInformation() (string, string, trace.SpanKind) {
+	return a.name, typeNameAPIKey, trace.SpanKindInternal
+}
+
+func (a *apiKeyAuth) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	logger := middlewares.GetLogger(req.Context(), a.name, typeNameAPIKey)
+
+	key, source := a.extractKey(req)
+	if key == "" {
+		logger.Debug().Msg("No API key found")
+		observability.SetStatusErrorf(req.Context(), "No API key found")
+
+		http.Error(rw, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+		return
+	}
+
+	entry := a.lookup(req.Context(), key)
+	if entry == nil || entry.expired(time.Now()) {
+		logger.Debug().Msg("Authentication failed")
+		observability.SetStatusErrorf(req.Context(), "Authentication failed")
+
+		http.Error(rw, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+		return
+	}
+
+	logData := accesslog.GetLogData(req)
+	if logData != nil {
+		logData.Core[accesslog.ClientUsername] = entry.consumer
+	}
+
+	if len(entry.routers) > 0 && !slices.Contains(entry.routers, a.routerName) {
+		logger.Debug().Msgf("API key of consumer %s is not allowed on router %s", entry.consumer, a.routerName)
+		observability.SetStatusErrorf(req.Context(), "API key not allowed on router")
+
+		http.Error(rw, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+		return
+	}
+
+	logger.Debug().Msg("Authentication succeeded")
+
+	if a.removeKey {
+		a.removeKeyFrom(req, source)
+	}
+
+	if a.headerField != "" {
+		req.Header[a.headerField] = []string{entry.consumer}
+	}
+
+	for header, metadata := range a.forwardMetadata {
+		req.Header.Del(header)
+		if value, ok := entry.metadata[metadata]; ok {
+			req.Header.Set(header, value)
+		}
+	}
+
+	req = req.WithContext(middlewares.WithAPIKeyConsumer(req.Context(), entry.consumer))
+
+	a.next.ServeHTTP(rw, req)
+}
+
+// extractKey returns the API key of the request, looking in turn at the header, the query parameter and the cookie.
+func (a *apiKeyAuth) extractKey(req *http.Request) (string, keySource) {
+	if key := req.Header.Get(a.headerName); key != "" {
+		return key, keySourceHeader
+	}
+
+	if a.queryParameter != "" {
+		if key := req.URL.Query().Get(a.queryParameter); key != "" {
+			return key, keySourceQuery
+		}
+	}
+
+	if a.cookieName != "" {
+		if cookie, err := req.Cookie(a.cookieName); err == nil && cookie.Value != "" {
+			return cookie.Value, keySourceCookie
+		}
+	}
+
+	return "", keySourceHeader
+}
+
+func (a *apiKeyAuth) removeKeyFrom(req *http.Request, source keySource) {
+	switch source {
+	case keySourceHeader:
+		req.Header.Del(a.headerName)
+
+	case keySourceQuery:
+		query := req.URL.Query()
+		query.Del(a.queryParameter)
+		req.URL.RawQuery = query.Encode()
+		req.RequestURI = req.URL.RequestURI()
+
+	case keySourceCookie:
+		cookies := req.Cookies()
+		req.Header.Del("Cookie")
+
+		for _, c := range cookies {
+			if c.Name != a.cookieName {
+				req.AddCookie(c)
+			}
+		}
+	}
+}
+
+// lookup returns the entry matching the API key, if any.
+func (a *apiKeyAuth) lookup(ctx context.Context, key string) *apiKeyEntry {
+	store := a.currentStore(ctx)
+	sum := sha256.Sum256([]byte(key))
+
+	if entry := store.lookupSHA256(sum); entry != nil {
+		return entry
+	}
+
+	candidates := store.slowCandidates(key)
+	if len(candidates) == 0 {
+		return nil
+	}
+
+	a.mu.RLock()
+	entry, ok := a.verified[sum]
+	a.mu.RUnlock()
+	if ok {
+		return entry
+	}
+
+	// The concurrent verifications of a same API key are done once, as the slow hashes are expensive to compute.
+	result, _, _ := a.singleflightGroup.Do(hex.EncodeToString(sum[:]), func() (any, error) {
+		select {
+		case a.slowVerifications <- struct{}{}:
+			defer func() { <-a.slowVerifications }()
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+
+		entry := lookupSlow(candidates, key)
+		if entry == nil {
+			return entry, nil
+		}
+
+		a.mu.Lock()
+		defer a.mu.Unlock()
+
+		// The entry is not cached when the store has been reloaded during the verification.
+		if a.store == store {
+			if len(a.verified) >= maxVerifiedKeys {
+				clear(a.verified)
+			}
+			a.verified[sum] = entry
+		}
+
+		return entry, nil
+	})
+
+	entry, _ = result.(*apiKeyEntry)
+	return entry
+}
+
+// currentStore returns the API key store, reloading the API keys file when it has changed.
+// The previous store is kept when the file cannot be reloaded.
+func (a *apiKeyAuth) currentStore(ctx context.Context) *apiKeyStore {
+	a.mu.RLock()
+	store, checkedAt := a.store, a.fileCheckedAt
+	a.mu.RUnlock()
+
+	if a.keysFile == "" || time.Since(checkedAt) < keysFileCheckInterval {
+		return store
+	}
+
+	a.reloadMu.Lock()
+	defer a.reloadMu.Unlock()
+
+	a.mu.RLock()
+	store, checkedAt = a.store, a.fileCheckedAt
+	a.mu.RUnlock()
+
+	// The file has been checked while waiting for the lock.
+	if time.Since(checkedAt) < keysFileCheckInterval {
+		return store
+	}
+
+	logger := middlewares.GetLogger(ctx, a.name, typeNameAPIKey)
+
+	fileKeys, err := a.statKeysFile()
+	if err != nil {
+		logger.Error().Err(err).Msg("Unable to reload the API keys file")
+		return store
+	}
+
+	if fileKeys == nil {
+		return store
+	}
+
+	newStore, err := newAPIKeyStore(slices.Concat(a.inlineKeys, fileKeys), a.qualifyRouter)
+	if err != nil {
+		logger.Error().Err(err).Msg("Unable to reload the API keys file")
+		return store
+	}
+
+	logger.Debug().Msg("API keys file reloaded")
+
+	a.mu.Lock()
+	a.store = newStore
+	clear(a.verified)
+	a.mu.Unlock()
+
+	return newStore
+}
+
+// statKeysFile returns the entries of the API keys file when it has changed since the last check, and nil otherwise.
+func (a *apiKeyAuth) statKeysFile() ([]dynamic.APIKeyEntry, error) {
+	if a.keysFile == "" {
+		return nil, nil
+	}
+
+	a.mu.Lock()
+	a.fileCheckedAt = time.Now()
+	modTime, size := a.fileModTime, a.fileSize
+	a.mu.Unlock()
+
+	info, err := os.Stat(a.keysFile)
+	if err != nil {
+		return nil, err
+	}
+
+	if info.ModTime().Equal(modTime) && info.Size() == size {
+		return nil, nil
+	}
+
+	entries, err := loadAPIKeysFile(a.keysFile)
+	if err != nil {
+		return nil, err
+	}
+
+	a.mu.Lock()
+	a.fileModTime, a.fileSize = info.ModTime(), info.Size()
+	a.mu.Unlock()
+
+	// An empty file still replaces the previous entries.
+	if entries == nil {
+		entries = []dynamic.APIKeyEntry{}
+	}
+
+	return entries, nil
+}
+
+// qualifyRouterName returns the name of the router qualified with the provider of the middleware, if not already qualified.
+// The keys file is read at runtime, so the routers of the keys cannot be qualified when the configuration is built.
+func qualifyRouterName(middlewareName, router string) string {
+	if strings.Contains(router, "@") {
+		return router
+	}
+
+	_, providerName, ok := strings.Cut(middlewareName, "@")
+	if !ok {
+		return router
+	}
+
+	return router + "@" + providerName
+}
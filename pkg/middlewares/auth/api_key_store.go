@@ -0,0 +1,202 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/traefik/traefik/v3/pkg/config/dynamic"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+	"gopkg.in/yaml.v3"
+)
+
+const sha256HashPrefix = "{SHA256}"
+
+// apiKeyEntry is a parsed API key entry.
+type apiKeyEntry struct {
+	consumer  string
+	routers   []string
+	expiresAt time.Time
+	metadata  map[string]string
+
+	// verify checks the API key against the hash of the entry.
+	// It is only set for the slow hashes, the SHA-256 hashes being looked up.
+	verify func(key string) bool
+}
+
+func (e *apiKeyEntry) expired(now time.Time) bool {
+	return !e.expiresAt.IsZero() && !now.Before(e.expiresAt)
+}
+
+// apiKeyStore holds the API key entries, indexed by their SHA-256 hash when possible, or by their prefix.
+type apiKeyStore struct {
+	sha256Keys map[[sha256.Size]byte]*apiKeyEntry
+	// prefixedKeys are the entries with a slow hash and a prefix, by prefix.
+	prefixedKeys map[string][]*apiKeyEntry
+	// slowKeys are the entries with a slow hash and no prefix, every API key is checked against them.
+	slowKeys []*apiKeyEntry
+}
+
+func newAPIKeyStore(entries []dynamic.APIKeyEntry, qualifyRouter func(string) string) (*apiKeyStore, error) {
+	store := &apiKeyStore{
+		sha256Keys:   make(map[[sha256.Size]byte]*apiKeyEntry),
+		prefixedKeys: make(map[string][]*apiKeyEntry),
+	}
+
+	for i, entry := range entries {
+		if err := store.add(entry, qualifyRouter); err != nil {
+			return nil, fmt.Errorf("parsing API key %d: %w", i, err)
+		}
+	}
+
+	return store, nil
+}
+
+func (s *apiKeyStore) add(entry dynamic.APIKeyEntry, qualifyRouter func(string) string) error {
+	if entry.Consumer == "" {
+		return errors.New("consumer is required")
+	}
+
+	parsed := &apiKeyEntry{
+		consumer: entry.Consumer,
+		metadata: entry.Metadata,
+	}
+
+	for _, router := range entry.Routers {
+		parsed.routers = append(parsed.routers, qualifyRouter(router))
+	}
+
+	if entry.ExpiresAt != "" {
+		expiresAt, err := time.Parse(time.RFC3339, entry.ExpiresAt)
+		if err != nil {
+			return fmt.Errorf("parsing expiration time: %w", err)
+		}
+		parsed.expiresAt = expiresAt
+	}
+
+	switch {
+	case strings.HasPrefix(entry.Hash, sha256HashPrefix):
+		sum, err := hex.DecodeString(strings.TrimPrefix(entry.Hash, sha256HashPrefix))
+		if err != nil || len(sum) != sha256.Size {
+			return errors.New("invalid SHA-256 hash")
+		}
+
+		s.sha256Keys[[sha256.Size]byte(sum)] = parsed
+		return nil
+
+	case strings.HasPrefix(entry.Hash, "$argon2id$"):
+		verify, err := parseArgon2idHash(entry.Hash)
+		if err != nil {
+			return err
+		}
+		parsed.verify = verify
+
+	case strings.HasPrefix(entry.Hash, "$2a$"), strings.HasPrefix(entry.Hash, "$2b$"), strings.HasPrefix(entry.Hash, "$2y$"):
+		if _, err := bcrypt.Cost([]byte(entry.Hash)); err != nil {
+			return fmt.Errorf("invalid bcrypt hash: %w", err)
+		}
+
+		hash := []byte(entry.Hash)
+		parsed.verify = func(key string) bool {
+			return bcrypt.CompareHashAndPassword(hash, []byte(key)) == nil
+		}
+
+	default:
+		return errors.New("unsupported hash format")
+	}
+
+	if entry.Prefix != "" {
+		s.prefixedKeys[entry.Prefix] = append(s.prefixedKeys[entry.Prefix], parsed)
+	} else {
+		s.slowKeys = append(s.slowKeys, parsed)
+	}
+	return nil
+}
+
+// lookupSHA256 returns the entry holding the SHA-256 hash of the API key, if any.
+func (s *apiKeyStore) lookupSHA256(sum [sha256.Size]byte) *apiKeyEntry {
+	return s.sha256Keys[sum]
+}
+
+// slowCandidates returns the entries with a slow hash the API key has to be checked against:
+// the ones with a prefix of the API key, and the ones without a prefix.
+func (s *apiKeyStore) slowCandidates(key string) []*apiKeyEntry {
+	var candidates []*apiKeyEntry
+	for prefix, entries := range s.prefixedKeys {
+		if strings.HasPrefix(key, prefix) {
+			candidates = append(candidates, entries...)
+		}
+	}
+	return append(candidates, s.slowKeys...)
+}
+
+// lookupSlow returns the first of the candidates matching the API key, if any.
+func lookupSlow(candidates []*apiKeyEntry, key string) *apiKeyEntry {
+	for _, entry := range candidates {
+		if entry.verify(key) {
+			return entry
+		}
+	}
+	return nil
+}
+
+// parseArgon2idHash parses an argon2id hash in the PHC string format,
+// i.e. $argon2id$v=19$m=65536,t=3,p=4$<salt>$<hash>, and returns its verification function.
+func parseArgon2idHash(encoded string) (func(key string) bool, error) {
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 {
+		return nil, errors.New("invalid argon2id hash")
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return nil, fmt.Errorf("unsupported argon2id version %q", parts[2])
+	}
+
+	var memory, iterations, threads uint32
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &memory, &iterations, &threads); err != nil {
+		return nil, fmt.Errorf("invalid argon2id parameters %q: %w", parts[3], err)
+	}
+	// argon2.IDKey panics on less than one iteration or thread.
+	if memory < 1 || iterations < 1 || threads < 1 || threads > math.MaxUint8 {
+		return nil, fmt.Errorf("invalid argon2id parameters %q: memory and iterations must be at least 1, threads between 1 and 255", parts[3])
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return nil, fmt.Errorf("invalid argon2id salt: %w", err)
+	}
+
+	hash, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(hash) == 0 {
+		return nil, errors.New("invalid argon2id hash")
+	}
+
+	return func(key string) bool {
+		computed := argon2.IDKey([]byte(key), salt, iterations, memory, uint8(threads), uint32(len(hash)))
+		return subtle.ConstantTimeCompare(computed, hash) == 1
+	}, nil
+}
+
+// loadAPIKeysFile reads the API key entries from a YAML file.
+func loadAPIKeysFile(filename string) ([]dynamic.APIKeyEntry, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []dynamic.APIKeyEntry
+	if err := yaml.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("parsing API keys file %s: %w", filename, err)
+	}
+
+	return entries, nil
+}
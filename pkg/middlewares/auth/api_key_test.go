@@ -0,0 +1,495 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/traefik/traefik/v3/pkg/config/dynamic"
+	"github.com/traefik/traefik/v3/pkg/middlewares"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestAPIKey(t *testing.T) {
+	bcryptHash, err := bcrypt.GenerateFromPassword([]byte("bcrypt-key"), bcrypt.MinCost)
+	require.NoError(t, err)
+
+	keys := []dynamic.APIKeyEntry{
+		{
+			Hash:     sha256Hash("sha256-key"),
+			Consumer: "alice",
+			Metadata: map[string]string{"plan": "gold"},
+		},
+		{
+			Hash:     string(bcryptHash),
+			Consumer: "bob",
+		},
+		{
+			Hash:     argon2idHash("argon2-key"),
+			Consumer: "carol",
+		},
+		{
+			Hash:      sha256Hash("expired-key"),
+			Consumer:  "dave",
+			ExpiresAt: time.Now().Add(-time.Hour).Format(time.RFC3339),
+		},
+		{
+			Hash:      sha256Hash("valid-key"),
+			Consumer:  "erin",
+			ExpiresAt: time.Now().Add(time.Hour).Format(time.RFC3339),
+		},
+		{
+			Hash:     sha256Hash("other-router-key"),
+			Consumer: "frank",
+			Routers:  []string{"other"},
+		},
+		{
+			Hash:     sha256Hash("router-key"),
+			Consumer: "grace",
+			Routers:  []string{"api", "other@docker"},
+		},
+	}
+
+	testCases := []struct {
+		desc             string
+		config           dynamic.APIKey
+		header           http.Header
+		target           string
+		expectedCode     int
+		expectedConsumer string
+		expectedHeaders  map[string]string
+		expectedURI      string
+		expectedCookie   string
+	}{
+		{
+			desc:         "missing key",
+			expectedCode: http.StatusUnauthorized,
+		},
+		{
+			desc:         "unknown key",
+			header:       http.Header{"X-Api-Key": {"unknown"}},
+			expectedCode: http.StatusUnauthorized,
+		},
+		{
+			desc:             "SHA-256 key",
+			header:           http.Header{"X-Api-Key": {"sha256-key"}},
+			expectedCode:     http.StatusOK,
+			expectedConsumer: "alice",
+		},
+		{
+			desc:             "bcrypt key",
+			header:           http.Header{"X-Api-Key": {"bcrypt-key"}},
+			expectedCode:     http.StatusOK,
+			expectedConsumer: "bob",
+		},
+		{
+			desc:             "argon2id key",
+			header:           http.Header{"X-Api-Key": {"argon2-key"}},
+			expectedCode:     http.StatusOK,
+			expectedConsumer: "carol",
+		},
+		{
+			desc:         "expired key",
+			header:       http.Header{"X-Api-Key": {"expired-key"}},
+			expectedCode: http.StatusUnauthorized,
+		},
+		{
+			desc:             "not yet expired key",
+			header:           http.Header{"X-Api-Key": {"valid-key"}},
+			expectedCode:     http.StatusOK,
+			expectedConsumer: "erin",
+		},
+		{
+			desc:         "key not allowed on the router",
+			header:       http.Header{"X-Api-Key": {"other-router-key"}},
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			desc:             "key allowed on the router",
+			header:           http.Header{"X-Api-Key": {"router-key"}},
+			expectedCode:     http.StatusOK,
+			expectedConsumer: "grace",
+		},
+		{
+			desc:             "custom header",
+			config:           dynamic.APIKey{HeaderName: "X-Token"},
+			header:           http.Header{"X-Token": {"sha256-key"}},
+			expectedCode:     http.StatusOK,
+			expectedConsumer: "alice",
+			expectedHeaders:  map[string]string{"X-Token": "sha256-key"},
+		},
+		{
+			desc:             "key in query parameter",
+			config:           dynamic.APIKey{QueryParameter: "api_key"},
+			target:           "/foo?api_key=sha256-key&bar=baz",
+			expectedCode:     http.StatusOK,
+			expectedConsumer: "alice",
+			expectedURI:      "/foo?api_key=sha256-key&bar=baz",
+		},
+		{
+			desc:         "query parameter not enabled",
+			target:       "/foo?api_key=sha256-key",
+			expectedCode: http.StatusUnauthorized,
+		},
+		{
+			desc:             "key in cookie",
+			config:           dynamic.APIKey{CookieName: "api_key"},
+			header:           http.Header{"Cookie": {"api_key=sha256-key; foo=bar"}},
+			expectedCode:     http.StatusOK,
+			expectedConsumer: "alice",
+			expectedCookie:   "api_key=sha256-key; foo=bar",
+		},
+		{
+			desc:             "remove key from header",
+			config:           dynamic.APIKey{RemoveKey: true},
+			header:           http.Header{"X-Api-Key": {"sha256-key"}},
+			expectedCode:     http.StatusOK,
+			expectedConsumer: "alice",
+			expectedHeaders:  map[string]string{"X-Api-Key": ""},
+		},
+		{
+			desc:             "remove key from query parameter",
+			config:           dynamic.APIKey{QueryParameter: "api_key", RemoveKey: true},
+			target:           "/foo?api_key=sha256-key&bar=baz",
+			expectedCode:     http.StatusOK,
+			expectedConsumer: "alice",
+			expectedURI:      "/foo?bar=baz",
+		},
+		{
+			desc:             "remove key from cookie",
+			config:           dynamic.APIKey{CookieName: "api_key", RemoveKey: true},
+			header:           http.Header{"Cookie": {"api_key=sha256-key; foo=bar"}},
+			expectedCode:     http.StatusOK,
+			expectedConsumer: "alice",
+			expectedCookie:   "foo=bar",
+		},
+		{
+			desc:             "forward consumer and metadata",
+			config:           dynamic.APIKey{HeaderField: "X-Consumer", ForwardMetadata: map[string]string{"x-plan": "plan", "X-Team": "team"}},
+			header:           http.Header{"X-Api-Key": {"sha256-key"}, "X-Consumer": {"mallory"}, "X-Team": {"spoofed"}},
+			expectedCode:     http.StatusOK,
+			expectedConsumer: "alice",
+			expectedHeaders:  map[string]string{"X-Consumer": "alice", "X-Plan": "gold", "X-Team": ""},
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			var forwarded *http.Request
+			next := http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+				forwarded = req
+			})
+
+			config := test.config
+			config.Keys = keys
+
+			ctx := middlewares.WithRouterName(t.Context(), "api@file")
+			handler, err := NewAPIKey(ctx, next, config, "api-key@file")
+			require.NoError(t, err)
+
+			target := test.target
+			if target == "" {
+				target = "/foo"
+			}
+
+			req := httptest.NewRequest(http.MethodGet, target, nil)
+			for name, values := range test.header {
+				req.Header[name] = values
+			}
+
+			rw := httptest.NewRecorder()
+			handler.ServeHTTP(rw, req)
+
+			assert.Equal(t, test.expectedCode, rw.Code)
+
+			if test.expectedCode != http.StatusOK {
+				assert.Nil(t, forwarded)
+				return
+			}
+
+			require.NotNil(t, forwarded)
+			assert.Equal(t, test.expectedConsumer, middlewares.GetAPIKeyConsumer(forwarded.Context()))
+
+			for name, value := range test.expectedHeaders {
+				assert.Equal(t, value, forwarded.Header.Get(name), name)
+			}
+
+			if test.expectedURI != "" {
+				assert.Equal(t, test.expectedURI, forwarded.RequestURI)
+				assert.Equal(t, test.expectedURI, forwarded.URL.RequestURI())
+			}
+
+			if test.expectedCookie != "" {
+				assert.Equal(t, test.expectedCookie, forwarded.Header.Get("Cookie"))
+			}
+		})
+	}
+}
+
+func TestAPIKey_keysFile(t *testing.T) {
+	keysFile := filepath.Join(t.TempDir(), "keys.yml")
+	writeKeysFile(t, keysFile, "alice", "first-key")
+
+	next := http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		_, _ = fmt.Fprint(rw, middlewares.GetAPIKeyConsumer(req.Context()))
+	})
+
+	handler, err := NewAPIKey(t.Context(), next, dynamic.APIKey{KeysFile: keysFile}, "api-key")
+	require.NoError(t, err)
+
+	assertConsumer(t, handler, "first-key", "alice")
+	assertConsumer(t, handler, "second-key", "")
+
+	writeKeysFile(t, keysFile, "bob", "second-key")
+
+	// The file is not checked for changes before the check interval.
+	assertConsumer(t, handler, "first-key", "alice")
+
+	expireKeysFileCheck(handler)
+
+	assertConsumer(t, handler, "first-key", "")
+	assertConsumer(t, handler, "second-key", "bob")
+
+	// The previous keys are kept when the file cannot be reloaded.
+	require.NoError(t, os.WriteFile(keysFile, []byte("- hash: invalid\n  consumer: carol\n"), 0o600))
+	expireKeysFileCheck(handler)
+
+	assertConsumer(t, handler, "second-key", "bob")
+}
+
+func TestAPIKey_cachedVerification(t *testing.T) {
+	bcryptHash, err := bcrypt.GenerateFromPassword([]byte("bcrypt-key"), bcrypt.MinCost)
+	require.NoError(t, err)
+
+	next := http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {})
+
+	handler, err := NewAPIKey(t.Context(), next, dynamic.APIKey{
+		Keys: []dynamic.APIKeyEntry{{Hash: string(bcryptHash), Consumer: "bob"}},
+	}, "api-key")
+	require.NoError(t, err)
+
+	a := handler.(*apiKeyAuth)
+
+	entry := a.lookup(t.Context(), "bcrypt-key")
+	require.NotNil(t, entry)
+	assert.Equal(t, "bob", entry.consumer)
+	assert.Len(t, a.verified, 1)
+
+	assert.Nil(t, a.lookup(t.Context(), "unknown"))
+	assert.Len(t, a.verified, 1)
+
+	assert.Same(t, entry, a.lookup(t.Context(), "bcrypt-key"))
+}
+
+func TestAPIKey_prefix(t *testing.T) {
+	store, err := newAPIKeyStore([]dynamic.APIKeyEntry{
+		{Hash: argon2idHash("team-a.secret"), Prefix: "team-a.", Consumer: "alice"},
+		{Hash: argon2idHash("team-b.secret"), Prefix: "team-b.", Consumer: "bob"},
+	}, func(router string) string { return router })
+	require.NoError(t, err)
+
+	// an API key without a known prefix is rejected without checking any hash
+	assert.Empty(t, store.slowCandidates("unknown"))
+
+	candidates := store.slowCandidates("team-a.secret")
+	require.Len(t, candidates, 1)
+
+	entry := lookupSlow(candidates, "team-a.secret")
+	require.NotNil(t, entry)
+	assert.Equal(t, "alice", entry.consumer)
+
+	assert.Nil(t, lookupSlow(store.slowCandidates("team-b.secret-guess"), "team-b.secret-guess"))
+}
+
+func TestAPIKey_canceledSlowVerification(t *testing.T) {
+	next := http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {})
+
+	handler, err := NewAPIKey(t.Context(), next, dynamic.APIKey{
+		Keys: []dynamic.APIKeyEntry{{Hash: argon2idHash("argon2-key"), Consumer: "carol"}},
+	}, "api-key")
+	require.NoError(t, err)
+
+	a := handler.(*apiKeyAuth)
+
+	// every slow verification slot is in use
+	for range cap(a.slowVerifications) {
+		a.slowVerifications <- struct{}{}
+	}
+
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+	assert.Nil(t, a.lookup(ctx, "argon2-key"))
+
+	for range cap(a.slowVerifications) {
+		<-a.slowVerifications
+	}
+	assert.NotNil(t, a.lookup(t.Context(), "argon2-key"))
+}
+
+func TestNewAPIKey_invalidConfig(t *testing.T) {
+	testCases := []struct {
+		desc   string
+		config dynamic.APIKey
+	}{
+		{
+			desc: "no keys",
+		},
+		{
+			desc:   "missing consumer",
+			config: dynamic.APIKey{Keys: []dynamic.APIKeyEntry{{Hash: sha256Hash("key")}}},
+		},
+		{
+			desc:   "unsupported hash",
+			config: dynamic.APIKey{Keys: []dynamic.APIKeyEntry{{Hash: "{SHA}W6ph5Mm5Pz8GgiULbPgzG37mj9g=", Consumer: "alice"}}},
+		},
+		{
+			desc:   "invalid SHA-256 hash",
+			config: dynamic.APIKey{Keys: []dynamic.APIKeyEntry{{Hash: "{SHA256}abcd", Consumer: "alice"}}},
+		},
+		{
+			desc:   "invalid bcrypt hash",
+			config: dynamic.APIKey{Keys: []dynamic.APIKeyEntry{{Hash: "$2y$foo", Consumer: "alice"}}},
+		},
+		{
+			desc:   "invalid argon2id hash",
+			config: dynamic.APIKey{Keys: []dynamic.APIKeyEntry{{Hash: "$argon2id$v=19$m=foo$salt$hash", Consumer: "alice"}}},
+		},
+		{
+			desc:   "invalid expiration time",
+			config: dynamic.APIKey{Keys: []dynamic.APIKeyEntry{{Hash: sha256Hash("key"), Consumer: "alice", ExpiresAt: "tomorrow"}}},
+		},
+		{
+			desc:   "missing keys file",
+			config: dynamic.APIKey{KeysFile: "/does/not/exist.yml"},
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := NewAPIKey(t.Context(), http.NotFoundHandler(), test.config, "api-key")
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestParseArgon2idHash(t *testing.T) {
+	const salt, hash = "MDEyMzQ1Njc4OWFiY2RlZg", "aGFzaA"
+
+	testCases := []struct {
+		desc        string
+		params      string
+		expectError bool
+	}{
+		{
+			desc:   "valid parameters",
+			params: "m=8192,t=1,p=1",
+		},
+		{
+			desc:   "maximum threads",
+			params: "m=8192,t=1,p=255",
+		},
+		{
+			desc:        "no memory",
+			params:      "m=0,t=1,p=1",
+			expectError: true,
+		},
+		{
+			desc:        "no iterations",
+			params:      "m=8192,t=0,p=1",
+			expectError: true,
+		},
+		{
+			desc:        "no threads",
+			params:      "m=8192,t=1,p=0",
+			expectError: true,
+		},
+		{
+			desc:        "too many threads",
+			params:      "m=8192,t=1,p=256",
+			expectError: true,
+		},
+		{
+			desc:        "invalid parameters",
+			params:      "m=8192,t=1",
+			expectError: true,
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			verify, err := parseArgon2idHash(fmt.Sprintf("$argon2id$v=%d$%s$%s$%s", argon2.Version, test.params, salt, hash))
+			if test.expectError {
+				assert.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.False(t, verify("key"))
+		})
+	}
+}
+
+func sha256Hash(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return sha256HashPrefix + hex.EncodeToString(sum[:])
+}
+
+func argon2idHash(key string) string {
+	salt := []byte("0123456789abcdef")
+	hash := argon2.IDKey([]byte(key), salt, 1, 8*1024, 1, 32)
+
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s", argon2.Version, 8*1024, 1, 1,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(hash))
+}
+
+func writeKeysFile(t *testing.T, filename, consumer, key string) {
+	t.Helper()
+
+	content := fmt.Sprintf("- hash: %q\n  consumer: %s\n", sha256Hash(key), consumer)
+	require.NoError(t, os.WriteFile(filename, []byte(content), 0o600))
+}
+
+func expireKeysFileCheck(handler http.Handler) {
+	a := handler.(*apiKeyAuth)
+
+	a.mu.Lock()
+	a.fileCheckedAt = time.Time{}
+	// Forces the change detection, as the modification time may not change on fast writes.
+	a.fileSize = -1
+	a.mu.Unlock()
+}
+
+func assertConsumer(t *testing.T, handler http.Handler, key, expectedConsumer string) {
+	t.Helper()
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("X-Api-Key", key)
+
+	rw := httptest.NewRecorder()
+	handler.ServeHTTP(rw, req)
+
+	if expectedConsumer == "" {
+		assert.Equal(t, http.StatusUnauthorized, rw.Code)
+		return
+	}
+
+	assert.Equal(t, http.StatusOK, rw.Code)
+	assert.Equal(t, expectedConsumer, rw.Body.String())
+}
@@ -0,0 +1,357 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/traefik/traefik/v3/pkg/config/dynamic"
+	"github.com/traefik/traefik/v3/pkg/middlewares"
+	"github.com/traefik/traefik/v3/pkg/middlewares/observability"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	typeNameHMAC = "HMAC"
+
+	defaultSignatureHeader = "X-Signature"
+	defaultSeparator       = "\n"
+	defaultMaxBodySize     = 10 * 1024 * 1024
+	defaultClockSkew       = 5 * time.Minute
+	defaultNonceCacheSize  = 10000
+)
+
+// signedPart returns the value of a part of the signed string.
+type signedPart func(req *http.Request, body []byte) string
+
+type hmacAuth struct {
+	next            http.Handler
+	name            string
+	secret          []byte
+	hash            func() hash.Hash
+	signatureHeader string
+	signaturePrefix string
+	decode          func(string) ([]byte, error)
+	parts           []signedPart
+	readBody        bool
+	maxBodySize     int64
+	separator       string
+	timestampHeader string
+	clockSkew       time.Duration
+	nonceHeader     string
+	nonces          *nonceCache
+}
+
+// NewHMAC creates an HMAC signature verification middleware.
+func NewHMAC(ctx context.Context, next http.Handler, config dynamic.HMAC, name string) (http.Handler, error) {
+	middlewares.GetLogger(ctx, name, typeNameHMAC).Debug().Msg("Creating middleware")
+
+	secret := config.Secret
+	if secret == "" && config.SecretFile != "" {
+		content, err := os.ReadFile(config.SecretFile)
+		if err != nil {
+			return nil, fmt.Errorf("reading secret file: %w", err)
+		}
+		secret = strings.TrimRight(string(content), "\r\n")
+	}
+
+	if secret == "" {
+		return nil, errors.New("secret or secretFile must be defined")
+	}
+
+	h := &hmacAuth{
+		next:            next,
+		name:            name,
+		secret:          []byte(secret),
+		signatureHeader: config.SignatureHeader,
+		signaturePrefix: config.SignaturePrefix,
+		separator:       config.Separator,
+		maxBodySize:     config.MaxBodySize,
+		timestampHeader: config.TimestampHeader,
+		clockSkew:       time.Duration(config.ClockSkew),
+		nonceHeader:     config.NonceHeader,
+	}
+
+	if h.signatureHeader == "" {
+		h.signatureHeader = defaultSignatureHeader
+	}
+
+	// Without a separator, the boundaries between the signed parts would not be signed.
+	if h.separator == "" {
+		h.separator = defaultSeparator
+	}
+
+	if h.maxBodySize <= 0 {
+		h.maxBodySize = defaultMaxBodySize
+	}
+
+	switch config.Algorithm {
+	case "", "sha256":
+		h.hash = sha256.New
+	case "sha1":
+		h.hash = sha1.New
+	case "sha512":
+		h.hash = sha512.New
+	default:
+		return nil, fmt.Errorf("unsupported algorithm %q, must be one of sha1, sha256 and sha512", config.Algorithm)
+	}
+
+	switch config.Encoding {
+	case "", "hex":
+		h.decode = hex.DecodeString
+	case "base64":
+		h.decode = base64.StdEncoding.DecodeString
+	default:
+		return nil, fmt.Errorf("unsupported encoding %q, must be one of hex and base64", config.Encoding)
+	}
+
+	signedParts := config.SignedParts
+	if len(signedParts) == 0 {
+		signedParts = []string{"body"}
+	}
+
+	for _, part := range signedParts {
+		signedPart, err := h.newSignedPart(part)
+		if err != nil {
+			return nil, err
+		}
+		h.parts = append(h.parts, signedPart)
+	}
+
+	// An unsigned timestamp or nonce could be replaced by an attacker replaying the request.
+	if h.timestampHeader != "" && !slices.Contains(signedParts, "timestamp") {
+		return nil, errors.New("timestamp must be a signed part when timestampHeader is defined")
+	}
+	if h.nonceHeader != "" && !slices.Contains(signedParts, "nonce") {
+		return nil, errors.New("nonce must be a signed part when nonceHeader is defined")
+	}
+	// The nonces are forgotten after a while, only the timestamp prevents the request from being replayed then.
+	if h.nonceHeader != "" && h.timestampHeader == "" {
+		return nil, errors.New("timestampHeader must be defined along with nonceHeader")
+	}
+
+	if h.clockSkew <= 0 {
+		h.clockSkew = defaultClockSkew
+	}
+
+	if h.nonceHeader != "" {
+		size := config.NonceCacheSize
+		if size <= 0 {
+			size = defaultNonceCacheSize
+		}
+
+		// The nonces are remembered for as long as their requests could be accepted.
+		h.nonces = newNonceCache(size, 2*h.clockSkew)
+	}
+
+	return h, nil
+}
+
+func (h *hmacAuth) newSignedPart(part string) (signedPart, error) {
+	if name, ok := strings.CutPrefix(part, "header:"); ok {
+		if name == "" {
+			return nil, errors.New("missing header name in signed part header:")
+		}
+
+		return func(req *http.Request, _ []byte) string {
+			return strings.TrimSpace(req.Header.Get(name))
+		}, nil
+	}
+
+	switch part {
+	case "method":
+		return func(req *http.Request, _ []byte) string { return req.Method }, nil
+	case "path":
+		return func(req *http.Request, _ []byte) string { return req.URL.EscapedPath() }, nil
+	case "query":
+		return func(req *http.Request, _ []byte) string { return req.URL.RawQuery }, nil
+	case "host":
+		return func(req *http.Request, _ []byte) string { return req.Host }, nil
+	case "timestamp":
+		if h.timestampHeader == "" {
+			return nil, errors.New("timestampHeader must be defined to sign the timestamp")
+		}
+		return func(req *http.Request, _ []byte) string { return req.Header.Get(h.timestampHeader) }, nil
+	case "nonce":
+		if h.nonceHeader == "" {
+			return nil, errors.New("nonceHeader must be defined to sign the nonce")
+		}
+		return func(req *http.Request, _ []byte) string { return req.Header.Get(h.nonceHeader) }, nil
+	case "body":
+		h.readBody = true
+		return func(_ *http.Request, body []byte) string { return string(body) }, nil
+	case "bodyDigest":
+		h.readBody = true
+		return func(_ *http.Request, body []byte) string {
+			digest := sha256.Sum256(body)
+			return hex.EncodeToString(digest[:])
+		}, nil
+	default:
+		return nil, fmt.Errorf("unsupported signed part %q", part)
+	}
+}
+
+func (h *hmacAuth) GetTracingInformation() (string, string, trace.SpanKind) {
+	return h.name, typeNameHMAC, trace.SpanKindInternal
+}
+
+func (h *hmacAuth) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	logger := middlewares.GetLogger(req.Context(), h.name, typeNameHMAC)
+
+	if err := h.verify(req); err != nil {
+		logger.Debug().Err(err).Msg("Signature verification failed")
+		observability.SetStatusErrorf(req.Context(), "Signature verification failed: %s", err)
+
+		code := http.StatusUnauthorized
+		if errors.Is(err, errBodyTooLarge) {
+			code = http.StatusRequestEntityTooLarge
+		}
+
+		http.Error(rw, err.Error(), code)
+		return
+	}
+
+	logger.Debug().Msg("Signature verification succeeded")
+
+	h.next.ServeHTTP(rw, req)
+}
+
+// verify checks the signature of the request, replacing its body so that it can still be read.
+// The returned errors are meant to be sent to the client.
+func (h *hmacAuth) verify(req *http.Request) error {
+	signatureValue, ok := strings.CutPrefix(strings.TrimSpace(req.Header.Get(h.signatureHeader)), h.signaturePrefix)
+	if !ok || signatureValue == "" {
+		return errors.New("missing signature")
+	}
+
+	signature, err := h.decode(signatureValue)
+	if err != nil {
+		return errors.New("malformed signature")
+	}
+
+	if h.timestampHeader != "" {
+		timestamp := req.Header.Get(h.timestampHeader)
+		if timestamp == "" {
+			return errors.New("missing timestamp")
+		}
+
+		seconds, err := strconv.ParseInt(timestamp, 10, 64)
+		if err != nil {
+			return errors.New("malformed timestamp")
+		}
+
+		if skew := time.Since(time.Unix(seconds, 0)).Abs(); skew > h.clockSkew {
+			return errors.New("timestamp outside of the allowed clock skew")
+		}
+	}
+
+	var nonce string
+	if h.nonceHeader != "" {
+		nonce = req.Header.Get(h.nonceHeader)
+		if nonce == "" {
+			return errors.New("missing nonce")
+		}
+	}
+
+	var body []byte
+	if h.readBody && req.Body != nil && req.Body != http.NoBody {
+		body, err = io.ReadAll(http.MaxBytesReader(nil, req.Body, h.maxBodySize))
+		if err != nil {
+			var maxBytesErr *http.MaxBytesError
+			if errors.As(err, &maxBytesErr) {
+				return errBodyTooLarge
+			}
+			return errors.New("unable to read body")
+		}
+		_ = req.Body.Close()
+
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	values := make([]string, len(h.parts))
+	for i, part := range h.parts {
+		values[i] = part(req, body)
+	}
+
+	mac := hmac.New(h.hash, h.secret)
+	mac.Write([]byte(strings.Join(values, h.separator)))
+
+	if !hmac.Equal(mac.Sum(nil), signature) {
+		return errors.New("invalid signature")
+	}
+
+	// The nonce is only remembered once the signature is verified, so that the cache cannot be filled with forged nonces.
+	if h.nonces != nil {
+		return h.nonces.add(nonce, time.Now())
+	}
+
+	return nil
+}
+
+var (
+	errReplayedRequest = errors.New("replayed request")
+	errNonceCacheFull  = errors.New("too many nonces in use")
+)
+
+// nonceCache remembers the nonces for a given duration.
+// Forgetting a nonce before it expires would allow to replay its request, so the new nonces are rejected when full.
+type nonceCache struct {
+	size int
+	ttl  time.Duration
+
+	mu sync.Mutex
+	// expirations holds the expiration time of the nonces.
+	expirations map[string]time.Time
+	// order holds the nonces in insertion order, which is also the expiration order.
+	order []string
+}
+
+func newNonceCache(size int, ttl time.Duration) *nonceCache {
+	return &nonceCache{
+		size:        size,
+		ttl:         ttl,
+		expirations: make(map[string]time.Time),
+	}
+}
+
+// add remembers the nonce, and returns an error when it is already known or the cache is full.
+func (c *nonceCache) add(nonce string, now time.Time) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var expired int
+	for expired < len(c.order) && !now.Before(c.expirations[c.order[expired]]) {
+		delete(c.expirations, c.order[expired])
+		expired++
+	}
+	c.order = c.order[expired:]
+
+	if _, ok := c.expirations[nonce]; ok {
+		return errReplayedRequest
+	}
+
+	if len(c.order) >= c.size {
+		return errNonceCacheFull
+	}
+
+	c.expirations[nonce] = now.Add(c.ttl)
+	c.order = append(c.order, nonce)
+
+	return nil
+}
@@ -0,0 +1,426 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"hash"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	ptypes "github.com/traefik/paerser/types"
+	"github.com/traefik/traefik/v3/pkg/config/dynamic"
+)
+
+func TestHMAC(t *testing.T) {
+	now := strconv.FormatInt(time.Now().Unix(), 10)
+	old := strconv.FormatInt(time.Now().Add(-time.Hour).Unix(), 10)
+
+	bodyDigest := sha256.Sum256([]byte("payload"))
+
+	testCases := []struct {
+		desc           string
+		config         dynamic.HMAC
+		method         string
+		target         string
+		headers        map[string]string
+		signedString   string
+		hash           func() hash.Hash
+		signature      func(mac []byte) string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			desc:           "valid body signature",
+			config:         dynamic.HMAC{Secret: "secret"},
+			signedString:   "payload",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			desc:           "invalid signature",
+			config:         dynamic.HMAC{Secret: "other"},
+			signedString:   "payload",
+			expectedStatus: http.StatusUnauthorized,
+			expectedBody:   "invalid signature",
+		},
+		{
+			desc:           "body within the maximum size",
+			config:         dynamic.HMAC{Secret: "secret", MaxBodySize: 7},
+			signedString:   "payload",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			desc:           "body too large",
+			config:         dynamic.HMAC{Secret: "secret", MaxBodySize: 6},
+			signedString:   "payload",
+			expectedStatus: http.StatusRequestEntityTooLarge,
+			expectedBody:   "request body too large",
+		},
+		{
+			desc: "default separator",
+			config: dynamic.HMAC{
+				Secret:      "secret",
+				SignedParts: []string{"method", "path"},
+			},
+			target:         "http://example.com/x",
+			signedString:   "POST\n/x",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			desc:           "missing signature",
+			config:         dynamic.HMAC{Secret: "secret"},
+			expectedStatus: http.StatusUnauthorized,
+			expectedBody:   "missing signature",
+		},
+		{
+			desc:           "malformed signature",
+			config:         dynamic.HMAC{Secret: "secret"},
+			signature:      func([]byte) string { return "not-hex" },
+			expectedStatus: http.StatusUnauthorized,
+			expectedBody:   "malformed signature",
+		},
+		{
+			desc: "signature with prefix",
+			config: dynamic.HMAC{
+				Secret:          "secret",
+				SignatureHeader: "X-Hub-Signature-256",
+				SignaturePrefix: "sha256=",
+			},
+			signedString:   "payload",
+			signature:      func(mac []byte) string { return "sha256=" + hex.EncodeToString(mac) },
+			expectedStatus: http.StatusOK,
+		},
+		{
+			desc: "signature without the expected prefix",
+			config: dynamic.HMAC{
+				Secret:          "secret",
+				SignaturePrefix: "sha256=",
+			},
+			signedString:   "payload",
+			expectedStatus: http.StatusUnauthorized,
+			expectedBody:   "missing signature",
+		},
+		{
+			desc: "base64 sha512 signature",
+			config: dynamic.HMAC{
+				Secret:    "secret",
+				Algorithm: "sha512",
+				Encoding:  "base64",
+			},
+			signedString:   "payload",
+			hash:           sha512.New,
+			signature:      base64.StdEncoding.EncodeToString,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			desc: "canonical string",
+			config: dynamic.HMAC{
+				Secret:      "secret",
+				SignedParts: []string{"method", "path", "query", "host", "header:Content-Type", "bodyDigest"},
+				Separator:   "\n",
+			},
+			method:         http.MethodPut,
+			target:         "http://example.com/foo%2Fbar?a=b",
+			headers:        map[string]string{"Content-Type": "text/plain"},
+			signedString:   "PUT\n/foo%2Fbar\na=b\nexample.com\ntext/plain\n" + hex.EncodeToString(bodyDigest[:]),
+			expectedStatus: http.StatusOK,
+		},
+		{
+			desc: "canonical string with another method",
+			config: dynamic.HMAC{
+				Secret:      "secret",
+				SignedParts: []string{"method", "path"},
+				Separator:   "\n",
+			},
+			signedString:   "PUT\n/",
+			expectedStatus: http.StatusUnauthorized,
+			expectedBody:   "invalid signature",
+		},
+		{
+			desc: "signed timestamp",
+			config: dynamic.HMAC{
+				Secret:          "secret",
+				SignedParts:     []string{"timestamp", "body"},
+				Separator:       ".",
+				TimestampHeader: "X-Timestamp",
+			},
+			headers:        map[string]string{"X-Timestamp": now},
+			signedString:   now + ".payload",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			desc: "timestamp outside of the clock skew",
+			config: dynamic.HMAC{
+				Secret:          "secret",
+				SignedParts:     []string{"timestamp", "body"},
+				Separator:       ".",
+				TimestampHeader: "X-Timestamp",
+				ClockSkew:       ptypes.Duration(time.Minute),
+			},
+			headers:        map[string]string{"X-Timestamp": old},
+			signedString:   old + ".payload",
+			expectedStatus: http.StatusUnauthorized,
+			expectedBody:   "timestamp outside of the allowed clock skew",
+		},
+		{
+			desc: "missing timestamp",
+			config: dynamic.HMAC{
+				Secret:          "secret",
+				SignedParts:     []string{"timestamp", "body"},
+				TimestampHeader: "X-Timestamp",
+			},
+			signedString:   "payload",
+			expectedStatus: http.StatusUnauthorized,
+			expectedBody:   "missing timestamp",
+		},
+		{
+			desc: "malformed timestamp",
+			config: dynamic.HMAC{
+				Secret:          "secret",
+				SignedParts:     []string{"timestamp", "body"},
+				TimestampHeader: "X-Timestamp",
+			},
+			headers:        map[string]string{"X-Timestamp": "yesterday"},
+			signedString:   "payload",
+			expectedStatus: http.StatusUnauthorized,
+			expectedBody:   "malformed timestamp",
+		},
+		{
+			desc: "missing nonce",
+			config: dynamic.HMAC{
+				Secret:          "secret",
+				SignedParts:     []string{"timestamp", "nonce", "body"},
+				TimestampHeader: "X-Timestamp",
+				NonceHeader:     "X-Nonce",
+			},
+			headers:        map[string]string{"X-Timestamp": now},
+			signedString:   "payload",
+			expectedStatus: http.StatusUnauthorized,
+			expectedBody:   "missing nonce",
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			next := http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+				body, err := io.ReadAll(req.Body)
+				require.NoError(t, err)
+
+				// The body is still readable once verified.
+				assert.Equal(t, "payload", string(body))
+			})
+
+			handler, err := NewHMAC(t.Context(), next, test.config, "hmac")
+			require.NoError(t, err)
+
+			method := test.method
+			if method == "" {
+				method = http.MethodPost
+			}
+
+			target := test.target
+			if target == "" {
+				target = "http://example.com/"
+			}
+
+			req := httptest.NewRequest(method, target, strings.NewReader("payload"))
+			for name, value := range test.headers {
+				req.Header.Set(name, value)
+			}
+
+			if test.signedString != "" || test.signature != nil {
+				signatureHeader := test.config.SignatureHeader
+				if signatureHeader == "" {
+					signatureHeader = "X-Signature"
+				}
+
+				req.Header.Set(signatureHeader, sign(test.hash, test.signature, test.signedString))
+			}
+
+			rw := httptest.NewRecorder()
+			handler.ServeHTTP(rw, req)
+
+			assert.Equal(t, test.expectedStatus, rw.Code)
+			assert.Equal(t, test.expectedBody, strings.TrimSpace(rw.Body.String()))
+		})
+	}
+}
+
+func TestHMAC_nonceReplay(t *testing.T) {
+	next := http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {})
+
+	handler, err := NewHMAC(t.Context(), next, dynamic.HMAC{
+		Secret:          "secret",
+		SignedParts:     []string{"timestamp", "nonce", "body"},
+		Separator:       "\n",
+		TimestampHeader: "X-Timestamp",
+		ClockSkew:       ptypes.Duration(time.Minute),
+		NonceHeader:     "X-Nonce",
+	}, "hmac")
+	require.NoError(t, err)
+
+	serve := func(timestamp time.Time, nonce, signedString string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "http://example.com/", strings.NewReader("payload"))
+		req.Header.Set("X-Timestamp", strconv.FormatInt(timestamp.Unix(), 10))
+		req.Header.Set("X-Nonce", nonce)
+		req.Header.Set("X-Signature", sign(nil, nil, signedString))
+
+		rw := httptest.NewRecorder()
+		handler.ServeHTTP(rw, req)
+
+		return rw
+	}
+
+	now := time.Now()
+	signed := func(timestamp time.Time, nonce string) string {
+		return strconv.FormatInt(timestamp.Unix(), 10) + "\n" + nonce + "\npayload"
+	}
+
+	// A forged request does not consume the nonce.
+	rw := serve(now, "foo", "forged")
+	assert.Equal(t, http.StatusUnauthorized, rw.Code)
+
+	rw = serve(now, "foo", signed(now, "foo"))
+	assert.Equal(t, http.StatusOK, rw.Code)
+
+	rw = serve(now, "foo", signed(now, "foo"))
+	assert.Equal(t, http.StatusUnauthorized, rw.Code)
+	assert.Equal(t, "replayed request", strings.TrimSpace(rw.Body.String()))
+
+	rw = serve(now, "bar", signed(now, "bar"))
+	assert.Equal(t, http.StatusOK, rw.Code)
+
+	// Once its nonce has expired, a request accepted a while ago is rejected by its timestamp.
+	accepted := now.Add(-2 * time.Minute)
+	require.NoError(t, handler.(*hmacAuth).nonces.add("baz", accepted))
+
+	rw = serve(accepted, "baz", signed(accepted, "baz"))
+	assert.Equal(t, http.StatusUnauthorized, rw.Code)
+	assert.Equal(t, "timestamp outside of the allowed clock skew", strings.TrimSpace(rw.Body.String()))
+}
+
+func TestHMAC_secretFile(t *testing.T) {
+	secretFile := filepath.Join(t.TempDir(), "secret")
+	require.NoError(t, os.WriteFile(secretFile, []byte("secret\n"), 0o600))
+
+	next := http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {})
+
+	handler, err := NewHMAC(t.Context(), next, dynamic.HMAC{SecretFile: secretFile}, "hmac")
+	require.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodPost, "http://example.com/", strings.NewReader("payload"))
+	req.Header.Set("X-Signature", sign(nil, nil, "payload"))
+
+	rw := httptest.NewRecorder()
+	handler.ServeHTTP(rw, req)
+
+	assert.Equal(t, http.StatusOK, rw.Code)
+}
+
+func TestNewHMAC_invalidConfig(t *testing.T) {
+	testCases := []struct {
+		desc   string
+		config dynamic.HMAC
+	}{
+		{
+			desc:   "missing secret",
+			config: dynamic.HMAC{},
+		},
+		{
+			desc:   "missing secret file",
+			config: dynamic.HMAC{SecretFile: filepath.Join(t.TempDir(), "missing")},
+		},
+		{
+			desc:   "unsupported algorithm",
+			config: dynamic.HMAC{Secret: "secret", Algorithm: "md5"},
+		},
+		{
+			desc:   "unsupported encoding",
+			config: dynamic.HMAC{Secret: "secret", Encoding: "base32"},
+		},
+		{
+			desc:   "unsupported signed part",
+			config: dynamic.HMAC{Secret: "secret", SignedParts: []string{"cookie"}},
+		},
+		{
+			desc:   "missing header name",
+			config: dynamic.HMAC{Secret: "secret", SignedParts: []string{"header:"}},
+		},
+		{
+			desc:   "signed timestamp without timestamp header",
+			config: dynamic.HMAC{Secret: "secret", SignedParts: []string{"timestamp"}},
+		},
+		{
+			desc:   "signed nonce without nonce header",
+			config: dynamic.HMAC{Secret: "secret", SignedParts: []string{"nonce"}},
+		},
+		{
+			desc:   "timestamp header without signed timestamp",
+			config: dynamic.HMAC{Secret: "secret", TimestampHeader: "X-Timestamp"},
+		},
+		{
+			desc:   "nonce header without signed nonce",
+			config: dynamic.HMAC{Secret: "secret", SignedParts: []string{"body"}, NonceHeader: "X-Nonce"},
+		},
+		{
+			desc:   "nonce header without timestamp header",
+			config: dynamic.HMAC{Secret: "secret", SignedParts: []string{"nonce", "body"}, NonceHeader: "X-Nonce"},
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			next := http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {})
+
+			_, err := NewHMAC(t.Context(), next, test.config, "hmac")
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestNonceCache(t *testing.T) {
+	cache := newNonceCache(2, time.Minute)
+	now := time.Now()
+
+	require.NoError(t, cache.add("a", now))
+	assert.ErrorIs(t, cache.add("a", now), errReplayedRequest)
+	require.NoError(t, cache.add("b", now))
+
+	// The nonces are not forgotten before they expire, so the new ones are rejected when the cache is full.
+	assert.ErrorIs(t, cache.add("c", now), errNonceCacheFull)
+	assert.ErrorIs(t, cache.add("a", now.Add(time.Minute-time.Second)), errReplayedRequest)
+
+	// The expired nonces are forgotten.
+	require.NoError(t, cache.add("c", now.Add(time.Minute)))
+	assert.Len(t, cache.order, 1)
+}
+
+// sign returns the signature of the string, hex-encoded with SHA-256 by default.
+func sign(hashFn func() hash.Hash, encode func([]byte) string, signedString string) string {
+	if hashFn == nil {
+		hashFn = sha256.New
+	}
+	if encode == nil {
+		encode = hex.EncodeToString
+	}
+
+	mac := hmac.New(hashFn, []byte("secret"))
+	mac.Write([]byte(signedString))
+
+	return encode(mac.Sum(nil))
+}
@@ -0,0 +1,243 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/go-jose/go-jose/v4"
+	"github.com/rs/zerolog/log"
+	"github.com/traefik/traefik/v3/pkg/types"
+)
+
+const (
+	defaultJWKSRefreshInterval = time.Hour
+	// minJWKSRefreshInterval is the minimum interval between two fetches of the key set,
+	// so that tokens signed by unknown keys cannot flood the key set server.
+	minJWKSRefreshInterval = 10 * time.Second
+	maxJWKSSize            = 1 << 20
+)
+
+// keySet holds the public keys verifying the token signatures.
+// The keys of a remote JSON Web Key Set are fetched lazily, and refreshed periodically
+// or when a token is signed by an unknown key.
+type keySet struct {
+	// staticKeys are the keys which cannot be selected by key ID.
+	staticKeys []crypto.PublicKey
+	// fileKeys are the keys of the JSON Web Key Set file.
+	fileKeys []jose.JSONWebKey
+
+	url             string
+	client          *http.Client
+	refreshInterval time.Duration
+
+	// fetchMu ensures that the key set is fetched once at a time.
+	fetchMu sync.Mutex
+	mu      sync.RWMutex
+	urlKeys []jose.JSONWebKey
+	// fetchedAt is the time of the last successful fetch.
+	fetchedAt time.Time
+	// attemptedAt is the time of the last fetch, successful or not.
+	attemptedAt time.Time
+}
+
+func newKeySet(publicKeys []types.FileOrContent, jwksFile, jwksURL string, refreshInterval time.Duration, client *http.Client) (*keySet, error) {
+	ks := &keySet{
+		url:             jwksURL,
+		client:          client,
+		refreshInterval: refreshInterval,
+	}
+
+	if ks.refreshInterval <= 0 {
+		ks.refreshInterval = defaultJWKSRefreshInterval
+	}
+
+	for i, publicKey := range publicKeys {
+		content, err := publicKey.Read()
+		if err != nil {
+			return nil, fmt.Errorf("reading public key %d: %w", i, err)
+		}
+
+		key, err := parsePublicKey(content)
+		if err != nil {
+			return nil, fmt.Errorf("parsing public key %d: %w", i, err)
+		}
+		ks.staticKeys = append(ks.staticKeys, key)
+	}
+
+	if jwksFile != "" {
+		content, err := os.ReadFile(jwksFile)
+		if err != nil {
+			return nil, fmt.Errorf("reading JWKS file: %w", err)
+		}
+
+		ks.fileKeys, err = parseJWKS(content)
+		if err != nil {
+			return nil, fmt.Errorf("parsing JWKS file: %w", err)
+		}
+	}
+
+	return ks, nil
+}
+
+// empty returns whether no key can verify asymmetric signatures.
+func (ks *keySet) empty() bool {
+	return len(ks.staticKeys) == 0 && len(ks.fileKeys) == 0 && ks.url == ""
+}
+
+// keys returns the keys which may verify the signature of a token signed by the key with the given ID.
+func (ks *keySet) keys(ctx context.Context, kid string) []crypto.PublicKey {
+	keys := slices.Concat(ks.staticKeys, selectKeys(ks.fileKeys, kid))
+
+	if ks.url == "" {
+		return keys
+	}
+
+	ks.mu.RLock()
+	urlKeys, fetchedAt, attemptedAt := ks.urlKeys, ks.fetchedAt, ks.attemptedAt
+	ks.mu.RUnlock()
+
+	selected := selectKeys(urlKeys, kid)
+
+	// The key set is fetched when it is stale,
+	// or when it does not hold the signing key, which may have been rotated.
+	// In both cases, it is not fetched more often than the minimum refresh interval.
+	stale := time.Since(fetchedAt) > ks.refreshInterval
+	unknownKey := len(selected) == 0
+	if (stale || unknownKey) && time.Since(attemptedAt) > minJWKSRefreshInterval {
+		if ks.refresh(ctx, attemptedAt) {
+			ks.mu.RLock()
+			selected = selectKeys(ks.urlKeys, kid)
+			ks.mu.RUnlock()
+		}
+	}
+
+	return append(keys, selected...)
+}
+
+// refresh fetches the key set, unless it has been fetched since the given time.
+// It returns whether the keys may have been updated.
+func (ks *keySet) refresh(ctx context.Context, since time.Time) bool {
+	ks.fetchMu.Lock()
+	defer ks.fetchMu.Unlock()
+
+	ks.mu.RLock()
+	attemptedAt := ks.attemptedAt
+	ks.mu.RUnlock()
+
+	// The key set has been fetched while waiting for the lock.
+	if attemptedAt.After(since) {
+		return true
+	}
+
+	keys, err := ks.fetch(ctx)
+
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+
+	ks.attemptedAt = time.Now()
+	// The previous keys are kept when the fetch fails.
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Str("url", ks.url).Msg("Unable to fetch the JSON Web Key Set")
+		return false
+	}
+
+	ks.urlKeys = keys
+	ks.fetchedAt = time.Now()
+	return true
+}
+
+func (ks *keySet) fetch(ctx context.Context) ([]jose.JSONWebKey, error) {
+	// The fetch is not canceled along with the request triggering it, as the keys are shared by the requests.
+	req, err := http.NewRequestWithContext(context.WithoutCancel(ctx), http.MethodGet, ks.url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+
+	resp, err := ks.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("sending request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+
+	content, err := io.ReadAll(io.LimitReader(resp.Body, maxJWKSSize))
+	if err != nil {
+		return nil, fmt.Errorf("reading response: %w", err)
+	}
+
+	return parseJWKS(content)
+}
+
+// parseJWKS parses a JSON Web Key Set, keeping the public signature keys.
+func parseJWKS(content []byte) ([]jose.JSONWebKey, error) {
+	var jwks jose.JSONWebKeySet
+	if err := json.Unmarshal(content, &jwks); err != nil {
+		return nil, err
+	}
+
+	var keys []jose.JSONWebKey
+	for _, key := range jwks.Keys {
+		if key.Use != "" && key.Use != "sig" {
+			continue
+		}
+
+		// Symmetric keys are not expected in a key set, and private keys are reduced to their public part.
+		public := key.Public()
+		if !public.Valid() {
+			continue
+		}
+		keys = append(keys, public)
+	}
+
+	if len(keys) == 0 {
+		return nil, errors.New("no public signature key")
+	}
+
+	return keys, nil
+}
+
+// selectKeys returns the keys with the given ID, or all the keys when the ID is empty.
+func selectKeys(keys []jose.JSONWebKey, kid string) []crypto.PublicKey {
+	var selected []crypto.PublicKey
+	for _, key := range keys {
+		if kid == "" || key.KeyID == kid {
+			selected = append(selected, key.Key)
+		}
+	}
+	return selected
+}
+
+// parsePublicKey parses a PEM-encoded public key or certificate.
+func parsePublicKey(content []byte) (crypto.PublicKey, error) {
+	block, _ := pem.Decode(content)
+	if block == nil {
+		return nil, errors.New("no PEM data")
+	}
+
+	switch block.Type {
+	case "CERTIFICATE":
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		return cert.PublicKey, nil
+	case "RSA PUBLIC KEY":
+		return x509.ParsePKCS1PublicKey(block.Bytes)
+	default:
+		return x509.ParsePKIXPublicKey(block.Bytes)
+	}
+}
@@ -0,0 +1,266 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/traefik/traefik/v3/pkg/config/dynamic"
+	"github.com/traefik/traefik/v3/pkg/middlewares"
+	"github.com/traefik/traefik/v3/pkg/middlewares/accesslog"
+	"github.com/traefik/traefik/v3/pkg/middlewares/observability"
+	"github.com/traefik/traefik/v3/pkg/types"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const typeNameJWT = "JWT"
+
+var (
+	hmacAlgorithms       = []string{"HS256", "HS384", "HS512"}
+	asymmetricAlgorithms = []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512", "EdDSA"}
+)
+
+type jwtAuth struct {
+	next         http.Handler
+	name         string
+	headerName   string
+	removeHeader bool
+
+	parser    *jwt.Parser
+	secret    []byte
+	keys      *keySet
+	issuers   []string
+	audiences []string
+
+	claims        *claimsTree
+	forwardClaims map[string][]string
+}
+
+// NewJWT creates a JWT middleware.
+func NewJWT(ctx context.Context, next http.Handler, config dynamic.JWT, name string) (http.Handler, error) {
+	middlewares.GetLogger(ctx, name, typeNameJWT).Debug().Msg("Creating middleware")
+
+	j := &jwtAuth{
+		next:          next,
+		name:          name,
+		headerName:    config.HeaderName,
+		removeHeader:  config.RemoveHeader,
+		issuers:       config.Issuers,
+		audiences:     config.Audiences,
+		forwardClaims: make(map[string][]string),
+	}
+
+	if j.headerName == "" {
+		j.headerName = authorizationHeader
+	}
+
+	if config.Secret != "" {
+		j.secret = []byte(config.Secret)
+	}
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	if config.TLS != nil {
+		clientTLS := &types.ClientTLS{
+			CA:                 config.TLS.CA,
+			Cert:               config.TLS.Cert,
+			Key:                config.TLS.Key,
+			InsecureSkipVerify: config.TLS.InsecureSkipVerify,
+		}
+
+		tlsConfig, err := clientTLS.CreateTLSConfig(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("unable to create client TLS configuration: %w", err)
+		}
+
+		tr := http.DefaultTransport.(*http.Transport).Clone()
+		tr.TLSClientConfig = tlsConfig
+		client.Transport = tr
+	}
+
+	var err error
+	j.keys, err = newKeySet(config.PublicKeys, config.JWKSFile, config.JWKSURL, time.Duration(config.JWKSRefreshInterval), client)
+	if err != nil {
+		return nil, err
+	}
+
+	if j.secret == nil && j.keys.empty() {
+		return nil, errors.New("a secret, public keys or a JSON Web Key Set is required")
+	}
+
+	algorithms := config.Algorithms
+	if len(algorithms) == 0 {
+		if j.secret != nil {
+			algorithms = append(algorithms, hmacAlgorithms...)
+		}
+		if !j.keys.empty() {
+			algorithms = append(algorithms, asymmetricAlgorithms...)
+		}
+	}
+
+	for _, algorithm := range algorithms {
+		switch {
+		case slices.Contains(hmacAlgorithms, algorithm):
+			if j.secret == nil {
+				return nil, fmt.Errorf("algorithm %s requires a secret", algorithm)
+			}
+		case slices.Contains(asymmetricAlgorithms, algorithm):
+			if j.keys.empty() {
+				return nil, fmt.Errorf("algorithm %s requires public keys or a JSON Web Key Set", algorithm)
+			}
+		default:
+			return nil, fmt.Errorf("unsupported algorithm %s", algorithm)
+		}
+	}
+
+	j.parser = jwt.NewParser(
+		jwt.WithValidMethods(algorithms),
+		jwt.WithLeeway(time.Duration(config.ClockSkew)),
+		jwt.WithJSONNumber(),
+	)
+
+	if config.Claims != "" {
+		j.claims, err = newClaimsTree(config.Claims)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for header, claim := range config.ForwardClaims {
+		j.forwardClaims[http.CanonicalHeaderKey(header)] = strings.Split(claim, ".")
+	}
+
+	return j, nil
+}
+
+func (j *jwtAuth) GetTracingInformation() (string, string, trace.SpanKind) {
+	return j.name, typeNameJWT, trace.SpanKindInternal
+}
+
+func (j *jwtAuth) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	logger := middlewares.GetLogger(req.Context(), j.name, typeNameJWT)
+
+	rawToken := j.extractToken(req)
+	if rawToken == "" {
+		logger.Debug().Msg("Missing token")
+		observability.SetStatusErrorf(req.Context(), "Missing token")
+
+		rw.Header().Set("WWW-Authenticate", fmt.Sprintf("Bearer realm=%q", defaultRealm))
+		http.Error(rw, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+		return
+	}
+
+	claims := jwt.MapClaims{}
+	_, err := j.parser.ParseWithClaims(rawToken, claims, func(token *jwt.Token) (any, error) {
+		return j.verificationKeys(req.Context(), token)
+	})
+	if err == nil {
+		err = j.validateClaims(claims)
+	}
+	if err != nil {
+		logger.Debug().Err(err).Msg("Invalid token")
+		observability.SetStatusErrorf(req.Context(), "Invalid token: %s", err)
+
+		rw.Header().Set("WWW-Authenticate", fmt.Sprintf("Bearer realm=%q, error=\"invalid_token\"", defaultRealm))
+		http.Error(rw, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+		return
+	}
+
+	if logData := accesslog.GetLogData(req); logData != nil {
+		if subject, ok := claims["sub"].(string); ok {
+			logData.Core[accesslog.ClientUsername] = subject
+		}
+	}
+
+	if j.claims != nil && !j.claims.match(claims) {
+		logger.Debug().Msg("Token claims do not match")
+		observability.SetStatusErrorf(req.Context(), "Token claims do not match")
+
+		http.Error(rw, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+		return
+	}
+
+	logger.Debug().Msg("Authentication succeeded")
+
+	if j.removeHeader {
+		req.Header.Del(j.headerName)
+	}
+
+	for header, path := range j.forwardClaims {
+		// The header is always removed, so that it cannot be forged by the client.
+		req.Header.Del(header)
+
+		if value, ok := lookupClaim(claims, path); ok {
+			req.Header.Set(header, claimHeaderValue(value))
+		}
+	}
+
+	req = req.WithContext(middlewares.WithJWTClaims(req.Context(), claims))
+
+	j.next.ServeHTTP(rw, req)
+}
+
+// extractToken returns the token of the request,
+// which is expected with the Bearer scheme in the Authorization header.
+func (j *jwtAuth) extractToken(req *http.Request) string {
+	value := strings.TrimSpace(req.Header.Get(j.headerName))
+	if !strings.EqualFold(j.headerName, authorizationHeader) {
+		return value
+	}
+
+	scheme, token, ok := strings.Cut(value, " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return ""
+	}
+	return strings.TrimSpace(token)
+}
+
+// verificationKeys returns the keys which may verify the token signature.
+// The secret is only used for the HMAC algorithms, so that a public key cannot be used as an HMAC secret.
+func (j *jwtAuth) verificationKeys(ctx context.Context, token *jwt.Token) (any, error) {
+	if _, ok := token.Method.(*jwt.SigningMethodHMAC); ok {
+		return j.secret, nil
+	}
+
+	kid, _ := token.Header["kid"].(string)
+
+	var keys jwt.VerificationKeySet
+	for _, key := range j.keys.keys(ctx, kid) {
+		keys.Keys = append(keys.Keys, key)
+	}
+
+	if len(keys.Keys) == 0 {
+		return nil, fmt.Errorf("no key found for key ID %q", kid)
+	}
+	return keys, nil
+}
+
+func (j *jwtAuth) validateClaims(claims jwt.MapClaims) error {
+	if len(j.issuers) > 0 {
+		issuer, err := claims.GetIssuer()
+		if err != nil {
+			return err
+		}
+		if !slices.Contains(j.issuers, issuer) {
+			return fmt.Errorf("issuer %q is not allowed", issuer)
+		}
+	}
+
+	if len(j.audiences) > 0 {
+		audiences, err := claims.GetAudience()
+		if err != nil {
+			return err
+		}
+		if !slices.ContainsFunc(audiences, func(audience string) bool {
+			return slices.Contains(j.audiences, audience)
+		}) {
+			return fmt.Errorf("audiences %q are not allowed", []string(audiences))
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,253 @@
+package auth
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/traefik/traefik/v3/pkg/rules"
+)
+
+// claimsMatcherFunc matches the claims of a token.
+type claimsMatcherFunc func(claims map[string]any) bool
+
+var claimsMatcherFuncs = map[string]func(values ...string) (claimsMatcherFunc, error){
+	"Claim":         claimMatcher,
+	"ClaimContains": claimContainsMatcher,
+	"ClaimRegexp":   claimRegexpMatcher,
+}
+
+// claimsTree represents the tree structure of a claims expression.
+type claimsTree struct {
+	// matcher is set when the tree is a leaf, and is then mutually exclusive with left and right.
+	matcher  claimsMatcherFunc
+	operator string
+	left     *claimsTree
+	right    *claimsTree
+}
+
+// newClaimsTree parses the claims expression.
+func newClaimsTree(expression string) (*claimsTree, error) {
+	var matchers []string
+	for matcher := range claimsMatcherFuncs {
+		matchers = append(matchers, matcher)
+	}
+
+	parser, err := rules.NewParser(matchers)
+	if err != nil {
+		return nil, fmt.Errorf("creating parser: %w", err)
+	}
+
+	parse, err := parser.Parse(expression)
+	if err != nil {
+		return nil, fmt.Errorf("parsing claims expression %s: %w", expression, err)
+	}
+
+	buildTree, ok := parse.(rules.TreeBuilder)
+	if !ok {
+		return nil, fmt.Errorf("parsing claims expression %s", expression)
+	}
+
+	tree := &claimsTree{}
+	if err := tree.addRule(buildTree()); err != nil {
+		return nil, err
+	}
+
+	return tree, nil
+}
+
+func (t *claimsTree) addRule(rule *rules.Tree) error {
+	switch rule.Matcher {
+	case "and", "or":
+		t.operator = rule.Matcher
+		t.left = &claimsTree{}
+		if err := t.left.addRule(rule.RuleLeft); err != nil {
+			return err
+		}
+
+		t.right = &claimsTree{}
+		return t.right.addRule(rule.RuleRight)
+	default:
+		if err := rules.CheckRule(rule); err != nil {
+			return fmt.Errorf("checking matcher %s: %w", rule.Matcher, err)
+		}
+
+		newMatcher, ok := claimsMatcherFuncs[rule.Matcher]
+		if !ok {
+			return fmt.Errorf("unknown matcher %s", rule.Matcher)
+		}
+
+		matcher, err := newMatcher(rule.Value...)
+		if err != nil {
+			return fmt.Errorf("creating matcher %s: %w", rule.Matcher, err)
+		}
+
+		if rule.Not {
+			t.matcher = func(claims map[string]any) bool {
+				return !matcher(claims)
+			}
+			return nil
+		}
+		t.matcher = matcher
+	}
+
+	return nil
+}
+
+func (t *claimsTree) match(claims map[string]any) bool {
+	if t.matcher != nil {
+		return t.matcher(claims)
+	}
+
+	switch t.operator {
+	case "or":
+		return t.left.match(claims) || t.right.match(claims)
+	case "and":
+		return t.left.match(claims) && t.right.match(claims)
+	default:
+		return false
+	}
+}
+
+// claimMatcher matches the claims whose value, or one of whose elements, equals one of the given values.
+func claimMatcher(values ...string) (claimsMatcherFunc, error) {
+	if len(values) < 2 {
+		return nil, fmt.Errorf("expected a claim name and at least one value, got %v", values)
+	}
+
+	path := strings.Split(values[0], ".")
+	expected := values[1:]
+
+	return func(claims map[string]any) bool {
+		return slices.ContainsFunc(claimValues(claims, path), func(value string) bool {
+			return slices.Contains(expected, value)
+		})
+	}, nil
+}
+
+// claimContainsMatcher matches the claims holding the given value as an element,
+// or as a word of a space-separated string, such as the scope claim.
+func claimContainsMatcher(values ...string) (claimsMatcherFunc, error) {
+	if len(values) != 2 {
+		return nil, fmt.Errorf("expected a claim name and a value, got %v", values)
+	}
+
+	path := strings.Split(values[0], ".")
+	expected := values[1]
+
+	return func(claims map[string]any) bool {
+		for _, value := range claimValues(claims, path) {
+			if slices.Contains(strings.Fields(value), expected) {
+				return true
+			}
+		}
+		return false
+	}, nil
+}
+
+// claimRegexpMatcher matches the claims whose value, or one of whose elements, matches the given regular expression.
+func claimRegexpMatcher(values ...string) (claimsMatcherFunc, error) {
+	if len(values) != 2 {
+		return nil, fmt.Errorf("expected a claim name and a regular expression, got %v", values)
+	}
+
+	path := strings.Split(values[0], ".")
+
+	re, err := regexp.Compile(values[1])
+	if err != nil {
+		return nil, fmt.Errorf("compiling regular expression %s: %w", values[1], err)
+	}
+
+	return func(claims map[string]any) bool {
+		return slices.ContainsFunc(claimValues(claims, path), re.MatchString)
+	}, nil
+}
+
+// lookupClaim returns the value of the claim at the given path.
+func lookupClaim(claims map[string]any, path []string) (any, bool) {
+	var value any = claims
+	for _, name := range path {
+		object, ok := value.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+
+		value, ok = object[name]
+		if !ok {
+			return nil, false
+		}
+	}
+	return value, true
+}
+
+// claimValues returns the string representations of the claim value, or of its elements when it is an array.
+func claimValues(claims map[string]any, path []string) []string {
+	value, ok := lookupClaim(claims, path)
+	if !ok {
+		return nil
+	}
+
+	if elements, ok := value.([]any); ok {
+		var values []string
+		for _, element := range elements {
+			if s, ok := claimString(element); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	}
+
+	if s, ok := claimString(value); ok {
+		return []string{s}
+	}
+	return nil
+}
+
+// claimString returns the string representation of a scalar claim value.
+func claimString(value any) (string, bool) {
+	switch v := value.(type) {
+	case string:
+		return v, true
+	case bool:
+		return strconv.FormatBool(v), true
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), true
+	case json.Number:
+		return v.String(), true
+	default:
+		return "", false
+	}
+}
+
+// claimHeaderValue returns the header value forwarding the claim value:
+// strings as-is, arrays as comma-separated values, and objects as JSON.
+func claimHeaderValue(value any) string {
+	if s, ok := claimString(value); ok {
+		return s
+	}
+
+	if elements, ok := value.([]any); ok {
+		var values []string
+		for _, element := range elements {
+			if s, ok := claimString(element); ok {
+				values = append(values, s)
+				continue
+			}
+			values = append(values, jsonClaim(element))
+		}
+		return strings.Join(values, ",")
+	}
+
+	return jsonClaim(value)
+}
+
+func jsonClaim(value any) string {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
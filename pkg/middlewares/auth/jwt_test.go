@@ -0,0 +1,539 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-jose/go-jose/v4"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	ptypes "github.com/traefik/paerser/types"
+	"github.com/traefik/traefik/v3/pkg/config/dynamic"
+	"github.com/traefik/traefik/v3/pkg/middlewares"
+	"github.com/traefik/traefik/v3/pkg/types"
+)
+
+func TestJWT_secret(t *testing.T) {
+	testCases := []struct {
+		desc            string
+		config          dynamic.JWT
+		header          string
+		token           string
+		claims          jwt.MapClaims
+		expectedCode    int
+		expectedWWWAuth string
+	}{
+		{
+			desc:            "missing token",
+			config:          dynamic.JWT{Secret: "secret"},
+			expectedCode:    http.StatusUnauthorized,
+			expectedWWWAuth: `Bearer realm="traefik"`,
+		},
+		{
+			desc:            "invalid token",
+			config:          dynamic.JWT{Secret: "secret"},
+			token:           "foo.bar.baz",
+			expectedCode:    http.StatusUnauthorized,
+			expectedWWWAuth: `Bearer realm="traefik", error="invalid_token"`,
+		},
+		{
+			desc:         "valid token",
+			config:       dynamic.JWT{Secret: "secret"},
+			claims:       jwt.MapClaims{"sub": "foo"},
+			expectedCode: http.StatusOK,
+		},
+		{
+			desc:            "wrong secret",
+			config:          dynamic.JWT{Secret: "other"},
+			claims:          jwt.MapClaims{"sub": "foo"},
+			expectedCode:    http.StatusUnauthorized,
+			expectedWWWAuth: `Bearer realm="traefik", error="invalid_token"`,
+		},
+		{
+			desc:         "expired token",
+			config:       dynamic.JWT{Secret: "secret"},
+			claims:       jwt.MapClaims{"exp": time.Now().Add(-time.Minute).Unix()},
+			expectedCode: http.StatusUnauthorized,
+		},
+		{
+			desc:         "expired token within clock skew",
+			config:       dynamic.JWT{Secret: "secret", ClockSkew: ptypes.Duration(5 * time.Minute)},
+			claims:       jwt.MapClaims{"exp": time.Now().Add(-time.Minute).Unix()},
+			expectedCode: http.StatusOK,
+		},
+		{
+			desc:         "token not yet valid",
+			config:       dynamic.JWT{Secret: "secret"},
+			claims:       jwt.MapClaims{"nbf": time.Now().Add(time.Hour).Unix()},
+			expectedCode: http.StatusUnauthorized,
+		},
+		{
+			desc:         "allowed issuer",
+			config:       dynamic.JWT{Secret: "secret", Issuers: []string{"foo", "bar"}},
+			claims:       jwt.MapClaims{"iss": "bar"},
+			expectedCode: http.StatusOK,
+		},
+		{
+			desc:         "disallowed issuer",
+			config:       dynamic.JWT{Secret: "secret", Issuers: []string{"foo"}},
+			claims:       jwt.MapClaims{"iss": "bar"},
+			expectedCode: http.StatusUnauthorized,
+		},
+		{
+			desc:         "missing issuer",
+			config:       dynamic.JWT{Secret: "secret", Issuers: []string{"foo"}},
+			claims:       jwt.MapClaims{},
+			expectedCode: http.StatusUnauthorized,
+		},
+		{
+			desc:         "allowed audience",
+			config:       dynamic.JWT{Secret: "secret", Audiences: []string{"api"}},
+			claims:       jwt.MapClaims{"aud": []string{"web", "api"}},
+			expectedCode: http.StatusOK,
+		},
+		{
+			desc:         "disallowed audience",
+			config:       dynamic.JWT{Secret: "secret", Audiences: []string{"api"}},
+			claims:       jwt.MapClaims{"aud": "web"},
+			expectedCode: http.StatusUnauthorized,
+		},
+		{
+			desc:         "disallowed algorithm",
+			config:       dynamic.JWT{Secret: "secret", Algorithms: []string{"HS512"}},
+			claims:       jwt.MapClaims{},
+			expectedCode: http.StatusUnauthorized,
+		},
+		{
+			desc:         "custom header",
+			config:       dynamic.JWT{Secret: "secret", HeaderName: "X-Token"},
+			header:       "X-Token",
+			claims:       jwt.MapClaims{},
+			expectedCode: http.StatusOK,
+		},
+		{
+			desc:         "token in authorization header without bearer scheme",
+			config:       dynamic.JWT{Secret: "secret"},
+			header:       "Authorization-Raw",
+			claims:       jwt.MapClaims{},
+			expectedCode: http.StatusUnauthorized,
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			handler, err := NewJWT(t.Context(), http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {}), test.config, "jwt")
+			require.NoError(t, err)
+
+			token := test.token
+			if test.claims != nil {
+				token = signToken(t, jwt.SigningMethodHS256, []byte("secret"), "", test.claims)
+			}
+
+			req := httptest.NewRequest(http.MethodGet, "http://foo", nil)
+			switch test.header {
+			case "":
+				if token != "" {
+					req.Header.Set("Authorization", "Bearer "+token)
+				}
+			case "Authorization-Raw":
+				req.Header.Set("Authorization", token)
+			default:
+				req.Header.Set(test.header, token)
+			}
+
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, req)
+
+			assert.Equal(t, test.expectedCode, recorder.Code)
+			if test.expectedWWWAuth != "" {
+				assert.Equal(t, test.expectedWWWAuth, recorder.Header().Get("WWW-Authenticate"))
+			}
+		})
+	}
+}
+
+func TestJWT_publicKeys(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		desc   string
+		method jwt.SigningMethod
+		key    crypto.Signer
+	}{
+		{desc: "RSA", method: jwt.SigningMethodRS256, key: rsaKey},
+		{desc: "RSA-PSS", method: jwt.SigningMethodPS384, key: rsaKey},
+		{desc: "ECDSA", method: jwt.SigningMethodES256, key: ecKey},
+		{desc: "EdDSA", method: jwt.SigningMethodEdDSA, key: edKey},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			der, err := x509.MarshalPKIXPublicKey(test.key.Public())
+			require.NoError(t, err)
+			publicKey := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+
+			handler, err := NewJWT(t.Context(), http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {}), dynamic.JWT{
+				PublicKeys: []types.FileOrContent{types.FileOrContent(publicKey)},
+			}, "jwt")
+			require.NoError(t, err)
+
+			assert.Equal(t, http.StatusOK, serveToken(handler, signToken(t, test.method, test.key, "", jwt.MapClaims{})))
+
+			// A token signed with the public key as an HMAC secret must be rejected.
+			assert.Equal(t, http.StatusUnauthorized, serveToken(handler, signToken(t, jwt.SigningMethodHS256, publicKey, "", jwt.MapClaims{})))
+		})
+	}
+}
+
+func TestJWT_JWKSFile(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	require.NoError(t, err)
+	otherKey, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	require.NoError(t, err)
+
+	jwksFile := filepath.Join(t.TempDir(), "jwks.json")
+	// The private key is reduced to its public part.
+	err = os.WriteFile(jwksFile, marshalJWKS(t, jose.JSONWebKey{Key: key, KeyID: "foo", Use: "sig"}), 0o600)
+	require.NoError(t, err)
+
+	handler, err := NewJWT(t.Context(), http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {}), dynamic.JWT{JWKSFile: jwksFile}, "jwt")
+	require.NoError(t, err)
+
+	assert.Equal(t, http.StatusOK, serveToken(handler, signToken(t, jwt.SigningMethodES384, key, "foo", jwt.MapClaims{})))
+	assert.Equal(t, http.StatusOK, serveToken(handler, signToken(t, jwt.SigningMethodES384, key, "", jwt.MapClaims{})))
+	assert.Equal(t, http.StatusUnauthorized, serveToken(handler, signToken(t, jwt.SigningMethodES384, key, "bar", jwt.MapClaims{})))
+	assert.Equal(t, http.StatusUnauthorized, serveToken(handler, signToken(t, jwt.SigningMethodES384, otherKey, "foo", jwt.MapClaims{})))
+}
+
+func TestJWT_JWKSURL(t *testing.T) {
+	oldKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	newKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	var (
+		mu      sync.Mutex
+		jwks    = marshalJWKS(t, jose.JSONWebKey{Key: oldKey.Public(), KeyID: "old"})
+		fetches atomic.Int32
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		fetches.Add(1)
+
+		mu.Lock()
+		defer mu.Unlock()
+		_, _ = rw.Write(jwks)
+	}))
+	t.Cleanup(server.Close)
+
+	handler, err := NewJWT(t.Context(), http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {}), dynamic.JWT{JWKSURL: server.URL}, "jwt")
+	require.NoError(t, err)
+
+	// The key set is fetched lazily, and cached.
+	assert.Equal(t, int32(0), fetches.Load())
+	assert.Equal(t, http.StatusOK, serveToken(handler, signToken(t, jwt.SigningMethodRS256, oldKey, "old", jwt.MapClaims{})))
+	assert.Equal(t, http.StatusOK, serveToken(handler, signToken(t, jwt.SigningMethodRS256, oldKey, "old", jwt.MapClaims{})))
+	assert.Equal(t, int32(1), fetches.Load())
+
+	mu.Lock()
+	jwks = marshalJWKS(t, jose.JSONWebKey{Key: newKey.Public(), KeyID: "new"})
+	mu.Unlock()
+
+	// The key set is not fetched again before the minimum refresh interval.
+	assert.Equal(t, http.StatusUnauthorized, serveToken(handler, signToken(t, jwt.SigningMethodRS256, newKey, "new", jwt.MapClaims{})))
+	assert.Equal(t, int32(1), fetches.Load())
+
+	// The key set is fetched again once a token is signed by an unknown key.
+	jwtHandler := handler.(*jwtAuth)
+	jwtHandler.keys.mu.Lock()
+	jwtHandler.keys.attemptedAt = time.Now().Add(-minJWKSRefreshInterval)
+	jwtHandler.keys.mu.Unlock()
+
+	assert.Equal(t, http.StatusOK, serveToken(handler, signToken(t, jwt.SigningMethodRS256, newKey, "new", jwt.MapClaims{})))
+	assert.Equal(t, http.StatusUnauthorized, serveToken(handler, signToken(t, jwt.SigningMethodRS256, oldKey, "old", jwt.MapClaims{})))
+	assert.Equal(t, int32(2), fetches.Load())
+}
+
+func TestJWT_JWKSURLUnavailable(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	var available atomic.Bool
+	available.Store(true)
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if !available.Load() {
+			rw.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		_, _ = rw.Write(marshalJWKS(t, jose.JSONWebKey{Key: key.Public(), KeyID: "foo"}))
+	}))
+	t.Cleanup(server.Close)
+
+	handler, err := NewJWT(t.Context(), http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {}), dynamic.JWT{JWKSURL: server.URL}, "jwt")
+	require.NoError(t, err)
+
+	token := signToken(t, jwt.SigningMethodRS256, key, "foo", jwt.MapClaims{})
+	assert.Equal(t, http.StatusOK, serveToken(handler, token))
+
+	// The previous keys are kept when the key set cannot be refreshed.
+	available.Store(false)
+	jwtHandler := handler.(*jwtAuth)
+	jwtHandler.keys.mu.Lock()
+	jwtHandler.keys.fetchedAt = time.Now().Add(-2 * defaultJWKSRefreshInterval)
+	jwtHandler.keys.attemptedAt = jwtHandler.keys.fetchedAt
+	jwtHandler.keys.mu.Unlock()
+
+	assert.Equal(t, http.StatusOK, serveToken(handler, token))
+
+	jwtHandler.keys.mu.RLock()
+	defer jwtHandler.keys.mu.RUnlock()
+	assert.WithinDuration(t, time.Now(), jwtHandler.keys.attemptedAt, time.Minute)
+}
+
+func TestJWT_claims(t *testing.T) {
+	claims := jwt.MapClaims{
+		"sub":   "foo",
+		"role":  "admin",
+		"scope": "read write",
+		"groups": []string{
+			"dev", "ops",
+		},
+		"level": 3,
+		"org": map[string]any{
+			"name": "traefik",
+		},
+	}
+
+	testCases := []struct {
+		desc         string
+		expression   string
+		expectedCode int
+	}{
+		{
+			desc:         "claim",
+			expression:   "Claim(`role`, `user`, `admin`)",
+			expectedCode: http.StatusOK,
+		},
+		{
+			desc:         "claim mismatch",
+			expression:   "Claim(`role`, `user`)",
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			desc:         "claim array element",
+			expression:   "Claim(`groups`, `ops`)",
+			expectedCode: http.StatusOK,
+		},
+		{
+			desc:         "numeric claim",
+			expression:   "Claim(`level`, `3`)",
+			expectedCode: http.StatusOK,
+		},
+		{
+			desc:         "nested claim",
+			expression:   "Claim(`org.name`, `traefik`)",
+			expectedCode: http.StatusOK,
+		},
+		{
+			desc:         "missing claim",
+			expression:   "Claim(`missing`, `foo`)",
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			desc:         "claim contains",
+			expression:   "ClaimContains(`scope`, `write`)",
+			expectedCode: http.StatusOK,
+		},
+		{
+			desc:         "claim does not contain",
+			expression:   "ClaimContains(`scope`, `delete`)",
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			desc:         "claim regexp",
+			expression:   "ClaimRegexp(`sub`, `^f.o$`)",
+			expectedCode: http.StatusOK,
+		},
+		{
+			desc:         "and",
+			expression:   "Claim(`role`, `admin`) && ClaimContains(`groups`, `dev`)",
+			expectedCode: http.StatusOK,
+		},
+		{
+			desc:         "or",
+			expression:   "Claim(`role`, `user`) || ClaimContains(`groups`, `dev`)",
+			expectedCode: http.StatusOK,
+		},
+		{
+			desc:         "not",
+			expression:   "!Claim(`role`, `admin`)",
+			expectedCode: http.StatusForbidden,
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			handler, err := NewJWT(t.Context(), http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {}), dynamic.JWT{
+				Secret: "secret",
+				Claims: test.expression,
+			}, "jwt")
+			require.NoError(t, err)
+
+			assert.Equal(t, test.expectedCode, serveToken(handler, signToken(t, jwt.SigningMethodHS256, []byte("secret"), "", claims)))
+		})
+	}
+}
+
+func TestJWT_forwardClaims(t *testing.T) {
+	next := http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, "foo", req.Header.Get("X-User"))
+		assert.Equal(t, "dev,ops", req.Header.Get("X-Groups"))
+		assert.JSONEq(t, `{"name":"traefik"}`, req.Header.Get("X-Org"))
+		assert.Equal(t, "traefik", req.Header.Get("X-Org-Name"))
+		assert.Equal(t, "3", req.Header.Get("X-Level"))
+		// The header of a missing claim is removed.
+		assert.Empty(t, req.Header.Values("X-Email"))
+		assert.Empty(t, req.Header.Get("Authorization"))
+		// The claims are available to the next middlewares.
+		assert.Equal(t, "foo", middlewares.GetJWTClaims(req.Context())["sub"])
+	})
+
+	handler, err := NewJWT(t.Context(), next, dynamic.JWT{
+		Secret: "secret",
+		ForwardClaims: map[string]string{
+			"X-User":     "sub",
+			"X-Groups":   "groups",
+			"X-Org":      "org",
+			"X-Org-Name": "org.name",
+			"X-Level":    "level",
+			"X-Email":    "email",
+		},
+		RemoveHeader: true,
+	}, "jwt")
+	require.NoError(t, err)
+
+	token := signToken(t, jwt.SigningMethodHS256, []byte("secret"), "", jwt.MapClaims{
+		"sub":    "foo",
+		"groups": []string{"dev", "ops"},
+		"org":    map[string]any{"name": "traefik"},
+		"level":  3,
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "http://foo", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("X-User", "forged")
+	req.Header.Set("X-Email", "forged@example.com")
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, req)
+
+	assert.Equal(t, http.StatusOK, recorder.Code)
+}
+
+func TestNewJWT_invalidConfig(t *testing.T) {
+	testCases := []struct {
+		desc   string
+		config dynamic.JWT
+	}{
+		{
+			desc:   "no key",
+			config: dynamic.JWT{},
+		},
+		{
+			desc:   "HMAC algorithm without secret",
+			config: dynamic.JWT{JWKSURL: "http://foo", Algorithms: []string{"HS256"}},
+		},
+		{
+			desc:   "asymmetric algorithm without public key",
+			config: dynamic.JWT{Secret: "secret", Algorithms: []string{"RS256"}},
+		},
+		{
+			desc:   "unsupported algorithm",
+			config: dynamic.JWT{Secret: "secret", Algorithms: []string{"none"}},
+		},
+		{
+			desc:   "invalid public key",
+			config: dynamic.JWT{PublicKeys: []types.FileOrContent{"foo"}},
+		},
+		{
+			desc:   "missing JWKS file",
+			config: dynamic.JWT{JWKSFile: "/does/not/exist.json"},
+		},
+		{
+			desc:   "invalid claims expression",
+			config: dynamic.JWT{Secret: "secret", Claims: "Unknown(`foo`)"},
+		},
+		{
+			desc:   "invalid claim regexp",
+			config: dynamic.JWT{Secret: "secret", Claims: "ClaimRegexp(`foo`, `(`)"},
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := NewJWT(t.Context(), http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {}), test.config, "jwt")
+			assert.Error(t, err)
+		})
+	}
+}
+
+func signToken(t *testing.T, method jwt.SigningMethod, key any, kid string, claims jwt.MapClaims) string {
+	t.Helper()
+
+	token := jwt.NewWithClaims(method, claims)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+
+	signed, err := token.SignedString(key)
+	require.NoError(t, err)
+
+	return signed
+}
+
+func serveToken(handler http.Handler, token string) int {
+	req := httptest.NewRequest(http.MethodGet, "http://foo", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, req)
+
+	return recorder.Code
+}
+
+func marshalJWKS(t *testing.T, keys ...jose.JSONWebKey) []byte {
+	t.Helper()
+
+	data, err := json.Marshal(jose.JSONWebKeySet{Keys: keys})
+	require.NoError(t, err)
+
+	return data
+}
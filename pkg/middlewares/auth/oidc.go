@@ -0,0 +1,459 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/traefik/traefik/v3/pkg/config/dynamic"
+	"github.com/traefik/traefik/v3/pkg/middlewares"
+	"github.com/traefik/traefik/v3/pkg/middlewares/accesslog"
+	"github.com/traefik/traefik/v3/pkg/middlewares/observability"
+	"github.com/traefik/traefik/v3/pkg/types"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const typeNameOIDC = "OIDC"
+
+const (
+	// flowDuration is how long the users have to authenticate with the provider.
+	flowDuration = 10 * time.Minute
+	// tokenRefreshMargin is how long before their expiry the tokens are refreshed.
+	tokenRefreshMargin = 30 * time.Second
+)
+
+type oidcAuth struct {
+	next     http.Handler
+	name     string
+	provider *oidcProvider
+	store    *cookieStore
+
+	scopes                []string
+	redirectPath          string
+	logoutPath            string
+	postLogoutRedirectURL string
+
+	sessionName   string
+	flowName      string
+	sessionMaxAge time.Duration
+
+	forwardClaims      map[string][]string
+	forwardAccessToken bool
+}
+
+// NewOIDC creates an OpenID Connect middleware.
+func NewOIDC(ctx context.Context, next http.Handler, config dynamic.OIDC, name string) (http.Handler, error) {
+	middlewares.GetLogger(ctx, name, typeNameOIDC).Debug().Msg("Creating middleware")
+
+	if config.Issuer == "" || config.ClientID == "" {
+		return nil, errors.New("issuer and client ID are required")
+	}
+
+	if config.Session == nil || config.Session.Secret == "" {
+		return nil, errors.New("session secret is required")
+	}
+
+	defaults := dynamic.OIDC{}
+	defaults.SetDefaults()
+	sessionDefaults := dynamic.OIDCSession{}
+	sessionDefaults.SetDefaults()
+
+	store, err := newCookieStore(config.Session.Secret, config.Session.Path, config.Session.Domain, config.Session.SameSite)
+	if err != nil {
+		return nil, err
+	}
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	if config.TLS != nil {
+		clientTLS := &types.ClientTLS{
+			CA:                 config.TLS.CA,
+			Cert:               config.TLS.Cert,
+			Key:                config.TLS.Key,
+			InsecureSkipVerify: config.TLS.InsecureSkipVerify,
+		}
+
+		tlsConfig, err := clientTLS.CreateTLSConfig(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("unable to create client TLS configuration: %w", err)
+		}
+
+		tr := http.DefaultTransport.(*http.Transport).Clone()
+		tr.TLSClientConfig = tlsConfig
+		client.Transport = tr
+	}
+
+	o := &oidcAuth{
+		next:                  next,
+		name:                  name,
+		provider:              newOIDCProvider(config.Issuer, config.ClientID, config.ClientSecret, time.Duration(config.DiscoveryRefreshInterval), client),
+		store:                 store,
+		scopes:                config.Scopes,
+		redirectPath:          config.RedirectPath,
+		logoutPath:            config.LogoutPath,
+		postLogoutRedirectURL: config.PostLogoutRedirectURL,
+		sessionName:           sessionCookieName(config.Session.Name, name),
+		sessionMaxAge:         time.Duration(config.Session.MaxAge),
+		forwardClaims:         make(map[string][]string),
+		forwardAccessToken:    config.ForwardAccessToken,
+	}
+	o.flowName = o.sessionName + "_flow"
+
+	if len(o.scopes) == 0 {
+		o.scopes = defaults.Scopes
+	}
+	if !slices.Contains(o.scopes, "openid") {
+		o.scopes = append([]string{"openid"}, o.scopes...)
+	}
+	if o.redirectPath == "" {
+		o.redirectPath = defaults.RedirectPath
+	}
+	if o.logoutPath == "" {
+		o.logoutPath = defaults.LogoutPath
+	}
+	if o.sessionMaxAge <= 0 {
+		o.sessionMaxAge = time.Duration(sessionDefaults.MaxAge)
+	}
+
+	for header, claim := range config.ForwardClaims {
+		o.forwardClaims[http.CanonicalHeaderKey(header)] = strings.Split(claim, ".")
+	}
+
+	return o, nil
+}
+
+func (o *oidcAuth) GetTracingInformation() (string, string, trace.SpanKind) {
+	return o.name, typeNameOIDC, trace.SpanKindInternal
+}
+
+func (o *oidcAuth) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	switch req.URL.Path {
+	case o.redirectPath:
+		o.callback(rw, req)
+		return
+	case o.logoutPath:
+		o.logout(rw, req)
+		return
+	}
+
+	logger := middlewares.GetLogger(req.Context(), o.name, typeNameOIDC)
+
+	var session oidcSession
+	if err := o.store.read(req, o.sessionName, &session); err != nil {
+		if !errors.Is(err, http.ErrNoCookie) {
+			logger.Debug().Err(err).Msg("Invalid session")
+		}
+		o.authenticate(rw, req)
+		return
+	}
+
+	if time.Since(session.CreatedAt) > o.sessionMaxAge {
+		logger.Debug().Msg("Session expired")
+		o.authenticate(rw, req)
+		return
+	}
+
+	// The tokens are silently refreshed when they are about to expire.
+	if !session.Expiry.IsZero() && time.Now().Add(tokenRefreshMargin).After(session.Expiry) {
+		if session.RefreshToken == "" {
+			logger.Debug().Msg("Tokens expired")
+			o.authenticate(rw, req)
+			return
+		}
+
+		if err := o.refresh(req.Context(), &session); err != nil {
+			logger.Debug().Err(err).Msg("Unable to refresh the tokens")
+			o.store.clear(rw, req, o.sessionName)
+			o.authenticate(rw, req)
+			return
+		}
+
+		if err := o.store.write(rw, req, o.sessionName, session, o.sessionMaxAge-time.Since(session.CreatedAt)); err != nil {
+			logger.Error().Err(err).Msg("Unable to write the session cookie")
+			observability.SetStatusErrorf(req.Context(), "Unable to write the session cookie: %s", err)
+
+			rw.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+	}
+
+	if logData := accesslog.GetLogData(req); logData != nil {
+		if subject, ok := session.Claims["sub"].(string); ok {
+			logData.Core[accesslog.ClientUsername] = subject
+		}
+	}
+
+	// The session cookies are not forwarded to the service.
+	removeCookies(req, o.sessionName, o.flowName)
+
+	for header, path := range o.forwardClaims {
+		// The header is always removed, so that it cannot be forged by the client.
+		req.Header.Del(header)
+
+		if value, ok := lookupClaim(session.Claims, path); ok {
+			req.Header.Set(header, claimHeaderValue(value))
+		}
+	}
+
+	if o.forwardAccessToken {
+		req.Header.Set(authorizationHeader, "Bearer "+session.AccessToken)
+	}
+
+	o.next.ServeHTTP(rw, req)
+}
+
+// authenticate redirects the user to the provider, to start an authorization code flow.
+func (o *oidcAuth) authenticate(rw http.ResponseWriter, req *http.Request) {
+	logger := middlewares.GetLogger(req.Context(), o.name, typeNameOIDC)
+
+	// Only the navigations can be redirected to the provider, as the other requests cannot be replayed once authenticated.
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		observability.SetStatusErrorf(req.Context(), "Authentication required")
+
+		http.Error(rw, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+		return
+	}
+
+	metadata, _, err := o.provider.discover(req.Context())
+	if err != nil {
+		logger.Error().Err(err).Msg("Unable to discover the OpenID Connect provider")
+		observability.SetStatusErrorf(req.Context(), "Unable to discover the OpenID Connect provider: %s", err)
+
+		rw.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	flow := oidcFlow{
+		State:        randomString(),
+		Nonce:        randomString(),
+		CodeVerifier: randomString(),
+		RedirectURI:  req.URL.RequestURI(),
+		Expiry:       time.Now().Add(flowDuration),
+	}
+
+	if err := o.store.write(rw, req, o.flowName, flow, flowDuration); err != nil {
+		logger.Error().Err(err).Msg("Unable to write the flow cookie")
+		observability.SetStatusErrorf(req.Context(), "Unable to write the flow cookie: %s", err)
+
+		rw.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	authURL, err := url.Parse(metadata.AuthorizationEndpoint)
+	if err != nil {
+		logger.Error().Err(err).Msg("Invalid authorization endpoint")
+		observability.SetStatusErrorf(req.Context(), "Invalid authorization endpoint: %s", err)
+
+		rw.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	challenge := sha256.Sum256([]byte(flow.CodeVerifier))
+
+	query := authURL.Query()
+	query.Set("response_type", "code")
+	query.Set("client_id", o.provider.clientID)
+	query.Set("redirect_uri", o.callbackURL(req))
+	query.Set("scope", strings.Join(o.scopes, " "))
+	query.Set("state", flow.State)
+	query.Set("nonce", flow.Nonce)
+	query.Set("code_challenge", base64.RawURLEncoding.EncodeToString(challenge[:]))
+	query.Set("code_challenge_method", "S256")
+	authURL.RawQuery = query.Encode()
+
+	logger.Debug().Msg("Redirecting to the OpenID Connect provider")
+	http.Redirect(rw, req, authURL.String(), http.StatusFound)
+}
+
+// callback completes the authorization code flow, once the provider sent back the user.
+func (o *oidcAuth) callback(rw http.ResponseWriter, req *http.Request) {
+	logger := middlewares.GetLogger(req.Context(), o.name, typeNameOIDC)
+
+	var flow oidcFlow
+	err := o.store.read(req, o.flowName, &flow)
+	o.store.clear(rw, req, o.flowName)
+
+	query := req.URL.Query()
+	switch {
+	case err != nil:
+		err = fmt.Errorf("invalid flow: %w", err)
+	case time.Now().After(flow.Expiry):
+		err = errors.New("flow expired")
+	case query.Get("state") != flow.State:
+		err = errors.New("state does not match")
+	case query.Get("error") != "":
+		err = fmt.Errorf("provider error %s: %s", query.Get("error"), query.Get("error_description"))
+	case query.Get("code") == "":
+		err = errors.New("missing authorization code")
+	}
+	if err != nil {
+		logger.Debug().Err(err).Msg("Authentication failed")
+		observability.SetStatusErrorf(req.Context(), "Authentication failed: %s", err)
+
+		http.Error(rw, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+		return
+	}
+
+	token, err := o.provider.exchange(req.Context(), url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {query.Get("code")},
+		"redirect_uri":  {o.callbackURL(req)},
+		"code_verifier": {flow.CodeVerifier},
+	})
+	if err == nil && token.IDToken == "" {
+		err = errors.New("token response is missing the ID token")
+	}
+
+	var claims jwt.MapClaims
+	if err == nil {
+		claims, err = o.provider.verifyIDToken(req.Context(), token.IDToken, flow.Nonce)
+	}
+	if err != nil {
+		logger.Debug().Err(err).Msg("Authentication failed")
+		observability.SetStatusErrorf(req.Context(), "Authentication failed: %s", err)
+
+		http.Error(rw, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+		return
+	}
+
+	session := oidcSession{CreatedAt: time.Now()}
+	session.update(token, claims)
+
+	if err := o.store.write(rw, req, o.sessionName, session, o.sessionMaxAge); err != nil {
+		logger.Error().Err(err).Msg("Unable to write the session cookie")
+		observability.SetStatusErrorf(req.Context(), "Unable to write the session cookie: %s", err)
+
+		rw.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	logger.Debug().Msg("Authentication succeeded")
+
+	// The redirection is restricted to the paths of the current host.
+	redirectURI := flow.RedirectURI
+	if !strings.HasPrefix(redirectURI, "/") || strings.HasPrefix(redirectURI, "//") {
+		redirectURI = "/"
+	}
+	http.Redirect(rw, req, redirectURI, http.StatusFound)
+}
+
+// logout ends the session of the user, and the provider session when the provider supports the RP-initiated logout.
+func (o *oidcAuth) logout(rw http.ResponseWriter, req *http.Request) {
+	logger := middlewares.GetLogger(req.Context(), o.name, typeNameOIDC)
+
+	var session oidcSession
+	_ = o.store.read(req, o.sessionName, &session)
+	o.store.clear(rw, req, o.sessionName)
+
+	redirectURL := o.postLogoutRedirectURL
+	if redirectURL == "" {
+		redirectURL = "/"
+	}
+
+	metadata, _, err := o.provider.discover(req.Context())
+	if err != nil {
+		logger.Error().Err(err).Msg("Unable to discover the OpenID Connect provider")
+	}
+
+	if metadata != nil && metadata.EndSessionEndpoint != "" {
+		endSessionURL, err := url.Parse(metadata.EndSessionEndpoint)
+		if err == nil {
+			query := endSessionURL.Query()
+			query.Set("client_id", o.provider.clientID)
+			if session.IDToken != "" {
+				query.Set("id_token_hint", session.IDToken)
+			}
+			if o.postLogoutRedirectURL != "" {
+				query.Set("post_logout_redirect_uri", o.postLogoutRedirectURL)
+			}
+			endSessionURL.RawQuery = query.Encode()
+			redirectURL = endSessionURL.String()
+		}
+	}
+
+	logger.Debug().Msg("Logged out")
+	http.Redirect(rw, req, redirectURL, http.StatusFound)
+}
+
+// refresh refreshes the tokens of the session.
+func (o *oidcAuth) refresh(ctx context.Context, session *oidcSession) error {
+	token, err := o.provider.exchange(ctx, url.Values{
+		"grant_type":    {"refresh_token"},
+		"refresh_token": {session.RefreshToken},
+	})
+	if err != nil {
+		return err
+	}
+
+	var claims jwt.MapClaims
+	if token.IDToken != "" {
+		claims, err = o.provider.verifyIDToken(ctx, token.IDToken, "")
+		if err != nil {
+			return err
+		}
+
+		// The refreshed ID token must identify the same user.
+		if claims["sub"] != session.Claims["sub"] {
+			return errors.New("subject of the refreshed ID token does not match")
+		}
+	}
+
+	session.update(token, claims)
+	return nil
+}
+
+// callbackURL returns the URL of the redirection endpoint, on the host of the request.
+func (o *oidcAuth) callbackURL(req *http.Request) string {
+	return requestScheme(req) + "://" + req.Host + o.redirectPath
+}
+
+// update updates the session with the tokens of the token response,
+// keeping the refresh token and the ID token when the response does not renew them.
+func (s *oidcSession) update(token *tokenResponse, claims jwt.MapClaims) {
+	s.AccessToken = token.AccessToken
+	if token.RefreshToken != "" {
+		s.RefreshToken = token.RefreshToken
+	}
+
+	if token.IDToken != "" {
+		s.IDToken = token.IDToken
+		s.Claims = claims
+	}
+
+	switch {
+	case token.ExpiresIn > 0:
+		s.Expiry = time.Now().Add(time.Duration(token.ExpiresIn) * time.Second)
+	case token.IDToken != "":
+		// The tokens are considered valid as long as the ID token.
+		if expiry, err := claims.GetExpirationTime(); err == nil && expiry != nil {
+			s.Expiry = expiry.Time
+		}
+	}
+}
+
+// removeCookies removes the cookies, and their chunks, with the given names from the request.
+func removeCookies(req *http.Request, names ...string) {
+	cookies := req.Cookies()
+	req.Header.Del("Cookie")
+
+	for _, c := range cookies {
+		if !slices.ContainsFunc(names, func(name string) bool { return isChunkOf(c.Name, name) }) {
+			req.AddCookie(c)
+		}
+	}
+}
+
+func randomString() string {
+	data := make([]byte, 32)
+	// rand.Read never returns an error, and always fills the slice entirely.
+	_, _ = rand.Read(data)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
@@ -0,0 +1,251 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	defaultDiscoveryRefreshInterval = time.Hour
+	maxProviderResponseSize         = 1 << 20
+)
+
+// providerMetadata holds the discovery document of an OpenID Connect provider.
+type providerMetadata struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+	EndSessionEndpoint    string `json:"end_session_endpoint"`
+}
+
+// tokenResponse holds the response of the token endpoint.
+type tokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int64  `json:"expires_in"`
+	IDToken      string `json:"id_token"`
+}
+
+// oidcProvider is the client of an OpenID Connect provider.
+// Its discovery document is fetched lazily, and cached for the refresh interval.
+type oidcProvider struct {
+	issuer          string
+	clientID        string
+	clientSecret    string
+	client          *http.Client
+	refreshInterval time.Duration
+
+	// fetchMu ensures that the discovery document is fetched once at a time.
+	fetchMu   sync.Mutex
+	mu        sync.RWMutex
+	metadata  *providerMetadata
+	keys      *keySet
+	fetchedAt time.Time
+}
+
+func newOIDCProvider(issuer, clientID, clientSecret string, refreshInterval time.Duration, client *http.Client) *oidcProvider {
+	if refreshInterval <= 0 {
+		refreshInterval = defaultDiscoveryRefreshInterval
+	}
+
+	return &oidcProvider{
+		issuer:          strings.TrimSuffix(issuer, "/"),
+		clientID:        clientID,
+		clientSecret:    clientSecret,
+		client:          client,
+		refreshInterval: refreshInterval,
+	}
+}
+
+// discover returns the discovery document and the key set of the provider.
+// The cached document is returned when it cannot be refreshed.
+func (p *oidcProvider) discover(ctx context.Context) (*providerMetadata, *keySet, error) {
+	p.mu.RLock()
+	metadata, keys, fetchedAt := p.metadata, p.keys, p.fetchedAt
+	p.mu.RUnlock()
+
+	if metadata != nil && time.Since(fetchedAt) < p.refreshInterval {
+		return metadata, keys, nil
+	}
+
+	p.fetchMu.Lock()
+	defer p.fetchMu.Unlock()
+
+	p.mu.RLock()
+	metadata, keys, fetchedAt = p.metadata, p.keys, p.fetchedAt
+	p.mu.RUnlock()
+
+	// The document has been fetched while waiting for the lock.
+	if metadata != nil && time.Since(fetchedAt) < p.refreshInterval {
+		return metadata, keys, nil
+	}
+
+	newMetadata, err := p.fetchMetadata(ctx)
+	if err != nil {
+		if metadata == nil {
+			return nil, nil, err
+		}
+
+		log.Ctx(ctx).Error().Err(err).Str("issuer", p.issuer).Msg("Unable to refresh the OpenID Connect discovery document")
+
+		// The refresh is not retried before the refresh interval.
+		p.mu.Lock()
+		p.fetchedAt = time.Now()
+		p.mu.Unlock()
+		return metadata, keys, nil
+	}
+
+	// The key set is kept, along with its cached keys, as long as its URL does not change.
+	if keys == nil || metadata.JWKSURI != newMetadata.JWKSURI {
+		keys, err = newKeySet(nil, "", newMetadata.JWKSURI, p.refreshInterval, p.client)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	p.mu.Lock()
+	p.metadata, p.keys, p.fetchedAt = newMetadata, keys, time.Now()
+	p.mu.Unlock()
+
+	return newMetadata, keys, nil
+}
+
+func (p *oidcProvider) fetchMetadata(ctx context.Context) (*providerMetadata, error) {
+	// The fetch is not canceled along with the request triggering it, as the document is shared by the requests.
+	req, err := http.NewRequestWithContext(context.WithoutCancel(ctx), http.MethodGet, p.issuer+"/.well-known/openid-configuration", nil)
+	if err != nil {
+		return nil, fmt.Errorf("creating discovery request: %w", err)
+	}
+
+	var metadata providerMetadata
+	if err := p.do(req, &metadata); err != nil {
+		return nil, fmt.Errorf("fetching discovery document: %w", err)
+	}
+
+	if metadata.Issuer != p.issuer {
+		return nil, fmt.Errorf("discovery document issuer %q does not match %q", metadata.Issuer, p.issuer)
+	}
+
+	if metadata.AuthorizationEndpoint == "" || metadata.TokenEndpoint == "" || metadata.JWKSURI == "" {
+		return nil, errors.New("discovery document is missing the authorization, token or JWKS endpoint")
+	}
+
+	return &metadata, nil
+}
+
+// exchange requests tokens to the token endpoint with the given grant parameters.
+func (p *oidcProvider) exchange(ctx context.Context, params url.Values) (*tokenResponse, error) {
+	metadata, _, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	params.Set("client_id", p.clientID)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, metadata.TokenEndpoint, strings.NewReader(params.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("creating token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	if p.clientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(p.clientID), url.QueryEscape(p.clientSecret))
+	}
+
+	var token tokenResponse
+	if err := p.do(req, &token); err != nil {
+		return nil, fmt.Errorf("requesting token: %w", err)
+	}
+
+	if token.AccessToken == "" {
+		return nil, errors.New("token response is missing the access token")
+	}
+
+	return &token, nil
+}
+
+// verifyIDToken verifies the signature and the claims of the ID token, and returns its claims.
+// The nonce is only checked when not empty, as the ID tokens issued on refresh may not hold it.
+func (p *oidcProvider) verifyIDToken(ctx context.Context, rawToken, nonce string) (jwt.MapClaims, error) {
+	metadata, keys, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	algorithms := asymmetricAlgorithms
+	if p.clientSecret != "" {
+		algorithms = slices.Concat(hmacAlgorithms, asymmetricAlgorithms)
+	}
+
+	parser := jwt.NewParser(
+		jwt.WithValidMethods(algorithms),
+		jwt.WithIssuer(metadata.Issuer),
+		jwt.WithAudience(p.clientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithJSONNumber(),
+	)
+
+	claims := jwt.MapClaims{}
+	_, err = parser.ParseWithClaims(rawToken, claims, func(token *jwt.Token) (any, error) {
+		// The ID tokens signed with an HMAC algorithm use the client secret as the key.
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); ok {
+			return []byte(p.clientSecret), nil
+		}
+
+		kid, _ := token.Header["kid"].(string)
+
+		var verificationKeys jwt.VerificationKeySet
+		for _, key := range keys.keys(ctx, kid) {
+			verificationKeys.Keys = append(verificationKeys.Keys, key)
+		}
+
+		if len(verificationKeys.Keys) == 0 {
+			return nil, fmt.Errorf("no key found for key ID %q", kid)
+		}
+		return verificationKeys, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if nonce != "" && claims["nonce"] != nonce {
+		return nil, errors.New("nonce does not match")
+	}
+
+	return claims, nil
+}
+
+func (p *oidcProvider) do(req *http.Request, result any) error {
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxProviderResponseSize))
+	if err != nil {
+		return fmt.Errorf("reading response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status code %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	return json.Unmarshal(body, result)
+}
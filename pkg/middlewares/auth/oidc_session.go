@@ -0,0 +1,252 @@
+package auth
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// maxCookieChunkSize is the maximum size of the value of a session cookie chunk,
+	// keeping the whole cookie below the 4096 bytes supported by the browsers.
+	maxCookieChunkSize = 3800
+	// maxCookieChunks is the maximum number of chunks of a session cookie.
+	maxCookieChunks = 10
+)
+
+// oidcSession holds the tokens of an authenticated user.
+type oidcSession struct {
+	IDToken      string         `json:"idToken"`
+	AccessToken  string         `json:"accessToken"`
+	RefreshToken string         `json:"refreshToken,omitempty"`
+	Expiry       time.Time      `json:"expiry"`
+	Claims       map[string]any `json:"claims"`
+	CreatedAt    time.Time      `json:"createdAt"`
+}
+
+// oidcFlow holds the state of an authorization code flow, until the user is sent back by the provider.
+type oidcFlow struct {
+	State        string    `json:"state"`
+	Nonce        string    `json:"nonce"`
+	CodeVerifier string    `json:"codeVerifier"`
+	RedirectURI  string    `json:"redirectURI"`
+	Expiry       time.Time `json:"expiry"`
+}
+
+// cookieStore writes and reads encrypted values in cookies,
+// splitting the values exceeding the cookie size limit into several cookies.
+type cookieStore struct {
+	aead     cipher.AEAD
+	path     string
+	domain   string
+	sameSite http.SameSite
+}
+
+func newCookieStore(secret, path, domain, sameSite string) (*cookieStore, error) {
+	if len(secret) < 32 {
+		return nil, errors.New("session secret must be at least 32 characters long")
+	}
+
+	key := sha256.Sum256([]byte(secret))
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	if path == "" {
+		path = "/"
+	}
+
+	if sameSite == "" {
+		sameSite = "lax"
+	}
+
+	return &cookieStore{
+		aead:     aead,
+		path:     path,
+		domain:   domain,
+		sameSite: convertSameSite(sameSite),
+	}, nil
+}
+
+// write encrypts the value into the cookie with the given name,
+// removing the chunks of the previous value which are no longer used.
+func (s *cookieStore) write(rw http.ResponseWriter, req *http.Request, name string, value any, maxAge time.Duration) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	// The cookie name is authenticated, so that the value of a cookie cannot be used as the value of another.
+	nonce := make([]byte, s.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+	encoded := base64.RawURLEncoding.EncodeToString(s.aead.Seal(nonce, nonce, data, []byte(name)))
+
+	var chunks []string
+	for len(encoded) > maxCookieChunkSize {
+		chunks = append(chunks, encoded[:maxCookieChunkSize])
+		encoded = encoded[maxCookieChunkSize:]
+	}
+	chunks = append(chunks, encoded)
+
+	if len(chunks) > maxCookieChunks {
+		return fmt.Errorf("value of cookie %s is too large", name)
+	}
+
+	secure := requestScheme(req) == "https"
+	for i, chunk := range chunks {
+		http.SetCookie(rw, s.cookie(chunkName(name, i), chunk, int(maxAge.Seconds()), secure))
+	}
+
+	for i := len(chunks); i < maxCookieChunks; i++ {
+		if _, err := req.Cookie(chunkName(name, i)); err == nil {
+			http.SetCookie(rw, s.cookie(chunkName(name, i), "", -1, secure))
+		}
+	}
+
+	return nil
+}
+
+// read decrypts the value of the cookie with the given name.
+func (s *cookieStore) read(req *http.Request, name string, value any) error {
+	var encoded strings.Builder
+	for i := range maxCookieChunks {
+		cookie, err := req.Cookie(chunkName(name, i))
+		if err != nil {
+			break
+		}
+		encoded.WriteString(cookie.Value)
+	}
+
+	if encoded.Len() == 0 {
+		return http.ErrNoCookie
+	}
+
+	sealed, err := base64.RawURLEncoding.DecodeString(encoded.String())
+	if err != nil {
+		return fmt.Errorf("decoding cookie %s: %w", name, err)
+	}
+
+	if len(sealed) < s.aead.NonceSize() {
+		return fmt.Errorf("decoding cookie %s: value too short", name)
+	}
+
+	data, err := s.aead.Open(nil, sealed[:s.aead.NonceSize()], sealed[s.aead.NonceSize():], []byte(name))
+	if err != nil {
+		return fmt.Errorf("decrypting cookie %s: %w", name, err)
+	}
+
+	return json.Unmarshal(data, value)
+}
+
+// clear removes all the chunks of the cookie with the given name.
+func (s *cookieStore) clear(rw http.ResponseWriter, req *http.Request, name string) {
+	secure := requestScheme(req) == "https"
+	for i := range maxCookieChunks {
+		if _, err := req.Cookie(chunkName(name, i)); err == nil {
+			http.SetCookie(rw, s.cookie(chunkName(name, i), "", -1, secure))
+		}
+	}
+}
+
+func (s *cookieStore) cookie(name, value string, maxAge int, secure bool) *http.Cookie {
+	return &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     s.path,
+		Domain:   s.domain,
+		MaxAge:   maxAge,
+		Secure:   secure,
+		HttpOnly: true,
+		SameSite: s.sameSite,
+	}
+}
+
+// sessionCookieName returns the name of the session cookie: the configured name, sanitized,
+// or a name generated from the middleware name.
+func sessionCookieName(cookieName, middlewareName string) string {
+	if cookieName != "" {
+		return strings.Map(sanitizeCookieNameRune, cookieName)
+	}
+
+	hash := sha1.Sum([]byte("_TRAEFIK_OIDC_" + middlewareName))
+	return fmt.Sprintf("_%x", hash)[:6]
+}
+
+// sanitizeCookieNameRune replaces the characters not allowed in a cookie name,
+// according to [RFC 2616](https://www.ietf.org/rfc/rfc2616.txt) section 2.2.
+func sanitizeCookieNameRune(r rune) rune {
+	switch {
+	case strings.ContainsRune("!#$%&'*+-.^`|~", r),
+		'a' <= r && r <= 'z', 'A' <= r && r <= 'Z', '0' <= r && r <= '9':
+		return r
+	default:
+		return '_'
+	}
+}
+
+// chunkName returns the name of the cookie holding the chunk with the given index.
+// The first chunk keeps the cookie name.
+func chunkName(name string, index int) string {
+	if index == 0 {
+		return name
+	}
+	return name + "_" + strconv.Itoa(index)
+}
+
+// isChunkOf returns whether the cookie is a chunk of the cookie with the given name.
+func isChunkOf(cookieName, name string) bool {
+	if cookieName == name {
+		return true
+	}
+
+	suffix, ok := strings.CutPrefix(cookieName, name+"_")
+	if !ok {
+		return false
+	}
+
+	index, err := strconv.Atoi(suffix)
+	return err == nil && index > 0 && index < maxCookieChunks
+}
+
+func convertSameSite(sameSite string) http.SameSite {
+	switch sameSite {
+	case "none":
+		return http.SameSiteNoneMode
+	case "lax":
+		return http.SameSiteLaxMode
+	case "strict":
+		return http.SameSiteStrictMode
+	default:
+		return http.SameSiteDefaultMode
+	}
+}
+
+// requestScheme returns the scheme of the request, as seen by the client.
+func requestScheme(req *http.Request) string {
+	if proto := req.Header.Get("X-Forwarded-Proto"); proto != "" {
+		return proto
+	}
+
+	if req.TLS != nil {
+		return "https"
+	}
+	return "http"
+}
@@ -0,0 +1,609 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-jose/go-jose/v4"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	ptypes "github.com/traefik/paerser/types"
+	"github.com/traefik/traefik/v3/pkg/config/dynamic"
+)
+
+const testSessionSecret = "0123456789abcdef0123456789abcdef"
+
+func TestOIDC_authorizationCodeFlow(t *testing.T) {
+	idp := newMockIdP(t)
+
+	next := http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, "user1", req.Header.Get("X-User"))
+		assert.Equal(t, "user1@example.com", req.Header.Get("X-Email"))
+		assert.Equal(t, "admin,dev", req.Header.Get("X-Groups"))
+		assert.Equal(t, "Bearer access-1", req.Header.Get("Authorization"))
+
+		// The session cookies are not forwarded, unlike the other cookies.
+		cookies := req.Cookies()
+		require.Len(t, cookies, 1)
+		assert.Equal(t, "foo", cookies[0].Name)
+
+		_, _ = rw.Write([]byte("protected"))
+	})
+
+	handler, err := NewOIDC(t.Context(), next, dynamic.OIDC{
+		Issuer:       idp.URL,
+		ClientID:     "client",
+		ClientSecret: "client-secret",
+		Session:      &dynamic.OIDCSession{Secret: testSessionSecret},
+		ForwardClaims: map[string]string{
+			"X-User":   "sub",
+			"X-Email":  "email",
+			"X-Groups": "groups",
+		},
+		ForwardAccessToken: true,
+	}, "oidc")
+	require.NoError(t, err)
+
+	browser := newMockBrowser(handler)
+	browser.cookies["foo"] = "bar"
+
+	resp := browser.get(t, "http://app.localhost/dashboard?tab=1")
+	require.Equal(t, http.StatusFound, resp.Code)
+
+	authURL, err := url.Parse(resp.Header().Get("Location"))
+	require.NoError(t, err)
+	assert.Equal(t, idp.URL+"/authorize", authURL.Scheme+"://"+authURL.Host+authURL.Path)
+
+	query := authURL.Query()
+	assert.Equal(t, "code", query.Get("response_type"))
+	assert.Equal(t, "client", query.Get("client_id"))
+	assert.Equal(t, "http://app.localhost/oauth2/callback", query.Get("redirect_uri"))
+	assert.Equal(t, "openid profile email", query.Get("scope"))
+	assert.Equal(t, "S256", query.Get("code_challenge_method"))
+
+	resp = browser.get(t, idp.authorize(t, authURL, "user1"))
+	require.Equal(t, http.StatusFound, resp.Code)
+	assert.Equal(t, "/dashboard?tab=1", resp.Header().Get("Location"))
+
+	resp = browser.get(t, "http://app.localhost/dashboard?tab=1")
+	require.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, "protected", resp.Body.String())
+}
+
+func TestOIDC_callbackErrors(t *testing.T) {
+	idp := newMockIdP(t)
+
+	handler, err := NewOIDC(t.Context(), http.NotFoundHandler(), dynamic.OIDC{
+		Issuer:   idp.URL,
+		ClientID: "client",
+		Session:  &dynamic.OIDCSession{Secret: testSessionSecret},
+	}, "oidc")
+	require.NoError(t, err)
+
+	testCases := []struct {
+		desc     string
+		callback func(t *testing.T, authURL *url.URL) string
+	}{
+		{
+			desc: "state mismatch",
+			callback: func(t *testing.T, authURL *url.URL) string {
+				t.Helper()
+
+				callbackURL, err := url.Parse(idp.authorize(t, authURL, "user1"))
+				require.NoError(t, err)
+
+				query := callbackURL.Query()
+				query.Set("state", "forged")
+				callbackURL.RawQuery = query.Encode()
+				return callbackURL.String()
+			},
+		},
+		{
+			desc: "unknown code",
+			callback: func(t *testing.T, authURL *url.URL) string {
+				t.Helper()
+
+				return authURL.Query().Get("redirect_uri") + "?code=unknown&state=" + authURL.Query().Get("state")
+			},
+		},
+		{
+			desc: "provider error",
+			callback: func(t *testing.T, authURL *url.URL) string {
+				t.Helper()
+
+				return authURL.Query().Get("redirect_uri") + "?error=access_denied&state=" + authURL.Query().Get("state")
+			},
+		},
+		{
+			desc: "nonce mismatch",
+			callback: func(t *testing.T, authURL *url.URL) string {
+				t.Helper()
+
+				query := authURL.Query()
+				query.Set("nonce", "forged")
+				forgedURL := *authURL
+				forgedURL.RawQuery = query.Encode()
+				return idp.authorize(t, &forgedURL, "user1")
+			},
+		},
+		{
+			desc: "code verifier mismatch",
+			callback: func(t *testing.T, authURL *url.URL) string {
+				t.Helper()
+
+				query := authURL.Query()
+				query.Set("code_challenge", "forged")
+				forgedURL := *authURL
+				forgedURL.RawQuery = query.Encode()
+				return idp.authorize(t, &forgedURL, "user1")
+			},
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			browser := newMockBrowser(handler)
+
+			resp := browser.get(t, "http://app.localhost/")
+			require.Equal(t, http.StatusFound, resp.Code)
+
+			authURL, err := url.Parse(resp.Header().Get("Location"))
+			require.NoError(t, err)
+
+			resp = browser.get(t, test.callback(t, authURL))
+			assert.Equal(t, http.StatusUnauthorized, resp.Code)
+			assert.Empty(t, browser.cookies)
+		})
+	}
+}
+
+func TestOIDC_unauthenticated(t *testing.T) {
+	idp := newMockIdP(t)
+
+	handler, err := NewOIDC(t.Context(), http.NotFoundHandler(), dynamic.OIDC{
+		Issuer:   idp.URL,
+		ClientID: "client",
+		Session:  &dynamic.OIDCSession{Secret: testSessionSecret},
+	}, "oidc")
+	require.NoError(t, err)
+
+	// The requests which cannot be replayed are not redirected.
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "http://app.localhost/", nil))
+	assert.Equal(t, http.StatusUnauthorized, recorder.Code)
+
+	// A tampered session cookie starts a new authentication.
+	req := httptest.NewRequest(http.MethodGet, "http://app.localhost/", nil)
+	req.AddCookie(&http.Cookie{Name: handler.(*oidcAuth).sessionName, Value: "tampered"})
+
+	recorder = httptest.NewRecorder()
+	handler.ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusFound, recorder.Code)
+	assert.True(t, strings.HasPrefix(recorder.Header().Get("Location"), idp.URL+"/authorize"))
+}
+
+func TestOIDC_refresh(t *testing.T) {
+	idp := newMockIdP(t)
+	idp.expiresIn = 1
+
+	var accessTokens []string
+	next := http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		accessTokens = append(accessTokens, req.Header.Get("Authorization"))
+	})
+
+	handler, err := NewOIDC(t.Context(), next, dynamic.OIDC{
+		Issuer:             idp.URL,
+		ClientID:           "client",
+		ClientSecret:       "client-secret",
+		Session:            &dynamic.OIDCSession{Secret: testSessionSecret},
+		ForwardAccessToken: true,
+	}, "oidc")
+	require.NoError(t, err)
+
+	browser := newMockBrowser(handler)
+	browser.login(t, idp, "http://app.localhost/", "user1")
+
+	// The access token expires within the refresh margin, and is thus refreshed on each request.
+	resp := browser.get(t, "http://app.localhost/")
+	require.Equal(t, http.StatusOK, resp.Code)
+	resp = browser.get(t, "http://app.localhost/")
+	require.Equal(t, http.StatusOK, resp.Code)
+
+	assert.Equal(t, []string{"Bearer access-2", "Bearer access-3"}, accessTokens)
+
+	// A failed refresh starts a new authentication.
+	idp.mu.Lock()
+	idp.refreshTokens = map[string]string{}
+	idp.mu.Unlock()
+
+	resp = browser.get(t, "http://app.localhost/")
+	assert.Equal(t, http.StatusFound, resp.Code)
+	assert.True(t, strings.HasPrefix(resp.Header().Get("Location"), idp.URL+"/authorize"))
+}
+
+func TestOIDC_sessionMaxAge(t *testing.T) {
+	idp := newMockIdP(t)
+
+	handler, err := NewOIDC(t.Context(), http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {}), dynamic.OIDC{
+		Issuer:   idp.URL,
+		ClientID: "client",
+		Session:  &dynamic.OIDCSession{Secret: testSessionSecret, MaxAge: ptypes.Duration(time.Minute)},
+	}, "oidc")
+	require.NoError(t, err)
+
+	browser := newMockBrowser(handler)
+	browser.login(t, idp, "http://app.localhost/", "user1")
+
+	resp := browser.get(t, "http://app.localhost/")
+	require.Equal(t, http.StatusOK, resp.Code)
+
+	oidcHandler := handler.(*oidcAuth)
+	req := browser.request(http.MethodGet, "http://app.localhost/")
+
+	var session oidcSession
+	require.NoError(t, oidcHandler.store.read(req, oidcHandler.sessionName, &session))
+	session.CreatedAt = time.Now().Add(-2 * time.Minute)
+
+	recorder := httptest.NewRecorder()
+	require.NoError(t, oidcHandler.store.write(recorder, req, oidcHandler.sessionName, session, time.Minute))
+	browser.store(recorder)
+
+	resp = browser.get(t, "http://app.localhost/")
+	assert.Equal(t, http.StatusFound, resp.Code)
+}
+
+func TestOIDC_logout(t *testing.T) {
+	idp := newMockIdP(t)
+
+	handler, err := NewOIDC(t.Context(), http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {}), dynamic.OIDC{
+		Issuer:                idp.URL,
+		ClientID:              "client",
+		PostLogoutRedirectURL: "http://app.localhost/bye",
+		Session:               &dynamic.OIDCSession{Secret: testSessionSecret},
+	}, "oidc")
+	require.NoError(t, err)
+
+	browser := newMockBrowser(handler)
+	browser.login(t, idp, "http://app.localhost/", "user1")
+	require.NotEmpty(t, browser.cookies)
+
+	resp := browser.get(t, "http://app.localhost/oauth2/logout")
+	require.Equal(t, http.StatusFound, resp.Code)
+	assert.Empty(t, browser.cookies)
+
+	logoutURL, err := url.Parse(resp.Header().Get("Location"))
+	require.NoError(t, err)
+	assert.Equal(t, idp.URL+"/logout", logoutURL.Scheme+"://"+logoutURL.Host+logoutURL.Path)
+	assert.Equal(t, "http://app.localhost/bye", logoutURL.Query().Get("post_logout_redirect_uri"))
+	assert.NotEmpty(t, logoutURL.Query().Get("id_token_hint"))
+}
+
+func TestOIDC_chunkedSession(t *testing.T) {
+	idp := newMockIdP(t)
+	// The large claims make the session exceed the cookie size limit.
+	idp.extraClaims = map[string]any{"large": strings.Repeat("x", 2*maxCookieChunkSize)}
+
+	handler, err := NewOIDC(t.Context(), http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		assert.Len(t, req.Header.Get("X-Large"), 2*maxCookieChunkSize)
+		assert.Empty(t, req.Cookies())
+	}), dynamic.OIDC{
+		Issuer:        idp.URL,
+		ClientID:      "client",
+		Session:       &dynamic.OIDCSession{Secret: testSessionSecret, Name: "session"},
+		ForwardClaims: map[string]string{"X-Large": "large"},
+	}, "oidc")
+	require.NoError(t, err)
+
+	browser := newMockBrowser(handler)
+	browser.login(t, idp, "http://app.localhost/", "user1")
+
+	assert.Contains(t, browser.cookies, "session")
+	assert.Contains(t, browser.cookies, "session_1")
+	assert.Contains(t, browser.cookies, "session_2")
+
+	resp := browser.get(t, "http://app.localhost/")
+	assert.Equal(t, http.StatusOK, resp.Code)
+}
+
+func TestCookieStore(t *testing.T) {
+	store, err := newCookieStore(testSessionSecret, "", "", "")
+	require.NoError(t, err)
+
+	value := map[string]string{"foo": strings.Repeat("x", 2*maxCookieChunkSize)}
+
+	recorder := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "https://foo", nil)
+	require.NoError(t, store.write(recorder, req, "foo", value, time.Hour))
+
+	cookies := recorder.Result().Cookies()
+	require.Len(t, cookies, 3)
+	for _, c := range cookies {
+		assert.True(t, c.HttpOnly)
+		assert.True(t, c.Secure)
+		assert.Equal(t, http.SameSiteLaxMode, c.SameSite)
+		assert.Equal(t, "/", c.Path)
+		req.AddCookie(c)
+	}
+
+	var read map[string]string
+	require.NoError(t, store.read(req, "foo", &read))
+	assert.Equal(t, value, read)
+
+	// The value of a cookie cannot be read as the value of another cookie.
+	other := httptest.NewRequest(http.MethodGet, "https://foo", nil)
+	for _, c := range cookies {
+		c.Name = strings.Replace(c.Name, "foo", "bar", 1)
+		other.AddCookie(c)
+	}
+	assert.Error(t, store.read(other, "bar", &read))
+
+	// The unused chunks are removed when the value shrinks.
+	recorder = httptest.NewRecorder()
+	require.NoError(t, store.write(recorder, req, "foo", "small", time.Hour))
+
+	cookies = recorder.Result().Cookies()
+	require.Len(t, cookies, 3)
+	assert.Equal(t, "foo", cookies[0].Name)
+	assert.Equal(t, -1, cookies[1].MaxAge)
+	assert.Equal(t, -1, cookies[2].MaxAge)
+
+	_, err = newCookieStore("short", "", "", "")
+	assert.Error(t, err)
+}
+
+func TestSessionCookieName(t *testing.T) {
+	assert.Equal(t, "_648b6", sessionCookieName("", "oidc@file"))
+	assert.Equal(t, "my_session_x", sessionCookieName("my session@x", "oidc@file"))
+}
+
+func TestNewOIDC_invalidConfig(t *testing.T) {
+	testCases := []struct {
+		desc   string
+		config dynamic.OIDC
+	}{
+		{
+			desc:   "missing issuer",
+			config: dynamic.OIDC{ClientID: "client", Session: &dynamic.OIDCSession{Secret: testSessionSecret}},
+		},
+		{
+			desc:   "missing client ID",
+			config: dynamic.OIDC{Issuer: "http://idp", Session: &dynamic.OIDCSession{Secret: testSessionSecret}},
+		},
+		{
+			desc:   "missing session secret",
+			config: dynamic.OIDC{Issuer: "http://idp", ClientID: "client"},
+		},
+		{
+			desc:   "short session secret",
+			config: dynamic.OIDC{Issuer: "http://idp", ClientID: "client", Session: &dynamic.OIDCSession{Secret: "short"}},
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := NewOIDC(t.Context(), http.NotFoundHandler(), test.config, "oidc")
+			assert.Error(t, err)
+		})
+	}
+}
+
+// mockIdP is a minimal OpenID Connect provider.
+type mockIdP struct {
+	*httptest.Server
+
+	key         *rsa.PrivateKey
+	expiresIn   int64
+	extraClaims map[string]any
+
+	mu            sync.Mutex
+	codes         map[string]mockAuthorization
+	refreshTokens map[string]string
+	tokens        int
+}
+
+type mockAuthorization struct {
+	subject       string
+	nonce         string
+	codeChallenge string
+	redirectURI   string
+}
+
+func newMockIdP(t *testing.T) *mockIdP {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	idp := &mockIdP{
+		key:           key,
+		expiresIn:     3600,
+		codes:         make(map[string]mockAuthorization),
+		refreshTokens: make(map[string]string),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(rw http.ResponseWriter, req *http.Request) {
+		_ = json.NewEncoder(rw).Encode(map[string]string{
+			"issuer":                 idp.URL,
+			"authorization_endpoint": idp.URL + "/authorize",
+			"token_endpoint":         idp.URL + "/token",
+			"jwks_uri":               idp.URL + "/jwks",
+			"end_session_endpoint":   idp.URL + "/logout",
+		})
+	})
+	mux.HandleFunc("/jwks", func(rw http.ResponseWriter, req *http.Request) {
+		_ = json.NewEncoder(rw).Encode(jose.JSONWebKeySet{Keys: []jose.JSONWebKey{{Key: key.Public(), KeyID: "key", Use: "sig"}}})
+	})
+	mux.HandleFunc("/token", idp.token)
+
+	idp.Server = httptest.NewServer(mux)
+	t.Cleanup(idp.Close)
+
+	return idp
+}
+
+// authorize simulates the authentication of the user, and returns the callback URL to which the user is redirected.
+func (m *mockIdP) authorize(t *testing.T, authURL *url.URL, subject string) string {
+	t.Helper()
+
+	query := authURL.Query()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	code := "code-" + subject + "-" + query.Get("state")[:8]
+	m.codes[code] = mockAuthorization{
+		subject:       subject,
+		nonce:         query.Get("nonce"),
+		codeChallenge: query.Get("code_challenge"),
+		redirectURI:   query.Get("redirect_uri"),
+	}
+
+	return query.Get("redirect_uri") + "?" + url.Values{"code": {code}, "state": {query.Get("state")}}.Encode()
+}
+
+func (m *mockIdP) token(rw http.ResponseWriter, req *http.Request) {
+	if err := req.ParseForm(); err != nil {
+		http.Error(rw, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if user, password, ok := req.BasicAuth(); ok && (user != "client" || password != "client-secret") {
+		http.Error(rw, `{"error":"invalid_client"}`, http.StatusUnauthorized)
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var subject, nonce string
+	switch req.Form.Get("grant_type") {
+	case "authorization_code":
+		authorization, ok := m.codes[req.Form.Get("code")]
+		delete(m.codes, req.Form.Get("code"))
+
+		challenge := sha256.Sum256([]byte(req.Form.Get("code_verifier")))
+		if !ok || authorization.redirectURI != req.Form.Get("redirect_uri") ||
+			authorization.codeChallenge != base64.RawURLEncoding.EncodeToString(challenge[:]) {
+			http.Error(rw, `{"error":"invalid_grant"}`, http.StatusBadRequest)
+			return
+		}
+		subject, nonce = authorization.subject, authorization.nonce
+
+	case "refresh_token":
+		var ok bool
+		subject, ok = m.refreshTokens[req.Form.Get("refresh_token")]
+		if !ok {
+			http.Error(rw, `{"error":"invalid_grant"}`, http.StatusBadRequest)
+			return
+		}
+
+	default:
+		http.Error(rw, `{"error":"unsupported_grant_type"}`, http.StatusBadRequest)
+		return
+	}
+
+	m.tokens++
+	refreshToken := "refresh-" + subject + "-" + strconv.Itoa(m.tokens)
+	m.refreshTokens[refreshToken] = subject
+
+	claims := jwt.MapClaims{
+		"iss":    m.URL,
+		"aud":    "client",
+		"sub":    subject,
+		"email":  subject + "@example.com",
+		"groups": []string{"admin", "dev"},
+		"exp":    time.Now().Add(time.Hour).Unix(),
+		"iat":    time.Now().Unix(),
+	}
+	if nonce != "" {
+		claims["nonce"] = nonce
+	}
+	for name, value := range m.extraClaims {
+		claims[name] = value
+	}
+
+	idToken := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	idToken.Header["kid"] = "key"
+	signed, err := idToken.SignedString(m.key)
+	if err != nil {
+		http.Error(rw, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	_ = json.NewEncoder(rw).Encode(map[string]any{
+		"access_token":  "access-" + strconv.Itoa(m.tokens),
+		"token_type":    "Bearer",
+		"refresh_token": refreshToken,
+		"expires_in":    m.expiresIn,
+		"id_token":      signed,
+	})
+}
+
+// mockBrowser keeps the cookies set by the handler across requests.
+type mockBrowser struct {
+	handler http.Handler
+	cookies map[string]string
+}
+
+func newMockBrowser(handler http.Handler) *mockBrowser {
+	return &mockBrowser{handler: handler, cookies: make(map[string]string)}
+}
+
+func (b *mockBrowser) request(method, target string) *http.Request {
+	req := httptest.NewRequest(method, target, nil)
+	for name, value := range b.cookies {
+		req.AddCookie(&http.Cookie{Name: name, Value: value})
+	}
+	return req
+}
+
+func (b *mockBrowser) get(t *testing.T, target string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	recorder := httptest.NewRecorder()
+	b.handler.ServeHTTP(recorder, b.request(http.MethodGet, target))
+	b.store(recorder)
+
+	return recorder
+}
+
+func (b *mockBrowser) store(recorder *httptest.ResponseRecorder) {
+	for _, c := range recorder.Result().Cookies() {
+		if c.MaxAge < 0 {
+			delete(b.cookies, c.Name)
+			continue
+		}
+		b.cookies[c.Name] = c.Value
+	}
+}
+
+// login goes through the authorization code flow.
+func (b *mockBrowser) login(t *testing.T, idp *mockIdP, target, subject string) {
+	t.Helper()
+
+	resp := b.get(t, target)
+	require.Equal(t, http.StatusFound, resp.Code)
+
+	authURL, err := url.Parse(resp.Header().Get("Location"))
+	require.NoError(t, err)
+
+	resp = b.get(t, idp.authorize(t, authURL, subject))
+	require.Equal(t, http.StatusFound, resp.Code)
+}
@@ -25,11 +25,14 @@ func GetSourceExtractor(ctx context.Context, sourceMatcher *dynamic.SourceCriter
 		if sourceMatcher.RequestHeaderName != "" && sourceMatcher.RequestHost {
 			return nil, errors.New("requestHost and RequestHeaderName are mutually exclusive")
 		}
+		if sourceMatcher.APIKeyConsumer && (sourceMatcher.IPStrategy != nil || sourceMatcher.RequestHeaderName != "" || sourceMatcher.RequestHost) {
+			return nil, errors.New("apiKeyConsumer, IPStrategy, RequestHeaderName and RequestHost are mutually exclusive")
+		}
 	}
 
 	if sourceMatcher == nil ||
 		sourceMatcher.IPStrategy == nil &&
-			sourceMatcher.RequestHeaderName == "" && !sourceMatcher.RequestHost {
+			sourceMatcher.RequestHeaderName == "" && !sourceMatcher.RequestHost && !sourceMatcher.APIKeyConsumer {
 		sourceMatcher = &dynamic.SourceCriterion{
 			IPStrategy: &dynamic.IPStrategy{},
 		}
@@ -58,5 +61,16 @@ func GetSourceExtractor(ctx context.Context, sourceMatcher *dynamic.SourceCriter
 		return utils.NewExtractor("request.host")
 	}
 
+	if sourceMatcher.APIKeyConsumer {
+		logger.Debug().Msg("Using APIKeyConsumer")
+		return utils.ExtractorFunc(func(req *http.Request) (string, int64, error) {
+			consumer := GetAPIKeyConsumer(req.Context())
+			if consumer == "" {
+				return "", 0, errors.New("no API key consumer, the request must be authenticated by an APIKey middleware first")
+			}
+			return consumer, 1, nil
+		}), nil
+	}
+
 	return nil, errors.New("no SourceCriterion criterion defined")
 }
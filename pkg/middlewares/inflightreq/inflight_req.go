@@ -30,15 +30,7 @@ func New(ctx context.Context, next http.Handler, config dynamic.InFlightReq, nam
 
 	ctxLog := logger.WithContext(ctx)
 
-	if config.SourceCriterion == nil ||
-		config.SourceCriterion.IPStrategy == nil &&
-			config.SourceCriterion.RequestHeaderName == "" && !config.SourceCriterion.RequestHost {
-		config.SourceCriterion = &dynamic.SourceCriterion{
-			RequestHost: true,
-		}
-	}
-
-	sourceMatcher, err := middlewares.GetSourceExtractor(ctxLog, config.SourceCriterion)
+	sourceMatcher, err := middlewares.GetSourceExtractor(ctxLog, sourceCriterion(config.SourceCriterion))
 	if err != nil {
 		return nil, fmt.Errorf("error creating requests limiter: %w", err)
 	}
@@ -60,3 +52,46 @@ func (i *inFlightReq) GetTracingInformation() (string, string, trace.SpanKind) {
 func (i *inFlightReq) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
 	i.handler.ServeHTTP(rw, req)
 }
+
+type queuedInFlightReq struct {
+	next       http.Handler
+	name       string
+	queue      *Queue
+	routerName string
+}
+
+// NewQueued creates a max request middleware queueing the requests exceeding the limit in the given queue.
+func NewQueued(ctx context.Context, next http.Handler, queue *Queue, name string) http.Handler {
+	middlewares.GetLogger(ctx, name, typeName).Debug().Msg("Creating middleware")
+
+	return &queuedInFlightReq{
+		next:       next,
+		name:       name,
+		queue:      queue,
+		routerName: middlewares.GetRouterName(ctx),
+	}
+}
+
+func (q *queuedInFlightReq) GetTracingInformation() (string, string, trace.SpanKind) {
+	return q.name, typeName, trace.SpanKindInternal
+}
+
+func (q *queuedInFlightReq) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	logger := middlewares.GetLogger(req.Context(), q.name, typeName)
+
+	sourceName, _, err := q.queue.extractor.Extract(req)
+	if err != nil {
+		logger.Error().Err(err).Msg("Failed to extract the source of the request")
+		http.Error(rw, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	if err := q.queue.acquire(req.Context(), sourceName, q.queue.priority(req, q.routerName)); err != nil {
+		logger.Debug().Err(err).Msgf("Limiting request source %s", sourceName)
+		http.Error(rw, http.StatusText(http.StatusTooManyRequests), http.StatusTooManyRequests)
+		return
+	}
+	defer q.queue.release(sourceName)
+
+	q.next.ServeHTTP(rw, req)
+}
@@ -0,0 +1,319 @@
+package inflightreq
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	gokitmetrics "github.com/go-kit/kit/metrics"
+	"github.com/traefik/traefik/v3/pkg/config/dynamic"
+	"github.com/traefik/traefik/v3/pkg/ip"
+	"github.com/traefik/traefik/v3/pkg/metrics"
+	"github.com/traefik/traefik/v3/pkg/middlewares"
+	"github.com/vulcand/oxy/v2/utils"
+)
+
+var (
+	errQueueFull    = errors.New("queue is full")
+	errQueueTimeout = errors.New("timed out waiting in the queue")
+)
+
+// Queue limits the number of in-flight requests per source,
+// queueing the excess requests until they can be admitted.
+// It is shared by the instances of a middleware built for the different routers.
+type Queue struct {
+	amount    int64
+	maxLength int
+	maxWait   time.Duration
+
+	extractor utils.SourceExtractor
+	classes   []*priorityClass
+
+	depthGauge    gokitmetrics.Gauge
+	waitHistogram metrics.ScalableHistogram
+
+	mu      sync.Mutex
+	sources map[string]*source
+	depth   int
+}
+
+// source holds the in-flight and queued requests of a source.
+type source struct {
+	inflight int64
+	// waiters are the queued requests, by priority class.
+	waiters [][]*waiter
+	length  int
+}
+
+type waiter struct {
+	ready    chan struct{}
+	admitted bool
+}
+
+// NewQueue creates the queue of the in-flight request middleware with the given name.
+// metricsRegistry, which may be nil, records the queue depth and the requests wait time.
+func NewQueue(ctx context.Context, config dynamic.InFlightReq, name string, metricsRegistry metrics.Registry) (*Queue, error) {
+	if config.Amount <= 0 {
+		return nil, errors.New("amount must be greater than zero in queue mode")
+	}
+
+	queueConfig := config.Queue
+	if queueConfig == nil {
+		queueConfig = &dynamic.InFlightReqQueue{}
+		queueConfig.SetDefaults()
+	}
+
+	extractor, err := middlewares.GetSourceExtractor(ctx, sourceCriterion(config.SourceCriterion))
+	if err != nil {
+		return nil, fmt.Errorf("creating source extractor: %w", err)
+	}
+
+	q := &Queue{
+		amount:    config.Amount,
+		maxLength: queueConfig.MaxLength,
+		maxWait:   time.Duration(queueConfig.MaxWait),
+		extractor: extractor,
+		sources:   make(map[string]*source),
+	}
+
+	for i, classConfig := range queueConfig.PriorityClasses {
+		class, err := newPriorityClass(ctx, classConfig)
+		if err != nil {
+			return nil, fmt.Errorf("creating priority class %d: %w", i, err)
+		}
+		q.classes = append(q.classes, class)
+	}
+
+	if metricsRegistry != nil {
+		if gauge := metricsRegistry.MiddlewareQueueDepthGauge(); gauge != nil {
+			q.depthGauge = gauge.With("middleware", name)
+		}
+		if histogram := metricsRegistry.MiddlewareQueueWaitHistogram(); histogram != nil {
+			q.waitHistogram = histogram.With("middleware", name)
+		}
+	}
+
+	return q, nil
+}
+
+// priority returns the index of the first priority class matched by the request,
+// or the number of classes when it matches none.
+func (q *Queue) priority(req *http.Request, routerName string) int {
+	for i, class := range q.classes {
+		if class.match(req, routerName) {
+			return i
+		}
+	}
+	return len(q.classes)
+}
+
+// acquire waits until the request of the given source and priority can be admitted.
+func (q *Queue) acquire(ctx context.Context, sourceName string, priority int) error {
+	q.mu.Lock()
+
+	src, ok := q.sources[sourceName]
+	if !ok {
+		src = &source{waiters: make([][]*waiter, len(q.classes)+1)}
+		q.sources[sourceName] = src
+	}
+
+	if src.inflight < q.amount && src.length == 0 {
+		src.inflight++
+		q.mu.Unlock()
+		return nil
+	}
+
+	if src.length >= q.maxLength {
+		q.cleanup(sourceName, src)
+		q.mu.Unlock()
+		return errQueueFull
+	}
+
+	w := &waiter{ready: make(chan struct{})}
+	src.waiters[priority] = append(src.waiters[priority], w)
+	src.length++
+	q.updateDepth(1)
+	q.mu.Unlock()
+
+	start := time.Now()
+	if q.waitHistogram != nil {
+		defer q.waitHistogram.ObserveFromStart(start)
+	}
+
+	var timeout <-chan time.Time
+	if q.maxWait > 0 {
+		timer := time.NewTimer(q.maxWait)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	var err error
+	select {
+	case <-w.ready:
+		return nil
+	case <-timeout:
+		err = errQueueTimeout
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	// The request has been admitted while giving up.
+	if w.admitted {
+		return nil
+	}
+
+	if i := slices.Index(src.waiters[priority], w); i >= 0 {
+		src.waiters[priority] = slices.Delete(src.waiters[priority], i, i+1)
+		src.length--
+		q.updateDepth(-1)
+	}
+	q.cleanup(sourceName, src)
+
+	return err
+}
+
+// release frees the slot of an in-flight request of the given source,
+// admitting the next queued request by priority.
+func (q *Queue) release(sourceName string) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	src, ok := q.sources[sourceName]
+	if !ok {
+		return
+	}
+
+	src.inflight--
+
+	for priority, waiters := range src.waiters {
+		if len(waiters) == 0 {
+			continue
+		}
+
+		w := waiters[0]
+		waiters[0] = nil
+		src.waiters[priority] = waiters[1:]
+		src.length--
+		q.updateDepth(-1)
+
+		// The slot is handed over to the admitted request.
+		src.inflight++
+		w.admitted = true
+		close(w.ready)
+		break
+	}
+
+	q.cleanup(sourceName, src)
+}
+
+// cleanup forgets the source once it has no in-flight nor queued request.
+// The caller must hold the lock.
+func (q *Queue) cleanup(sourceName string, src *source) {
+	if src.inflight == 0 && src.length == 0 {
+		delete(q.sources, sourceName)
+	}
+}
+
+// updateDepth updates the number of queued requests.
+// The caller must hold the lock.
+func (q *Queue) updateDepth(delta int) {
+	q.depth += delta
+	if q.depthGauge != nil {
+		q.depthGauge.Set(float64(q.depth))
+	}
+}
+
+// priorityClass matches the requests of a priority class.
+type priorityClass struct {
+	headerName   string
+	headerValues []string
+
+	extractor utils.SourceExtractor
+	sources   []string
+	checker   *ip.Checker
+
+	// routers are the qualified names of the routers matching the class.
+	routers []string
+}
+
+func newPriorityClass(ctx context.Context, config dynamic.InFlightReqPriorityClass) (*priorityClass, error) {
+	class := &priorityClass{
+		headerName:   config.HeaderName,
+		headerValues: config.HeaderValues,
+		sources:      config.Sources,
+		routers:      config.Routers,
+	}
+
+	if config.SourceCriterion == nil {
+		return class, nil
+	}
+
+	var err error
+	class.extractor, err = middlewares.GetSourceExtractor(ctx, config.SourceCriterion)
+	if err != nil {
+		return nil, fmt.Errorf("creating source extractor: %w", err)
+	}
+
+	if config.SourceCriterion.IPStrategy != nil && len(config.Sources) > 0 {
+		class.checker, err = ip.NewChecker(config.Sources)
+		if err != nil {
+			return nil, fmt.Errorf("parsing sources: %w", err)
+		}
+	}
+
+	return class, nil
+}
+
+func (c *priorityClass) match(req *http.Request, routerName string) bool {
+	if c.headerName != "" {
+		if values := req.Header.Values(c.headerName); len(values) > 0 {
+			if len(c.headerValues) == 0 {
+				return true
+			}
+			for _, value := range values {
+				if slices.Contains(c.headerValues, value) {
+					return true
+				}
+			}
+		}
+	}
+
+	if routerName != "" && slices.Contains(c.routers, routerName) {
+		return true
+	}
+
+	if c.extractor == nil {
+		return false
+	}
+
+	sourceName, _, err := c.extractor.Extract(req)
+	if err != nil {
+		return false
+	}
+
+	if c.checker != nil {
+		ok, err := c.checker.Contains(sourceName)
+		return err == nil && ok
+	}
+
+	return slices.Contains(c.sources, sourceName)
+}
+
+// sourceCriterion returns the given source criterion, defaulting to the request host.
+func sourceCriterion(criterion *dynamic.SourceCriterion) *dynamic.SourceCriterion {
+	if criterion == nil ||
+		criterion.IPStrategy == nil &&
+			criterion.RequestHeaderName == "" && !criterion.RequestHost && !criterion.APIKeyConsumer {
+		return &dynamic.SourceCriterion{
+			RequestHost: true,
+		}
+	}
+	return criterion
+}
@@ -0,0 +1,195 @@
+package inflightreq
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	ptypes "github.com/traefik/paerser/types"
+	"github.com/traefik/traefik/v3/pkg/config/dynamic"
+	"github.com/traefik/traefik/v3/pkg/middlewares"
+	"github.com/traefik/traefik/v3/pkg/testhelpers"
+)
+
+func TestQueued(t *testing.T) {
+	testCases := []struct {
+		desc         string
+		maxLength    int
+		maxWait      time.Duration
+		expectedCode int
+	}{
+		{
+			desc:         "queued request admitted",
+			maxLength:    1,
+			maxWait:      time.Second,
+			expectedCode: http.StatusOK,
+		},
+		{
+			desc:         "queue full",
+			maxLength:    0,
+			maxWait:      time.Second,
+			expectedCode: http.StatusTooManyRequests,
+		},
+		{
+			desc:         "max wait exceeded",
+			maxLength:    1,
+			maxWait:      10 * time.Millisecond,
+			expectedCode: http.StatusTooManyRequests,
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			queue, err := NewQueue(t.Context(), dynamic.InFlightReq{
+				Amount: 1,
+				Queue: &dynamic.InFlightReqQueue{
+					MaxLength: test.maxLength,
+					MaxWait:   ptypes.Duration(test.maxWait),
+				},
+			}, "test", nil)
+			require.NoError(t, err)
+
+			unblock := make(chan struct{})
+			started := make(chan struct{}, 2)
+			handler := NewQueued(t.Context(), http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+				started <- struct{}{}
+				if req.Header.Get("X-Block") != "" {
+					<-unblock
+				}
+			}), queue, "test")
+
+			blocking := httptest.NewRequest(http.MethodGet, "http://foo", nil)
+			blocking.Header.Set("X-Block", "true")
+			done := make(chan struct{})
+			go func() {
+				handler.ServeHTTP(httptest.NewRecorder(), blocking)
+				close(done)
+			}()
+			<-started
+
+			if test.expectedCode == http.StatusOK {
+				go func() {
+					// Releases the in-flight request once the second one is queued.
+					for queueDepth(queue) == 0 {
+						time.Sleep(time.Millisecond)
+					}
+					close(unblock)
+				}()
+			}
+
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "http://foo", nil))
+			assert.Equal(t, test.expectedCode, recorder.Code)
+
+			if test.expectedCode != http.StatusOK {
+				close(unblock)
+			}
+			<-done
+
+			assert.Equal(t, 0, queueDepth(queue))
+			assert.Empty(t, queue.sources)
+		})
+	}
+}
+
+func TestQueuePriority(t *testing.T) {
+	queue, err := NewQueue(t.Context(), dynamic.InFlightReq{
+		Amount: 1,
+		Queue: &dynamic.InFlightReqQueue{
+			MaxLength: 10,
+			MaxWait:   ptypes.Duration(time.Second),
+			PriorityClasses: []dynamic.InFlightReqPriorityClass{
+				{HeaderName: "X-Priority", HeaderValues: []string{"high"}},
+				{Routers: []string{"medium@file"}},
+			},
+		},
+	}, "test", nil)
+	require.NoError(t, err)
+
+	high := httptest.NewRequest(http.MethodGet, "http://foo", nil)
+	high.Header.Set("X-Priority", "high")
+	low := httptest.NewRequest(http.MethodGet, "http://foo", nil)
+
+	assert.Equal(t, 0, queue.priority(high, "medium@file"))
+	assert.Equal(t, 1, queue.priority(low, "medium@file"))
+	assert.Equal(t, 2, queue.priority(low, "other@file"))
+
+	require.NoError(t, queue.acquire(t.Context(), "foo", 2))
+
+	admitted := make(chan string, 3)
+	for _, name := range []string{"low", "medium", "high"} {
+		priority := map[string]int{"high": 0, "medium": 1, "low": 2}[name]
+		depth := queueDepth(queue)
+		go func() {
+			assert.NoError(t, queue.acquire(context.Background(), "foo", priority))
+			admitted <- name
+		}()
+		for queueDepth(queue) == depth {
+			time.Sleep(time.Millisecond)
+		}
+	}
+
+	var order []string
+	for range 3 {
+		queue.release("foo")
+		order = append(order, <-admitted)
+	}
+	queue.release("foo")
+
+	assert.Equal(t, []string{"high", "medium", "low"}, order)
+	assert.Empty(t, queue.sources)
+}
+
+func TestQueuedPriorityFromRouter(t *testing.T) {
+	queue, err := NewQueue(t.Context(), dynamic.InFlightReq{
+		Amount: 1,
+		Queue: &dynamic.InFlightReqQueue{
+			PriorityClasses: []dynamic.InFlightReqPriorityClass{
+				{Routers: []string{"router@file"}},
+			},
+		},
+	}, "test", nil)
+	require.NoError(t, err)
+
+	handler := NewQueued(middlewares.WithRouterName(t.Context(), "router@file"), http.NotFoundHandler(), queue, "test")
+
+	assert.Equal(t, 0, queue.priority(httptest.NewRequest(http.MethodGet, "http://foo", nil), handler.(*queuedInFlightReq).routerName))
+}
+
+func TestQueueMetrics(t *testing.T) {
+	gauge := &testhelpers.CollectingGauge{}
+
+	queue, err := NewQueue(t.Context(), dynamic.InFlightReq{
+		Amount: 1,
+		Queue:  &dynamic.InFlightReqQueue{MaxLength: 1, MaxWait: ptypes.Duration(10 * time.Millisecond)},
+	}, "test", nil)
+	require.NoError(t, err)
+	queue.depthGauge = gauge.With("middleware", "test")
+
+	require.NoError(t, queue.acquire(t.Context(), "foo", 0))
+
+	err = queue.acquire(t.Context(), "foo", 0)
+	assert.ErrorIs(t, err, errQueueTimeout)
+	assert.InDelta(t, 0, gauge.GaugeValue, 0)
+	assert.Equal(t, []string{"middleware", "test"}, gauge.LastLabelValues)
+
+	queue.release("foo")
+	assert.Empty(t, queue.sources)
+}
+
+func TestNewQueueInvalidAmount(t *testing.T) {
+	_, err := NewQueue(t.Context(), dynamic.InFlightReq{Queue: &dynamic.InFlightReqQueue{}}, "test", nil)
+	assert.Error(t, err)
+}
+
+func queueDepth(q *Queue) int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.depth
+}
@@ -17,3 +17,42 @@ func GetLogger(ctx context.Context, middleware, middlewareType string) *zerolog.
 
 	return &logger
 }
+
+type routerNameKey struct{}
+
+// WithRouterName returns a context holding the name of the router the middlewares are built for.
+func WithRouterName(ctx context.Context, routerName string) context.Context {
+	return context.WithValue(ctx, routerNameKey{}, routerName)
+}
+
+// GetRouterName returns the name of the router the middlewares are built for, if any.
+func GetRouterName(ctx context.Context) string {
+	routerName, _ := ctx.Value(routerNameKey{}).(string)
+	return routerName
+}
+
+type apiKeyConsumerKey struct{}
+
+// WithAPIKeyConsumer returns a context holding the consumer of the API key authenticating the request.
+func WithAPIKeyConsumer(ctx context.Context, consumer string) context.Context {
+	return context.WithValue(ctx, apiKeyConsumerKey{}, consumer)
+}
+
+// GetAPIKeyConsumer returns the consumer of the API key authenticating the request, if any.
+func GetAPIKeyConsumer(ctx context.Context) string {
+	consumer, _ := ctx.Value(apiKeyConsumerKey{}).(string)
+	return consumer
+}
+
+type jwtClaimsKey struct{}
+
+// WithJWTClaims returns a context holding the claims of the JWT authenticating the request.
+func WithJWTClaims(ctx context.Context, claims map[string]any) context.Context {
+	return context.WithValue(ctx, jwtClaimsKey{}, claims)
+}
+
+// GetJWTClaims returns the claims of the JWT authenticating the request, if any.
+func GetJWTClaims(ctx context.Context) map[string]any {
+	claims, _ := ctx.Value(jwtClaimsKey{}).(map[string]any)
+	return claims
+}
@@ -42,7 +42,7 @@ func newInMemoryRateLimiter(rate rate.Limit, burst int64, maxDelay time.Duration
 	}, nil
 }
 
-func (i *inMemoryRateLimiter) Allow(_ context.Context, source string) (*time.Duration, error) {
+func (i *inMemoryRateLimiter) Allow(_ context.Context, source string) (*time.Duration, float64, error) {
 	// Get bucket which contains limiter information.
 	var bucket *rate.Limiter
 	if rlSource, exists := i.buckets.Get(source); exists {
@@ -55,12 +55,12 @@ func (i *inMemoryRateLimiter) Allow(_ context.Context, source string) (*time.Dur
 	// because we want to update the expiryTime everytime we get the source,
 	// as the expiryTime is supposed to reflect the activity (or lack thereof) on that source.
 	if err := i.buckets.Set(source, bucket, i.ttl); err != nil {
-		return nil, fmt.Errorf("setting buckets: %w", err)
+		return nil, 0, fmt.Errorf("setting buckets: %w", err)
 	}
 
 	res := bucket.Reserve()
 	if !res.OK() {
-		return nil, nil
+		return nil, 0, nil
 	}
 
 	delay := res.Delay()
@@ -68,5 +68,5 @@ func (i *inMemoryRateLimiter) Allow(_ context.Context, source string) (*time.Dur
 		res.Cancel()
 	}
 
-	return &delay, nil
+	return &delay, bucket.Tokens(), nil
 }
@@ -64,3 +64,19 @@ redis.call('expire', key, ttl)
 return {tostring(true), tostring(wait_duration),tostring(tokens)}`
 
 var AllowTokenBucketScript = redis.NewScript(AllowTokenBucketRaw)
+
+var ConsumeQuotaRaw = `
+local key = KEYS[1]
+local limit, expire_at = tonumber(ARGV[1]), tonumber(ARGV[2])
+
+local count = tonumber(redis.call('get', key)) or 0
+if count >= limit then
+    return {tostring(false), tostring(count)}
+end
+
+count = redis.call('incr', key)
+redis.call('expireat', key, expire_at)
+
+return {tostring(true), tostring(count)}`
+
+var ConsumeQuotaScript = redis.NewScript(ConsumeQuotaRaw)
@@ -0,0 +1,93 @@
+package ratelimiter
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/traefik/traefik/v3/pkg/config/dynamic"
+	"github.com/traefik/traefik/v3/pkg/middlewares"
+)
+
+// planSelector returns the plan of a request, or an empty string when the request has no plan.
+type planSelector func(req *http.Request) string
+
+func newPlanSelector(criterion *dynamic.PlanCriterion, plans map[string]*limits) (planSelector, error) {
+	if criterion == nil {
+		if len(plans) > 0 {
+			return nil, errors.New("planCriterion must be defined along with the plans")
+		}
+		return nil, nil
+	}
+
+	var criteria int
+	for _, set := range []bool{criterion.RequestHeaderName != "", criterion.JWTClaim != "", criterion.APIKeyConsumer} {
+		if set {
+			criteria++
+		}
+	}
+	if criteria != 1 {
+		return nil, errors.New("exactly one of requestHeaderName, jwtClaim and apiKeyConsumer must be defined in planCriterion")
+	}
+
+	for value, plan := range criterion.Mapping {
+		if _, ok := plans[plan]; !ok {
+			return nil, fmt.Errorf("plan %q of value %q is not defined", plan, value)
+		}
+	}
+
+	var value func(req *http.Request) string
+	switch {
+	case criterion.RequestHeaderName != "":
+		value = func(req *http.Request) string {
+			return req.Header.Get(criterion.RequestHeaderName)
+		}
+
+	case criterion.JWTClaim != "":
+		path := strings.Split(criterion.JWTClaim, ".")
+		value = func(req *http.Request) string {
+			return claimValue(middlewares.GetJWTClaims(req.Context()), path)
+		}
+
+	default:
+		value = func(req *http.Request) string {
+			return middlewares.GetAPIKeyConsumer(req.Context())
+		}
+	}
+
+	return func(req *http.Request) string {
+		v := value(req)
+		if plan, ok := criterion.Mapping[v]; ok {
+			return plan
+		}
+		return v
+	}, nil
+}
+
+// claimValue returns the string representation of the claim at the given path, if any.
+func claimValue(claims map[string]any, path []string) string {
+	var value any = claims
+	for _, name := range path {
+		object, ok := value.(map[string]any)
+		if !ok {
+			return ""
+		}
+		value = object[name]
+	}
+
+	switch v := value.(type) {
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,248 @@
+package ratelimiter
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// quotaFlushInterval is the maximum delay before the quota counters are written to the quota file.
+	quotaFlushInterval = time.Second
+	// quotaPurgeInterval is the minimum interval between two removals of the expired in-memory quota counters.
+	quotaPurgeInterval = time.Minute
+)
+
+type quotaStore interface {
+	// Consume counts a request for the key when its count is below the limit,
+	// and returns the count along with whether the request has been counted.
+	// The count expires at the given time.
+	Consume(ctx context.Context, key string, limit int64, expiresAt time.Time) (int64, bool, error)
+}
+
+// quota limits the number of requests of a source in a calendar window.
+type quota struct {
+	limit  int64
+	window string
+	store  quotaStore
+}
+
+func newQuota(limit int64, window string, store quotaStore) (*quota, error) {
+	if limit <= 0 {
+		return nil, errors.New("quota limit must be greater than 0")
+	}
+
+	if window == "" {
+		window = "day"
+	}
+
+	if _, _, err := windowBounds(window, time.Now()); err != nil {
+		return nil, err
+	}
+
+	return &quota{
+		limit:  limit,
+		window: window,
+		store:  store,
+	}, nil
+}
+
+// consume counts a request for the source in the current window, and returns the resulting quota status.
+func (q *quota) consume(ctx context.Context, source string, now time.Time) (limitStatus, bool, error) {
+	// The window is validated on creation.
+	start, end, _ := windowBounds(q.window, now)
+
+	// The window start is part of the key, so that the counts of the previous windows are not reused even when not expired yet.
+	key := source + ":" + q.window + ":" + strconv.FormatInt(start.Unix(), 10)
+
+	count, ok, err := q.store.Consume(ctx, key, q.limit, end)
+	if err != nil {
+		return limitStatus{}, false, err
+	}
+
+	return limitStatus{
+		limit:     q.limit,
+		remaining: max(q.limit-count, 0),
+		reset:     end.Sub(now),
+	}, ok, nil
+}
+
+// windowBounds returns the start and the end of the calendar window, in UTC, holding the given time.
+func windowBounds(window string, now time.Time) (time.Time, time.Time, error) {
+	now = now.UTC()
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+
+	switch window {
+	case "hour":
+		start := now.Truncate(time.Hour)
+		return start, start.Add(time.Hour), nil
+	case "day":
+		return day, day.AddDate(0, 0, 1), nil
+	case "week":
+		// Weeks start on Monday.
+		start := day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+		return start, start.AddDate(0, 0, 7), nil
+	case "month":
+		start := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+		return start, start.AddDate(0, 1, 0), nil
+	default:
+		return time.Time{}, time.Time{}, fmt.Errorf("unsupported quota window %q, must be one of hour, day, week and month", window)
+	}
+}
+
+type quotaCounter struct {
+	Count     int64     `json:"count"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+var (
+	fileQuotaStoresMu sync.Mutex
+	// fileQuotaStores holds the file quota stores by file path,
+	// so that the middlewares sharing a file, or recreated on configuration reloads, share the counters.
+	fileQuotaStores = make(map[string]*fileQuotaStore)
+)
+
+// fileQuotaStore keeps the quota counters in memory, and persists them in a file when a path is set.
+type fileQuotaStore struct {
+	path string
+
+	mu         sync.Mutex
+	counters   map[string]*quotaCounter
+	purgedAt   time.Time
+	flushTimer *time.Timer
+
+	// writeMu ensures that the file is written once at a time.
+	writeMu sync.Mutex
+}
+
+func newMemoryQuotaStore() *fileQuotaStore {
+	return &fileQuotaStore{
+		counters: make(map[string]*quotaCounter),
+		purgedAt: time.Now(),
+	}
+}
+
+// getFileQuotaStore returns the quota store persisted in the given file, loading its counters when created.
+func getFileQuotaStore(path string) (*fileQuotaStore, error) {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+
+	fileQuotaStoresMu.Lock()
+	defer fileQuotaStoresMu.Unlock()
+
+	if store, ok := fileQuotaStores[path]; ok {
+		return store, nil
+	}
+
+	store := newMemoryQuotaStore()
+	store.path = path
+
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("reading quota file: %w", err)
+	}
+
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &store.counters); err != nil {
+			return nil, fmt.Errorf("parsing quota file %s: %w", path, err)
+		}
+		store.purge(time.Now())
+	}
+
+	fileQuotaStores[path] = store
+
+	return store, nil
+}
+
+func (s *fileQuotaStore) Consume(_ context.Context, key string, limit int64, expiresAt time.Time) (int64, bool, error) {
+	now := time.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if now.Sub(s.purgedAt) > quotaPurgeInterval {
+		s.purge(now)
+	}
+
+	counter, ok := s.counters[key]
+	if !ok || !now.Before(counter.ExpiresAt) {
+		counter = &quotaCounter{ExpiresAt: expiresAt}
+		s.counters[key] = counter
+	}
+
+	if counter.Count >= limit {
+		return counter.Count, false, nil
+	}
+
+	counter.Count++
+
+	if s.path != "" && s.flushTimer == nil {
+		s.flushTimer = time.AfterFunc(quotaFlushInterval, s.flush)
+	}
+
+	return counter.Count, true, nil
+}
+
+// purge removes the expired counters. The caller must hold the lock.
+func (s *fileQuotaStore) purge(now time.Time) {
+	for key, counter := range s.counters {
+		if !now.Before(counter.ExpiresAt) {
+			delete(s.counters, key)
+		}
+	}
+	s.purgedAt = now
+}
+
+// flush writes the counters to the quota file.
+func (s *fileQuotaStore) flush() {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	s.mu.Lock()
+	s.flushTimer = nil
+	s.purge(time.Now())
+	data, err := json.Marshal(s.counters)
+	s.mu.Unlock()
+
+	if err != nil {
+		log.Error().Err(err).Str("file", s.path).Msg("Unable to encode the quota counters")
+		return
+	}
+
+	if err := writeFileAtomically(s.path, data); err != nil {
+		log.Error().Err(err).Str("file", s.path).Msg("Unable to write the quota file")
+	}
+}
+
+// writeFileAtomically writes the data to a temporary file renamed to the given path,
+// so that the file is never partially written.
+func writeFileAtomically(path string, data []byte) error {
+	file, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+
+	if _, err := file.Write(data); err != nil {
+		_ = file.Close()
+		_ = os.Remove(file.Name())
+		return err
+	}
+
+	if err := file.Close(); err != nil {
+		_ = os.Remove(file.Name())
+		return err
+	}
+
+	return os.Rename(file.Name(), path)
+}
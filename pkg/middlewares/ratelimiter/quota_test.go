@@ -0,0 +1,149 @@
+package ratelimiter
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWindowBounds(t *testing.T) {
+	// Wednesday.
+	now := time.Date(2024, time.February, 28, 15, 42, 10, 0, time.UTC)
+
+	testCases := []struct {
+		window        string
+		expectedStart time.Time
+		expectedEnd   time.Time
+		expectedErr   bool
+	}{
+		{
+			window:        "hour",
+			expectedStart: time.Date(2024, time.February, 28, 15, 0, 0, 0, time.UTC),
+			expectedEnd:   time.Date(2024, time.February, 28, 16, 0, 0, 0, time.UTC),
+		},
+		{
+			window:        "day",
+			expectedStart: time.Date(2024, time.February, 28, 0, 0, 0, 0, time.UTC),
+			expectedEnd:   time.Date(2024, time.February, 29, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			window:        "week",
+			expectedStart: time.Date(2024, time.February, 26, 0, 0, 0, 0, time.UTC),
+			expectedEnd:   time.Date(2024, time.March, 4, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			window:        "month",
+			expectedStart: time.Date(2024, time.February, 1, 0, 0, 0, 0, time.UTC),
+			expectedEnd:   time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			window:      "year",
+			expectedErr: true,
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.window, func(t *testing.T) {
+			t.Parallel()
+
+			start, end, err := windowBounds(test.window, now.In(time.FixedZone("UTC+10", 10*3600)))
+			if test.expectedErr {
+				assert.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, test.expectedStart, start)
+			assert.Equal(t, test.expectedEnd, end)
+		})
+	}
+}
+
+func TestWindowBounds_weekStartsOnMonday(t *testing.T) {
+	// Sunday.
+	start, _, err := windowBounds("week", time.Date(2024, time.March, 3, 23, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+
+	assert.Equal(t, time.Date(2024, time.February, 26, 0, 0, 0, 0, time.UTC), start)
+}
+
+func TestMemoryQuotaStore(t *testing.T) {
+	store := newMemoryQuotaStore()
+	expiresAt := time.Now().Add(time.Hour)
+
+	for _, expected := range []int64{1, 2} {
+		count, ok, err := store.Consume(t.Context(), "foo", 2, expiresAt)
+		require.NoError(t, err)
+		assert.True(t, ok)
+		assert.Equal(t, expected, count)
+	}
+
+	count, ok, err := store.Consume(t.Context(), "foo", 2, expiresAt)
+	require.NoError(t, err)
+	assert.False(t, ok)
+	assert.Equal(t, int64(2), count)
+
+	// An expired count is reset.
+	store.counters["foo"].ExpiresAt = time.Now().Add(-time.Second)
+
+	count, ok, err = store.Consume(t.Context(), "foo", 2, expiresAt)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, int64(1), count)
+}
+
+func TestFileQuotaStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "quota.json")
+
+	store, err := getFileQuotaStore(path)
+	require.NoError(t, err)
+
+	// The stores are shared by file.
+	other, err := getFileQuotaStore(path)
+	require.NoError(t, err)
+	assert.Same(t, store, other)
+
+	expiresAt := time.Now().Add(time.Hour)
+	for range 3 {
+		_, _, err = store.Consume(t.Context(), "foo", 5, expiresAt)
+		require.NoError(t, err)
+	}
+
+	_, _, err = store.Consume(t.Context(), "expired", 5, time.Now())
+	require.NoError(t, err)
+
+	store.mu.Lock()
+	require.NotNil(t, store.flushTimer)
+	store.flushTimer.Stop()
+	store.mu.Unlock()
+
+	store.flush()
+
+	// Simulates a restart.
+	fileQuotaStoresMu.Lock()
+	delete(fileQuotaStores, store.path)
+	fileQuotaStoresMu.Unlock()
+
+	reloaded, err := getFileQuotaStore(path)
+	require.NoError(t, err)
+	assert.NotSame(t, store, reloaded)
+
+	count, ok, err := reloaded.Consume(t.Context(), "foo", 5, expiresAt)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, int64(4), count)
+
+	assert.NotContains(t, reloaded.counters, "expired")
+}
+
+func TestFileQuotaStore_invalidFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "quota.json")
+	require.NoError(t, os.WriteFile(path, []byte("not json"), 0o600))
+
+	_, err := getFileQuotaStore(path)
+	assert.Error(t, err)
+}
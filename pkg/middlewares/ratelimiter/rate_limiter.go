@@ -6,10 +6,12 @@ import (
 	"fmt"
 	"math"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
+	ptypes "github.com/traefik/paerser/types"
 	"github.com/traefik/traefik/v3/pkg/config/dynamic"
 	"github.com/traefik/traefik/v3/pkg/middlewares"
 	"github.com/traefik/traefik/v3/pkg/middlewares/observability"
@@ -24,22 +26,33 @@ const (
 )
 
 type limiter interface {
-	Allow(ctx context.Context, token string) (*time.Duration, error)
+	// Allow reserves a token in the bucket of the source,
+	// and returns the delay before the reservation is effective along with the tokens left in the bucket.
+	Allow(ctx context.Context, token string) (*time.Duration, float64, error)
+}
+
+// limits holds the rate and the quota applied to the requests of a plan.
+type limits struct {
+	rate  rate.Limit // reqs/s
+	burst int64
+	// maxDelay is the maximum duration we're willing to wait for a bucket reservation to become effective, in nanoseconds.
+	// For now it is somewhat arbitrarily set to 1/(2*rate).
+	maxDelay time.Duration
+	limiter  limiter
+	quota    *quota
 }
 
 // rateLimiter implements rate limiting and traffic shaping with a set of token buckets;
-// one for each traffic source. The same parameters are applied to all the buckets.
+// one for each traffic source. The same parameters are applied to all the buckets of a plan.
 type rateLimiter struct {
 	name string
-	rate rate.Limit // reqs/s
-	// maxDelay is the maximum duration we're willing to wait for a bucket reservation to become effective, in nanoseconds.
-	// For now it is somewhat arbitrarily set to 1/(2*rate).
-	maxDelay      time.Duration
+	// limits are the limits applied to the requests without plan.
+	limits
+	plans         map[string]*limits
+	planSelector  planSelector
 	sourceMatcher utils.SourceExtractor
 	next          http.Handler
 	logger        *zerolog.Logger
-
-	limiter limiter
 }
 
 // New returns a rate limiter middleware.
@@ -51,7 +64,8 @@ func New(ctx context.Context, next http.Handler, config dynamic.RateLimit, name
 
 	if config.SourceCriterion == nil ||
 		config.SourceCriterion.IPStrategy == nil &&
-			config.SourceCriterion.RequestHeaderName == "" && !config.SourceCriterion.RequestHost {
+			config.SourceCriterion.RequestHeaderName == "" && !config.SourceCriterion.RequestHost &&
+			!config.SourceCriterion.APIKeyConsumer {
 		config.SourceCriterion = &dynamic.SourceCriterion{
 			IPStrategy: &dynamic.IPStrategy{},
 		}
@@ -62,12 +76,57 @@ func New(ctx context.Context, next http.Handler, config dynamic.RateLimit, name
 		return nil, fmt.Errorf("getting source extractor: %w", err)
 	}
 
-	burst := config.Burst
+	b := &limitsBuilder{config: config, logger: logger}
+	if config.Redis != nil {
+		b.redisClient, err = newRedisClient(ctx, config.Redis)
+		if err != nil {
+			return nil, fmt.Errorf("creating redis limiter: %w", err)
+		}
+	}
+
+	defaultLimits, err := b.build(config.Average, config.Period, config.Burst, config.Quota)
+	if err != nil {
+		return nil, err
+	}
+
+	plans := make(map[string]*limits, len(config.Plans))
+	for planName, plan := range config.Plans {
+		plans[planName], err = b.build(plan.Average, plan.Period, plan.Burst, plan.Quota)
+		if err != nil {
+			return nil, fmt.Errorf("creating plan %s: %w", planName, err)
+		}
+	}
+
+	selector, err := newPlanSelector(config.PlanCriterion, plans)
+	if err != nil {
+		return nil, err
+	}
+
+	return &rateLimiter{
+		logger:        logger,
+		name:          name,
+		limits:        *defaultLimits,
+		plans:         plans,
+		planSelector:  selector,
+		next:          next,
+		sourceMatcher: sourceMatcher,
+	}, nil
+}
+
+// limitsBuilder builds the limits of the plans, sharing the Redis client and the quota store between them.
+type limitsBuilder struct {
+	config      dynamic.RateLimit
+	logger      *zerolog.Logger
+	redisClient Rediser
+	quotaStore  quotaStore
+}
+
+func (b *limitsBuilder) build(average int64, configPeriod ptypes.Duration, burst int64, quotaConfig *dynamic.RateLimitQuota) (*limits, error) {
 	if burst < 1 {
 		burst = 1
 	}
 
-	period := time.Duration(config.Period)
+	period := time.Duration(configPeriod)
 	if period < 0 {
 		return nil, fmt.Errorf("negative value not valid for period: %v", period)
 	}
@@ -81,8 +140,8 @@ func New(ctx context.Context, next http.Handler, config dynamic.RateLimit, name
 	// will be <= 0 in the Inf case (i.e. the average == 0 case).
 	var maxDelay time.Duration
 
-	if config.Average > 0 {
-		rtl = float64(config.Average*int64(time.Second)) / float64(period)
+	if average > 0 {
+		rtl = float64(average*int64(time.Second)) / float64(period)
 		// maxDelay does not scale well for rates below 1,
 		// so we just cap it to the corresponding value, i.e. 0.5s, in order to keep the effective rate predictable.
 		// One alternative would be to switch to a no-reservation mode (Allow() method) whenever we are in such a low rate regime.
@@ -103,28 +162,57 @@ func New(ctx context.Context, next http.Handler, config dynamic.RateLimit, name
 	} else if rtl > 0 {
 		ttl += int(1 / rtl)
 	}
-	var limiter limiter
-	if config.Redis != nil {
-		limiter, err = newRedisLimiter(ctx, rate.Limit(rtl), burst, maxDelay, ttl, config, logger)
-		if err != nil {
-			return nil, fmt.Errorf("creating redis limiter: %w", err)
-		}
+
+	l := &limits{
+		rate:     rate.Limit(rtl),
+		burst:    burst,
+		maxDelay: maxDelay,
+	}
+
+	if b.redisClient != nil {
+		l.limiter = newRedisLimiter(rate.Limit(rtl), burst, maxDelay, ttl, configPeriod, b.redisClient, b.logger)
 	} else {
-		limiter, err = newInMemoryRateLimiter(rate.Limit(rtl), burst, maxDelay, ttl, logger)
+		var err error
+		l.limiter, err = newInMemoryRateLimiter(rate.Limit(rtl), burst, maxDelay, ttl, b.logger)
 		if err != nil {
 			return nil, fmt.Errorf("creating in-memory limiter: %w", err)
 		}
 	}
 
-	return &rateLimiter{
-		logger:        logger,
-		name:          name,
-		rate:          rate.Limit(rtl),
-		maxDelay:      maxDelay,
-		next:          next,
-		sourceMatcher: sourceMatcher,
-		limiter:       limiter,
-	}, nil
+	if quotaConfig != nil {
+		store, err := b.getQuotaStore()
+		if err != nil {
+			return nil, fmt.Errorf("creating quota store: %w", err)
+		}
+
+		l.quota, err = newQuota(quotaConfig.Limit, quotaConfig.Window, store)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return l, nil
+}
+
+func (b *limitsBuilder) getQuotaStore() (quotaStore, error) {
+	if b.quotaStore != nil {
+		return b.quotaStore, nil
+	}
+
+	switch {
+	case b.redisClient != nil:
+		b.quotaStore = &redisQuotaStore{client: b.redisClient}
+	case b.config.QuotaFile != "":
+		store, err := getFileQuotaStore(b.config.QuotaFile)
+		if err != nil {
+			return nil, err
+		}
+		b.quotaStore = store
+	default:
+		b.quotaStore = newMemoryQuotaStore()
+	}
+
+	return b.quotaStore, nil
 }
 
 func (rl *rateLimiter) GetTracingInformation() (string, string, trace.SpanKind) {
@@ -146,28 +234,73 @@ func (rl *rateLimiter) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
 		logger.Info().Msgf("ignoring token bucket amount > 1: %d", amount)
 	}
 
+	plan, l := rl.selectLimits(req)
+
 	// Each rate limiter has its own source space,
 	// ensuring independence between rate limiters,
 	// i.e., rate limit rules are only applied based on traffic
 	// where the rate limiter is active.
+	// Each plan has its own source space as well, as the buckets and quotas of a plan have its own parameters.
 	rlSource := fmt.Sprintf("%s:%s", rl.name, source)
-	delay, err := rl.limiter.Allow(ctx, rlSource)
-	if err != nil {
-		rl.logger.Error().Err(err).Msg("Could not insert/update bucket")
-		observability.SetStatusErrorf(ctx, "Could not insert/update bucket")
-		http.Error(rw, "Could not insert/update bucket", http.StatusInternalServerError)
-		return
+	if plan != "" {
+		rlSource = fmt.Sprintf("%s:%q:%s", rl.name, plan, source)
 	}
 
-	if delay == nil {
-		observability.SetStatusErrorf(ctx, "No bursty traffic allowed")
-		http.Error(rw, "No bursty traffic allowed", http.StatusTooManyRequests)
-		return
+	var status *limitStatus
+	var delay time.Duration
+
+	if l.rate != rate.Inf {
+		reservationDelay, tokens, err := l.limiter.Allow(ctx, rlSource)
+		if err != nil {
+			rl.logger.Error().Err(err).Msg("Could not insert/update bucket")
+			observability.SetStatusErrorf(ctx, "Could not insert/update bucket")
+			// The state of the bucket is unknown, the client can retry once a token is added to it.
+			rw.Header().Set("Retry-After", strconv.FormatInt(ceilSeconds(l.tokenInterval()), 10))
+			http.Error(rw, "Could not insert/update bucket", http.StatusInternalServerError)
+			return
+		}
+
+		if reservationDelay == nil {
+			l.bucketStatus(tokens).setHeaders(rw.Header())
+			rw.Header().Set("Retry-After", strconv.FormatInt(ceilSeconds(l.tokenInterval()), 10))
+			observability.SetStatusErrorf(ctx, "No bursty traffic allowed")
+			http.Error(rw, "No bursty traffic allowed", http.StatusTooManyRequests)
+			return
+		}
+
+		status = l.bucketStatus(tokens)
+
+		if *reservationDelay > l.maxDelay {
+			status.setHeaders(rw.Header())
+			rl.serveDelayError(ctx, rw, *reservationDelay)
+			return
+		}
+
+		delay = *reservationDelay
 	}
 
-	if *delay > rl.maxDelay {
-		rl.serveDelayError(ctx, rw, *delay)
-		return
+	if l.quota != nil {
+		quotaStatus, ok, err := l.quota.consume(ctx, rlSource, time.Now())
+		if err != nil {
+			rl.logger.Error().Err(err).Msg("Could not update quota")
+			observability.SetStatusErrorf(ctx, "Could not update quota")
+			http.Error(rw, "Could not update quota", http.StatusInternalServerError)
+			return
+		}
+
+		if !ok {
+			quotaStatus.setHeaders(rw.Header())
+			rw.Header().Set("Retry-After", strconv.FormatInt(ceilSeconds(quotaStatus.reset), 10))
+			observability.SetStatusErrorf(ctx, "Quota exceeded")
+			http.Error(rw, "Quota exceeded", http.StatusTooManyRequests)
+			return
+		}
+
+		status = mostRestrictive(status, &quotaStatus)
+	}
+
+	if status != nil {
+		status.setHeaders(rw.Header())
 	}
 
 	select {
@@ -176,12 +309,80 @@ func (rl *rateLimiter) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
 		http.Error(rw, "context canceled", http.StatusInternalServerError)
 		return
 
-	case <-time.After(*delay):
+	case <-time.After(delay):
 	}
 
 	rl.next.ServeHTTP(rw, req)
 }
 
+// selectLimits returns the name and the limits of the plan of the request, the name is empty for the default limits.
+func (rl *rateLimiter) selectLimits(req *http.Request) (string, *limits) {
+	if rl.planSelector == nil {
+		return "", &rl.limits
+	}
+
+	name := rl.planSelector(req)
+	if plan, ok := rl.plans[name]; ok {
+		return name, plan
+	}
+	return "", &rl.limits
+}
+
+// bucketStatus returns the status of a token bucket holding the given tokens.
+func (l *limits) bucketStatus(tokens float64) *limitStatus {
+	status := &limitStatus{
+		limit:     l.burst,
+		remaining: max(int64(math.Floor(tokens)), 0),
+	}
+
+	if missing := float64(l.burst) - tokens; missing > 0 {
+		status.reset = time.Duration(missing / float64(l.rate) * float64(time.Second))
+	}
+
+	return status
+}
+
+// tokenInterval returns how long it takes to add a token to a bucket.
+func (l *limits) tokenInterval() time.Duration {
+	return time.Duration(float64(time.Second) / float64(l.rate))
+}
+
+// limitStatus is the status of a limit, as advertised by the RateLimit headers.
+type limitStatus struct {
+	limit     int64
+	remaining int64
+	reset     time.Duration
+}
+
+func (s *limitStatus) setHeaders(header http.Header) {
+	header.Set("RateLimit-Limit", strconv.FormatInt(s.limit, 10))
+	header.Set("RateLimit-Remaining", strconv.FormatInt(s.remaining, 10))
+	header.Set("RateLimit-Reset", strconv.FormatInt(ceilSeconds(s.reset), 10))
+}
+
+// mostRestrictive returns the status with the fewest remaining requests, or the longest reset on equality.
+func mostRestrictive(a, b *limitStatus) *limitStatus {
+	switch {
+	case a == nil:
+		return b
+	case b == nil:
+		return a
+	case a.remaining != b.remaining:
+		if a.remaining < b.remaining {
+			return a
+		}
+		return b
+	case a.reset >= b.reset:
+		return a
+	default:
+		return b
+	}
+}
+
+func ceilSeconds(d time.Duration) int64 {
+	return int64(math.Ceil(d.Seconds()))
+}
+
 func (rl *rateLimiter) serveDelayError(ctx context.Context, w http.ResponseWriter, delay time.Duration) {
 	w.Header().Set("Retry-After", fmt.Sprintf("%.0f", math.Ceil(delay.Seconds())))
 	w.Header().Set("X-Retry-In", delay.String())
@@ -9,6 +9,7 @@ import (
 	"net/http/httptest"
 	"os"
 	"strconv"
+	"sync"
 	"testing"
 	"time"
 
@@ -18,6 +19,7 @@ import (
 	"github.com/stretchr/testify/require"
 	ptypes "github.com/traefik/paerser/types"
 	"github.com/traefik/traefik/v3/pkg/config/dynamic"
+	"github.com/traefik/traefik/v3/pkg/middlewares"
 	"github.com/traefik/traefik/v3/pkg/testhelpers"
 	"github.com/vulcand/oxy/v2/utils"
 	lua "github.com/yuin/gopher-lua"
@@ -33,6 +35,7 @@ func TestNewRateLimiter(t *testing.T) {
 		expectedMaxDelay time.Duration
 		expectedSourceIP string
 		requestHeader    string
+		apiKeyConsumer   string
 		expectedError    string
 		expectedRTL      rate.Limit
 	}{
@@ -92,6 +95,67 @@ func TestNewRateLimiter(t *testing.T) {
 			},
 			expectedError: "getting source extractor: iPStrategy and RequestHeaderName are mutually exclusive",
 		},
+		{
+			desc: "APIKeyConsumer SourceCriterion",
+			config: dynamic.RateLimit{
+				Average: 200,
+				Burst:   10,
+				SourceCriterion: &dynamic.SourceCriterion{
+					APIKeyConsumer: true,
+				},
+			},
+			apiKeyConsumer: "alice",
+		},
+		{
+			desc: "APIKeyConsumer is exclusive with the other SourceCriteria",
+			config: dynamic.RateLimit{
+				Average: 200,
+				Burst:   10,
+				SourceCriterion: &dynamic.SourceCriterion{
+					APIKeyConsumer: true,
+					RequestHost:    true,
+				},
+			},
+			expectedError: "getting source extractor: apiKeyConsumer, IPStrategy, RequestHeaderName and RequestHost are mutually exclusive",
+		},
+		{
+			desc: "Plans without PlanCriterion",
+			config: dynamic.RateLimit{
+				Plans: map[string]dynamic.RateLimitPlan{"gold": {Average: 100}},
+			},
+			expectedError: "planCriterion must be defined along with the plans",
+		},
+		{
+			desc: "PlanCriterion with several criteria",
+			config: dynamic.RateLimit{
+				Plans:         map[string]dynamic.RateLimitPlan{"gold": {Average: 100}},
+				PlanCriterion: &dynamic.PlanCriterion{RequestHeaderName: "X-Plan", APIKeyConsumer: true},
+			},
+			expectedError: "exactly one of requestHeaderName, jwtClaim and apiKeyConsumer must be defined in planCriterion",
+		},
+		{
+			desc: "PlanCriterion mapping to an unknown plan",
+			config: dynamic.RateLimit{
+				Plans:         map[string]dynamic.RateLimitPlan{"gold": {Average: 100}},
+				PlanCriterion: &dynamic.PlanCriterion{RequestHeaderName: "X-Plan", Mapping: map[string]string{"premium": "platinum"}},
+			},
+			expectedError: `plan "platinum" of value "premium" is not defined`,
+		},
+		{
+			desc: "Quota with an unsupported window",
+			config: dynamic.RateLimit{
+				Quota: &dynamic.RateLimitQuota{Limit: 10, Window: "year"},
+			},
+			expectedError: `unsupported quota window "year", must be one of hour, day, week and month`,
+		},
+		{
+			desc: "Plan quota without limit",
+			config: dynamic.RateLimit{
+				Plans:         map[string]dynamic.RateLimitPlan{"gold": {Quota: &dynamic.RateLimitQuota{}}},
+				PlanCriterion: &dynamic.PlanCriterion{RequestHeaderName: "X-Plan"},
+			},
+			expectedError: "creating plan gold: quota limit must be greater than 0",
+		},
 		{
 			desc: "Use Redis",
 			config: dynamic.RateLimit{
@@ -147,6 +211,19 @@ func TestNewRateLimiter(t *testing.T) {
 				assert.NoError(t, err)
 				assert.Equal(t, test.requestHeader, hd)
 			}
+			if test.apiKeyConsumer != "" {
+				extractor, ok := rtl.sourceMatcher.(utils.ExtractorFunc)
+				require.True(t, ok, "Not an ExtractorFunc")
+
+				req := httptest.NewRequest(http.MethodGet, "/", nil)
+				_, _, err := extractor(req)
+				assert.Error(t, err)
+
+				req = req.WithContext(middlewares.WithAPIKeyConsumer(req.Context(), test.apiKeyConsumer))
+				consumer, _, err := extractor(req)
+				assert.NoError(t, err)
+				assert.Equal(t, test.apiKeyConsumer, consumer)
+			}
 			if test.expectedRTL != 0 {
 				assert.InDelta(t, float64(test.expectedRTL), float64(rtl.rate), delta)
 			}
@@ -154,6 +231,308 @@ func TestNewRateLimiter(t *testing.T) {
 	}
 }
 
+func TestRateLimitHeaders(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+	h, err := New(t.Context(), next, dynamic.RateLimit{Average: 10, Burst: 3}, "rate-limiter")
+	require.NoError(t, err)
+
+	for _, remaining := range []string{"2", "1", "0"} {
+		rw := serveFrom(h, "127.0.0.1")
+
+		assert.Equal(t, http.StatusOK, rw.Code)
+		assert.Equal(t, "3", rw.Header().Get("RateLimit-Limit"))
+		assert.Equal(t, remaining, rw.Header().Get("RateLimit-Remaining"))
+		assert.NotEmpty(t, rw.Header().Get("RateLimit-Reset"))
+	}
+
+	rw := serveFrom(h, "127.0.0.1")
+
+	assert.Equal(t, http.StatusTooManyRequests, rw.Code)
+	assert.Equal(t, "3", rw.Header().Get("RateLimit-Limit"))
+	assert.Equal(t, "0", rw.Header().Get("RateLimit-Remaining"))
+	assert.Equal(t, "1", rw.Header().Get("RateLimit-Reset"))
+	assert.Equal(t, "1", rw.Header().Get("Retry-After"))
+
+	// The other sources have their own bucket.
+	rw = serveFrom(h, "127.0.0.2")
+
+	assert.Equal(t, http.StatusOK, rw.Code)
+	assert.Equal(t, "2", rw.Header().Get("RateLimit-Remaining"))
+}
+
+func TestRateLimitHeaders_rejected(t *testing.T) {
+	testCases := []struct {
+		desc              string
+		limiter           limiter
+		expectedStatus    int
+		expectedRemaining string
+	}{
+		{
+			desc:              "no bursty traffic allowed",
+			limiter:           stubLimiter{},
+			expectedStatus:    http.StatusTooManyRequests,
+			expectedRemaining: "0",
+		},
+		{
+			desc:           "bucket store error",
+			limiter:        stubLimiter{err: errors.New("unavailable")},
+			expectedStatus: http.StatusInternalServerError,
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+			h, err := New(t.Context(), next, dynamic.RateLimit{Average: 1, Period: ptypes.Duration(2 * time.Second), Burst: 3}, "rate-limiter")
+			require.NoError(t, err)
+			h.(*rateLimiter).limiter = test.limiter
+
+			rw := serveFrom(h, "127.0.0.1")
+
+			assert.Equal(t, test.expectedStatus, rw.Code)
+			assert.Equal(t, "2", rw.Header().Get("Retry-After"))
+			assert.Equal(t, test.expectedRemaining, rw.Header().Get("RateLimit-Remaining"))
+		})
+	}
+}
+
+// stubLimiter rejects all the requests, or fails with err.
+type stubLimiter struct {
+	err error
+}
+
+func (s stubLimiter) Allow(context.Context, string) (*time.Duration, float64, error) {
+	return nil, 0, s.err
+}
+
+func TestRateLimitQuota(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+	h, err := New(t.Context(), next, dynamic.RateLimit{
+		Average: 100,
+		Burst:   10,
+		Quota:   &dynamic.RateLimitQuota{Limit: 2, Window: "hour"},
+	}, "rate-limiter")
+	require.NoError(t, err)
+
+	for _, remaining := range []string{"1", "0"} {
+		rw := serveFrom(h, "127.0.0.1")
+
+		assert.Equal(t, http.StatusOK, rw.Code)
+		// The quota is more restrictive than the rate.
+		assert.Equal(t, "2", rw.Header().Get("RateLimit-Limit"))
+		assert.Equal(t, remaining, rw.Header().Get("RateLimit-Remaining"))
+	}
+
+	rw := serveFrom(h, "127.0.0.1")
+
+	assert.Equal(t, http.StatusTooManyRequests, rw.Code)
+	assert.Equal(t, "2", rw.Header().Get("RateLimit-Limit"))
+	assert.Equal(t, "0", rw.Header().Get("RateLimit-Remaining"))
+
+	_, end, err := windowBounds("hour", time.Now())
+	require.NoError(t, err)
+
+	retryAfter, err := strconv.Atoi(rw.Header().Get("Retry-After"))
+	require.NoError(t, err)
+	assert.InDelta(t, time.Until(end).Seconds(), float64(retryAfter), 2)
+	assert.Equal(t, rw.Header().Get("Retry-After"), rw.Header().Get("RateLimit-Reset"))
+
+	rw = serveFrom(h, "127.0.0.2")
+	assert.Equal(t, http.StatusOK, rw.Code)
+}
+
+func TestRateLimitPlans(t *testing.T) {
+	testCases := []struct {
+		desc           string
+		criterion      *dynamic.PlanCriterion
+		header         http.Header
+		ctx            func(ctx context.Context) context.Context
+		expectedAllows int
+	}{
+		{
+			desc:           "no plan",
+			criterion:      &dynamic.PlanCriterion{RequestHeaderName: "X-Plan"},
+			expectedAllows: 1,
+		},
+		{
+			desc:           "unknown plan",
+			criterion:      &dynamic.PlanCriterion{RequestHeaderName: "X-Plan"},
+			header:         http.Header{"X-Plan": {"platinum"}},
+			expectedAllows: 1,
+		},
+		{
+			desc:           "plan from header",
+			criterion:      &dynamic.PlanCriterion{RequestHeaderName: "X-Plan"},
+			header:         http.Header{"X-Plan": {"gold"}},
+			expectedAllows: 3,
+		},
+		{
+			desc:           "mapped plan from header",
+			criterion:      &dynamic.PlanCriterion{RequestHeaderName: "X-Plan", Mapping: map[string]string{"premium": "gold"}},
+			header:         http.Header{"X-Plan": {"premium"}},
+			expectedAllows: 3,
+		},
+		{
+			desc:      "plan from JWT claim",
+			criterion: &dynamic.PlanCriterion{JWTClaim: "org.plan"},
+			ctx: func(ctx context.Context) context.Context {
+				return middlewares.WithJWTClaims(ctx, map[string]any{"org": map[string]any{"plan": "silver"}})
+			},
+			expectedAllows: 2,
+		},
+		{
+			desc:      "missing JWT claim",
+			criterion: &dynamic.PlanCriterion{JWTClaim: "org.plan"},
+			ctx: func(ctx context.Context) context.Context {
+				return middlewares.WithJWTClaims(ctx, map[string]any{"org": "acme"})
+			},
+			expectedAllows: 1,
+		},
+		{
+			desc:      "plan from API key consumer",
+			criterion: &dynamic.PlanCriterion{APIKeyConsumer: true, Mapping: map[string]string{"alice": "gold"}},
+			ctx: func(ctx context.Context) context.Context {
+				return middlewares.WithAPIKeyConsumer(ctx, "alice")
+			},
+			expectedAllows: 3,
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+			h, err := New(t.Context(), next, dynamic.RateLimit{
+				Quota: &dynamic.RateLimitQuota{Limit: 1},
+				Plans: map[string]dynamic.RateLimitPlan{
+					"gold":   {Quota: &dynamic.RateLimitQuota{Limit: 3}},
+					"silver": {Quota: &dynamic.RateLimitQuota{Limit: 2}},
+				},
+				PlanCriterion: test.criterion,
+			}, "rate-limiter")
+			require.NoError(t, err)
+
+			var allows int
+			for range 5 {
+				req := httptest.NewRequest(http.MethodGet, "/", nil)
+				req.RemoteAddr = "127.0.0.1:1234"
+				for name, values := range test.header {
+					req.Header[name] = values
+				}
+				if test.ctx != nil {
+					req = req.WithContext(test.ctx(req.Context()))
+				}
+
+				rw := httptest.NewRecorder()
+				h.ServeHTTP(rw, req)
+
+				if rw.Code == http.StatusOK {
+					allows++
+				}
+			}
+
+			assert.Equal(t, test.expectedAllows, allows)
+		})
+	}
+}
+
+func TestRateLimitPlans_sameSource(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+	h, err := New(t.Context(), next, dynamic.RateLimit{
+		Plans: map[string]dynamic.RateLimitPlan{
+			"gold":   {Average: 100, Quota: &dynamic.RateLimitQuota{Limit: 3}},
+			"silver": {Average: 10, Quota: &dynamic.RateLimitQuota{Limit: 2}},
+		},
+		PlanCriterion: &dynamic.PlanCriterion{RequestHeaderName: "X-Plan"},
+	}, "rate-limiter")
+	require.NoError(t, err)
+
+	// The plans share the bucket store, as with Redis.
+	keys := &keyRecorder{}
+	for _, plan := range h.(*rateLimiter).plans {
+		plan.limiter = keys
+	}
+
+	serve := func(plan string) int {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = "127.0.0.1:1234"
+		req.Header.Set("X-Plan", plan)
+
+		rw := httptest.NewRecorder()
+		h.ServeHTTP(rw, req)
+		return rw.Code
+	}
+
+	// The consumers of different plans behind the same IP do not share their quota.
+	for _, plan := range []string{"silver", "silver", "gold", "gold", "gold"} {
+		assert.Equal(t, http.StatusOK, serve(plan), plan)
+	}
+	assert.Equal(t, http.StatusTooManyRequests, serve("silver"))
+	assert.Equal(t, http.StatusTooManyRequests, serve("gold"))
+
+	// Nor their bucket.
+	assert.Len(t, keys.keys, 2)
+}
+
+// keyRecorder allows all the requests, and records the keys of their buckets.
+type keyRecorder struct {
+	mu   sync.Mutex
+	keys map[string]struct{}
+}
+
+func (k *keyRecorder) Allow(_ context.Context, key string) (*time.Duration, float64, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if k.keys == nil {
+		k.keys = make(map[string]struct{})
+	}
+	k.keys[key] = struct{}{}
+
+	var delay time.Duration
+	return &delay, 1, nil
+}
+
+func TestRedisRateLimitQuota(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+	h, err := New(t.Context(), next, dynamic.RateLimit{
+		Quota: &dynamic.RateLimitQuota{Limit: 2, Window: "month"},
+		Redis: &dynamic.Redis{Endpoints: []string{"localhost:6379"}},
+	}, "rate-limiter")
+	require.NoError(t, err)
+
+	store := h.(*rateLimiter).quota.store.(*redisQuotaStore)
+	store.client = newMockRedisQuotaClient()
+
+	for _, expectedCode := range []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests} {
+		rw := serveFrom(h, "127.0.0.1")
+		assert.Equal(t, expectedCode, rw.Code)
+	}
+
+	rw := serveFrom(h, "127.0.0.2")
+	assert.Equal(t, http.StatusOK, rw.Code)
+	assert.Equal(t, "1", rw.Header().Get("RateLimit-Remaining"))
+}
+
+func serveFrom(h http.Handler, ip string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = ip + ":1234"
+
+	rw := httptest.NewRecorder()
+	h.ServeHTTP(rw, req)
+
+	return rw
+}
+
 func TestInMemoryRateLimit(t *testing.T) {
 	testCases := []struct {
 		desc         string
@@ -685,6 +1064,89 @@ func (m *mockRedisClient) EvalShaRO(ctx context.Context, sha1 string, keys []str
 	return nil
 }
 
+type mockRedisQuotaClient struct {
+	Rediser
+
+	mu     sync.Mutex
+	values map[string]string
+}
+
+func newMockRedisQuotaClient() Rediser {
+	return &mockRedisQuotaClient{values: make(map[string]string)}
+}
+
+func (m *mockRedisQuotaClient) EvalSha(ctx context.Context, _ string, keys []string, args ...interface{}) *redis.Cmd {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	state := lua.NewState()
+	defer state.Close()
+
+	tableKeys := state.NewTable()
+	for _, key := range keys {
+		tableKeys.Append(lua.LString(key))
+	}
+	state.SetGlobal("KEYS", tableKeys)
+
+	tableArgv := state.NewTable()
+	for _, arg := range args {
+		tableArgv.Append(lua.LString(fmt.Sprint(arg)))
+	}
+	state.SetGlobal("ARGV", tableArgv)
+
+	mod := state.SetFuncs(state.NewTable(), map[string]lua.LGFunction{
+		"call": func(state *lua.LState) int {
+			key := state.Get(2).String()
+
+			switch state.Get(1).String() {
+			case "get":
+				value, ok := m.values[key]
+				if !ok {
+					state.Push(lua.LFalse)
+					return 1
+				}
+				state.Push(lua.LString(value))
+			case "incr":
+				count, _ := strconv.Atoi(m.values[key])
+				m.values[key] = strconv.Itoa(count + 1)
+				state.Push(lua.LNumber(count + 1))
+			case "expireat":
+				state.Push(lua.LNumber(1))
+			default:
+				return 0
+			}
+
+			return 1
+		},
+	})
+	state.SetGlobal("redis", mod)
+
+	cmd := redis.NewCmd(ctx)
+	if err := state.DoString(ConsumeQuotaRaw); err != nil {
+		cmd.SetErr(err)
+		return cmd
+	}
+
+	resultTable, ok := state.Get(-1).(*lua.LTable)
+	if !ok {
+		cmd.SetErr(errors.New("unexpected response type"))
+		return cmd
+	}
+
+	var resultSlice []interface{}
+	resultTable.ForEach(func(_ lua.LValue, value lua.LValue) {
+		resultSlice = append(resultSlice, value.String())
+	})
+
+	cmd.SetVal(resultSlice)
+
+	return cmd
+}
+
+func (m *mockRedisQuotaClient) Eval(ctx context.Context, script string, keys []string, args ...interface{}) *redis.Cmd {
+	return m.EvalSha(ctx, script, keys, args...)
+}
+
 func computeMinCount(wantCount int) int {
 	if os.Getenv("CI") != "" {
 		return wantCount * 60 / 100
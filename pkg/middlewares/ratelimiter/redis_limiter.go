@@ -13,7 +13,10 @@ import (
 	"golang.org/x/time/rate"
 )
 
-const redisPrefix = "rate:"
+const (
+	redisPrefix      = "rate:"
+	redisQuotaPrefix = "quota:"
+)
 
 type redisLimiter struct {
 	rate     rate.Limit // reqs/s
@@ -25,70 +28,74 @@ type redisLimiter struct {
 	client   Rediser
 }
 
-func newRedisLimiter(ctx context.Context, rate rate.Limit, burst int64, maxDelay time.Duration, ttl int, config dynamic.RateLimit, logger *zerolog.Logger) (limiter, error) {
+func newRedisLimiter(rate rate.Limit, burst int64, maxDelay time.Duration, ttl int, period ptypes.Duration, client Rediser, logger *zerolog.Logger) limiter {
+	return &redisLimiter{
+		rate:     rate,
+		burst:    burst,
+		period:   period,
+		maxDelay: maxDelay,
+		logger:   logger,
+		ttl:      ttl,
+		client:   client,
+	}
+}
+
+func newRedisClient(ctx context.Context, config *dynamic.Redis) (Rediser, error) {
 	options := &redis.UniversalOptions{
-		Addrs:          config.Redis.Endpoints,
-		Username:       config.Redis.Username,
-		Password:       config.Redis.Password,
-		DB:             config.Redis.DB,
-		PoolSize:       config.Redis.PoolSize,
-		MinIdleConns:   config.Redis.MinIdleConns,
-		MaxActiveConns: config.Redis.MaxActiveConns,
+		Addrs:          config.Endpoints,
+		Username:       config.Username,
+		Password:       config.Password,
+		DB:             config.DB,
+		PoolSize:       config.PoolSize,
+		MinIdleConns:   config.MinIdleConns,
+		MaxActiveConns: config.MaxActiveConns,
 	}
 
-	if config.Redis.DialTimeout != nil && *config.Redis.DialTimeout > 0 {
-		options.DialTimeout = time.Duration(*config.Redis.DialTimeout)
+	if config.DialTimeout != nil && *config.DialTimeout > 0 {
+		options.DialTimeout = time.Duration(*config.DialTimeout)
 	}
 
-	if config.Redis.ReadTimeout != nil {
-		if *config.Redis.ReadTimeout > 0 {
-			options.ReadTimeout = time.Duration(*config.Redis.ReadTimeout)
+	if config.ReadTimeout != nil {
+		if *config.ReadTimeout > 0 {
+			options.ReadTimeout = time.Duration(*config.ReadTimeout)
 		} else {
 			options.ReadTimeout = -1
 		}
 	}
 
-	if config.Redis.WriteTimeout != nil {
-		if *config.Redis.ReadTimeout > 0 {
-			options.WriteTimeout = time.Duration(*config.Redis.WriteTimeout)
+	if config.WriteTimeout != nil {
+		if *config.ReadTimeout > 0 {
+			options.WriteTimeout = time.Duration(*config.WriteTimeout)
 		} else {
 			options.WriteTimeout = -1
 		}
 	}
 
-	if config.Redis.TLS != nil {
+	if config.TLS != nil {
 		var err error
-		options.TLSConfig, err = config.Redis.TLS.CreateTLSConfig(ctx)
+		options.TLSConfig, err = config.TLS.CreateTLSConfig(ctx)
 		if err != nil {
 			return nil, fmt.Errorf("creating TLS config: %w", err)
 		}
 	}
 
-	return &redisLimiter{
-		rate:     rate,
-		burst:    burst,
-		period:   config.Period,
-		maxDelay: maxDelay,
-		logger:   logger,
-		ttl:      ttl,
-		client:   redis.NewUniversalClient(options),
-	}, nil
+	return redis.NewUniversalClient(options), nil
 }
 
-func (r *redisLimiter) Allow(ctx context.Context, source string) (*time.Duration, error) {
-	ok, delay, err := r.evaluateScript(ctx, source)
+func (r *redisLimiter) Allow(ctx context.Context, source string) (*time.Duration, float64, error) {
+	ok, delay, tokens, err := r.evaluateScript(ctx, source)
 	if err != nil {
-		return nil, fmt.Errorf("evaluating script: %w", err)
+		return nil, 0, fmt.Errorf("evaluating script: %w", err)
 	}
 	if !ok {
-		return nil, nil
+		return nil, 0, nil
 	}
-	return delay, nil
+	return delay, tokens, nil
 }
 
-func (r *redisLimiter) evaluateScript(ctx context.Context, key string) (bool, *time.Duration, error) {
+func (r *redisLimiter) evaluateScript(ctx context.Context, key string) (bool, *time.Duration, float64, error) {
 	if r.rate == rate.Inf {
-		return true, nil, nil
+		return true, nil, 0, nil
 	}
 
 	params := []interface{}{
@@ -100,19 +107,47 @@ func (r *redisLimiter) evaluateScript(ctx context.Context, key string) (bool, *t
 	}
 	v, err := AllowTokenBucketScript.Run(ctx, r.client, []string{redisPrefix + key}, params...).Result()
 	if err != nil {
-		return false, nil, fmt.Errorf("running script: %w", err)
+		return false, nil, 0, fmt.Errorf("running script: %w", err)
 	}
 
 	values := v.([]interface{})
 	ok, err := strconv.ParseBool(values[0].(string))
 	if err != nil {
-		return false, nil, fmt.Errorf("parsing ok value from redis rate lua script: %w", err)
+		return false, nil, 0, fmt.Errorf("parsing ok value from redis rate lua script: %w", err)
 	}
 	delay, err := strconv.ParseFloat(values[1].(string), 64)
 	if err != nil {
-		return false, nil, fmt.Errorf("parsing delay value from redis rate lua script: %w", err)
+		return false, nil, 0, fmt.Errorf("parsing delay value from redis rate lua script: %w", err)
+	}
+	tokens, err := strconv.ParseFloat(values[2].(string), 64)
+	if err != nil {
+		return false, nil, 0, fmt.Errorf("parsing tokens value from redis rate lua script: %w", err)
 	}
 
 	microDelay := time.Duration(delay * float64(time.Microsecond))
-	return ok, &microDelay, nil
+	return ok, &microDelay, tokens, nil
+}
+
+// redisQuotaStore keeps the quota counters in Redis.
+type redisQuotaStore struct {
+	client Rediser
+}
+
+func (r *redisQuotaStore) Consume(ctx context.Context, key string, limit int64, expiresAt time.Time) (int64, bool, error) {
+	v, err := ConsumeQuotaScript.Run(ctx, r.client, []string{redisQuotaPrefix + key}, limit, expiresAt.Unix()).Result()
+	if err != nil {
+		return 0, false, fmt.Errorf("running script: %w", err)
+	}
+
+	values := v.([]interface{})
+	ok, err := strconv.ParseBool(values[0].(string))
+	if err != nil {
+		return 0, false, fmt.Errorf("parsing ok value from redis quota lua script: %w", err)
+	}
+	count, err := strconv.ParseInt(values[1].(string), 10, 64)
+	if err != nil {
+		return 0, false, fmt.Errorf("parsing count value from redis quota lua script: %w", err)
+	}
+
+	return count, ok, nil
 }
@@ -9,12 +9,16 @@ import (
 	"net"
 	"net/http"
 	"net/http/httptrace"
+	"slices"
+	"strconv"
+	"sync/atomic"
 	"time"
 
 	"github.com/cenkalti/backoff/v4"
 	"github.com/traefik/traefik/v3/pkg/config/dynamic"
 	"github.com/traefik/traefik/v3/pkg/middlewares"
 	"github.com/traefik/traefik/v3/pkg/tracing"
+	"github.com/traefik/traefik/v3/pkg/types"
 	"go.opentelemetry.io/otel/attribute"
 	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
 	"go.opentelemetry.io/otel/trace"
@@ -43,7 +47,10 @@ func (l Listeners) Retried(req *http.Request, attempt int) {
 	}
 }
 
-type shouldRetryContextKey struct{}
+type (
+	shouldRetryContextKey struct{}
+	attemptContextKey     struct{}
+)
 
 // ShouldRetry is a function allowing to enable/disable the retry middleware mechanism.
 type ShouldRetry func(shouldRetry bool)
@@ -54,6 +61,11 @@ func ContextShouldRetry(ctx context.Context) ShouldRetry {
 	return f
 }
 
+// WithShouldRetry returns a copy of ctx with the given ShouldRetry function.
+func WithShouldRetry(ctx context.Context, shouldRetry ShouldRetry) context.Context {
+	return context.WithValue(ctx, shouldRetryContextKey{}, shouldRetry)
+}
+
 // WrapHandler wraps a given http.Handler to inject the httptrace.ClientTrace in the request context when it is needed
 // by the retry middleware.
 func WrapHandler(next http.Handler) http.Handler {
@@ -78,6 +90,20 @@ func WrapHandler(next http.Handler) http.Handler {
 	})
 }
 
+// NotifyHandler wraps a given http.Handler to notify the listener of the attempts of the requests retried by the retry middleware.
+func NotifyHandler(next http.Handler, listener Listener) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if attempt, ok := req.Context().Value(attemptContextKey{}).(int); ok && attempt > 1 {
+			listener.Retried(req, attempt)
+		}
+
+		next.ServeHTTP(rw, req)
+	})
+}
+
+// idempotentMethods are the methods of the requests retried on a response condition by default.
+var idempotentMethods = []string{http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete}
+
 // retry is a middleware that retries requests.
 type retry struct {
 	attempts        int
@@ -85,6 +111,13 @@ type retry struct {
 	next            http.Handler
 	listener        Listener
 	name            string
+
+	retryOnConnectFailure bool
+	retryOnTimeout        bool
+	statuses              types.HTTPCodeRanges
+	grpcStatuses          []int
+	methods               []string
+	budget                *budget
 }
 
 // New returns a new retry middleware.
@@ -95,13 +128,49 @@ func New(ctx context.Context, next http.Handler, config dynamic.Retry, listener
 		return nil, fmt.Errorf("incorrect (or empty) value for attempt (%d)", config.Attempts)
 	}
 
-	return &retry{
+	statuses, err := types.NewHTTPCodeRanges(config.Status)
+	if err != nil {
+		return nil, fmt.Errorf("parsing status: %w", err)
+	}
+
+	r := &retry{
 		attempts:        config.Attempts,
 		initialInterval: time.Duration(config.InitialInterval),
 		next:            next,
 		listener:        listener,
 		name:            name,
-	}, nil
+		statuses:        statuses,
+		grpcStatuses:    config.GRPCStatus,
+		methods:         idempotentMethods,
+	}
+
+	retryOn := config.RetryOn
+	if len(retryOn) == 0 {
+		retryOn = []string{dynamic.RetryOnConnectFailure}
+	}
+	for _, condition := range retryOn {
+		switch condition {
+		case dynamic.RetryOnConnectFailure:
+			r.retryOnConnectFailure = true
+		case dynamic.RetryOnTimeout:
+			r.retryOnTimeout = true
+		default:
+			return nil, fmt.Errorf("unknown retry condition %q", condition)
+		}
+	}
+
+	if len(config.Methods) > 0 {
+		r.methods = config.Methods
+	}
+
+	if config.Budget != nil {
+		if config.Budget.Percent < 0 || config.Budget.MinRetries < 0 {
+			return nil, fmt.Errorf("incorrect value for the retry budget percent (%d) or min retries (%d)", config.Budget.Percent, config.Budget.MinRetries)
+		}
+		r.budget = &budget{percent: int64(config.Budget.Percent), minRetries: int64(config.Budget.MinRetries)}
+	}
+
+	return r, nil
 }
 
 func (r *retry) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
@@ -110,6 +179,11 @@ func (r *retry) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
 		return
 	}
 
+	if r.budget != nil {
+		r.budget.active.Add(1)
+		defer r.budget.active.Add(-1)
+	}
+
 	closableBody := req.Body
 	defer closableBody.Close()
 
@@ -117,7 +191,19 @@ func (r *retry) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
 	// cf https://github.com/traefik/traefik/issues/1008
 	req.Body = io.NopCloser(closableBody)
 
+	// The load-balancers send the retries to other servers when possible.
+	req = req.WithContext(middlewares.WithTriedServers(req.Context()))
+
+	retryResponses := slices.Contains(r.methods, req.Method) && (len(r.statuses) > 0 || len(r.grpcStatuses) > 0 || r.retryOnTimeout)
+
 	attempts := 1
+	// budgetHeld is whether the current attempt is a retry allowed by the retry budget.
+	var budgetHeld bool
+	defer func() {
+		if budgetHeld {
+			r.budget.release()
+		}
+	}()
 
 	initialCtx := req.Context()
 	tracer := tracing.TracerFromContext(initialCtx)
@@ -145,18 +231,34 @@ func (r *retry) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
 
 		remainAttempts := attempts < r.attempts
 		retryResponseWriter := newResponseWriter(rw)
+		retryResponseWriter.budget = r.budget
+		if remainAttempts && retryResponses {
+			retryResponseWriter.retryResponse = r.retryResponse
+		}
 
 		var shouldRetry ShouldRetry = func(shouldRetry bool) {
-			retryResponseWriter.SetShouldRetry(remainAttempts && shouldRetry)
+			retryResponseWriter.SetShouldRetry(remainAttempts && r.retryOnConnectFailure && shouldRetry)
 		}
 		newCtx := context.WithValue(req.Context(), shouldRetryContextKey{}, shouldRetry)
+		newCtx = context.WithValue(newCtx, attemptContextKey{}, attempts)
 
 		r.next.ServeHTTP(retryResponseWriter, req.Clone(newCtx))
 
+		if budgetHeld {
+			r.budget.release()
+			budgetHeld = false
+		}
+
 		if !retryResponseWriter.ShouldRetry() {
 			return nil
 		}
 
+		if !retryResponseWriter.allowRetry() {
+			middlewares.GetLogger(req.Context(), r.name, typeName).Debug().Msg("Retry budget exhausted")
+			return nil
+		}
+		budgetHeld = r.budget != nil
+
 		attempts++
 
 		return fmt.Errorf("attempt %d failed", attempts-1)
@@ -182,6 +284,25 @@ func (r *retry) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
 	}
 }
 
+// retryResponse returns whether the response of the given status code and headers triggers a retry.
+func (r *retry) retryResponse(code int, headers http.Header) bool {
+	if r.retryOnTimeout && code == http.StatusGatewayTimeout {
+		return true
+	}
+
+	if r.statuses.Contains(code) {
+		return true
+	}
+
+	if len(r.grpcStatuses) > 0 {
+		if grpcStatus, err := strconv.Atoi(headers.Get("Grpc-Status")); err == nil {
+			return slices.Contains(r.grpcStatuses, grpcStatus)
+		}
+	}
+
+	return false
+}
+
 func (r *retry) newBackOff() backoff.BackOff {
 	if r.attempts < 2 || r.initialInterval <= 0 {
 		return &backoff.ZeroBackOff{}
@@ -212,6 +333,23 @@ type responseWriter struct {
 	headers        http.Header
 	shouldRetry    bool
 	written        bool
+
+	// retryResponse returns whether the response of the given status code and headers triggers a retry,
+	// nil if the responses do not trigger retries.
+	retryResponse func(code int, headers http.Header) bool
+
+	budget         *budget
+	budgetChecked  bool
+	budgetAcquired bool
+}
+
+// allowRetry returns whether the retry budget allows to retry, it is checked only once.
+func (r *responseWriter) allowRetry() bool {
+	if !r.budgetChecked {
+		r.budgetChecked = true
+		r.budgetAcquired = r.budget.acquire()
+	}
+	return r.budgetAcquired
 }
 
 func (r *responseWriter) ShouldRetry() bool {
@@ -240,10 +378,22 @@ func (r *responseWriter) Write(buf []byte) (int, error) {
 }
 
 func (r *responseWriter) WriteHeader(code int) {
-	if r.shouldRetry || r.written {
+	if r.written {
 		return
 	}
 
+	if code >= http.StatusOK && !r.shouldRetry && r.retryResponse != nil && r.retryResponse(code, r.headers) {
+		r.shouldRetry = true
+	}
+
+	if r.shouldRetry {
+		// The response is written to the client when the retry budget is exhausted.
+		if code < http.StatusOK || r.allowRetry() {
+			return
+		}
+		r.shouldRetry = false
+	}
+
 	// In that case retry case is set to false which means we at least managed
 	// to write headers to the backend : we are not going to perform any further retry.
 	// So it is now safe to alter current response headers with headers collected during
@@ -277,3 +427,34 @@ func (r *responseWriter) Flush() {
 		flusher.Flush()
 	}
 }
+
+// budget limits the concurrent retries to a percentage of the requests in progress.
+type budget struct {
+	percent    int64
+	minRetries int64
+
+	// active is the number of requests in progress.
+	active atomic.Int64
+	// retries is the number of retries in progress.
+	retries atomic.Int64
+}
+
+// acquire returns whether a retry is allowed, it must then be released once done.
+// A nil budget allows all retries.
+func (b *budget) acquire() bool {
+	if b == nil {
+		return true
+	}
+
+	retries := b.retries.Add(1)
+	if retries <= max(b.minRetries, b.active.Load()*b.percent/100) {
+		return true
+	}
+
+	b.retries.Add(-1)
+	return false
+}
+
+func (b *budget) release() {
+	b.retries.Add(-1)
+}
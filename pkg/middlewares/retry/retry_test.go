@@ -8,6 +8,7 @@ import (
 	"net/http/httptrace"
 	"net/textproto"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -16,6 +17,7 @@ import (
 	"github.com/stretchr/testify/require"
 	ptypes "github.com/traefik/paerser/types"
 	"github.com/traefik/traefik/v3/pkg/config/dynamic"
+	"github.com/traefik/traefik/v3/pkg/middlewares"
 	"github.com/traefik/traefik/v3/pkg/testhelpers"
 )
 
@@ -416,3 +418,239 @@ type countingRetryListener struct {
 func (l *countingRetryListener) Retried(req *http.Request, attempt int) {
 	l.timesCalled++
 }
+
+func TestRetryOnResponse(t *testing.T) {
+	testCases := []struct {
+		desc               string
+		config             dynamic.Retry
+		method             string
+		responses          []int
+		grpcStatus         string
+		wantRetryAttempts  int
+		wantResponseStatus int
+	}{
+		{
+			desc:               "no retry on status by default",
+			config:             dynamic.Retry{Attempts: 3},
+			method:             http.MethodGet,
+			responses:          []int{http.StatusServiceUnavailable, http.StatusOK},
+			wantResponseStatus: http.StatusServiceUnavailable,
+		},
+		{
+			desc:               "retry on status",
+			config:             dynamic.Retry{Attempts: 3, Status: []string{"502-504"}},
+			method:             http.MethodGet,
+			responses:          []int{http.StatusServiceUnavailable, http.StatusBadGateway, http.StatusOK},
+			wantRetryAttempts:  2,
+			wantResponseStatus: http.StatusOK,
+		},
+		{
+			desc:               "last attempt response is returned",
+			config:             dynamic.Retry{Attempts: 2, Status: []string{"503"}},
+			method:             http.MethodGet,
+			responses:          []int{http.StatusServiceUnavailable, http.StatusServiceUnavailable, http.StatusOK},
+			wantRetryAttempts:  1,
+			wantResponseStatus: http.StatusServiceUnavailable,
+		},
+		{
+			desc:               "no retry on other status",
+			config:             dynamic.Retry{Attempts: 3, Status: []string{"503"}},
+			method:             http.MethodGet,
+			responses:          []int{http.StatusInternalServerError, http.StatusOK},
+			wantResponseStatus: http.StatusInternalServerError,
+		},
+		{
+			desc:               "no retry of non-idempotent request",
+			config:             dynamic.Retry{Attempts: 3, Status: []string{"503"}},
+			method:             http.MethodPost,
+			responses:          []int{http.StatusServiceUnavailable, http.StatusOK},
+			wantResponseStatus: http.StatusServiceUnavailable,
+		},
+		{
+			desc:               "retry of opted-in non-idempotent request",
+			config:             dynamic.Retry{Attempts: 3, Status: []string{"503"}, Methods: []string{http.MethodPost}},
+			method:             http.MethodPost,
+			responses:          []int{http.StatusServiceUnavailable, http.StatusOK},
+			wantRetryAttempts:  1,
+			wantResponseStatus: http.StatusOK,
+		},
+		{
+			desc:               "retry on timeout",
+			config:             dynamic.Retry{Attempts: 3, RetryOn: []string{dynamic.RetryOnTimeout}},
+			method:             http.MethodGet,
+			responses:          []int{http.StatusGatewayTimeout, http.StatusOK},
+			wantRetryAttempts:  1,
+			wantResponseStatus: http.StatusOK,
+		},
+		{
+			desc:               "retry on gRPC status",
+			config:             dynamic.Retry{Attempts: 3, GRPCStatus: []int{14}},
+			method:             http.MethodGet,
+			responses:          []int{http.StatusOK, http.StatusOK},
+			grpcStatus:         "14",
+			wantRetryAttempts:  1,
+			wantResponseStatus: http.StatusOK,
+		},
+		{
+			desc:               "no retry on other gRPC status",
+			config:             dynamic.Retry{Attempts: 3, GRPCStatus: []int{14}},
+			method:             http.MethodGet,
+			responses:          []int{http.StatusOK, http.StatusOK},
+			grpcStatus:         "5",
+			wantResponseStatus: http.StatusOK,
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			var attempts int
+			next := http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+				// The request is sent to the backend.
+				if shouldRetry := ContextShouldRetry(req.Context()); shouldRetry != nil {
+					shouldRetry(false)
+				}
+
+				if test.grpcStatus != "" && attempts == 0 {
+					rw.Header().Set("Grpc-Status", test.grpcStatus)
+				}
+				rw.WriteHeader(test.responses[attempts])
+				attempts++
+			})
+
+			retryListener := &countingRetryListener{}
+			retry, err := New(t.Context(), next, test.config, retryListener, "traefikTest")
+			require.NoError(t, err)
+
+			recorder := httptest.NewRecorder()
+			retry.ServeHTTP(recorder, httptest.NewRequest(test.method, "http://localhost:3000/ok", nil))
+
+			assert.Equal(t, test.wantResponseStatus, recorder.Code)
+			assert.Equal(t, test.wantRetryAttempts, retryListener.timesCalled)
+			if test.wantRetryAttempts > 0 {
+				// The headers of the retried responses are discarded.
+				assert.Empty(t, recorder.Header().Get("Grpc-Status"))
+			}
+		})
+	}
+}
+
+func TestRetryOnConnectFailureDisabled(t *testing.T) {
+	next := http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		ContextShouldRetry(req.Context())(true)
+		rw.WriteHeader(http.StatusBadGateway)
+	})
+
+	retryListener := &countingRetryListener{}
+	retry, err := New(t.Context(), next, dynamic.Retry{Attempts: 3, RetryOn: []string{dynamic.RetryOnTimeout}}, retryListener, "traefikTest")
+	require.NoError(t, err)
+
+	recorder := httptest.NewRecorder()
+	retry.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "http://localhost:3000/ok", nil))
+
+	assert.Equal(t, http.StatusBadGateway, recorder.Code)
+	assert.Equal(t, 0, retryListener.timesCalled)
+}
+
+func TestRetryInvalidConfig(t *testing.T) {
+	next := http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {})
+
+	_, err := New(t.Context(), next, dynamic.Retry{Attempts: 3, RetryOn: []string{"unknown"}}, &countingRetryListener{}, "traefikTest")
+	require.Error(t, err)
+
+	_, err = New(t.Context(), next, dynamic.Retry{Attempts: 3, Status: []string{"5xx"}}, &countingRetryListener{}, "traefikTest")
+	require.Error(t, err)
+
+	_, err = New(t.Context(), next, dynamic.Retry{Attempts: 3, Budget: &dynamic.RetryBudget{Percent: -1}}, &countingRetryListener{}, "traefikTest")
+	require.Error(t, err)
+}
+
+func TestRetryBudget(t *testing.T) {
+	// The first request is blocked in its retry until the others have been served.
+	release := make(chan struct{})
+	blocked := make(chan struct{})
+
+	var mu sync.Mutex
+	attempts := make(map[string]int)
+	next := http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		mu.Lock()
+		attempts[req.URL.Path]++
+		attempt := attempts[req.URL.Path]
+		mu.Unlock()
+
+		if req.URL.Path == "/first" && attempt == 2 {
+			close(blocked)
+			<-release
+			rw.WriteHeader(http.StatusOK)
+			return
+		}
+		rw.WriteHeader(http.StatusServiceUnavailable)
+	})
+
+	config := dynamic.Retry{Attempts: 2, Status: []string{"503"}, Budget: &dynamic.RetryBudget{Percent: 10, MinRetries: 1}}
+	retryListener := &countingRetryListener{}
+	retry, err := New(t.Context(), next, config, retryListener, "traefikTest")
+	require.NoError(t, err)
+
+	first := httptest.NewRecorder()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		retry.ServeHTTP(first, httptest.NewRequest(http.MethodGet, "http://localhost/first", nil))
+	}()
+	<-blocked
+
+	// The budget is exhausted by the retry in progress.
+	second := httptest.NewRecorder()
+	retry.ServeHTTP(second, httptest.NewRequest(http.MethodGet, "http://localhost/second", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, second.Code)
+	assert.Equal(t, 1, attempts["/second"])
+
+	close(release)
+	<-done
+	assert.Equal(t, http.StatusOK, first.Code)
+
+	// The budget is available again.
+	third := httptest.NewRecorder()
+	retry.ServeHTTP(third, httptest.NewRequest(http.MethodGet, "http://localhost/third", nil))
+	assert.Equal(t, 2, attempts["/third"])
+}
+
+func TestRetryToOtherServer(t *testing.T) {
+	var servers []string
+	next := http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		tried := middlewares.GetTriedServers(req.Context())
+		require.NotNil(t, tried)
+
+		for _, name := range []string{"first", "second"} {
+			if !tried.Has(name) {
+				tried.Add(name)
+				servers = append(servers, name)
+				break
+			}
+		}
+		rw.WriteHeader(http.StatusServiceUnavailable)
+	})
+
+	retry, err := New(t.Context(), next, dynamic.Retry{Attempts: 2, Status: []string{"503"}}, &countingRetryListener{}, "traefikTest")
+	require.NoError(t, err)
+
+	retry.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "http://localhost/", nil))
+
+	assert.Equal(t, []string{"first", "second"}, servers)
+}
+
+func TestNotifyHandler(t *testing.T) {
+	notifyListener := &countingRetryListener{}
+	next := NotifyHandler(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.WriteHeader(http.StatusServiceUnavailable)
+	}), notifyListener)
+
+	retry, err := New(t.Context(), next, dynamic.Retry{Attempts: 3, Status: []string{"503"}}, &countingRetryListener{}, "traefikTest")
+	require.NoError(t, err)
+
+	retry.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "http://localhost/", nil))
+
+	assert.Equal(t, 2, notifyListener.timesCalled)
+}
@@ -0,0 +1,51 @@
+package middlewares
+
+import (
+	"context"
+	"sync"
+)
+
+type triedServersKey struct{}
+
+// TriedServers records the servers a request has been sent to,
+// so that a load-balancer sends its retries to other servers.
+type TriedServers struct {
+	mu    sync.Mutex
+	names map[string]struct{}
+}
+
+// WithTriedServers returns a copy of ctx recording the servers the request is sent to.
+func WithTriedServers(ctx context.Context) context.Context {
+	return context.WithValue(ctx, triedServersKey{}, &TriedServers{names: make(map[string]struct{})})
+}
+
+// GetTriedServers returns the servers recorded in ctx, nil if they are not recorded.
+func GetTriedServers(ctx context.Context) *TriedServers {
+	tried, _ := ctx.Value(triedServersKey{}).(*TriedServers)
+	return tried
+}
+
+// Add records the server of the given name.
+func (t *TriedServers) Add(name string) {
+	if t == nil {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.names[name] = struct{}{}
+}
+
+// Has returns whether the server of the given name has been tried.
+func (t *TriedServers) Has(name string) bool {
+	if t == nil {
+		return false
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	_, ok := t.names[name]
+	return ok
+}
@@ -279,6 +279,16 @@ func (p *Provider) addServer(item itemData, loadBalancer *dynamic.ServersLoadBal
 		return errors.New("address is missing")
 	}
 
+	// The locality defined with tags takes precedence.
+	if item.Locality != nil {
+		if loadBalancer.Servers[0].Zone == "" {
+			loadBalancer.Servers[0].Zone = item.Locality.Zone
+		}
+		if loadBalancer.Servers[0].Region == "" {
+			loadBalancer.Servers[0].Region = item.Locality.Region
+		}
+	}
+
 	if loadBalancer.Servers[0].URL != "" {
 		if loadBalancer.Servers[0].Scheme != "" || loadBalancer.Servers[0].Port != "" {
 			return errors.New("defining scheme or port is not allowed when URL is defined")
@@ -44,6 +44,8 @@ type itemData struct {
 	Labels     map[string]string
 	Tags       []string
 	ExtraConf  configuration
+	// Locality is the zone and region of the service instance, or of its node.
+	Locality *api.Locality
 }
 
 // ProviderBuilder is responsible for constructing namespaced instances of the Consul Catalog provider.
@@ -336,6 +338,10 @@ func (p *Provider) getConsulServicesData(ctx context.Context) ([]itemData, error
 				Labels:     tagsToNeutralLabels(consulService.Service.Tags, p.Prefix),
 				Tags:       consulService.Service.Tags,
 				Status:     status,
+				Locality:   consulService.Service.Locality,
+			}
+			if item.Locality == nil {
+				item.Locality = consulService.Node.Locality
 			}
 
 			extraConf, err := p.getExtraConf(item.Labels)
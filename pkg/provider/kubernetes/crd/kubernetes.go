@@ -32,6 +32,7 @@ import (
 	"github.com/traefik/traefik/v3/pkg/safe"
 	"github.com/traefik/traefik/v3/pkg/tls"
 	"github.com/traefik/traefik/v3/pkg/types"
+	"gopkg.in/yaml.v3"
 	corev1 "k8s.io/api/core/v1"
 	apiextensionv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	"k8s.io/apimachinery/pkg/labels"
@@ -236,6 +237,12 @@ func (p *Provider) loadConfigurationFromCRD(ctx context.Context, client Client)
 			continue
 		}
 
+		apiKey, err := createAPIKeyMiddleware(client, middleware.Namespace, middleware.Spec.APIKey)
+		if err != nil {
+			logger.Error().Err(err).Msg("Error while reading API key middleware")
+			continue
+		}
+
 		digestAuth, err := createDigestAuthMiddleware(client, middleware.Namespace, middleware.Spec.DigestAuth)
 		if err != nil {
 			logger.Error().Err(err).Msg("Error while reading digest auth middleware")
@@ -299,6 +306,7 @@ func (p *Provider) loadConfigurationFromCRD(ctx context.Context, client Client)
 			RedirectRegex:     middleware.Spec.RedirectRegex,
 			RedirectScheme:    middleware.Spec.RedirectScheme,
 			BasicAuth:         basicAuth,
+			APIKey:            apiKey,
 			DigestAuth:        digestAuth,
 			ForwardAuth:       forwardAuth,
 			InFlightReq:       middleware.Spec.InFlightReq,
@@ -791,6 +799,35 @@ func createRateLimitMiddleware(client Client, namespace string, rateLimit *traef
 		rl.SourceCriterion = rateLimit.SourceCriterion
 	}
 
+	rl.Quota = rateLimit.Quota
+	rl.QuotaFile = rateLimit.QuotaFile
+	rl.PlanCriterion = rateLimit.PlanCriterion
+
+	if len(rateLimit.Plans) > 0 {
+		rl.Plans = make(map[string]dynamic.RateLimitPlan, len(rateLimit.Plans))
+		for name, plan := range rateLimit.Plans {
+			p := dynamic.RateLimitPlan{Quota: plan.Quota}
+			p.SetDefaults()
+
+			if plan.Average != nil {
+				p.Average = *plan.Average
+			}
+
+			if plan.Burst != nil {
+				p.Burst = *plan.Burst
+			}
+
+			if plan.Period != nil {
+				err := p.Period.Set(plan.Period.String())
+				if err != nil {
+					return nil, fmt.Errorf("setting the period of plan %s: %w", name, err)
+				}
+			}
+
+			rl.Plans[name] = p
+		}
+	}
+
 	if rateLimit.Redis != nil {
 		rl.Redis = &dynamic.Redis{
 			DB:             rateLimit.Redis.DB,
@@ -887,7 +924,14 @@ func createRetryMiddleware(retry *traefikv1alpha1.Retry) (*dynamic.Retry, error)
 		return nil, nil
 	}
 
-	r := &dynamic.Retry{Attempts: retry.Attempts}
+	r := &dynamic.Retry{
+		Attempts:   retry.Attempts,
+		RetryOn:    retry.RetryOn,
+		Status:     retry.Status,
+		GRPCStatus: retry.GRPCStatus,
+		Methods:    retry.Methods,
+		Budget:     retry.Budget,
+	}
 
 	err := r.InitialInterval.Set(retry.InitialInterval.String())
 	if err != nil {
@@ -1064,6 +1108,50 @@ func loadAuthTLSSecret(namespace, secretName string, k8sClient Client) (string,
 	return getCertificateBlocks(secret, namespace, secretName)
 }
 
+func createAPIKeyMiddleware(client Client, namespace string, apiKey *traefikv1alpha1.APIKey) (*dynamic.APIKey, error) {
+	if apiKey == nil {
+		return nil, nil
+	}
+
+	if apiKey.Secret == "" {
+		return nil, errors.New("API keys secret must be set")
+	}
+
+	secret, ok, err := client.GetSecret(namespace, apiKey.Secret)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch secret '%s/%s': %w", namespace, apiKey.Secret, err)
+	}
+	if !ok {
+		return nil, fmt.Errorf("secret '%s/%s' not found", namespace, apiKey.Secret)
+	}
+	if secret == nil {
+		return nil, fmt.Errorf("data for secret '%s/%s' must not be nil", namespace, apiKey.Secret)
+	}
+	if len(secret.Data) != 1 {
+		return nil, fmt.Errorf("found %d elements for secret '%s/%s', must be single element exactly", len(secret.Data), namespace, apiKey.Secret)
+	}
+
+	var keys []dynamic.APIKeyEntry
+	for _, data := range secret.Data {
+		if err := yaml.Unmarshal(data, &keys); err != nil {
+			return nil, fmt.Errorf("failed to parse API keys of secret '%s/%s': %w", namespace, apiKey.Secret, err)
+		}
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("secret '%s/%s' does not contain any API key", namespace, apiKey.Secret)
+	}
+
+	return &dynamic.APIKey{
+		HeaderName:      apiKey.HeaderName,
+		QueryParameter:  apiKey.QueryParameter,
+		CookieName:      apiKey.CookieName,
+		Keys:            keys,
+		HeaderField:     apiKey.HeaderField,
+		ForwardMetadata: apiKey.ForwardMetadata,
+		RemoveKey:       apiKey.RemoveKey,
+	}, nil
+}
+
 func createBasicAuthMiddleware(client Client, namespace string, basicAuth *traefikv1alpha1.BasicAuth) (*dynamic.BasicAuth, error) {
 	if basicAuth == nil {
 		return nil, nil
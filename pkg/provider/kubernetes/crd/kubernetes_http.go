@@ -329,9 +329,17 @@ func (c configBuilder) buildServersLB(namespace string, svc traefikv1alpha1.Load
 	// TODO: remove this when the fake client apply default values.
 	if svc.Strategy != "" {
 		switch svc.Strategy {
-		case dynamic.BalancerStrategyWRR, dynamic.BalancerStrategyP2C:
+		case dynamic.BalancerStrategyWRR, dynamic.BalancerStrategyP2C, dynamic.BalancerStrategyPeakEWMA:
 			lb.Strategy = svc.Strategy
 
+		case dynamic.BalancerStrategyRingHash, dynamic.BalancerStrategyMaglev:
+			lb.Strategy = svc.Strategy
+			lb.ConsistentHash = svc.ConsistentHash
+
+		case dynamic.BalancerStrategyLocality:
+			lb.Strategy = svc.Strategy
+			lb.Locality = svc.Locality
+
 		// Here we are just logging a warning as the default value is already applied.
 		case "RoundRobin":
 			log.Warn().
@@ -585,6 +593,7 @@ func (c configBuilder) loadServers(parentNamespace string, svc traefikv1alpha1.L
 				servers = append(servers, dynamic.Server{
 					URL:    fmt.Sprintf("%s://%s", protocol, net.JoinHostPort(address, strconv.Itoa(int(port)))),
 					Fenced: ptr.Deref(endpoint.Conditions.Terminating, false) && ptr.Deref(endpoint.Conditions.Serving, false),
+					Zone:   k8s.EndpointZone(endpoint),
 				})
 			}
 		}
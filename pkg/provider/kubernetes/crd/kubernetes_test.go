@@ -1766,6 +1766,109 @@ func TestLoadIngressRoutes(t *testing.T) {
 				TLS: &dynamic.TLSConfiguration{},
 			},
 		},
+		{
+			desc:  "Simple Ingress Route with consistent hashing",
+			paths: []string{"services.yml", "with_consistent_hash.yml"},
+			expected: &dynamic.Configuration{
+				UDP: &dynamic.UDPConfiguration{
+					Routers:  map[string]*dynamic.UDPRouter{},
+					Services: map[string]*dynamic.UDPService{},
+				},
+				TCP: &dynamic.TCPConfiguration{
+					Routers:           map[string]*dynamic.TCPRouter{},
+					Middlewares:       map[string]*dynamic.TCPMiddleware{},
+					Services:          map[string]*dynamic.TCPService{},
+					ServersTransports: map[string]*dynamic.TCPServersTransport{},
+				},
+				HTTP: &dynamic.HTTPConfiguration{
+					Routers: map[string]*dynamic.Router{
+						"default-test-route-6b204d94623b3df4370c": {
+							EntryPoints: []string{"foo"},
+							Service:     "default-test-route-6b204d94623b3df4370c",
+							Rule:        "Host(`foo.com`) && PathPrefix(`/bar`)",
+							Priority:    12,
+						},
+					},
+					Middlewares: map[string]*dynamic.Middleware{},
+					Services: map[string]*dynamic.Service{
+						"default-test-route-6b204d94623b3df4370c": {
+							LoadBalancer: &dynamic.ServersLoadBalancer{
+								Strategy: dynamic.BalancerStrategyMaglev,
+								ConsistentHash: &dynamic.ConsistentHash{
+									Header:    "X-User",
+									TableSize: 251,
+								},
+								Servers: []dynamic.Server{
+									{
+										URL: "http://10.10.0.1:80",
+									},
+									{
+										URL: "http://10.10.0.2:80",
+									},
+								},
+								PassHostHeader: pointer(true),
+								ResponseForwarding: &dynamic.ResponseForwarding{
+									FlushInterval: ptypes.Duration(100 * time.Millisecond),
+								},
+							},
+						},
+					},
+					ServersTransports: map[string]*dynamic.ServersTransport{},
+				},
+				TLS: &dynamic.TLSConfiguration{},
+			},
+		},
+		{
+			desc:  "Simple Ingress Route with locality strategy",
+			paths: []string{"services.yml", "with_locality.yml"},
+			expected: &dynamic.Configuration{
+				UDP: &dynamic.UDPConfiguration{
+					Routers:  map[string]*dynamic.UDPRouter{},
+					Services: map[string]*dynamic.UDPService{},
+				},
+				TCP: &dynamic.TCPConfiguration{
+					Routers:           map[string]*dynamic.TCPRouter{},
+					Middlewares:       map[string]*dynamic.TCPMiddleware{},
+					Services:          map[string]*dynamic.TCPService{},
+					ServersTransports: map[string]*dynamic.TCPServersTransport{},
+				},
+				HTTP: &dynamic.HTTPConfiguration{
+					Routers: map[string]*dynamic.Router{
+						"default-test-route-6b204d94623b3df4370c": {
+							EntryPoints: []string{"foo"},
+							Service:     "default-test-route-6b204d94623b3df4370c",
+							Rule:        "Host(`foo.com`) && PathPrefix(`/bar`)",
+							Priority:    12,
+						},
+					},
+					Middlewares: map[string]*dynamic.Middleware{},
+					Services: map[string]*dynamic.Service{
+						"default-test-route-6b204d94623b3df4370c": {
+							LoadBalancer: &dynamic.ServersLoadBalancer{
+								Strategy: dynamic.BalancerStrategyLocality,
+								Locality: &dynamic.Locality{
+									MinHealthyPercent: 50,
+								},
+								Servers: []dynamic.Server{
+									{
+										URL: "http://10.10.0.1:80",
+									},
+									{
+										URL: "http://10.10.0.2:80",
+									},
+								},
+								PassHostHeader: pointer(true),
+								ResponseForwarding: &dynamic.ResponseForwarding{
+									FlushInterval: ptypes.Duration(100 * time.Millisecond),
+								},
+							},
+						},
+					},
+					ServersTransports: map[string]*dynamic.ServersTransport{},
+				},
+				TLS: &dynamic.TLSConfiguration{},
+			},
+		},
 		{
 			desc:                "Simple Ingress Route with middleware",
 			allowCrossNamespace: true,
@@ -1891,6 +1994,21 @@ func TestLoadIngressRoutes(t *testing.T) {
 									WriteTimeout:   pointer(ptypes.Duration(42 * time.Second)),
 									DialTimeout:    pointer(ptypes.Duration(42 * time.Second)),
 								},
+								Quota: &dynamic.RateLimitQuota{
+									Limit:  1000,
+									Window: "day",
+								},
+								PlanCriterion: &dynamic.PlanCriterion{
+									RequestHeaderName: "X-Plan",
+								},
+								Plans: map[string]dynamic.RateLimitPlan{
+									"premium": {
+										Average: 60,
+										Period:  ptypes.Duration(time.Minute),
+										Burst:   1,
+										Quota:   &dynamic.RateLimitQuota{Limit: 100000},
+									},
+								},
 							},
 						},
 					},
@@ -8189,6 +8307,65 @@ func TestCreateBasicAuthCredentials(t *testing.T) {
 	assert.True(t, auth.CheckSecret("test2", hashedPassword))
 }
 
+func TestCreateAPIKeyMiddleware(t *testing.T) {
+	var k8sObjects []runtime.Object
+	yamlContent, err := os.ReadFile(filepath.FromSlash("./fixtures/api_key_secrets.yml"))
+	require.NoError(t, err)
+
+	for _, obj := range k8s.MustParseYaml(yamlContent) {
+		if o, ok := obj.(*corev1.Secret); ok {
+			k8sObjects = append(k8sObjects, o)
+		}
+	}
+
+	kubeClient := kubefake.NewClientset(k8sObjects...)
+	crdClient := traefikcrdfake.NewSimpleClientset()
+
+	client := newClientImpl(kubeClient, crdClient)
+
+	stopCh := make(chan struct{})
+
+	eventCh, err := client.WatchAll([]string{"default"}, stopCh)
+	require.NoError(t, err)
+
+	// just wait for the first event
+	<-eventCh
+
+	apiKey, err := createAPIKeyMiddleware(client, "default", &traefikv1alpha1.APIKey{
+		Secret:          "api-key-secret",
+		HeaderName:      "X-Token",
+		HeaderField:     "X-Consumer",
+		ForwardMetadata: map[string]string{"X-Plan": "plan"},
+		RemoveKey:       true,
+	})
+	require.NoError(t, err)
+
+	expected := &dynamic.APIKey{
+		HeaderName: "X-Token",
+		Keys: []dynamic.APIKeyEntry{
+			{
+				Hash:     "{SHA256}85dbe15d75ef9308c7ae0f33c7a324cc6f4bf519a2ed2f3027bd33c140a4f9aa",
+				Consumer: "alice",
+				Routers:  []string{"api@kubernetescrd"},
+				Metadata: map[string]string{"plan": "gold"},
+			},
+		},
+		HeaderField:     "X-Consumer",
+		ForwardMetadata: map[string]string{"X-Plan": "plan"},
+		RemoveKey:       true,
+	}
+	assert.Equal(t, expected, apiKey)
+
+	_, err = createAPIKeyMiddleware(client, "default", &traefikv1alpha1.APIKey{Secret: "api-key-invalid-secret"})
+	assert.Error(t, err)
+
+	_, err = createAPIKeyMiddleware(client, "default", &traefikv1alpha1.APIKey{Secret: "missing"})
+	assert.Error(t, err)
+
+	_, err = createAPIKeyMiddleware(client, "default", &traefikv1alpha1.APIKey{})
+	assert.Error(t, err)
+}
+
 func TestFillExtensionBuilderRegistry(t *testing.T) {
 	testCases := []struct {
 		desc       string
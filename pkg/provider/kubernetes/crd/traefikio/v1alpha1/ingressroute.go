@@ -114,11 +114,16 @@ type LoadBalancerSpec struct {
 	// It defaults to https when Kubernetes Service port is 443, http otherwise.
 	Scheme string `json:"scheme,omitempty"`
 	// Strategy defines the load balancing strategy between the servers.
-	// Supported values are: wrr (Weighed round-robin) and p2c (Power of two choices).
+	// Supported values are: wrr (Weighed round-robin), p2c (Power of two choices),
+	// ringhash and maglev (Consistent hashing), peakewma (Least latency) and locality (Zone-aware weighed round-robin).
 	// RoundRobin value is deprecated and supported for backward compatibility.
 	// TODO: when the deprecated RoundRobin value will be removed, set the default value to wrr.
-	// +kubebuilder:validation:Enum=wrr;p2c;RoundRobin
+	// +kubebuilder:validation:Enum=wrr;p2c;ringhash;maglev;peakewma;locality;RoundRobin
 	Strategy dynamic.BalancerStrategy `json:"strategy,omitempty"`
+	// ConsistentHash defines the request attribute hashed by the ringhash and maglev strategies.
+	ConsistentHash *dynamic.ConsistentHash `json:"consistentHash,omitempty"`
+	// Locality defines when the locality strategy overflows the traffic to the servers of the next locality.
+	Locality *dynamic.Locality `json:"locality,omitempty"`
 	// PassHostHeader defines whether the client Host header is forwarded to the upstream Kubernetes Service.
 	// By default, passHostHeader is true.
 	PassHostHeader *bool `json:"passHostHeader,omitempty"`
@@ -41,6 +41,7 @@ type MiddlewareSpec struct {
 	RedirectRegex     *dynamic.RedirectRegex     `json:"redirectRegex,omitempty"`
 	RedirectScheme    *dynamic.RedirectScheme    `json:"redirectScheme,omitempty"`
 	BasicAuth         *BasicAuth                 `json:"basicAuth,omitempty"`
+	APIKey            *APIKey                    `json:"apiKey,omitempty"`
 	DigestAuth        *DigestAuth                `json:"digestAuth,omitempty"`
 	ForwardAuth       *ForwardAuth               `json:"forwardAuth,omitempty"`
 	InFlightReq       *dynamic.InFlightReq       `json:"inFlightReq,omitempty"`
@@ -116,6 +117,28 @@ type Chain struct {
 
 // +k8s:deepcopy-gen=true
 
+// APIKey holds the API key middleware configuration.
+// This middleware restricts access to your services to the consumers of known API keys.
+type APIKey struct {
+	// Secret is the name of the referenced Kubernetes Secret containing the API keys, as a YAML list of hashed entries.
+	Secret string `json:"secret,omitempty"`
+	// HeaderName defines the name of the header holding the API key.
+	// Default: X-API-Key.
+	HeaderName string `json:"headerName,omitempty"`
+	// QueryParameter defines the name of the query parameter holding the API key, when not in the header.
+	QueryParameter string `json:"queryParameter,omitempty"`
+	// CookieName defines the name of the cookie holding the API key, when neither in the header nor in the query parameter.
+	CookieName string `json:"cookieName,omitempty"`
+	// HeaderField defines a header field to store the consumer of the API key.
+	HeaderField string `json:"headerField,omitempty"`
+	// ForwardMetadata defines the headers to set on the forwarded request from the API key metadata, as a map of header names to metadata names.
+	ForwardMetadata map[string]string `json:"forwardMetadata,omitempty"`
+	// RemoveKey defines whether to remove the API key from the request before forwarding it to the service.
+	RemoveKey bool `json:"removeKey,omitempty"`
+}
+
+// +k8s:deepcopy-gen=true
+
 // BasicAuth holds the basic auth middleware configuration.
 // This middleware restricts access to your services to known users.
 // More info: https://doc.traefik.io/traefik/v3.5/middlewares/http/basicauth/
@@ -223,6 +246,36 @@ type RateLimit struct {
 	SourceCriterion *dynamic.SourceCriterion `json:"sourceCriterion,omitempty"`
 	// Redis hold the configs of Redis as bucket in rate limiter.
 	Redis *Redis `json:"redis,omitempty"`
+	// Quota defines a long-window quota for the given source, enforced in addition to the rate.
+	Quota *dynamic.RateLimitQuota `json:"quota,omitempty"`
+	// QuotaFile defines the path of the file persisting the quota counters, when Redis is not used.
+	// If not specified, the quota counters are only kept in memory.
+	QuotaFile string `json:"quotaFile,omitempty"`
+	// Plans defines named plans, each with its own rate and quota, replacing the Average, Period, Burst and Quota options
+	// for the requests of the plan.
+	Plans map[string]RateLimitPlan `json:"plans,omitempty"`
+	// PlanCriterion defines what criterion is used to select the plan of a request.
+	// The requests without plan are limited by the Average, Period, Burst and Quota options.
+	PlanCriterion *dynamic.PlanCriterion `json:"planCriterion,omitempty"`
+}
+
+// +k8s:deepcopy-gen=true
+
+// RateLimitPlan holds the limits of a rate limiting plan.
+type RateLimitPlan struct {
+	// Average is the maximum rate, by default in requests/s, allowed for the given source.
+	// It defaults to 0, which means no rate limiting.
+	// +kubebuilder:validation:Minimum=0
+	Average *int64 `json:"average,omitempty"`
+	// Period, in combination with Average, defines the actual maximum rate. It defaults to a second.
+	// +kubebuilder:validation:XIntOrString
+	Period *intstr.IntOrString `json:"period,omitempty"`
+	// Burst is the maximum number of requests allowed to arrive in the same arbitrarily small period of time.
+	// It defaults to 1.
+	// +kubebuilder:validation:Minimum=0
+	Burst *int64 `json:"burst,omitempty"`
+	// Quota defines a long-window quota for the given source, enforced in addition to the rate.
+	Quota *dynamic.RateLimitQuota `json:"quota,omitempty"`
 }
 
 // +k8s:deepcopy-gen=true
@@ -307,7 +360,8 @@ type Compress struct {
 
 // Retry holds the retry middleware configuration.
 // This middleware reissues requests a given number of times to a backend server if that server does not reply.
-// As soon as the server answers, the middleware stops retrying, regardless of the response status.
+// As soon as the server answers, the middleware stops retrying,
+// unless the response status matches the status, grpcStatus or retryOn conditions.
 // More info: https://doc.traefik.io/traefik/v3.5/middlewares/http/retry/
 type Retry struct {
 	// Attempts defines how many times the request should be retried.
@@ -321,6 +375,28 @@ type Retry struct {
 	// +kubebuilder:validation:Pattern="^([0-9]+(ns|us|µs|ms|s|m|h)?)+$"
 	// +kubebuilder:validation:XIntOrString
 	InitialInterval intstr.IntOrString `json:"initialInterval,omitempty"`
+	// RetryOn defines the conditions, besides the response statuses, triggering a retry:
+	// connectFailure, when the request could not be sent to the backend,
+	// and timeout, when the backend did not answer in time (504 Gateway Timeout).
+	// Default: connectFailure.
+	// +kubebuilder:validation:items:Enum=connectFailure;timeout
+	RetryOn []string `json:"retryOn,omitempty"`
+	// Status defines which status or range of statuses of the backend response trigger a retry.
+	// It can be either a status code as a number (502),
+	// as multiple comma-separated numbers (502,503),
+	// as ranges by separating two codes with a dash (502-504),
+	// or a combination of the two (429,502-504).
+	// +kubebuilder:validation:items:Pattern=`^([1-5][0-9]{2}[,-]?)+$`
+	Status []string `json:"status,omitempty"`
+	// GRPCStatus defines the gRPC status codes of the backend response triggering a retry (14 for UNAVAILABLE).
+	// The gRPC status is read from the headers of the response, which is how errors are returned by gRPC servers.
+	GRPCStatus []int `json:"grpcStatus,omitempty"`
+	// Methods defines the methods of the requests retried on a response condition (status, gRPC status or timeout).
+	// Requests which could not be sent to the backend are retried whatever their method.
+	// Default: the idempotent methods, GET, HEAD, OPTIONS, TRACE, PUT and DELETE.
+	Methods []string `json:"methods,omitempty"`
+	// Budget limits the number of concurrent retries, to prevent retry storms.
+	Budget *dynamic.RetryBudget `json:"budget,omitempty"`
 }
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
@@ -49,6 +49,9 @@ type ServiceIng struct {
 	Sticky           *dynamic.Sticky `json:"sticky,omitempty" label:"allowEmpty"`
 	NativeLB         *bool           `json:"nativeLB,omitempty"`
 	NodePortLB       bool            `json:"nodePortLB,omitempty"`
+
+	Strategy dynamic.BalancerStrategy `json:"strategy,omitempty"`
+	Locality *dynamic.Locality        `json:"locality,omitempty" label:"allowEmpty"`
 }
 
 // SetDefaults sets the default values.
@@ -143,6 +143,23 @@ func Test_parseServiceConfig(t *testing.T) {
 				},
 			},
 		},
+		{
+			desc: "locality strategy annotations",
+			annotations: map[string]string{
+				"traefik.ingress.kubernetes.io/service.strategy":                      "locality",
+				"traefik.ingress.kubernetes.io/service.locality.maxinflightperserver": "10",
+			},
+			expected: &ServiceConfig{
+				Service: &ServiceIng{
+					Strategy: dynamic.BalancerStrategyLocality,
+					Locality: &dynamic.Locality{
+						MinHealthyPercent:    70,
+						MaxInFlightPerServer: 10,
+					},
+					PassHostHeader: pointer(true),
+				},
+			},
+		},
 		{
 			desc: "simple sticky annotation",
 			annotations: map[string]string{
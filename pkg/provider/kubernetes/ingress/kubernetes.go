@@ -556,6 +556,12 @@ func (p *Provider) loadService(client Client, namespace string, backend netv1.In
 			svc.LoadBalancer.ServersTransport = svcConfig.Service.ServersTransport
 		}
 
+		if svcConfig.Service.Strategy != "" {
+			svc.LoadBalancer.Strategy = svcConfig.Service.Strategy
+		}
+
+		svc.LoadBalancer.Locality = svcConfig.Service.Locality
+
 		if svcConfig.Service.NativeLB != nil {
 			nativeLB = *svcConfig.Service.NativeLB
 		}
@@ -659,6 +665,7 @@ func (p *Provider) loadService(client Client, namespace string, backend netv1.In
 				svc.LoadBalancer.Servers = append(svc.LoadBalancer.Servers, dynamic.Server{
 					URL:    fmt.Sprintf("%s://%s", protocol, net.JoinHostPort(address, strconv.Itoa(int(port)))),
 					Fenced: ptr.Deref(endpoint.Conditions.Terminating, false) && ptr.Deref(endpoint.Conditions.Serving, false),
+					Zone:   k8s.EndpointZone(endpoint),
 				})
 			}
 		}
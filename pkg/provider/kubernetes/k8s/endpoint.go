@@ -9,3 +9,15 @@ import (
 func EndpointServing(endpoint v1.Endpoint) bool {
 	return ptr.Deref(endpoint.Conditions.Ready, false) || ptr.Deref(endpoint.Conditions.Serving, false)
 }
+
+// EndpointZone returns the zone of the endpoint,
+// or the zone the topology hints assign it to when the zone is unknown.
+func EndpointZone(endpoint v1.Endpoint) string {
+	if endpoint.Zone != nil {
+		return *endpoint.Zone
+	}
+	if endpoint.Hints != nil && len(endpoint.Hints.ForZones) > 0 {
+		return endpoint.Hints.ForZones[0].Name
+	}
+	return ""
+}
@@ -72,4 +72,39 @@ func TestEndpointServing(t *testing.T) {
 	}
 }
 
+func TestEndpointZone(t *testing.T) {
+	tests := []struct {
+		name     string
+		endpoint v1.Endpoint
+		want     string
+	}{
+		{
+			name:     "no zone",
+			endpoint: v1.Endpoint{},
+			want:     "",
+		},
+		{
+			name: "zone",
+			endpoint: v1.Endpoint{
+				Zone:  pointer("zone-a"),
+				Hints: &v1.EndpointHints{ForZones: []v1.ForZone{{Name: "zone-b"}}},
+			},
+			want: "zone-a",
+		},
+		{
+			name: "topology hints",
+			endpoint: v1.Endpoint{
+				Hints: &v1.EndpointHints{ForZones: []v1.ForZone{{Name: "zone-b"}}},
+			},
+			want: "zone-b",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := EndpointZone(test.endpoint)
+			assert.Equal(t, test.want, got)
+		})
+	}
+}
+
 func pointer[T any](v T) *T { return &v }
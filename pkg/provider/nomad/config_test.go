@@ -2803,7 +2803,8 @@ func Test_buildConfig(t *testing.T) {
 								Strategy: dynamic.BalancerStrategyWRR,
 								Servers: []dynamic.Server{
 									{
-										URL: "http://127.0.0.1:80",
+										URL:  "http://127.0.0.1:80",
+										Zone: "dc1",
 									},
 								},
 								PassHostHeader: pointer(true),
@@ -2817,7 +2818,8 @@ func Test_buildConfig(t *testing.T) {
 								Strategy: dynamic.BalancerStrategyWRR,
 								Servers: []dynamic.Server{
 									{
-										URL: "http://127.0.0.2:80",
+										URL:  "http://127.0.0.2:80",
+										Zone: "dc1",
 									},
 								},
 								PassHostHeader: pointer(true),
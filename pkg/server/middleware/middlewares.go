@@ -9,10 +9,14 @@ import (
 	"reflect"
 	"slices"
 	"strings"
+	"sync"
 
 	"github.com/containous/alice"
 	"github.com/rs/zerolog/log"
+	"github.com/traefik/traefik/v3/pkg/config/dynamic"
 	"github.com/traefik/traefik/v3/pkg/config/runtime"
+	"github.com/traefik/traefik/v3/pkg/metrics"
+	"github.com/traefik/traefik/v3/pkg/middlewares/accesslog"
 	"github.com/traefik/traefik/v3/pkg/middlewares/addprefix"
 	"github.com/traefik/traefik/v3/pkg/middlewares/auth"
 	"github.com/traefik/traefik/v3/pkg/middlewares/buffering"
@@ -49,9 +53,15 @@ const (
 
 // Builder the middleware builder.
 type Builder struct {
-	configs        map[string]*runtime.MiddlewareInfo
-	pluginBuilder  PluginsBuilder
-	serviceBuilder serviceBuilder
+	configs         map[string]*runtime.MiddlewareInfo
+	pluginBuilder   PluginsBuilder
+	serviceBuilder  serviceBuilder
+	metricsRegistry metrics.Registry
+
+	// inFlightReqQueues are the queues of the InFlightReq middlewares in queue mode,
+	// shared by the routers using them, keyed by middleware name.
+	inFlightReqQueuesMu sync.Mutex
+	inFlightReqQueues   map[string]*inflightreq.Queue
 }
 
 type serviceBuilder interface {
@@ -59,8 +69,15 @@ type serviceBuilder interface {
 }
 
 // NewBuilder creates a new Builder.
-func NewBuilder(configs map[string]*runtime.MiddlewareInfo, serviceBuilder serviceBuilder, pluginBuilder PluginsBuilder) *Builder {
-	return &Builder{configs: configs, serviceBuilder: serviceBuilder, pluginBuilder: pluginBuilder}
+// metricsRegistry may be nil.
+func NewBuilder(configs map[string]*runtime.MiddlewareInfo, serviceBuilder serviceBuilder, pluginBuilder PluginsBuilder, metricsRegistry metrics.Registry) *Builder {
+	return &Builder{
+		configs:           configs,
+		serviceBuilder:    serviceBuilder,
+		pluginBuilder:     pluginBuilder,
+		metricsRegistry:   metricsRegistry,
+		inFlightReqQueues: make(map[string]*inflightreq.Queue),
+	}
 }
 
 // BuildChain creates a middleware chain.
@@ -99,6 +116,25 @@ func (b *Builder) BuildChain(ctx context.Context, middlewares []string) *alice.C
 	return &chain
 }
 
+// getInFlightReqQueue returns the queue of the InFlightReq middleware with the given name,
+// creating it for the first router using the middleware.
+func (b *Builder) getInFlightReqQueue(ctx context.Context, middlewareName string, config dynamic.InFlightReq) (*inflightreq.Queue, error) {
+	b.inFlightReqQueuesMu.Lock()
+	defer b.inFlightReqQueuesMu.Unlock()
+
+	if queue, ok := b.inFlightReqQueues[middlewareName]; ok {
+		return queue, nil
+	}
+
+	queue, err := inflightreq.NewQueue(ctx, config, middlewareName, b.metricsRegistry)
+	if err != nil {
+		return nil, fmt.Errorf("creating in-flight requests queue: %w", err)
+	}
+
+	b.inFlightReqQueues[middlewareName] = queue
+	return queue, nil
+}
+
 func checkRecursion(ctx context.Context, middlewareName string) (context.Context, error) {
 	currentStack, ok := ctx.Value(middlewareStackKey).([]string)
 	if !ok {
@@ -232,6 +268,46 @@ func (b *Builder) buildConstructor(ctx context.Context, middlewareName string) (
 		}
 	}
 
+	// JWT
+	if config.JWT != nil {
+		if middleware != nil {
+			return nil, badConf
+		}
+		middleware = func(next http.Handler) (http.Handler, error) {
+			return auth.NewJWT(ctx, next, *config.JWT, middlewareName)
+		}
+	}
+
+	// OIDC
+	if config.OIDC != nil {
+		if middleware != nil {
+			return nil, badConf
+		}
+		middleware = func(next http.Handler) (http.Handler, error) {
+			return auth.NewOIDC(ctx, next, *config.OIDC, middlewareName)
+		}
+	}
+
+	// APIKey
+	if config.APIKey != nil {
+		if middleware != nil {
+			return nil, badConf
+		}
+		middleware = func(next http.Handler) (http.Handler, error) {
+			return auth.NewAPIKey(ctx, next, *config.APIKey, middlewareName)
+		}
+	}
+
+	// HMAC
+	if config.HMAC != nil {
+		if middleware != nil {
+			return nil, badConf
+		}
+		middleware = func(next http.Handler) (http.Handler, error) {
+			return auth.NewHMAC(ctx, next, *config.HMAC, middlewareName)
+		}
+	}
+
 	// GrpcWeb
 	if config.GrpcWeb != nil {
 		if middleware != nil {
@@ -280,8 +356,26 @@ func (b *Builder) buildConstructor(ctx context.Context, middlewareName string) (
 		if middleware != nil {
 			return nil, badConf
 		}
+
+		if config.InFlightReq.Queue != nil {
+			for i, class := range config.InFlightReq.Queue.PriorityClasses {
+				var qualifiedNames []string
+				for _, name := range class.Routers {
+					qualifiedNames = append(qualifiedNames, provider.GetQualifiedName(ctx, name))
+				}
+				config.InFlightReq.Queue.PriorityClasses[i].Routers = qualifiedNames
+			}
+		}
 		middleware = func(next http.Handler) (http.Handler, error) {
-			return inflightreq.New(ctx, next, *config.InFlightReq, middlewareName)
+			if config.InFlightReq.Queue == nil {
+				return inflightreq.New(ctx, next, *config.InFlightReq, middlewareName)
+			}
+
+			queue, err := b.getInFlightReqQueue(ctx, middlewareName, *config.InFlightReq)
+			if err != nil {
+				return nil, err
+			}
+			return inflightreq.NewQueued(ctx, next, queue, middlewareName), nil
 		}
 	}
 
@@ -351,8 +445,8 @@ func (b *Builder) buildConstructor(ctx context.Context, middlewareName string) (
 			return nil, badConf
 		}
 		middleware = func(next http.Handler) (http.Handler, error) {
-			// TODO missing metrics / accessLog
-			return retry.New(ctx, next, *config.Retry, retry.Listeners{}, middlewareName)
+			// The retries are counted in the service metrics by the service handlers.
+			return retry.New(ctx, next, *config.Retry, retry.Listeners{&accesslog.SaveRetries{}}, middlewareName)
 		}
 	}
 
@@ -166,7 +166,7 @@ func Test_Routing(t *testing.T) {
 
 	dialerManager := tcp2.NewDialerManager(nil)
 	dialerManager.Update(map[string]*dynamic.TCPServersTransport{"default@internal": {}})
-	serviceManager := tcp.NewManager(conf, dialerManager)
+	serviceManager := tcp.NewManager(conf, dialerManager, nil)
 
 	certPEM, keyPEM, err := generate.KeyPair("foo.bar", time.Time{})
 	require.NoError(t, err)
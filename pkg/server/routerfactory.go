@@ -14,6 +14,7 @@ import (
 	tcprouter "github.com/traefik/traefik/v3/pkg/server/router/tcp"
 	udprouter "github.com/traefik/traefik/v3/pkg/server/router/udp"
 	"github.com/traefik/traefik/v3/pkg/server/service"
+	"github.com/traefik/traefik/v3/pkg/server/service/loadbalancer"
 	tcpsvc "github.com/traefik/traefik/v3/pkg/server/service/tcp"
 	udpsvc "github.com/traefik/traefik/v3/pkg/server/service/udp"
 	"github.com/traefik/traefik/v3/pkg/tcp"
@@ -38,6 +39,9 @@ type RouterFactory struct {
 
 	cancelPrevState func()
 
+	// tcpSlowStarts are the SlowStarts of the last built TCP services.
+	tcpSlowStarts *loadbalancer.SlowStarts
+
 	parser httpmuxer.SyntaxParser
 }
 
@@ -101,7 +105,7 @@ func (f *RouterFactory) CreateRouters(rtConf *runtime.Configuration) (map[string
 	// HTTP
 	serviceManager := f.managerFactory.Build(rtConf)
 
-	middlewaresBuilder := middleware.NewBuilder(rtConf.Middlewares, serviceManager, f.pluginBuilder)
+	middlewaresBuilder := middleware.NewBuilder(rtConf.Middlewares, serviceManager, f.pluginBuilder, f.observabilityMgr.MetricsRegistry())
 
 	routerManager := router.NewManager(rtConf, serviceManager, middlewaresBuilder, f.observabilityMgr, f.tlsManager, f.parser)
 
@@ -111,7 +115,8 @@ func (f *RouterFactory) CreateRouters(rtConf *runtime.Configuration) (map[string
 	serviceManager.LaunchHealthCheck(ctx)
 
 	// TCP
-	svcTCPManager := tcpsvc.NewManager(rtConf, f.dialerManager)
+	f.tcpSlowStarts = loadbalancer.NewSlowStarts(f.tcpSlowStarts)
+	svcTCPManager := tcpsvc.NewManager(rtConf, f.dialerManager, f.tcpSlowStarts)
 
 	middlewaresTCPBuilder := tcpmiddleware.NewBuilder(rtConf.TCPMiddlewares)
 
@@ -0,0 +1,358 @@
+package hash
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/rs/zerolog/log"
+	"github.com/traefik/traefik/v3/pkg/config/dynamic"
+	"github.com/traefik/traefik/v3/pkg/ip"
+	"github.com/traefik/traefik/v3/pkg/middlewares"
+	"github.com/traefik/traefik/v3/pkg/server/service/loadbalancer"
+	"github.com/zeebo/xxh3"
+)
+
+const (
+	defaultMaglevTableSize = 65537
+	defaultRingSize        = 4096
+)
+
+type namedHandler struct {
+	http.Handler
+
+	name   string
+	weight int
+}
+
+// table maps a key hash onto one of the servers it has been built of.
+type table interface {
+	// lookup returns the server of the entry the hash maps onto, or of the next entry whose server is not skipped.
+	// The server of the entry the hash maps onto is returned if all of them are skipped.
+	lookup(hash uint64, skip func(h *namedHandler) bool) *namedHandler
+}
+
+// Balancer is a consistent hashing load balancer: a request attribute is hashed onto a lookup table (Maglev)
+// or a ring of virtual nodes built of the healthy servers, so requests with the same attribute value reach
+// the same server. When a server goes down or comes back, only the keys of this server are remapped.
+type Balancer struct {
+	wantsHealthCheck bool
+
+	strategy  dynamic.BalancerStrategy
+	tableSize int
+	key       func(req *http.Request) string
+	sourceIP  ip.Strategy
+
+	// handlersMu is a mutex to protect the handlers slice, the status and the fenced maps, and the table.
+	handlersMu sync.RWMutex
+	handlers   []*namedHandler
+	// status is a record of which child services of the Balancer are healthy, keyed
+	// by name of child service. A service is initially added to the map when it is
+	// created via Add, and it is later removed or added to the map as needed,
+	// through the SetStatus method.
+	status map[string]struct{}
+	// fenced is the list of terminating yet still serving child services.
+	fenced map[string]struct{}
+	// table is built of the healthy and not fenced handlers, nil if there is none.
+	table table
+
+	// updaters is the list of hooks that are run (to update the Balancer
+	// parent(s)), whenever the Balancer status changes.
+	updaters []func(bool)
+
+	sticky *loadbalancer.Sticky
+}
+
+// New creates a new consistent hashing load balancer of the given strategy (ringhash or maglev).
+func New(stickyConfig *dynamic.Sticky, hashConfig *dynamic.ConsistentHash, strategy dynamic.BalancerStrategy, wantsHealthCheck bool) (*Balancer, error) {
+	if hashConfig == nil {
+		hashConfig = &dynamic.ConsistentHash{}
+	}
+
+	balancer := &Balancer{
+		status:           make(map[string]struct{}),
+		fenced:           make(map[string]struct{}),
+		wantsHealthCheck: wantsHealthCheck,
+		strategy:         strategy,
+		tableSize:        hashConfig.TableSize,
+	}
+
+	switch strategy {
+	case dynamic.BalancerStrategyMaglev:
+		if balancer.tableSize == 0 {
+			balancer.tableSize = defaultMaglevTableSize
+		}
+		if !isPrime(balancer.tableSize) {
+			return nil, fmt.Errorf("maglev table size must be a prime number, got %d", balancer.tableSize)
+		}
+	case dynamic.BalancerStrategyRingHash:
+		if balancer.tableSize == 0 {
+			balancer.tableSize = defaultRingSize
+		}
+		if balancer.tableSize < 0 {
+			return nil, fmt.Errorf("ring size must be positive, got %d", balancer.tableSize)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported consistent hashing strategy %q", strategy)
+	}
+
+	var err error
+	if balancer.sourceIP, err = hashConfig.SourceIP.Get(); err != nil {
+		return nil, fmt.Errorf("getting source IP strategy: %w", err)
+	}
+	if balancer.key, err = keyFunc(hashConfig); err != nil {
+		return nil, err
+	}
+
+	if stickyConfig != nil && stickyConfig.Cookie != nil {
+		balancer.sticky = loadbalancer.NewSticky(*stickyConfig.Cookie)
+	}
+
+	return balancer, nil
+}
+
+// keyFunc returns the extractor of the configured request attribute, nil for the source IP.
+func keyFunc(config *dynamic.ConsistentHash) (func(req *http.Request) string, error) {
+	var (
+		key func(req *http.Request) string
+		set int
+	)
+	if config.Header != "" {
+		name := http.CanonicalHeaderKey(config.Header)
+		key = func(req *http.Request) string { return req.Header.Get(name) }
+		set++
+	}
+	if config.Cookie != "" {
+		name := config.Cookie
+		key = func(req *http.Request) string {
+			if cookie, err := req.Cookie(name); err == nil {
+				return cookie.Value
+			}
+			return ""
+		}
+		set++
+	}
+	if config.Query != "" {
+		name := config.Query
+		key = func(req *http.Request) string { return req.URL.Query().Get(name) }
+		set++
+	}
+	if config.Path {
+		key = func(req *http.Request) string { return req.URL.Path }
+		set++
+	}
+	if config.SourceIP != nil {
+		set++
+	}
+
+	if set > 1 {
+		return nil, errors.New("only one of header, cookie, query, path and sourceIP can be hashed")
+	}
+	return key, nil
+}
+
+// hash returns the hash of the configured request attribute, or of the source IP if the request has no such attribute.
+func (b *Balancer) hash(req *http.Request) uint64 {
+	if b.key != nil {
+		if key := b.key(req); key != "" {
+			return xxh3.HashString(key)
+		}
+	}
+	return xxh3.HashString(b.sourceIP.GetIP(req))
+}
+
+// SetStatus sets on the balancer that its given child is now of the given
+// status. balancerName is only needed for logging purposes.
+func (b *Balancer) SetStatus(ctx context.Context, childName string, up bool) {
+	b.handlersMu.Lock()
+	defer b.handlersMu.Unlock()
+
+	upBefore := len(b.status) > 0
+
+	status := "DOWN"
+	if up {
+		status = "UP"
+	}
+
+	log.Ctx(ctx).Debug().Msgf("Setting status of %s to %v", childName, status)
+
+	_, wasUp := b.status[childName]
+	if up {
+		b.status[childName] = struct{}{}
+	} else {
+		delete(b.status, childName)
+	}
+	if wasUp != up {
+		b.rebuildTable()
+	}
+
+	upAfter := len(b.status) > 0
+	status = "DOWN"
+	if upAfter {
+		status = "UP"
+	}
+
+	// No Status Change
+	if upBefore == upAfter {
+		// We're still with the same status, no need to propagate
+		log.Ctx(ctx).Debug().Msgf("Still %s, no need to propagate", status)
+		return
+	}
+
+	// Status Change
+	log.Ctx(ctx).Debug().Msgf("Propagating new %s status", status)
+	for _, fn := range b.updaters {
+		fn(upAfter)
+	}
+}
+
+// RegisterStatusUpdater adds fn to the list of hooks that are run when the
+// status of the Balancer changes.
+// Not thread safe.
+func (b *Balancer) RegisterStatusUpdater(fn func(up bool)) error {
+	if !b.wantsHealthCheck {
+		return errors.New("healthCheck not enabled in config for this consistent hashing service")
+	}
+	b.updaters = append(b.updaters, fn)
+	return nil
+}
+
+// rebuildTable rebuilds the table of the healthy and not fenced handlers.
+// The caller must hold the handlers lock.
+func (b *Balancer) rebuildTable() {
+	var healthy []*namedHandler
+	for _, h := range b.handlers {
+		if _, ok := b.status[h.name]; ok {
+			if _, fenced := b.fenced[h.name]; !fenced {
+				healthy = append(healthy, h)
+			}
+		}
+	}
+
+	if len(healthy) == 0 {
+		b.table = nil
+		return
+	}
+
+	// servers are shuffled on creation: the table must not depend on their order,
+	// so that every instance maps a key to the same server.
+	slices.SortFunc(healthy, func(a, b *namedHandler) int { return strings.Compare(a.name, b.name) })
+
+	switch b.strategy {
+	case dynamic.BalancerStrategyMaglev:
+		b.table = newMaglev(healthy, b.tableSize)
+	default:
+		var totalWeight int
+		for _, h := range b.handlers {
+			totalWeight += h.weight
+		}
+		b.table = newRing(healthy, b.tableSize, totalWeight)
+	}
+}
+
+var errNoAvailableServer = errors.New("no available server")
+
+// nextServer returns the handler the request hashes onto. A retry is given the next server of the table
+// instead of a tried one, when there are others available.
+func (b *Balancer) nextServer(req *http.Request, tried *middlewares.TriedServers) (*namedHandler, error) {
+	hash := b.hash(req)
+
+	b.handlersMu.RLock()
+	defer b.handlersMu.RUnlock()
+
+	if b.table == nil {
+		return nil, errNoAvailableServer
+	}
+
+	h := b.table.lookup(hash, func(h *namedHandler) bool { return tried.Has(h.name) })
+	log.Debug().Msgf("Service selected by %s: %s", b.strategy, h.name)
+	return h, nil
+}
+
+func (b *Balancer) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	tried := middlewares.GetTriedServers(req.Context())
+
+	if b.sticky != nil {
+		h, rewrite, err := b.sticky.StickyHandler(req)
+		if err != nil {
+			log.Error().Err(err).Msg("Error while getting sticky handler")
+		} else if h != nil {
+			b.handlersMu.RLock()
+			_, ok := b.status[h.Name]
+			b.handlersMu.RUnlock()
+			if ok && !tried.Has(h.Name) {
+				tried.Add(h.Name)
+				if rewrite {
+					if err := b.sticky.WriteStickyCookie(rw, h.Name); err != nil {
+						log.Error().Err(err).Msg("Writing sticky cookie")
+					}
+				}
+
+				h.ServeHTTP(rw, req)
+				return
+			}
+		}
+	}
+
+	server, err := b.nextServer(req, tried)
+	if err != nil {
+		if errors.Is(err, errNoAvailableServer) {
+			http.Error(rw, errNoAvailableServer.Error(), http.StatusServiceUnavailable)
+		} else {
+			http.Error(rw, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	if b.sticky != nil {
+		if err := b.sticky.WriteStickyCookie(rw, server.name); err != nil {
+			log.Error().Err(err).Msg("Error while writing sticky cookie")
+		}
+	}
+
+	tried.Add(server.name)
+	server.ServeHTTP(rw, req)
+}
+
+// AddServer adds a handler with a server.
+// A handler with a non-positive weight is ignored.
+func (b *Balancer) AddServer(name string, handler http.Handler, server dynamic.Server) {
+	weight := 1
+	if server.Weight != nil {
+		weight = *server.Weight
+	}
+	if weight <= 0 { // non-positive weight is meaningless
+		return
+	}
+
+	h := &namedHandler{Handler: handler, name: name, weight: weight}
+
+	b.handlersMu.Lock()
+	b.handlers = append(b.handlers, h)
+	b.status[name] = struct{}{}
+	if server.Fenced {
+		b.fenced[name] = struct{}{}
+	}
+	b.rebuildTable()
+	b.handlersMu.Unlock()
+
+	if b.sticky != nil {
+		b.sticky.AddHandler(name, h)
+	}
+}
+
+func isPrime(n int) bool {
+	if n < 2 {
+		return false
+	}
+	for i := 2; i*i <= n; i++ {
+		if n%i == 0 {
+			return false
+		}
+	}
+	return true
+}
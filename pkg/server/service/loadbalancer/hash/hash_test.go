@@ -0,0 +1,334 @@
+package hash
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/traefik/traefik/v3/pkg/config/dynamic"
+	"github.com/traefik/traefik/v3/pkg/middlewares"
+)
+
+func pointer[T any](v T) *T { return &v }
+
+// newTestBalancer builds a balancer of handlers answering with their name in the server header.
+func newTestBalancer(t *testing.T, strategy dynamic.BalancerStrategy, config *dynamic.ConsistentHash, weights ...int) *Balancer {
+	t.Helper()
+
+	balancer, err := New(nil, config, strategy, true)
+	require.NoError(t, err)
+
+	for i, weight := range weights {
+		name := strconv.Itoa(i)
+		balancer.AddServer(name, http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+			rw.Header().Set("server", name)
+		}), dynamic.Server{Weight: pointer(weight)})
+	}
+	return balancer
+}
+
+func serve(balancer *Balancer, req *http.Request) string {
+	recorder := httptest.NewRecorder()
+	balancer.ServeHTTP(recorder, req)
+	return recorder.Header().Get("server")
+}
+
+func TestNew(t *testing.T) {
+	testCases := []struct {
+		desc      string
+		strategy  dynamic.BalancerStrategy
+		config    *dynamic.ConsistentHash
+		expectErr bool
+	}{
+		{
+			desc:     "maglev default",
+			strategy: dynamic.BalancerStrategyMaglev,
+		},
+		{
+			desc:     "ring default",
+			strategy: dynamic.BalancerStrategyRingHash,
+		},
+		{
+			desc:      "maglev table size not prime",
+			strategy:  dynamic.BalancerStrategyMaglev,
+			config:    &dynamic.ConsistentHash{TableSize: 100},
+			expectErr: true,
+		},
+		{
+			desc:      "negative ring size",
+			strategy:  dynamic.BalancerStrategyRingHash,
+			config:    &dynamic.ConsistentHash{TableSize: -1},
+			expectErr: true,
+		},
+		{
+			desc:      "several attributes",
+			strategy:  dynamic.BalancerStrategyRingHash,
+			config:    &dynamic.ConsistentHash{Header: "X-User", Query: "user"},
+			expectErr: true,
+		},
+		{
+			desc:      "unsupported strategy",
+			strategy:  dynamic.BalancerStrategyWRR,
+			expectErr: true,
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := New(nil, test.config, test.strategy, false)
+			if test.expectErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestKeys(t *testing.T) {
+	testCases := []struct {
+		desc   string
+		config *dynamic.ConsistentHash
+		with   func(req *http.Request, value string)
+	}{
+		{
+			desc:   "header",
+			config: &dynamic.ConsistentHash{Header: "x-user"},
+			with:   func(req *http.Request, value string) { req.Header.Set("X-User", value) },
+		},
+		{
+			desc:   "cookie",
+			config: &dynamic.ConsistentHash{Cookie: "session"},
+			with:   func(req *http.Request, value string) { req.AddCookie(&http.Cookie{Name: "session", Value: value}) },
+		},
+		{
+			desc:   "query",
+			config: &dynamic.ConsistentHash{Query: "user"},
+			with:   func(req *http.Request, value string) { req.URL.RawQuery = "user=" + value },
+		},
+		{
+			desc:   "path",
+			config: &dynamic.ConsistentHash{Path: true},
+			with:   func(req *http.Request, value string) { req.URL.Path = "/" + value },
+		},
+		{
+			desc:   "source IP",
+			config: &dynamic.ConsistentHash{SourceIP: &dynamic.IPStrategy{}},
+			with:   func(req *http.Request, value string) { req.RemoteAddr = value + ":1234" },
+		},
+		{
+			desc:   "forwarded source IP",
+			config: &dynamic.ConsistentHash{SourceIP: &dynamic.IPStrategy{Depth: 1}},
+			with:   func(req *http.Request, value string) { req.Header.Set("X-Forwarded-For", value) },
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			balancer := newTestBalancer(t, dynamic.BalancerStrategyMaglev, test.config, 1, 1, 1, 1)
+
+			servers := make(map[string]struct{})
+			for i := range 100 {
+				value := "10.0.0." + strconv.Itoa(i)
+
+				req := httptest.NewRequest(http.MethodGet, "/", nil)
+				test.with(req, value)
+				server := serve(balancer, req)
+
+				// the same value always reaches the same server, whatever the other attributes
+				other := httptest.NewRequest(http.MethodGet, "/", nil)
+				other.RemoteAddr = "192.168.0.1:1234"
+				test.with(other, value)
+				if test.desc == "source IP" {
+					other.Header.Set("X-User", "other")
+				}
+				assert.Equal(t, server, serve(balancer, other))
+
+				servers[server] = struct{}{}
+			}
+			assert.Len(t, servers, 4)
+		})
+	}
+}
+
+func TestMissingAttributeFallsBackToSourceIP(t *testing.T) {
+	balancer := newTestBalancer(t, dynamic.BalancerStrategyRingHash, &dynamic.ConsistentHash{Header: "X-User"}, 1, 1, 1, 1)
+
+	servers := make(map[string]struct{})
+	for i := range 100 {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = "10.0.0." + strconv.Itoa(i) + ":1234"
+		server := serve(balancer, req)
+
+		req.RemoteAddr = "10.0.0." + strconv.Itoa(i) + ":4321"
+		assert.Equal(t, server, serve(balancer, req))
+
+		servers[server] = struct{}{}
+	}
+	assert.Len(t, servers, 4)
+}
+
+func TestWeightedDistribution(t *testing.T) {
+	for _, strategy := range []dynamic.BalancerStrategy{dynamic.BalancerStrategyMaglev, dynamic.BalancerStrategyRingHash} {
+		t.Run(string(strategy), func(t *testing.T) {
+			t.Parallel()
+
+			balancer := newTestBalancer(t, strategy, &dynamic.ConsistentHash{Header: "X-User"}, 1, 3, 0)
+
+			counts := make(map[string]int)
+			for i := range 10000 {
+				req := httptest.NewRequest(http.MethodGet, "/", nil)
+				req.Header.Set("X-User", strconv.Itoa(i))
+				counts[serve(balancer, req)]++
+			}
+
+			assert.InDelta(t, 2500, counts["0"], 400)
+			assert.InDelta(t, 7500, counts["1"], 400)
+			assert.Zero(t, counts["2"], "a server with a zero weight must not be selected")
+		})
+	}
+}
+
+func TestMinimalRemapping(t *testing.T) {
+	for _, strategy := range []dynamic.BalancerStrategy{dynamic.BalancerStrategyMaglev, dynamic.BalancerStrategyRingHash} {
+		t.Run(string(strategy), func(t *testing.T) {
+			t.Parallel()
+
+			balancer := newTestBalancer(t, strategy, &dynamic.ConsistentHash{Header: "X-User"}, 1, 1, 1, 1, 1)
+
+			const keys = 10000
+			request := func(i int) *http.Request {
+				req := httptest.NewRequest(http.MethodGet, "/", nil)
+				req.Header.Set("X-User", strconv.Itoa(i))
+				return req
+			}
+
+			before := make([]string, keys)
+			for i := range keys {
+				before[i] = serve(balancer, request(i))
+			}
+
+			balancer.SetStatus(context.Background(), "2", false)
+
+			var moved int
+			for i := range keys {
+				after := serve(balancer, request(i))
+				assert.NotEqual(t, "2", after)
+				if before[i] != "2" && after != before[i] {
+					moved++
+				}
+			}
+			// only the keys of the server that went down are remapped (Maglev allows a little disruption)
+			assert.Less(t, moved, keys/100)
+
+			balancer.SetStatus(context.Background(), "2", true)
+
+			for i := range keys {
+				assert.Equal(t, before[i], serve(balancer, request(i)))
+			}
+		})
+	}
+}
+
+func TestStatusPropagation(t *testing.T) {
+	balancer := newTestBalancer(t, dynamic.BalancerStrategyMaglev, nil, 1, 1)
+
+	var statuses []bool
+	require.NoError(t, balancer.RegisterStatusUpdater(func(up bool) {
+		statuses = append(statuses, up)
+	}))
+
+	balancer.SetStatus(context.Background(), "0", false)
+	assert.Empty(t, statuses)
+
+	balancer.SetStatus(context.Background(), "1", false)
+	assert.Equal(t, []bool{false}, statuses)
+
+	recorder := httptest.NewRecorder()
+	balancer.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+
+	balancer.SetStatus(context.Background(), "1", true)
+	assert.Equal(t, []bool{false, true}, statuses)
+	assert.Equal(t, "1", serve(balancer, httptest.NewRequest(http.MethodGet, "/", nil)))
+}
+
+func TestFencedServer(t *testing.T) {
+	balancer, err := New(nil, nil, dynamic.BalancerStrategyRingHash, false)
+	require.NoError(t, err)
+
+	balancer.AddServer("fenced", http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.Header().Set("server", "fenced")
+	}), dynamic.Server{Fenced: true})
+	balancer.AddServer("serving", http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.Header().Set("server", "serving")
+	}), dynamic.Server{})
+
+	for i := range 100 {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = "10.0.0." + strconv.Itoa(i) + ":1234"
+		assert.Equal(t, "serving", serve(balancer, req))
+	}
+}
+
+func TestSticky(t *testing.T) {
+	balancer, err := New(&dynamic.Sticky{
+		Cookie: &dynamic.Cookie{Name: "test"},
+	}, &dynamic.ConsistentHash{Header: "X-User"}, dynamic.BalancerStrategyMaglev, false)
+	require.NoError(t, err)
+
+	for _, name := range []string{"first", "second"} {
+		balancer.AddServer(name, http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+			rw.Header().Set("server", name)
+		}), dynamic.Server{})
+	}
+
+	recorder := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("X-User", "0")
+	balancer.ServeHTTP(recorder, req)
+	server := recorder.Header().Get("server")
+	cookies := recorder.Result().Cookies()
+	require.Len(t, cookies, 1)
+
+	// the sticky cookie takes precedence over the hash
+	for i := range 20 {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("X-User", strconv.Itoa(i))
+		req.AddCookie(cookies[0])
+		assert.Equal(t, server, serve(balancer, req))
+	}
+}
+
+func TestRetryAvoidsTriedServers(t *testing.T) {
+	for _, strategy := range []dynamic.BalancerStrategy{dynamic.BalancerStrategyRingHash, dynamic.BalancerStrategyMaglev} {
+		t.Run(string(strategy), func(t *testing.T) {
+			t.Parallel()
+
+			balancer := newTestBalancer(t, strategy, &dynamic.ConsistentHash{Header: "X-User"}, 1, 1, 1)
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set("X-User", "user")
+			first := serve(balancer, req)
+
+			req = req.WithContext(middlewares.WithTriedServers(req.Context()))
+
+			seen := make(map[string]struct{})
+			for range 3 {
+				seen[serve(balancer, req)] = struct{}{}
+			}
+			assert.Len(t, seen, 3)
+
+			// once every server has been tried, the request hashes onto its own server again
+			assert.Equal(t, first, serve(balancer, req))
+		})
+	}
+}
@@ -0,0 +1,56 @@
+package hash
+
+import (
+	"github.com/zeebo/xxh3"
+)
+
+// maglev is the lookup table of Maglev hashing (https://research.google/pubs/pub44824/):
+// each server fills the entries of its own permutation of the table in turn, so the table is evenly shared,
+// and a change of the servers set moves only a small part of the entries of the remaining ones.
+type maglev struct {
+	entries []*namedHandler
+}
+
+// newMaglev populates a table of the given prime size, each server is given a number of turns
+// per round proportional to its weight.
+func newMaglev(handlers []*namedHandler, size int) *maglev {
+	m := uint64(size)
+
+	offsets := make([]uint64, len(handlers))
+	skips := make([]uint64, len(handlers))
+	next := make([]uint64, len(handlers))
+	for i, h := range handlers {
+		offsets[i] = xxh3.HashString(h.name) % m
+		skips[i] = xxh3.HashStringSeed(h.name, 1)%(m-1) + 1
+	}
+
+	entries := make([]*namedHandler, size)
+	filled := 0
+	for filled < size {
+		for i, h := range handlers {
+			for turn := 0; turn < h.weight && filled < size; turn++ {
+				// next free entry of the server's permutation
+				entry := (offsets[i] + next[i]*skips[i]) % m
+				for entries[entry] != nil {
+					next[i]++
+					entry = (offsets[i] + next[i]*skips[i]) % m
+				}
+				entries[entry] = h
+				next[i]++
+				filled++
+			}
+		}
+	}
+
+	return &maglev{entries: entries}
+}
+
+func (t *maglev) lookup(hash uint64, skip func(h *namedHandler) bool) *namedHandler {
+	i := int(hash % uint64(len(t.entries)))
+	for n := range len(t.entries) {
+		if h := t.entries[(i+n)%len(t.entries)]; !skip(h) {
+			return h
+		}
+	}
+	return t.entries[i]
+}
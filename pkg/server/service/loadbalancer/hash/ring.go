@@ -0,0 +1,56 @@
+package hash
+
+import (
+	"cmp"
+	"slices"
+	"strconv"
+
+	"github.com/zeebo/xxh3"
+)
+
+type vnode struct {
+	hash    uint64
+	handler *namedHandler
+}
+
+// ring is a consistent hashing ring (Ketama like): each server is placed on the ring at a number of points
+// proportional to its weight, and a key belongs to the first point at or after its hash.
+// A server going away hands its keys over to the next points only.
+type ring struct {
+	vnodes []vnode
+}
+
+// newRing places about size virtual nodes on the ring, shared by the servers according to their weight.
+// totalWeight is the weight of all the servers, including the ones left out of the ring, so that the points of a
+// server do not depend on the health of the others.
+func newRing(handlers []*namedHandler, size, totalWeight int) *ring {
+	r := &ring{}
+	for _, h := range handlers {
+		count := max(1, size*h.weight/totalWeight)
+		for i := range count {
+			r.vnodes = append(r.vnodes, vnode{
+				hash:    xxh3.HashString(h.name + "-" + strconv.Itoa(i)),
+				handler: h,
+			})
+		}
+	}
+
+	slices.SortFunc(r.vnodes, func(a, b vnode) int { return cmp.Compare(a.hash, b.hash) })
+
+	return r
+}
+
+func (r *ring) lookup(hash uint64, skip func(h *namedHandler) bool) *namedHandler {
+	i, _ := slices.BinarySearchFunc(r.vnodes, hash, func(n vnode, h uint64) int { return cmp.Compare(n.hash, h) })
+	if i == len(r.vnodes) {
+		i = 0
+	}
+
+	// walk the ring clockwise, as when the skipped servers are gone.
+	for n := range len(r.vnodes) {
+		if h := r.vnodes[(i+n)%len(r.vnodes)].handler; !skip(h) {
+			return h
+		}
+	}
+	return r.vnodes[i].handler
+}
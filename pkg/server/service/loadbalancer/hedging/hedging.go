@@ -0,0 +1,372 @@
+package hedging
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	gokitmetrics "github.com/go-kit/kit/metrics"
+	"github.com/rs/zerolog/log"
+	"github.com/traefik/traefik/v3/pkg/config/dynamic"
+	"github.com/traefik/traefik/v3/pkg/middlewares"
+	"github.com/traefik/traefik/v3/pkg/middlewares/retry"
+	"golang.org/x/net/http/httpguts"
+)
+
+const (
+	// latencySamples is the number of the last observed latencies the hedging delay percentile is computed from.
+	latencySamples = 1000
+	// minLatencySamples is the number of observed latencies required to use the percentile as hedging delay.
+	minLatencySamples = 100
+	// maxBudgetTokens is the maximum number of hedges the budget accumulates.
+	maxBudgetTokens = 10
+)
+
+// safeMethods are the methods of the requests hedged by default.
+var safeMethods = []string{http.MethodGet, http.MethodHead, http.MethodOptions}
+
+var errHedgeLost = errors.New("hedged request lost")
+
+type statusUpdater interface {
+	RegisterStatusUpdater(fn func(up bool)) error
+}
+
+// Hedging sends a duplicate of the requests whose response headers are not received within the hedging delay
+// to another server of the load-balancer, and uses the first response: the other request is canceled.
+type Hedging struct {
+	next http.Handler
+
+	delay      time.Duration
+	percentile float64
+	methods    []string
+
+	// hedgesCounter and winsCounter are labelled with the service name, nil if metrics are disabled.
+	hedgesCounter gokitmetrics.Counter
+	winsCounter   gokitmetrics.Counter
+
+	budget *budget
+
+	// latenciesMu protects the latencies ring.
+	latenciesMu sync.Mutex
+	latencies   []time.Duration
+	latencyIdx  int
+	// percentileDelay is the hedging delay computed from the observed latencies, 0 until enough are observed.
+	percentileDelay atomic.Int64
+}
+
+// New creates a new hedging handler in front of the given load-balancer.
+// The counters, labelled with the service name, may be nil.
+func New(next http.Handler, config *dynamic.Hedging, hedgesCounter, winsCounter gokitmetrics.Counter) (*Hedging, error) {
+	if config.Delay <= 0 && config.Percentile <= 0 {
+		return nil, errors.New("hedging delay or percentile must be greater than zero")
+	}
+	if config.Percentile < 0 || config.Percentile > 100 {
+		return nil, fmt.Errorf("hedging percentile must be between 0 and 100: %v", config.Percentile)
+	}
+	if config.BudgetPercent < 0 || config.BudgetPercent > 100 {
+		return nil, fmt.Errorf("hedging budget percent must be between 0 and 100: %d", config.BudgetPercent)
+	}
+
+	h := &Hedging{
+		next:          next,
+		delay:         time.Duration(config.Delay),
+		percentile:    config.Percentile,
+		methods:       safeMethods,
+		hedgesCounter: hedgesCounter,
+		winsCounter:   winsCounter,
+		budget:        &budget{ratio: float64(config.BudgetPercent) / 100},
+	}
+	if len(config.Methods) > 0 {
+		h.methods = config.Methods
+	}
+	if h.percentile > 0 {
+		h.latencies = make([]time.Duration, 0, latencySamples)
+	}
+
+	return h, nil
+}
+
+func (h *Hedging) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	h.budget.deposit()
+
+	// A request with a body cannot be sent twice, nor can a connection be upgraded twice.
+	if !slices.Contains(h.methods, req.Method) || (req.Body != nil && req.Body != http.NoBody && req.ContentLength != 0) ||
+		httpguts.HeaderValuesContainsToken(req.Header["Connection"], "Upgrade") {
+		h.next.ServeHTTP(rw, req)
+		return
+	}
+
+	ctx := req.Context()
+	// The load-balancer sends the hedged request to another server.
+	if middlewares.GetTriedServers(ctx) == nil {
+		ctx = middlewares.WithTriedServers(ctx)
+	}
+
+	race := &race{
+		rw:          rw,
+		winner:      -1,
+		headers:     make(chan struct{}),
+		shouldRetry: retry.ContextShouldRetry(ctx),
+		pending:     make(map[int]bool),
+	}
+	start := time.Now()
+
+	var wg sync.WaitGroup
+	serve := func(attempt int) {
+		attemptCtx, cancel := context.WithCancelCause(ctx)
+		race.addCancel(cancel)
+		if race.shouldRetry != nil {
+			attemptCtx = retry.WithShouldRetry(attemptCtx, func(shouldRetry bool) {
+				race.setShouldRetry(attempt, shouldRetry)
+			})
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer cancel(nil)
+
+			arw := &attemptResponseWriter{race: race, attempt: attempt, header: make(http.Header)}
+			h.next.ServeHTTP(arw, req.Clone(attemptCtx))
+			// A handler not writing any response answers with a 200 OK.
+			arw.WriteHeader(http.StatusOK)
+		}()
+	}
+
+	serve(0)
+
+	timer := time.NewTimer(h.hedgingDelay())
+	select {
+	case <-race.headers:
+		timer.Stop()
+	case <-timer.C:
+		if h.budget.withdraw() {
+			log.Ctx(ctx).Debug().Msg("Sending hedged request")
+			if h.hedgesCounter != nil {
+				h.hedgesCounter.Add(1)
+			}
+			serve(1)
+		}
+	}
+
+	<-race.headers
+	if h.percentile > 0 {
+		h.observe(time.Since(start))
+	}
+	if race.winner == 1 && h.winsCounter != nil {
+		h.winsCounter.Add(1)
+	}
+
+	wg.Wait()
+}
+
+// RegisterStatusUpdater adds fn to the list of hooks that are run when the status of the load-balancer changes.
+func (h *Hedging) RegisterStatusUpdater(fn func(up bool)) error {
+	updater, ok := h.next.(statusUpdater)
+	if !ok {
+		return fmt.Errorf("load-balancer %T does not propagate its status", h.next)
+	}
+	return updater.RegisterStatusUpdater(fn)
+}
+
+// hedgingDelay returns the percentile of the observed latencies, or the configured delay until enough are observed.
+func (h *Hedging) hedgingDelay() time.Duration {
+	if delay := time.Duration(h.percentileDelay.Load()); delay > 0 {
+		return delay
+	}
+	return h.delay
+}
+
+// observe records the latency of the response headers, and updates the hedging delay every minLatencySamples.
+func (h *Hedging) observe(latency time.Duration) {
+	h.latenciesMu.Lock()
+	defer h.latenciesMu.Unlock()
+
+	if len(h.latencies) < latencySamples {
+		h.latencies = append(h.latencies, latency)
+	} else {
+		h.latencies[h.latencyIdx] = latency
+	}
+	h.latencyIdx = (h.latencyIdx + 1) % latencySamples
+
+	if len(h.latencies) < minLatencySamples || h.latencyIdx%minLatencySamples != 0 {
+		return
+	}
+
+	sorted := slices.Clone(h.latencies)
+	slices.Sort(sorted)
+	idx := int(math.Ceil(h.percentile/100*float64(len(sorted)))) - 1
+	h.percentileDelay.Store(int64(sorted[min(max(idx, 0), len(sorted)-1)]))
+}
+
+// race elects the first attempt writing its response headers, the other one is canceled.
+type race struct {
+	rw http.ResponseWriter
+
+	// shouldRetry is the ShouldRetry function of the Retry middleware, nil if there is none.
+	// Only the winning attempt notifies it, as it is not safe for concurrent use.
+	shouldRetry retry.ShouldRetry
+
+	mu      sync.Mutex
+	winner  int
+	cancels []context.CancelCauseFunc
+	// headers is closed once the winner is elected.
+	headers chan struct{}
+	// pending are the last ShouldRetry values of the attempts, until the winner is elected.
+	pending map[int]bool
+}
+
+func (r *race) addCancel(cancel context.CancelCauseFunc) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// The race is already decided.
+	if r.winner >= 0 {
+		cancel(errHedgeLost)
+	}
+	r.cancels = append(r.cancels, cancel)
+}
+
+func (r *race) setShouldRetry(attempt int, shouldRetry bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	switch r.winner {
+	case -1:
+		r.pending[attempt] = shouldRetry
+	case attempt:
+		r.shouldRetry(shouldRetry)
+	}
+}
+
+// elect returns whether the given attempt wins the race.
+func (r *race) elect(attempt int) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.winner >= 0 {
+		return r.winner == attempt
+	}
+
+	r.winner = attempt
+	for i, cancel := range r.cancels {
+		if i != attempt {
+			cancel(errHedgeLost)
+		}
+	}
+	if shouldRetry, ok := r.pending[attempt]; ok {
+		r.shouldRetry(shouldRetry)
+	}
+	close(r.headers)
+
+	return true
+}
+
+// attemptResponseWriter writes the response of the winning attempt, and discards the other one.
+type attemptResponseWriter struct {
+	race    *race
+	attempt int
+	header  http.Header
+
+	wroteHeader bool
+	won         bool
+}
+
+func (a *attemptResponseWriter) Header() http.Header {
+	if a.won {
+		return a.race.rw.Header()
+	}
+	return a.header
+}
+
+// WriteHeader elects the attempt on its first response headers, informational ones included,
+// as the server has started to answer. Only the winning attempt forwards its response.
+func (a *attemptResponseWriter) WriteHeader(code int) {
+	if a.wroteHeader {
+		return
+	}
+	// A protocol switch is the final response of the request.
+	if code >= http.StatusOK || code == http.StatusSwitchingProtocols {
+		a.wroteHeader = true
+	}
+
+	if !a.won {
+		if !a.race.elect(a.attempt) {
+			return
+		}
+		a.won = true
+
+		headers := a.race.rw.Header()
+		for k, v := range a.header {
+			headers[k] = v
+		}
+	}
+	a.race.rw.WriteHeader(code)
+}
+
+func (a *attemptResponseWriter) Write(b []byte) (int, error) {
+	if !a.wroteHeader {
+		a.WriteHeader(http.StatusOK)
+	}
+	if !a.won {
+		return 0, errHedgeLost
+	}
+	return a.race.rw.Write(b)
+}
+
+// Flush sends any buffered data to the client.
+func (a *attemptResponseWriter) Flush() {
+	if !a.won {
+		return
+	}
+	if flusher, ok := a.race.rw.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Hijack hijacks the connection of the winning attempt.
+func (a *attemptResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if !a.won {
+		return nil, nil, errHedgeLost
+	}
+	hijacker, ok := a.race.rw.(http.Hijacker)
+	if !ok {
+		return nil, nil, fmt.Errorf("%T is not a http.Hijacker", a.race.rw)
+	}
+	return hijacker.Hijack()
+}
+
+// budget limits the hedges to a ratio of the requests:
+// each request deposits ratio tokens, up to maxBudgetTokens, and each hedge withdraws one.
+type budget struct {
+	ratio float64
+
+	mu     sync.Mutex
+	tokens float64
+}
+
+func (b *budget) deposit() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.tokens = min(b.tokens+b.ratio, maxBudgetTokens)
+}
+
+func (b *budget) withdraw() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
@@ -0,0 +1,262 @@
+package hedging
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	ptypes "github.com/traefik/paerser/types"
+	"github.com/traefik/traefik/v3/pkg/config/dynamic"
+	"github.com/traefik/traefik/v3/pkg/middlewares"
+	"github.com/traefik/traefik/v3/pkg/middlewares/retry"
+	"github.com/traefik/traefik/v3/pkg/testhelpers"
+)
+
+// servers is a load-balancer sending the requests to the first server not tried yet.
+type servers struct {
+	names    []string
+	latency  map[string]time.Duration
+	canceled chan string
+}
+
+func (s *servers) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	tried := middlewares.GetTriedServers(req.Context())
+
+	name := s.names[0]
+	for _, n := range s.names {
+		if !tried.Has(n) {
+			name = n
+			break
+		}
+	}
+	tried.Add(name)
+
+	select {
+	case <-time.After(s.latency[name]):
+		rw.Header().Set("server", name)
+		rw.WriteHeader(http.StatusOK)
+		_, _ = rw.Write([]byte(name))
+	case <-req.Context().Done():
+		s.canceled <- name
+	}
+}
+
+func newServers(latencies ...time.Duration) *servers {
+	s := &servers{latency: make(map[string]time.Duration), canceled: make(chan string, len(latencies))}
+	for i, latency := range latencies {
+		name := string(rune('a' + i))
+		s.names = append(s.names, name)
+		s.latency[name] = latency
+	}
+	return s
+}
+
+func TestHedging(t *testing.T) {
+	testCases := []struct {
+		desc           string
+		config         dynamic.Hedging
+		method         string
+		latencies      []time.Duration
+		expectedServer string
+		expectedHedges float64
+		expectedWins   float64
+	}{
+		{
+			desc:           "fast response",
+			config:         dynamic.Hedging{Delay: ptypes.Duration(time.Second), BudgetPercent: 100},
+			method:         http.MethodGet,
+			latencies:      []time.Duration{0, 0},
+			expectedServer: "a",
+		},
+		{
+			desc:           "slow response is hedged",
+			config:         dynamic.Hedging{Delay: ptypes.Duration(10 * time.Millisecond), BudgetPercent: 100},
+			method:         http.MethodGet,
+			latencies:      []time.Duration{time.Minute, 0},
+			expectedServer: "b",
+			expectedHedges: 1,
+			expectedWins:   1,
+		},
+		{
+			desc:           "original response wins",
+			config:         dynamic.Hedging{Delay: ptypes.Duration(10 * time.Millisecond), BudgetPercent: 100},
+			method:         http.MethodGet,
+			latencies:      []time.Duration{50 * time.Millisecond, time.Minute},
+			expectedServer: "a",
+			expectedHedges: 1,
+		},
+		{
+			desc:           "unsafe method",
+			config:         dynamic.Hedging{Delay: ptypes.Duration(10 * time.Millisecond), BudgetPercent: 100},
+			method:         http.MethodPost,
+			latencies:      []time.Duration{50 * time.Millisecond, 0},
+			expectedServer: "a",
+		},
+		{
+			desc:           "opted-in method",
+			config:         dynamic.Hedging{Delay: ptypes.Duration(10 * time.Millisecond), BudgetPercent: 100, Methods: []string{http.MethodPost}},
+			method:         http.MethodPost,
+			latencies:      []time.Duration{time.Minute, 0},
+			expectedServer: "b",
+			expectedHedges: 1,
+			expectedWins:   1,
+		},
+		{
+			desc:           "budget exhausted",
+			config:         dynamic.Hedging{Delay: ptypes.Duration(10 * time.Millisecond), BudgetPercent: 50},
+			method:         http.MethodGet,
+			latencies:      []time.Duration{50 * time.Millisecond, 0},
+			expectedServer: "a",
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			hedges, wins := &testhelpers.CollectingCounter{}, &testhelpers.CollectingCounter{}
+			servers := newServers(test.latencies...)
+			h, err := New(servers, &test.config, hedges, wins)
+			require.NoError(t, err)
+
+			recorder := httptest.NewRecorder()
+			h.ServeHTTP(recorder, httptest.NewRequest(test.method, "/", nil))
+
+			assert.Equal(t, http.StatusOK, recorder.Code)
+			assert.Equal(t, test.expectedServer, recorder.Header().Get("server"))
+			assert.Equal(t, test.expectedServer, recorder.Body.String())
+			assert.InDelta(t, test.expectedHedges, hedges.CounterValue, 0)
+			assert.InDelta(t, test.expectedWins, wins.CounterValue, 0)
+
+			// The losing request is canceled.
+			if test.expectedHedges > 0 {
+				select {
+				case name := <-servers.canceled:
+					assert.NotEqual(t, test.expectedServer, name)
+				default:
+					t.Error("the losing request was not canceled")
+				}
+			}
+		})
+	}
+}
+
+func TestHedging_requestWithBody(t *testing.T) {
+	servers := newServers(50*time.Millisecond, 0)
+	h, err := New(servers, &dynamic.Hedging{Delay: ptypes.Duration(10 * time.Millisecond), BudgetPercent: 100}, nil, nil)
+	require.NoError(t, err)
+
+	recorder := httptest.NewRecorder()
+	h.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/", strings.NewReader("body")))
+
+	assert.Equal(t, "a", recorder.Body.String())
+}
+
+func TestHedging_upgrade(t *testing.T) {
+	servers := newServers(50*time.Millisecond, 0)
+	h, err := New(servers, &dynamic.Hedging{Delay: ptypes.Duration(10 * time.Millisecond), BudgetPercent: 100}, nil, nil)
+	require.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "websocket")
+
+	recorder := httptest.NewRecorder()
+	h.ServeHTTP(recorder, req)
+
+	assert.Equal(t, "a", recorder.Body.String())
+}
+
+func TestHedging_informationalResponses(t *testing.T) {
+	next := http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.Header().Set("Link", "</style.css>; rel=preload")
+		rw.WriteHeader(http.StatusEarlyHints)
+		rw.WriteHeader(http.StatusSwitchingProtocols)
+
+		_, _, err := rw.(http.Hijacker).Hijack()
+		assert.NoError(t, err)
+	})
+
+	h, err := New(next, &dynamic.Hedging{Delay: ptypes.Duration(time.Second), BudgetPercent: 100}, nil, nil)
+	require.NoError(t, err)
+
+	recorder := &hijackRecorder{ResponseRecorder: httptest.NewRecorder()}
+	h.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	assert.Equal(t, []int{http.StatusEarlyHints, http.StatusSwitchingProtocols}, recorder.codes)
+	assert.Equal(t, "</style.css>; rel=preload", recorder.Header().Get("Link"))
+	assert.True(t, recorder.hijacked)
+}
+
+func TestHedging_percentile(t *testing.T) {
+	h, err := New(newServers(0), &dynamic.Hedging{Delay: ptypes.Duration(time.Second), Percentile: 90}, nil, nil)
+	require.NoError(t, err)
+
+	for i := range minLatencySamples - 1 {
+		h.observe(time.Duration(i+1) * time.Millisecond)
+	}
+	assert.Equal(t, time.Second, h.hedgingDelay())
+
+	h.observe(minLatencySamples * time.Millisecond)
+	assert.Equal(t, 90*time.Millisecond, h.hedgingDelay())
+}
+
+func TestHedging_shouldRetry(t *testing.T) {
+	servers := newServers(time.Minute, 0)
+	next := http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		// The original request asks for a retry, the hedged one does not.
+		original := !middlewares.GetTriedServers(req.Context()).Has("a")
+		retry.ContextShouldRetry(req.Context())(original)
+
+		servers.ServeHTTP(rw, req)
+	})
+
+	h, err := New(next, &dynamic.Hedging{Delay: ptypes.Duration(10 * time.Millisecond), BudgetPercent: 100}, nil, nil)
+	require.NoError(t, err)
+
+	// Only the winning request notifies the retry middleware.
+	var shouldRetries []bool
+	ctx := retry.WithShouldRetry(context.Background(), func(shouldRetry bool) {
+		shouldRetries = append(shouldRetries, shouldRetry)
+	})
+	recorder := httptest.NewRecorder()
+	h.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx))
+
+	assert.Equal(t, "b", recorder.Body.String())
+	assert.Equal(t, []bool{false}, shouldRetries)
+}
+
+func TestNew_invalidConfig(t *testing.T) {
+	_, err := New(newServers(0), &dynamic.Hedging{}, nil, nil)
+	require.Error(t, err)
+
+	_, err = New(newServers(0), &dynamic.Hedging{Percentile: 101}, nil, nil)
+	require.Error(t, err)
+
+	_, err = New(newServers(0), &dynamic.Hedging{Delay: ptypes.Duration(time.Second), BudgetPercent: -1}, nil, nil)
+	require.Error(t, err)
+}
+
+// hijackRecorder records the status codes written, informational ones included, and the connection hijack.
+type hijackRecorder struct {
+	*httptest.ResponseRecorder
+
+	codes    []int
+	hijacked bool
+}
+
+func (r *hijackRecorder) WriteHeader(code int) {
+	r.codes = append(r.codes, code)
+}
+
+func (r *hijackRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	r.hijacked = true
+	return nil, nil, nil
+}
@@ -0,0 +1,252 @@
+package locality
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"sync/atomic"
+
+	"github.com/rs/zerolog/log"
+	"github.com/traefik/traefik/v3/pkg/config/dynamic"
+	"github.com/traefik/traefik/v3/pkg/middlewares"
+	"github.com/traefik/traefik/v3/pkg/server/service/loadbalancer"
+	"github.com/traefik/traefik/v3/pkg/server/service/loadbalancer/wrr"
+)
+
+// Localities of the servers, relatively to Traefik, in order of preference.
+const (
+	sameZone = iota
+	sameRegion
+	remote
+	localitiesCount
+)
+
+var errNoAvailableServer = errors.New("no available server")
+
+// tier is the weighted round-robin load-balancer of the servers of a locality.
+type tier struct {
+	*wrr.Balancer
+
+	// servers are the names of the servers of the locality, and healthy the healthy ones.
+	servers map[string]struct{}
+	healthy map[string]struct{}
+
+	inflight atomic.Int64
+}
+
+// Balancer is a locality-aware load-balancer.
+// It sends the requests to the servers in the same zone as Traefik while they have enough capacity,
+// overflowing to the servers in the same region, and then to the remaining ones.
+// Within a locality, the requests are balanced with the weighted round-robin strategy.
+type Balancer struct {
+	wantsHealthCheck bool
+
+	zone   string
+	region string
+
+	minHealthyPercent    int
+	maxInFlightPerServer int
+
+	// mu protects the tiers servers and the localities map.
+	mu    sync.RWMutex
+	tiers [localitiesCount]*tier
+	// localities are the localities of the servers, keyed by server name.
+	localities map[string]int
+
+	// updaters is the list of hooks that are run (to update the Balancer
+	// parent(s)), whenever the Balancer status changes.
+	updaters []func(bool)
+}
+
+// New creates a new locality-aware load-balancer, for a Traefik instance running in the given zone and region.
+// slowStart, which may be nil, ramps up the weight of the handlers added or becoming healthy again.
+func New(sticky *dynamic.Sticky, config *dynamic.Locality, zone, region string, wantsHealthCheck bool, slowStart *loadbalancer.SlowStart) *Balancer {
+	if config == nil {
+		config = &dynamic.Locality{}
+		config.SetDefaults()
+	}
+
+	b := &Balancer{
+		wantsHealthCheck:     wantsHealthCheck,
+		zone:                 zone,
+		region:               region,
+		minHealthyPercent:    config.MinHealthyPercent,
+		maxInFlightPerServer: config.MaxInFlightPerServer,
+		localities:           make(map[string]int),
+	}
+	for i := range b.tiers {
+		b.tiers[i] = &tier{
+			Balancer: wrr.New(sticky, false, slowStart),
+			servers:  make(map[string]struct{}),
+			healthy:  make(map[string]struct{}),
+		}
+	}
+
+	return b
+}
+
+// AddServer adds a handler with a server.
+// A server with a non-positive weight is ignored.
+func (b *Balancer) AddServer(name string, handler http.Handler, server dynamic.Server) {
+	if server.Weight != nil && *server.Weight <= 0 {
+		return
+	}
+
+	locality := b.locality(server)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.localities[name] = locality
+	// A fenced server is not sent new requests, so it does not count in the locality capacity.
+	if !server.Fenced {
+		b.tiers[locality].servers[name] = struct{}{}
+		b.tiers[locality].healthy[name] = struct{}{}
+	}
+	b.tiers[locality].AddServer(name, handler, server)
+}
+
+// locality returns the locality of the server relatively to Traefik.
+func (b *Balancer) locality(server dynamic.Server) int {
+	switch {
+	case b.zone != "" && server.Zone == b.zone:
+		return sameZone
+	case b.region != "" && server.Region == b.region:
+		return sameRegion
+	default:
+		return remote
+	}
+}
+
+// SetStatus sets on the balancer that its given child is now of the given
+// status. balancerName is only needed for logging purposes.
+func (b *Balancer) SetStatus(ctx context.Context, childName string, up bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	locality, ok := b.localities[childName]
+	if !ok {
+		return
+	}
+
+	upBefore := b.up()
+
+	t := b.tiers[locality]
+	t.SetStatus(ctx, childName, up)
+	if _, ok := t.servers[childName]; ok {
+		if up {
+			t.healthy[childName] = struct{}{}
+		} else {
+			delete(t.healthy, childName)
+		}
+	}
+
+	upAfter := b.up()
+	status := "DOWN"
+	if upAfter {
+		status = "UP"
+	}
+
+	// No Status Change
+	if upBefore == upAfter {
+		// We're still with the same status, no need to propagate
+		log.Ctx(ctx).Debug().Msgf("Still %s, no need to propagate", status)
+		return
+	}
+
+	// Status Change
+	log.Ctx(ctx).Debug().Msgf("Propagating new %s status", status)
+	for _, fn := range b.updaters {
+		fn(upAfter)
+	}
+}
+
+// up returns whether a locality has a healthy server.
+// The caller must hold the lock.
+func (b *Balancer) up() bool {
+	for _, t := range b.tiers {
+		if len(t.healthy) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// RegisterStatusUpdater adds fn to the list of hooks that are run when the
+// status of the Balancer changes.
+// Not thread safe.
+func (b *Balancer) RegisterStatusUpdater(fn func(up bool)) error {
+	if !b.wantsHealthCheck {
+		return errors.New("healthCheck not enabled in config for this locality service")
+	}
+	b.updaters = append(b.updaters, fn)
+	return nil
+}
+
+func (b *Balancer) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	t := b.nextTier(middlewares.GetTriedServers(req.Context()))
+	if t == nil {
+		http.Error(rw, errNoAvailableServer.Error(), http.StatusServiceUnavailable)
+		return
+	}
+
+	t.inflight.Add(1)
+	defer t.inflight.Add(-1)
+
+	t.ServeHTTP(rw, req)
+}
+
+// nextTier returns the first locality with enough capacity,
+// or the first one with a healthy server when none has enough capacity.
+// A retry overflows to the next locality once all the healthy servers of a locality have been tried,
+// the first locality with a healthy server is returned when all of them have been tried.
+func (b *Balancer) nextTier(tried *middlewares.TriedServers) *tier {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	var fallback, triedFallback *tier
+	for _, t := range b.tiers {
+		healthy := len(t.healthy)
+		if healthy == 0 {
+			continue
+		}
+		if triedFallback == nil {
+			triedFallback = t
+		}
+		if t.tried(tried) {
+			continue
+		}
+		if fallback == nil {
+			fallback = t
+		}
+
+		if healthy*100 < b.minHealthyPercent*len(t.servers) {
+			continue
+		}
+		if b.maxInFlightPerServer > 0 && t.inflight.Load() >= int64(b.maxInFlightPerServer*healthy) {
+			continue
+		}
+
+		return t
+	}
+
+	if fallback == nil {
+		return triedFallback
+	}
+	return fallback
+}
+
+// tried returns whether all the healthy servers of the locality have been tried.
+// The caller must hold the lock.
+func (t *tier) tried(tried *middlewares.TriedServers) bool {
+	if tried == nil {
+		return false
+	}
+	for name := range t.healthy {
+		if !tried.Has(name) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,197 @@
+package locality
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/traefik/traefik/v3/pkg/config/dynamic"
+	"github.com/traefik/traefik/v3/pkg/middlewares"
+)
+
+type server struct {
+	name   string
+	zone   string
+	region string
+	down   bool
+}
+
+func TestBalancer(t *testing.T) {
+	testCases := []struct {
+		desc            string
+		config          *dynamic.Locality
+		zone            string
+		region          string
+		servers         []server
+		inflight        [localitiesCount]int64
+		expectedServers map[string]int
+	}{
+		{
+			desc:   "same zone servers",
+			zone:   "zone-a",
+			region: "region-1",
+			servers: []server{
+				{name: "a1", zone: "zone-a", region: "region-1"},
+				{name: "a2", zone: "zone-a", region: "region-1"},
+				{name: "b1", zone: "zone-b", region: "region-1"},
+				{name: "c1", zone: "zone-c", region: "region-2"},
+			},
+			expectedServers: map[string]int{"a1": 2, "a2": 2},
+		},
+		{
+			desc:   "overflow to the same region when too few healthy servers in the zone",
+			zone:   "zone-a",
+			region: "region-1",
+			servers: []server{
+				{name: "a1", zone: "zone-a", region: "region-1"},
+				{name: "a2", zone: "zone-a", region: "region-1", down: true},
+				{name: "b1", zone: "zone-b", region: "region-1"},
+				{name: "c1", zone: "zone-c", region: "region-2"},
+			},
+			expectedServers: map[string]int{"b1": 4},
+		},
+		{
+			desc:   "overflow to remote servers",
+			zone:   "zone-a",
+			region: "region-1",
+			servers: []server{
+				{name: "a1", zone: "zone-a", region: "region-1", down: true},
+				{name: "b1", zone: "zone-b", region: "region-1", down: true},
+				{name: "c1", zone: "zone-c", region: "region-2"},
+			},
+			expectedServers: map[string]int{"c1": 4},
+		},
+		{
+			desc:   "lower healthy threshold",
+			config: &dynamic.Locality{MinHealthyPercent: 50},
+			zone:   "zone-a",
+			region: "region-1",
+			servers: []server{
+				{name: "a1", zone: "zone-a", region: "region-1"},
+				{name: "a2", zone: "zone-a", region: "region-1", down: true},
+				{name: "b1", zone: "zone-b", region: "region-1"},
+			},
+			expectedServers: map[string]int{"a1": 4},
+		},
+		{
+			desc:   "no locality with enough healthy servers",
+			zone:   "zone-a",
+			region: "region-1",
+			servers: []server{
+				{name: "a1", zone: "zone-a", region: "region-1"},
+				{name: "a2", zone: "zone-a", region: "region-1", down: true},
+				{name: "c1", zone: "zone-c", region: "region-2"},
+				{name: "c2", zone: "zone-c", region: "region-2", down: true},
+			},
+			expectedServers: map[string]int{"a1": 4},
+		},
+		{
+			desc:     "overflow on load",
+			config:   &dynamic.Locality{MinHealthyPercent: 70, MaxInFlightPerServer: 2},
+			zone:     "zone-a",
+			region:   "region-1",
+			inflight: [localitiesCount]int64{2, 0, 0},
+			servers: []server{
+				{name: "a1", zone: "zone-a", region: "region-1"},
+				{name: "b1", zone: "zone-b", region: "region-1"},
+			},
+			expectedServers: map[string]int{"b1": 4},
+		},
+		{
+			desc: "no Traefik locality",
+			servers: []server{
+				{name: "a1", zone: "zone-a", region: "region-1"},
+				{name: "b1", zone: "zone-b", region: "region-1"},
+			},
+			expectedServers: map[string]int{"a1": 2, "b1": 2},
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			balancer := New(nil, test.config, test.zone, test.region, true, nil)
+			for _, s := range test.servers {
+				balancer.AddServer(s.name, http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+					rw.Header().Set("server", s.name)
+				}), dynamic.Server{Zone: s.zone, Region: s.region})
+			}
+			for _, s := range test.servers {
+				if s.down {
+					balancer.SetStatus(context.Background(), s.name, false)
+				}
+			}
+			for i, inflight := range test.inflight {
+				balancer.tiers[i].inflight.Store(inflight)
+			}
+
+			servers := make(map[string]int)
+			for range 4 {
+				recorder := httptest.NewRecorder()
+				balancer.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/", nil))
+				servers[recorder.Header().Get("server")]++
+			}
+
+			assert.Equal(t, test.expectedServers, servers)
+		})
+	}
+}
+
+func TestBalancerRetryOverflows(t *testing.T) {
+	balancer := New(nil, nil, "zone-a", "region-1", false, nil)
+	for _, s := range []server{
+		{name: "a1", zone: "zone-a", region: "region-1"},
+		{name: "b1", zone: "zone-b", region: "region-1"},
+		{name: "c1", zone: "zone-c", region: "region-2"},
+	} {
+		balancer.AddServer(s.name, http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+			rw.Header().Set("server", s.name)
+		}), dynamic.Server{Zone: s.zone, Region: s.region})
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req = req.WithContext(middlewares.WithTriedServers(req.Context()))
+
+	var servers []string
+	for range 4 {
+		recorder := httptest.NewRecorder()
+		balancer.ServeHTTP(recorder, req)
+		servers = append(servers, recorder.Header().Get("server"))
+	}
+
+	// once every server has been tried, the closest locality is used again
+	assert.Equal(t, []string{"a1", "b1", "c1", "a1"}, servers)
+}
+
+func TestBalancerNoAvailableServer(t *testing.T) {
+	balancer := New(nil, nil, "zone-a", "", true, nil)
+	balancer.AddServer("a1", http.NotFoundHandler(), dynamic.Server{Zone: "zone-a"})
+	balancer.SetStatus(context.Background(), "a1", false)
+
+	recorder := httptest.NewRecorder()
+	balancer.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	assert.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+}
+
+func TestBalancerPropagate(t *testing.T) {
+	balancer := New(nil, nil, "zone-a", "", true, nil)
+	balancer.AddServer("a1", http.NotFoundHandler(), dynamic.Server{Zone: "zone-a"})
+	balancer.AddServer("b1", http.NotFoundHandler(), dynamic.Server{Zone: "zone-b"})
+
+	var statuses []bool
+	err := balancer.RegisterStatusUpdater(func(up bool) {
+		statuses = append(statuses, up)
+	})
+	require.NoError(t, err)
+
+	balancer.SetStatus(context.Background(), "a1", false)
+	balancer.SetStatus(context.Background(), "b1", false)
+	balancer.SetStatus(context.Background(), "b1", true)
+
+	assert.Equal(t, []bool{false, true}, statuses)
+}
@@ -0,0 +1,365 @@
+package mirror
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	gokitmetrics "github.com/go-kit/kit/metrics"
+	"github.com/rs/zerolog"
+	"github.com/traefik/traefik/v3/pkg/config/dynamic"
+)
+
+const (
+	resultMatch    = "match"
+	resultMismatch = "mismatch"
+
+	// maxDifferences is the maximum number of differences reported for a mismatch.
+	maxDifferences = 10
+	// maxValueLength is the maximum length of the JSON values reported in the differences.
+	maxValueLength = 64
+)
+
+// comparison compares the responses of the mirrors with the response of the main handler.
+type comparison struct {
+	headers       []string
+	body          bool
+	json          bool
+	ignoredFields [][]string
+	maxBodySize   int64
+	logPercent    int
+
+	counter gokitmetrics.Counter
+
+	lock       sync.Mutex
+	mismatches uint64
+	logged     uint64
+}
+
+func newComparison(config *dynamic.MirrorComparison, counter gokitmetrics.Counter) *comparison {
+	c := &comparison{
+		body:        config.Body || config.JSON,
+		json:        config.JSON,
+		maxBodySize: config.MaxBodySize,
+		logPercent:  config.LogPercent,
+		counter:     counter,
+	}
+
+	for _, header := range config.Headers {
+		c.headers = append(c.headers, http.CanonicalHeaderKey(header))
+	}
+
+	for _, field := range config.IgnoredFields {
+		c.ignoredFields = append(c.ignoredFields, strings.Split(field, "."))
+	}
+
+	return c
+}
+
+func (c *comparison) newCapturedResponse() *capturedResponse {
+	return &capturedResponse{
+		header:      http.Header{},
+		captureBody: c.body,
+		maxBodySize: c.maxBodySize,
+	}
+}
+
+// compare records the result of the comparison of the mirror response with the main one,
+// and logs the differences of a sample of the mismatches.
+func (c *comparison) compare(logger *zerolog.Logger, mirrorName string, primary, mirrored *capturedResponse) {
+	// The main response cannot be compared once its connection has been hijacked.
+	if primary.hijacked {
+		return
+	}
+
+	differences := c.differences(primary, mirrored)
+
+	result := resultMatch
+	if len(differences) > 0 {
+		result = resultMismatch
+	}
+
+	if c.counter != nil {
+		c.counter.With("mirror", mirrorName, "result", result).Add(1)
+	}
+
+	if result == resultMismatch && c.sampleMismatch() {
+		logger.Warn().Str("mirror", mirrorName).Strs("differences", differences).
+			Msg("Mirror response differs from the main service response")
+	}
+}
+
+// sampleMismatch counts a mismatch, and returns whether it should be logged.
+func (c *comparison) sampleMismatch() bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.mismatches++
+	if c.logged*100 < c.mismatches*uint64(c.logPercent) {
+		c.logged++
+		return true
+	}
+	return false
+}
+
+func (c *comparison) differences(primary, mirrored *capturedResponse) []string {
+	var differences []string
+
+	if primary.status() != mirrored.status() {
+		differences = append(differences, fmt.Sprintf("status: %d != %d", primary.status(), mirrored.status()))
+	}
+
+	for _, name := range c.headers {
+		primaryValue := strings.Join(primary.header.Values(name), ", ")
+		mirroredValue := strings.Join(mirrored.header.Values(name), ", ")
+		if primaryValue != mirroredValue {
+			differences = append(differences, fmt.Sprintf("header %s: %q != %q", name, primaryValue, mirroredValue))
+		}
+	}
+
+	// The bodies larger than the allowed size are not captured, and thus not compared.
+	if !c.body || primary.truncated || mirrored.truncated {
+		return differences
+	}
+
+	primaryBody, mirroredBody := primary.body.Bytes(), mirrored.body.Bytes()
+
+	if c.json {
+		var primaryDoc, mirroredDoc any
+		if json.Unmarshal(primaryBody, &primaryDoc) == nil && json.Unmarshal(mirroredBody, &mirroredDoc) == nil {
+			for _, field := range c.ignoredFields {
+				deleteField(primaryDoc, field)
+				deleteField(mirroredDoc, field)
+			}
+			return jsonDifferences("body", primaryDoc, mirroredDoc, differences)
+		}
+	}
+
+	if !bytes.Equal(primaryBody, mirroredBody) {
+		differences = append(differences, fmt.Sprintf("body: differs from byte %d", firstDifference(primaryBody, mirroredBody)))
+	}
+
+	return differences
+}
+
+// deleteField removes the field at the given path from the JSON document.
+// The path is applied to each element of the arrays it goes through.
+func deleteField(doc any, path []string) {
+	switch value := doc.(type) {
+	case map[string]any:
+		if len(path) == 1 {
+			delete(value, path[0])
+			return
+		}
+		deleteField(value[path[0]], path[1:])
+	case []any:
+		for _, elem := range value {
+			deleteField(elem, path)
+		}
+	}
+}
+
+// jsonDifferences appends the paths of the values that differ between the two JSON documents.
+func jsonDifferences(path string, primary, mirrored any, differences []string) []string {
+	if len(differences) >= maxDifferences {
+		return differences
+	}
+
+	switch primaryValue := primary.(type) {
+	case map[string]any:
+		mirroredValue, ok := mirrored.(map[string]any)
+		if !ok {
+			break
+		}
+
+		keys := make([]string, 0, len(primaryValue)+len(mirroredValue))
+		for key := range primaryValue {
+			keys = append(keys, key)
+		}
+		for key := range mirroredValue {
+			if _, exists := primaryValue[key]; !exists {
+				keys = append(keys, key)
+			}
+		}
+		slices.Sort(keys)
+
+		for _, key := range keys {
+			p, inPrimary := primaryValue[key]
+			m, inMirrored := mirroredValue[key]
+
+			switch {
+			case !inPrimary:
+				differences = append(differences, fmt.Sprintf("%s.%s: missing != %s", path, key, jsonValue(m)))
+			case !inMirrored:
+				differences = append(differences, fmt.Sprintf("%s.%s: %s != missing", path, key, jsonValue(p)))
+			default:
+				differences = jsonDifferences(path+"."+key, p, m, differences)
+			}
+
+			if len(differences) >= maxDifferences {
+				return differences
+			}
+		}
+		return differences
+
+	case []any:
+		mirroredValue, ok := mirrored.([]any)
+		if !ok || len(primaryValue) != len(mirroredValue) {
+			break
+		}
+
+		for i := range primaryValue {
+			differences = jsonDifferences(path+"["+strconv.Itoa(i)+"]", primaryValue[i], mirroredValue[i], differences)
+		}
+		return differences
+	}
+
+	if !reflect.DeepEqual(primary, mirrored) {
+		differences = append(differences, fmt.Sprintf("%s: %s != %s", path, jsonValue(primary), jsonValue(mirrored)))
+	}
+
+	return differences
+}
+
+// jsonValue returns the JSON representation of the value, truncated for logging.
+func jsonValue(value any) string {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprintf("%v", value)
+	}
+
+	if len(data) > maxValueLength {
+		return string(data[:maxValueLength]) + "..."
+	}
+	return string(data)
+}
+
+func firstDifference(a, b []byte) int {
+	for i := range min(len(a), len(b)) {
+		if a[i] != b[i] {
+			return i
+		}
+	}
+	return min(len(a), len(b))
+}
+
+// capturedResponse captures a response to be compared, keeping at most maxBodySize bytes of its body.
+// It is used as the response writer of the mirrors when the comparison is enabled.
+type capturedResponse struct {
+	header      http.Header
+	code        int
+	captureBody bool
+	maxBodySize int64
+	body        bytes.Buffer
+	truncated   bool
+	hijacked    bool
+}
+
+func (c *capturedResponse) Header() http.Header {
+	return c.header
+}
+
+func (c *capturedResponse) WriteHeader(code int) {
+	// Informational responses are not the final response.
+	if c.code != 0 || code < http.StatusOK {
+		return
+	}
+	c.code = code
+}
+
+func (c *capturedResponse) Write(data []byte) (int, error) {
+	c.WriteHeader(http.StatusOK)
+	c.capture(data)
+	return len(data), nil
+}
+
+func (c *capturedResponse) Flush() {}
+
+func (c *capturedResponse) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return nil, nil, errors.New("connection on capturedResponse cannot be hijacked")
+}
+
+func (c *capturedResponse) capture(data []byte) {
+	if !c.captureBody || c.truncated {
+		return
+	}
+
+	if int64(c.body.Len()+len(data)) > c.maxBodySize {
+		c.truncated = true
+		c.body = bytes.Buffer{}
+		return
+	}
+
+	c.body.Write(data)
+}
+
+func (c *capturedResponse) status() int {
+	if c.code == 0 {
+		return http.StatusOK
+	}
+	return c.code
+}
+
+// teeResponseWriter writes the main response to the client while capturing it for the comparison.
+type teeResponseWriter struct {
+	http.ResponseWriter
+
+	captured *capturedResponse
+	headers  []string
+}
+
+func (t *teeResponseWriter) WriteHeader(code int) {
+	t.captureHeader(code)
+	t.ResponseWriter.WriteHeader(code)
+}
+
+func (t *teeResponseWriter) Write(data []byte) (int, error) {
+	n, err := t.ResponseWriter.Write(data)
+	// The status code and headers are captured after the write,
+	// to get the headers set by the underlying writer, such as the sniffed Content-Type.
+	t.captureHeader(http.StatusOK)
+	t.captured.capture(data[:n])
+	return n, err
+}
+
+func (t *teeResponseWriter) captureHeader(code int) {
+	if t.captured.code != 0 || code < http.StatusOK {
+		return
+	}
+
+	t.captured.code = code
+	// Only the compared headers are captured.
+	for _, name := range t.headers {
+		if values := t.ResponseWriter.Header().Values(name); len(values) > 0 {
+			t.captured.header[name] = slices.Clone(values)
+		}
+	}
+}
+
+func (t *teeResponseWriter) Flush() {
+	if flusher, ok := t.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (t *teeResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := t.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, fmt.Errorf("%T is not a http.Hijacker", t.ResponseWriter)
+	}
+	t.captured.hijacked = true
+	return hijacker.Hijack()
+}
+
+func (t *teeResponseWriter) Unwrap() http.ResponseWriter {
+	return t.ResponseWriter
+}
@@ -11,6 +11,7 @@ import (
 	"net/http"
 	"sync"
 
+	gokitmetrics "github.com/go-kit/kit/metrics"
 	"github.com/rs/zerolog/log"
 	"github.com/traefik/traefik/v3/pkg/config/dynamic"
 	"github.com/traefik/traefik/v3/pkg/healthcheck"
@@ -29,6 +30,8 @@ type Mirroring struct {
 	maxBodySize      int64
 	wantsHealthCheck bool
 
+	comparison *comparison
+
 	lock  sync.RWMutex
 	total uint64
 }
@@ -45,6 +48,13 @@ func New(handler http.Handler, pool *safe.Pool, mirrorBody bool, maxBodySize int
 	}
 }
 
+// EnableComparison enables the comparison of the mirrors responses with the main handler ones.
+// counter, which may be nil, records the result of each comparison.
+// Not thread safe.
+func (m *Mirroring) EnableComparison(config *dynamic.MirrorComparison, counter gokitmetrics.Counter) {
+	m.comparison = newComparison(config, counter)
+}
+
 func (m *Mirroring) inc() uint64 {
 	m.lock.Lock()
 	defer m.lock.Unlock()
@@ -54,16 +64,17 @@ func (m *Mirroring) inc() uint64 {
 
 type mirrorHandler struct {
 	http.Handler
+	name    string
 	percent int
 
 	lock  sync.RWMutex
 	count uint64
 }
 
-func (m *Mirroring) getActiveMirrors() []http.Handler {
+func (m *Mirroring) getActiveMirrors() []*mirrorHandler {
 	total := m.inc()
 
-	var mirrors []http.Handler
+	var mirrors []*mirrorHandler
 	for _, handler := range m.mirrorHandlers {
 		handler.lock.Lock()
 		if handler.count*100 < total*uint64(handler.percent) {
@@ -99,6 +110,13 @@ func (m *Mirroring) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
 		return
 	}
 
+	// The main response is captured while it is written, so the comparison adds no latency to it.
+	var primary *capturedResponse
+	if m.comparison != nil {
+		primary = m.comparison.newCapturedResponse()
+		rw = &teeResponseWriter{ResponseWriter: rw, captured: primary, headers: m.comparison.headers}
+	}
+
 	m.handler.ServeHTTP(rw, rr.clone(req.Context()))
 
 	select {
@@ -126,17 +144,25 @@ func (m *Mirroring) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
 			// which would trigger a cancellation of the ongoing mirrored requests.
 			// Therefore, we give a new, non-cancellable context  to each of the mirrored calls,
 			// so they can terminate by themselves.
-			handler.ServeHTTP(m.rw, r.WithContext(contextStopPropagation{ctx}))
+			if primary == nil {
+				handler.ServeHTTP(m.rw, r.WithContext(contextStopPropagation{ctx}))
+				continue
+			}
+
+			mirrored := m.comparison.newCapturedResponse()
+			handler.ServeHTTP(mirrored, r.WithContext(contextStopPropagation{ctx}))
+			m.comparison.compare(logger, handler.name, primary, mirrored)
 		}
 	})
 }
 
 // AddMirror adds an httpHandler to mirror to.
-func (m *Mirroring) AddMirror(handler http.Handler, percent int) error {
+// The name identifies the mirror in the comparison metrics and logs.
+func (m *Mirroring) AddMirror(name string, handler http.Handler, percent int) error {
 	if percent < 0 || percent > 100 {
 		return errors.New("percent must be between 0 and 100")
 	}
-	m.mirrorHandlers = append(m.mirrorHandlers, &mirrorHandler{Handler: handler, percent: percent})
+	m.mirrorHandlers = append(m.mirrorHandlers, &mirrorHandler{Handler: handler, name: name, percent: percent})
 	return nil
 }
 
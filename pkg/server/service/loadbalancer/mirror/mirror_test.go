@@ -9,7 +9,10 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/traefik/traefik/v3/pkg/config/dynamic"
 	"github.com/traefik/traefik/v3/pkg/safe"
+	"github.com/traefik/traefik/v3/pkg/testhelpers"
 )
 
 const defaultMaxBodySize int64 = -1
@@ -21,12 +24,12 @@ func TestMirroringOn100(t *testing.T) {
 	})
 	pool := safe.NewPool(t.Context())
 	mirror := New(handler, pool, true, defaultMaxBodySize, nil)
-	err := mirror.AddMirror(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+	err := mirror.AddMirror("mirror", http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
 		atomic.AddInt32(&countMirror1, 1)
 	}), 10)
 	assert.NoError(t, err)
 
-	err = mirror.AddMirror(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+	err = mirror.AddMirror("mirror", http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
 		atomic.AddInt32(&countMirror2, 1)
 	}), 50)
 	assert.NoError(t, err)
@@ -50,12 +53,12 @@ func TestMirroringOn10(t *testing.T) {
 	})
 	pool := safe.NewPool(t.Context())
 	mirror := New(handler, pool, true, defaultMaxBodySize, nil)
-	err := mirror.AddMirror(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+	err := mirror.AddMirror("mirror", http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
 		atomic.AddInt32(&countMirror1, 1)
 	}), 10)
 	assert.NoError(t, err)
 
-	err = mirror.AddMirror(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+	err = mirror.AddMirror("mirror", http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
 		atomic.AddInt32(&countMirror2, 1)
 	}), 50)
 	assert.NoError(t, err)
@@ -74,16 +77,16 @@ func TestMirroringOn10(t *testing.T) {
 
 func TestInvalidPercent(t *testing.T) {
 	mirror := New(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {}), safe.NewPool(t.Context()), true, defaultMaxBodySize, nil)
-	err := mirror.AddMirror(nil, -1)
+	err := mirror.AddMirror("mirror", nil, -1)
 	assert.Error(t, err)
 
-	err = mirror.AddMirror(nil, 101)
+	err = mirror.AddMirror("mirror", nil, 101)
 	assert.Error(t, err)
 
-	err = mirror.AddMirror(nil, 100)
+	err = mirror.AddMirror("mirror", nil, 100)
 	assert.NoError(t, err)
 
-	err = mirror.AddMirror(nil, 0)
+	err = mirror.AddMirror("mirror", nil, 0)
 	assert.NoError(t, err)
 }
 
@@ -95,7 +98,7 @@ func TestHijack(t *testing.T) {
 	mirror := New(handler, pool, true, defaultMaxBodySize, nil)
 
 	var mirrorRequest bool
-	err := mirror.AddMirror(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+	err := mirror.AddMirror("mirror", http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
 		hijacker, ok := rw.(http.Hijacker)
 		assert.True(t, ok)
 
@@ -119,7 +122,7 @@ func TestFlush(t *testing.T) {
 	mirror := New(handler, pool, true, defaultMaxBodySize, nil)
 
 	var mirrorRequest bool
-	err := mirror.AddMirror(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+	err := mirror.AddMirror("mirror", http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
 		hijacker, ok := rw.(http.Flusher)
 		assert.True(t, ok)
 
@@ -156,7 +159,7 @@ func TestMirroringWithBody(t *testing.T) {
 	mirror := New(handler, pool, true, defaultMaxBodySize, nil)
 
 	for range numMirrors {
-		err := mirror.AddMirror(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		err := mirror.AddMirror("mirror", http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
 			assert.NotNil(t, r.Body)
 			bb, err := io.ReadAll(r.Body)
 			assert.NoError(t, err)
@@ -198,7 +201,7 @@ func TestMirroringWithIgnoredBody(t *testing.T) {
 	mirror := New(handler, pool, false, defaultMaxBodySize, nil)
 
 	for range numMirrors {
-		err := mirror.AddMirror(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		err := mirror.AddMirror("mirror", http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
 			assert.NotNil(t, r.Body)
 			bb, err := io.ReadAll(r.Body)
 			assert.NoError(t, err)
@@ -218,6 +221,167 @@ func TestMirroringWithIgnoredBody(t *testing.T) {
 	assert.Equal(t, numMirrors, int(val))
 }
 
+type response struct {
+	code    int
+	headers map[string]string
+	body    string
+}
+
+func (r response) handler() http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		for name, value := range r.headers {
+			rw.Header().Set(name, value)
+		}
+		if r.code != 0 {
+			rw.WriteHeader(r.code)
+		}
+		_, _ = rw.Write([]byte(r.body))
+	})
+}
+
+func TestMirroringComparison(t *testing.T) {
+	testCases := []struct {
+		desc           string
+		config         dynamic.MirrorComparison
+		primary        response
+		mirrored       response
+		expectedResult string
+	}{
+		{
+			desc:           "same status",
+			primary:        response{code: http.StatusOK, body: "foo"},
+			mirrored:       response{body: "bar"},
+			expectedResult: resultMatch,
+		},
+		{
+			desc:           "different status",
+			primary:        response{code: http.StatusOK},
+			mirrored:       response{code: http.StatusInternalServerError},
+			expectedResult: resultMismatch,
+		},
+		{
+			desc:           "different ignored header",
+			primary:        response{headers: map[string]string{"X-Foo": "foo"}},
+			mirrored:       response{headers: map[string]string{"X-Foo": "bar"}},
+			expectedResult: resultMatch,
+		},
+		{
+			desc:           "different compared header",
+			config:         dynamic.MirrorComparison{Headers: []string{"x-foo"}},
+			primary:        response{headers: map[string]string{"X-Foo": "foo"}},
+			mirrored:       response{headers: map[string]string{"X-Foo": "bar"}},
+			expectedResult: resultMismatch,
+		},
+		{
+			desc:           "different body",
+			config:         dynamic.MirrorComparison{Body: true},
+			primary:        response{body: "foo"},
+			mirrored:       response{body: "bar"},
+			expectedResult: resultMismatch,
+		},
+		{
+			desc:           "same JSON body with different formatting",
+			config:         dynamic.MirrorComparison{JSON: true},
+			primary:        response{body: `{"a":1,"b":[1,2]}`},
+			mirrored:       response{body: `{ "b": [1, 2], "a": 1 }`},
+			expectedResult: resultMatch,
+		},
+		{
+			desc:           "different JSON ignored field",
+			config:         dynamic.MirrorComparison{JSON: true, IgnoredFields: []string{"data.date"}},
+			primary:        response{body: `{"data":{"id":1,"date":"2024-01-01"}}`},
+			mirrored:       response{body: `{"data":{"id":1,"date":"2024-01-02"}}`},
+			expectedResult: resultMatch,
+		},
+		{
+			desc:           "different body larger than the max body size",
+			config:         dynamic.MirrorComparison{Body: true, MaxBodySize: 2},
+			primary:        response{body: "foo"},
+			mirrored:       response{body: "bar"},
+			expectedResult: resultMatch,
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			config := &dynamic.MirrorComparison{}
+			config.SetDefaults()
+			config.Headers = test.config.Headers
+			config.Body = test.config.Body
+			config.JSON = test.config.JSON
+			config.IgnoredFields = test.config.IgnoredFields
+			if test.config.MaxBodySize != 0 {
+				config.MaxBodySize = test.config.MaxBodySize
+			}
+
+			counter := &testhelpers.CollectingCounter{}
+
+			pool := safe.NewPool(t.Context())
+			mirror := New(test.primary.handler(), pool, true, defaultMaxBodySize, nil)
+			mirror.EnableComparison(config, counter)
+			err := mirror.AddMirror("mirror1", test.mirrored.handler(), 100)
+			require.NoError(t, err)
+
+			recorder := httptest.NewRecorder()
+			mirror.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/", nil))
+
+			pool.Stop()
+
+			// The main response is not altered by the comparison.
+			expected := httptest.NewRecorder()
+			test.primary.handler().ServeHTTP(expected, httptest.NewRequest(http.MethodGet, "/", nil))
+			assert.Equal(t, expected.Code, recorder.Code)
+			assert.Equal(t, expected.Header(), recorder.Header())
+			assert.Equal(t, expected.Body.String(), recorder.Body.String())
+
+			assert.InDelta(t, 1, counter.CounterValue, 0)
+			assert.Equal(t, []string{"mirror", "mirror1", "result", test.expectedResult}, counter.LastLabelValues)
+		})
+	}
+}
+
+func TestComparisonDifferences(t *testing.T) {
+	c := newComparison(&dynamic.MirrorComparison{
+		Headers:       []string{"X-Foo"},
+		JSON:          true,
+		IgnoredFields: []string{"items.date"},
+		MaxBodySize:   1024,
+	}, nil)
+
+	primary := c.newCapturedResponse()
+	primary.Header().Set("X-Foo", "foo")
+	_, _ = primary.Write([]byte(`{"id":1,"items":[{"name":"a","date":1},{"name":"b","date":2}],"removed":true}`))
+
+	mirrored := c.newCapturedResponse()
+	mirrored.WriteHeader(http.StatusNotFound)
+	_, _ = mirrored.Write([]byte(`{"id":"1","items":[{"name":"a","date":3},{"name":"c","date":4}],"added":true}`))
+
+	expected := []string{
+		`status: 200 != 404`,
+		`header X-Foo: "foo" != ""`,
+		`body.added: missing != true`,
+		`body.id: 1 != "1"`,
+		`body.items[1].name: "b" != "c"`,
+		`body.removed: true != missing`,
+	}
+	assert.Equal(t, expected, c.differences(primary, mirrored))
+}
+
+func TestComparisonLogSampling(t *testing.T) {
+	c := newComparison(&dynamic.MirrorComparison{LogPercent: 10}, nil)
+
+	var logged int
+	for range 100 {
+		if c.sampleMismatch() {
+			logged++
+		}
+	}
+
+	assert.Equal(t, 10, logged)
+}
+
 func TestCloneRequest(t *testing.T) {
 	t.Run("http request body is nil", func(t *testing.T) {
 		req, err := http.NewRequest(http.MethodPost, "/", nil)
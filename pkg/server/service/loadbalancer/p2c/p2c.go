@@ -5,12 +5,14 @@ import (
 	"errors"
 	"math/rand"
 	"net/http"
+	"slices"
 	"sync"
 	"sync/atomic"
 	"time"
 
 	"github.com/rs/zerolog/log"
 	"github.com/traefik/traefik/v3/pkg/config/dynamic"
+	"github.com/traefik/traefik/v3/pkg/middlewares"
 	"github.com/traefik/traefik/v3/pkg/server/service/loadbalancer"
 )
 
@@ -22,6 +24,8 @@ type namedHandler struct {
 	// inflight is the number of inflight requests.
 	// It is used to implement the "power-of-two-random-choices" algorithm.
 	inflight atomic.Int64
+	// started is the time the handler ramps up from, when slow start is enabled.
+	started time.Time
 }
 
 func (h *namedHandler) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
@@ -60,16 +64,21 @@ type Balancer struct {
 
 	sticky *loadbalancer.Sticky
 
+	// slowStart ramps up the weight of the handlers added or becoming healthy again, nil if disabled.
+	slowStart *loadbalancer.SlowStart
+
 	randMu sync.Mutex
 	rand   rnd
 }
 
 // New creates a new power-of-two-random-choices load balancer.
-func New(stickyConfig *dynamic.Sticky, wantsHealthCheck bool) *Balancer {
+// slowStart, which may be nil, ramps up the weight of the handlers added or becoming healthy again.
+func New(stickyConfig *dynamic.Sticky, wantsHealthCheck bool, slowStart *loadbalancer.SlowStart) *Balancer {
 	balancer := &Balancer{
 		status:           make(map[string]struct{}),
 		fenced:           make(map[string]struct{}),
 		wantsHealthCheck: wantsHealthCheck,
+		slowStart:        slowStart,
 		rand:             rand.New(rand.NewSource(time.Now().UnixNano())),
 	}
 	if stickyConfig != nil && stickyConfig.Cookie != nil {
@@ -95,6 +104,13 @@ func (b *Balancer) SetStatus(ctx context.Context, childName string, up bool) {
 	log.Ctx(ctx).Debug().Msgf("Setting status of %s to %v", childName, status)
 
 	if up {
+		if _, ok := b.status[childName]; !ok && b.slowStart != nil {
+			for _, h := range b.handlers {
+				if h.name == childName {
+					h.started = b.slowStart.Restart(childName)
+				}
+			}
+		}
 		b.status[childName] = struct{}{}
 	} else {
 		delete(b.status, childName)
@@ -133,7 +149,8 @@ func (b *Balancer) RegisterStatusUpdater(fn func(up bool)) error {
 
 var errNoAvailableServer = errors.New("no available server")
 
-func (b *Balancer) nextServer() (*namedHandler, error) {
+// nextServer returns the next handler, avoiding the tried ones when there are others available.
+func (b *Balancer) nextServer(tried *middlewares.TriedServers) (*namedHandler, error) {
 	// We kept the same representation (map) as in the WRR strategy to improve maintainability.
 	// However, with the P2C strategy, we only need a slice of healthy servers.
 	b.handlersMu.RLock()
@@ -151,6 +168,15 @@ func (b *Balancer) nextServer() (*namedHandler, error) {
 		return nil, errNoAvailableServer
 	}
 
+	if tried != nil {
+		untried := slices.DeleteFunc(slices.Clone(healthy), func(h *namedHandler) bool {
+			return tried.Has(h.name)
+		})
+		if len(untried) > 0 {
+			healthy = untried
+		}
+	}
+
 	// If there is only one healthy server, return it.
 	if len(healthy) == 1 {
 		return healthy[0], nil
@@ -168,7 +194,7 @@ func (b *Balancer) nextServer() (*namedHandler, error) {
 
 	h1, h2 := healthy[n1], healthy[n2]
 	// Ensure h1 has fewer inflight requests than h2.
-	if h2.inflight.Load() < h1.inflight.Load() {
+	if b.load(h2) < b.load(h1) {
 		log.Debug().Msgf("Service selected by P2C: %s", h2.name)
 		return h2, nil
 	}
@@ -178,6 +204,8 @@ func (b *Balancer) nextServer() (*namedHandler, error) {
 }
 
 func (b *Balancer) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	tried := middlewares.GetTriedServers(req.Context())
+
 	if b.sticky != nil {
 		h, rewrite, err := b.sticky.StickyHandler(req)
 		if err != nil {
@@ -186,7 +214,8 @@ func (b *Balancer) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
 			b.handlersMu.RLock()
 			_, ok := b.status[h.Name]
 			b.handlersMu.RUnlock()
-			if ok {
+			if ok && !tried.Has(h.Name) {
+				tried.Add(h.Name)
 				if rewrite {
 					if err := b.sticky.WriteStickyCookie(rw, h.Name); err != nil {
 						log.Error().Err(err).Msg("Writing sticky cookie")
@@ -199,7 +228,7 @@ func (b *Balancer) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
 		}
 	}
 
-	server, err := b.nextServer()
+	server, err := b.nextServer(tried)
 	if err != nil {
 		if errors.Is(err, errNoAvailableServer) {
 			http.Error(rw, errNoAvailableServer.Error(), http.StatusServiceUnavailable)
@@ -215,12 +244,31 @@ func (b *Balancer) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
 		}
 	}
 
+	tried.Add(server.name)
 	server.ServeHTTP(rw, req)
 }
 
+// load returns the number of inflight requests of the handler.
+// When slow start is enabled, it is divided by the ramp-up factor of the handler,
+// counting the request to come so that a ramping up handler is not preferred when idle.
+func (b *Balancer) load(h *namedHandler) float64 {
+	if b.slowStart == nil {
+		return float64(h.inflight.Load())
+	}
+
+	b.handlersMu.RLock()
+	started := h.started
+	b.handlersMu.RUnlock()
+
+	return float64(h.inflight.Load()+1) / b.slowStart.Factor(started)
+}
+
 // AddServer adds a handler with a server.
 func (b *Balancer) AddServer(name string, handler http.Handler, server dynamic.Server) {
 	h := &namedHandler{Handler: handler, name: name}
+	if b.slowStart != nil {
+		h.started = b.slowStart.Add(name)
+	}
 
 	b.handlersMu.Lock()
 	b.handlers = append(b.handlers, h)
@@ -5,10 +5,13 @@ import (
 	"net/http/httptest"
 	"strconv"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	ptypes "github.com/traefik/paerser/types"
 	"github.com/traefik/traefik/v3/pkg/config/dynamic"
+	"github.com/traefik/traefik/v3/pkg/server/service/loadbalancer"
 )
 
 func TestP2C(t *testing.T) {
@@ -48,7 +51,7 @@ func TestP2C(t *testing.T) {
 		t.Run(test.desc, func(t *testing.T) {
 			t.Parallel()
 
-			balancer := New(nil, false)
+			balancer := New(nil, false, nil)
 			balancer.rand = test.rand
 
 			for _, h := range test.handlers {
@@ -56,7 +59,7 @@ func TestP2C(t *testing.T) {
 				balancer.status[h.name] = struct{}{}
 			}
 
-			got, err := balancer.nextServer()
+			got, err := balancer.nextServer(nil)
 			require.NoError(t, err)
 
 			assert.Equal(t, test.expectedHandler, got.name)
@@ -74,7 +77,7 @@ func TestSticky(t *testing.T) {
 			MaxAge:   42,
 			Path:     func(v string) *string { return &v }("/foo"),
 		},
-	}, false)
+	}, false, nil)
 	balancer.rand = &mockRand{vals: []int{1, 0}}
 
 	balancer.AddServer("first", http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
@@ -117,7 +120,7 @@ func TestSticky(t *testing.T) {
 func TestSticky_Fallback(t *testing.T) {
 	balancer := New(&dynamic.Sticky{
 		Cookie: &dynamic.Cookie{Name: "test"},
-	}, false)
+	}, false, nil)
 	balancer.rand = &mockRand{vals: []int{1, 0}}
 
 	balancer.AddServer("first", http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
@@ -146,7 +149,7 @@ func TestSticky_Fallback(t *testing.T) {
 
 // TestSticky_Fenced checks that fenced node receive traffic if their sticky cookie matches.
 func TestSticky_Fenced(t *testing.T) {
-	balancer := New(&dynamic.Sticky{Cookie: &dynamic.Cookie{Name: "test"}}, false)
+	balancer := New(&dynamic.Sticky{Cookie: &dynamic.Cookie{Name: "test"}}, false, nil)
 	balancer.rand = &mockRand{vals: []int{1, 0, 1, 0}}
 
 	balancer.AddServer("first", http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
@@ -184,7 +187,7 @@ func TestSticky_Fenced(t *testing.T) {
 }
 
 func TestBalancerPropagate(t *testing.T) {
-	balancer := New(nil, true)
+	balancer := New(nil, true, nil)
 
 	balancer.AddServer("first", http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
 		rw.Header().Set("server", "first")
@@ -233,7 +236,7 @@ func TestBalancerPropagate(t *testing.T) {
 }
 
 func TestBalancerAllServersFenced(t *testing.T) {
-	balancer := New(nil, false)
+	balancer := New(nil, false, nil)
 
 	balancer.AddServer("test", http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {}), dynamic.Server{Fenced: true})
 	balancer.AddServer("test2", http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {}), dynamic.Server{Fenced: true})
@@ -285,3 +288,60 @@ func testHandlers(inflights ...int) []*namedHandler {
 	}
 	return out
 }
+
+func TestSlowStart(t *testing.T) {
+	testCases := []struct {
+		desc            string
+		inflights       []int
+		ramping         int
+		expectedHandler string
+	}{
+		{
+			desc:            "ramping up handler is not preferred when idle",
+			inflights:       []int{0, 0},
+			ramping:         1,
+			expectedHandler: "0",
+		},
+		{
+			desc:            "ramping up handler with fewer inflight requests",
+			inflights:       []int{5, 0},
+			ramping:         1,
+			expectedHandler: "0",
+		},
+		{
+			desc:            "ramping up handler with much fewer inflight requests",
+			inflights:       []int{20, 0},
+			ramping:         1,
+			expectedHandler: "1",
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			slowStart := loadbalancer.NewSlowStart(&dynamic.SlowStart{
+				Window:           ptypes.Duration(time.Hour),
+				Aggression:       1,
+				MinWeightPercent: 10,
+			}, nil)
+			balancer := New(nil, false, slowStart)
+			balancer.rand = &mockRand{vals: []int{0, 1}}
+
+			for i, h := range testHandlers(test.inflights...) {
+				if i != test.ramping {
+					h.started = time.Now().Add(-2 * time.Hour)
+				} else {
+					h.started = time.Now()
+				}
+				balancer.handlers = append(balancer.handlers, h)
+				balancer.status[h.name] = struct{}{}
+			}
+
+			got, err := balancer.nextServer(nil)
+			require.NoError(t, err)
+
+			assert.Equal(t, test.expectedHandler, got.name)
+		})
+	}
+}
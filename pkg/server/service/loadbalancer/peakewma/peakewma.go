@@ -0,0 +1,420 @@
+package peakewma
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"net"
+	"net/http"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	gokitmetrics "github.com/go-kit/kit/metrics"
+	"github.com/rs/zerolog/log"
+	"github.com/traefik/traefik/v3/pkg/config/dynamic"
+	"github.com/traefik/traefik/v3/pkg/middlewares"
+	"github.com/traefik/traefik/v3/pkg/server/service/loadbalancer"
+)
+
+const (
+	// decay is the time constant of the latency average:
+	// a sample weighs for about a third (1/e) of its initial weight after decay.
+	decay = 10 * time.Second
+	// penalty is the latency assumed for a server with in-flight requests but no latency sample yet.
+	penalty = time.Second
+)
+
+type namedHandler struct {
+	http.Handler
+
+	// name is the handler name.
+	name   string
+	weight float64
+	// inflight is the number of inflight requests.
+	inflight atomic.Int64
+	// costGauge reports the cost after each request, nil if metrics are disabled.
+	costGauge gokitmetrics.Gauge
+	// slowStart ramps up the weight of the handler, nil if disabled.
+	slowStart *loadbalancer.SlowStart
+	now       func() time.Time
+
+	// mu protects the latency average, its stamp and the start time.
+	mu sync.Mutex
+	// latency is the peak EWMA of the round-trip times in nanoseconds, 0 until the first sample.
+	latency float64
+	// stamp is the time of the last sample.
+	stamp time.Time
+	// started is the time the handler ramps up from, when slow start is enabled.
+	started time.Time
+}
+
+// ServeHTTP forwards the request to the server and accounts its time to first byte as the round-trip time,
+// so that streaming a large or long-lived response body does not count as latency.
+func (h *namedHandler) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	h.inflight.Add(1)
+	recorder := &firstByteRecorder{ResponseWriter: rw, handler: h, start: h.now()}
+	defer func() {
+		h.inflight.Add(-1)
+		// nothing was written, e.g. the connection failed: the whole round-trip is accounted.
+		recorder.observe()
+	}()
+
+	h.Handler.ServeHTTP(recorder, req)
+}
+
+// observe accounts a round-trip time: a peak is taken into account at once, whereas a lower
+// latency lowers the average according to the time elapsed since the previous sample.
+func (h *namedHandler) observe(rtt time.Duration) {
+	now := h.now()
+	sample := float64(rtt.Nanoseconds())
+
+	h.mu.Lock()
+	if sample > h.latency {
+		h.latency = sample
+	} else {
+		w := math.Exp(-float64(now.Sub(h.stamp)) / float64(decay))
+		h.latency = h.latency*w + sample*(1-w)
+	}
+	h.stamp = now
+	h.mu.Unlock()
+
+	if h.costGauge != nil {
+		h.costGauge.Set(h.cost(now) / float64(time.Second))
+	}
+}
+
+// cost returns the latency average, decayed since the last sample so that an idle server gets probed again,
+// multiplied by the in-flight requests and divided by the weight.
+// When slow start is enabled, the weight is scaled by the ramp-up factor of the handler.
+func (h *namedHandler) cost(now time.Time) float64 {
+	inflight := float64(h.inflight.Load())
+
+	h.mu.Lock()
+	latency, stamp, started := h.latency, h.stamp, h.started
+	h.mu.Unlock()
+
+	weight := h.weight
+	if h.slowStart != nil {
+		weight *= h.slowStart.Factor(started)
+	}
+
+	if latency == 0 {
+		// not measured yet: an idle server is preferred, a busy one is penalized.
+		return float64(penalty.Nanoseconds()) * inflight / weight
+	}
+
+	latency *= math.Exp(-float64(now.Sub(stamp)) / float64(decay))
+	return latency * (inflight + 1) / weight
+}
+
+// restart makes the handler, which becomes healthy again, ramp up from now.
+func (h *namedHandler) restart() {
+	if h.slowStart == nil {
+		return
+	}
+
+	h.mu.Lock()
+	h.started = h.slowStart.Restart(h.name)
+	h.mu.Unlock()
+}
+
+type rnd interface {
+	Intn(n int) int
+}
+
+// Balancer implements the least latency load balancing, based on the peak EWMA of the server latencies.
+// As with the power-of-two-random-choices algorithm, two of the available servers are randomly selected,
+// and the one with the lowest cost is chosen. The cost of a server is the exponentially weighted moving average
+// of its round-trip times, which follows the peaks at once, multiplied by its number of in-flight requests.
+// A slow yet not saturated server is therefore given less requests than a fast one.
+type Balancer struct {
+	wantsHealthCheck bool
+
+	// handlersMu is a mutex to protect the handlers slice, the status and the fenced maps.
+	handlersMu sync.RWMutex
+	handlers   []*namedHandler
+	// status is a record of which child services of the Balancer are healthy, keyed
+	// by name of child service. A service is initially added to the map when it is
+	// created via Add, and it is later removed or added to the map as needed,
+	// through the SetStatus method.
+	status map[string]struct{}
+	// fenced is the list of terminating yet still serving child services.
+	fenced map[string]struct{}
+
+	// updaters is the list of hooks that are run (to update the Balancer
+	// parent(s)), whenever the Balancer status changes.
+	updaters []func(bool)
+
+	sticky *loadbalancer.Sticky
+
+	// costGauge is the server cost gauge, labelled with the service name, nil if metrics are disabled.
+	costGauge gokitmetrics.Gauge
+	// slowStart ramps up the weight of the handlers added or becoming healthy again, nil if disabled.
+	slowStart *loadbalancer.SlowStart
+	now       func() time.Time
+
+	randMu sync.Mutex
+	rand   rnd
+}
+
+// New creates a new peak EWMA load balancer.
+// costGauge, labelled with the service name, reports the cost of each server, it may be nil.
+// slowStart, which may be nil, ramps up the weight of the handlers added or becoming healthy again.
+func New(stickyConfig *dynamic.Sticky, wantsHealthCheck bool, costGauge gokitmetrics.Gauge, slowStart *loadbalancer.SlowStart) *Balancer {
+	balancer := &Balancer{
+		status:           make(map[string]struct{}),
+		fenced:           make(map[string]struct{}),
+		wantsHealthCheck: wantsHealthCheck,
+		costGauge:        costGauge,
+		slowStart:        slowStart,
+		now:              time.Now,
+		rand:             rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+	if stickyConfig != nil && stickyConfig.Cookie != nil {
+		balancer.sticky = loadbalancer.NewSticky(*stickyConfig.Cookie)
+	}
+
+	return balancer
+}
+
+// SetStatus sets on the balancer that its given child is now of the given
+// status. balancerName is only needed for logging purposes.
+func (b *Balancer) SetStatus(ctx context.Context, childName string, up bool) {
+	b.handlersMu.Lock()
+	defer b.handlersMu.Unlock()
+
+	upBefore := len(b.status) > 0
+
+	status := "DOWN"
+	if up {
+		status = "UP"
+	}
+
+	log.Ctx(ctx).Debug().Msgf("Setting status of %s to %v", childName, status)
+
+	if up {
+		if _, ok := b.status[childName]; !ok {
+			for _, h := range b.handlers {
+				if h.name == childName {
+					h.restart()
+				}
+			}
+		}
+		b.status[childName] = struct{}{}
+	} else {
+		delete(b.status, childName)
+	}
+
+	upAfter := len(b.status) > 0
+	status = "DOWN"
+	if upAfter {
+		status = "UP"
+	}
+
+	// No Status Change
+	if upBefore == upAfter {
+		// We're still with the same status, no need to propagate
+		log.Ctx(ctx).Debug().Msgf("Still %s, no need to propagate", status)
+		return
+	}
+
+	// Status Change
+	log.Ctx(ctx).Debug().Msgf("Propagating new %s status", status)
+	for _, fn := range b.updaters {
+		fn(upAfter)
+	}
+}
+
+// RegisterStatusUpdater adds fn to the list of hooks that are run when the
+// status of the Balancer changes.
+// Not thread safe.
+func (b *Balancer) RegisterStatusUpdater(fn func(up bool)) error {
+	if !b.wantsHealthCheck {
+		return errors.New("healthCheck not enabled in config for this peak EWMA service")
+	}
+	b.updaters = append(b.updaters, fn)
+	return nil
+}
+
+var errNoAvailableServer = errors.New("no available server")
+
+// nextServer returns the next handler, avoiding the tried ones when there are others available.
+func (b *Balancer) nextServer(tried *middlewares.TriedServers) (*namedHandler, error) {
+	b.handlersMu.RLock()
+	var healthy []*namedHandler
+	for _, h := range b.handlers {
+		if _, ok := b.status[h.name]; ok {
+			if _, fenced := b.fenced[h.name]; !fenced {
+				healthy = append(healthy, h)
+			}
+		}
+	}
+	b.handlersMu.RUnlock()
+
+	if len(healthy) == 0 {
+		return nil, errNoAvailableServer
+	}
+
+	if tried != nil {
+		untried := slices.DeleteFunc(slices.Clone(healthy), func(h *namedHandler) bool {
+			return tried.Has(h.name)
+		})
+		if len(untried) > 0 {
+			healthy = untried
+		}
+	}
+
+	// If there is only one healthy server, return it.
+	if len(healthy) == 1 {
+		return healthy[0], nil
+	}
+	// In order to not get the same backend twice, we shift over the second index if it is equal to the first one.
+	b.randMu.Lock()
+	n1, n2 := b.rand.Intn(len(healthy)), b.rand.Intn(len(healthy))
+	b.randMu.Unlock()
+
+	if n2 == n1 {
+		n2 = (n2 + 1) % len(healthy)
+	}
+
+	now := b.now()
+	h1, h2 := healthy[n1], healthy[n2]
+	if h2.cost(now) < h1.cost(now) {
+		log.Debug().Msgf("Service selected by peak EWMA: %s", h2.name)
+		return h2, nil
+	}
+
+	log.Debug().Msgf("Service selected by peak EWMA: %s", h1.name)
+	return h1, nil
+}
+
+func (b *Balancer) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	tried := middlewares.GetTriedServers(req.Context())
+
+	if b.sticky != nil {
+		h, rewrite, err := b.sticky.StickyHandler(req)
+		if err != nil {
+			log.Error().Err(err).Msg("Error while getting sticky handler")
+		} else if h != nil {
+			b.handlersMu.RLock()
+			_, ok := b.status[h.Name]
+			b.handlersMu.RUnlock()
+			if ok && !tried.Has(h.Name) {
+				tried.Add(h.Name)
+				if rewrite {
+					if err := b.sticky.WriteStickyCookie(rw, h.Name); err != nil {
+						log.Error().Err(err).Msg("Writing sticky cookie")
+					}
+				}
+
+				h.ServeHTTP(rw, req)
+				return
+			}
+		}
+	}
+
+	server, err := b.nextServer(tried)
+	if err != nil {
+		if errors.Is(err, errNoAvailableServer) {
+			http.Error(rw, errNoAvailableServer.Error(), http.StatusServiceUnavailable)
+		} else {
+			http.Error(rw, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	if b.sticky != nil {
+		if err := b.sticky.WriteStickyCookie(rw, server.name); err != nil {
+			log.Error().Err(err).Msg("Error while writing sticky cookie")
+		}
+	}
+
+	tried.Add(server.name)
+	server.ServeHTTP(rw, req)
+}
+
+// AddServer adds a handler with a server.
+// A handler with a non-positive weight is ignored.
+func (b *Balancer) AddServer(name string, handler http.Handler, server dynamic.Server) {
+	weight := 1
+	if server.Weight != nil {
+		weight = *server.Weight
+	}
+	if weight <= 0 { // non-positive weight is meaningless
+		return
+	}
+
+	h := &namedHandler{Handler: handler, name: name, weight: float64(weight), slowStart: b.slowStart, now: b.now}
+	if b.slowStart != nil {
+		h.started = b.slowStart.Add(name)
+	}
+	if b.costGauge != nil {
+		h.costGauge = b.costGauge.With("url", name)
+	}
+
+	b.handlersMu.Lock()
+	b.handlers = append(b.handlers, h)
+	b.status[name] = struct{}{}
+	if server.Fenced {
+		b.fenced[name] = struct{}{}
+	}
+	b.handlersMu.Unlock()
+
+	if b.sticky != nil {
+		b.sticky.AddHandler(name, h)
+	}
+}
+
+// firstByteRecorder accounts the round-trip time of the handler on the first byte of the response.
+type firstByteRecorder struct {
+	http.ResponseWriter
+
+	handler  *namedHandler
+	start    time.Time
+	observed bool
+}
+
+// observe accounts the time elapsed since the start of the request, once.
+func (r *firstByteRecorder) observe() {
+	if r.observed {
+		return
+	}
+	r.observed = true
+	r.handler.observe(r.handler.now().Sub(r.start))
+}
+
+// WriteHeader accounts the round-trip time on the final status code, or on a protocol switch,
+// an informational response such as 100 Continue does not mean the server has answered yet.
+func (r *firstByteRecorder) WriteHeader(code int) {
+	if code >= http.StatusOK || code == http.StatusSwitchingProtocols {
+		r.observe()
+	}
+	r.ResponseWriter.WriteHeader(code)
+}
+
+func (r *firstByteRecorder) Write(b []byte) (int, error) {
+	r.observe()
+	return r.ResponseWriter.Write(b)
+}
+
+// Flush sends any buffered data to the client.
+func (r *firstByteRecorder) Flush() {
+	if flusher, ok := r.ResponseWriter.(http.Flusher); ok {
+		r.observe()
+		flusher.Flush()
+	}
+}
+
+// Hijack hijacks the connection.
+func (r *firstByteRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := r.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, fmt.Errorf("%T is not a http.Hijacker", r.ResponseWriter)
+	}
+	return hijacker.Hijack()
+}
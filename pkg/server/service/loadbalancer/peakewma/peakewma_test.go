@@ -0,0 +1,335 @@
+package peakewma
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	gokitmetrics "github.com/go-kit/kit/metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	ptypes "github.com/traefik/paerser/types"
+	"github.com/traefik/traefik/v3/pkg/config/dynamic"
+	"github.com/traefik/traefik/v3/pkg/server/service/loadbalancer"
+)
+
+func TestCost(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	h := &namedHandler{name: "test", weight: 1, now: clock.Now}
+
+	// not measured yet
+	assert.Zero(t, h.cost(clock.now))
+	h.inflight.Store(2)
+	assert.InDelta(t, 2*float64(penalty), h.cost(clock.now), 1)
+	h.inflight.Store(0)
+
+	// a peak is taken into account at once
+	h.observe(10 * time.Millisecond)
+	assert.InDelta(t, float64(10*time.Millisecond), h.cost(clock.now), 1)
+	h.observe(100 * time.Millisecond)
+	assert.InDelta(t, float64(100*time.Millisecond), h.cost(clock.now), 1)
+
+	// a lower latency lowers the average according to the time elapsed since the previous sample
+	clock.now = clock.now.Add(decay)
+	h.observe(10 * time.Millisecond)
+	assert.InDelta(t, float64(10*time.Millisecond)+float64(90*time.Millisecond)/2.718281828, h.cost(clock.now), float64(time.Microsecond))
+
+	// in-flight requests and weight
+	latency := h.cost(clock.now)
+	h.inflight.Store(3)
+	assert.InDelta(t, 4*latency, h.cost(clock.now), 1)
+	h.weight = 2
+	assert.InDelta(t, 2*latency, h.cost(clock.now), 1)
+
+	// the cost of an idle server decays
+	h.inflight.Store(0)
+	h.weight = 1
+	assert.Less(t, h.cost(clock.now.Add(10*decay)), latency/1000)
+}
+
+func TestPeakEWMA(t *testing.T) {
+	testCases := []struct {
+		desc            string
+		latencies       []time.Duration
+		inflights       []int64
+		weights         []float64
+		rand            *mockRand
+		expectedHandler string
+	}{
+		{
+			desc:            "one healthy handler",
+			latencies:       []time.Duration{time.Second},
+			inflights:       []int64{0},
+			weights:         []float64{1},
+			expectedHandler: "0",
+		},
+		{
+			desc:            "chooses lower latency",
+			latencies:       []time.Duration{100 * time.Millisecond, 10 * time.Millisecond},
+			inflights:       []int64{0, 0},
+			weights:         []float64{1, 1},
+			rand:            &mockRand{vals: []int{0, 1}},
+			expectedHandler: "1",
+		},
+		{
+			desc:            "chooses fewer in-flight requests at same latency",
+			latencies:       []time.Duration{10 * time.Millisecond, 10 * time.Millisecond},
+			inflights:       []int64{3, 1},
+			weights:         []float64{1, 1},
+			rand:            &mockRand{vals: []int{0, 1}},
+			expectedHandler: "1",
+		},
+		{
+			desc:            "slow but idle beats fast but busy",
+			latencies:       []time.Duration{30 * time.Millisecond, 10 * time.Millisecond},
+			inflights:       []int64{0, 5},
+			weights:         []float64{1, 1},
+			rand:            &mockRand{vals: []int{1, 0}},
+			expectedHandler: "0",
+		},
+		{
+			desc:            "weight lowers the cost",
+			latencies:       []time.Duration{10 * time.Millisecond, 15 * time.Millisecond},
+			inflights:       []int64{0, 0},
+			weights:         []float64{1, 2},
+			rand:            &mockRand{vals: []int{0, 1}},
+			expectedHandler: "1",
+		},
+		{
+			desc:            "unmeasured idle server is preferred",
+			latencies:       []time.Duration{time.Millisecond, 0},
+			inflights:       []int64{0, 0},
+			weights:         []float64{1, 1},
+			rand:            &mockRand{vals: []int{0, 1}},
+			expectedHandler: "1",
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			clock := &fakeClock{now: time.Unix(0, 0)}
+			balancer := New(nil, false, nil, nil)
+			balancer.now = clock.Now
+			balancer.rand = test.rand
+
+			for i, latency := range test.latencies {
+				h := &namedHandler{name: strconv.Itoa(i), weight: test.weights[i], now: clock.Now}
+				if latency > 0 {
+					h.observe(latency)
+				}
+				h.inflight.Store(test.inflights[i])
+				balancer.handlers = append(balancer.handlers, h)
+				balancer.status[h.name] = struct{}{}
+			}
+
+			got, err := balancer.nextServer(nil)
+			require.NoError(t, err)
+
+			assert.Equal(t, test.expectedHandler, got.name)
+		})
+	}
+}
+
+func TestSlowStart(t *testing.T) {
+	testCases := []struct {
+		desc            string
+		latencies       []time.Duration
+		ramping         int
+		expectedHandler string
+	}{
+		{
+			desc:            "ramping up handler is not preferred at same latency",
+			latencies:       []time.Duration{10 * time.Millisecond, 10 * time.Millisecond},
+			ramping:         1,
+			expectedHandler: "0",
+		},
+		{
+			desc:            "ramping up handler with much lower latency",
+			latencies:       []time.Duration{200 * time.Millisecond, 10 * time.Millisecond},
+			ramping:         1,
+			expectedHandler: "1",
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			slowStart := loadbalancer.NewSlowStart(&dynamic.SlowStart{
+				Window:           ptypes.Duration(time.Hour),
+				Aggression:       1,
+				MinWeightPercent: 10,
+			}, nil)
+			clock := &fakeClock{now: time.Unix(0, 0)}
+			balancer := New(nil, false, nil, slowStart)
+			balancer.now = clock.Now
+			balancer.rand = &mockRand{vals: []int{0, 1}}
+
+			for i, latency := range test.latencies {
+				h := &namedHandler{name: strconv.Itoa(i), weight: 1, slowStart: slowStart, now: clock.Now}
+				h.observe(latency)
+				if i != test.ramping {
+					h.started = time.Now().Add(-2 * time.Hour)
+				} else {
+					h.started = time.Now()
+				}
+				balancer.handlers = append(balancer.handlers, h)
+				balancer.status[h.name] = struct{}{}
+			}
+
+			got, err := balancer.nextServer(nil)
+			require.NoError(t, err)
+
+			assert.Equal(t, test.expectedHandler, got.name)
+		})
+	}
+}
+
+func TestSlowServerGetsLessRequests(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	balancer := New(nil, false, nil, nil)
+	balancer.now = clock.Now
+
+	latencies := map[string]time.Duration{"fast": 10 * time.Millisecond, "slow": 100 * time.Millisecond}
+	counts := map[string]int{}
+	for name, latency := range latencies {
+		balancer.AddServer(name, http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+			clock.Advance(latency)
+			counts[name]++
+		}), dynamic.Server{})
+	}
+
+	for range 1000 {
+		balancer.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	}
+
+	assert.Greater(t, counts["fast"], 9*counts["slow"])
+	assert.Positive(t, counts["slow"], "the slow server must be probed again once its cost has decayed")
+}
+
+func TestLatencyIsTimeToFirstByte(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	balancer := New(nil, false, nil, nil)
+	balancer.now = clock.Now
+
+	balancer.AddServer("download", http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		clock.Advance(10 * time.Millisecond)
+		rw.WriteHeader(http.StatusOK)
+		// streaming the body is not accounted
+		clock.Advance(time.Minute)
+		_, _ = rw.Write([]byte("body"))
+	}), dynamic.Server{})
+
+	balancer.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	require.Len(t, balancer.handlers, 1)
+	h := balancer.handlers[0]
+	assert.InDelta(t, float64(10*time.Millisecond), h.latency, 1)
+}
+
+func TestCostGauge(t *testing.T) {
+	gauge := &gaugeMock{values: map[string]float64{}}
+	balancer := New(nil, false, gauge, nil)
+
+	balancer.AddServer("first", http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {}), dynamic.Server{})
+	balancer.AddServer("ignored", http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {}), dynamic.Server{Weight: func(v int) *int { return &v }(0)})
+
+	balancer.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	require.Contains(t, gauge.values, "first")
+	assert.NotContains(t, gauge.values, "ignored")
+	assert.GreaterOrEqual(t, gauge.values["first"], 0.0)
+}
+
+func TestBalancerPropagate(t *testing.T) {
+	balancer := New(nil, true, nil, nil)
+
+	balancer.AddServer("first", http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.Header().Set("server", "first")
+	}), dynamic.Server{})
+	balancer.AddServer("second", http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.Header().Set("server", "second")
+	}), dynamic.Server{})
+
+	var statuses []bool
+	err := balancer.RegisterStatusUpdater(func(up bool) {
+		statuses = append(statuses, up)
+	})
+	require.NoError(t, err)
+
+	balancer.SetStatus(context.Background(), "first", false)
+	assert.Empty(t, statuses)
+
+	recorder := httptest.NewRecorder()
+	balancer.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, "second", recorder.Header().Get("server"))
+
+	balancer.SetStatus(context.Background(), "second", false)
+	assert.Equal(t, []bool{false}, statuses)
+
+	recorder = httptest.NewRecorder()
+	balancer.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+
+	balancer.SetStatus(context.Background(), "first", true)
+	assert.Equal(t, []bool{false, true}, statuses)
+}
+
+func TestBalancerAllServersFenced(t *testing.T) {
+	balancer := New(nil, false, nil, nil)
+
+	balancer.AddServer("test", http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {}), dynamic.Server{Fenced: true})
+	balancer.AddServer("test2", http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {}), dynamic.Server{Fenced: true})
+
+	recorder := httptest.NewRecorder()
+	balancer.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	assert.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+}
+
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.now = c.now.Add(d)
+}
+
+type mockRand struct {
+	vals  []int
+	calls int
+}
+
+func (m *mockRand) Intn(int) int {
+	defer func() {
+		m.calls++
+	}()
+	return m.vals[m.calls]
+}
+
+// gaugeMock records the last value set by url.
+type gaugeMock struct {
+	values map[string]float64
+	url    string
+}
+
+func (g *gaugeMock) With(labelValues ...string) gokitmetrics.Gauge {
+	return &gaugeMock{values: g.values, url: labelValues[1]}
+}
+
+func (g *gaugeMock) Set(value float64) {
+	g.values[g.url] = value
+}
+
+func (g *gaugeMock) Add(delta float64) {
+	g.values[g.url] += delta
+}
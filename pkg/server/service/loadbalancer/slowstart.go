@@ -0,0 +1,135 @@
+package loadbalancer
+
+import (
+	"maps"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/traefik/traefik/v3/pkg/config/dynamic"
+)
+
+// minSlowStartFactor is the lowest factor a server is given, so that it still gets some traffic.
+const minSlowStartFactor = 0.01
+
+// SlowStarts holds the SlowStart of the load-balancers built for a dynamic configuration, keyed by service name.
+// Load-balancers are rebuilt on each dynamic configuration reload,
+// the servers they have in common with the previous one keep ramping up from when they were added.
+type SlowStarts struct {
+	mu       sync.Mutex
+	current  map[string]*SlowStart
+	previous map[string]*SlowStart
+}
+
+// NewSlowStarts creates the SlowStarts of a new dynamic configuration,
+// which keeps the start times of the servers of the services the previous one, if any, knows.
+// Only the services of the previous configuration are kept, so that removed services are forgotten.
+func NewSlowStarts(previous *SlowStarts) *SlowStarts {
+	slowStarts := &SlowStarts{current: make(map[string]*SlowStart)}
+	if previous != nil {
+		previous.mu.Lock()
+		slowStarts.previous = previous.current
+		previous.mu.Unlock()
+	}
+
+	return slowStarts
+}
+
+// New creates the SlowStart of the load-balancer of the given service, nil if it is not configured.
+// The start times of the servers known to the load-balancer of the service in the previous configuration are kept.
+func (s *SlowStarts) New(serviceName string, config *dynamic.SlowStart) *SlowStart {
+	if s == nil {
+		return NewSlowStart(config, nil)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	slowStart := NewSlowStart(config, s.previous[serviceName])
+	if slowStart != nil {
+		s.current[serviceName] = slowStart
+	}
+
+	return slowStart
+}
+
+// SlowStart ramps up the effective weight of the servers of a load-balancer, from the time they are added or become healthy again.
+type SlowStart struct {
+	window     time.Duration
+	aggression float64
+	minFactor  float64
+
+	now func() time.Time
+
+	// startsMu protects the starts and the previous maps.
+	startsMu sync.Mutex
+	starts   map[string]time.Time
+	// previous are the start times of the servers of the previous load-balancer of the service.
+	previous map[string]time.Time
+}
+
+// NewSlowStart creates a SlowStart, nil if it is not configured.
+// The start times of the servers known to the previous SlowStart, if any, are kept.
+func NewSlowStart(config *dynamic.SlowStart, previous *SlowStart) *SlowStart {
+	if config == nil || config.Window <= 0 {
+		return nil
+	}
+
+	slowStart := &SlowStart{
+		window:     time.Duration(config.Window),
+		aggression: config.Aggression,
+		minFactor:  float64(config.MinWeightPercent) / 100,
+		now:        time.Now,
+		starts:     make(map[string]time.Time),
+	}
+	if slowStart.aggression <= 0 {
+		slowStart.aggression = 1
+	}
+	slowStart.minFactor = min(max(slowStart.minFactor, minSlowStartFactor), 1)
+
+	if previous != nil {
+		// The previous load-balancer still serves until the new one replaces it.
+		previous.startsMu.Lock()
+		slowStart.previous = maps.Clone(previous.starts)
+		previous.startsMu.Unlock()
+	}
+
+	return slowStart
+}
+
+// Add registers the server of the given name, and returns the time it ramps up from:
+// now, unless the server is known to the previous load-balancer of the service.
+func (s *SlowStart) Add(name string) time.Time {
+	s.startsMu.Lock()
+	defer s.startsMu.Unlock()
+
+	start, ok := s.previous[name]
+	if !ok {
+		start = s.now()
+	}
+	s.starts[name] = start
+
+	return start
+}
+
+// Restart returns the time the server of the given name, which becomes healthy again, ramps up from.
+func (s *SlowStart) Restart(name string) time.Time {
+	s.startsMu.Lock()
+	defer s.startsMu.Unlock()
+
+	start := s.now()
+	s.starts[name] = start
+
+	return start
+}
+
+// Factor returns the fraction of its weight a server ramping up from start is given.
+func (s *SlowStart) Factor(start time.Time) float64 {
+	elapsed := s.now().Sub(start)
+	if elapsed >= s.window {
+		return 1
+	}
+
+	factor := math.Pow(float64(elapsed)/float64(s.window), 1/s.aggression)
+	return max(factor, s.minFactor)
+}
@@ -0,0 +1,134 @@
+package loadbalancer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	ptypes "github.com/traefik/paerser/types"
+	"github.com/traefik/traefik/v3/pkg/config/dynamic"
+)
+
+func TestNewSlowStart_disabled(t *testing.T) {
+	assert.Nil(t, NewSlowStart(nil, nil))
+	assert.Nil(t, NewSlowStart(&dynamic.SlowStart{}, nil))
+}
+
+func TestSlowStart_Factor(t *testing.T) {
+	testCases := []struct {
+		desc           string
+		aggression     float64
+		minWeight      int
+		elapsed        time.Duration
+		expectedFactor float64
+	}{
+		{
+			desc:           "linear",
+			aggression:     1,
+			elapsed:        3 * time.Second,
+			expectedFactor: 0.3,
+		},
+		{
+			desc:           "minimum weight",
+			aggression:     1,
+			minWeight:      50,
+			elapsed:        3 * time.Second,
+			expectedFactor: 0.5,
+		},
+		{
+			desc:           "lowest factor",
+			aggression:     1,
+			expectedFactor: minSlowStartFactor,
+		},
+		{
+			desc:           "aggressive",
+			aggression:     2,
+			elapsed:        2500 * time.Millisecond,
+			expectedFactor: 0.5,
+		},
+		{
+			desc:           "conservative",
+			aggression:     0.5,
+			elapsed:        5 * time.Second,
+			expectedFactor: 0.25,
+		},
+		{
+			desc:           "default aggression",
+			elapsed:        5 * time.Second,
+			expectedFactor: 0.5,
+		},
+		{
+			desc:           "window elapsed",
+			aggression:     1,
+			elapsed:        time.Minute,
+			expectedFactor: 1,
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.desc, func(t *testing.T) {
+			t.Parallel()
+
+			slowStart := NewSlowStart(&dynamic.SlowStart{
+				Window:           ptypes.Duration(10 * time.Second),
+				Aggression:       test.aggression,
+				MinWeightPercent: test.minWeight,
+			}, nil)
+
+			start := time.Unix(0, 0)
+			slowStart.now = func() time.Time { return start.Add(test.elapsed) }
+
+			assert.InDelta(t, test.expectedFactor, slowStart.Factor(start), 1e-9)
+		})
+	}
+}
+
+func TestSlowStarts_reload(t *testing.T) {
+	config := &dynamic.SlowStart{}
+	config.SetDefaults()
+
+	now := time.Unix(0, 0)
+	clock := func() time.Time { return now }
+
+	first := NewSlowStarts(nil)
+	firstSlowStart := first.New("reload", config)
+	firstSlowStart.now = clock
+	assert.Equal(t, now, firstSlowStart.Add("old"))
+
+	now = now.Add(10 * time.Second)
+
+	// The servers of the previous load-balancer keep ramping up from when they were added.
+	second := NewSlowStarts(first)
+	secondSlowStart := second.New("reload", config)
+	secondSlowStart.now = clock
+	assert.Equal(t, time.Unix(0, 0), secondSlowStart.Add("old"))
+	assert.Equal(t, now, secondSlowStart.Add("new"))
+
+	// A server becoming healthy again ramps up again.
+	assert.Equal(t, now, secondSlowStart.Restart("old"))
+
+	// Only the servers of the previous load-balancer are kept.
+	now = now.Add(10 * time.Second)
+	third := NewSlowStarts(second)
+	thirdSlowStart := third.New("reload", config)
+	thirdSlowStart.now = clock
+	assert.Equal(t, time.Unix(10, 0), thirdSlowStart.Add("new"))
+	assert.Equal(t, time.Unix(10, 0), thirdSlowStart.Add("old"))
+}
+
+func TestSlowStarts_removedService(t *testing.T) {
+	config := &dynamic.SlowStart{}
+	config.SetDefaults()
+
+	first := NewSlowStarts(nil)
+	first.New("removed", config).Add("server")
+	first.New("kept", config).Add("server")
+
+	second := NewSlowStarts(first)
+	second.New("kept", config)
+
+	// The services which are no longer configured are forgotten.
+	third := NewSlowStarts(second)
+	assert.Len(t, third.previous, 1)
+	assert.Contains(t, third.previous, "kept")
+}
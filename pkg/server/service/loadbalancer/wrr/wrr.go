@@ -5,10 +5,13 @@ import (
 	"context"
 	"errors"
 	"net/http"
+	"slices"
 	"sync"
+	"time"
 
 	"github.com/rs/zerolog/log"
 	"github.com/traefik/traefik/v3/pkg/config/dynamic"
+	"github.com/traefik/traefik/v3/pkg/middlewares"
 	"github.com/traefik/traefik/v3/pkg/server/service/loadbalancer"
 )
 
@@ -17,6 +20,8 @@ type namedHandler struct {
 	name     string
 	weight   float64
 	deadline float64
+	// started is the time the handler ramps up from, when slow start is enabled.
+	started time.Time
 }
 
 // Balancer is a WeightedRoundRobin load balancer based on Earliest Deadline First (EDF).
@@ -44,15 +49,20 @@ type Balancer struct {
 
 	sticky *loadbalancer.Sticky
 
+	// slowStart ramps up the weight of the handlers added or becoming healthy again, nil if disabled.
+	slowStart *loadbalancer.SlowStart
+
 	curDeadline float64
 }
 
 // New creates a new load balancer.
-func New(sticky *dynamic.Sticky, wantsHealthCheck bool) *Balancer {
+// slowStart, which may be nil, ramps up the weight of the handlers added or becoming healthy again.
+func New(sticky *dynamic.Sticky, wantsHealthCheck bool, slowStart *loadbalancer.SlowStart) *Balancer {
 	balancer := &Balancer{
 		status:           make(map[string]struct{}),
 		fenced:           make(map[string]struct{}),
 		wantsHealthCheck: wantsHealthCheck,
+		slowStart:        slowStart,
 	}
 	if sticky != nil && sticky.Cookie != nil {
 		balancer.sticky = loadbalancer.NewSticky(*sticky.Cookie)
@@ -108,6 +118,9 @@ func (b *Balancer) SetStatus(ctx context.Context, childName string, up bool) {
 	log.Ctx(ctx).Debug().Msgf("Setting status of %s to %v", childName, status)
 
 	if up {
+		if _, ok := b.status[childName]; !ok && b.slowStart != nil {
+			b.restart(childName)
+		}
 		b.status[childName] = struct{}{}
 	} else {
 		delete(b.status, childName)
@@ -146,7 +159,8 @@ func (b *Balancer) RegisterStatusUpdater(fn func(up bool)) error {
 
 var errNoAvailableServer = errors.New("no available server")
 
-func (b *Balancer) nextServer() (*namedHandler, error) {
+// nextServer returns the next handler, avoiding the tried ones when there are others available.
+func (b *Balancer) nextServer(tried *middlewares.TriedServers) (*namedHandler, error) {
 	b.handlersMu.Lock()
 	defer b.handlersMu.Unlock()
 
@@ -154,6 +168,10 @@ func (b *Balancer) nextServer() (*namedHandler, error) {
 		return nil, errNoAvailableServer
 	}
 
+	avoidTried := tried != nil && slices.ContainsFunc(b.handlers, func(h *namedHandler) bool {
+		return b.available(h.name) && !tried.Has(h.name)
+	})
+
 	var handler *namedHandler
 	for {
 		// Pick handler with closest deadline.
@@ -161,14 +179,12 @@ func (b *Balancer) nextServer() (*namedHandler, error) {
 
 		// curDeadline should be handler's deadline so that new added entry would have a fair competition environment with the old ones.
 		b.curDeadline = handler.deadline
-		handler.deadline += 1 / handler.weight
+		handler.deadline += 1 / b.weight(handler)
 
 		heap.Push(b, handler)
-		if _, ok := b.status[handler.name]; ok {
-			if _, ok := b.fenced[handler.name]; !ok {
-				// do not select a fenced handler.
-				break
-			}
+		// do not select a fenced handler.
+		if b.available(handler.name) && (!avoidTried || !tried.Has(handler.name)) {
+			break
 		}
 	}
 
@@ -176,7 +192,19 @@ func (b *Balancer) nextServer() (*namedHandler, error) {
 	return handler, nil
 }
 
+// available returns whether the handler of the given name is healthy and not fenced.
+// The caller must hold the handlers lock.
+func (b *Balancer) available(name string) bool {
+	if _, ok := b.status[name]; !ok {
+		return false
+	}
+	_, fenced := b.fenced[name]
+	return !fenced
+}
+
 func (b *Balancer) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	tried := middlewares.GetTriedServers(req.Context())
+
 	if b.sticky != nil {
 		h, rewrite, err := b.sticky.StickyHandler(req)
 		if err != nil {
@@ -185,7 +213,8 @@ func (b *Balancer) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
 			b.handlersMu.RLock()
 			_, ok := b.status[h.Name]
 			b.handlersMu.RUnlock()
-			if ok {
+			if ok && !tried.Has(h.Name) {
+				tried.Add(h.Name)
 				if rewrite {
 					if err := b.sticky.WriteStickyCookie(rw, h.Name); err != nil {
 						log.Error().Err(err).Msg("Writing sticky cookie")
@@ -198,7 +227,7 @@ func (b *Balancer) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
 		}
 	}
 
-	server, err := b.nextServer()
+	server, err := b.nextServer(tried)
 	if err != nil {
 		if errors.Is(err, errNoAvailableServer) {
 			http.Error(rw, errNoAvailableServer.Error(), http.StatusServiceUnavailable)
@@ -214,9 +243,29 @@ func (b *Balancer) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
 		}
 	}
 
+	tried.Add(server.name)
 	server.ServeHTTP(rw, req)
 }
 
+// weight returns the effective weight of the handler, ramped up when slow start is enabled.
+func (b *Balancer) weight(h *namedHandler) float64 {
+	if b.slowStart == nil {
+		return h.weight
+	}
+	return h.weight * b.slowStart.Factor(h.started)
+}
+
+// restart ramps up again the weight of the handler of the given name.
+// The caller must hold the handlers lock.
+func (b *Balancer) restart(name string) {
+	for _, h := range b.handlers {
+		if h.name == name {
+			h.started = b.slowStart.Restart(name)
+			return
+		}
+	}
+}
+
 // AddServer adds a handler with a server.
 func (b *Balancer) AddServer(name string, handler http.Handler, server dynamic.Server) {
 	b.Add(name, handler, server.Weight, server.Fenced)
@@ -235,9 +284,12 @@ func (b *Balancer) Add(name string, handler http.Handler, weight *int, fenced bo
 	}
 
 	h := &namedHandler{Handler: handler, name: name, weight: float64(w)}
+	if b.slowStart != nil {
+		h.started = b.slowStart.Add(name)
+	}
 
 	b.handlersMu.Lock()
-	h.deadline = b.curDeadline + 1/h.weight
+	h.deadline = b.curDeadline + 1/b.weight(h)
 	heap.Push(b, h)
 	b.status[name] = struct{}{}
 	if fenced {
@@ -9,6 +9,7 @@ import (
 	"github.com/traefik/traefik/v3/pkg/metrics"
 	"github.com/traefik/traefik/v3/pkg/safe"
 	"github.com/traefik/traefik/v3/pkg/server/middleware"
+	"github.com/traefik/traefik/v3/pkg/server/service/loadbalancer"
 )
 
 // ManagerFactory a factory of service manager.
@@ -26,6 +27,11 @@ type ManagerFactory struct {
 	acmeHTTPHandler  http.Handler
 
 	routinesPool *safe.Pool
+
+	locality *static.Locality
+
+	// slowStarts are the SlowStarts of the last built manager.
+	slowStarts *loadbalancer.SlowStarts
 }
 
 // NewManagerFactory creates a new ManagerFactory.
@@ -36,6 +42,7 @@ func NewManagerFactory(staticConfiguration static.Configuration, routinesPool *s
 		transportManager: transportManager,
 		proxyBuilder:     proxyBuilder,
 		acmeHTTPHandler:  acmeHTTPHandler,
+		locality:         staticConfiguration.Locality,
 	}
 
 	if staticConfiguration.API != nil {
@@ -69,5 +76,11 @@ func (f *ManagerFactory) Build(configuration *runtime.Configuration) *Manager {
 	}
 
 	internalHandlers := NewInternalHandlers(apiHandler, f.restHandler, f.metricsHandler, f.pingHandler, f.dashboardHandler, f.acmeHTTPHandler)
-	return NewManager(configuration.Services, f.observabilityMgr, f.routinesPool, f.transportManager, f.proxyBuilder, internalHandlers)
+	manager := NewManager(configuration.Services, f.observabilityMgr, f.routinesPool, f.transportManager, f.proxyBuilder, internalHandlers)
+	manager.locality = f.locality
+
+	f.slowStarts = loadbalancer.NewSlowStarts(f.slowStarts)
+	manager.slowStarts = f.slowStarts
+
+	return manager
 }
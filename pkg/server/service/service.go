@@ -2,6 +2,7 @@ package service
 
 import (
 	"context"
+	"crypto/tls"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -13,9 +14,11 @@ import (
 	"time"
 
 	"github.com/containous/alice"
+	gokitmetrics "github.com/go-kit/kit/metrics"
 	"github.com/rs/zerolog/log"
 	"github.com/traefik/traefik/v3/pkg/config/dynamic"
 	"github.com/traefik/traefik/v3/pkg/config/runtime"
+	"github.com/traefik/traefik/v3/pkg/config/static"
 	"github.com/traefik/traefik/v3/pkg/healthcheck"
 	"github.com/traefik/traefik/v3/pkg/logs"
 	"github.com/traefik/traefik/v3/pkg/middlewares/accesslog"
@@ -28,9 +31,14 @@ import (
 	"github.com/traefik/traefik/v3/pkg/server/cookie"
 	"github.com/traefik/traefik/v3/pkg/server/middleware"
 	"github.com/traefik/traefik/v3/pkg/server/provider"
+	"github.com/traefik/traefik/v3/pkg/server/service/loadbalancer"
 	"github.com/traefik/traefik/v3/pkg/server/service/loadbalancer/failover"
+	"github.com/traefik/traefik/v3/pkg/server/service/loadbalancer/hash"
+	"github.com/traefik/traefik/v3/pkg/server/service/loadbalancer/hedging"
+	"github.com/traefik/traefik/v3/pkg/server/service/loadbalancer/locality"
 	"github.com/traefik/traefik/v3/pkg/server/service/loadbalancer/mirror"
 	"github.com/traefik/traefik/v3/pkg/server/service/loadbalancer/p2c"
+	"github.com/traefik/traefik/v3/pkg/server/service/loadbalancer/peakewma"
 	"github.com/traefik/traefik/v3/pkg/server/service/loadbalancer/wrr"
 	"google.golang.org/grpc/status"
 )
@@ -57,7 +65,13 @@ type Manager struct {
 	services       map[string]http.Handler
 	configs        map[string]*runtime.ServiceInfo
 	healthCheckers map[string]*healthcheck.ServiceHealthChecker
-	rand           *rand.Rand // For the initial shuffling of load-balancers.
+	// outlierDetectors are the passive health checkers, keyed by service name.
+	outlierDetectors map[string]*healthcheck.OutlierDetector
+	rand             *rand.Rand // For the initial shuffling of load-balancers.
+	// locality is the zone and region Traefik runs in, nil if not configured.
+	locality *static.Locality
+	// slowStarts keeps the start times of the servers across reloads, nil if not kept.
+	slowStarts *loadbalancer.SlowStarts
 }
 
 // NewManager creates a new Manager.
@@ -71,6 +85,7 @@ func NewManager(configs map[string]*runtime.ServiceInfo, observabilityMgr *middl
 		services:         make(map[string]http.Handler),
 		configs:          configs,
 		healthCheckers:   make(map[string]*healthcheck.ServiceHealthChecker),
+		outlierDetectors: make(map[string]*healthcheck.OutlierDetector),
 		rand:             rand.New(rand.NewSource(time.Now().UnixNano())),
 	}
 }
@@ -140,7 +155,7 @@ func (m *Manager) BuildHTTP(rootCtx context.Context, serviceName string) (http.H
 		}
 	case conf.Mirroring != nil:
 		var err error
-		lb, err = m.getMirrorServiceHandler(ctx, conf.Mirroring)
+		lb, err = m.getMirrorServiceHandler(ctx, serviceName, conf.Mirroring)
 		if err != nil {
 			conf.AddError(err, true)
 			return nil, err
@@ -210,7 +225,7 @@ func (m *Manager) getFailoverServiceHandler(ctx context.Context, serviceName str
 	return f, nil
 }
 
-func (m *Manager) getMirrorServiceHandler(ctx context.Context, config *dynamic.Mirroring) (http.Handler, error) {
+func (m *Manager) getMirrorServiceHandler(ctx context.Context, serviceName string, config *dynamic.Mirroring) (http.Handler, error) {
 	serviceHandler, err := m.BuildHTTP(ctx, config.Service)
 	if err != nil {
 		return nil, err
@@ -226,13 +241,21 @@ func (m *Manager) getMirrorServiceHandler(ctx context.Context, config *dynamic.M
 		maxBodySize = *config.MaxBodySize
 	}
 	handler := mirror.New(serviceHandler, m.routinePool, mirrorBody, maxBodySize, config.HealthCheck)
+	if config.Comparison != nil {
+		var comparisonsCounter gokitmetrics.Counter
+		if m.observabilityMgr.MetricsRegistry() != nil && m.observabilityMgr.MetricsRegistry().IsSvcEnabled() {
+			comparisonsCounter = m.observabilityMgr.MetricsRegistry().ServiceMirrorComparisonsCounter().With("service", serviceName)
+		}
+		handler.EnableComparison(config.Comparison, comparisonsCounter)
+	}
+
 	for _, mirrorConfig := range config.Mirrors {
 		mirrorHandler, err := m.BuildHTTP(ctx, mirrorConfig.Name)
 		if err != nil {
 			return nil, err
 		}
 
-		err = handler.AddMirror(mirrorHandler, mirrorConfig.Percent)
+		err = handler.AddMirror(mirrorConfig.Name, mirrorHandler, mirrorConfig.Percent)
 		if err != nil {
 			return nil, err
 		}
@@ -246,7 +269,7 @@ func (m *Manager) getWRRServiceHandler(ctx context.Context, serviceName string,
 		config.Sticky.Cookie.Name = cookie.GetName(config.Sticky.Cookie.Name, serviceName)
 	}
 
-	balancer := wrr.New(config.Sticky, config.HealthCheck != nil)
+	balancer := wrr.New(config.Sticky, config.HealthCheck != nil, nil)
 	for _, service := range shuffle(config.Services, m.rand) {
 		serviceHandler, err := m.getServiceHandler(ctx, service)
 		if err != nil {
@@ -344,13 +367,41 @@ func (m *Manager) getLoadBalancerServiceHandler(ctx context.Context, serviceName
 	// Here we are handling the empty value to comply with providers that are not applying defaults (e.g. REST provider)
 	// TODO: remove this when all providers apply default values.
 	case dynamic.BalancerStrategyWRR, "":
-		lb = wrr.New(service.Sticky, service.HealthCheck != nil)
+		lb = wrr.New(service.Sticky, service.HealthCheck != nil, m.slowStarts.New(serviceName, service.SlowStart))
 	case dynamic.BalancerStrategyP2C:
-		lb = p2c.New(service.Sticky, service.HealthCheck != nil)
+		lb = p2c.New(service.Sticky, service.HealthCheck != nil, m.slowStarts.New(serviceName, service.SlowStart))
+	case dynamic.BalancerStrategyPeakEWMA:
+		var costGauge gokitmetrics.Gauge
+		if m.observabilityMgr.MetricsRegistry() != nil && m.observabilityMgr.MetricsRegistry().IsSvcEnabled() {
+			costGauge = m.observabilityMgr.MetricsRegistry().ServiceServerCostGauge().With("service", serviceName)
+		}
+		lb = peakewma.New(service.Sticky, service.HealthCheck != nil, costGauge, m.slowStarts.New(serviceName, service.SlowStart))
+	case dynamic.BalancerStrategyLocality:
+		var zone, region string
+		if m.locality != nil {
+			zone, region = m.locality.Zone, m.locality.Region
+		}
+		lb = locality.New(service.Sticky, service.Locality, zone, region, service.HealthCheck != nil, m.slowStarts.New(serviceName, service.SlowStart))
+	case dynamic.BalancerStrategyRingHash, dynamic.BalancerStrategyMaglev:
+		if service.SlowStart != nil {
+			return nil, fmt.Errorf("slow start is not supported by the %q load-balancer strategy", service.Strategy)
+		}
+
+		var err error
+		lb, err = hash.New(service.Sticky, service.ConsistentHash, service.Strategy, service.HealthCheck != nil)
+		if err != nil {
+			return nil, fmt.Errorf("creating consistent hashing load-balancer: %w", err)
+		}
 	default:
 		return nil, fmt.Errorf("unsupported load-balancer strategy %q", service.Strategy)
 	}
 
+	var outlierDetector *healthcheck.OutlierDetector
+	if service.OutlierDetection != nil {
+		outlierDetector = healthcheck.NewOutlierDetector(ctx, m.observabilityMgr.MetricsRegistry(), service.OutlierDetection, lb, info, serviceName)
+		m.outlierDetectors[serviceName] = outlierDetector
+	}
+
 	healthCheckTargets := make(map[string]*url.URL)
 
 	for i, server := range shuffle(service.Servers, m.rand) {
@@ -385,6 +436,7 @@ func (m *Manager) getLoadBalancerServiceHandler(ctx context.Context, serviceName
 		if m.observabilityMgr.MetricsRegistry() != nil && m.observabilityMgr.MetricsRegistry().IsSvcEnabled() &&
 			m.observabilityMgr.ShouldAddMetrics(qualifiedSvcName, nil) {
 			metricsHandler := metricsMiddle.WrapServiceHandler(ctx, m.observabilityMgr.MetricsRegistry(), serviceName)
+			proxy = retry.NotifyHandler(proxy, metricsMiddle.NewRetryListener(m.observabilityMgr.MetricsRegistry(), serviceName))
 
 			proxy, err = alice.New().
 				Append(observability.WrapMiddleware(ctx, metricsHandler)).
@@ -409,6 +461,10 @@ func (m *Manager) getLoadBalancerServiceHandler(ctx context.Context, serviceName
 			proxy, _ = capture.Wrap(proxy)
 		}
 
+		if outlierDetector != nil {
+			proxy = outlierDetector.Wrap(server.URL, target, proxy)
+		}
+
 		lb.AddServer(server.URL, proxy, server)
 
 		// servers are considered UP by default.
@@ -423,16 +479,49 @@ func (m *Manager) getLoadBalancerServiceHandler(ctx context.Context, serviceName
 			return nil, fmt.Errorf("getting RoundTripper: %w", err)
 		}
 
-		m.healthCheckers[serviceName] = healthcheck.NewServiceHealthChecker(
+		// The outlier detector stands between the health checker and the load-balancer.
+		var statusSetter healthcheck.StatusSetter = lb
+		if outlierDetector != nil {
+			statusSetter = outlierDetector
+		}
+
+		var tlsConfig *tls.Config
+		if service.HealthCheck.Mode == healthcheck.ModeTLS {
+			tlsConfig, err = m.transportManager.GetTLSConfig(service.ServersTransport)
+			if err != nil {
+				return nil, fmt.Errorf("getting TLS config: %w", err)
+			}
+		}
+
+		healthChecker, err := healthcheck.NewServiceHealthChecker(
 			ctx,
 			m.observabilityMgr.MetricsRegistry(),
 			service.HealthCheck,
-			lb,
+			statusSetter,
 			info,
 			roundTripper,
+			tlsConfig,
 			healthCheckTargets,
 			serviceName,
 		)
+		if err != nil {
+			return nil, fmt.Errorf("creating health checker: %w", err)
+		}
+		m.healthCheckers[serviceName] = healthChecker
+	}
+
+	if service.Hedging != nil {
+		var hedgesCounter, winsCounter gokitmetrics.Counter
+		if m.observabilityMgr.MetricsRegistry() != nil && m.observabilityMgr.MetricsRegistry().IsSvcEnabled() {
+			hedgesCounter = m.observabilityMgr.MetricsRegistry().ServiceHedgesCounter().With("service", serviceName)
+			winsCounter = m.observabilityMgr.MetricsRegistry().ServiceHedgeWinsCounter().With("service", serviceName)
+		}
+
+		hedged, err := hedging.New(lb, service.Hedging, hedgesCounter, winsCounter)
+		if err != nil {
+			return nil, fmt.Errorf("creating hedging: %w", err)
+		}
+		return hedged, nil
 	}
 
 	return lb, nil
@@ -444,6 +533,11 @@ func (m *Manager) LaunchHealthCheck(ctx context.Context) {
 		logger := log.Ctx(ctx).With().Str(logs.ServiceName, serviceName).Logger()
 		go hc.Launch(logger.WithContext(ctx))
 	}
+
+	for serviceName, od := range m.outlierDetectors {
+		logger := log.Ctx(ctx).With().Str(logs.ServiceName, serviceName).Logger()
+		go od.Launch(logger.WithContext(ctx))
+	}
 }
 
 func shuffle[T any](values []T, r *rand.Rand) []T {
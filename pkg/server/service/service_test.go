@@ -67,6 +67,16 @@ func TestGetLoadBalancer(t *testing.T) {
 			fwd:         &forwarderMock{},
 			expectError: false,
 		},
+		{
+			desc:        "Fails when slowStart is set with a consistent hashing strategy",
+			serviceName: "test",
+			service: &dynamic.ServersLoadBalancer{
+				Strategy:  dynamic.BalancerStrategyMaglev,
+				SlowStart: &dynamic.SlowStart{},
+			},
+			fwd:         &forwarderMock{},
+			expectError: true,
+		},
 	}
 
 	for _, test := range testCases {
@@ -12,6 +12,7 @@ import (
 	"github.com/traefik/traefik/v3/pkg/config/runtime"
 	"github.com/traefik/traefik/v3/pkg/logs"
 	"github.com/traefik/traefik/v3/pkg/server/provider"
+	"github.com/traefik/traefik/v3/pkg/server/service/loadbalancer"
 	"github.com/traefik/traefik/v3/pkg/tcp"
 	"golang.org/x/net/proxy"
 )
@@ -21,14 +22,17 @@ type Manager struct {
 	dialerManager *tcp.DialerManager
 	configs       map[string]*runtime.TCPServiceInfo
 	rand          *rand.Rand // For the initial shuffling of load-balancers.
+	slowStarts    *loadbalancer.SlowStarts
 }
 
 // NewManager creates a new manager.
-func NewManager(conf *runtime.Configuration, dialerManager *tcp.DialerManager) *Manager {
+// The slowStarts keep the start times of the servers across reloads, they can be nil.
+func NewManager(conf *runtime.Configuration, dialerManager *tcp.DialerManager, slowStarts *loadbalancer.SlowStarts) *Manager {
 	return &Manager{
 		dialerManager: dialerManager,
 		configs:       conf.TCPServices,
 		rand:          rand.New(rand.NewSource(time.Now().UnixNano())),
+		slowStarts:    slowStarts,
 	}
 }
 
@@ -53,6 +57,9 @@ func (m *Manager) BuildTCP(rootCtx context.Context, serviceName string) (tcp.Han
 	switch {
 	case conf.LoadBalancer != nil:
 		loadBalancer := tcp.NewWRRLoadBalancer()
+		if slowStart := m.slowStarts.New(serviceQualifiedName, conf.LoadBalancer.SlowStart); slowStart != nil {
+			loadBalancer.SetSlowStart(slowStart)
+		}
 
 		if conf.LoadBalancer.TerminationDelay != nil {
 			log.Ctx(ctx).Warn().Msgf("Service %q load balancer uses `TerminationDelay`, but this option is deprecated, please use ServersTransport configuration instead.", serviceName)
@@ -91,7 +98,7 @@ func (m *Manager) BuildTCP(rootCtx context.Context, serviceName string) (tcp.Han
 				continue
 			}
 
-			loadBalancer.AddServer(handler)
+			loadBalancer.AddNamedServer(server.Address, handler)
 			logger.Debug().Msg("Creating TCP server")
 		}
 
@@ -246,7 +246,7 @@ func TestManager_BuildTCP(t *testing.T) {
 
 			manager := NewManager(&runtime.Configuration{
 				TCPServices: test.configs,
-			}, dialerManager)
+			}, dialerManager, nil)
 
 			ctx := t.Context()
 			if len(test.providerName) > 0 {
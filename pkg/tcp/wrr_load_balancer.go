@@ -2,7 +2,9 @@ package tcp
 
 import (
 	"errors"
+	"math"
 	"sync"
+	"time"
 
 	"github.com/rs/zerolog/log"
 )
@@ -12,6 +14,16 @@ var errNoServersInPool = errors.New("no servers in the pool")
 type server struct {
 	Handler
 	weight int
+	// started is the time the server ramps up from, when slow start is enabled.
+	started time.Time
+}
+
+// SlowStart ramps up the weight of the servers from the time they are added.
+type SlowStart interface {
+	// Add registers the server of the given name, and returns the time it ramps up from.
+	Add(name string) time.Time
+	// Factor returns the fraction of its weight a server ramping up from start is given.
+	Factor(start time.Time) float64
 }
 
 // WRRLoadBalancer is a naive RoundRobin load balancer for TCP services.
@@ -20,6 +32,7 @@ type WRRLoadBalancer struct {
 	lock          sync.Mutex
 	currentWeight int
 	index         int
+	slowStart     SlowStart
 }
 
 // NewWRRLoadBalancer creates a new WRRLoadBalancer.
@@ -29,6 +42,14 @@ func NewWRRLoadBalancer() *WRRLoadBalancer {
 	}
 }
 
+// SetSlowStart enables the ramp up of the weight of the servers added with AddNamedServer.
+func (b *WRRLoadBalancer) SetSlowStart(slowStart SlowStart) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	b.slowStart = slowStart
+}
+
 // ServeTCP forwards the connection to the right service.
 func (b *WRRLoadBalancer) ServeTCP(conn WriteCloser) {
 	b.lock.Lock()
@@ -64,11 +85,47 @@ func (b *WRRLoadBalancer) AddWeightServer(serverHandler Handler, weight *int) {
 	b.servers = append(b.servers, server{Handler: serverHandler, weight: w})
 }
 
+// AddNamedServer appends a server to the existing list,
+// its weight is ramped up from the time it is added when slow start is enabled.
+func (b *WRRLoadBalancer) AddNamedServer(name string, serverHandler Handler) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	srv := server{Handler: serverHandler, weight: 1}
+	if b.slowStart != nil {
+		srv.started = b.slowStart.Add(name)
+	}
+	b.servers = append(b.servers, srv)
+}
+
+// slowStartScale is the scale of the effective weights when slow start is enabled,
+// so that a ramping up server is given a fraction of its weight.
+const slowStartScale = 100
+
+// effectiveWeight returns the weight of the server, ramped up when slow start is enabled.
+func (b *WRRLoadBalancer) effectiveWeight(s server) int {
+	if b.slowStart == nil {
+		return s.weight
+	}
+
+	// Servers added without a name are not ramped up.
+	factor := 1.0
+	if !s.started.IsZero() {
+		factor = b.slowStart.Factor(s.started)
+	}
+
+	w := int(math.Round(float64(s.weight*slowStartScale) * factor))
+	if s.weight > 0 {
+		w = max(w, 1)
+	}
+	return w
+}
+
 func (b *WRRLoadBalancer) maxWeight() int {
 	maximum := -1
 	for _, s := range b.servers {
-		if s.weight > maximum {
-			maximum = s.weight
+		if w := b.effectiveWeight(s); w > maximum {
+			maximum = w
 		}
 	}
 	return maximum
@@ -78,9 +135,9 @@ func (b *WRRLoadBalancer) weightGcd() int {
 	divisor := -1
 	for _, s := range b.servers {
 		if divisor == -1 {
-			divisor = s.weight
+			divisor = b.effectiveWeight(s)
 		} else {
-			divisor = gcd(divisor, s.weight)
+			divisor = gcd(divisor, b.effectiveWeight(s))
 		}
 	}
 	return divisor
@@ -120,7 +177,7 @@ func (b *WRRLoadBalancer) next() (Handler, error) {
 			}
 		}
 		srv := b.servers[b.index]
-		if srv.weight >= b.currentWeight {
+		if b.effectiveWeight(srv) >= b.currentWeight {
 			return srv, nil
 		}
 	}
@@ -142,3 +142,48 @@ func TestLoadBalancing(t *testing.T) {
 		})
 	}
 }
+
+type fakeSlowStart struct {
+	starts  map[string]time.Time
+	factors map[time.Time]float64
+}
+
+func (f *fakeSlowStart) Add(name string) time.Time {
+	return f.starts[name]
+}
+
+func (f *fakeSlowStart) Factor(start time.Time) float64 {
+	return f.factors[start]
+}
+
+func TestLoadBalancing_slowStart(t *testing.T) {
+	ramping, ramped := time.Unix(1, 0), time.Unix(2, 0)
+	slowStart := &fakeSlowStart{
+		starts:  map[string]time.Time{"h1": ramped, "h2": ramping},
+		factors: map[time.Time]float64{ramped: 1, ramping: 0.25},
+	}
+
+	balancer := NewWRRLoadBalancer()
+	balancer.SetSlowStart(slowStart)
+	for _, server := range []string{"h1", "h2"} {
+		balancer.AddNamedServer(server, HandlerFunc(func(conn WriteCloser) {
+			_, err := conn.Write([]byte(server))
+			require.NoError(t, err)
+		}))
+	}
+
+	conn := &fakeConn{writeCall: make(map[string]int)}
+	for range 10 {
+		balancer.ServeTCP(conn)
+	}
+	assert.Equal(t, map[string]int{"h1": 8, "h2": 2}, conn.writeCall)
+
+	// Ramped up.
+	slowStart.factors[ramping] = 1
+
+	conn = &fakeConn{writeCall: make(map[string]int)}
+	for range 10 {
+		balancer.ServeTCP(conn)
+	}
+	assert.Equal(t, map[string]int{"h1": 5, "h2": 5}, conn.writeCall)
+}